@@ -130,6 +130,10 @@ func makeJweDecryptCmd() *cli.Command {
 		keyFlag("decrypt"),
 		keyFormatFlag(),
 		keyEncryptionFlag(false),
+		&cli.BoolFlag{
+			Name:  "allow-rsa1_5",
+			Usage: "Allow decryption using RSA1_5",
+		},
 		outputFlag(),
 	}
 	cmd.Action = func(c *cli.Context) error {
@@ -163,7 +167,7 @@ func makeJweDecryptCmd() *cli.Command {
 
 			// if we have an explicit key encryption algorithm, we don't have to
 			// guess it.
-			v, err := jwe.Decrypt(buf, jwe.WithKey(keyenc, key))
+			v, err := jwe.Decrypt(buf, jwe.WithKey(keyenc, key), jwe.WithAllowRSA1_5(c.Bool("allow-rsa1_5")))
 			if err != nil {
 				return fmt.Errorf(`failed to decrypt message: %w`, err)
 			}
@@ -172,7 +176,7 @@ func makeJweDecryptCmd() *cli.Command {
 			v, err := jwe.Decrypt(buf, jwe.WithKeyProvider(jwe.KeyProviderFunc(func(_ context.Context, sink jwe.KeySink, r jwe.Recipient, _ *jwe.Message) error {
 				sink.Key(r.Headers().Algorithm(), key)
 				return nil
-			})))
+			})), jwe.WithAllowRSA1_5(c.Bool("allow-rsa1_5")))
 			if err != nil {
 				return fmt.Errorf(`failed to decrypt message: %w`, err)
 			}
@@ -10,9 +10,13 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/sjwl/jwx/v2/ed448"
+	"github.com/sjwl/jwx/v2/internal/mldsa"
+	"github.com/sjwl/jwx/v2/internal/mlkem"
 	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/sjwl/jwx/v2/jwk"
 	"github.com/sjwl/jwx/v2/x25519"
+	"github.com/sjwl/jwx/v2/x448"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/crypto/ed25519"
 )
@@ -103,7 +107,7 @@ func makeJwkGenerateCmd() *cli.Command {
 		&cli.StringFlag{
 			Name:     "type",
 			Aliases:  []string{"t"},
-			Usage:    "JWK type `TYPE` (RSA/EC/OKP/oct)",
+			Usage:    "JWK type `TYPE` (RSA/EC/OKP/oct/AKP)",
 			Required: true,
 		},
 		&cli.StringFlag{
@@ -111,6 +115,11 @@ func makeJwkGenerateCmd() *cli.Command {
 			Aliases: []string{"c"},
 			Usage:   "Elliptic curve name `CURVE` (" + crvnames.String() + ") for ECDSA and OKP keys",
 		},
+		&cli.StringFlag{
+			Name:    "algorithm",
+			Aliases: []string{"a"},
+			Usage:   "Algorithm name `ALG` (e.g. ML-DSA-65, ML-KEM-768, ML-KEM-768+A192KW) for AKP keys",
+		},
 		&cli.StringFlag{
 			Name:  "template",
 			Usage: `Extra values in the JWK as JSON object`,
@@ -129,6 +138,7 @@ func makeJwkGenerateCmd() *cli.Command {
 
 	cmd.Action = func(c *cli.Context) error {
 		var rawkey interface{}
+		var akpAlg jwa.KeyAlgorithm
 		switch typ := jwa.KeyType(c.String("type")); typ {
 		case jwa.RSA:
 			v, err := rsa.GenerateKey(rand.Reader, c.Int("keysize"))
@@ -178,9 +188,51 @@ func makeJwkGenerateCmd() *cli.Command {
 					return fmt.Errorf(`failed to generate x25519 private key: %w`, err)
 				}
 				rawkey = priv
+			case jwa.Ed448:
+				_, priv, err := ed448.GenerateKey(rand.Reader)
+				if err != nil {
+					return fmt.Errorf(`failed to generate ed448 private key: %w`, err)
+				}
+				rawkey = priv
+			case jwa.X448:
+				_, priv, err := x448.GenerateKey(rand.Reader)
+				if err != nil {
+					return fmt.Errorf(`failed to generate x448 private key: %w`, err)
+				}
+				rawkey = priv
 			default:
-				return fmt.Errorf(`invalid elliptic curve for OKP: %s (expected %s/%s)`, crvalg, jwa.Ed25519, jwa.X25519)
+				return fmt.Errorf(`invalid elliptic curve for OKP: %s (expected %s/%s/%s/%s)`, crvalg, jwa.Ed25519, jwa.X25519, jwa.Ed448, jwa.X448)
 			}
+		case jwa.AKP:
+			alg := jwa.KeyAlgorithmFrom(c.String("algorithm"))
+			switch alg {
+			case jwa.MLDSA44, jwa.MLDSA65, jwa.MLDSA87:
+				params := mldsa.MLDSA44()
+				switch alg {
+				case jwa.MLDSA65:
+					params = mldsa.MLDSA65()
+				case jwa.MLDSA87:
+					params = mldsa.MLDSA87()
+				}
+				v, err := mldsa.GenerateKey(params, rand.Reader)
+				if err != nil {
+					return fmt.Errorf(`failed to generate ML-DSA private key: %w`, err)
+				}
+				rawkey = v
+			case jwa.MLKEM768, jwa.MLKEM768_A192KW, jwa.MLKEM1024, jwa.MLKEM1024_A256KW:
+				params := mlkem.MLKEM768()
+				if alg == jwa.MLKEM1024 || alg == jwa.MLKEM1024_A256KW {
+					params = mlkem.MLKEM1024()
+				}
+				v, err := mlkem.GenerateKey(params, rand.Reader)
+				if err != nil {
+					return fmt.Errorf(`failed to generate ML-KEM private key: %w`, err)
+				}
+				rawkey = v
+			default:
+				return fmt.Errorf(`invalid algorithm for AKP: %q`, c.String("algorithm"))
+			}
+			akpAlg = alg
 		default:
 			return fmt.Errorf(`invalid key type %s`, typ)
 		}
@@ -195,6 +247,14 @@ func makeJwkGenerateCmd() *cli.Command {
 			return fmt.Errorf(`failed to create new JWK from raw key: %w`, err)
 		}
 
+		// ML-KEM keys may also be used with AES key wrap, in which case
+		// "alg" must be the combined algorithm
+		if akpAlg != nil {
+			if err := key.Set(jwk.AlgorithmKey, akpAlg); err != nil {
+				return fmt.Errorf(`failed to set field %s: %w`, jwk.AlgorithmKey, err)
+			}
+		}
+
 		for k, v := range attrs {
 			if err := key.Set(k, v); err != nil {
 				return fmt.Errorf(`failed to set field %s: %w`, k, err)
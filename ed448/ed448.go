@@ -0,0 +1,211 @@
+// Package ed448 implements the Ed448 signature algorithm (RFC 8032).
+//
+// The API mirrors that of Go's crypto/ed25519 package. Only "pure" Ed448
+// with an empty context string is supported, which is what is used by
+// JOSE (RFC 8037).
+package ed448
+
+import (
+	"bytes"
+	"crypto"
+	cryptorand "crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/sjwl/jwx/v2/internal/curve448"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// PublicKeySize is the size, in bytes, of public keys as used in this package.
+	PublicKeySize = 57
+	// PrivateKeySize is the size, in bytes, of private keys as used in this package.
+	PrivateKeySize = 114
+	// SignatureSize is the size, in bytes, of signatures generated and verified by this package.
+	SignatureSize = 114
+	// SeedSize is the size, in bytes, of private key seeds. These are the private key representations used by RFC 8032.
+	SeedSize = 57
+)
+
+// dom4 is the domain separation prefix for Ed448 with an empty context
+// string (RFC 8032, Section 5.2)
+var dom4 = []byte{'S', 'i', 'g', 'E', 'd', '4', '4', '8', 0x00, 0x00}
+
+// PublicKey is the type of Ed448 public keys.
+type PublicKey []byte
+
+// Equal reports whether pub and x have the same value.
+func (pub PublicKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(PublicKey)
+	if !ok {
+		return false
+	}
+	return bytes.Equal(pub, xx)
+}
+
+// PrivateKey is the type of Ed448 private keys. It implements crypto.Signer.
+type PrivateKey []byte
+
+// Public returns the PublicKey corresponding to priv.
+func (priv PrivateKey) Public() crypto.PublicKey {
+	publicKey := make([]byte, PublicKeySize)
+	copy(publicKey, priv[SeedSize:])
+	return PublicKey(publicKey)
+}
+
+// Equal reports whether priv and x have the same value.
+func (priv PrivateKey) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(PrivateKey)
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare(priv, xx) == 1
+}
+
+// Seed returns the private key seed corresponding to priv. It is provided for
+// interoperability with RFC 8032. RFC 8032's private keys correspond to seeds
+// in this package.
+func (priv PrivateKey) Seed() []byte {
+	seed := make([]byte, SeedSize)
+	copy(seed, priv[:SeedSize])
+	return seed
+}
+
+// Sign signs the given message with priv. rand is ignored. opts.HashFunc()
+// must return zero, as pre-hashed messages (Ed448ph) are not supported.
+func (priv PrivateKey) Sign(_ io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != crypto.Hash(0) {
+		return nil, errors.New("ed448: cannot sign hashed message")
+	}
+	return Sign(priv, message), nil
+}
+
+// GenerateKey generates a public/private key pair using entropy from rand.
+// If rand is nil, crypto/rand.Reader will be used.
+func GenerateKey(rand io.Reader) (PublicKey, PrivateKey, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+
+	seed := make([]byte, SeedSize)
+	if _, err := io.ReadFull(rand, seed); err != nil {
+		return nil, nil, err
+	}
+
+	privateKey := NewKeyFromSeed(seed)
+	publicKey := make([]byte, PublicKeySize)
+	copy(publicKey, privateKey[SeedSize:])
+
+	return publicKey, privateKey, nil
+}
+
+// NewKeyFromSeed calculates a private key from a seed. It will panic if
+// len(seed) is not SeedSize. This function is provided for interoperability
+// with RFC 8032. RFC 8032's private keys correspond to seeds in this
+// package.
+func NewKeyFromSeed(seed []byte) PrivateKey {
+	if l := len(seed); l != SeedSize {
+		panic("ed448: bad seed length: " + strconv.Itoa(l))
+	}
+
+	s, _ := expandSeed(seed)
+	var A curve448.Point
+	A.ScalarBaseMult(s)
+
+	privateKey := make([]byte, PrivateKeySize)
+	copy(privateKey, seed)
+	copy(privateKey[SeedSize:], A.Bytes())
+	return privateKey
+}
+
+// expandSeed returns the secret scalar and the prefix used to generate
+// nonces, derived from the seed as described in RFC 8032, Section 5.2.5
+func expandSeed(seed []byte) (*curve448.Scalar, []byte) {
+	h := make([]byte, 2*SeedSize)
+	sha3.ShakeSum256(h, seed)
+
+	var s curve448.Scalar
+	if _, err := s.SetBytesWithClamping(h[:SeedSize]); err != nil {
+		panic(fmt.Sprintf("ed448: internal error: %s", err))
+	}
+	return &s, h[SeedSize:]
+}
+
+// hashToScalar returns SHAKE256(dom4 || parts..., 114) modulo L
+func hashToScalar(parts ...[]byte) *curve448.Scalar {
+	h := sha3.NewShake256()
+	_, _ = h.Write(dom4)
+	for _, part := range parts {
+		_, _ = h.Write(part)
+	}
+	digest := make([]byte, 2*SeedSize)
+	_, _ = h.Read(digest)
+
+	var s curve448.Scalar
+	if _, err := s.SetUniformBytes(digest); err != nil {
+		panic(fmt.Sprintf("ed448: internal error: %s", err))
+	}
+	return &s
+}
+
+// Sign signs the message with privateKey and returns a signature. It will
+// panic if len(privateKey) is not PrivateKeySize.
+func Sign(privateKey PrivateKey, message []byte) []byte {
+	if l := len(privateKey); l != PrivateKeySize {
+		panic("ed448: bad private key length: " + strconv.Itoa(l))
+	}
+	seed, publicKey := privateKey[:SeedSize], privateKey[SeedSize:]
+
+	s, prefix := expandSeed(seed)
+	r := hashToScalar(prefix, message)
+
+	var R curve448.Point
+	R.ScalarBaseMult(r)
+	encodedR := R.Bytes()
+
+	k := hashToScalar(encodedR, publicKey, message)
+
+	var S curve448.Scalar
+	S.MultiplyAdd(k, s, r)
+
+	signature := make([]byte, SignatureSize)
+	copy(signature, encodedR)
+	copy(signature[curve448.PointBytesSize:], S.Bytes())
+	return signature
+}
+
+// Verify reports whether sig is a valid signature of message by publicKey. It
+// will panic if len(publicKey) is not PublicKeySize.
+func Verify(publicKey PublicKey, message, sig []byte) bool {
+	if l := len(publicKey); l != PublicKeySize {
+		panic("ed448: bad public key length: " + strconv.Itoa(l))
+	}
+
+	if len(sig) != SignatureSize {
+		return false
+	}
+
+	var A curve448.Point
+	if _, err := A.SetBytes(publicKey); err != nil {
+		return false
+	}
+
+	var S curve448.Scalar
+	if _, err := S.SetCanonicalBytes(sig[curve448.PointBytesSize:]); err != nil {
+		return false
+	}
+
+	k := hashToScalar(sig[:curve448.PointBytesSize], publicKey, message)
+
+	// R' = [S]B - [k]A
+	var sB, kA, R curve448.Point
+	sB.ScalarBaseMult(&S)
+	kA.ScalarMult(k, &A)
+	kA.Negate(&kA)
+	R.Add(&sB, &kA)
+
+	return bytes.Equal(sig[:curve448.PointBytesSize], R.Bytes())
+}
@@ -0,0 +1,81 @@
+package ed448_test
+
+import (
+	"crypto"
+	"encoding/hex"
+	"testing"
+
+	"github.com/sjwl/jwx/v2/ed448"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRFC8032(t *testing.T) {
+	// These test vectors are from RFC8032 Section 7.4
+	testcases := []struct {
+		Name      string
+		Seed      string
+		Public    string
+		Message   string
+		Signature string
+	}{
+		{
+			Name:      "Blank",
+			Seed:      `6c82a562cb808d10d632be89c8513ebf6c929f34ddfa8c9f63c9960ef6e348a3528c8a3fcc2f044e39a3fc5b94492f8f032e7549a20098f95b`,
+			Public:    `5fd7449b59b461fd2ce787ec616ad46a1da1342485a70e1f8a0ea75d80e96778edf124769b46c7061bd6783df1e50f6cd1fa1abeafe8256180`,
+			Message:   ``,
+			Signature: `533a37f6bbe457251f023c0d88f976ae2dfb504a843e34d2074fd823d41a591f2b233f034f628281f2fd7a22ddd47d7828c59bd0a21bfd3980ff0d2028d4b18a9df63e006c5d1c2d345b925d8dc00b4104852db99ac5c7cdda8530a113a0f4dbb61149f05a7363268c71d95808ff2e652600`,
+		},
+		{
+			Name:      "1 octet",
+			Seed:      `c4eab05d357007c632f3dbb48489924d552b08fe0c353a0d4a1f00acda2c463afbea67c5e8d2877c5e3bc397a659949ef8021e954e0a12274e`,
+			Public:    `43ba28f430cdff456ae531545f7ecd0ac834a55d9358c0372bfa0c6c6798c0866aea01eb00742802b8438ea4cb82169c235160627b4c3a9480`,
+			Message:   `03`,
+			Signature: `26b8f91727bd62897af15e41eb43c377efb9c610d48f2335cb0bd0087810f4352541b143c4b981b7e18f62de8ccdf633fc1bf037ab7cd779805e0dbcc0aae1cbcee1afb2e027df36bc04dcecbf154336c19f0af7e0a6472905e799f1953d2a0ff3348ab21aa4adafd1d234441cf807c03a00`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			seed, err := hex.DecodeString(tc.Seed)
+			require.NoError(t, err, `hex.DecodeString should succeed`)
+			msg, err := hex.DecodeString(tc.Message)
+			require.NoError(t, err, `hex.DecodeString should succeed`)
+
+			priv := ed448.NewKeyFromSeed(seed)
+			pub := priv.Public().(ed448.PublicKey)
+			require.Equal(t, tc.Public, hex.EncodeToString(pub))
+			require.Equal(t, seed, priv.Seed())
+
+			sig, err := priv.Sign(nil, msg, crypto.Hash(0))
+			require.NoError(t, err, `Sign should succeed`)
+			require.Equal(t, tc.Signature, hex.EncodeToString(sig))
+			require.True(t, ed448.Verify(pub, msg, sig), `Verify should succeed`)
+
+			sig[0] ^= 1
+			require.False(t, ed448.Verify(pub, msg, sig), `Verify should fail for a modified signature`)
+			sig[0] ^= 1
+			require.False(t, ed448.Verify(pub, append(msg, 0), sig), `Verify should fail for a modified message`)
+		})
+	}
+}
+
+func TestGenerateKey(t *testing.T) {
+	pub, priv, err := ed448.GenerateKey(nil)
+	require.NoError(t, err, `ed448.GenerateKey should work even if argument is nil`)
+	require.True(t, pub.Equal(priv.Public()), `public keys should match`)
+	require.True(t, priv.Equal(ed448.NewKeyFromSeed(priv.Seed())), `private keys should match`)
+	require.False(t, priv.Equal(pub), `private key should not equal public key`)
+
+	msg := []byte("Lorem ipsum")
+	sig := ed448.Sign(priv, msg)
+	require.True(t, ed448.Verify(pub, msg, sig), `Verify should succeed`)
+
+	otherPub, _, err := ed448.GenerateKey(nil)
+	require.NoError(t, err, `ed448.GenerateKey should succeed`)
+	require.False(t, ed448.Verify(otherPub, msg, sig), `Verify should fail with another key`)
+	require.False(t, ed448.Verify(pub, msg, sig[:10]), `Verify should fail for truncated signatures`)
+
+	_, err = priv.Sign(nil, msg, crypto.SHA256)
+	require.Error(t, err, `Sign should fail for hashed messages`)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/binary"
 	"fmt"
+	"io"
 )
 
 func Encode(src []byte) []byte {
@@ -63,3 +64,16 @@ func Decode(src []byte) ([]byte, error) {
 func DecodeString(src string) ([]byte, error) {
 	return Decode([]byte(src))
 }
+
+// NewEncoder returns a stream encoder that writes the raw (unpadded)
+// URL-safe base64 encoding of the data written to it to `dst`.
+// The caller must call Close() on the encoder after writing all data.
+func NewEncoder(dst io.Writer) io.WriteCloser {
+	return base64.NewEncoder(base64.RawURLEncoding, dst)
+}
+
+// NewDecoder returns a stream decoder that reads the raw (unpadded)
+// URL-safe base64 encoded data from `src`
+func NewDecoder(src io.Reader) io.Reader {
+	return base64.NewDecoder(base64.RawURLEncoding, src)
+}
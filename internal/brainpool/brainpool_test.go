@@ -0,0 +1,95 @@
+package brainpool_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/sjwl/jwx/v2/internal/brainpool"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurves(t *testing.T) {
+	// Expected values were computed independently, using affine
+	// arithmetic directly on the r1 curves
+	testcases := []struct {
+		Name   string
+		Curve  elliptic.Curve
+		Scalar string
+		X      string
+		Y      string
+	}{
+		{
+			Name:   "brainpoolP256r1",
+			Curve:  brainpool.P256r1(),
+			Scalar: `4dbce271545cd8413448cb1506279a5b67434892cf89ceafef7f6ed4ad1ea3a7`,
+			X:      `6bfc03702557c1d335dd5aa582bdf64e28d94872e2cf08aeb4c82fa38fb53d7e`,
+			Y:      `1294fd41866ac9d0ce88a96dd342f3ce57158d2d68816bd4206b86faee2eccf1`,
+		},
+		{
+			Name:   "brainpoolP384r1",
+			Curve:  brainpool.P384r1(),
+			Scalar: `86822156d801b1967aee82f4a267e08f5698f37e005a08518b95b2a72fe8087556d49b32831bdfc883cbd276d09b8ed8`,
+			X:      `48352335e9f85a6d27601e91c97f6aadbaa9a49dfa94dd8ddb97928f8fbe5d49ad136522b60823996e914e152f36beb3`,
+			Y:      `624fab599dabc56af5770da8c8dbe3b03fb0d303d1850a0b23e5d710e36df1332722f74b027e118579dc26c5dc2d4f4d`,
+		},
+		{
+			Name:   "brainpoolP512r1",
+			Curve:  brainpool.P512r1(),
+			Scalar: `0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef`,
+			X:      `72f4a8ebcd121cf53409101f24bc9b7f048d815b328e3faa09c445402fa64688d1492e2d627cc24b5d3a0e149d29b5927d30b314585c0cd045a95e5146e014d2`,
+			Y:      `5103f7cb1c6adf6fb3069e87ffaec2f0dffe871a06a5ca3ebab93d7569ae011eaef6097895bf8decca5bfd3d627a66d7bc968274ac08af476a8f537aeaf5f0a3`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			crv := tc.Curve
+			params := crv.Params()
+			require.Equal(t, tc.Name, params.Name)
+			require.True(t, brainpool.IsCurve(crv), `brainpool.IsCurve should be true`)
+			require.False(t, brainpool.IsCurve(elliptic.P256()), `brainpool.IsCurve should be false for P-256`)
+			require.True(t, crv.IsOnCurve(params.Gx, params.Gy), `base point should be on the curve`)
+
+			scalar, err := hex.DecodeString(tc.Scalar)
+			require.NoError(t, err, `hex.DecodeString should succeed`)
+
+			x, y := crv.ScalarBaseMult(scalar)
+			require.Equal(t, tc.X, hex.EncodeToString(x.FillBytes(make([]byte, len(scalar)))))
+			require.Equal(t, tc.Y, hex.EncodeToString(y.FillBytes(make([]byte, len(scalar)))))
+			require.True(t, crv.IsOnCurve(x, y), `result should be on the curve`)
+
+			x2, y2 := crv.ScalarMult(params.Gx, params.Gy, scalar)
+			require.Equal(t, x, x2)
+			require.Equal(t, y, y2)
+
+			dx, dy := crv.Double(x, y)
+			ax, ay := crv.Add(x, y, x, y)
+			require.Equal(t, dx, ax)
+			require.Equal(t, dy, ay)
+
+			ox, oy := crv.ScalarBaseMult(params.N.Bytes())
+			require.Zero(t, ox.Sign(), `n * G should be the point at infinity`)
+			require.Zero(t, oy.Sign(), `n * G should be the point at infinity`)
+
+			require.False(t, crv.IsOnCurve(x, new(big.Int).Add(y, big.NewInt(1))), `modified point should not be on the curve`)
+		})
+		t.Run(tc.Name+" ECDSA", func(t *testing.T) {
+			key, err := ecdsa.GenerateKey(tc.Curve, rand.Reader)
+			require.NoError(t, err, `ecdsa.GenerateKey should succeed`)
+
+			digest := sha256.Sum256([]byte(tc.Name))
+			sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+			require.NoError(t, err, `ecdsa.SignASN1 should succeed`)
+			require.True(t, ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig), `ecdsa.VerifyASN1 should succeed`)
+
+			digest[0] ^= 0x01
+			require.False(t, ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig), `ecdsa.VerifyASN1 should fail for a different digest`)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package curve448_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/sjwl/jwx/v2/internal/curve448"
+	"github.com/stretchr/testify/require"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err, `hex.DecodeString should succeed`)
+	return b
+}
+
+func TestX448(t *testing.T) {
+	t.Run("RFC7748 Section 5.2", func(t *testing.T) {
+		testcases := []struct {
+			Scalar string
+			Point  string
+			Output string
+		}{
+			{
+				Scalar: `3d262fddf9ec8e88495266fea19a34d28882acef045104d0d1aae121700a779c984c24f8cdd78fbff44943eba368f54b29259a4f1c600ad3`,
+				Point:  `06fce640fa3487bfda5f6cf2d5263f8aad88334cbd07437f020f08f9814dc031ddbdc38c19c6da2583fa5429db94ada18aa7a7fb4ef8a086`,
+				Output: `ce3e4ff95a60dc6697da1db1d85e6afbdf79b50a2412d7546d5f239fe14fbaadeb445fc66a01b0779d98223961111e21766282f73dd96b6f`,
+			},
+			{
+				Scalar: `203d494428b8399352665ddca42f9de8fef600908e0d461cb021f8c538345dd77c3e4806e25f46d3315c44e0a5b4371282dd2c8d5be3095f`,
+				Point:  `0fbcc2f993cd56d3305b0b7d9e55d4c1a8fb5dbb52f8e9a1e9b6201b165d015894e56c4d3570bee52fe205e28a78b91cdfbde71ce8d157db`,
+				Output: `884a02576239ff7a2f2f63b2db6a9ff37047ac13568e1e30fe63c4a7ad1b3ee3a5700df34321d62077e63633c575c1c954514e99da7c179d`,
+			},
+		}
+		for _, tc := range testcases {
+			out, err := curve448.X448(mustHex(t, tc.Scalar), mustHex(t, tc.Point))
+			require.NoError(t, err, `curve448.X448 should succeed`)
+			require.Equal(t, tc.Output, hex.EncodeToString(out))
+		}
+	})
+	t.Run("RFC7748 Section 6.2", func(t *testing.T) {
+		alice := mustHex(t, `9a8f4925d1519f5775cf46b04b5800d4ee9ee8bae8bc5565d498c28dd9c9baf574a9419744897391006382a6f127ab1d9ac2d8c0a598726b`)
+		bob := mustHex(t, `1c306a7ac2a0e2e0990b294470cba339e6453772b075811d8fad0d1d6927c120bb5ee8972b0d3e21374c9c921b09d1b0366f10b65173992d`)
+
+		alicePub, err := curve448.X448(alice, curve448.Basepoint)
+		require.NoError(t, err, `curve448.X448 should succeed`)
+		require.Equal(t, `9b08f7cc31b7e3e67d22d5aea121074a273bd2b83de09c63faa73d2c22c5d9bbc836647241d953d40c5b12da88120d53177f80e532c41fa0`, hex.EncodeToString(alicePub))
+		bobPub, err := curve448.X448(bob, curve448.Basepoint)
+		require.NoError(t, err, `curve448.X448 should succeed`)
+		require.Equal(t, `3eb7a829b0cd20f5bcfc0b599b6feccf6da4627107bdb0d4f345b43027d8b972fc3e34fb4232a13ca706dcb57aec3dae07bdc1c67bf33609`, hex.EncodeToString(bobPub))
+
+		const shared = `07fff4181ac6cc95ec1c16a94a0f74d12da232ce40a77552281d282bb60c0b56fd2464c335543936521c24403085d59a449a5037514a879d`
+		k1, err := curve448.X448(alice, bobPub)
+		require.NoError(t, err, `curve448.X448 should succeed`)
+		require.Equal(t, shared, hex.EncodeToString(k1))
+		k2, err := curve448.X448(bob, alicePub)
+		require.NoError(t, err, `curve448.X448 should succeed`)
+		require.Equal(t, shared, hex.EncodeToString(k2))
+	})
+	t.Run("low order point", func(t *testing.T) {
+		_, err := curve448.X448(make([]byte, curve448.ScalarSize), make([]byte, curve448.PointSize))
+		require.Error(t, err, `curve448.X448 should fail for the zero point`)
+	})
+	t.Run("invalid lengths", func(t *testing.T) {
+		_, err := curve448.X448(make([]byte, 32), curve448.Basepoint)
+		require.Error(t, err, `curve448.X448 should fail for short scalars`)
+		_, err = curve448.X448(make([]byte, curve448.ScalarSize), make([]byte, 32))
+		require.Error(t, err, `curve448.X448 should fail for short points`)
+	})
+}
+
+func TestScalar(t *testing.T) {
+	t.Run("SetUniformBytes", func(t *testing.T) {
+		buf := make([]byte, 114)
+		for i := range buf {
+			buf[i] = 0xff
+		}
+		var s curve448.Scalar
+		_, err := s.SetUniformBytes(buf)
+		require.NoError(t, err, `SetUniformBytes should succeed`)
+		require.Equal(t, `81dee731a93f88112e1dad8707160f80293ea637fb19e320c5b624bb85c972cf17ae447cc4a34bc19c1aaf70d0e4b7bc522029b723f8392900`, hex.EncodeToString(s.Bytes()))
+	})
+	t.Run("MultiplyAdd", func(t *testing.T) {
+		a := make([]byte, 57)
+		b := make([]byte, 57)
+		for i := range a {
+			a[i] = byte(i)
+			b[i] = byte(100 + i)
+		}
+		var x, y, z, r curve448.Scalar
+		_, err := x.SetUniformBytes(a)
+		require.NoError(t, err, `SetUniformBytes should succeed`)
+		_, err = y.SetUniformBytes(b)
+		require.NoError(t, err, `SetUniformBytes should succeed`)
+		_, err = z.SetUniformBytes([]byte{0x39, 0x30})
+		require.NoError(t, err, `SetUniformBytes should succeed`)
+		require.Equal(t, `60acc515aec45bfd889e2cfecfe8e3cff252a2179b3416528cac3aea8e1d1e1f202122232425262728292a2b2c2d2e2f303132333435363700`, hex.EncodeToString(x.Bytes()))
+		require.Equal(t, `2ecb8d69c19e1eae3bef55c251f57eb70209a5ef4a3fa672daab3259c18282838485868788898a8b8c8d8e8f909192939495969798999a1b00`, hex.EncodeToString(y.Bytes()))
+
+		r.MultiplyAdd(&x, &y, &z)
+		require.Equal(t, `59006717f6683be5024e100dadf3fd3f87700a46ffae874b3d89dead6c460c3b7382927c2209290895e418b82727ce7bdf432f03ad851c0200`, hex.EncodeToString(r.Bytes()))
+	})
+	t.Run("SetCanonicalBytes", func(t *testing.T) {
+		lMinusOne := mustHex(t, `f24458ab92c27823558fc58d72c26c219036d6ae49db4ec4e923ca7cffffffffffffffffffffffffffffffffffffffffffffffffffffff3f00`)
+		var s curve448.Scalar
+		_, err := s.SetCanonicalBytes(lMinusOne)
+		require.NoError(t, err, `L - 1 should be accepted`)
+
+		l := make([]byte, len(lMinusOne))
+		copy(l, lMinusOne)
+		l[0]++
+		_, err = s.SetCanonicalBytes(l)
+		require.Error(t, err, `L should be rejected`)
+	})
+}
+
+func TestPoint(t *testing.T) {
+	g := curve448.NewGeneratorPoint()
+	encoded := g.Bytes()
+	require.Equal(t, `14fa30f25b790898adc8d74e2c13bdfdc4397ce61cffd33ad7c2a0051e9c78874098a36c7373ea4b62c7c9563720768824bcb66e71463f6900`, hex.EncodeToString(encoded))
+
+	// 2G computed by addition and by doubling should match
+	var sum, dbl curve448.Point
+	sum.Add(g, g)
+	dbl.Double(g)
+	require.Equal(t, sum.Bytes(), dbl.Bytes())
+
+	// G + (-G) is the identity
+	var neg, id curve448.Point
+	neg.Negate(g)
+	id.Add(g, &neg)
+	require.Equal(t, curve448.NewIdentityPoint().Bytes(), id.Bytes())
+
+	// Encoding round trip
+	var p curve448.Point
+	_, err := p.SetBytes(dbl.Bytes())
+	require.NoError(t, err, `SetBytes should succeed`)
+	require.Equal(t, dbl.Bytes(), p.Bytes())
+
+	// Non-canonical y
+	bad := make([]byte, curve448.PointBytesSize)
+	for i := 0; i < 56; i++ {
+		bad[i] = 0xff
+	}
+	_, err = p.SetBytes(bad)
+	require.Error(t, err, `SetBytes should fail for y >= p`)
+
+	// L * G is the identity
+	lMinusOne := mustHex(t, `f24458ab92c27823558fc58d72c26c219036d6ae49db4ec4e923ca7cffffffffffffffffffffffffffffffffffffffffffffffffffffff3f00`)
+	var s curve448.Scalar
+	_, err = s.SetCanonicalBytes(lMinusOne)
+	require.NoError(t, err, `SetCanonicalBytes should succeed`)
+	var q curve448.Point
+	q.ScalarBaseMult(&s)
+	q.Add(&q, g)
+	require.Equal(t, curve448.NewIdentityPoint().Bytes(), q.Bytes())
+}
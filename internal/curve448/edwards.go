@@ -0,0 +1,212 @@
+package curve448
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// PointBytesSize is the size, in bytes, of encoded edwards448 points
+const PointBytesSize = 57
+
+// edwardsD is the absolute value of the edwards448 curve
+// parameter d = -39081
+const edwardsD = 39081
+
+// Point is a point on the edwards448 curve, x^2 + y^2 = 1 + d * x^2 * y^2,
+// in projective coordinates (X : Y : Z).
+//
+// The addition formulas are complete, and all operations except for
+// decoding run in constant time.
+type Point struct {
+	x, y, z fieldElement
+}
+
+// generatorBytes is the encoding of the Ed448 base point (RFC 8032, Section 5.2)
+var generatorBytes = []byte{
+	0x14, 0xfa, 0x30, 0xf2, 0x5b, 0x79, 0x08, 0x98, 0xad, 0xc8, 0xd7, 0x4e, 0x2c, 0x13, 0xbd, 0xfd,
+	0xc4, 0x39, 0x7c, 0xe6, 0x1c, 0xff, 0xd3, 0x3a, 0xd7, 0xc2, 0xa0, 0x05, 0x1e, 0x9c, 0x78, 0x87,
+	0x40, 0x98, 0xa3, 0x6c, 0x73, 0x73, 0xea, 0x4b, 0x62, 0xc7, 0xc9, 0x56, 0x37, 0x20, 0x76, 0x88,
+	0x24, 0xbc, 0xb6, 0x6e, 0x71, 0x46, 0x3f, 0x69, 0x00,
+}
+
+var generator Point
+
+func init() {
+	if _, err := generator.SetBytes(generatorBytes); err != nil {
+		panic(fmt.Sprintf(`curve448: invalid generator: %s`, err))
+	}
+}
+
+// NewIdentityPoint returns a new Point set to the identity
+func NewIdentityPoint() *Point {
+	return &Point{x: feZero, y: feOne, z: feOne}
+}
+
+// NewGeneratorPoint returns a new Point set to the Ed448 base point
+func NewGeneratorPoint() *Point {
+	p := generator
+	return &p
+}
+
+// Set sets p = q, and returns p
+func (p *Point) Set(q *Point) *Point {
+	*p = *q
+	return p
+}
+
+// SetBytes sets p to the point encoded in x according to RFC 8032,
+// Section 5.2.3, and returns p. Non-canonical encodings are rejected
+func (p *Point) SetBytes(x []byte) (*Point, error) {
+	if len(x) != PointBytesSize {
+		return nil, fmt.Errorf(`invalid point length: %d bytes, expected %d`, len(x), PointBytesSize)
+	}
+	if x[PointBytesSize-1]&0x7f != 0 {
+		return nil, fmt.Errorf(`invalid point encoding`)
+	}
+
+	var y fieldElement
+	y.SetBytes(x[:fieldSize])
+	if !bytes.Equal(y.Bytes(), x[:fieldSize]) {
+		return nil, fmt.Errorf(`invalid point encoding: y is not reduced`)
+	}
+
+	// x^2 = u / v, where u = y^2 - 1 and v = d * y^2 - 1
+	var u, v, yy fieldElement
+	yy.Square(&y)
+	u.Sub(&yy, &feOne)
+	v.MultiplySmall(&yy, edwardsD)
+	v.Negate(&v)
+	v.Sub(&v, &feOne)
+
+	// x = u^3 * v * (u^5 * v^3)^((p - 3) / 4)
+	var u2, u3, u5, v3, t, xx fieldElement
+	u2.Square(&u)
+	u3.Multiply(&u2, &u)
+	u5.Multiply(&u3, &u2)
+	v3.Square(&v)
+	v3.Multiply(&v3, &v)
+	t.Multiply(&u5, &v3)
+	t.pow(&t, expSqrt)
+	t.Multiply(&t, &u3)
+	t.Multiply(&t, &v)
+
+	xx.Square(&t)
+	xx.Multiply(&xx, &v)
+	if xx.Equal(&u) != 1 {
+		return nil, fmt.Errorf(`invalid point encoding: not on the curve`)
+	}
+
+	sign := int(x[PointBytesSize-1] >> 7)
+	if t.Equal(&feZero) == 1 && sign == 1 {
+		return nil, fmt.Errorf(`invalid point encoding: invalid sign of x`)
+	}
+	if t.IsNegative() != sign {
+		t.Negate(&t)
+	}
+
+	p.x = t
+	p.y = y
+	p.z = feOne
+	return p, nil
+}
+
+// Bytes returns the encoding of p according to RFC 8032, Section 5.2.2
+func (p *Point) Bytes() []byte {
+	var zinv, x, y fieldElement
+	zinv.Invert(&p.z)
+	x.Multiply(&p.x, &zinv)
+	y.Multiply(&p.y, &zinv)
+
+	buf := make([]byte, PointBytesSize)
+	copy(buf, y.Bytes())
+	buf[PointBytesSize-1] = byte(x.IsNegative() << 7)
+	return buf
+}
+
+// Add sets p = q + r, and returns p
+func (p *Point) Add(q, r *Point) *Point {
+	var a, b, c, d, e, f, g, h, t fieldElement
+	a.Multiply(&q.z, &r.z)
+	b.Square(&a)
+	c.Multiply(&q.x, &r.x)
+	d.Multiply(&q.y, &r.y)
+	e.Multiply(&c, &d)
+	e.MultiplySmall(&e, edwardsD)
+	e.Negate(&e)
+	f.Sub(&b, &e)
+	g.Add(&b, &e)
+	h.Add(&q.x, &q.y)
+	t.Add(&r.x, &r.y)
+	h.Multiply(&h, &t)
+
+	// X3 = A * F * (H - C - D)
+	h.Sub(&h, &c)
+	h.Sub(&h, &d)
+	p.x.Multiply(&a, &f)
+	p.x.Multiply(&p.x, &h)
+	// Y3 = A * G * (D - C)
+	t.Sub(&d, &c)
+	p.y.Multiply(&a, &g)
+	p.y.Multiply(&p.y, &t)
+	// Z3 = F * G
+	p.z.Multiply(&f, &g)
+	return p
+}
+
+// Double sets p = 2 * q, and returns p
+func (p *Point) Double(q *Point) *Point {
+	var b, c, d, e, h, j fieldElement
+	b.Add(&q.x, &q.y)
+	b.Square(&b)
+	c.Square(&q.x)
+	d.Square(&q.y)
+	e.Add(&c, &d)
+	h.Square(&q.z)
+	j.Add(&h, &h)
+	j.Sub(&e, &j)
+
+	// X3 = (B - E) * J
+	b.Sub(&b, &e)
+	p.x.Multiply(&b, &j)
+	// Y3 = E * (C - D)
+	c.Sub(&c, &d)
+	p.y.Multiply(&e, &c)
+	// Z3 = E * J
+	p.z.Multiply(&e, &j)
+	return p
+}
+
+// Negate sets p = -q, and returns p
+func (p *Point) Negate(q *Point) *Point {
+	p.x.Negate(&q.x)
+	p.y = q.y
+	p.z = q.z
+	return p
+}
+
+// selectPoint sets p to a if cond is 1, and to b if cond is 0
+func (p *Point) selectPoint(a, b *Point, cond int) *Point {
+	p.x.Select(&a.x, &b.x, cond)
+	p.y.Select(&a.y, &b.y, cond)
+	p.z.Select(&a.z, &b.z, cond)
+	return p
+}
+
+// ScalarMult sets p = s * q, and returns p
+func (p *Point) ScalarMult(s *Scalar, q *Point) *Point {
+	base := *q
+	acc := NewIdentityPoint()
+	var sum Point
+	for i := 16*limbBits - 1; i >= 0; i-- {
+		acc.Double(acc)
+		sum.Add(acc, &base)
+		acc.selectPoint(&sum, acc, s.bit(i))
+	}
+	*p = *acc
+	return p
+}
+
+// ScalarBaseMult sets p = s * B, where B is the Ed448 base point, and returns p
+func (p *Point) ScalarBaseMult(s *Scalar) *Point {
+	return p.ScalarMult(s, &generator)
+}
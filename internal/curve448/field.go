@@ -0,0 +1,243 @@
+package curve448
+
+import "crypto/subtle"
+
+// fieldElement represents an element of GF(p), p = 2^448 - 2^224 - 1,
+// using 16 limbs of 28 bits each. Limbs are allowed to exceed 28 bits
+// slightly between operations: the operations below only require their
+// inputs to have been produced by another field operation.
+//
+// All operations run in constant time with respect to the values of
+// the field elements.
+type fieldElement [16]uint64
+
+const (
+	limbBits = 28
+	limbMask = (1 << limbBits) - 1
+
+	// fieldSize is the size, in bytes, of an encoded field element
+	fieldSize = 56
+)
+
+// fieldPrime holds the limbs of p
+var fieldPrime = fieldElement{
+	limbMask, limbMask, limbMask, limbMask, limbMask, limbMask, limbMask, limbMask,
+	limbMask - 1, limbMask, limbMask, limbMask, limbMask, limbMask, limbMask, limbMask,
+}
+
+var feZero = fieldElement{}
+var feOne = fieldElement{1}
+
+// carry propagates the carries of each limb into the next one, folding
+// the carry of the most significant limb using 2^448 = 2^224 + 1 (mod p)
+func (v *fieldElement) carry() {
+	for i := 0; i < 15; i++ {
+		v[i+1] += v[i] >> limbBits
+		v[i] &= limbMask
+	}
+	c := v[15] >> limbBits
+	v[15] &= limbMask
+	v[0] += c
+	v[8] += c
+	v[1] += v[0] >> limbBits
+	v[0] &= limbMask
+	v[9] += v[8] >> limbBits
+	v[8] &= limbMask
+}
+
+// Add sets v = a + b, and returns v
+func (v *fieldElement) Add(a, b *fieldElement) *fieldElement {
+	for i := range v {
+		v[i] = a[i] + b[i]
+	}
+	v.carry()
+	return v
+}
+
+// Sub sets v = a - b, and returns v
+func (v *fieldElement) Sub(a, b *fieldElement) *fieldElement {
+	// 2p is added so that none of the limbs underflow
+	for i := range v {
+		v[i] = a[i] + 2*fieldPrime[i] - b[i]
+	}
+	v.carry()
+	return v
+}
+
+// Negate sets v = -a, and returns v
+func (v *fieldElement) Negate(a *fieldElement) *fieldElement {
+	return v.Sub(&feZero, a)
+}
+
+// Multiply sets v = a * b, and returns v
+func (v *fieldElement) Multiply(a, b *fieldElement) *fieldElement {
+	var z [32]uint64
+	for i := 0; i < 16; i++ {
+		for j := 0; j < 16; j++ {
+			z[i+j] += a[i] * b[j]
+		}
+	}
+	for i := 0; i < 31; i++ {
+		z[i+1] += z[i] >> limbBits
+		z[i] &= limbMask
+	}
+
+	// 2^(28*i) = 2^(28*(i-8)) + 2^(28*(i-16)) (mod p) for i >= 16
+	for i := 31; i >= 16; i-- {
+		z[i-8] += z[i]
+		z[i-16] += z[i]
+	}
+	copy(v[:], z[:16])
+	v.carry()
+	return v
+}
+
+// Square sets v = a * a, and returns v
+func (v *fieldElement) Square(a *fieldElement) *fieldElement {
+	return v.Multiply(a, a)
+}
+
+// MultiplySmall sets v = a * c, where c is less than 2^32, and returns v
+func (v *fieldElement) MultiplySmall(a *fieldElement, c uint32) *fieldElement {
+	for i := range v {
+		v[i] = a[i] * uint64(c)
+	}
+	v.carry()
+	return v
+}
+
+// pow sets v = a^e, where e is a big-endian encoded public exponent,
+// and returns v
+func (v *fieldElement) pow(a *fieldElement, e []byte) *fieldElement {
+	x := *a
+	r := feOne
+	for _, b := range e {
+		for i := 7; i >= 0; i-- {
+			r.Square(&r)
+			if (b>>i)&1 == 1 {
+				r.Multiply(&r, &x)
+			}
+		}
+	}
+	*v = r
+	return v
+}
+
+// exponents used for inversion and square roots
+var (
+	// p - 2 = 2^448 - 2^224 - 3
+	expInvert = fieldExponent(2)
+	// (p - 3) / 4 = 2^446 - 2^222 - 1
+	expSqrt = fieldExponentSqrt()
+)
+
+// fieldExponent returns p - sub as a big-endian byte slice, for small values of sub
+func fieldExponent(sub byte) []byte {
+	var buf [fieldSize]byte
+	for i := range buf {
+		buf[i] = 0xff
+	}
+	// clear the bit for 2^224
+	buf[fieldSize-1-28] = 0xfe
+	buf[fieldSize-1] = 0xff - sub
+	return buf[:]
+}
+
+// fieldExponentSqrt returns (p - 3) / 4 as a big-endian byte slice
+func fieldExponentSqrt() []byte {
+	e := fieldExponent(3)
+	// shift right by 2 bits
+	var carry byte
+	for i := range e {
+		next := e[i] & 3
+		e[i] = (e[i] >> 2) | (carry << 6)
+		carry = next
+	}
+	return e
+}
+
+// Invert sets v = 1/a, and returns v. If a is zero, v is set to zero
+func (v *fieldElement) Invert(a *fieldElement) *fieldElement {
+	return v.pow(a, expInvert)
+}
+
+// Swap swaps v and u if cond is 1, and leaves them unchanged if cond is 0
+func (v *fieldElement) Swap(u *fieldElement, cond int) {
+	mask := -uint64(cond)
+	for i := range v {
+		t := mask & (v[i] ^ u[i])
+		v[i] ^= t
+		u[i] ^= t
+	}
+}
+
+// Select sets v to a if cond is 1, and to b if cond is 0, and returns v
+func (v *fieldElement) Select(a, b *fieldElement, cond int) *fieldElement {
+	mask := -uint64(cond)
+	for i := range v {
+		v[i] = (mask & a[i]) | (^mask & b[i])
+	}
+	return v
+}
+
+// Bytes returns the canonical little-endian encoding of v
+func (v *fieldElement) Bytes() []byte {
+	t := *v
+	t.carry()
+	t.carry()
+
+	// r = t - p. The final borrow is -1 if t < p, and 0 otherwise
+	var r fieldElement
+	var borrow int64
+	for i := range t {
+		x := int64(t[i]) - int64(fieldPrime[i]) + borrow
+		r[i] = uint64(x) & limbMask
+		borrow = x >> limbBits
+	}
+
+	// normalize t without folding, which is exact when t < p
+	var c fieldElement
+	var carry uint64
+	for i := range t {
+		x := t[i] + carry
+		c[i] = x & limbMask
+		carry = x >> limbBits
+	}
+
+	var out fieldElement
+	out.Select(&c, &r, int(-borrow))
+
+	buf := make([]byte, fieldSize)
+	for i := 0; i < 8; i++ {
+		w := out[2*i] | out[2*i+1]<<limbBits
+		for j := 0; j < 7; j++ {
+			buf[7*i+j] = byte(w >> (8 * j))
+		}
+	}
+	return buf
+}
+
+// SetBytes sets v to the little-endian encoded value in x, which must
+// be 56 bytes long, and returns v. Values that are not reduced modulo
+// p are accepted
+func (v *fieldElement) SetBytes(x []byte) *fieldElement {
+	for i := 0; i < 8; i++ {
+		var w uint64
+		for j := 0; j < 7; j++ {
+			w |= uint64(x[7*i+j]) << (8 * j)
+		}
+		v[2*i] = w & limbMask
+		v[2*i+1] = w >> limbBits
+	}
+	return v
+}
+
+// Equal returns 1 if v and u are equal, and 0 otherwise
+func (v *fieldElement) Equal(u *fieldElement) int {
+	return subtle.ConstantTimeCompare(v.Bytes(), u.Bytes())
+}
+
+// IsNegative returns 1 if v is odd, and 0 otherwise
+func (v *fieldElement) IsNegative() int {
+	return int(v.Bytes()[0] & 1)
+}
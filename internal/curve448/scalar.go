@@ -0,0 +1,166 @@
+package curve448
+
+import (
+	"crypto/subtle"
+	"fmt"
+)
+
+// ScalarBytesSize is the size, in bytes, of encoded edwards448 scalars
+const ScalarBytesSize = 57
+
+// Scalar is an integer modulo the order of the edwards448 prime order
+// subgroup, L = 2^446 - 13818066809895115352007386748515426880336692474882178609894547503885.
+// It uses the same 28-bit limbs as field elements, and is always fully reduced.
+//
+// All operations run in constant time with respect to the value of the scalar.
+type Scalar struct {
+	d [16]uint64
+}
+
+// scalarOrder holds the limbs of L
+var scalarOrder = [16]uint64{
+	0xb5844f3, 0x78c292a, 0x58f5523, 0xc2728dc, 0x690216c, 0x49aed63, 0x9c44edb, 0x7cca23e,
+	0xfffffff, 0xfffffff, 0xfffffff, 0xfffffff, 0xfffffff, 0xfffffff, 0xfffffff, 0x3ffffff,
+}
+
+// scalarFold holds the limbs of 2^446 - L
+var scalarFold = [8]uint64{
+	0x4a7bb0d, 0x873d6d5, 0xa70aadc, 0x3d8d723, 0x96fde93, 0xb65129c, 0x63bb124, 0x8335dc1,
+}
+
+// wideScalar holds integers of up to 924 bits, which is enough for the
+// 912-bit values used by Ed448 as well as for products of two scalars
+type wideScalar [33]uint64
+
+func (w *wideScalar) normalize() {
+	for i := 0; i < len(w)-1; i++ {
+		w[i+1] += w[i] >> limbBits
+		w[i] &= limbMask
+	}
+}
+
+// fold replaces w = hi * 2^446 + lo with lo + hi * (2^446 - L),
+// which is congruent modulo L. w must be normalized
+func (w *wideScalar) fold() {
+	var hi [18]uint64
+	for j := range hi {
+		v := w[15+j] >> 26
+		if 16+j < len(w) {
+			v |= w[16+j] << 2
+		}
+		hi[j] = v & limbMask
+	}
+	w[15] &= (1 << 26) - 1
+	for i := 16; i < len(w); i++ {
+		w[i] = 0
+	}
+	for i := range hi {
+		for j := range scalarFold {
+			w[i+j] += hi[i] * scalarFold[j]
+		}
+	}
+	w.normalize()
+}
+
+// reduce sets s = w mod L. w must be normalized
+func (s *Scalar) reduce(w *wideScalar) *Scalar {
+	// Each fold reduces the size of w: 924 -> 703 -> 482 -> 447 bits.
+	// After that w < 2^446 + 2^225 < 2L, and a single conditional
+	// subtraction gives the result
+	for i := 0; i < 5; i++ {
+		w.fold()
+	}
+
+	var r [16]uint64
+	var borrow int64
+	for i := range r {
+		x := int64(w[i]) - int64(scalarOrder[i]) + borrow
+		r[i] = uint64(x) & limbMask
+		borrow = x >> limbBits
+	}
+	// w[16] is always zero at this point
+	mask := uint64(borrow) // all ones if w < L
+	for i := range s.d {
+		s.d[i] = (mask & w[i]) | (^mask & r[i])
+	}
+	return s
+}
+
+func wideFromBytes(b []byte) *wideScalar {
+	var w wideScalar
+	for i := 0; i < len(b)*8; i++ {
+		w[i/limbBits] |= uint64(b[i/8]>>(i%8)&1) << (i % limbBits)
+	}
+	return &w
+}
+
+// SetUniformBytes sets s = x mod L, where x is a little-endian integer
+// of up to 114 bytes, and returns s
+func (s *Scalar) SetUniformBytes(x []byte) (*Scalar, error) {
+	if len(x) > 114 {
+		return nil, fmt.Errorf(`invalid scalar length: %d bytes`, len(x))
+	}
+	return s.reduce(wideFromBytes(x)), nil
+}
+
+// SetBytesWithClamping applies the Ed448 buffer pruning described in
+// RFC 8032, Section 5.2.5 to the 57 bytes in x, and sets s to the result
+// modulo L. It returns s
+func (s *Scalar) SetBytesWithClamping(x []byte) (*Scalar, error) {
+	if len(x) != ScalarBytesSize {
+		return nil, fmt.Errorf(`invalid scalar length: %d bytes, expected %d`, len(x), ScalarBytesSize)
+	}
+	var buf [ScalarBytesSize]byte
+	copy(buf[:], x)
+	buf[0] &= 252
+	buf[55] |= 128
+	buf[56] = 0
+	return s.reduce(wideFromBytes(buf[:])), nil
+}
+
+// SetCanonicalBytes sets s to the little-endian integer in x, which must
+// be 57 bytes long and less than L, and returns s
+func (s *Scalar) SetCanonicalBytes(x []byte) (*Scalar, error) {
+	if len(x) != ScalarBytesSize {
+		return nil, fmt.Errorf(`invalid scalar length: %d bytes, expected %d`, len(x), ScalarBytesSize)
+	}
+	var t Scalar
+	t.reduce(wideFromBytes(x))
+	if subtle.ConstantTimeCompare(t.Bytes(), x) != 1 {
+		return nil, fmt.Errorf(`invalid scalar encoding`)
+	}
+	*s = t
+	return s, nil
+}
+
+// MultiplyAdd sets s = x * y + z mod L, and returns s
+func (s *Scalar) MultiplyAdd(x, y, z *Scalar) *Scalar {
+	var w wideScalar
+	for i := range x.d {
+		for j := range y.d {
+			w[i+j] += x.d[i] * y.d[j]
+		}
+	}
+	for i := range z.d {
+		w[i] += z.d[i]
+	}
+	w.normalize()
+	return s.reduce(&w)
+}
+
+// Bytes returns the 57-byte little-endian encoding of s
+func (s *Scalar) Bytes() []byte {
+	buf := make([]byte, ScalarBytesSize)
+	for i := 0; i < 8; i++ {
+		w := s.d[2*i] | s.d[2*i+1]<<limbBits
+		for j := 0; j < 7; j++ {
+			buf[7*i+j] = byte(w >> (8 * j))
+		}
+	}
+	return buf
+}
+
+// bit returns the i-th bit of s
+func (s *Scalar) bit(i int) int {
+	return int(s.d[i/limbBits]>>(i%limbBits)) & 1
+}
@@ -0,0 +1,96 @@
+// Package curve448 implements the X448 function (RFC 7748) and the group
+// operations of the edwards448 curve that are required by Ed448 (RFC 8032).
+//
+// It is used by the x448 and ed448 packages, and is not meant to be used
+// directly.
+package curve448
+
+import (
+	"crypto/subtle"
+	"fmt"
+)
+
+const (
+	// ScalarSize is the size, in bytes, of X448 scalars
+	ScalarSize = 56
+	// PointSize is the size, in bytes, of X448 u-coordinates
+	PointSize = 56
+)
+
+// Basepoint is the canonical curve448 generator
+var Basepoint []byte
+
+func init() {
+	Basepoint = make([]byte, PointSize)
+	Basepoint[0] = 5
+}
+
+// a24 is (A - 2) / 4, where A = 156326 is the curve448 coefficient
+const a24 = 39081
+
+// X448 returns the result of the scalar multiplication (scalar * point),
+// according to RFC 7748, Section 5. scalar and point must be 56 bytes long.
+//
+// If the result is the all-zero value, X448 returns an error, as it
+// indicates that point is a low order point.
+func X448(scalar, point []byte) ([]byte, error) {
+	if l := len(scalar); l != ScalarSize {
+		return nil, fmt.Errorf(`bad scalar length: %d, expected %d`, l, ScalarSize)
+	}
+	if l := len(point); l != PointSize {
+		return nil, fmt.Errorf(`bad point length: %d, expected %d`, l, PointSize)
+	}
+
+	var k [ScalarSize]byte
+	copy(k[:], scalar)
+	k[0] &= 252
+	k[55] |= 128
+
+	var x1, x2, z2, x3, z3 fieldElement
+	x1.SetBytes(point)
+	x2 = feOne
+	x3 = x1
+	z3 = feOne
+
+	var a, aa, b, bb, e, c, d, da, cb fieldElement
+	swap := 0
+	for t := 447; t >= 0; t-- {
+		kt := int(k[t/8]>>(t%8)) & 1
+		swap ^= kt
+		x2.Swap(&x3, swap)
+		z2.Swap(&z3, swap)
+		swap = kt
+
+		a.Add(&x2, &z2)
+		aa.Square(&a)
+		b.Sub(&x2, &z2)
+		bb.Square(&b)
+		e.Sub(&aa, &bb)
+		c.Add(&x3, &z3)
+		d.Sub(&x3, &z3)
+		da.Multiply(&d, &a)
+		cb.Multiply(&c, &b)
+
+		x3.Add(&da, &cb)
+		x3.Square(&x3)
+		z3.Sub(&da, &cb)
+		z3.Square(&z3)
+		z3.Multiply(&z3, &x1)
+		x2.Multiply(&aa, &bb)
+		z2.MultiplySmall(&e, a24)
+		z2.Add(&z2, &aa)
+		z2.Multiply(&z2, &e)
+	}
+	x2.Swap(&x3, swap)
+	z2.Swap(&z3, swap)
+
+	z2.Invert(&z2)
+	x2.Multiply(&x2, &z2)
+
+	out := x2.Bytes()
+	var zero [PointSize]byte
+	if subtle.ConstantTimeCompare(out, zero[:]) == 1 {
+		return nil, fmt.Errorf(`bad input point: low order point`)
+	}
+	return out, nil
+}
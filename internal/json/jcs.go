@@ -0,0 +1,306 @@
+package json
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Canonicalize converts the JSON text in `src` to the canonical form
+// defined by the JSON Canonicalization Scheme (JCS, RFC8785): whitespace
+// is removed, object members are sorted by their names, and strings and
+// numbers are serialized the same way as ECMAScript's JSON.stringify().
+//
+// As required by RFC8785, `src` must be I-JSON (RFC7493): objects with
+// duplicate member names, invalid UTF-8, and unpaired surrogates are
+// rejected instead of being silently replaced.
+func Canonicalize(src []byte) ([]byte, error) {
+	if err := checkIJSONText(src); err != nil {
+		return nil, err
+	}
+
+	dec := NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+
+	v, err := decodeIJSONValue(dec)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to decode JSON: %w`, err)
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf(`unexpected data after JSON value`)
+	}
+
+	var buf bytes.Buffer
+	if err := canonicalizeValue(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// checkIJSONText rejects text that the decoder would otherwise silently
+// convert to U+FFFD: invalid UTF-8, and unpaired surrogates in \u escapes
+func checkIJSONText(src []byte) error {
+	if !utf8.Valid(src) {
+		return fmt.Errorf(`invalid UTF-8 in JSON text`)
+	}
+
+	for i := 0; i < len(src); i++ {
+		if src[i] != '\\' {
+			continue
+		}
+		r, ok := unicodeEscape(src[i:])
+		if !ok {
+			// skip the escaped character, which may be a backslash
+			i++
+			continue
+		}
+		i += 5
+		switch {
+		case r >= 0xdc00 && r <= 0xdfff:
+			return fmt.Errorf(`unpaired surrogate \u%04x in JSON text`, r)
+		case r >= 0xd800 && r <= 0xdbff:
+			low, ok := unicodeEscape(src[i+1:])
+			if !ok || low < 0xdc00 || low > 0xdfff {
+				return fmt.Errorf(`unpaired surrogate \u%04x in JSON text`, r)
+			}
+			i += 6
+		}
+	}
+	return nil
+}
+
+// unicodeEscape decodes the \uXXXX escape sequence at the start of `b`
+func unicodeEscape(b []byte) (rune, bool) {
+	if len(b) < 6 || b[0] != '\\' || b[1] != 'u' {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(string(b[2:6]), 16, 16)
+	if err != nil {
+		return 0, false
+	}
+	return rune(v), true
+}
+
+// decodeIJSONValue decodes the next value from `dec` one token at a time,
+// so that objects with duplicate member names can be rejected
+func decodeIJSONValue(dec *Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(Delim)
+	if !ok {
+		// string, Number, bool, or nil
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		obj := make(map[string]interface{})
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			name, ok := tok.(string)
+			if !ok {
+				return nil, fmt.Errorf(`invalid member name %v`, tok)
+			}
+			if _, ok := obj[name]; ok {
+				return nil, fmt.Errorf(`duplicate member name %q`, name)
+			}
+			v, err := decodeIJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj[name] = v
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case '[':
+		arr := []interface{}{}
+		for dec.More() {
+			v, err := decodeIJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return arr, nil
+	default:
+		return nil, fmt.Errorf(`unexpected delimiter %v`, delim)
+	}
+}
+
+func canonicalizeValue(dst *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		dst.WriteString(`null`)
+	case bool:
+		if v {
+			dst.WriteString(`true`)
+		} else {
+			dst.WriteString(`false`)
+		}
+	case Number:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return fmt.Errorf(`failed to parse number %q: %w`, v, err)
+		}
+		s, err := canonicalNumber(f)
+		if err != nil {
+			return err
+		}
+		dst.WriteString(s)
+	case string:
+		canonicalString(dst, v)
+	case []interface{}:
+		dst.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				dst.WriteByte(',')
+			}
+			if err := canonicalizeValue(dst, elem); err != nil {
+				return err
+			}
+		}
+		dst.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		// Names are sorted by their UTF-16 code units (RFC8785 Section 3.2.3)
+		sort.Slice(keys, func(i, j int) bool {
+			return lessUTF16(keys[i], keys[j])
+		})
+
+		dst.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				dst.WriteByte(',')
+			}
+			canonicalString(dst, k)
+			dst.WriteByte(':')
+			if err := canonicalizeValue(dst, v[k]); err != nil {
+				return err
+			}
+		}
+		dst.WriteByte('}')
+	default:
+		return fmt.Errorf(`unexpected value of type %T`, v)
+	}
+	return nil
+}
+
+func lessUTF16(a, b string) bool {
+	ua := utf16.Encode([]rune(a))
+	ub := utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+func canonicalString(dst *bytes.Buffer, s string) {
+	const hex = `0123456789abcdef`
+	dst.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			dst.WriteString(`\"`)
+		case '\\':
+			dst.WriteString(`\\`)
+		case '\b':
+			dst.WriteString(`\b`)
+		case '\f':
+			dst.WriteString(`\f`)
+		case '\n':
+			dst.WriteString(`\n`)
+		case '\r':
+			dst.WriteString(`\r`)
+		case '\t':
+			dst.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				dst.WriteString(`\u00`)
+				dst.WriteByte(hex[r>>4])
+				dst.WriteByte(hex[r&0xF])
+			} else {
+				dst.WriteRune(r)
+			}
+		}
+	}
+	dst.WriteByte('"')
+}
+
+// canonicalNumber formats `f` the same way as ECMAScript's
+// Number.prototype.toString() (RFC8785 Section 3.2.2.3)
+func canonicalNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf(`invalid number %v`, f)
+	}
+	if f == 0 {
+		return `0`, nil
+	}
+
+	var sign string
+	if f < 0 {
+		sign = `-`
+		f = -f
+	}
+
+	// The shortest representation that round-trips, in the form
+	// "d.ddde±xx". Extract the digits and the exponent
+	repr := strconv.FormatFloat(f, 'e', -1, 64)
+	epos := strings.IndexByte(repr, 'e')
+	digits := strings.Replace(repr[:epos], ".", "", 1)
+	exp, err := strconv.Atoi(repr[epos+1:])
+	if err != nil {
+		return "", fmt.Errorf(`failed to parse exponent of %q: %w`, repr, err)
+	}
+
+	// n is the position of the decimal point relative to the digits
+	k := len(digits)
+	n := exp + 1
+
+	var s string
+	switch {
+	case k <= n && n <= 21:
+		s = digits + strings.Repeat(`0`, n-k)
+	case 0 < n && n <= 21:
+		s = digits[:n] + `.` + digits[n:]
+	case -6 < n && n <= 0:
+		s = `0.` + strings.Repeat(`0`, -n) + digits
+	default:
+		esign := `+`
+		if n-1 < 0 {
+			esign = `-`
+		}
+		e := n - 1
+		if e < 0 {
+			e = -e
+		}
+		if k == 1 {
+			s = digits + `e` + esign + strconv.Itoa(e)
+		} else {
+			s = digits[:1] + `.` + digits[1:] + `e` + esign + strconv.Itoa(e)
+		}
+	}
+	return sign + s, nil
+}
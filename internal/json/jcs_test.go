@@ -0,0 +1,112 @@
+package json_test
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/sjwl/jwx/v2/internal/json"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	t.Run("RFC8785 Section 3.2.2", func(t *testing.T) {
+		const src = `{
+  "numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`
+		const expected = `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+
+		canonical, err := json.Canonicalize([]byte(src))
+		require.NoError(t, err, `json.Canonicalize should succeed`)
+		require.Equal(t, expected, string(canonical))
+	})
+	t.Run("RFC8785 Section 3.2.3", func(t *testing.T) {
+		const src = `{
+  "\u20ac": "Euro Sign",
+  "\r": "Carriage Return",
+  "\ufb33": "Hebrew Letter Dalet With Dagesh",
+  "1": "One",
+  "\ud83d\ude00": "Emoji: Grinning Face",
+  "\u0080": "Control",
+  "\u00f6": "Latin Small Letter O With Diaeresis"
+}`
+		const expected = "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"\u00f6\":\"Latin Small Letter O With Diaeresis\",\"\u20ac\":\"Euro Sign\",\"\U0001F600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}"
+
+		canonical, err := json.Canonicalize([]byte(src))
+		require.NoError(t, err, `json.Canonicalize should succeed`)
+		require.Equal(t, expected, string(canonical))
+	})
+	t.Run("RFC8785 Appendix B", func(t *testing.T) {
+		testcases := map[uint64]string{
+			0x0000000000000000: `0`,
+			0x8000000000000000: `0`,
+			0x0000000000000001: `5e-324`,
+			0x8000000000000001: `-5e-324`,
+			0x7fefffffffffffff: `1.7976931348623157e+308`,
+			0xffefffffffffffff: `-1.7976931348623157e+308`,
+			0x4340000000000000: `9007199254740992`,
+			0xc340000000000000: `-9007199254740992`,
+			0x4430000000000000: `295147905179352830000`,
+			0x44b52d02c7e14af5: `9.999999999999997e+22`,
+			0x44b52d02c7e14af6: `1e+23`,
+			0x44b52d02c7e14af7: `1.0000000000000001e+23`,
+			0x444b1ae4d6e2ef4e: `999999999999999700000`,
+			0x444b1ae4d6e2ef4f: `999999999999999900000`,
+			0x444b1ae4d6e2ef50: `1e+21`,
+			0x3eb0c6f7a0b5ed8c: `9.999999999999997e-7`,
+			0x3eb0c6f7a0b5ed8d: `0.000001`,
+			0x41b3de4355555553: `333333333.3333332`,
+			0x41b3de4355555554: `333333333.33333325`,
+			0x41b3de4355555555: `333333333.3333333`,
+			0x41b3de4355555556: `333333333.3333334`,
+			0x41b3de4355555557: `333333333.33333343`,
+			0xbecbf647612f3696: `-0.0000033333333333333333`,
+			0x43143ff3c1cb0959: `1424953923781206.2`,
+		}
+		for bits, expected := range testcases {
+			f := math.Float64frombits(bits)
+			canonical, err := json.Canonicalize([]byte(strconv.FormatFloat(f, 'g', -1, 64)))
+			require.NoError(t, err, `json.Canonicalize should succeed`)
+			require.Equal(t, expected, string(canonical), `value %016x`, bits)
+		}
+	})
+	t.Run("invalid input", func(t *testing.T) {
+		_, err := json.Canonicalize([]byte(`{"a":1} {"b":2}`))
+		require.Error(t, err, `trailing data should be rejected`)
+
+		_, err = json.Canonicalize([]byte(`{"a":`))
+		require.Error(t, err, `truncated input should be rejected`)
+	})
+	t.Run("duplicate member names", func(t *testing.T) {
+		for _, src := range []string{
+			`{"a":1,"a":2}`,
+			`{"a":1,"\u0061":2}`,
+			`[{"b":{"a":1,"a":1}}]`,
+		} {
+			_, err := json.Canonicalize([]byte(src))
+			require.Error(t, err, `duplicate member names should be rejected (%s)`, src)
+		}
+
+		canonical, err := json.Canonicalize([]byte(`{"a":{"a":1},"b":[{"a":1},{"a":2}]}`))
+		require.NoError(t, err, `the same name in different objects should be accepted`)
+		require.Equal(t, `{"a":{"a":1},"b":[{"a":1},{"a":2}]}`, string(canonical))
+	})
+	t.Run("invalid UTF-8", func(t *testing.T) {
+		for _, src := range []string{
+			"{\"a\":\"\xff\"}",
+			"{\"\xc3\x28\":1}",
+			`{"a":"\ud800"}`,
+			`{"a":"\udc00\ud800"}`,
+			`{"a":"\ud800\u0041"}`,
+		} {
+			_, err := json.Canonicalize([]byte(src))
+			require.Error(t, err, `invalid UTF-8 should be rejected (%q)`, src)
+		}
+
+		canonical, err := json.Canonicalize([]byte(`{"a":"\\ud800","b":"\ud83d\ude00"}`))
+		require.NoError(t, err, `escaped backslashes and surrogate pairs should be accepted`)
+		require.Equal(t, "{\"a\":\"\\\\ud800\",\"b\":\"\U0001F600\"}", string(canonical))
+	})
+}
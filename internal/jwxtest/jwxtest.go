@@ -15,12 +15,16 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/sjwl/jwx/v2/ed448"
 	"github.com/sjwl/jwx/v2/internal/ecutil"
+	"github.com/sjwl/jwx/v2/internal/mldsa"
+	"github.com/sjwl/jwx/v2/internal/mlkem"
 	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/sjwl/jwx/v2/jwe"
 	"github.com/sjwl/jwx/v2/jwk"
 	"github.com/sjwl/jwx/v2/jws"
 	"github.com/sjwl/jwx/v2/x25519"
+	"github.com/sjwl/jwx/v2/x448"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -138,6 +142,80 @@ func GenerateX25519Jwk() (jwk.Key, error) {
 	return k, nil
 }
 
+func GenerateEd448Key() (ed448.PrivateKey, error) {
+	_, priv, err := ed448.GenerateKey(rand.Reader)
+	return priv, err
+}
+
+func GenerateEd448Jwk() (jwk.Key, error) {
+	key, err := GenerateEd448Key()
+	if err != nil {
+		return nil, fmt.Errorf(`failed to generate Ed448 private key: %w`, err)
+	}
+
+	k, err := jwk.FromRaw(key)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to generate jwk.OKPPrivateKey: %w`, err)
+	}
+
+	return k, nil
+}
+
+func GenerateX448Key() (x448.PrivateKey, error) {
+	_, priv, err := x448.GenerateKey(rand.Reader)
+	return priv, err
+}
+
+func GenerateX448Jwk() (jwk.Key, error) {
+	key, err := GenerateX448Key()
+	if err != nil {
+		return nil, fmt.Errorf(`failed to generate X448 private key: %w`, err)
+	}
+
+	k, err := jwk.FromRaw(key)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to generate jwk.OKPPrivateKey: %w`, err)
+	}
+
+	return k, nil
+}
+
+func GenerateMLDSAKey(params *mldsa.Parameters) (*mldsa.PrivateKey, error) {
+	return mldsa.GenerateKey(params, rand.Reader)
+}
+
+func GenerateMLDSAJwk(params *mldsa.Parameters) (jwk.Key, error) {
+	key, err := GenerateMLDSAKey(params)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to generate %s private key: %w`, params, err)
+	}
+
+	k, err := jwk.FromRaw(key)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to generate jwk.AKPPrivateKey: %w`, err)
+	}
+
+	return k, nil
+}
+
+func GenerateMLKEMKey(params *mlkem.Parameters) (*mlkem.DecapsulationKey, error) {
+	return mlkem.GenerateKey(params, rand.Reader)
+}
+
+func GenerateMLKEMJwk(params *mlkem.Parameters) (jwk.Key, error) {
+	key, err := GenerateMLKEMKey(params)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to generate %s private key: %w`, params, err)
+	}
+
+	k, err := jwk.FromRaw(key)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to generate jwk.AKPPrivateKey: %w`, err)
+	}
+
+	return k, nil
+}
+
 func WriteFile(template string, src io.Reader) (string, func(), error) {
 	file, cleanup, err := CreateTempFile(template)
 	if err != nil {
@@ -274,7 +352,8 @@ func DecryptJweFile(ctx context.Context, file string, alg jwa.KeyEncryptionAlgor
 		return nil, fmt.Errorf(`failed to obtain raw key from JWK: %w`, err)
 	}
 
-	return jwe.Decrypt(buf, jwe.WithKey(alg, rawkey))
+	// interop tests include RSA1_5, which must be enabled explicitly
+	return jwe.Decrypt(buf, jwe.WithKey(alg, rawkey), jwe.WithAllowRSA1_5(alg == jwa.RSA1_5))
 }
 
 func EncryptJweFile(ctx context.Context, payload []byte, keyalg jwa.KeyEncryptionAlgorithm, keyfile string, contentalg jwa.ContentEncryptionAlgorithm, compressalg jwa.CompressionAlgorithm) (string, func(), error) {
@@ -286,7 +365,7 @@ func EncryptJweFile(ctx context.Context, payload []byte, keyalg jwa.KeyEncryptio
 	var keyif interface{}
 
 	switch keyalg {
-	case jwa.RSA1_5, jwa.RSA_OAEP, jwa.RSA_OAEP_256:
+	case jwa.RSA1_5, jwa.RSA_OAEP, jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512:
 		var rawkey rsa.PrivateKey
 		if err := key.Raw(&rawkey); err != nil {
 			return "", nil, fmt.Errorf(`failed to obtain raw key: %w`, err)
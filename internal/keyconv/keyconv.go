@@ -7,6 +7,8 @@ import (
 	"fmt"
 
 	"github.com/lestrrat-go/blackmagic"
+	"github.com/sjwl/jwx/v2/ed448"
+	"github.com/sjwl/jwx/v2/internal/mldsa"
 	"github.com/sjwl/jwx/v2/jwk"
 	"golang.org/x/crypto/ed25519"
 )
@@ -175,3 +177,115 @@ func Ed25519PublicKey(dst, src interface{}) error {
 	}
 	return blackmagic.AssignIfCompatible(dst, ptr)
 }
+
+func Ed448PrivateKey(dst, src interface{}) error {
+	if jwkKey, ok := src.(jwk.Key); ok {
+		var raw ed448.PrivateKey
+		if err := jwkKey.Raw(&raw); err != nil {
+			return fmt.Errorf(`failed to produce ed448.PrivateKey from %T: %w`, src, err)
+		}
+		src = &raw
+	}
+
+	var ptr *ed448.PrivateKey
+	switch src := src.(type) {
+	case ed448.PrivateKey:
+		ptr = &src
+	case *ed448.PrivateKey:
+		ptr = src
+	default:
+		return fmt.Errorf(`expected ed448.PrivateKey or *ed448.PrivateKey, got %T`, src)
+	}
+	return blackmagic.AssignIfCompatible(dst, ptr)
+}
+
+func Ed448PublicKey(dst, src interface{}) error {
+	if jwkKey, ok := src.(jwk.Key); ok {
+		var raw ed448.PublicKey
+		if err := jwkKey.Raw(&raw); err != nil {
+			return fmt.Errorf(`failed to produce ed448.PublicKey from %T: %w`, src, err)
+		}
+		src = &raw
+	}
+
+	var ptr *ed448.PublicKey
+	switch src := src.(type) {
+	case ed448.PublicKey:
+		ptr = &src
+	case *ed448.PublicKey:
+		ptr = src
+	case *crypto.PublicKey:
+		tmp, ok := (*src).(ed448.PublicKey)
+		if !ok {
+			return fmt.Errorf(`failed to retrieve ed448.PublicKey out of *crypto.PublicKey`)
+		}
+		ptr = &tmp
+	case crypto.PublicKey:
+		tmp, ok := src.(ed448.PublicKey)
+		if !ok {
+			return fmt.Errorf(`failed to retrieve ed448.PublicKey out of crypto.PublicKey`)
+		}
+		ptr = &tmp
+	default:
+		return fmt.Errorf(`expected ed448.PublicKey or *ed448.PublicKey, got %T`, src)
+	}
+	return blackmagic.AssignIfCompatible(dst, ptr)
+}
+
+// MLDSAPrivateKey assigns src to dst.
+// `dst` should be a pointer to a *mldsa.PrivateKey variable.
+// `src` may be *mldsa.PrivateKey, or a jwk.Key
+func MLDSAPrivateKey(dst, src interface{}) error {
+	if jwkKey, ok := src.(jwk.Key); ok {
+		var raw *mldsa.PrivateKey
+		if err := jwkKey.Raw(&raw); err != nil {
+			return fmt.Errorf(`failed to produce *mldsa.PrivateKey from %T: %w`, src, err)
+		}
+		src = raw
+	}
+
+	ptr, ok := src.(*mldsa.PrivateKey)
+	if !ok || ptr == nil {
+		return fmt.Errorf(`expected *mldsa.PrivateKey, got %T`, src)
+	}
+	if dstptr, ok := dst.(**mldsa.PrivateKey); ok {
+		*dstptr = ptr
+		return nil
+	}
+	return blackmagic.AssignIfCompatible(dst, ptr)
+}
+
+// MLDSAPublicKey assigns src to dst.
+// `dst` should be a pointer to a *mldsa.PublicKey variable.
+// `src` may be *mldsa.PublicKey, or a jwk.Key
+func MLDSAPublicKey(dst, src interface{}) error {
+	if jwkKey, ok := src.(jwk.Key); ok {
+		var raw *mldsa.PublicKey
+		if err := jwkKey.Raw(&raw); err != nil {
+			return fmt.Errorf(`failed to produce *mldsa.PublicKey from %T: %w`, src, err)
+		}
+		src = raw
+	}
+
+	var ptr *mldsa.PublicKey
+	switch src := src.(type) {
+	case *mldsa.PublicKey:
+		ptr = src
+	case *crypto.PublicKey:
+		tmp, ok := (*src).(*mldsa.PublicKey)
+		if !ok {
+			return fmt.Errorf(`failed to retrieve *mldsa.PublicKey out of *crypto.PublicKey`)
+		}
+		ptr = tmp
+	default:
+		return fmt.Errorf(`expected *mldsa.PublicKey, got %T`, src)
+	}
+	if ptr == nil {
+		return fmt.Errorf(`expected *mldsa.PublicKey, got nil`)
+	}
+	if dstptr, ok := dst.(**mldsa.PublicKey); ok {
+		*dstptr = ptr
+		return nil
+	}
+	return blackmagic.AssignIfCompatible(dst, ptr)
+}
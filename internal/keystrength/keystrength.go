@@ -0,0 +1,115 @@
+// Package keystrength implements the checks for `jwa.KeyStrengthPolicy`
+// that are shared between jws and jwe
+package keystrength
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"fmt"
+
+	"github.com/sjwl/jwx/v2/ed448"
+	"github.com/sjwl/jwx/v2/internal/ecutil"
+	"github.com/sjwl/jwx/v2/jwa"
+	"github.com/sjwl/jwx/v2/jwk"
+	"github.com/sjwl/jwx/v2/x25519"
+	"github.com/sjwl/jwx/v2/x448"
+	"golang.org/x/crypto/ed25519"
+)
+
+// publicKeyer is implemented by opaque private keys, such as crypto.Signer,
+// crypto.Decrypter, and jwe.KeyAgreement
+type publicKeyer interface {
+	Public() crypto.PublicKey
+}
+
+// Check verifies that `key` is strong enough to be used with `alg`
+// according to the policy `p`. `key` may be a raw key, a jwk.Key,
+// or an opaque private key such as a crypto.Signer, a crypto.Decrypter,
+// or a jwe.KeyAgreement.
+//
+// Symmetric keys are checked against the HMAC requirements when `alg`
+// is a signature algorithm, and against the AES requirements when `alg`
+// is a key wrapping algorithm with a fixed key size (e.g. A128KW).
+// Other symmetric keys, such as PBES2 passwords and the content encryption
+// key given to "dir", are not checked here.
+func Check(p *jwa.KeyStrengthPolicy, alg jwa.KeyAlgorithm, key interface{}) error {
+	if p == nil || key == nil {
+		return nil
+	}
+
+	var raw interface{}
+	switch key := key.(type) {
+	case jwk.Key:
+		if err := key.Raw(&raw); err != nil {
+			return fmt.Errorf(`failed to retrieve raw key from %T: %w`, key, err)
+		}
+	default:
+		raw = key
+	}
+
+	pubkey, err := jwk.PublicRawKeyOf(raw)
+	if err != nil {
+		// opaque keys such as those stored in a KMS
+		v, ok := raw.(publicKeyer)
+		if !ok {
+			return fmt.Errorf(`failed to retrieve public key from %T: %w`, raw, err)
+		}
+		pubkey = v.Public()
+	}
+
+	switch pubkey := pubkey.(type) {
+	case *rsa.PublicKey:
+		return p.CheckRSAKeySize(pubkey.N.BitLen())
+	case *ecdsa.PublicKey:
+		crv, ok := ecutil.AlgorithmForCurve(pubkey.Curve)
+		if !ok {
+			return fmt.Errorf(`unknown elliptic curve %q`, pubkey.Curve.Params().Name)
+		}
+		return p.CheckCurve(crv)
+	case ed25519.PublicKey:
+		return p.CheckCurve(jwa.Ed25519)
+	case x25519.PublicKey:
+		return p.CheckCurve(jwa.X25519)
+	case ed448.PublicKey:
+		return p.CheckCurve(jwa.Ed448)
+	case x448.PublicKey:
+		return p.CheckCurve(jwa.X448)
+	case []byte:
+		return checkSymmetric(p, alg, len(pubkey)*8)
+	}
+	return nil
+}
+
+func checkSymmetric(p *jwa.KeyStrengthPolicy, alg jwa.KeyAlgorithm, bits int) error {
+	switch alg := alg.(type) {
+	case jwa.SignatureAlgorithm:
+		return p.CheckHMACKeySize(alg, bits)
+	case jwa.KeyEncryptionAlgorithm:
+		if md, ok := alg.Metadata(); ok && md.Symmetric && md.KeySize > 0 {
+			return p.CheckAESKeySize(bits)
+		}
+	}
+	return nil
+}
+
+// CheckContentEncryption verifies that the AES key used by the content
+// encryption algorithm `enc` is strong enough according to the policy `p`
+func CheckContentEncryption(p *jwa.KeyStrengthPolicy, enc jwa.ContentEncryptionAlgorithm) error {
+	if p == nil {
+		return nil
+	}
+
+	md, ok := enc.Metadata()
+	if !ok || md.KeySize == 0 {
+		return nil
+	}
+
+	// For AES_CBC_HMAC_SHA2 algorithms, half of the content encryption
+	// key is used for the HMAC, and the other half is used for AES
+	bits := md.KeySize
+	if md.Hash != 0 {
+		bits /= 2
+	}
+	return p.CheckAESKeySize(bits)
+}
@@ -0,0 +1,516 @@
+// Package mldsa implements the ML-DSA post-quantum signature algorithm
+// (FIPS 204), with the ML-DSA-44, ML-DSA-65, and ML-DSA-87 parameter sets.
+//
+// Private keys are represented by their 32 byte seed, which is also the
+// representation used by JOSE. Only "pure" ML-DSA with an empty context
+// string is supported, which is what is used by JOSE.
+//
+// This package is EXPERIMENTAL. It is a straightforward implementation
+// of the specification, which has not been audited or hardened against
+// side-channel attacks, and is only provided for interoperability with
+// systems that require ML-DSA. It is tested against the NIST ACVP
+// vectors for FIPS 204, and is internal so that it is only used through
+// the AKP key type.
+package mldsa
+
+import (
+	"bytes"
+	"crypto"
+	cryptorand "crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// SeedSize is the size, in bytes, of private key seeds
+const SeedSize = 32
+
+// Parameters represents one of the parameter sets defined in FIPS 204.
+// Values are obtained using MLDSA44(), MLDSA65(), and MLDSA87(), and
+// can be compared using ==
+type Parameters struct {
+	name       string
+	k, l       int
+	eta        int
+	tau        int
+	beta       uint32
+	gamma1Bits int
+	gamma2     uint32
+	omega      int
+	lambda     int
+}
+
+var params44 = &Parameters{
+	name:       "ML-DSA-44",
+	k:          4,
+	l:          4,
+	eta:        2,
+	tau:        39,
+	beta:       78,
+	gamma1Bits: 17,
+	gamma2:     (q - 1) / 88,
+	omega:      80,
+	lambda:     128,
+}
+
+var params65 = &Parameters{
+	name:       "ML-DSA-65",
+	k:          6,
+	l:          5,
+	eta:        4,
+	tau:        49,
+	beta:       196,
+	gamma1Bits: 19,
+	gamma2:     (q - 1) / 32,
+	omega:      55,
+	lambda:     192,
+}
+
+var params87 = &Parameters{
+	name:       "ML-DSA-87",
+	k:          8,
+	l:          7,
+	eta:        2,
+	tau:        60,
+	beta:       120,
+	gamma1Bits: 19,
+	gamma2:     (q - 1) / 32,
+	omega:      75,
+	lambda:     256,
+}
+
+// MLDSA44 returns the ML-DSA-44 parameter set
+func MLDSA44() *Parameters {
+	return params44
+}
+
+// MLDSA65 returns the ML-DSA-65 parameter set
+func MLDSA65() *Parameters {
+	return params65
+}
+
+// MLDSA87 returns the ML-DSA-87 parameter set
+func MLDSA87() *Parameters {
+	return params87
+}
+
+// String returns the name of the parameter set, e.g. "ML-DSA-44"
+func (p *Parameters) String() string {
+	return p.name
+}
+
+// PublicKeySize returns the size, in bytes, of public keys
+func (p *Parameters) PublicKeySize() int {
+	return 32 + 32*10*p.k
+}
+
+// SignatureSize returns the size, in bytes, of signatures
+func (p *Parameters) SignatureSize() int {
+	return p.lambda/4 + 32*(p.gamma1Bits+1)*p.l + p.omega + p.k
+}
+
+// w1Bits is the number of bits used to encode the coefficients of w1
+func (p *Parameters) w1Bits() int {
+	if p.gamma2 == (q-1)/88 {
+		return 6
+	}
+	return 4
+}
+
+// PublicKey is an ML-DSA public key
+type PublicKey struct {
+	params *Parameters
+	raw    []byte
+	tr     [64]byte
+	a      []poly // the matrix A, in NTT form
+	t1     []poly // t1 * 2^d, in NTT form
+}
+
+// NewPublicKey creates a public key from its encoded form
+func NewPublicKey(params *Parameters, b []byte) (*PublicKey, error) {
+	if params == nil {
+		return nil, errors.New(`mldsa: missing parameters`)
+	}
+	if len(b) != params.PublicKeySize() {
+		return nil, fmt.Errorf(`mldsa: invalid public key size for %s: %d`, params, len(b))
+	}
+
+	pk := &PublicKey{
+		params: params,
+		raw:    append([]byte(nil), b...),
+		a:      expandA(b[:32], params.k, params.l),
+		t1:     make([]poly, params.k),
+	}
+	for i := range pk.t1 {
+		unpackBits(&pk.t1[i], b[32+320*i:32+320*(i+1)], 10)
+		for j, c := range pk.t1[i] {
+			pk.t1[i][j] = c << d
+		}
+		pk.t1[i].ntt()
+	}
+	shake256(pk.tr[:], b)
+	return pk, nil
+}
+
+// Bytes returns the encoded form of the public key
+func (pk *PublicKey) Bytes() []byte {
+	return append([]byte(nil), pk.raw...)
+}
+
+// Parameters returns the parameter set of the public key
+func (pk *PublicKey) Parameters() *Parameters {
+	return pk.params
+}
+
+// Equal reports whether pk and x are the same key
+func (pk *PublicKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*PublicKey)
+	if !ok {
+		return false
+	}
+	return pk.params == xx.params && bytes.Equal(pk.raw, xx.raw)
+}
+
+// PrivateKey is an ML-DSA private key. It implements crypto.Signer
+type PrivateKey struct {
+	seed [SeedSize]byte
+	pub  *PublicKey
+	key  [32]byte
+	s1   []poly // in NTT form
+	s2   []poly // in NTT form
+	t0   []poly // in NTT form
+}
+
+// GenerateKey generates a new private key using entropy from rand.
+// If rand is nil, crypto/rand.Reader will be used.
+func GenerateKey(params *Parameters, rand io.Reader) (*PrivateKey, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+
+	seed := make([]byte, SeedSize)
+	if _, err := io.ReadFull(rand, seed); err != nil {
+		return nil, fmt.Errorf(`mldsa: failed to read seed: %w`, err)
+	}
+	return NewPrivateKey(params, seed)
+}
+
+// NewPrivateKey derives a private key from a 32 byte seed
+// (FIPS 204, Algorithm 6)
+func NewPrivateKey(params *Parameters, seed []byte) (*PrivateKey, error) {
+	if params == nil {
+		return nil, errors.New(`mldsa: missing parameters`)
+	}
+	if len(seed) != SeedSize {
+		return nil, fmt.Errorf(`mldsa: invalid seed size: %d`, len(seed))
+	}
+
+	sk := &PrivateKey{}
+	copy(sk.seed[:], seed)
+
+	var expanded [128]byte
+	shake256(expanded[:], seed, []byte{byte(params.k), byte(params.l)})
+	rho, rhoPrime := expanded[:32], expanded[32:96]
+	copy(sk.key[:], expanded[96:])
+
+	s1 := make([]poly, params.l)
+	for i := range s1 {
+		rejBoundedPoly(&s1[i], rhoPrime, uint16(i), params.eta)
+	}
+	s2 := make([]poly, params.k)
+	for i := range s2 {
+		rejBoundedPoly(&s2[i], rhoPrime, uint16(params.l+i), params.eta)
+	}
+	sk.s1 = nttVector(s1)
+	sk.s2 = nttVector(s2)
+
+	a := expandA(rho, params.k, params.l)
+	t := mulMatrix(a, sk.s1, params.k)
+
+	raw := make([]byte, 0, params.PublicKeySize())
+	raw = append(raw, rho...)
+	t0 := make([]poly, params.k)
+	for i := range t {
+		t[i].invNTT()
+		t[i].add(&t[i], &s2[i])
+
+		var t1 poly
+		for j, c := range t[i] {
+			r1, r0 := power2Round(c)
+			t1[j] = r1
+			t0[i][j] = fieldFromInt(r0)
+		}
+		raw = packBits(raw, &t1, 10)
+	}
+	sk.t0 = nttVector(t0)
+
+	pub, err := NewPublicKey(params, raw)
+	if err != nil {
+		return nil, err
+	}
+	sk.pub = pub
+	return sk, nil
+}
+
+// Bytes returns the seed of the private key
+func (sk *PrivateKey) Bytes() []byte {
+	return append([]byte(nil), sk.seed[:]...)
+}
+
+// Parameters returns the parameter set of the private key
+func (sk *PrivateKey) Parameters() *Parameters {
+	return sk.pub.params
+}
+
+// Public returns the public key corresponding to sk, as a *PublicKey
+func (sk *PrivateKey) Public() crypto.PublicKey {
+	return sk.pub
+}
+
+// PublicKey returns the public key corresponding to sk
+func (sk *PrivateKey) PublicKey() *PublicKey {
+	return sk.pub
+}
+
+// Equal reports whether sk and x are the same key
+func (sk *PrivateKey) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*PrivateKey)
+	if !ok {
+		return false
+	}
+	return sk.pub.params == xx.pub.params && subtle.ConstantTimeCompare(sk.seed[:], xx.seed[:]) == 1
+}
+
+// Sign signs message with sk. opts.HashFunc() must return zero, as
+// only pure ML-DSA is supported.
+//
+// The signature is randomized using 32 bytes read from rand. If rand
+// is nil, the deterministic variant of ML-DSA is used instead.
+func (sk *PrivateKey) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts != nil && opts.HashFunc() != crypto.Hash(0) {
+		return nil, errors.New(`mldsa: cannot sign hashed message`)
+	}
+
+	var rnd [32]byte
+	if rand != nil {
+		if _, err := io.ReadFull(rand, rnd[:]); err != nil {
+			return nil, fmt.Errorf(`mldsa: failed to read randomness: %w`, err)
+		}
+	}
+	return sk.sign(message, rnd[:]), nil
+}
+
+// messageRepresentative computes mu for message, using an empty
+// context string (FIPS 204, Algorithms 2 and 3)
+func (pk *PublicKey) messageRepresentative(message []byte) []byte {
+	mu := make([]byte, 64)
+	shake256(mu, pk.tr[:], []byte{0, 0}, message)
+	return mu
+}
+
+// sign implements ML-DSA.Sign with an empty context string
+// (FIPS 204, Algorithm 2)
+func (sk *PrivateKey) sign(message, rnd []byte) []byte {
+	return sk.signInternal(sk.pub.messageRepresentative(message), rnd)
+}
+
+// signInternal implements ML-DSA.Sign_internal, given the message
+// representative mu (FIPS 204, Algorithm 7)
+func (sk *PrivateKey) signInternal(mu, rnd []byte) []byte {
+	params := sk.pub.params
+	k, l := params.k, params.l
+	gamma1 := uint32(1) << params.gamma1Bits
+
+	var rhoPrime [64]byte
+	shake256(rhoPrime[:], sk.key[:], rnd, mu)
+
+	ctilde := make([]byte, params.lambda/4)
+	w1 := make([]byte, 0, 32*params.w1Bits()*k)
+	y := make([]poly, l)
+	var c, t poly
+	for kappa := 0; ; kappa += l {
+		for i := range y {
+			expandMask(&y[i], rhoPrime[:], uint16(kappa+i), params.gamma1Bits)
+		}
+		w := mulMatrix(sk.pub.a, nttVector(y), k)
+
+		w1 = w1[:0]
+		for i := range w {
+			w[i].invNTT()
+			var hi poly
+			for j, coef := range w[i] {
+				hi[j] = highBits(coef, params.gamma2)
+			}
+			w1 = packBits(w1, &hi, params.w1Bits())
+		}
+		shake256(ctilde, mu, w1)
+
+		sampleInBall(&c, ctilde, params.tau)
+		c.ntt()
+
+		// z = y + c * s1
+		z := make([]poly, l)
+		reject := false
+		for i := range z {
+			t.mulNTT(&c, &sk.s1[i])
+			t.invNTT()
+			z[i].add(&y[i], &t)
+			reject = z[i].exceeds(gamma1-params.beta) || reject
+		}
+		if reject {
+			continue
+		}
+
+		// r = w - c * s2, whose low bits must be small
+		r := make([]poly, k)
+		for i := range r {
+			t.mulNTT(&c, &sk.s2[i])
+			t.invNTT()
+			r[i].sub(&w[i], &t)
+			var lo poly
+			for j, coef := range r[i] {
+				_, r0 := decompose(coef, params.gamma2)
+				lo[j] = fieldFromInt(r0)
+			}
+			reject = lo.exceeds(params.gamma2-params.beta) || reject
+		}
+		if reject {
+			continue
+		}
+
+		// h = MakeHint(-c * t0, r + c * t0)
+		h := make([]poly, k)
+		hints := 0
+		for i := range h {
+			t.mulNTT(&c, &sk.t0[i])
+			t.invNTT()
+			reject = t.exceeds(params.gamma2) || reject
+			for j := range h[i] {
+				h[i][j] = makeHint(fieldSub(0, t[j]), fieldAdd(r[i][j], t[j]), params.gamma2)
+				hints += int(h[i][j])
+			}
+		}
+		if reject || hints > params.omega {
+			continue
+		}
+
+		return sk.pub.params.encodeSignature(ctilde, z, h)
+	}
+}
+
+// encodeSignature encodes a signature (FIPS 204, Algorithm 26)
+func (p *Parameters) encodeSignature(ctilde []byte, z, h []poly) []byte {
+	sig := make([]byte, 0, p.SignatureSize())
+	sig = append(sig, ctilde...)
+	for i := range z {
+		sig = packGamma1(sig, &z[i], p.gamma1Bits)
+	}
+
+	hints := make([]byte, p.omega+p.k)
+	index := 0
+	for i := range h {
+		for j, v := range h[i] {
+			if v != 0 {
+				hints[index] = byte(j)
+				index++
+			}
+		}
+		hints[p.omega+i] = byte(index)
+	}
+	return append(sig, hints...)
+}
+
+// decodeHints decodes the hints in a signature, rejecting encodings
+// that are not canonical (FIPS 204, Algorithm 21)
+func (p *Parameters) decodeHints(b []byte) ([]poly, bool) {
+	h := make([]poly, p.k)
+	index := 0
+	for i := 0; i < p.k; i++ {
+		limit := int(b[p.omega+i])
+		if limit < index || limit > p.omega {
+			return nil, false
+		}
+		first := index
+		for ; index < limit; index++ {
+			if index > first && b[index-1] >= b[index] {
+				return nil, false
+			}
+			h[i][b[index]] = 1
+		}
+	}
+	for ; index < p.omega; index++ {
+		if b[index] != 0 {
+			return nil, false
+		}
+	}
+	return h, true
+}
+
+// Verify reports whether sig is a valid signature of message by pk
+// (FIPS 204, Algorithm 3)
+func Verify(pk *PublicKey, message, sig []byte) bool {
+	if pk == nil {
+		return false
+	}
+	return verifyInternal(pk, pk.messageRepresentative(message), sig)
+}
+
+// verifyInternal implements ML-DSA.Verify_internal, given the message
+// representative mu (FIPS 204, Algorithm 8)
+func verifyInternal(pk *PublicKey, mu, sig []byte) bool {
+	params := pk.params
+	if len(sig) != params.SignatureSize() {
+		return false
+	}
+	k, l := params.k, params.l
+
+	ctilde := sig[:params.lambda/4]
+	zLen := 32 * (params.gamma1Bits + 1)
+	z := make([]poly, l)
+	for i := range z {
+		offset := len(ctilde) + zLen*i
+		unpackGamma1(&z[i], sig[offset:offset+zLen], params.gamma1Bits)
+		if z[i].exceeds(uint32(1)<<params.gamma1Bits - params.beta) {
+			return false
+		}
+	}
+	h, ok := params.decodeHints(sig[len(ctilde)+zLen*l:])
+	if !ok {
+		return false
+	}
+
+	var c, t poly
+	sampleInBall(&c, ctilde, params.tau)
+	c.ntt()
+
+	// w' = A * z - c * t1 * 2^d
+	w := mulMatrix(pk.a, nttVector(z), k)
+	w1 := make([]byte, 0, 32*params.w1Bits()*k)
+	for i := range w {
+		t.mulNTT(&c, &pk.t1[i])
+		w[i].sub(&w[i], &t)
+		w[i].invNTT()
+
+		var hi poly
+		for j, coef := range w[i] {
+			hi[j] = useHint(h[i][j], coef, params.gamma2)
+		}
+		w1 = packBits(w1, &hi, params.w1Bits())
+	}
+
+	expected := make([]byte, len(ctilde))
+	shake256(expected, mu, w1)
+	return subtle.ConstantTimeCompare(ctilde, expected) == 1
+}
+
+// shake256 fills out with the SHAKE256 output of the concatenation of inputs
+func shake256(out []byte, inputs ...[]byte) {
+	h := sha3.NewShake256()
+	for _, in := range inputs {
+		_, _ = h.Write(in)
+	}
+	_, _ = h.Read(out)
+}
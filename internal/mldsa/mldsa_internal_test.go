@@ -0,0 +1,238 @@
+package mldsa
+
+import (
+	"compress/bzip2"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The NIST ACVP vector sets, and their expected results (see testdata/README)
+type acvpVectorSet struct {
+	VsID       int             `json:"vsId"`
+	Mode       string          `json:"mode"`
+	TestGroups []acvpTestGroup `json:"testGroups"`
+}
+
+type acvpTestGroup struct {
+	TgID               int        `json:"tgId"`
+	ParameterSet       string     `json:"parameterSet"`
+	SignatureInterface string     `json:"signatureInterface"`
+	PreHash            string     `json:"preHash"`
+	Deterministic      bool       `json:"deterministic"`
+	ExternalMu         bool       `json:"externalMu"`
+	Tests              []acvpTest `json:"tests"`
+}
+
+type acvpTest struct {
+	TcID       int      `json:"tcId"`
+	Seed       hexBytes `json:"seed"`
+	PK         hexBytes `json:"pk"`
+	SK         hexBytes `json:"sk"`
+	Message    hexBytes `json:"message"`
+	Context    hexBytes `json:"context"`
+	Mu         hexBytes `json:"mu"`
+	Rnd        hexBytes `json:"rnd"`
+	Signature  hexBytes `json:"signature"`
+	TestPassed *bool    `json:"testPassed"`
+}
+
+type hexBytes []byte
+
+func (b *hexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+func loadACVP(t *testing.T, name string) []acvpVectorSet {
+	t.Helper()
+	f, err := os.Open(name)
+	require.NoError(t, err, `os.Open should succeed`)
+	defer f.Close()
+
+	// the first element describes the test session, and is skipped
+	var sets []acvpVectorSet
+	require.NoError(t, json.NewDecoder(bzip2.NewReader(f)).Decode(&sets), `json.Decode should succeed`)
+	require.True(t, len(sets) > 1, `there should be vector sets`)
+	return sets[1:]
+}
+
+// acvpCase is a test case, combined with its expected result
+type acvpCase struct {
+	group    *acvpTestGroup
+	test     *acvpTest
+	expected *acvpTest
+}
+
+func loadACVPCases(t *testing.T) map[string][]acvpCase {
+	t.Helper()
+	expected := make(map[string]*acvpTest)
+	for _, set := range loadACVP(t, `testdata/expected.json.bz2`) {
+		for _, group := range set.TestGroups {
+			for i := range group.Tests {
+				expected[fmt.Sprintf(`%d/%d/%d`, set.VsID, group.TgID, group.Tests[i].TcID)] = &group.Tests[i]
+			}
+		}
+	}
+
+	cases := make(map[string][]acvpCase)
+	for _, set := range loadACVP(t, `testdata/vectors.json.bz2`) {
+		for i := range set.TestGroups {
+			group := &set.TestGroups[i]
+			for j := range group.Tests {
+				test := &group.Tests[j]
+				result, ok := expected[fmt.Sprintf(`%d/%d/%d`, set.VsID, group.TgID, test.TcID)]
+				require.True(t, ok, `expected result for test case %d should exist`, test.TcID)
+				cases[set.Mode] = append(cases[set.Mode], acvpCase{group: group, test: test, expected: result})
+			}
+		}
+	}
+	return cases
+}
+
+func acvpParameters(t *testing.T, name string) *Parameters {
+	t.Helper()
+	for _, params := range []*Parameters{params44, params65, params87} {
+		if params.name == name {
+			return params
+		}
+	}
+	require.Fail(t, `unknown parameter set`, name)
+	return nil
+}
+
+// decodePrivateKey decodes the expanded form of a private key, which is
+// used by the vectors (FIPS 204, Algorithm 25). The public key is only
+// populated with the values that are needed to sign
+func decodePrivateKey(params *Parameters, b []byte) *PrivateKey {
+	k, l := params.k, params.l
+	etaBits := 3
+	if params.eta == 4 {
+		etaBits = 4
+	}
+
+	sk := &PrivateKey{
+		pub: &PublicKey{
+			params: params,
+			a:      expandA(b[:32], k, l),
+		},
+	}
+	copy(sk.key[:], b[32:64])
+	copy(sk.pub.tr[:], b[64:128])
+	b = b[128:]
+
+	decodeEta := func(n int) []poly {
+		v := make([]poly, n)
+		for i := range v {
+			unpackBits(&v[i], b[:32*etaBits], etaBits)
+			b = b[32*etaBits:]
+			for j, c := range v[i] {
+				v[i][j] = fieldFromInt(int32(params.eta) - int32(c))
+			}
+		}
+		return nttVector(v)
+	}
+	sk.s1 = decodeEta(l)
+	sk.s2 = decodeEta(k)
+
+	t0 := make([]poly, k)
+	for i := range t0 {
+		unpackBits(&t0[i], b[:32*d], d)
+		b = b[32*d:]
+		for j, c := range t0[i] {
+			t0[i][j] = fieldFromInt(1<<(d-1) - int32(c))
+		}
+	}
+	sk.t0 = nttVector(t0)
+	return sk
+}
+
+// acvpMessageRepresentative computes mu for the external interface, which
+// includes the context string (FIPS 204, Algorithms 2 and 3)
+func acvpMessageRepresentative(tr []byte, c *acvpCase) []byte {
+	if c.group.ExternalMu {
+		return c.test.Mu
+	}
+	mu := make([]byte, 64)
+	shake256(mu, tr, []byte{0, byte(len(c.test.Context))}, c.test.Context, c.test.Message)
+	return mu
+}
+
+func TestACVP(t *testing.T) {
+	cases := loadACVPCases(t)
+
+	t.Run("keyGen", func(t *testing.T) {
+		require.NotEmpty(t, cases[`keyGen`])
+		for _, c := range cases[`keyGen`] {
+			c := c
+			t.Run(fmt.Sprintf(`%s/%d`, c.group.ParameterSet, c.test.TcID), func(t *testing.T) {
+				params := acvpParameters(t, c.group.ParameterSet)
+				sk, err := NewPrivateKey(params, c.test.Seed)
+				require.NoError(t, err, `NewPrivateKey should succeed`)
+				require.Equal(t, []byte(c.expected.PK), sk.PublicKey().Bytes(), `public keys should match`)
+
+				expected := decodePrivateKey(params, c.expected.SK)
+				require.Equal(t, expected.key, sk.key, `K should match`)
+				require.Equal(t, expected.pub.tr, sk.pub.tr, `tr should match`)
+				require.Equal(t, expected.s1, sk.s1, `s1 should match`)
+				require.Equal(t, expected.s2, sk.s2, `s2 should match`)
+				require.Equal(t, expected.t0, sk.t0, `t0 should match`)
+			})
+		}
+	})
+	t.Run("sigGen", func(t *testing.T) {
+		require.NotEmpty(t, cases[`sigGen`])
+		for _, c := range cases[`sigGen`] {
+			c := c
+			t.Run(fmt.Sprintf(`%s/%s/%d`, c.group.ParameterSet, c.group.SignatureInterface, c.test.TcID), func(t *testing.T) {
+				require.True(t, c.group.SignatureInterface == `internal` || c.group.PreHash == `pure`, `only pure ML-DSA is supported`)
+				sk := decodePrivateKey(acvpParameters(t, c.group.ParameterSet), c.test.SK)
+				rnd := make([]byte, 32)
+				if !c.group.Deterministic {
+					rnd = c.test.Rnd
+				}
+				sig := sk.signInternal(acvpMessageRepresentative(sk.pub.tr[:], &c), rnd)
+				require.Equal(t, []byte(c.expected.Signature), sig, `signatures should match`)
+			})
+		}
+	})
+	t.Run("sigVer", func(t *testing.T) {
+		require.NotEmpty(t, cases[`sigVer`])
+		for _, c := range cases[`sigVer`] {
+			c := c
+			t.Run(fmt.Sprintf(`%s/%s/%d`, c.group.ParameterSet, c.group.SignatureInterface, c.test.TcID), func(t *testing.T) {
+				require.True(t, c.group.SignatureInterface == `internal` || c.group.PreHash == `pure`, `only pure ML-DSA is supported`)
+				require.NotNil(t, c.expected.TestPassed, `expected result should be specified`)
+				pk, err := NewPublicKey(acvpParameters(t, c.group.ParameterSet), c.test.PK)
+				require.NoError(t, err, `NewPublicKey should succeed`)
+				ok := verifyInternal(pk, acvpMessageRepresentative(pk.tr[:], &c), c.test.Signature)
+				require.Equal(t, *c.expected.TestPassed, ok, `verification result should match`)
+
+				if !c.group.ExternalMu && len(c.test.Context) == 0 {
+					require.Equal(t, ok, Verify(pk, c.test.Message, c.test.Signature), `Verify should agree`)
+				}
+			})
+		}
+	})
+}
+
+// The pure ML-DSA functions use an empty context string
+func TestMessageRepresentative(t *testing.T) {
+	sk, err := NewPrivateKey(params44, make([]byte, SeedSize))
+	require.NoError(t, err, `NewPrivateKey should succeed`)
+	msg := []byte(`Lorem ipsum`)
+	c := acvpCase{group: &acvpTestGroup{}, test: &acvpTest{Message: msg}}
+	require.Equal(t, acvpMessageRepresentative(sk.pub.tr[:], &c), sk.pub.messageRepresentative(msg))
+}
@@ -0,0 +1,54 @@
+package mldsa_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"testing"
+
+	"github.com/sjwl/jwx/v2/internal/mldsa"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVerify(t *testing.T) {
+	for _, params := range []*mldsa.Parameters{mldsa.MLDSA44(), mldsa.MLDSA65(), mldsa.MLDSA87()} {
+		params := params
+		t.Run(params.String(), func(t *testing.T) {
+			priv, err := mldsa.GenerateKey(params, rand.Reader)
+			require.NoError(t, err, `mldsa.GenerateKey should succeed`)
+			pub, ok := priv.Public().(*mldsa.PublicKey)
+			require.True(t, ok, `priv.Public() should return *mldsa.PublicKey`)
+
+			msg := []byte(`Lorem ipsum`)
+			sig1, err := priv.Sign(rand.Reader, msg, crypto.Hash(0))
+			require.NoError(t, err, `priv.Sign should succeed`)
+			sig2, err := priv.Sign(rand.Reader, msg, crypto.Hash(0))
+			require.NoError(t, err, `priv.Sign should succeed`)
+			require.NotEqual(t, sig1, sig2, `randomized signatures should differ`)
+			require.True(t, mldsa.Verify(pub, msg, sig1), `mldsa.Verify should succeed`)
+			require.True(t, mldsa.Verify(pub, msg, sig2), `mldsa.Verify should succeed`)
+
+			require.False(t, mldsa.Verify(pub, []byte(`Lorem ipsum!`), sig1), `mldsa.Verify should fail for a different message`)
+			for _, i := range []int{0, len(sig1) / 2, len(sig1) - 1} {
+				tampered := append([]byte(nil), sig1...)
+				tampered[i] ^= 0x01
+				require.False(t, mldsa.Verify(pub, msg, tampered), `mldsa.Verify should fail for a modified signature`)
+			}
+			require.False(t, mldsa.Verify(pub, msg, sig1[:len(sig1)-1]), `mldsa.Verify should fail for a truncated signature`)
+
+			other, err := mldsa.GenerateKey(params, rand.Reader)
+			require.NoError(t, err, `mldsa.GenerateKey should succeed`)
+			require.False(t, mldsa.Verify(other.PublicKey(), msg, sig1), `mldsa.Verify should fail for a different key`)
+			require.False(t, other.Equal(priv), `different keys should not be equal`)
+
+			_, err = priv.Sign(rand.Reader, msg, crypto.SHA256)
+			require.Error(t, err, `priv.Sign should fail for hashed messages`)
+		})
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	_, err := mldsa.NewPrivateKey(mldsa.MLDSA44(), make([]byte, 31))
+	require.Error(t, err, `mldsa.NewPrivateKey should fail for a short seed`)
+	_, err = mldsa.NewPublicKey(mldsa.MLDSA65(), make([]byte, mldsa.MLDSA44().PublicKeySize()))
+	require.Error(t, err, `mldsa.NewPublicKey should fail for a key of the wrong size`)
+}
@@ -0,0 +1,388 @@
+package mldsa
+
+import (
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	n = 256
+	q = 8380417
+	d = 13
+
+	// nInv is 256^-1 mod q, used to scale the result of the inverse NTT
+	nInv = 8347681
+	// zeta is the 512th root of unity used by the NTT
+	zeta = 1753
+)
+
+// poly is a polynomial in R_q = Z_q[X]/(X^256 + 1). Coefficients are
+// always kept in the range [0, q). The same type is used for
+// polynomials in NTT form
+type poly [n]uint32
+
+// zetas holds zeta^brv(k) mod q for k = 0...255, where brv reverses
+// the 8 bits of k
+var zetas = func() (z [n]uint32) {
+	for k := 0; k < n; k++ {
+		var brv int
+		for i := 0; i < 8; i++ {
+			brv |= ((k >> i) & 1) << (7 - i)
+		}
+		v := uint64(1)
+		for i := 0; i < brv; i++ {
+			v = v * zeta % q
+		}
+		z[k] = uint32(v)
+	}
+	return
+}()
+
+func fieldAdd(a, b uint32) uint32 {
+	x := a + b - q
+	return x + (uint32(int32(x)>>31) & q)
+}
+
+func fieldSub(a, b uint32) uint32 {
+	x := a - b
+	return x + (uint32(int32(x)>>31) & q)
+}
+
+func fieldMul(a, b uint32) uint32 {
+	return uint32(uint64(a) * uint64(b) % q)
+}
+
+// fieldFromInt converts a value in (-q, q) to a field element
+func fieldFromInt(v int32) uint32 {
+	return uint32(v) + (uint32(v>>31) & q)
+}
+
+// centered returns the representative of a in (-(q-1)/2, (q-1)/2]
+func centered(a uint32) int32 {
+	x := int32(a)
+	return x - (q & ((int32((q-1)/2) - x) >> 31))
+}
+
+// abs returns the absolute value of the centered representative of a
+func abs(a uint32) uint32 {
+	x := centered(a)
+	mask := x >> 31
+	return uint32((x ^ mask) - mask)
+}
+
+// ntt computes the number-theoretic transform of f in place (FIPS 204, Algorithm 41)
+func (f *poly) ntt() {
+	m := 0
+	for length := 128; length >= 1; length /= 2 {
+		for start := 0; start < n; start += 2 * length {
+			m++
+			z := zetas[m]
+			for j := start; j < start+length; j++ {
+				t := fieldMul(z, f[j+length])
+				f[j+length] = fieldSub(f[j], t)
+				f[j] = fieldAdd(f[j], t)
+			}
+		}
+	}
+}
+
+// invNTT computes the inverse of the number-theoretic transform of f in
+// place (FIPS 204, Algorithm 42)
+func (f *poly) invNTT() {
+	m := n
+	for length := 1; length < n; length *= 2 {
+		for start := 0; start < n; start += 2 * length {
+			m--
+			z := q - zetas[m]
+			for j := start; j < start+length; j++ {
+				t := f[j]
+				f[j] = fieldAdd(t, f[j+length])
+				f[j+length] = fieldMul(z, fieldSub(t, f[j+length]))
+			}
+		}
+	}
+	for j := range f {
+		f[j] = fieldMul(f[j], nInv)
+	}
+}
+
+func (f *poly) add(a, b *poly) {
+	for i := range f {
+		f[i] = fieldAdd(a[i], b[i])
+	}
+}
+
+func (f *poly) sub(a, b *poly) {
+	for i := range f {
+		f[i] = fieldSub(a[i], b[i])
+	}
+}
+
+// mulNTT multiplies two polynomials in NTT form
+func (f *poly) mulNTT(a, b *poly) {
+	for i := range f {
+		f[i] = fieldMul(a[i], b[i])
+	}
+}
+
+// exceeds returns true if the infinity norm of f is at least bound
+func (f *poly) exceeds(bound uint32) bool {
+	var result uint32
+	for _, c := range f {
+		// set the top bit of result if abs(c) >= bound
+		result |= bound - 1 - abs(c)
+	}
+	return result>>31 == 1
+}
+
+// nttVector returns the NTT of each polynomial in v
+func nttVector(v []poly) []poly {
+	out := make([]poly, len(v))
+	for i := range v {
+		out[i] = v[i]
+		out[i].ntt()
+	}
+	return out
+}
+
+// mulMatrix computes A * v, where A is a k x l matrix stored in row
+// major order, and v has l elements. All values are in NTT form
+func mulMatrix(a []poly, v []poly, k int) []poly {
+	l := len(v)
+	out := make([]poly, k)
+	var t poly
+	for i := 0; i < k; i++ {
+		for j := 0; j < l; j++ {
+			t.mulNTT(&a[i*l+j], &v[j])
+			out[i].add(&out[i], &t)
+		}
+	}
+	return out
+}
+
+// expandA samples the k x l matrix A from rho (FIPS 204, Algorithm 32)
+func expandA(rho []byte, k, l int) []poly {
+	a := make([]poly, k*l)
+	for r := 0; r < k; r++ {
+		for s := 0; s < l; s++ {
+			rejNTTPoly(&a[r*l+s], rho, byte(s), byte(r))
+		}
+	}
+	return a
+}
+
+// rejNTTPoly samples a polynomial in NTT form with uniformly random
+// coefficients (FIPS 204, Algorithm 30)
+func rejNTTPoly(f *poly, rho []byte, s, r byte) {
+	h := sha3.NewShake128()
+	_, _ = h.Write(rho)
+	_, _ = h.Write([]byte{s, r})
+
+	var buf [168]byte
+	j := 0
+	for j < n {
+		_, _ = h.Read(buf[:])
+		for i := 0; i < len(buf) && j < n; i += 3 {
+			c := uint32(buf[i]) | uint32(buf[i+1])<<8 | uint32(buf[i+2]&0x7f)<<16
+			if c < q {
+				f[j] = c
+				j++
+			}
+		}
+	}
+}
+
+// rejBoundedPoly samples a polynomial with coefficients in [-eta, eta]
+// (FIPS 204, Algorithm 31)
+func rejBoundedPoly(f *poly, rho []byte, r uint16, eta int) {
+	h := sha3.NewShake256()
+	_, _ = h.Write(rho)
+	_, _ = h.Write([]byte{byte(r), byte(r >> 8)})
+
+	var buf [136]byte
+	j := 0
+	for j < n {
+		_, _ = h.Read(buf[:])
+		for i := 0; i < len(buf) && j < n; i++ {
+			for _, b := range []byte{buf[i] & 0x0f, buf[i] >> 4} {
+				if j >= n {
+					break
+				}
+				switch {
+				case eta == 2 && b < 15:
+					f[j] = fieldFromInt(2 - int32(b%5))
+					j++
+				case eta == 4 && b < 9:
+					f[j] = fieldFromInt(4 - int32(b))
+					j++
+				}
+			}
+		}
+	}
+}
+
+// expandMask samples a polynomial with coefficients in (-gamma1, gamma1]
+// (FIPS 204, Algorithm 34)
+func expandMask(f *poly, rho []byte, mu uint16, gamma1Bits int) {
+	h := sha3.NewShake256()
+	_, _ = h.Write(rho)
+	_, _ = h.Write([]byte{byte(mu), byte(mu >> 8)})
+
+	buf := make([]byte, 32*(gamma1Bits+1))
+	_, _ = h.Read(buf)
+	unpackGamma1(f, buf, gamma1Bits)
+}
+
+// sampleInBall samples a polynomial with tau coefficients set to
+// 1 or -1, and the rest set to 0 (FIPS 204, Algorithm 29)
+func sampleInBall(f *poly, seed []byte, tau int) {
+	h := sha3.NewShake256()
+	_, _ = h.Write(seed)
+
+	var buf [136]byte
+	_, _ = h.Read(buf[:])
+	var signs uint64
+	for i := 0; i < 8; i++ {
+		signs |= uint64(buf[i]) << (8 * i)
+	}
+	pos := 8
+
+	*f = poly{}
+	for i := n - tau; i < n; i++ {
+		var j int
+		for {
+			if pos == len(buf) {
+				_, _ = h.Read(buf[:])
+				pos = 0
+			}
+			j = int(buf[pos])
+			pos++
+			if j <= i {
+				break
+			}
+		}
+		f[i] = f[j]
+		f[j] = 1 + (uint32(-(signs & 1)) & (q - 2))
+		signs >>= 1
+	}
+}
+
+// power2Round splits r into r1 * 2^d + r0, where r0 is in
+// (-2^(d-1), 2^(d-1)] (FIPS 204, Algorithm 35)
+func power2Round(r uint32) (uint32, int32) {
+	r0 := int32(r & (1<<d - 1))
+	r0 -= (1 << d) & ((1<<(d-1) - r0) >> 31)
+	return uint32(int32(r)-r0) >> d, r0
+}
+
+// decompose splits r into r1 * 2 * gamma2 + r0, where r0 is in
+// (-gamma2, gamma2] (FIPS 204, Algorithm 36)
+func decompose(r uint32, gamma2 uint32) (uint32, int32) {
+	var r0 int32
+	var r1 uint32
+	// the divisions are written out for each parameter, so that they
+	// are compiled to multiplications
+	if gamma2 == (q-1)/88 {
+		r0 = int32(r % (2 * ((q - 1) / 88)))
+		r0 -= 2 * ((q - 1) / 88) & (((q-1)/88 - r0) >> 31)
+		r1 = uint32(int32(r)-r0) / (2 * ((q - 1) / 88))
+	} else {
+		r0 = int32(r % (2 * ((q - 1) / 32)))
+		r0 -= 2 * ((q - 1) / 32) & (((q-1)/32 - r0) >> 31)
+		r1 = uint32(int32(r)-r0) / (2 * ((q - 1) / 32))
+	}
+
+	// if r - r0 == q - 1, then r1 = 0 and r0 = r0 - 1
+	m := (q - 1) / (2 * gamma2)
+	isEdge := uint32(int32(m-1-r1) >> 31)
+	r1 &^= isEdge
+	r0 -= int32(isEdge & 1)
+	return r1, r0
+}
+
+func highBits(r uint32, gamma2 uint32) uint32 {
+	r1, _ := decompose(r, gamma2)
+	return r1
+}
+
+// makeHint returns 1 if adding z to r changes the high bits of r
+// (FIPS 204, Algorithm 39)
+func makeHint(z, r uint32, gamma2 uint32) uint32 {
+	r1 := highBits(r, gamma2)
+	v1 := highBits(fieldAdd(r, z), gamma2)
+	x := r1 ^ v1
+	return (x | -x) >> 31
+}
+
+// useHint returns the high bits of r, adjusted according to the hint h
+// (FIPS 204, Algorithm 40)
+func useHint(h uint32, r uint32, gamma2 uint32) uint32 {
+	m := (q - 1) / (2 * gamma2)
+	r1, r0 := decompose(r, gamma2)
+	if h == 0 {
+		return r1
+	}
+	if r0 > 0 {
+		if r1 == m-1 {
+			return 0
+		}
+		return r1 + 1
+	}
+	if r1 == 0 {
+		return m - 1
+	}
+	return r1 - 1
+}
+
+// packBits appends the coefficients of f to dst, using the given number
+// of bits per coefficient in little-endian bit order
+// (FIPS 204, Algorithms 16 and 17)
+func packBits(dst []byte, f *poly, bits int) []byte {
+	var acc uint64
+	var accBits int
+	for _, c := range f {
+		acc |= uint64(c) << accBits
+		accBits += bits
+		for accBits >= 8 {
+			dst = append(dst, byte(acc))
+			acc >>= 8
+			accBits -= 8
+		}
+	}
+	return dst
+}
+
+// unpackBits is the inverse of packBits. src must contain exactly
+// 32 * bits bytes
+func unpackBits(f *poly, src []byte, bits int) {
+	var acc uint64
+	var accBits int
+	mask := uint64(1)<<bits - 1
+	j := 0
+	for _, b := range src {
+		acc |= uint64(b) << accBits
+		accBits += 8
+		for accBits >= bits {
+			f[j] = uint32(acc & mask)
+			j++
+			acc >>= bits
+			accBits -= bits
+		}
+	}
+}
+
+// packGamma1 appends the coefficients of f, which are in
+// (-gamma1, gamma1], as gamma1 - c
+func packGamma1(dst []byte, f *poly, gamma1Bits int) []byte {
+	var t poly
+	for i, c := range f {
+		t[i] = fieldSub(1<<gamma1Bits, c)
+	}
+	return packBits(dst, &t, gamma1Bits+1)
+}
+
+func unpackGamma1(f *poly, src []byte, gamma1Bits int) {
+	unpackBits(f, src, gamma1Bits+1)
+	for i, c := range f {
+		f[i] = fieldSub(1<<gamma1Bits, c)
+	}
+}
@@ -0,0 +1,347 @@
+// Package mlkem implements the ML-KEM post-quantum key encapsulation
+// mechanism (FIPS 203), with the ML-KEM-768 and ML-KEM-1024 parameter sets.
+//
+// Decapsulation keys are represented by their 64 byte seed, which is also
+// the representation used by JOSE.
+//
+// This package is EXPERIMENTAL. It is a straightforward implementation
+// of the specification, which has not been audited or hardened against
+// side-channel attacks, and is only provided for interoperability with
+// systems that require ML-KEM. It is tested against the NIST ACVP
+// vectors for FIPS 203, and is internal so that it is only used through
+// the AKP key type.
+package mlkem
+
+import (
+	"bytes"
+	"crypto"
+	cryptorand "crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// SeedSize is the size, in bytes, of decapsulation key seeds
+	SeedSize = 64
+	// SharedKeySize is the size, in bytes, of shared keys
+	SharedKeySize = 32
+)
+
+// Parameters represents one of the parameter sets defined in FIPS 203.
+// Values are obtained using MLKEM768() and MLKEM1024(), and can be
+// compared using ==
+type Parameters struct {
+	name   string
+	k      int
+	du, dv int
+}
+
+var params768 = &Parameters{
+	name: "ML-KEM-768",
+	k:    3,
+	du:   10,
+	dv:   4,
+}
+
+var params1024 = &Parameters{
+	name: "ML-KEM-1024",
+	k:    4,
+	du:   11,
+	dv:   5,
+}
+
+// MLKEM768 returns the ML-KEM-768 parameter set
+func MLKEM768() *Parameters {
+	return params768
+}
+
+// MLKEM1024 returns the ML-KEM-1024 parameter set
+func MLKEM1024() *Parameters {
+	return params1024
+}
+
+// String returns the name of the parameter set, e.g. "ML-KEM-768"
+func (p *Parameters) String() string {
+	return p.name
+}
+
+// EncapsulationKeySize returns the size, in bytes, of encapsulation keys
+func (p *Parameters) EncapsulationKeySize() int {
+	return 384*p.k + 32
+}
+
+// CiphertextSize returns the size, in bytes, of ciphertexts
+func (p *Parameters) CiphertextSize() int {
+	return 32 * (p.du*p.k + p.dv)
+}
+
+// EncapsulationKey is an ML-KEM encapsulation (public) key
+type EncapsulationKey struct {
+	params *Parameters
+	raw    []byte
+	h      [32]byte
+	a      []poly // the matrix A, in NTT form
+	t      []poly // in NTT form
+}
+
+// NewEncapsulationKey creates an encapsulation key from its encoded form
+func NewEncapsulationKey(params *Parameters, b []byte) (*EncapsulationKey, error) {
+	if params == nil {
+		return nil, errors.New(`mlkem: missing parameters`)
+	}
+	if len(b) != params.EncapsulationKeySize() {
+		return nil, fmt.Errorf(`mlkem: invalid encapsulation key size for %s: %d`, params, len(b))
+	}
+
+	ek := &EncapsulationKey{
+		params: params,
+		raw:    append([]byte(nil), b...),
+		t:      make([]poly, params.k),
+	}
+	for i := range ek.t {
+		unpackBits(&ek.t[i], b[384*i:384*(i+1)], 12)
+		// FIPS 203, Section 7.2: the coefficients must be reduced mod q
+		for _, c := range ek.t[i] {
+			if c >= q {
+				return nil, errors.New(`mlkem: invalid encapsulation key`)
+			}
+		}
+	}
+	ek.a = expandA(b[384*params.k:], params.k)
+	ek.h = sha3.Sum256(b)
+	return ek, nil
+}
+
+// Bytes returns the encoded form of the encapsulation key
+func (ek *EncapsulationKey) Bytes() []byte {
+	return append([]byte(nil), ek.raw...)
+}
+
+// Parameters returns the parameter set of the encapsulation key
+func (ek *EncapsulationKey) Parameters() *Parameters {
+	return ek.params
+}
+
+// Equal reports whether ek and x are the same key
+func (ek *EncapsulationKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*EncapsulationKey)
+	if !ok {
+		return false
+	}
+	return ek.params == xx.params && bytes.Equal(ek.raw, xx.raw)
+}
+
+// Encapsulate generates a shared key and the ciphertext that can be
+// used by the holder of the decapsulation key to recover it, using
+// entropy from rand. If rand is nil, crypto/rand.Reader will be used.
+func (ek *EncapsulationKey) Encapsulate(rand io.Reader) (sharedKey, ciphertext []byte, err error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+
+	var m [32]byte
+	if _, err := io.ReadFull(rand, m[:]); err != nil {
+		return nil, nil, fmt.Errorf(`mlkem: failed to read randomness: %w`, err)
+	}
+	sharedKey, ciphertext = ek.encapsulate(m[:])
+	return sharedKey, ciphertext, nil
+}
+
+// encapsulate implements ML-KEM.Encaps_internal (FIPS 203, Algorithm 17)
+func (ek *EncapsulationKey) encapsulate(m []byte) ([]byte, []byte) {
+	g := sha3.Sum512(append(append([]byte(nil), m...), ek.h[:]...))
+	return g[:32], ek.encrypt(m, g[32:])
+}
+
+// encrypt implements K-PKE.Encrypt (FIPS 203, Algorithm 14)
+func (ek *EncapsulationKey) encrypt(m, r []byte) []byte {
+	k := ek.params.k
+	var counter byte
+
+	y := make([]poly, k)
+	for i := range y {
+		samplePolyCBD(&y[i], r, counter)
+		counter++
+		y[i].ntt()
+	}
+
+	out := make([]byte, 0, ek.params.CiphertextSize())
+
+	col := make([]poly, k)
+	for i := 0; i < k; i++ {
+		var e1 poly
+		samplePolyCBD(&e1, r, counter)
+		counter++
+
+		// u[i] = (A^T * y)[i] + e1
+		for j := 0; j < k; j++ {
+			col[j] = ek.a[j*k+i]
+		}
+		u := dot(col, y)
+		u.invNTT()
+		u.add(&u, &e1)
+		out = packCompressed(out, &u, ek.params.du)
+	}
+
+	var e2 poly
+	samplePolyCBD(&e2, r, counter)
+
+	var mu poly
+	unpackBits(&mu, m, 1)
+	for i, c := range mu {
+		mu[i] = decompress(c, 1)
+	}
+
+	v := dot(ek.t, y)
+	v.invNTT()
+	v.add(&v, &e2)
+	v.add(&v, &mu)
+	return packCompressed(out, &v, ek.params.dv)
+}
+
+// DecapsulationKey is an ML-KEM decapsulation (private) key
+type DecapsulationKey struct {
+	seed [SeedSize]byte
+	ek   *EncapsulationKey
+	s    []poly // in NTT form
+}
+
+// GenerateKey generates a new decapsulation key using entropy from rand.
+// If rand is nil, crypto/rand.Reader will be used.
+func GenerateKey(params *Parameters, rand io.Reader) (*DecapsulationKey, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+
+	seed := make([]byte, SeedSize)
+	if _, err := io.ReadFull(rand, seed); err != nil {
+		return nil, fmt.Errorf(`mlkem: failed to read seed: %w`, err)
+	}
+	return NewDecapsulationKey(params, seed)
+}
+
+// NewDecapsulationKey derives a decapsulation key from a 64 byte seed,
+// which is the concatenation of d and z (FIPS 203, Algorithms 13 and 16)
+func NewDecapsulationKey(params *Parameters, seed []byte) (*DecapsulationKey, error) {
+	if params == nil {
+		return nil, errors.New(`mlkem: missing parameters`)
+	}
+	if len(seed) != SeedSize {
+		return nil, fmt.Errorf(`mlkem: invalid seed size: %d`, len(seed))
+	}
+
+	dk := &DecapsulationKey{}
+	copy(dk.seed[:], seed)
+
+	g := sha3.Sum512(append(append([]byte(nil), seed[:32]...), byte(params.k)))
+	rho, sigma := g[:32], g[32:]
+
+	var counter byte
+	dk.s = make([]poly, params.k)
+	for i := range dk.s {
+		samplePolyCBD(&dk.s[i], sigma, counter)
+		counter++
+		dk.s[i].ntt()
+	}
+
+	a := expandA(rho, params.k)
+	raw := make([]byte, 0, params.EncapsulationKeySize())
+	for i := 0; i < params.k; i++ {
+		var e poly
+		samplePolyCBD(&e, sigma, counter)
+		counter++
+		e.ntt()
+
+		t := dot(a[i*params.k:(i+1)*params.k], dk.s)
+		t.add(&t, &e)
+		raw = packBits(raw, &t, 12)
+	}
+	raw = append(raw, rho...)
+
+	ek, err := NewEncapsulationKey(params, raw)
+	if err != nil {
+		return nil, err
+	}
+	dk.ek = ek
+	return dk, nil
+}
+
+// Bytes returns the seed of the decapsulation key
+func (dk *DecapsulationKey) Bytes() []byte {
+	return append([]byte(nil), dk.seed[:]...)
+}
+
+// Parameters returns the parameter set of the decapsulation key
+func (dk *DecapsulationKey) Parameters() *Parameters {
+	return dk.ek.params
+}
+
+// Public returns the encapsulation key corresponding to dk, as
+// an *EncapsulationKey
+func (dk *DecapsulationKey) Public() crypto.PublicKey {
+	return dk.ek
+}
+
+// EncapsulationKey returns the encapsulation key corresponding to dk
+func (dk *DecapsulationKey) EncapsulationKey() *EncapsulationKey {
+	return dk.ek
+}
+
+// Equal reports whether dk and x are the same key
+func (dk *DecapsulationKey) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*DecapsulationKey)
+	if !ok {
+		return false
+	}
+	return dk.ek.params == xx.ek.params && subtle.ConstantTimeCompare(dk.seed[:], xx.seed[:]) == 1
+}
+
+// Decapsulate recovers the shared key from the ciphertext. As specified
+// by ML-KEM, an invalid ciphertext results in a pseudorandom shared key
+// rather than an error (FIPS 203, Algorithm 18)
+func (dk *DecapsulationKey) Decapsulate(ciphertext []byte) ([]byte, error) {
+	params := dk.ek.params
+	if len(ciphertext) != params.CiphertextSize() {
+		return nil, fmt.Errorf(`mlkem: invalid ciphertext size for %s: %d`, params, len(ciphertext))
+	}
+
+	m := dk.decrypt(ciphertext)
+	g := sha3.Sum512(append(m, dk.ek.h[:]...))
+	sharedKey, r := g[:32], g[32:]
+
+	// implicit rejection: J(z || c)
+	var rejected [32]byte
+	h := sha3.NewShake256()
+	_, _ = h.Write(dk.seed[32:])
+	_, _ = h.Write(ciphertext)
+	_, _ = h.Read(rejected[:])
+
+	expected := dk.ek.encrypt(m, r)
+	subtle.ConstantTimeCopy(1-subtle.ConstantTimeCompare(ciphertext, expected), sharedKey, rejected[:])
+	return sharedKey, nil
+}
+
+// decrypt implements K-PKE.Decrypt (FIPS 203, Algorithm 15)
+func (dk *DecapsulationKey) decrypt(c []byte) []byte {
+	params := dk.ek.params
+	k := params.k
+	usize := 32 * params.du
+
+	u := make([]poly, k)
+	for i := range u {
+		unpackCompressed(&u[i], c[usize*i:usize*(i+1)], params.du)
+		u[i].ntt()
+	}
+
+	var v poly
+	unpackCompressed(&v, c[usize*k:], params.dv)
+
+	w := dot(dk.s, u)
+	w.invNTT()
+	w.sub(&v, &w)
+	return packCompressed(nil, &w, 1)
+}
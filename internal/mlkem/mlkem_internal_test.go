@@ -0,0 +1,183 @@
+package mlkem
+
+import (
+	"compress/bzip2"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The NIST ACVP vector sets, and their expected results (see testdata/README)
+type acvpVectorSet struct {
+	VsID       int             `json:"vsId"`
+	Mode       string          `json:"mode"`
+	TestGroups []acvpTestGroup `json:"testGroups"`
+}
+
+type acvpTestGroup struct {
+	TgID         int        `json:"tgId"`
+	ParameterSet string     `json:"parameterSet"`
+	Function     string     `json:"function"`
+	Tests        []acvpTest `json:"tests"`
+}
+
+type acvpTest struct {
+	TcID int      `json:"tcId"`
+	D    hexBytes `json:"d"`
+	Z    hexBytes `json:"z"`
+	EK   hexBytes `json:"ek"`
+	DK   hexBytes `json:"dk"`
+	M    hexBytes `json:"m"`
+	C    hexBytes `json:"c"`
+	K    hexBytes `json:"k"`
+}
+
+type hexBytes []byte
+
+func (b *hexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+func loadACVP(t *testing.T, name string) []acvpVectorSet {
+	t.Helper()
+	f, err := os.Open(name)
+	require.NoError(t, err, `os.Open should succeed`)
+	defer f.Close()
+
+	// the first element describes the test session, and is skipped
+	var sets []acvpVectorSet
+	require.NoError(t, json.NewDecoder(bzip2.NewReader(f)).Decode(&sets), `json.Decode should succeed`)
+	require.True(t, len(sets) > 1, `there should be vector sets`)
+	return sets[1:]
+}
+
+// acvpCase is a test case, combined with its expected result
+type acvpCase struct {
+	group    *acvpTestGroup
+	test     *acvpTest
+	expected *acvpTest
+}
+
+func loadACVPCases(t *testing.T) map[string][]acvpCase {
+	t.Helper()
+	expected := make(map[string]*acvpTest)
+	for _, set := range loadACVP(t, `testdata/expected.json.bz2`) {
+		for _, group := range set.TestGroups {
+			for i := range group.Tests {
+				expected[fmt.Sprintf(`%d/%d/%d`, set.VsID, group.TgID, group.Tests[i].TcID)] = &group.Tests[i]
+			}
+		}
+	}
+
+	cases := make(map[string][]acvpCase)
+	for _, set := range loadACVP(t, `testdata/vectors.json.bz2`) {
+		for i := range set.TestGroups {
+			group := &set.TestGroups[i]
+			for j := range group.Tests {
+				test := &group.Tests[j]
+				result, ok := expected[fmt.Sprintf(`%d/%d/%d`, set.VsID, group.TgID, test.TcID)]
+				require.True(t, ok, `expected result for test case %d should exist`, test.TcID)
+				mode := set.Mode
+				if group.Function != "" {
+					mode = group.Function
+				}
+				cases[mode] = append(cases[mode], acvpCase{group: group, test: test, expected: result})
+			}
+		}
+	}
+	return cases
+}
+
+func acvpParameters(t *testing.T, name string) *Parameters {
+	t.Helper()
+	for _, params := range []*Parameters{params768, params1024} {
+		if params.name == name {
+			return params
+		}
+	}
+	require.Fail(t, `unknown parameter set`, name)
+	return nil
+}
+
+// decodeDecapsulationKey decodes the expanded form of a decapsulation key,
+// which is used by the vectors (FIPS 203, Algorithm 16). d is not part of
+// the expanded form, so only the last 32 bytes of the seed are populated
+func decodeDecapsulationKey(t *testing.T, params *Parameters, b []byte) *DecapsulationKey {
+	t.Helper()
+	k := params.k
+	ekSize := params.EncapsulationKeySize()
+	require.Len(t, b, 384*k+ekSize+64, `decapsulation key size should match`)
+
+	ek, err := NewEncapsulationKey(params, b[384*k:384*k+ekSize])
+	require.NoError(t, err, `NewEncapsulationKey should succeed`)
+	require.Equal(t, ek.h[:], b[384*k+ekSize:384*k+ekSize+32], `H(ek) should match`)
+
+	dk := &DecapsulationKey{
+		ek: ek,
+		s:  make([]poly, k),
+	}
+	for i := range dk.s {
+		unpackBits(&dk.s[i], b[384*i:384*(i+1)], 12)
+	}
+	copy(dk.seed[32:], b[384*k+ekSize+32:])
+	return dk
+}
+
+func TestACVP(t *testing.T) {
+	cases := loadACVPCases(t)
+
+	t.Run("keyGen", func(t *testing.T) {
+		require.NotEmpty(t, cases[`keyGen`])
+		for _, c := range cases[`keyGen`] {
+			c := c
+			t.Run(fmt.Sprintf(`%s/%d`, c.group.ParameterSet, c.test.TcID), func(t *testing.T) {
+				params := acvpParameters(t, c.group.ParameterSet)
+				dk, err := NewDecapsulationKey(params, append(append([]byte(nil), c.test.D...), c.test.Z...))
+				require.NoError(t, err, `NewDecapsulationKey should succeed`)
+				require.Equal(t, []byte(c.expected.EK), dk.EncapsulationKey().Bytes(), `encapsulation keys should match`)
+
+				expected := decodeDecapsulationKey(t, params, c.expected.DK)
+				require.Equal(t, expected.s, dk.s, `s should match`)
+				require.Equal(t, expected.seed[32:], dk.seed[32:], `z should match`)
+			})
+		}
+	})
+	t.Run("encapsulation", func(t *testing.T) {
+		require.NotEmpty(t, cases[`encapsulation`])
+		for _, c := range cases[`encapsulation`] {
+			c := c
+			t.Run(fmt.Sprintf(`%s/%d`, c.group.ParameterSet, c.test.TcID), func(t *testing.T) {
+				ek, err := NewEncapsulationKey(acvpParameters(t, c.group.ParameterSet), c.test.EK)
+				require.NoError(t, err, `NewEncapsulationKey should succeed`)
+				sharedKey, ciphertext := ek.encapsulate(c.test.M)
+				require.Equal(t, []byte(c.expected.C), ciphertext, `ciphertexts should match`)
+				require.Equal(t, []byte(c.expected.K), sharedKey, `shared keys should match`)
+			})
+		}
+	})
+	t.Run("decapsulation", func(t *testing.T) {
+		require.NotEmpty(t, cases[`decapsulation`])
+		for _, c := range cases[`decapsulation`] {
+			c := c
+			t.Run(fmt.Sprintf(`%s/%d`, c.group.ParameterSet, c.test.TcID), func(t *testing.T) {
+				dk := decodeDecapsulationKey(t, acvpParameters(t, c.group.ParameterSet), c.test.DK)
+				sharedKey, err := dk.Decapsulate(c.test.C)
+				require.NoError(t, err, `Decapsulate should succeed`)
+				require.Equal(t, []byte(c.expected.K), sharedKey, `shared keys should match`)
+			})
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package mlkem_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/sjwl/jwx/v2/internal/mlkem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncapsulateDecapsulate(t *testing.T) {
+	for _, params := range []*mlkem.Parameters{mlkem.MLKEM768(), mlkem.MLKEM1024()} {
+		params := params
+		t.Run(params.String(), func(t *testing.T) {
+			dk, err := mlkem.GenerateKey(params, rand.Reader)
+			require.NoError(t, err, `mlkem.GenerateKey should succeed`)
+			ek, ok := dk.Public().(*mlkem.EncapsulationKey)
+			require.True(t, ok, `dk.Public() should return *mlkem.EncapsulationKey`)
+
+			sharedKey, ciphertext, err := ek.Encapsulate(nil)
+			require.NoError(t, err, `ek.Encapsulate should succeed`)
+			require.Len(t, sharedKey, mlkem.SharedKeySize)
+
+			decapsulated, err := dk.Decapsulate(ciphertext)
+			require.NoError(t, err, `dk.Decapsulate should succeed`)
+			require.Equal(t, sharedKey, decapsulated)
+
+			// implicit rejection: a modified ciphertext yields a different,
+			// but deterministic, shared key
+			tampered := append([]byte(nil), ciphertext...)
+			tampered[0] ^= 0x01
+			rejected, err := dk.Decapsulate(tampered)
+			require.NoError(t, err, `dk.Decapsulate should succeed`)
+			require.NotEqual(t, sharedKey, rejected)
+			rejected2, err := dk.Decapsulate(tampered)
+			require.NoError(t, err, `dk.Decapsulate should succeed`)
+			require.Equal(t, rejected, rejected2)
+
+			_, err = dk.Decapsulate(ciphertext[:len(ciphertext)-1])
+			require.Error(t, err, `dk.Decapsulate should fail for a truncated ciphertext`)
+
+			other, err := mlkem.GenerateKey(params, rand.Reader)
+			require.NoError(t, err, `mlkem.GenerateKey should succeed`)
+			require.False(t, other.Equal(dk), `different keys should not be equal`)
+			require.False(t, other.EncapsulationKey().Equal(ek), `different keys should not be equal`)
+		})
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	_, err := mlkem.NewDecapsulationKey(mlkem.MLKEM768(), make([]byte, 32))
+	require.Error(t, err, `mlkem.NewDecapsulationKey should fail for a short seed`)
+	_, err = mlkem.NewEncapsulationKey(mlkem.MLKEM1024(), make([]byte, mlkem.MLKEM768().EncapsulationKeySize()))
+	require.Error(t, err, `mlkem.NewEncapsulationKey should fail for a key of the wrong size`)
+
+	// coefficients that are not reduced modulo q must be rejected
+	invalid := make([]byte, mlkem.MLKEM768().EncapsulationKeySize())
+	for i := 0; i < 384; i++ {
+		invalid[i] = 0xff
+	}
+	_, err = mlkem.NewEncapsulationKey(mlkem.MLKEM768(), invalid)
+	require.Error(t, err, `mlkem.NewEncapsulationKey should fail for unreduced coefficients`)
+}
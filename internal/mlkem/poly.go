@@ -0,0 +1,253 @@
+package mlkem
+
+import (
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	n = 256
+	q = 3329
+
+	// nInv is 128^-1 mod q, used to scale the result of the inverse NTT
+	nInv = 3303
+	// zeta is the 256th root of unity used by the NTT
+	zeta = 17
+)
+
+// poly is a polynomial in R_q = Z_q[X]/(X^256 + 1). Coefficients are
+// always kept in the range [0, q). The same type is used for
+// polynomials in NTT form
+type poly [n]uint16
+
+// zetas holds zeta^brv(k) mod q for k = 0...127, where brv reverses
+// the 7 bits of k
+var zetas [128]uint16
+
+// gammas holds zeta^(2*brv(k)+1) mod q for k = 0...127, which are used
+// to multiply polynomials in NTT form
+var gammas [128]uint16
+
+func init() {
+	pow := func(e int) uint16 {
+		v := uint32(1)
+		for i := 0; i < e; i++ {
+			v = v * zeta % q
+		}
+		return uint16(v)
+	}
+	for k := 0; k < 128; k++ {
+		var brv int
+		for i := 0; i < 7; i++ {
+			brv |= ((k >> i) & 1) << (6 - i)
+		}
+		zetas[k] = pow(brv)
+		gammas[k] = pow(2*brv + 1)
+	}
+}
+
+func fieldAdd(a, b uint16) uint16 {
+	x := uint32(a) + uint32(b) - q
+	return uint16(x + (uint32(int32(x)>>31) & q))
+}
+
+func fieldSub(a, b uint16) uint16 {
+	x := uint32(a) - uint32(b)
+	return uint16(x + (uint32(int32(x)>>31) & q))
+}
+
+func fieldMul(a, b uint16) uint16 {
+	return uint16(uint32(a) * uint32(b) % q)
+}
+
+// ntt computes the number-theoretic transform of f in place (FIPS 203, Algorithm 9)
+func (f *poly) ntt() {
+	k := 1
+	for length := 128; length >= 2; length /= 2 {
+		for start := 0; start < n; start += 2 * length {
+			z := zetas[k]
+			k++
+			for j := start; j < start+length; j++ {
+				t := fieldMul(z, f[j+length])
+				f[j+length] = fieldSub(f[j], t)
+				f[j] = fieldAdd(f[j], t)
+			}
+		}
+	}
+}
+
+// invNTT computes the inverse of the number-theoretic transform of f in
+// place (FIPS 203, Algorithm 10)
+func (f *poly) invNTT() {
+	k := 127
+	for length := 2; length <= 128; length *= 2 {
+		for start := 0; start < n; start += 2 * length {
+			z := zetas[k]
+			k--
+			for j := start; j < start+length; j++ {
+				t := f[j]
+				f[j] = fieldAdd(t, f[j+length])
+				f[j+length] = fieldMul(z, fieldSub(f[j+length], t))
+			}
+		}
+	}
+	for j := range f {
+		f[j] = fieldMul(f[j], nInv)
+	}
+}
+
+func (f *poly) add(a, b *poly) {
+	for i := range f {
+		f[i] = fieldAdd(a[i], b[i])
+	}
+}
+
+func (f *poly) sub(a, b *poly) {
+	for i := range f {
+		f[i] = fieldSub(a[i], b[i])
+	}
+}
+
+// mulNTT multiplies two polynomials in NTT form (FIPS 203, Algorithms 11 and 12)
+func (f *poly) mulNTT(a, b *poly) {
+	for i := 0; i < n/2; i++ {
+		a0, a1 := uint32(a[2*i]), uint32(a[2*i+1])
+		b0, b1 := uint32(b[2*i]), uint32(b[2*i+1])
+		f[2*i] = uint16((a0*b0 + a1*b1%q*uint32(gammas[i])) % q)
+		f[2*i+1] = uint16((a0*b1 + a1*b0) % q)
+	}
+}
+
+// dot computes the inner product of two vectors in NTT form
+func dot(a, b []poly) poly {
+	var out, t poly
+	for i := range a {
+		t.mulNTT(&a[i], &b[i])
+		out.add(&out, &t)
+	}
+	return out
+}
+
+// expandA samples the k x k matrix A from rho, stored in row major
+// order (FIPS 203, Algorithm 13)
+func expandA(rho []byte, k int) []poly {
+	a := make([]poly, k*k)
+	for i := 0; i < k; i++ {
+		for j := 0; j < k; j++ {
+			sampleNTT(&a[i*k+j], rho, byte(j), byte(i))
+		}
+	}
+	return a
+}
+
+// sampleNTT samples a polynomial in NTT form with uniformly random
+// coefficients (FIPS 203, Algorithm 7)
+func sampleNTT(f *poly, rho []byte, j, i byte) {
+	h := sha3.NewShake128()
+	_, _ = h.Write(rho)
+	_, _ = h.Write([]byte{j, i})
+
+	var buf [168]byte
+	k := 0
+	for k < n {
+		_, _ = h.Read(buf[:])
+		for b := 0; b < len(buf) && k < n; b += 3 {
+			d1 := uint16(buf[b]) | uint16(buf[b+1]&0x0f)<<8
+			d2 := uint16(buf[b+1])>>4 | uint16(buf[b+2])<<4
+			if d1 < q {
+				f[k] = d1
+				k++
+			}
+			if d2 < q && k < n {
+				f[k] = d2
+				k++
+			}
+		}
+	}
+}
+
+// samplePolyCBD samples a polynomial from the centered binomial
+// distribution with parameter eta = 2, using 64 * eta bytes from
+// PRF(sigma, b) (FIPS 203, Algorithm 8)
+func samplePolyCBD(f *poly, sigma []byte, b byte) {
+	var buf [128]byte
+	h := sha3.NewShake256()
+	_, _ = h.Write(sigma)
+	_, _ = h.Write([]byte{b})
+	_, _ = h.Read(buf[:])
+
+	for i := 0; i < n; i += 2 {
+		v := buf[i/2]
+		x0 := uint16(v&1) + uint16(v>>1&1)
+		y0 := uint16(v>>2&1) + uint16(v>>3&1)
+		x1 := uint16(v>>4&1) + uint16(v>>5&1)
+		y1 := uint16(v>>6&1) + uint16(v>>7&1)
+		f[i] = fieldSub(x0, y0)
+		f[i+1] = fieldSub(x1, y1)
+	}
+}
+
+// compress maps x to round(2^bits / q * x) mod 2^bits (FIPS 203, Section 4.2.1)
+func compress(x uint16, bits int) uint16 {
+	v := (uint32(x)<<bits + q/2) / q
+	return uint16(v & (1<<bits - 1))
+}
+
+// decompress maps y to round(q / 2^bits * y)
+func decompress(y uint16, bits int) uint16 {
+	return uint16((uint32(y)*q + 1<<(bits-1)) >> bits)
+}
+
+// packBits appends the coefficients of f to dst, using the given number
+// of bits per coefficient in little-endian bit order
+// (FIPS 203, Algorithm 5)
+func packBits(dst []byte, f *poly, bits int) []byte {
+	var acc uint64
+	var accBits int
+	for _, c := range f {
+		acc |= uint64(c) << accBits
+		accBits += bits
+		for accBits >= 8 {
+			dst = append(dst, byte(acc))
+			acc >>= 8
+			accBits -= 8
+		}
+	}
+	return dst
+}
+
+// unpackBits is the inverse of packBits. src must contain exactly
+// 32 * bits bytes (FIPS 203, Algorithm 6)
+func unpackBits(f *poly, src []byte, bits int) {
+	var acc uint64
+	var accBits int
+	mask := uint64(1)<<bits - 1
+	j := 0
+	for _, b := range src {
+		acc |= uint64(b) << accBits
+		accBits += 8
+		for accBits >= bits {
+			f[j] = uint16(acc & mask)
+			j++
+			acc >>= bits
+			accBits -= bits
+		}
+	}
+}
+
+// packCompressed compresses the coefficients of f, and appends them to dst
+func packCompressed(dst []byte, f *poly, bits int) []byte {
+	var t poly
+	for i, c := range f {
+		t[i] = compress(c, bits)
+	}
+	return packBits(dst, &t, bits)
+}
+
+// unpackCompressed is the inverse of packCompressed, up to the
+// rounding error introduced by the compression
+func unpackCompressed(f *poly, src []byte, bits int) {
+	unpackBits(f, src, bits)
+	for i, c := range f {
+		f[i] = decompress(c, bits)
+	}
+}
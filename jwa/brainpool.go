@@ -0,0 +1,17 @@
+//go:build jwx_brainpool
+// +build jwx_brainpool
+
+package jwa
+
+// These constants are only available if compiled with jwx_brainpool build tag
+const (
+	BrainpoolP256r1 EllipticCurveAlgorithm = "BP-256"
+	BrainpoolP384r1 EllipticCurveAlgorithm = "BP-384"
+	BrainpoolP512r1 EllipticCurveAlgorithm = "BP-512"
+)
+
+func init() {
+	RegisterEllipticCurveAlgorithm(BrainpoolP256r1)
+	RegisterEllipticCurveAlgorithm(BrainpoolP384r1)
+	RegisterEllipticCurveAlgorithm(BrainpoolP512r1)
+}
@@ -0,0 +1,24 @@
+//go:build jwx_brainpool
+// +build jwx_brainpool
+
+package jwa_test
+
+import (
+	"testing"
+
+	"github.com/sjwl/jwx/v2/jwa"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrainpool(t *testing.T) {
+	t.Parallel()
+	for _, crv := range []jwa.EllipticCurveAlgorithm{jwa.BrainpoolP256r1, jwa.BrainpoolP384r1, jwa.BrainpoolP512r1} {
+		crv := crv
+		t.Run(crv.String(), func(t *testing.T) {
+			t.Parallel()
+			var dst jwa.EllipticCurveAlgorithm
+			require.NoError(t, dst.Accept(crv.String()), `accept is successful`)
+			require.Equal(t, crv, dst, `accepted value should be equal to constant`)
+		})
+	}
+}
@@ -17,25 +17,58 @@ const (
 	NoCompress CompressionAlgorithm = ""    // No compression
 )
 
+var muCompressionAlgorithms sync.RWMutex
 var allCompressionAlgorithms = map[CompressionAlgorithm]struct{}{
 	Deflate:    {},
 	NoCompress: {},
 }
 
-var listCompressionAlgorithmOnce sync.Once
 var listCompressionAlgorithm []CompressionAlgorithm
 
+func init() {
+	muCompressionAlgorithms.Lock()
+	defer muCompressionAlgorithms.Unlock()
+	rebuildCompressionAlgorithm()
+}
+
+// RegisterCompressionAlgorithm registers a new CompressionAlgorithm so that
+// jwx can properly handle the new value (e.g. in Accept() and CompressionAlgorithms()).
+// Duplicates are silently ignored.
+func RegisterCompressionAlgorithm(v CompressionAlgorithm) {
+	muCompressionAlgorithms.Lock()
+	defer muCompressionAlgorithms.Unlock()
+	if _, ok := allCompressionAlgorithms[v]; !ok {
+		allCompressionAlgorithms[v] = struct{}{}
+		rebuildCompressionAlgorithm()
+	}
+}
+
+// UnregisterCompressionAlgorithm unregisters a CompressionAlgorithm from the list of
+// known values. Non-existent entries are silently ignored.
+func UnregisterCompressionAlgorithm(v CompressionAlgorithm) {
+	muCompressionAlgorithms.Lock()
+	defer muCompressionAlgorithms.Unlock()
+	if _, ok := allCompressionAlgorithms[v]; ok {
+		delete(allCompressionAlgorithms, v)
+		rebuildCompressionAlgorithm()
+	}
+}
+
+func rebuildCompressionAlgorithm() {
+	list := make([]CompressionAlgorithm, 0, len(allCompressionAlgorithms))
+	for v := range allCompressionAlgorithms {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return string(list[i]) < string(list[j])
+	})
+	listCompressionAlgorithm = list
+}
+
 // CompressionAlgorithms returns a list of all available values for CompressionAlgorithm
 func CompressionAlgorithms() []CompressionAlgorithm {
-	listCompressionAlgorithmOnce.Do(func() {
-		listCompressionAlgorithm = make([]CompressionAlgorithm, 0, len(allCompressionAlgorithms))
-		for v := range allCompressionAlgorithms {
-			listCompressionAlgorithm = append(listCompressionAlgorithm, v)
-		}
-		sort.Slice(listCompressionAlgorithm, func(i, j int) bool {
-			return string(listCompressionAlgorithm[i]) < string(listCompressionAlgorithm[j])
-		})
-	})
+	muCompressionAlgorithms.RLock()
+	defer muCompressionAlgorithms.RUnlock()
 	return listCompressionAlgorithm
 }
 
@@ -57,7 +90,11 @@ func (v *CompressionAlgorithm) Accept(value interface{}) error {
 		}
 		tmp = CompressionAlgorithm(s)
 	}
-	if _, ok := allCompressionAlgorithms[tmp]; !ok {
+
+	muCompressionAlgorithms.RLock()
+	_, ok := allCompressionAlgorithms[tmp]
+	muCompressionAlgorithms.RUnlock()
+	if !ok {
 		return fmt.Errorf(`invalid jwa.CompressionAlgorithm value`)
 	}
 
@@ -114,3 +114,32 @@ func TestCompressionAlgorithm(t *testing.T) {
 		}
 	})
 }
+
+func TestCompressionAlgorithmCustomAlgorithm(t *testing.T) {
+	const customAlgorithm = jwa.CompressionAlgorithm(`custom-algorithm`)
+	var dst jwa.CompressionAlgorithm
+	if !assert.Error(t, dst.Accept(customAlgorithm), `accept should fail before registration`) {
+		return
+	}
+
+	jwa.RegisterCompressionAlgorithm(customAlgorithm)
+	defer jwa.UnregisterCompressionAlgorithm(customAlgorithm)
+
+	if !assert.NoError(t, dst.Accept(customAlgorithm.String()), `accept should succeed after registration`) {
+		return
+	}
+	if !assert.Equal(t, customAlgorithm, dst, `accepted value should be equal to constant`) {
+		return
+	}
+	if !assert.Contains(t, jwa.CompressionAlgorithms(), customAlgorithm, `list should contain the registered value`) {
+		return
+	}
+
+	jwa.UnregisterCompressionAlgorithm(customAlgorithm)
+	if !assert.Error(t, dst.Accept(customAlgorithm), `accept should fail after unregistration`) {
+		return
+	}
+	if !assert.NotContains(t, jwa.CompressionAlgorithms(), customAlgorithm, `list should not contain the unregistered value`) {
+		return
+	}
+}
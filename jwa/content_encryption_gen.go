@@ -19,8 +19,10 @@ const (
 	A192GCM       ContentEncryptionAlgorithm = "A192GCM"       // AES-GCM (192)
 	A256CBC_HS512 ContentEncryptionAlgorithm = "A256CBC-HS512" // AES-CBC + HMAC-SHA512 (256)
 	A256GCM       ContentEncryptionAlgorithm = "A256GCM"       // AES-GCM (256)
+	XC20P         ContentEncryptionAlgorithm = "XC20P"         // XChaCha20-Poly1305
 )
 
+var muContentEncryptionAlgorithms sync.RWMutex
 var allContentEncryptionAlgorithms = map[ContentEncryptionAlgorithm]struct{}{
 	A128CBC_HS256: {},
 	A128GCM:       {},
@@ -28,22 +30,55 @@ var allContentEncryptionAlgorithms = map[ContentEncryptionAlgorithm]struct{}{
 	A192GCM:       {},
 	A256CBC_HS512: {},
 	A256GCM:       {},
+	XC20P:         {},
 }
 
-var listContentEncryptionAlgorithmOnce sync.Once
 var listContentEncryptionAlgorithm []ContentEncryptionAlgorithm
 
+func init() {
+	muContentEncryptionAlgorithms.Lock()
+	defer muContentEncryptionAlgorithms.Unlock()
+	rebuildContentEncryptionAlgorithm()
+}
+
+// RegisterContentEncryptionAlgorithm registers a new ContentEncryptionAlgorithm so that
+// jwx can properly handle the new value (e.g. in Accept() and ContentEncryptionAlgorithms()).
+// Duplicates are silently ignored.
+func RegisterContentEncryptionAlgorithm(v ContentEncryptionAlgorithm) {
+	muContentEncryptionAlgorithms.Lock()
+	defer muContentEncryptionAlgorithms.Unlock()
+	if _, ok := allContentEncryptionAlgorithms[v]; !ok {
+		allContentEncryptionAlgorithms[v] = struct{}{}
+		rebuildContentEncryptionAlgorithm()
+	}
+}
+
+// UnregisterContentEncryptionAlgorithm unregisters a ContentEncryptionAlgorithm from the list of
+// known values. Non-existent entries are silently ignored.
+func UnregisterContentEncryptionAlgorithm(v ContentEncryptionAlgorithm) {
+	muContentEncryptionAlgorithms.Lock()
+	defer muContentEncryptionAlgorithms.Unlock()
+	if _, ok := allContentEncryptionAlgorithms[v]; ok {
+		delete(allContentEncryptionAlgorithms, v)
+		rebuildContentEncryptionAlgorithm()
+	}
+}
+
+func rebuildContentEncryptionAlgorithm() {
+	list := make([]ContentEncryptionAlgorithm, 0, len(allContentEncryptionAlgorithms))
+	for v := range allContentEncryptionAlgorithms {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return string(list[i]) < string(list[j])
+	})
+	listContentEncryptionAlgorithm = list
+}
+
 // ContentEncryptionAlgorithms returns a list of all available values for ContentEncryptionAlgorithm
 func ContentEncryptionAlgorithms() []ContentEncryptionAlgorithm {
-	listContentEncryptionAlgorithmOnce.Do(func() {
-		listContentEncryptionAlgorithm = make([]ContentEncryptionAlgorithm, 0, len(allContentEncryptionAlgorithms))
-		for v := range allContentEncryptionAlgorithms {
-			listContentEncryptionAlgorithm = append(listContentEncryptionAlgorithm, v)
-		}
-		sort.Slice(listContentEncryptionAlgorithm, func(i, j int) bool {
-			return string(listContentEncryptionAlgorithm[i]) < string(listContentEncryptionAlgorithm[j])
-		})
-	})
+	muContentEncryptionAlgorithms.RLock()
+	defer muContentEncryptionAlgorithms.RUnlock()
 	return listContentEncryptionAlgorithm
 }
 
@@ -65,7 +100,11 @@ func (v *ContentEncryptionAlgorithm) Accept(value interface{}) error {
 		}
 		tmp = ContentEncryptionAlgorithm(s)
 	}
-	if _, ok := allContentEncryptionAlgorithms[tmp]; !ok {
+
+	muContentEncryptionAlgorithms.RLock()
+	_, ok := allContentEncryptionAlgorithms[tmp]
+	muContentEncryptionAlgorithms.RUnlock()
+	if !ok {
 		return fmt.Errorf(`invalid jwa.ContentEncryptionAlgorithm value`)
 	}
 
@@ -227,6 +227,42 @@ func TestContentEncryptionAlgorithm(t *testing.T) {
 			return
 		}
 	})
+	t.Run(`accept jwa constant XC20P`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.ContentEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.XC20P), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.XC20P, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string XC20P`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.ContentEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("XC20P"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.XC20P, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for XC20P`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.ContentEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "XC20P"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.XC20P, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for XC20P`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "XC20P", jwa.XC20P.String(), `stringified value matches`) {
+			return
+		}
+	})
 	t.Run(`bail out on random integer value`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.ContentEncryptionAlgorithm
@@ -250,6 +286,7 @@ func TestContentEncryptionAlgorithm(t *testing.T) {
 			jwa.A192GCM:       {},
 			jwa.A256CBC_HS512: {},
 			jwa.A256GCM:       {},
+			jwa.XC20P:         {},
 		}
 		for _, v := range jwa.ContentEncryptionAlgorithms() {
 			if _, ok := expected[v]; !assert.True(t, ok, `%s should be in the expected list`, v) {
@@ -262,3 +299,32 @@ func TestContentEncryptionAlgorithm(t *testing.T) {
 		}
 	})
 }
+
+func TestContentEncryptionAlgorithmCustomAlgorithm(t *testing.T) {
+	const customAlgorithm = jwa.ContentEncryptionAlgorithm(`custom-algorithm`)
+	var dst jwa.ContentEncryptionAlgorithm
+	if !assert.Error(t, dst.Accept(customAlgorithm), `accept should fail before registration`) {
+		return
+	}
+
+	jwa.RegisterContentEncryptionAlgorithm(customAlgorithm)
+	defer jwa.UnregisterContentEncryptionAlgorithm(customAlgorithm)
+
+	if !assert.NoError(t, dst.Accept(customAlgorithm.String()), `accept should succeed after registration`) {
+		return
+	}
+	if !assert.Equal(t, customAlgorithm, dst, `accepted value should be equal to constant`) {
+		return
+	}
+	if !assert.Contains(t, jwa.ContentEncryptionAlgorithms(), customAlgorithm, `list should contain the registered value`) {
+		return
+	}
+
+	jwa.UnregisterContentEncryptionAlgorithm(customAlgorithm)
+	if !assert.Error(t, dst.Accept(customAlgorithm), `accept should fail after unregistration`) {
+		return
+	}
+	if !assert.NotContains(t, jwa.ContentEncryptionAlgorithms(), customAlgorithm, `list should not contain the unregistered value`) {
+		return
+	}
+}
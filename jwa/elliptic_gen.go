@@ -23,6 +23,7 @@ const (
 	X448                 EllipticCurveAlgorithm = "X448"
 )
 
+var muEllipticCurveAlgorithms sync.RWMutex
 var allEllipticCurveAlgorithms = map[EllipticCurveAlgorithm]struct{}{
 	Ed25519: {},
 	Ed448:   {},
@@ -33,20 +34,52 @@ var allEllipticCurveAlgorithms = map[EllipticCurveAlgorithm]struct{}{
 	X448:    {},
 }
 
-var listEllipticCurveAlgorithmOnce sync.Once
 var listEllipticCurveAlgorithm []EllipticCurveAlgorithm
 
+func init() {
+	muEllipticCurveAlgorithms.Lock()
+	defer muEllipticCurveAlgorithms.Unlock()
+	rebuildEllipticCurveAlgorithm()
+}
+
+// RegisterEllipticCurveAlgorithm registers a new EllipticCurveAlgorithm so that
+// jwx can properly handle the new value (e.g. in Accept() and EllipticCurveAlgorithms()).
+// Duplicates are silently ignored.
+func RegisterEllipticCurveAlgorithm(v EllipticCurveAlgorithm) {
+	muEllipticCurveAlgorithms.Lock()
+	defer muEllipticCurveAlgorithms.Unlock()
+	if _, ok := allEllipticCurveAlgorithms[v]; !ok {
+		allEllipticCurveAlgorithms[v] = struct{}{}
+		rebuildEllipticCurveAlgorithm()
+	}
+}
+
+// UnregisterEllipticCurveAlgorithm unregisters a EllipticCurveAlgorithm from the list of
+// known values. Non-existent entries are silently ignored.
+func UnregisterEllipticCurveAlgorithm(v EllipticCurveAlgorithm) {
+	muEllipticCurveAlgorithms.Lock()
+	defer muEllipticCurveAlgorithms.Unlock()
+	if _, ok := allEllipticCurveAlgorithms[v]; ok {
+		delete(allEllipticCurveAlgorithms, v)
+		rebuildEllipticCurveAlgorithm()
+	}
+}
+
+func rebuildEllipticCurveAlgorithm() {
+	list := make([]EllipticCurveAlgorithm, 0, len(allEllipticCurveAlgorithms))
+	for v := range allEllipticCurveAlgorithms {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return string(list[i]) < string(list[j])
+	})
+	listEllipticCurveAlgorithm = list
+}
+
 // EllipticCurveAlgorithms returns a list of all available values for EllipticCurveAlgorithm
 func EllipticCurveAlgorithms() []EllipticCurveAlgorithm {
-	listEllipticCurveAlgorithmOnce.Do(func() {
-		listEllipticCurveAlgorithm = make([]EllipticCurveAlgorithm, 0, len(allEllipticCurveAlgorithms))
-		for v := range allEllipticCurveAlgorithms {
-			listEllipticCurveAlgorithm = append(listEllipticCurveAlgorithm, v)
-		}
-		sort.Slice(listEllipticCurveAlgorithm, func(i, j int) bool {
-			return string(listEllipticCurveAlgorithm[i]) < string(listEllipticCurveAlgorithm[j])
-		})
-	})
+	muEllipticCurveAlgorithms.RLock()
+	defer muEllipticCurveAlgorithms.RUnlock()
 	return listEllipticCurveAlgorithm
 }
 
@@ -68,7 +101,11 @@ func (v *EllipticCurveAlgorithm) Accept(value interface{}) error {
 		}
 		tmp = EllipticCurveAlgorithm(s)
 	}
-	if _, ok := allEllipticCurveAlgorithms[tmp]; !ok {
+
+	muEllipticCurveAlgorithms.RLock()
+	_, ok := allEllipticCurveAlgorithms[tmp]
+	muEllipticCurveAlgorithms.RUnlock()
+	if !ok {
 		return fmt.Errorf(`invalid jwa.EllipticCurveAlgorithm value`)
 	}
 
@@ -297,8 +297,9 @@ func TestEllipticCurveAlgorithm(t *testing.T) {
 		}
 		for _, v := range jwa.EllipticCurveAlgorithms() {
 			// There is no good way to detect from a test if es256k (secp256k1)
-			// is supported, so just allow it
-			if v.String() == `secp256k1` {
+			// or brainpool curves are supported, so just allow them
+			switch v.String() {
+			case `secp256k1`, `BP-256`, `BP-384`, `BP-512`:
 				continue
 			}
 			if _, ok := expected[v]; !assert.True(t, ok, `%s should be in the expected list`, v) {
@@ -311,3 +312,32 @@ func TestEllipticCurveAlgorithm(t *testing.T) {
 		}
 	})
 }
+
+func TestEllipticCurveAlgorithmCustomAlgorithm(t *testing.T) {
+	const customAlgorithm = jwa.EllipticCurveAlgorithm(`custom-algorithm`)
+	var dst jwa.EllipticCurveAlgorithm
+	if !assert.Error(t, dst.Accept(customAlgorithm), `accept should fail before registration`) {
+		return
+	}
+
+	jwa.RegisterEllipticCurveAlgorithm(customAlgorithm)
+	defer jwa.UnregisterEllipticCurveAlgorithm(customAlgorithm)
+
+	if !assert.NoError(t, dst.Accept(customAlgorithm.String()), `accept should succeed after registration`) {
+		return
+	}
+	if !assert.Equal(t, customAlgorithm, dst, `accepted value should be equal to constant`) {
+		return
+	}
+	if !assert.Contains(t, jwa.EllipticCurveAlgorithms(), customAlgorithm, `list should contain the registered value`) {
+		return
+	}
+
+	jwa.UnregisterEllipticCurveAlgorithm(customAlgorithm)
+	if !assert.Error(t, dst.Accept(customAlgorithm), `accept should fail after unregistration`) {
+		return
+	}
+	if !assert.NotContains(t, jwa.EllipticCurveAlgorithms(), customAlgorithm, `list should not contain the unregistered value`) {
+		return
+	}
+}
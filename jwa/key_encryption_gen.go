@@ -20,18 +20,41 @@ const (
 	A256GCMKW          KeyEncryptionAlgorithm = "A256GCMKW"          // AES-GCM key wrap (256)
 	A256KW             KeyEncryptionAlgorithm = "A256KW"             // AES key wrap (256)
 	DIRECT             KeyEncryptionAlgorithm = "dir"                // Direct encryption
+	ECDH_1PU           KeyEncryptionAlgorithm = "ECDH-1PU"           // ECDH-1PU
+	ECDH_1PU_A128KW    KeyEncryptionAlgorithm = "ECDH-1PU+A128KW"    // ECDH-1PU + AES key wrap (128)
+	ECDH_1PU_A192KW    KeyEncryptionAlgorithm = "ECDH-1PU+A192KW"    // ECDH-1PU + AES key wrap (192)
+	ECDH_1PU_A256KW    KeyEncryptionAlgorithm = "ECDH-1PU+A256KW"    // ECDH-1PU + AES key wrap (256)
 	ECDH_ES            KeyEncryptionAlgorithm = "ECDH-ES"            // ECDH-ES
 	ECDH_ES_A128KW     KeyEncryptionAlgorithm = "ECDH-ES+A128KW"     // ECDH-ES + AES key wrap (128)
 	ECDH_ES_A192KW     KeyEncryptionAlgorithm = "ECDH-ES+A192KW"     // ECDH-ES + AES key wrap (192)
 	ECDH_ES_A256KW     KeyEncryptionAlgorithm = "ECDH-ES+A256KW"     // ECDH-ES + AES key wrap (256)
+	ECDH_ES_XC20PKW    KeyEncryptionAlgorithm = "ECDH-ES+XC20PKW"    // ECDH-ES + XChaCha20-Poly1305 key wrap
+	HPKE_0             KeyEncryptionAlgorithm = "HPKE-0"             // HPKE integrated encryption (P-256, HKDF-SHA256, AES-128-GCM)
+	HPKE_0_KE          KeyEncryptionAlgorithm = "HPKE-0-KE"          // HPKE key encryption (P-256, HKDF-SHA256, AES-128-GCM)
+	HPKE_1             KeyEncryptionAlgorithm = "HPKE-1"             // HPKE integrated encryption (P-384, HKDF-SHA384, AES-256-GCM)
+	HPKE_1_KE          KeyEncryptionAlgorithm = "HPKE-1-KE"          // HPKE key encryption (P-384, HKDF-SHA384, AES-256-GCM)
+	HPKE_2             KeyEncryptionAlgorithm = "HPKE-2"             // HPKE integrated encryption (P-521, HKDF-SHA512, AES-256-GCM)
+	HPKE_2_KE          KeyEncryptionAlgorithm = "HPKE-2-KE"          // HPKE key encryption (P-521, HKDF-SHA512, AES-256-GCM)
+	HPKE_3             KeyEncryptionAlgorithm = "HPKE-3"             // HPKE integrated encryption (X25519, HKDF-SHA256, AES-128-GCM)
+	HPKE_3_KE          KeyEncryptionAlgorithm = "HPKE-3-KE"          // HPKE key encryption (X25519, HKDF-SHA256, AES-128-GCM)
+	HPKE_4             KeyEncryptionAlgorithm = "HPKE-4"             // HPKE integrated encryption (X25519, HKDF-SHA256, ChaCha20Poly1305)
+	HPKE_4_KE          KeyEncryptionAlgorithm = "HPKE-4-KE"          // HPKE key encryption (X25519, HKDF-SHA256, ChaCha20Poly1305)
+	MLKEM1024          KeyEncryptionAlgorithm = "ML-KEM-1024"        // ML-KEM-1024 key encapsulation, used directly as the content encryption key
+	MLKEM1024_A256KW   KeyEncryptionAlgorithm = "ML-KEM-1024+A256KW" // ML-KEM-1024 key encapsulation + AES key wrap (256)
+	MLKEM768           KeyEncryptionAlgorithm = "ML-KEM-768"         // ML-KEM-768 key encapsulation, used directly as the content encryption key
+	MLKEM768_A192KW    KeyEncryptionAlgorithm = "ML-KEM-768+A192KW"  // ML-KEM-768 key encapsulation + AES key wrap (192)
 	PBES2_HS256_A128KW KeyEncryptionAlgorithm = "PBES2-HS256+A128KW" // PBES2 + HMAC-SHA256 + AES key wrap (128)
 	PBES2_HS384_A192KW KeyEncryptionAlgorithm = "PBES2-HS384+A192KW" // PBES2 + HMAC-SHA384 + AES key wrap (192)
 	PBES2_HS512_A256KW KeyEncryptionAlgorithm = "PBES2-HS512+A256KW" // PBES2 + HMAC-SHA512 + AES key wrap (256)
 	RSA1_5             KeyEncryptionAlgorithm = "RSA1_5"             // RSA-PKCS1v1.5
 	RSA_OAEP           KeyEncryptionAlgorithm = "RSA-OAEP"           // RSA-OAEP-SHA1
 	RSA_OAEP_256       KeyEncryptionAlgorithm = "RSA-OAEP-256"       // RSA-OAEP-SHA256
+	RSA_OAEP_384       KeyEncryptionAlgorithm = "RSA-OAEP-384"       // RSA-OAEP-SHA384
+	RSA_OAEP_512       KeyEncryptionAlgorithm = "RSA-OAEP-512"       // RSA-OAEP-SHA512
+	XC20PKW            KeyEncryptionAlgorithm = "XC20PKW"            // XChaCha20-Poly1305 key wrap
 )
 
+var muKeyEncryptionAlgorithms sync.RWMutex
 var allKeyEncryptionAlgorithms = map[KeyEncryptionAlgorithm]struct{}{
 	A128GCMKW:          {},
 	A128KW:             {},
@@ -40,32 +63,86 @@ var allKeyEncryptionAlgorithms = map[KeyEncryptionAlgorithm]struct{}{
 	A256GCMKW:          {},
 	A256KW:             {},
 	DIRECT:             {},
+	ECDH_1PU:           {},
+	ECDH_1PU_A128KW:    {},
+	ECDH_1PU_A192KW:    {},
+	ECDH_1PU_A256KW:    {},
 	ECDH_ES:            {},
 	ECDH_ES_A128KW:     {},
 	ECDH_ES_A192KW:     {},
 	ECDH_ES_A256KW:     {},
+	ECDH_ES_XC20PKW:    {},
+	HPKE_0:             {},
+	HPKE_0_KE:          {},
+	HPKE_1:             {},
+	HPKE_1_KE:          {},
+	HPKE_2:             {},
+	HPKE_2_KE:          {},
+	HPKE_3:             {},
+	HPKE_3_KE:          {},
+	HPKE_4:             {},
+	HPKE_4_KE:          {},
+	MLKEM1024:          {},
+	MLKEM1024_A256KW:   {},
+	MLKEM768:           {},
+	MLKEM768_A192KW:    {},
 	PBES2_HS256_A128KW: {},
 	PBES2_HS384_A192KW: {},
 	PBES2_HS512_A256KW: {},
 	RSA1_5:             {},
 	RSA_OAEP:           {},
 	RSA_OAEP_256:       {},
+	RSA_OAEP_384:       {},
+	RSA_OAEP_512:       {},
+	XC20PKW:            {},
 }
 
-var listKeyEncryptionAlgorithmOnce sync.Once
 var listKeyEncryptionAlgorithm []KeyEncryptionAlgorithm
 
+func init() {
+	muKeyEncryptionAlgorithms.Lock()
+	defer muKeyEncryptionAlgorithms.Unlock()
+	rebuildKeyEncryptionAlgorithm()
+}
+
+// RegisterKeyEncryptionAlgorithm registers a new KeyEncryptionAlgorithm so that
+// jwx can properly handle the new value (e.g. in Accept() and KeyEncryptionAlgorithms()).
+// Duplicates are silently ignored.
+func RegisterKeyEncryptionAlgorithm(v KeyEncryptionAlgorithm) {
+	muKeyEncryptionAlgorithms.Lock()
+	defer muKeyEncryptionAlgorithms.Unlock()
+	if _, ok := allKeyEncryptionAlgorithms[v]; !ok {
+		allKeyEncryptionAlgorithms[v] = struct{}{}
+		rebuildKeyEncryptionAlgorithm()
+	}
+}
+
+// UnregisterKeyEncryptionAlgorithm unregisters a KeyEncryptionAlgorithm from the list of
+// known values. Non-existent entries are silently ignored.
+func UnregisterKeyEncryptionAlgorithm(v KeyEncryptionAlgorithm) {
+	muKeyEncryptionAlgorithms.Lock()
+	defer muKeyEncryptionAlgorithms.Unlock()
+	if _, ok := allKeyEncryptionAlgorithms[v]; ok {
+		delete(allKeyEncryptionAlgorithms, v)
+		rebuildKeyEncryptionAlgorithm()
+	}
+}
+
+func rebuildKeyEncryptionAlgorithm() {
+	list := make([]KeyEncryptionAlgorithm, 0, len(allKeyEncryptionAlgorithms))
+	for v := range allKeyEncryptionAlgorithms {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return string(list[i]) < string(list[j])
+	})
+	listKeyEncryptionAlgorithm = list
+}
+
 // KeyEncryptionAlgorithms returns a list of all available values for KeyEncryptionAlgorithm
 func KeyEncryptionAlgorithms() []KeyEncryptionAlgorithm {
-	listKeyEncryptionAlgorithmOnce.Do(func() {
-		listKeyEncryptionAlgorithm = make([]KeyEncryptionAlgorithm, 0, len(allKeyEncryptionAlgorithms))
-		for v := range allKeyEncryptionAlgorithms {
-			listKeyEncryptionAlgorithm = append(listKeyEncryptionAlgorithm, v)
-		}
-		sort.Slice(listKeyEncryptionAlgorithm, func(i, j int) bool {
-			return string(listKeyEncryptionAlgorithm[i]) < string(listKeyEncryptionAlgorithm[j])
-		})
-	})
+	muKeyEncryptionAlgorithms.RLock()
+	defer muKeyEncryptionAlgorithms.RUnlock()
 	return listKeyEncryptionAlgorithm
 }
 
@@ -87,7 +164,11 @@ func (v *KeyEncryptionAlgorithm) Accept(value interface{}) error {
 		}
 		tmp = KeyEncryptionAlgorithm(s)
 	}
-	if _, ok := allKeyEncryptionAlgorithms[tmp]; !ok {
+
+	muKeyEncryptionAlgorithms.RLock()
+	_, ok := allKeyEncryptionAlgorithms[tmp]
+	muKeyEncryptionAlgorithms.RUnlock()
+	if !ok {
 		return fmt.Errorf(`invalid jwa.KeyEncryptionAlgorithm value`)
 	}
 
@@ -103,7 +184,7 @@ func (v KeyEncryptionAlgorithm) String() string {
 // IsSymmetric returns true if the algorithm is a symmetric type
 func (v KeyEncryptionAlgorithm) IsSymmetric() bool {
 	switch v {
-	case A128GCMKW, A128KW, A192GCMKW, A192KW, A256GCMKW, A256KW, DIRECT, PBES2_HS256_A128KW, PBES2_HS384_A192KW, PBES2_HS512_A256KW:
+	case A128GCMKW, A128KW, A192GCMKW, A192KW, A256GCMKW, A256KW, DIRECT, PBES2_HS256_A128KW, PBES2_HS384_A192KW, PBES2_HS512_A256KW, XC20PKW:
 		return true
 	}
 	return false
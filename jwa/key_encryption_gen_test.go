@@ -263,147 +263,831 @@ func TestKeyEncryptionAlgorithm(t *testing.T) {
 			return
 		}
 	})
+	t.Run(`accept jwa constant ECDH_1PU`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.ECDH_1PU), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_1PU, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ECDH-1PU`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("ECDH-1PU"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_1PU, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ECDH-1PU`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ECDH-1PU"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_1PU, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ECDH-1PU`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ECDH-1PU", jwa.ECDH_1PU.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant ECDH_1PU_A128KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.ECDH_1PU_A128KW), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_1PU_A128KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ECDH-1PU+A128KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("ECDH-1PU+A128KW"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_1PU_A128KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ECDH-1PU+A128KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ECDH-1PU+A128KW"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_1PU_A128KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ECDH-1PU+A128KW`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ECDH-1PU+A128KW", jwa.ECDH_1PU_A128KW.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant ECDH_1PU_A192KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.ECDH_1PU_A192KW), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_1PU_A192KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ECDH-1PU+A192KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("ECDH-1PU+A192KW"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_1PU_A192KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ECDH-1PU+A192KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ECDH-1PU+A192KW"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_1PU_A192KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ECDH-1PU+A192KW`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ECDH-1PU+A192KW", jwa.ECDH_1PU_A192KW.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant ECDH_1PU_A256KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.ECDH_1PU_A256KW), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_1PU_A256KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ECDH-1PU+A256KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("ECDH-1PU+A256KW"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_1PU_A256KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ECDH-1PU+A256KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ECDH-1PU+A256KW"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_1PU_A256KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ECDH-1PU+A256KW`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ECDH-1PU+A256KW", jwa.ECDH_1PU_A256KW.String(), `stringified value matches`) {
+			return
+		}
+	})
 	t.Run(`accept jwa constant ECDH_ES`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.KeyEncryptionAlgorithm
-		if !assert.NoError(t, dst.Accept(jwa.ECDH_ES), `accept is successful`) {
+		if !assert.NoError(t, dst.Accept(jwa.ECDH_ES), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_ES, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ECDH-ES`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("ECDH-ES"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_ES, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ECDH-ES`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ECDH-ES"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_ES, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ECDH-ES`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ECDH-ES", jwa.ECDH_ES.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant ECDH_ES_A128KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.ECDH_ES_A128KW), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_ES_A128KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ECDH-ES+A128KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("ECDH-ES+A128KW"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_ES_A128KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ECDH-ES+A128KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ECDH-ES+A128KW"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_ES_A128KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ECDH-ES+A128KW`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ECDH-ES+A128KW", jwa.ECDH_ES_A128KW.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant ECDH_ES_A192KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.ECDH_ES_A192KW), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_ES_A192KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ECDH-ES+A192KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("ECDH-ES+A192KW"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_ES_A192KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ECDH-ES+A192KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ECDH-ES+A192KW"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_ES_A192KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ECDH-ES+A192KW`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ECDH-ES+A192KW", jwa.ECDH_ES_A192KW.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant ECDH_ES_A256KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.ECDH_ES_A256KW), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_ES_A256KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ECDH-ES+A256KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("ECDH-ES+A256KW"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_ES_A256KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ECDH-ES+A256KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ECDH-ES+A256KW"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_ES_A256KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ECDH-ES+A256KW`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ECDH-ES+A256KW", jwa.ECDH_ES_A256KW.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant ECDH_ES_XC20PKW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.ECDH_ES_XC20PKW), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_ES_XC20PKW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ECDH-ES+XC20PKW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("ECDH-ES+XC20PKW"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_ES_XC20PKW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ECDH-ES+XC20PKW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ECDH-ES+XC20PKW"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ECDH_ES_XC20PKW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ECDH-ES+XC20PKW`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ECDH-ES+XC20PKW", jwa.ECDH_ES_XC20PKW.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant HPKE_0`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.HPKE_0), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_0, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string HPKE-0`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("HPKE-0"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_0, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for HPKE-0`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "HPKE-0"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_0, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for HPKE-0`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "HPKE-0", jwa.HPKE_0.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant HPKE_0_KE`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.HPKE_0_KE), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_0_KE, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string HPKE-0-KE`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("HPKE-0-KE"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_0_KE, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for HPKE-0-KE`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "HPKE-0-KE"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_0_KE, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for HPKE-0-KE`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "HPKE-0-KE", jwa.HPKE_0_KE.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant HPKE_1`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.HPKE_1), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_1, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string HPKE-1`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("HPKE-1"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_1, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for HPKE-1`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "HPKE-1"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_1, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for HPKE-1`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "HPKE-1", jwa.HPKE_1.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant HPKE_1_KE`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.HPKE_1_KE), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_1_KE, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string HPKE-1-KE`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("HPKE-1-KE"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_1_KE, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for HPKE-1-KE`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "HPKE-1-KE"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_1_KE, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for HPKE-1-KE`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "HPKE-1-KE", jwa.HPKE_1_KE.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant HPKE_2`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.HPKE_2), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_2, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string HPKE-2`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("HPKE-2"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_2, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for HPKE-2`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "HPKE-2"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_2, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for HPKE-2`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "HPKE-2", jwa.HPKE_2.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant HPKE_2_KE`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.HPKE_2_KE), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_2_KE, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string HPKE-2-KE`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("HPKE-2-KE"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_2_KE, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for HPKE-2-KE`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "HPKE-2-KE"}), `accept is successful`) {
 			return
 		}
-		if !assert.Equal(t, jwa.ECDH_ES, dst, `accepted value should be equal to constant`) {
+		if !assert.Equal(t, jwa.HPKE_2_KE, dst, `accepted value should be equal to constant`) {
 			return
 		}
 	})
-	t.Run(`accept the string ECDH-ES`, func(t *testing.T) {
+	t.Run(`stringification for HPKE-2-KE`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "HPKE-2-KE", jwa.HPKE_2_KE.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant HPKE_3`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.KeyEncryptionAlgorithm
-		if !assert.NoError(t, dst.Accept("ECDH-ES"), `accept is successful`) {
+		if !assert.NoError(t, dst.Accept(jwa.HPKE_3), `accept is successful`) {
 			return
 		}
-		if !assert.Equal(t, jwa.ECDH_ES, dst, `accepted value should be equal to constant`) {
+		if !assert.Equal(t, jwa.HPKE_3, dst, `accepted value should be equal to constant`) {
 			return
 		}
 	})
-	t.Run(`accept fmt.Stringer for ECDH-ES`, func(t *testing.T) {
+	t.Run(`accept the string HPKE-3`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.KeyEncryptionAlgorithm
-		if !assert.NoError(t, dst.Accept(stringer{src: "ECDH-ES"}), `accept is successful`) {
+		if !assert.NoError(t, dst.Accept("HPKE-3"), `accept is successful`) {
 			return
 		}
-		if !assert.Equal(t, jwa.ECDH_ES, dst, `accepted value should be equal to constant`) {
+		if !assert.Equal(t, jwa.HPKE_3, dst, `accepted value should be equal to constant`) {
 			return
 		}
 	})
-	t.Run(`stringification for ECDH-ES`, func(t *testing.T) {
+	t.Run(`accept fmt.Stringer for HPKE-3`, func(t *testing.T) {
 		t.Parallel()
-		if !assert.Equal(t, "ECDH-ES", jwa.ECDH_ES.String(), `stringified value matches`) {
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "HPKE-3"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HPKE_3, dst, `accepted value should be equal to constant`) {
 			return
 		}
 	})
-	t.Run(`accept jwa constant ECDH_ES_A128KW`, func(t *testing.T) {
+	t.Run(`stringification for HPKE-3`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "HPKE-3", jwa.HPKE_3.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant HPKE_3_KE`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.KeyEncryptionAlgorithm
-		if !assert.NoError(t, dst.Accept(jwa.ECDH_ES_A128KW), `accept is successful`) {
+		if !assert.NoError(t, dst.Accept(jwa.HPKE_3_KE), `accept is successful`) {
 			return
 		}
-		if !assert.Equal(t, jwa.ECDH_ES_A128KW, dst, `accepted value should be equal to constant`) {
+		if !assert.Equal(t, jwa.HPKE_3_KE, dst, `accepted value should be equal to constant`) {
 			return
 		}
 	})
-	t.Run(`accept the string ECDH-ES+A128KW`, func(t *testing.T) {
+	t.Run(`accept the string HPKE-3-KE`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.KeyEncryptionAlgorithm
-		if !assert.NoError(t, dst.Accept("ECDH-ES+A128KW"), `accept is successful`) {
+		if !assert.NoError(t, dst.Accept("HPKE-3-KE"), `accept is successful`) {
 			return
 		}
-		if !assert.Equal(t, jwa.ECDH_ES_A128KW, dst, `accepted value should be equal to constant`) {
+		if !assert.Equal(t, jwa.HPKE_3_KE, dst, `accepted value should be equal to constant`) {
 			return
 		}
 	})
-	t.Run(`accept fmt.Stringer for ECDH-ES+A128KW`, func(t *testing.T) {
+	t.Run(`accept fmt.Stringer for HPKE-3-KE`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.KeyEncryptionAlgorithm
-		if !assert.NoError(t, dst.Accept(stringer{src: "ECDH-ES+A128KW"}), `accept is successful`) {
+		if !assert.NoError(t, dst.Accept(stringer{src: "HPKE-3-KE"}), `accept is successful`) {
 			return
 		}
-		if !assert.Equal(t, jwa.ECDH_ES_A128KW, dst, `accepted value should be equal to constant`) {
+		if !assert.Equal(t, jwa.HPKE_3_KE, dst, `accepted value should be equal to constant`) {
 			return
 		}
 	})
-	t.Run(`stringification for ECDH-ES+A128KW`, func(t *testing.T) {
+	t.Run(`stringification for HPKE-3-KE`, func(t *testing.T) {
 		t.Parallel()
-		if !assert.Equal(t, "ECDH-ES+A128KW", jwa.ECDH_ES_A128KW.String(), `stringified value matches`) {
+		if !assert.Equal(t, "HPKE-3-KE", jwa.HPKE_3_KE.String(), `stringified value matches`) {
 			return
 		}
 	})
-	t.Run(`accept jwa constant ECDH_ES_A192KW`, func(t *testing.T) {
+	t.Run(`accept jwa constant HPKE_4`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.KeyEncryptionAlgorithm
-		if !assert.NoError(t, dst.Accept(jwa.ECDH_ES_A192KW), `accept is successful`) {
+		if !assert.NoError(t, dst.Accept(jwa.HPKE_4), `accept is successful`) {
 			return
 		}
-		if !assert.Equal(t, jwa.ECDH_ES_A192KW, dst, `accepted value should be equal to constant`) {
+		if !assert.Equal(t, jwa.HPKE_4, dst, `accepted value should be equal to constant`) {
 			return
 		}
 	})
-	t.Run(`accept the string ECDH-ES+A192KW`, func(t *testing.T) {
+	t.Run(`accept the string HPKE-4`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.KeyEncryptionAlgorithm
-		if !assert.NoError(t, dst.Accept("ECDH-ES+A192KW"), `accept is successful`) {
+		if !assert.NoError(t, dst.Accept("HPKE-4"), `accept is successful`) {
 			return
 		}
-		if !assert.Equal(t, jwa.ECDH_ES_A192KW, dst, `accepted value should be equal to constant`) {
+		if !assert.Equal(t, jwa.HPKE_4, dst, `accepted value should be equal to constant`) {
 			return
 		}
 	})
-	t.Run(`accept fmt.Stringer for ECDH-ES+A192KW`, func(t *testing.T) {
+	t.Run(`accept fmt.Stringer for HPKE-4`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.KeyEncryptionAlgorithm
-		if !assert.NoError(t, dst.Accept(stringer{src: "ECDH-ES+A192KW"}), `accept is successful`) {
+		if !assert.NoError(t, dst.Accept(stringer{src: "HPKE-4"}), `accept is successful`) {
 			return
 		}
-		if !assert.Equal(t, jwa.ECDH_ES_A192KW, dst, `accepted value should be equal to constant`) {
+		if !assert.Equal(t, jwa.HPKE_4, dst, `accepted value should be equal to constant`) {
 			return
 		}
 	})
-	t.Run(`stringification for ECDH-ES+A192KW`, func(t *testing.T) {
+	t.Run(`stringification for HPKE-4`, func(t *testing.T) {
 		t.Parallel()
-		if !assert.Equal(t, "ECDH-ES+A192KW", jwa.ECDH_ES_A192KW.String(), `stringified value matches`) {
+		if !assert.Equal(t, "HPKE-4", jwa.HPKE_4.String(), `stringified value matches`) {
 			return
 		}
 	})
-	t.Run(`accept jwa constant ECDH_ES_A256KW`, func(t *testing.T) {
+	t.Run(`accept jwa constant HPKE_4_KE`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.KeyEncryptionAlgorithm
-		if !assert.NoError(t, dst.Accept(jwa.ECDH_ES_A256KW), `accept is successful`) {
+		if !assert.NoError(t, dst.Accept(jwa.HPKE_4_KE), `accept is successful`) {
 			return
 		}
-		if !assert.Equal(t, jwa.ECDH_ES_A256KW, dst, `accepted value should be equal to constant`) {
+		if !assert.Equal(t, jwa.HPKE_4_KE, dst, `accepted value should be equal to constant`) {
 			return
 		}
 	})
-	t.Run(`accept the string ECDH-ES+A256KW`, func(t *testing.T) {
+	t.Run(`accept the string HPKE-4-KE`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.KeyEncryptionAlgorithm
-		if !assert.NoError(t, dst.Accept("ECDH-ES+A256KW"), `accept is successful`) {
+		if !assert.NoError(t, dst.Accept("HPKE-4-KE"), `accept is successful`) {
 			return
 		}
-		if !assert.Equal(t, jwa.ECDH_ES_A256KW, dst, `accepted value should be equal to constant`) {
+		if !assert.Equal(t, jwa.HPKE_4_KE, dst, `accepted value should be equal to constant`) {
 			return
 		}
 	})
-	t.Run(`accept fmt.Stringer for ECDH-ES+A256KW`, func(t *testing.T) {
+	t.Run(`accept fmt.Stringer for HPKE-4-KE`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.KeyEncryptionAlgorithm
-		if !assert.NoError(t, dst.Accept(stringer{src: "ECDH-ES+A256KW"}), `accept is successful`) {
+		if !assert.NoError(t, dst.Accept(stringer{src: "HPKE-4-KE"}), `accept is successful`) {
 			return
 		}
-		if !assert.Equal(t, jwa.ECDH_ES_A256KW, dst, `accepted value should be equal to constant`) {
+		if !assert.Equal(t, jwa.HPKE_4_KE, dst, `accepted value should be equal to constant`) {
 			return
 		}
 	})
-	t.Run(`stringification for ECDH-ES+A256KW`, func(t *testing.T) {
+	t.Run(`stringification for HPKE-4-KE`, func(t *testing.T) {
 		t.Parallel()
-		if !assert.Equal(t, "ECDH-ES+A256KW", jwa.ECDH_ES_A256KW.String(), `stringified value matches`) {
+		if !assert.Equal(t, "HPKE-4-KE", jwa.HPKE_4_KE.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant MLKEM1024`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.MLKEM1024), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLKEM1024, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ML-KEM-1024`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("ML-KEM-1024"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLKEM1024, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ML-KEM-1024`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ML-KEM-1024"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLKEM1024, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ML-KEM-1024`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ML-KEM-1024", jwa.MLKEM1024.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant MLKEM1024_A256KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.MLKEM1024_A256KW), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLKEM1024_A256KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ML-KEM-1024+A256KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("ML-KEM-1024+A256KW"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLKEM1024_A256KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ML-KEM-1024+A256KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ML-KEM-1024+A256KW"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLKEM1024_A256KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ML-KEM-1024+A256KW`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ML-KEM-1024+A256KW", jwa.MLKEM1024_A256KW.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant MLKEM768`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.MLKEM768), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLKEM768, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ML-KEM-768`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("ML-KEM-768"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLKEM768, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ML-KEM-768`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ML-KEM-768"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLKEM768, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ML-KEM-768`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ML-KEM-768", jwa.MLKEM768.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant MLKEM768_A192KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.MLKEM768_A192KW), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLKEM768_A192KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ML-KEM-768+A192KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("ML-KEM-768+A192KW"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLKEM768_A192KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ML-KEM-768+A192KW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ML-KEM-768+A192KW"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLKEM768_A192KW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ML-KEM-768+A192KW`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ML-KEM-768+A192KW", jwa.MLKEM768_A192KW.String(), `stringified value matches`) {
 			return
 		}
 	})
@@ -623,6 +1307,114 @@ func TestKeyEncryptionAlgorithm(t *testing.T) {
 			return
 		}
 	})
+	t.Run(`accept jwa constant RSA_OAEP_384`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.RSA_OAEP_384), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.RSA_OAEP_384, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string RSA-OAEP-384`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("RSA-OAEP-384"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.RSA_OAEP_384, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for RSA-OAEP-384`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "RSA-OAEP-384"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.RSA_OAEP_384, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for RSA-OAEP-384`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "RSA-OAEP-384", jwa.RSA_OAEP_384.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant RSA_OAEP_512`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.RSA_OAEP_512), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.RSA_OAEP_512, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string RSA-OAEP-512`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("RSA-OAEP-512"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.RSA_OAEP_512, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for RSA-OAEP-512`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "RSA-OAEP-512"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.RSA_OAEP_512, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for RSA-OAEP-512`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "RSA-OAEP-512", jwa.RSA_OAEP_512.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant XC20PKW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.XC20PKW), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.XC20PKW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string XC20PKW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept("XC20PKW"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.XC20PKW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for XC20PKW`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyEncryptionAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "XC20PKW"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.XC20PKW, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for XC20PKW`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "XC20PKW", jwa.XC20PKW.String(), `stringified value matches`) {
+			return
+		}
+	})
 	t.Run(`bail out on random integer value`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.KeyEncryptionAlgorithm
@@ -660,6 +1452,18 @@ func TestKeyEncryptionAlgorithm(t *testing.T) {
 		t.Run(`DIRECT`, func(t *testing.T) {
 			assert.True(t, jwa.DIRECT.IsSymmetric(), `jwa.DIRECT should be symmetric`)
 		})
+		t.Run(`ECDH_1PU`, func(t *testing.T) {
+			assert.False(t, jwa.ECDH_1PU.IsSymmetric(), `jwa.ECDH_1PU should NOT be symmetric`)
+		})
+		t.Run(`ECDH_1PU_A128KW`, func(t *testing.T) {
+			assert.False(t, jwa.ECDH_1PU_A128KW.IsSymmetric(), `jwa.ECDH_1PU_A128KW should NOT be symmetric`)
+		})
+		t.Run(`ECDH_1PU_A192KW`, func(t *testing.T) {
+			assert.False(t, jwa.ECDH_1PU_A192KW.IsSymmetric(), `jwa.ECDH_1PU_A192KW should NOT be symmetric`)
+		})
+		t.Run(`ECDH_1PU_A256KW`, func(t *testing.T) {
+			assert.False(t, jwa.ECDH_1PU_A256KW.IsSymmetric(), `jwa.ECDH_1PU_A256KW should NOT be symmetric`)
+		})
 		t.Run(`ECDH_ES`, func(t *testing.T) {
 			assert.False(t, jwa.ECDH_ES.IsSymmetric(), `jwa.ECDH_ES should NOT be symmetric`)
 		})
@@ -672,6 +1476,51 @@ func TestKeyEncryptionAlgorithm(t *testing.T) {
 		t.Run(`ECDH_ES_A256KW`, func(t *testing.T) {
 			assert.False(t, jwa.ECDH_ES_A256KW.IsSymmetric(), `jwa.ECDH_ES_A256KW should NOT be symmetric`)
 		})
+		t.Run(`ECDH_ES_XC20PKW`, func(t *testing.T) {
+			assert.False(t, jwa.ECDH_ES_XC20PKW.IsSymmetric(), `jwa.ECDH_ES_XC20PKW should NOT be symmetric`)
+		})
+		t.Run(`HPKE_0`, func(t *testing.T) {
+			assert.False(t, jwa.HPKE_0.IsSymmetric(), `jwa.HPKE_0 should NOT be symmetric`)
+		})
+		t.Run(`HPKE_0_KE`, func(t *testing.T) {
+			assert.False(t, jwa.HPKE_0_KE.IsSymmetric(), `jwa.HPKE_0_KE should NOT be symmetric`)
+		})
+		t.Run(`HPKE_1`, func(t *testing.T) {
+			assert.False(t, jwa.HPKE_1.IsSymmetric(), `jwa.HPKE_1 should NOT be symmetric`)
+		})
+		t.Run(`HPKE_1_KE`, func(t *testing.T) {
+			assert.False(t, jwa.HPKE_1_KE.IsSymmetric(), `jwa.HPKE_1_KE should NOT be symmetric`)
+		})
+		t.Run(`HPKE_2`, func(t *testing.T) {
+			assert.False(t, jwa.HPKE_2.IsSymmetric(), `jwa.HPKE_2 should NOT be symmetric`)
+		})
+		t.Run(`HPKE_2_KE`, func(t *testing.T) {
+			assert.False(t, jwa.HPKE_2_KE.IsSymmetric(), `jwa.HPKE_2_KE should NOT be symmetric`)
+		})
+		t.Run(`HPKE_3`, func(t *testing.T) {
+			assert.False(t, jwa.HPKE_3.IsSymmetric(), `jwa.HPKE_3 should NOT be symmetric`)
+		})
+		t.Run(`HPKE_3_KE`, func(t *testing.T) {
+			assert.False(t, jwa.HPKE_3_KE.IsSymmetric(), `jwa.HPKE_3_KE should NOT be symmetric`)
+		})
+		t.Run(`HPKE_4`, func(t *testing.T) {
+			assert.False(t, jwa.HPKE_4.IsSymmetric(), `jwa.HPKE_4 should NOT be symmetric`)
+		})
+		t.Run(`HPKE_4_KE`, func(t *testing.T) {
+			assert.False(t, jwa.HPKE_4_KE.IsSymmetric(), `jwa.HPKE_4_KE should NOT be symmetric`)
+		})
+		t.Run(`MLKEM1024`, func(t *testing.T) {
+			assert.False(t, jwa.MLKEM1024.IsSymmetric(), `jwa.MLKEM1024 should NOT be symmetric`)
+		})
+		t.Run(`MLKEM1024_A256KW`, func(t *testing.T) {
+			assert.False(t, jwa.MLKEM1024_A256KW.IsSymmetric(), `jwa.MLKEM1024_A256KW should NOT be symmetric`)
+		})
+		t.Run(`MLKEM768`, func(t *testing.T) {
+			assert.False(t, jwa.MLKEM768.IsSymmetric(), `jwa.MLKEM768 should NOT be symmetric`)
+		})
+		t.Run(`MLKEM768_A192KW`, func(t *testing.T) {
+			assert.False(t, jwa.MLKEM768_A192KW.IsSymmetric(), `jwa.MLKEM768_A192KW should NOT be symmetric`)
+		})
 		t.Run(`PBES2_HS256_A128KW`, func(t *testing.T) {
 			assert.True(t, jwa.PBES2_HS256_A128KW.IsSymmetric(), `jwa.PBES2_HS256_A128KW should be symmetric`)
 		})
@@ -690,6 +1539,15 @@ func TestKeyEncryptionAlgorithm(t *testing.T) {
 		t.Run(`RSA_OAEP_256`, func(t *testing.T) {
 			assert.False(t, jwa.RSA_OAEP_256.IsSymmetric(), `jwa.RSA_OAEP_256 should NOT be symmetric`)
 		})
+		t.Run(`RSA_OAEP_384`, func(t *testing.T) {
+			assert.False(t, jwa.RSA_OAEP_384.IsSymmetric(), `jwa.RSA_OAEP_384 should NOT be symmetric`)
+		})
+		t.Run(`RSA_OAEP_512`, func(t *testing.T) {
+			assert.False(t, jwa.RSA_OAEP_512.IsSymmetric(), `jwa.RSA_OAEP_512 should NOT be symmetric`)
+		})
+		t.Run(`XC20PKW`, func(t *testing.T) {
+			assert.True(t, jwa.XC20PKW.IsSymmetric(), `jwa.XC20PKW should be symmetric`)
+		})
 	})
 	t.Run(`check list of elements`, func(t *testing.T) {
 		t.Parallel()
@@ -701,16 +1559,38 @@ func TestKeyEncryptionAlgorithm(t *testing.T) {
 			jwa.A256GCMKW:          {},
 			jwa.A256KW:             {},
 			jwa.DIRECT:             {},
+			jwa.ECDH_1PU:           {},
+			jwa.ECDH_1PU_A128KW:    {},
+			jwa.ECDH_1PU_A192KW:    {},
+			jwa.ECDH_1PU_A256KW:    {},
 			jwa.ECDH_ES:            {},
 			jwa.ECDH_ES_A128KW:     {},
 			jwa.ECDH_ES_A192KW:     {},
 			jwa.ECDH_ES_A256KW:     {},
+			jwa.ECDH_ES_XC20PKW:    {},
+			jwa.HPKE_0:             {},
+			jwa.HPKE_0_KE:          {},
+			jwa.HPKE_1:             {},
+			jwa.HPKE_1_KE:          {},
+			jwa.HPKE_2:             {},
+			jwa.HPKE_2_KE:          {},
+			jwa.HPKE_3:             {},
+			jwa.HPKE_3_KE:          {},
+			jwa.HPKE_4:             {},
+			jwa.HPKE_4_KE:          {},
+			jwa.MLKEM1024:          {},
+			jwa.MLKEM1024_A256KW:   {},
+			jwa.MLKEM768:           {},
+			jwa.MLKEM768_A192KW:    {},
 			jwa.PBES2_HS256_A128KW: {},
 			jwa.PBES2_HS384_A192KW: {},
 			jwa.PBES2_HS512_A256KW: {},
 			jwa.RSA1_5:             {},
 			jwa.RSA_OAEP:           {},
 			jwa.RSA_OAEP_256:       {},
+			jwa.RSA_OAEP_384:       {},
+			jwa.RSA_OAEP_512:       {},
+			jwa.XC20PKW:            {},
 		}
 		for _, v := range jwa.KeyEncryptionAlgorithms() {
 			if _, ok := expected[v]; !assert.True(t, ok, `%s should be in the expected list`, v) {
@@ -723,3 +1603,32 @@ func TestKeyEncryptionAlgorithm(t *testing.T) {
 		}
 	})
 }
+
+func TestKeyEncryptionAlgorithmCustomAlgorithm(t *testing.T) {
+	const customAlgorithm = jwa.KeyEncryptionAlgorithm(`custom-algorithm`)
+	var dst jwa.KeyEncryptionAlgorithm
+	if !assert.Error(t, dst.Accept(customAlgorithm), `accept should fail before registration`) {
+		return
+	}
+
+	jwa.RegisterKeyEncryptionAlgorithm(customAlgorithm)
+	defer jwa.UnregisterKeyEncryptionAlgorithm(customAlgorithm)
+
+	if !assert.NoError(t, dst.Accept(customAlgorithm.String()), `accept should succeed after registration`) {
+		return
+	}
+	if !assert.Equal(t, customAlgorithm, dst, `accepted value should be equal to constant`) {
+		return
+	}
+	if !assert.Contains(t, jwa.KeyEncryptionAlgorithms(), customAlgorithm, `list should contain the registered value`) {
+		return
+	}
+
+	jwa.UnregisterKeyEncryptionAlgorithm(customAlgorithm)
+	if !assert.Error(t, dst.Accept(customAlgorithm), `accept should fail after unregistration`) {
+		return
+	}
+	if !assert.NotContains(t, jwa.KeyEncryptionAlgorithms(), customAlgorithm, `list should not contain the unregistered value`) {
+		return
+	}
+}
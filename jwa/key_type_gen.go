@@ -13,6 +13,7 @@ type KeyType string
 
 // Supported values for KeyType
 const (
+	AKP            KeyType = "AKP" // Algorithm key pairs (used to represent post-quantum keys such as ML-DSA)
 	EC             KeyType = "EC"  // Elliptic Curve
 	InvalidKeyType KeyType = ""    // Invalid KeyType
 	OKP            KeyType = "OKP" // Octet string key pairs
@@ -21,6 +22,7 @@ const (
 )
 
 var allKeyTypes = map[KeyType]struct{}{
+	AKP:      {},
 	EC:       {},
 	OKP:      {},
 	OctetSeq: {},
@@ -0,0 +1,203 @@
+package jwa
+
+import (
+	"fmt"
+)
+
+// KeyStrengthPolicy decides the minimum strength of keys that may be
+// used when signing, verifying, encrypting, or decrypting messages.
+//
+// A policy can specify the minimum size of RSA keys, the minimum size
+// of HMAC keys for each HMAC based signature algorithm, the minimum
+// size of AES keys (used for both key wrapping and content encryption),
+// and the list of elliptic curves that may be used. Requirements that
+// have not been specified are not enforced.
+//
+// KeyStrengthPolicy objects are immutable: methods that modify the
+// policy return a new policy. A nil policy allows all keys.
+//
+// Policies are accepted by `jws.Settings()`, `jws.WithKeyStrengthPolicy()`,
+// `jwe.Settings()`, and `jwe.WithKeyStrengthPolicy()`.
+type KeyStrengthPolicy struct {
+	minRSAKeySize  int
+	minHMACKeySize map[SignatureAlgorithm]int
+	minAESKeySize  int
+	curves         map[EllipticCurveAlgorithm]struct{}
+}
+
+// NewKeyStrengthPolicy creates a new policy that allows all keys
+func NewKeyStrengthPolicy() *KeyStrengthPolicy {
+	return &KeyStrengthPolicy{}
+}
+
+// StrictKeyStrengthPolicy creates a new policy that enforces the
+// requirements of RFC7518: RSA keys must be at least 2048 bits long,
+// and HMAC keys must be at least as long as the output of the hash
+// function (Section 3.2).
+func StrictKeyStrengthPolicy() *KeyStrengthPolicy {
+	return NewKeyStrengthPolicy().
+		MinRSAKeySize(2048).
+		MinHMACKeySize(HS256, 256).
+		MinHMACKeySize(HS384, 384).
+		MinHMACKeySize(HS512, 512)
+}
+
+func (p *KeyStrengthPolicy) clone() *KeyStrengthPolicy {
+	dst := &KeyStrengthPolicy{
+		minHMACKeySize: make(map[SignatureAlgorithm]int),
+	}
+	if p == nil {
+		return dst
+	}
+	dst.minRSAKeySize = p.minRSAKeySize
+	dst.minAESKeySize = p.minAESKeySize
+	for k, v := range p.minHMACKeySize {
+		dst.minHMACKeySize[k] = v
+	}
+	if p.curves != nil {
+		dst.curves = make(map[EllipticCurveAlgorithm]struct{})
+		for k := range p.curves {
+			dst.curves[k] = struct{}{}
+		}
+	}
+	return dst
+}
+
+// MinRSAKeySize returns a new policy that requires RSA keys to be
+// at least `bits` bits long
+func (p *KeyStrengthPolicy) MinRSAKeySize(bits int) *KeyStrengthPolicy {
+	dst := p.clone()
+	dst.minRSAKeySize = bits
+	return dst
+}
+
+// MinHMACKeySize returns a new policy that requires keys used with
+// the HMAC based signature algorithm `alg` to be at least `bits` bits long
+func (p *KeyStrengthPolicy) MinHMACKeySize(alg SignatureAlgorithm, bits int) *KeyStrengthPolicy {
+	dst := p.clone()
+	dst.minHMACKeySize[alg] = bits
+	return dst
+}
+
+// MinAESKeySize returns a new policy that requires AES keys to be at
+// least `bits` bits long. This applies to keys used for AES key wrapping
+// (e.g. A128KW and A128GCMKW) as well as the AES keys derived from the
+// content encryption key (e.g. 128 bits for both A128GCM and A128CBC-HS256)
+func (p *KeyStrengthPolicy) MinAESKeySize(bits int) *KeyStrengthPolicy {
+	dst := p.clone()
+	dst.minAESKeySize = bits
+	return dst
+}
+
+// AllowCurves returns a new policy that only allows elliptic curve
+// keys (including OKP keys such as Ed25519 and X25519) that use one
+// of the given curves. Calling this method multiple times adds to
+// the list of allowed curves.
+func (p *KeyStrengthPolicy) AllowCurves(crvs ...EllipticCurveAlgorithm) *KeyStrengthPolicy {
+	dst := p.clone()
+	if dst.curves == nil {
+		dst.curves = make(map[EllipticCurveAlgorithm]struct{})
+	}
+	for _, crv := range crvs {
+		dst.curves[crv] = struct{}{}
+	}
+	return dst
+}
+
+// CheckRSAKeySize returns an error if the policy does not allow
+// RSA keys of the given size (in bits)
+func (p *KeyStrengthPolicy) CheckRSAKeySize(bits int) error {
+	if p == nil || bits >= p.minRSAKeySize {
+		return nil
+	}
+	return &keyTooSmallError{kind: `RSA`, bits: bits, min: p.minRSAKeySize}
+}
+
+// CheckHMACKeySize returns an error if the policy does not allow
+// keys of the given size (in bits) to be used with the HMAC based
+// signature algorithm `alg`
+func (p *KeyStrengthPolicy) CheckHMACKeySize(alg SignatureAlgorithm, bits int) error {
+	if p == nil {
+		return nil
+	}
+	if min := p.minHMACKeySize[alg]; bits < min {
+		return &keyTooSmallError{kind: alg.String(), bits: bits, min: min}
+	}
+	return nil
+}
+
+// CheckAESKeySize returns an error if the policy does not allow
+// AES keys of the given size (in bits)
+func (p *KeyStrengthPolicy) CheckAESKeySize(bits int) error {
+	if p == nil || bits >= p.minAESKeySize {
+		return nil
+	}
+	return &keyTooSmallError{kind: `AES`, bits: bits, min: p.minAESKeySize}
+}
+
+// CheckCurve returns an error if the policy does not allow keys
+// using the given elliptic curve
+func (p *KeyStrengthPolicy) CheckCurve(crv EllipticCurveAlgorithm) error {
+	if p == nil || p.curves == nil {
+		return nil
+	}
+	if _, ok := p.curves[crv]; !ok {
+		return &curveNotAllowedError{crv: crv.String()}
+	}
+	return nil
+}
+
+type keyTooSmallError struct {
+	kind string
+	bits int
+	min  int
+}
+
+func (err *keyTooSmallError) Error() string {
+	if err.kind == "" {
+		return `key is too small`
+	}
+	return fmt.Sprintf(`%s key is too small: expected at least %d bits, got %d bits`, err.kind, err.min, err.bits)
+}
+
+func (err *keyTooSmallError) Is(target error) bool {
+	_, ok := target.(*keyTooSmallError)
+	return ok
+}
+
+var errKeyTooSmall = &keyTooSmallError{}
+
+// ErrKeyTooSmall returns the immutable error used when a key is
+// rejected by a `jwa.KeyStrengthPolicy` because it is too small.
+//
+// The return value should only be used for comparison using `errors.Is()`
+func ErrKeyTooSmall() error {
+	return errKeyTooSmall
+}
+
+type curveNotAllowedError struct {
+	crv string
+}
+
+func (err *curveNotAllowedError) Error() string {
+	if err.crv == "" {
+		return `curve is not allowed by policy`
+	}
+	return fmt.Sprintf(`curve %q is not allowed by policy`, err.crv)
+}
+
+func (err *curveNotAllowedError) Is(target error) bool {
+	_, ok := target.(*curveNotAllowedError)
+	return ok
+}
+
+var errCurveNotAllowed = &curveNotAllowedError{}
+
+// ErrCurveNotAllowed returns the immutable error used when a key is
+// rejected by a `jwa.KeyStrengthPolicy` because its elliptic curve
+// is not in the list of allowed curves.
+//
+// The return value should only be used for comparison using `errors.Is()`
+func ErrCurveNotAllowed() error {
+	return errCurveNotAllowed
+}
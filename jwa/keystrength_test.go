@@ -0,0 +1,56 @@
+package jwa_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sjwl/jwx/v2/jwa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyStrengthPolicy(t *testing.T) {
+	t.Run("nil policy allows everything", func(t *testing.T) {
+		var p *jwa.KeyStrengthPolicy
+		assert.NoError(t, p.CheckRSAKeySize(512))
+		assert.NoError(t, p.CheckHMACKeySize(jwa.HS256, 8))
+		assert.NoError(t, p.CheckAESKeySize(0))
+		assert.NoError(t, p.CheckCurve(jwa.P256))
+	})
+	t.Run("strict policy", func(t *testing.T) {
+		p := jwa.StrictKeyStrengthPolicy()
+
+		err := p.CheckRSAKeySize(1024)
+		assert.Error(t, err, `1024 bit RSA keys should be rejected`)
+		assert.True(t, errors.Is(err, jwa.ErrKeyTooSmall()), `error should be ErrKeyTooSmall`)
+		assert.NoError(t, p.CheckRSAKeySize(2048))
+
+		for alg, bits := range map[jwa.SignatureAlgorithm]int{jwa.HS256: 256, jwa.HS384: 384, jwa.HS512: 512} {
+			assert.True(t, errors.Is(p.CheckHMACKeySize(alg, bits-8), jwa.ErrKeyTooSmall()), `%s keys smaller than %d bits should be rejected`, alg, bits)
+			assert.NoError(t, p.CheckHMACKeySize(alg, bits), `%s keys of %d bits should be accepted`, alg, bits)
+		}
+
+		assert.NoError(t, p.CheckAESKeySize(128), `AES keys are not restricted`)
+		assert.NoError(t, p.CheckCurve(jwa.P256), `curves are not restricted`)
+	})
+	t.Run("AES key size", func(t *testing.T) {
+		p := jwa.NewKeyStrengthPolicy().MinAESKeySize(256)
+		assert.True(t, errors.Is(p.CheckAESKeySize(128), jwa.ErrKeyTooSmall()))
+		assert.NoError(t, p.CheckAESKeySize(256))
+	})
+	t.Run("allowed curves", func(t *testing.T) {
+		p := jwa.NewKeyStrengthPolicy().AllowCurves(jwa.P384).AllowCurves(jwa.Ed25519)
+		assert.NoError(t, p.CheckCurve(jwa.P384))
+		assert.NoError(t, p.CheckCurve(jwa.Ed25519))
+
+		err := p.CheckCurve(jwa.P256)
+		assert.Error(t, err, `P-256 should be rejected`)
+		assert.True(t, errors.Is(err, jwa.ErrCurveNotAllowed()), `error should be ErrCurveNotAllowed`)
+		assert.False(t, errors.Is(err, jwa.ErrKeyTooSmall()), `error should not be ErrKeyTooSmall`)
+	})
+	t.Run("policies are immutable", func(t *testing.T) {
+		p1 := jwa.NewKeyStrengthPolicy()
+		p2 := p1.MinRSAKeySize(4096)
+		assert.NoError(t, p1.CheckRSAKeySize(2048), `p1 should not be modified`)
+		assert.Error(t, p2.CheckRSAKeySize(2048))
+	})
+}
@@ -0,0 +1,240 @@
+package jwa
+
+import (
+	"crypto"
+	"sync"
+)
+
+// AlgorithmMetadata describes the properties of an algorithm, so that
+// users (and the library itself) can make decisions about algorithms
+// without hard-coding their own tables.
+//
+// Metadata for the algorithms defined in this package is available
+// via the `Metadata()` method of `jwa.SignatureAlgorithm`,
+// `jwa.KeyEncryptionAlgorithm`, and `jwa.ContentEncryptionAlgorithm`.
+// Metadata for custom algorithms may be registered using
+// `jwa.RegisterSignatureAlgorithmMetadata()` and friends.
+type AlgorithmMetadata struct {
+	// KeyTypes lists the key types that can be used with the algorithm.
+	// It is empty for algorithms that do not use keys (e.g. "none")
+	KeyTypes []KeyType
+
+	// Hash is the hash function used by the algorithm. It is zero
+	// if the algorithm does not use a hash function, or if the hash
+	// function is not fixed by the algorithm (e.g. EdDSA)
+	Hash crypto.Hash
+
+	// KeySize is the size of the key in bits, for algorithms that
+	// require a key of a particular size (e.g. 256 for ES256 and
+	// A256KW). It is zero if the size of the key is not fixed.
+	KeySize int
+
+	// MinKeySize is the minimum size of the key in bits, for algorithms
+	// that accept keys of varying sizes (e.g. 2048 for RS256, as required
+	// by RFC7518). It is zero if there is no such requirement.
+	MinKeySize int
+
+	// Curve is the curve that must be used with the algorithm, for
+	// fully-specified algorithms such as ESP256, Ed25519 and HPKE-0. It is
+	// empty if the algorithm does not restrict the curve by itself
+	// (e.g. EdDSA, whose curve is determined by the key)
+	Curve EllipticCurveAlgorithm
+
+	// Symmetric is true if the algorithm uses a shared secret
+	Symmetric bool
+
+	// Deprecated is true if the use of the algorithm is discouraged
+	// by the relevant specifications, and should not be used in new
+	// applications
+	Deprecated bool
+
+	// Unsafe is true if the algorithm is known to be insecure, or
+	// provides no protection at all (e.g. "none")
+	Unsafe bool
+}
+
+// HasKeyType returns true if the given key type can be used with the algorithm
+func (md AlgorithmMetadata) HasKeyType(kty KeyType) bool {
+	for _, v := range md.KeyTypes {
+		if v == kty {
+			return true
+		}
+	}
+	return false
+}
+
+var muAlgorithmMetadata sync.RWMutex
+var algorithmMetadata = map[interface{}]AlgorithmMetadata{}
+
+func init() {
+	rsaKey := []KeyType{RSA}
+	ecKey := []KeyType{EC}
+	okpKey := []KeyType{OKP}
+	ecdhKey := []KeyType{EC, OKP}
+	octKey := []KeyType{OctetSeq}
+	akpKey := []KeyType{AKP}
+
+	// The names of the brainpool curves are spelled out, because the
+	// jwa.BrainpoolPxxxr1 constants require the jwx_brainpool build tag
+	for alg, md := range map[SignatureAlgorithm]AlgorithmMetadata{
+		ES256:        {KeyTypes: ecKey, Hash: crypto.SHA256, KeySize: 256},
+		ES256K:       {KeyTypes: ecKey, Hash: crypto.SHA256, KeySize: 256},
+		ESB256:       {KeyTypes: ecKey, Hash: crypto.SHA256, KeySize: 256, Curve: "BP-256"},
+		ESB384:       {KeyTypes: ecKey, Hash: crypto.SHA384, KeySize: 384, Curve: "BP-384"},
+		ESB512:       {KeyTypes: ecKey, Hash: crypto.SHA512, KeySize: 512, Curve: "BP-512"},
+		ES384:        {KeyTypes: ecKey, Hash: crypto.SHA384, KeySize: 384},
+		ES512:        {KeyTypes: ecKey, Hash: crypto.SHA512, KeySize: 521},
+		ESP256:       {KeyTypes: ecKey, Hash: crypto.SHA256, KeySize: 256, Curve: P256},
+		ESP384:       {KeyTypes: ecKey, Hash: crypto.SHA384, KeySize: 384, Curve: P384},
+		ESP512:       {KeyTypes: ecKey, Hash: crypto.SHA512, KeySize: 521, Curve: P521},
+		EdDSA:        {KeyTypes: okpKey},
+		EdDSAEd25519: {KeyTypes: okpKey, Curve: Ed25519},
+		EdDSAEd448:   {KeyTypes: okpKey, Curve: Ed448},
+		HS256:        {KeyTypes: octKey, Hash: crypto.SHA256, MinKeySize: 256, Symmetric: true},
+		HS384:        {KeyTypes: octKey, Hash: crypto.SHA384, MinKeySize: 384, Symmetric: true},
+		HS512:        {KeyTypes: octKey, Hash: crypto.SHA512, MinKeySize: 512, Symmetric: true},
+		MLDSA44:      {KeyTypes: akpKey},
+		MLDSA65:      {KeyTypes: akpKey},
+		MLDSA87:      {KeyTypes: akpKey},
+		NoSignature:  {Deprecated: true, Unsafe: true},
+		PS256:        {KeyTypes: rsaKey, Hash: crypto.SHA256, MinKeySize: 2048},
+		PS384:        {KeyTypes: rsaKey, Hash: crypto.SHA384, MinKeySize: 2048},
+		PS512:        {KeyTypes: rsaKey, Hash: crypto.SHA512, MinKeySize: 2048},
+		RS256:        {KeyTypes: rsaKey, Hash: crypto.SHA256, MinKeySize: 2048},
+		RS384:        {KeyTypes: rsaKey, Hash: crypto.SHA384, MinKeySize: 2048},
+		RS512:        {KeyTypes: rsaKey, Hash: crypto.SHA512, MinKeySize: 2048},
+	} {
+		algorithmMetadata[alg] = md
+	}
+
+	for alg, md := range map[KeyEncryptionAlgorithm]AlgorithmMetadata{
+		A128GCMKW:          {KeyTypes: octKey, KeySize: 128, Symmetric: true},
+		A128KW:             {KeyTypes: octKey, KeySize: 128, Symmetric: true},
+		A192GCMKW:          {KeyTypes: octKey, KeySize: 192, Symmetric: true},
+		A192KW:             {KeyTypes: octKey, KeySize: 192, Symmetric: true},
+		A256GCMKW:          {KeyTypes: octKey, KeySize: 256, Symmetric: true},
+		A256KW:             {KeyTypes: octKey, KeySize: 256, Symmetric: true},
+		DIRECT:             {KeyTypes: octKey, Symmetric: true},
+		ECDH_ES:            {KeyTypes: ecdhKey, Hash: crypto.SHA256},
+		ECDH_ES_A128KW:     {KeyTypes: ecdhKey, Hash: crypto.SHA256},
+		ECDH_ES_A192KW:     {KeyTypes: ecdhKey, Hash: crypto.SHA256},
+		ECDH_ES_A256KW:     {KeyTypes: ecdhKey, Hash: crypto.SHA256},
+		ECDH_ES_XC20PKW:    {KeyTypes: ecdhKey, Hash: crypto.SHA256},
+		ECDH_1PU:           {KeyTypes: ecdhKey, Hash: crypto.SHA256},
+		ECDH_1PU_A128KW:    {KeyTypes: ecdhKey, Hash: crypto.SHA256},
+		ECDH_1PU_A192KW:    {KeyTypes: ecdhKey, Hash: crypto.SHA256},
+		ECDH_1PU_A256KW:    {KeyTypes: ecdhKey, Hash: crypto.SHA256},
+		HPKE_0:             {KeyTypes: ecKey, Hash: crypto.SHA256, Curve: P256},
+		HPKE_0_KE:          {KeyTypes: ecKey, Hash: crypto.SHA256, Curve: P256},
+		HPKE_1:             {KeyTypes: ecKey, Hash: crypto.SHA384, Curve: P384},
+		HPKE_1_KE:          {KeyTypes: ecKey, Hash: crypto.SHA384, Curve: P384},
+		HPKE_2:             {KeyTypes: ecKey, Hash: crypto.SHA512, Curve: P521},
+		HPKE_2_KE:          {KeyTypes: ecKey, Hash: crypto.SHA512, Curve: P521},
+		HPKE_3:             {KeyTypes: okpKey, Hash: crypto.SHA256, Curve: X25519},
+		HPKE_3_KE:          {KeyTypes: okpKey, Hash: crypto.SHA256, Curve: X25519},
+		HPKE_4:             {KeyTypes: okpKey, Hash: crypto.SHA256, Curve: X25519},
+		HPKE_4_KE:          {KeyTypes: okpKey, Hash: crypto.SHA256, Curve: X25519},
+		MLKEM768:           {KeyTypes: akpKey, Hash: crypto.SHA256},
+		MLKEM768_A192KW:    {KeyTypes: akpKey, Hash: crypto.SHA256},
+		MLKEM1024:          {KeyTypes: akpKey, Hash: crypto.SHA256},
+		MLKEM1024_A256KW:   {KeyTypes: akpKey, Hash: crypto.SHA256},
+		PBES2_HS256_A128KW: {KeyTypes: octKey, Hash: crypto.SHA256, Symmetric: true},
+		PBES2_HS384_A192KW: {KeyTypes: octKey, Hash: crypto.SHA384, Symmetric: true},
+		PBES2_HS512_A256KW: {KeyTypes: octKey, Hash: crypto.SHA512, Symmetric: true},
+		RSA1_5:             {KeyTypes: rsaKey, MinKeySize: 2048, Deprecated: true, Unsafe: true},
+		RSA_OAEP:           {KeyTypes: rsaKey, Hash: crypto.SHA1, MinKeySize: 2048},
+		RSA_OAEP_256:       {KeyTypes: rsaKey, Hash: crypto.SHA256, MinKeySize: 2048},
+		RSA_OAEP_384:       {KeyTypes: rsaKey, Hash: crypto.SHA384, MinKeySize: 2048},
+		RSA_OAEP_512:       {KeyTypes: rsaKey, Hash: crypto.SHA512, MinKeySize: 2048},
+		XC20PKW:            {KeyTypes: octKey, KeySize: 256, Symmetric: true},
+	} {
+		algorithmMetadata[alg] = md
+	}
+
+	// For content encryption algorithms, KeySize is the size of the CEK
+	for alg, md := range map[ContentEncryptionAlgorithm]AlgorithmMetadata{
+		A128CBC_HS256: {KeyTypes: octKey, Hash: crypto.SHA256, KeySize: 256, Symmetric: true},
+		A128GCM:       {KeyTypes: octKey, KeySize: 128, Symmetric: true},
+		A192CBC_HS384: {KeyTypes: octKey, Hash: crypto.SHA384, KeySize: 384, Symmetric: true},
+		A192GCM:       {KeyTypes: octKey, KeySize: 192, Symmetric: true},
+		A256CBC_HS512: {KeyTypes: octKey, Hash: crypto.SHA512, KeySize: 512, Symmetric: true},
+		A256GCM:       {KeyTypes: octKey, KeySize: 256, Symmetric: true},
+		XC20P:         {KeyTypes: octKey, KeySize: 256, Symmetric: true},
+	} {
+		algorithmMetadata[alg] = md
+	}
+}
+
+func lookupAlgorithmMetadata(alg interface{}) (AlgorithmMetadata, bool) {
+	muAlgorithmMetadata.RLock()
+	md, ok := algorithmMetadata[alg]
+	muAlgorithmMetadata.RUnlock()
+	return md, ok
+}
+
+func registerAlgorithmMetadata(alg interface{}, md AlgorithmMetadata) {
+	muAlgorithmMetadata.Lock()
+	algorithmMetadata[alg] = md
+	muAlgorithmMetadata.Unlock()
+}
+
+// Metadata returns the metadata associated with the algorithm. The
+// second return value is false if no metadata is known for the algorithm.
+func (v SignatureAlgorithm) Metadata() (AlgorithmMetadata, bool) {
+	return lookupAlgorithmMetadata(v)
+}
+
+// Metadata returns the metadata associated with the algorithm. The
+// second return value is false if no metadata is known for the algorithm.
+func (v KeyEncryptionAlgorithm) Metadata() (AlgorithmMetadata, bool) {
+	return lookupAlgorithmMetadata(v)
+}
+
+// Metadata returns the metadata associated with the algorithm. The
+// second return value is false if no metadata is known for the algorithm.
+func (v ContentEncryptionAlgorithm) Metadata() (AlgorithmMetadata, bool) {
+	return lookupAlgorithmMetadata(v)
+}
+
+var polymorphicSignatureAlgorithms = map[SignatureAlgorithm]SignatureAlgorithm{
+	ESP256:       ES256,
+	ESP384:       ES384,
+	ESP512:       ES512,
+	EdDSAEd25519: EdDSA,
+	EdDSAEd448:   EdDSA,
+}
+
+// Polymorphic returns the polymorphic algorithm that corresponds to
+// a fully-specified algorithm, e.g. jwa.EdDSA for jwa.EdDSAEd25519 and
+// jwa.ES256 for jwa.ESP256. Signatures created using either algorithm
+// are identical, so the returned value may be used when interoperating
+// with implementations that do not know about fully-specified algorithms.
+//
+// If the algorithm has no polymorphic counterpart, v itself is returned
+func (v SignatureAlgorithm) Polymorphic() SignatureAlgorithm {
+	if alg, ok := polymorphicSignatureAlgorithms[v]; ok {
+		return alg
+	}
+	return v
+}
+
+// RegisterSignatureAlgorithmMetadata associates metadata with a
+// signature algorithm, replacing any existing metadata. This is
+// typically used along with `jwa.RegisterSignatureAlgorithm()`
+func RegisterSignatureAlgorithmMetadata(v SignatureAlgorithm, md AlgorithmMetadata) {
+	registerAlgorithmMetadata(v, md)
+}
+
+// RegisterKeyEncryptionAlgorithmMetadata associates metadata with a
+// key encryption algorithm, replacing any existing metadata. This is
+// typically used along with `jwa.RegisterKeyEncryptionAlgorithm()`
+func RegisterKeyEncryptionAlgorithmMetadata(v KeyEncryptionAlgorithm, md AlgorithmMetadata) {
+	registerAlgorithmMetadata(v, md)
+}
+
+// RegisterContentEncryptionAlgorithmMetadata associates metadata with a
+// content encryption algorithm, replacing any existing metadata. This is
+// typically used along with `jwa.RegisterContentEncryptionAlgorithm()`
+func RegisterContentEncryptionAlgorithmMetadata(v ContentEncryptionAlgorithm, md AlgorithmMetadata) {
+	registerAlgorithmMetadata(v, md)
+}
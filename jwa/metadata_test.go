@@ -0,0 +1,96 @@
+package jwa_test
+
+import (
+	"crypto"
+	"testing"
+
+	"github.com/sjwl/jwx/v2/jwa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlgorithmMetadata(t *testing.T) {
+	t.Run("all built-in algorithms have metadata", func(t *testing.T) {
+		for _, alg := range jwa.SignatureAlgorithms() {
+			_, ok := alg.Metadata()
+			assert.True(t, ok, `%s should have metadata`, alg)
+		}
+		for _, alg := range jwa.KeyEncryptionAlgorithms() {
+			md, ok := alg.Metadata()
+			assert.True(t, ok, `%s should have metadata`, alg)
+			assert.Equal(t, alg.IsSymmetric(), md.Symmetric, `%s: Symmetric should match IsSymmetric()`, alg)
+		}
+		for _, alg := range jwa.ContentEncryptionAlgorithms() {
+			_, ok := alg.Metadata()
+			assert.True(t, ok, `%s should have metadata`, alg)
+		}
+	})
+	t.Run("spot checks", func(t *testing.T) {
+		md, _ := jwa.RS256.Metadata()
+		assert.True(t, md.HasKeyType(jwa.RSA), `RS256 should accept RSA keys`)
+		assert.False(t, md.HasKeyType(jwa.EC), `RS256 should not accept EC keys`)
+		assert.Equal(t, crypto.SHA256, md.Hash)
+		assert.Equal(t, 2048, md.MinKeySize)
+
+		md, _ = jwa.ES512.Metadata()
+		assert.Equal(t, 521, md.KeySize)
+
+		md, _ = jwa.NoSignature.Metadata()
+		assert.True(t, md.Unsafe, `none should be unsafe`)
+		assert.Len(t, md.KeyTypes, 0)
+
+		md, _ = jwa.RSA1_5.Metadata()
+		assert.True(t, md.Deprecated, `RSA1_5 should be deprecated`)
+		assert.True(t, md.Unsafe, `RSA1_5 should be unsafe`)
+
+		md, _ = jwa.ECDH_ES.Metadata()
+		assert.True(t, md.HasKeyType(jwa.EC))
+		assert.True(t, md.HasKeyType(jwa.OKP))
+
+		md, _ = jwa.A128CBC_HS256.Metadata()
+		assert.Equal(t, 256, md.KeySize)
+	})
+	t.Run("fully-specified algorithms", func(t *testing.T) {
+		testcases := []struct {
+			Algorithm   jwa.SignatureAlgorithm
+			Curve       jwa.EllipticCurveAlgorithm
+			Polymorphic jwa.SignatureAlgorithm
+		}{
+			{Algorithm: jwa.ESP256, Curve: jwa.P256, Polymorphic: jwa.ES256},
+			{Algorithm: jwa.ESP384, Curve: jwa.P384, Polymorphic: jwa.ES384},
+			{Algorithm: jwa.ESP512, Curve: jwa.P521, Polymorphic: jwa.ES512},
+			{Algorithm: jwa.EdDSAEd25519, Curve: jwa.Ed25519, Polymorphic: jwa.EdDSA},
+			{Algorithm: jwa.EdDSAEd448, Curve: jwa.Ed448, Polymorphic: jwa.EdDSA},
+			{Algorithm: jwa.ESB256, Curve: jwa.EllipticCurveAlgorithm("BP-256"), Polymorphic: jwa.ESB256},
+			{Algorithm: jwa.EdDSA, Polymorphic: jwa.EdDSA},
+			{Algorithm: jwa.ES256, Polymorphic: jwa.ES256},
+		}
+		for _, tc := range testcases {
+			md, ok := tc.Algorithm.Metadata()
+			assert.True(t, ok, `%s should have metadata`, tc.Algorithm)
+			assert.Equal(t, tc.Curve, md.Curve, `%s: Curve should match`, tc.Algorithm)
+			assert.Equal(t, tc.Polymorphic, tc.Algorithm.Polymorphic(), `%s: Polymorphic() should match`, tc.Algorithm)
+		}
+
+		md, _ := jwa.HPKE_0.Metadata()
+		assert.Equal(t, jwa.P256, md.Curve, `HPKE-0 should require P-256`)
+		md, _ = jwa.ECDH_ES.Metadata()
+		assert.Empty(t, md.Curve, `ECDH-ES should not restrict the curve`)
+	})
+	t.Run("custom algorithm", func(t *testing.T) {
+		const alg = jwa.SignatureAlgorithm(`X-METADATA`)
+		_, ok := alg.Metadata()
+		assert.False(t, ok, `unknown algorithms should not have metadata`)
+
+		jwa.RegisterSignatureAlgorithmMetadata(alg, jwa.AlgorithmMetadata{
+			KeyTypes: []jwa.KeyType{jwa.OKP},
+			Unsafe:   true,
+		})
+		md, ok := alg.Metadata()
+		assert.True(t, ok, `registered algorithms should have metadata`)
+		assert.True(t, md.Unsafe)
+
+		// metadata is keyed by type as well as name
+		_, ok = jwa.KeyEncryptionAlgorithm(alg).Metadata()
+		assert.False(t, ok, `metadata should not leak to other algorithm types`)
+	})
+}
@@ -0,0 +1,140 @@
+package jwa
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// AlgorithmPolicy decides which algorithms may be used when verifying
+// or decrypting messages. Policies are checked against the "alg" and
+// "enc" values of incoming messages before any cryptographic operation
+// takes place, which protects against algorithm confusion and
+// downgrade attacks.
+//
+// A policy consists of an allow list and a deny list. Algorithms in
+// the deny list are always rejected. If the allow list contains
+// algorithms of a given type (e.g. `jwa.SignatureAlgorithm`), all
+// algorithms of the same type that are not in the allow list are
+// rejected. Types that do not appear in the allow list are not
+// restricted, so allowing ES256 does not affect the JWE content
+// encryption algorithms.
+//
+// AlgorithmPolicy objects are immutable: `Allow()` and `Deny()` return
+// a new policy. A nil policy allows all algorithms.
+//
+// Policies are accepted by `jws.Settings()`, `jws.WithAlgorithmPolicy()`,
+// `jwe.Settings()`, and `jwe.WithAlgorithmPolicy()`.
+type AlgorithmPolicy struct {
+	allowed      map[interface{}]struct{}
+	allowedTypes map[reflect.Type]struct{}
+	denied       map[interface{}]struct{}
+}
+
+// NewAlgorithmPolicy creates a new policy that allows all algorithms
+func NewAlgorithmPolicy() *AlgorithmPolicy {
+	return &AlgorithmPolicy{}
+}
+
+// StrictAlgorithmPolicy creates a new policy that denies algorithms
+// that are either unsafe or are prone to misuse: "none", RSA1_5,
+// the PBES2 family, and "dir".
+func StrictAlgorithmPolicy() *AlgorithmPolicy {
+	return NewAlgorithmPolicy().Deny(
+		NoSignature,
+		RSA1_5,
+		DIRECT,
+		PBES2_HS256_A128KW,
+		PBES2_HS384_A192KW,
+		PBES2_HS512_A256KW,
+	)
+}
+
+func (p *AlgorithmPolicy) clone() *AlgorithmPolicy {
+	dst := &AlgorithmPolicy{
+		allowed:      make(map[interface{}]struct{}),
+		allowedTypes: make(map[reflect.Type]struct{}),
+		denied:       make(map[interface{}]struct{}),
+	}
+	if p == nil {
+		return dst
+	}
+	for k := range p.allowed {
+		dst.allowed[k] = struct{}{}
+	}
+	for k := range p.allowedTypes {
+		dst.allowedTypes[k] = struct{}{}
+	}
+	for k := range p.denied {
+		dst.denied[k] = struct{}{}
+	}
+	return dst
+}
+
+// Allow returns a new policy with the given algorithms added to the
+// allow list. `algs` should be values of type `jwa.SignatureAlgorithm`,
+// `jwa.KeyEncryptionAlgorithm`, or `jwa.ContentEncryptionAlgorithm`
+func (p *AlgorithmPolicy) Allow(algs ...KeyAlgorithm) *AlgorithmPolicy {
+	dst := p.clone()
+	for _, alg := range algs {
+		dst.allowed[alg] = struct{}{}
+		dst.allowedTypes[reflect.TypeOf(alg)] = struct{}{}
+	}
+	return dst
+}
+
+// Deny returns a new policy with the given algorithms added to the
+// deny list. `algs` should be values of type `jwa.SignatureAlgorithm`,
+// `jwa.KeyEncryptionAlgorithm`, or `jwa.ContentEncryptionAlgorithm`
+func (p *AlgorithmPolicy) Deny(algs ...KeyAlgorithm) *AlgorithmPolicy {
+	dst := p.clone()
+	for _, alg := range algs {
+		dst.denied[alg] = struct{}{}
+	}
+	return dst
+}
+
+// Check returns an error if the policy does not allow the use of
+// the given algorithm. The error can be detected by using
+// `errors.Is(err, jwa.ErrAlgorithmNotAllowed())`
+func (p *AlgorithmPolicy) Check(alg KeyAlgorithm) error {
+	if p == nil {
+		return nil
+	}
+
+	if _, ok := p.denied[alg]; ok {
+		return &algorithmNotAllowedError{alg: alg.String()}
+	}
+
+	if _, ok := p.allowedTypes[reflect.TypeOf(alg)]; ok {
+		if _, ok := p.allowed[alg]; !ok {
+			return &algorithmNotAllowedError{alg: alg.String()}
+		}
+	}
+	return nil
+}
+
+type algorithmNotAllowedError struct {
+	alg string
+}
+
+func (err *algorithmNotAllowedError) Error() string {
+	if err.alg == "" {
+		return `algorithm is not allowed by policy`
+	}
+	return fmt.Sprintf(`algorithm %q is not allowed by policy`, err.alg)
+}
+
+func (err *algorithmNotAllowedError) Is(target error) bool {
+	_, ok := target.(*algorithmNotAllowedError)
+	return ok
+}
+
+var errAlgorithmNotAllowed = &algorithmNotAllowedError{}
+
+// ErrAlgorithmNotAllowed returns the immutable error used when an
+// algorithm is rejected by a `jwa.AlgorithmPolicy`.
+//
+// The return value should only be used for comparison using `errors.Is()`
+func ErrAlgorithmNotAllowed() error {
+	return errAlgorithmNotAllowed
+}
@@ -0,0 +1,48 @@
+package jwa_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sjwl/jwx/v2/jwa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlgorithmPolicy(t *testing.T) {
+	t.Run("nil policy allows everything", func(t *testing.T) {
+		var p *jwa.AlgorithmPolicy
+		assert.NoError(t, p.Check(jwa.RSA1_5))
+		assert.NoError(t, p.Check(jwa.NoSignature))
+	})
+	t.Run("allow list is applied per type", func(t *testing.T) {
+		p := jwa.NewAlgorithmPolicy().Allow(jwa.ES256, jwa.RS256)
+		assert.NoError(t, p.Check(jwa.ES256))
+		assert.NoError(t, p.Check(jwa.RS256))
+
+		err := p.Check(jwa.HS256)
+		assert.Error(t, err, `HS256 is not in the allow list`)
+		assert.True(t, errors.Is(err, jwa.ErrAlgorithmNotAllowed()), `error should be ErrAlgorithmNotAllowed`)
+
+		assert.NoError(t, p.Check(jwa.RSA_OAEP), `key encryption algorithms are not restricted`)
+		assert.NoError(t, p.Check(jwa.A128GCM), `content encryption algorithms are not restricted`)
+	})
+	t.Run("deny list takes precedence", func(t *testing.T) {
+		p := jwa.NewAlgorithmPolicy().Allow(jwa.ES256).Deny(jwa.ES256)
+		assert.Error(t, p.Check(jwa.ES256))
+	})
+	t.Run("policies are immutable", func(t *testing.T) {
+		p1 := jwa.NewAlgorithmPolicy()
+		p2 := p1.Deny(jwa.HS256)
+		assert.NoError(t, p1.Check(jwa.HS256), `p1 should not be modified`)
+		assert.Error(t, p2.Check(jwa.HS256))
+	})
+	t.Run("strict policy", func(t *testing.T) {
+		p := jwa.StrictAlgorithmPolicy()
+		for _, alg := range []jwa.KeyAlgorithm{jwa.NoSignature, jwa.RSA1_5, jwa.DIRECT, jwa.PBES2_HS256_A128KW, jwa.PBES2_HS384_A192KW, jwa.PBES2_HS512_A256KW} {
+			assert.Error(t, p.Check(alg), `%s should be denied`, alg)
+		}
+		for _, alg := range []jwa.KeyAlgorithm{jwa.ES256, jwa.RSA_OAEP_256, jwa.ECDH_ES_A256KW, jwa.A256GCM} {
+			assert.NoError(t, p.Check(alg), `%s should be allowed`, alg)
+		}
+	})
+}
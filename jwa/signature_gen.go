@@ -13,55 +13,110 @@ type SignatureAlgorithm string
 
 // Supported values for SignatureAlgorithm
 const (
-	ES256       SignatureAlgorithm = "ES256"  // ECDSA using P-256 and SHA-256
-	ES256K      SignatureAlgorithm = "ES256K" // ECDSA using secp256k1 and SHA-256
-	ES384       SignatureAlgorithm = "ES384"  // ECDSA using P-384 and SHA-384
-	ES512       SignatureAlgorithm = "ES512"  // ECDSA using P-521 and SHA-512
-	EdDSA       SignatureAlgorithm = "EdDSA"  // EdDSA signature algorithms
-	HS256       SignatureAlgorithm = "HS256"  // HMAC using SHA-256
-	HS384       SignatureAlgorithm = "HS384"  // HMAC using SHA-384
-	HS512       SignatureAlgorithm = "HS512"  // HMAC using SHA-512
-	NoSignature SignatureAlgorithm = "none"
-	PS256       SignatureAlgorithm = "PS256" // RSASSA-PSS using SHA256 and MGF1-SHA256
-	PS384       SignatureAlgorithm = "PS384" // RSASSA-PSS using SHA384 and MGF1-SHA384
-	PS512       SignatureAlgorithm = "PS512" // RSASSA-PSS using SHA512 and MGF1-SHA512
-	RS256       SignatureAlgorithm = "RS256" // RSASSA-PKCS-v1.5 using SHA-256
-	RS384       SignatureAlgorithm = "RS384" // RSASSA-PKCS-v1.5 using SHA-384
-	RS512       SignatureAlgorithm = "RS512" // RSASSA-PKCS-v1.5 using SHA-512
+	ES256        SignatureAlgorithm = "ES256"     // ECDSA using P-256 and SHA-256
+	ES256K       SignatureAlgorithm = "ES256K"    // ECDSA using secp256k1 and SHA-256
+	ES384        SignatureAlgorithm = "ES384"     // ECDSA using P-384 and SHA-384
+	ES512        SignatureAlgorithm = "ES512"     // ECDSA using P-521 and SHA-512
+	ESB256       SignatureAlgorithm = "ESB256"    // ECDSA using brainpoolP256r1 and SHA-256
+	ESB384       SignatureAlgorithm = "ESB384"    // ECDSA using brainpoolP384r1 and SHA-384
+	ESB512       SignatureAlgorithm = "ESB512"    // ECDSA using brainpoolP512r1 and SHA-512
+	ESP256       SignatureAlgorithm = "ESP256"    // ECDSA using P-256 and SHA-256 (fully-specified)
+	ESP384       SignatureAlgorithm = "ESP384"    // ECDSA using P-384 and SHA-384 (fully-specified)
+	ESP512       SignatureAlgorithm = "ESP512"    // ECDSA using P-521 and SHA-512 (fully-specified)
+	EdDSA        SignatureAlgorithm = "EdDSA"     // EdDSA signature algorithms
+	EdDSAEd25519 SignatureAlgorithm = "Ed25519"   // EdDSA using the Ed25519 parameter set (fully-specified)
+	EdDSAEd448   SignatureAlgorithm = "Ed448"     // EdDSA using the Ed448 parameter set (fully-specified)
+	HS256        SignatureAlgorithm = "HS256"     // HMAC using SHA-256
+	HS384        SignatureAlgorithm = "HS384"     // HMAC using SHA-384
+	HS512        SignatureAlgorithm = "HS512"     // HMAC using SHA-512
+	MLDSA44      SignatureAlgorithm = "ML-DSA-44" // ML-DSA using the ML-DSA-44 parameter set (FIPS 204)
+	MLDSA65      SignatureAlgorithm = "ML-DSA-65" // ML-DSA using the ML-DSA-65 parameter set (FIPS 204)
+	MLDSA87      SignatureAlgorithm = "ML-DSA-87" // ML-DSA using the ML-DSA-87 parameter set (FIPS 204)
+	NoSignature  SignatureAlgorithm = "none"
+	PS256        SignatureAlgorithm = "PS256" // RSASSA-PSS using SHA256 and MGF1-SHA256
+	PS384        SignatureAlgorithm = "PS384" // RSASSA-PSS using SHA384 and MGF1-SHA384
+	PS512        SignatureAlgorithm = "PS512" // RSASSA-PSS using SHA512 and MGF1-SHA512
+	RS256        SignatureAlgorithm = "RS256" // RSASSA-PKCS-v1.5 using SHA-256
+	RS384        SignatureAlgorithm = "RS384" // RSASSA-PKCS-v1.5 using SHA-384
+	RS512        SignatureAlgorithm = "RS512" // RSASSA-PKCS-v1.5 using SHA-512
 )
 
+var muSignatureAlgorithms sync.RWMutex
 var allSignatureAlgorithms = map[SignatureAlgorithm]struct{}{
-	ES256:       {},
-	ES256K:      {},
-	ES384:       {},
-	ES512:       {},
-	EdDSA:       {},
-	HS256:       {},
-	HS384:       {},
-	HS512:       {},
-	NoSignature: {},
-	PS256:       {},
-	PS384:       {},
-	PS512:       {},
-	RS256:       {},
-	RS384:       {},
-	RS512:       {},
+	ES256:        {},
+	ES256K:       {},
+	ES384:        {},
+	ES512:        {},
+	ESB256:       {},
+	ESB384:       {},
+	ESB512:       {},
+	ESP256:       {},
+	ESP384:       {},
+	ESP512:       {},
+	EdDSA:        {},
+	EdDSAEd25519: {},
+	EdDSAEd448:   {},
+	HS256:        {},
+	HS384:        {},
+	HS512:        {},
+	MLDSA44:      {},
+	MLDSA65:      {},
+	MLDSA87:      {},
+	NoSignature:  {},
+	PS256:        {},
+	PS384:        {},
+	PS512:        {},
+	RS256:        {},
+	RS384:        {},
+	RS512:        {},
 }
 
-var listSignatureAlgorithmOnce sync.Once
 var listSignatureAlgorithm []SignatureAlgorithm
 
+func init() {
+	muSignatureAlgorithms.Lock()
+	defer muSignatureAlgorithms.Unlock()
+	rebuildSignatureAlgorithm()
+}
+
+// RegisterSignatureAlgorithm registers a new SignatureAlgorithm so that
+// jwx can properly handle the new value (e.g. in Accept() and SignatureAlgorithms()).
+// Duplicates are silently ignored.
+func RegisterSignatureAlgorithm(v SignatureAlgorithm) {
+	muSignatureAlgorithms.Lock()
+	defer muSignatureAlgorithms.Unlock()
+	if _, ok := allSignatureAlgorithms[v]; !ok {
+		allSignatureAlgorithms[v] = struct{}{}
+		rebuildSignatureAlgorithm()
+	}
+}
+
+// UnregisterSignatureAlgorithm unregisters a SignatureAlgorithm from the list of
+// known values. Non-existent entries are silently ignored.
+func UnregisterSignatureAlgorithm(v SignatureAlgorithm) {
+	muSignatureAlgorithms.Lock()
+	defer muSignatureAlgorithms.Unlock()
+	if _, ok := allSignatureAlgorithms[v]; ok {
+		delete(allSignatureAlgorithms, v)
+		rebuildSignatureAlgorithm()
+	}
+}
+
+func rebuildSignatureAlgorithm() {
+	list := make([]SignatureAlgorithm, 0, len(allSignatureAlgorithms))
+	for v := range allSignatureAlgorithms {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return string(list[i]) < string(list[j])
+	})
+	listSignatureAlgorithm = list
+}
+
 // SignatureAlgorithms returns a list of all available values for SignatureAlgorithm
 func SignatureAlgorithms() []SignatureAlgorithm {
-	listSignatureAlgorithmOnce.Do(func() {
-		listSignatureAlgorithm = make([]SignatureAlgorithm, 0, len(allSignatureAlgorithms))
-		for v := range allSignatureAlgorithms {
-			listSignatureAlgorithm = append(listSignatureAlgorithm, v)
-		}
-		sort.Slice(listSignatureAlgorithm, func(i, j int) bool {
-			return string(listSignatureAlgorithm[i]) < string(listSignatureAlgorithm[j])
-		})
-	})
+	muSignatureAlgorithms.RLock()
+	defer muSignatureAlgorithms.RUnlock()
 	return listSignatureAlgorithm
 }
 
@@ -83,7 +138,11 @@ func (v *SignatureAlgorithm) Accept(value interface{}) error {
 		}
 		tmp = SignatureAlgorithm(s)
 	}
-	if _, ok := allSignatureAlgorithms[tmp]; !ok {
+
+	muSignatureAlgorithms.RLock()
+	_, ok := allSignatureAlgorithms[tmp]
+	muSignatureAlgorithms.RUnlock()
+	if !ok {
 		return fmt.Errorf(`invalid jwa.SignatureAlgorithm value`)
 	}
 
@@ -155,6 +155,222 @@ func TestSignatureAlgorithm(t *testing.T) {
 			return
 		}
 	})
+	t.Run(`accept jwa constant ESB256`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.ESB256), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESB256, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ESB256`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept("ESB256"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESB256, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ESB256`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ESB256"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESB256, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ESB256`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ESB256", jwa.ESB256.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant ESB384`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.ESB384), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESB384, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ESB384`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept("ESB384"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESB384, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ESB384`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ESB384"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESB384, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ESB384`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ESB384", jwa.ESB384.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant ESB512`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.ESB512), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESB512, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ESB512`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept("ESB512"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESB512, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ESB512`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ESB512"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESB512, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ESB512`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ESB512", jwa.ESB512.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant ESP256`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.ESP256), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESP256, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ESP256`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept("ESP256"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESP256, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ESP256`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ESP256"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESP256, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ESP256`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ESP256", jwa.ESP256.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant ESP384`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.ESP384), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESP384, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ESP384`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept("ESP384"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESP384, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ESP384`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ESP384"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESP384, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ESP384`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ESP384", jwa.ESP384.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant ESP512`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.ESP512), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESP512, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ESP512`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept("ESP512"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESP512, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ESP512`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ESP512"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ESP512, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ESP512`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ESP512", jwa.ESP512.String(), `stringified value matches`) {
+			return
+		}
+	})
 	t.Run(`accept jwa constant EdDSA`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.SignatureAlgorithm
@@ -191,6 +407,78 @@ func TestSignatureAlgorithm(t *testing.T) {
 			return
 		}
 	})
+	t.Run(`accept jwa constant EdDSAEd25519`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.EdDSAEd25519), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.EdDSAEd25519, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string Ed25519`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept("Ed25519"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.EdDSAEd25519, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for Ed25519`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "Ed25519"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.EdDSAEd25519, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for Ed25519`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "Ed25519", jwa.EdDSAEd25519.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant EdDSAEd448`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.EdDSAEd448), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.EdDSAEd448, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string Ed448`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept("Ed448"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.EdDSAEd448, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for Ed448`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "Ed448"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.EdDSAEd448, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for Ed448`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "Ed448", jwa.EdDSAEd448.String(), `stringified value matches`) {
+			return
+		}
+	})
 	t.Run(`accept jwa constant HS256`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.SignatureAlgorithm
@@ -299,6 +587,114 @@ func TestSignatureAlgorithm(t *testing.T) {
 			return
 		}
 	})
+	t.Run(`accept jwa constant MLDSA44`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.MLDSA44), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLDSA44, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ML-DSA-44`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept("ML-DSA-44"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLDSA44, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ML-DSA-44`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ML-DSA-44"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLDSA44, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ML-DSA-44`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ML-DSA-44", jwa.MLDSA44.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant MLDSA65`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.MLDSA65), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLDSA65, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ML-DSA-65`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept("ML-DSA-65"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLDSA65, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ML-DSA-65`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ML-DSA-65"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLDSA65, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ML-DSA-65`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ML-DSA-65", jwa.MLDSA65.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant MLDSA87`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.MLDSA87), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLDSA87, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ML-DSA-87`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept("ML-DSA-87"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLDSA87, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ML-DSA-87`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ML-DSA-87"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.MLDSA87, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ML-DSA-87`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ML-DSA-87", jwa.MLDSA87.String(), `stringified value matches`) {
+			return
+		}
+	})
 	t.Run(`accept jwa constant NoSignature`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.SignatureAlgorithm
@@ -568,21 +964,32 @@ func TestSignatureAlgorithm(t *testing.T) {
 	t.Run(`check list of elements`, func(t *testing.T) {
 		t.Parallel()
 		var expected = map[jwa.SignatureAlgorithm]struct{}{
-			jwa.ES256:       {},
-			jwa.ES256K:      {},
-			jwa.ES384:       {},
-			jwa.ES512:       {},
-			jwa.EdDSA:       {},
-			jwa.HS256:       {},
-			jwa.HS384:       {},
-			jwa.HS512:       {},
-			jwa.NoSignature: {},
-			jwa.PS256:       {},
-			jwa.PS384:       {},
-			jwa.PS512:       {},
-			jwa.RS256:       {},
-			jwa.RS384:       {},
-			jwa.RS512:       {},
+			jwa.ES256:        {},
+			jwa.ES256K:       {},
+			jwa.ES384:        {},
+			jwa.ES512:        {},
+			jwa.ESB256:       {},
+			jwa.ESB384:       {},
+			jwa.ESB512:       {},
+			jwa.ESP256:       {},
+			jwa.ESP384:       {},
+			jwa.ESP512:       {},
+			jwa.EdDSA:        {},
+			jwa.EdDSAEd25519: {},
+			jwa.EdDSAEd448:   {},
+			jwa.HS256:        {},
+			jwa.HS384:        {},
+			jwa.HS512:        {},
+			jwa.MLDSA44:      {},
+			jwa.MLDSA65:      {},
+			jwa.MLDSA87:      {},
+			jwa.NoSignature:  {},
+			jwa.PS256:        {},
+			jwa.PS384:        {},
+			jwa.PS512:        {},
+			jwa.RS256:        {},
+			jwa.RS384:        {},
+			jwa.RS512:        {},
 		}
 		for _, v := range jwa.SignatureAlgorithms() {
 			if _, ok := expected[v]; !assert.True(t, ok, `%s should be in the expected list`, v) {
@@ -595,3 +1002,32 @@ func TestSignatureAlgorithm(t *testing.T) {
 		}
 	})
 }
+
+func TestSignatureAlgorithmCustomAlgorithm(t *testing.T) {
+	const customAlgorithm = jwa.SignatureAlgorithm(`custom-algorithm`)
+	var dst jwa.SignatureAlgorithm
+	if !assert.Error(t, dst.Accept(customAlgorithm), `accept should fail before registration`) {
+		return
+	}
+
+	jwa.RegisterSignatureAlgorithm(customAlgorithm)
+	defer jwa.UnregisterSignatureAlgorithm(customAlgorithm)
+
+	if !assert.NoError(t, dst.Accept(customAlgorithm.String()), `accept should succeed after registration`) {
+		return
+	}
+	if !assert.Equal(t, customAlgorithm, dst, `accepted value should be equal to constant`) {
+		return
+	}
+	if !assert.Contains(t, jwa.SignatureAlgorithms(), customAlgorithm, `list should contain the registered value`) {
+		return
+	}
+
+	jwa.UnregisterSignatureAlgorithm(customAlgorithm)
+	if !assert.Error(t, dst.Accept(customAlgorithm), `accept should fail after unregistration`) {
+		return
+	}
+	if !assert.NotContains(t, jwa.SignatureAlgorithms(), customAlgorithm, `list should not contain the unregistered value`) {
+		return
+	}
+}
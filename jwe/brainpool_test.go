@@ -0,0 +1,62 @@
+//go:build jwx_brainpool
+// +build jwx_brainpool
+
+package jwe_test
+
+import (
+	"testing"
+
+	"github.com/sjwl/jwx/v2/internal/jwxtest"
+	"github.com/sjwl/jwx/v2/jwa"
+	"github.com/sjwl/jwx/v2/jwe"
+	"github.com/sjwl/jwx/v2/jwk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrainpool(t *testing.T) {
+	for _, crv := range []jwa.EllipticCurveAlgorithm{jwa.BrainpoolP256r1, jwa.BrainpoolP384r1, jwa.BrainpoolP512r1} {
+		crv := crv
+		t.Run(crv.String(), func(t *testing.T) {
+			key, err := jwxtest.GenerateEcdsaKey(crv)
+			require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+			privjwk, err := jwk.FromRaw(key)
+			require.NoError(t, err, `jwk.FromRaw should succeed`)
+			pubjwk, err := jwk.PublicKeyOf(privjwk)
+			require.NoError(t, err, `jwk.PublicKeyOf should succeed`)
+
+			for _, alg := range []jwa.KeyEncryptionAlgorithm{jwa.ECDH_ES, jwa.ECDH_ES_A128KW, jwa.ECDH_ES_A256KW} {
+				alg := alg
+				t.Run(alg.String(), func(t *testing.T) {
+					for _, pubkey := range []interface{}{&key.PublicKey, pubjwk} {
+						encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(alg, pubkey))
+						require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+						msg, err := jwe.Parse(encrypted)
+						require.NoError(t, err, `jwe.Parse should succeed`)
+						epk, ok := msg.ProtectedHeaders().EphemeralPublicKey().(jwk.ECDSAPublicKey)
+						require.True(t, ok, `"epk" should be an EC public key`)
+						require.Equal(t, crv, epk.Crv(), `"epk" should use the same curve`)
+
+						// The arithmetic for the Brainpool curves is not constant
+						// time, so decrypting with static private keys is refused
+						for _, privkey := range []interface{}{key, privjwk} {
+							_, err = jwe.Decrypt(encrypted, jwe.WithKey(alg, privkey))
+							require.Error(t, err, `jwe.Decrypt should fail for %T`, privkey)
+						}
+
+						// Keys behind a jwe.KeyAgreement can still be used
+						decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(alg, &hsmKeyAgreement{key: key}))
+						require.NoError(t, err, `jwe.Decrypt should succeed using a jwe.KeyAgreement`)
+						require.Equal(t, examplePayload, string(decrypted), `payloads should match`)
+					}
+				})
+			}
+			t.Run("ECDH-1PU sender key", func(t *testing.T) {
+				recipient, err := jwxtest.GenerateEcdsaKey(crv)
+				require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+				_, err = jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.ECDH_1PU, &recipient.PublicKey), jwe.WithSenderKey(key))
+				require.Error(t, err, `jwe.Encrypt should fail for a static Brainpool sender key`)
+			})
+		})
+	}
+}
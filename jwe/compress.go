@@ -5,19 +5,106 @@ import (
 	"compress/flate"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/sjwl/jwx/v2/internal/pool"
+	"github.com/sjwl/jwx/v2/jwa"
 )
 
-func uncompress(plaintext []byte) ([]byte, error) {
-	return io.ReadAll(flate.NewReader(bytes.NewReader(plaintext)))
+// Compressor compresses and decompresses JWE payloads using the
+// algorithm specified in the "zip" header.
+type Compressor interface {
+	// NewWriter returns an io.WriteCloser that writes the compressed form
+	// of the data written to it to `dst`. All of the compressed data must
+	// have been written to `dst` when Close() returns.
+	NewWriter(dst io.Writer) (io.WriteCloser, error)
+
+	// NewReader returns an io.ReadCloser that reads the decompressed
+	// form of the data read from `src`.
+	NewReader(src io.Reader) (io.ReadCloser, error)
+}
+
+var muCompressorDB sync.RWMutex
+var compressorDB = make(map[jwa.CompressionAlgorithm]Compressor)
+
+// RegisterCompressor is used to register a Compressor for the given
+// compression algorithm. Once registered, messages whose "zip" header
+// is set to `alg` can be created by specifying `jwe.WithCompress(alg)`
+// to `jwe.Encrypt()`, and can be decrypted by `jwe.Decrypt()`. The limit
+// set by `jwe.WithMaxDecompressedSize()` applies to registered compressors
+// as well.
+//
+// `alg` must also be registered using `jwa.RegisterCompressionAlgorithm()`,
+// so that the "zip" header can be parsed.
+//
+// The standard DEFLATE algorithm (`jwa.Deflate`) is always handled by this
+// library, and cannot be replaced. Registering a Compressor for `jwa.Deflate`
+// or `jwa.NoCompress` has no effect.
+func RegisterCompressor(alg jwa.CompressionAlgorithm, c Compressor) {
+	muCompressorDB.Lock()
+	compressorDB[alg] = c
+	muCompressorDB.Unlock()
+}
+
+// UnregisterCompressor removes the Compressor registered for the given
+// algorithm using `jwe.RegisterCompressor()`.
+func UnregisterCompressor(alg jwa.CompressionAlgorithm) {
+	muCompressorDB.Lock()
+	delete(compressorDB, alg)
+	muCompressorDB.Unlock()
+}
+
+// lookupCompressor returns the Compressor for the given algorithm.
+// It must not be called with jwa.NoCompress
+func lookupCompressor(alg jwa.CompressionAlgorithm) (Compressor, error) {
+	if alg == jwa.Deflate {
+		return deflateCompressor{}, nil
+	}
+
+	muCompressorDB.RLock()
+	c, ok := compressorDB[alg]
+	muCompressorDB.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf(`unsupported compression algorithm (%s)`, alg)
+	}
+	return c, nil
 }
 
-func compress(plaintext []byte) ([]byte, error) {
+// deflateCompressor implements the DEFLATE ("DEF") algorithm
+type deflateCompressor struct{}
+
+func (deflateCompressor) NewWriter(dst io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(dst, 1)
+}
+
+func (deflateCompressor) NewReader(src io.Reader) (io.ReadCloser, error) {
+	return flate.NewReader(src), nil
+}
+
+// uncompress decompresses `plaintext`. If `max` is larger than 0, it fails
+// once the payload expands to more than `max` bytes
+func uncompress(c Compressor, plaintext []byte, max int64) ([]byte, error) {
+	r, err := c.NewReader(bytes.NewReader(plaintext))
+	if err != nil {
+		return nil, fmt.Errorf(`failed to create decompression reader: %w`, err)
+	}
+	defer r.Close()
+
+	var src io.Reader = r
+	if max > 0 {
+		src = newLimitedReader(r, max, errMaxDecompressedSizeExceeded)
+	}
+	return io.ReadAll(src)
+}
+
+func compress(c Compressor, plaintext []byte) ([]byte, error) {
 	buf := pool.GetBytesBuffer()
 	defer pool.ReleaseBytesBuffer(buf)
 
-	w, _ := flate.NewWriter(buf, 1)
+	w, err := c.NewWriter(buf)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to create compression writer: %w`, err)
+	}
 	in := plaintext
 	for len(in) > 0 {
 		n, err := w.Write(in)
@@ -0,0 +1,69 @@
+package jwe
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sjwl/jwx/v2/jwa"
+	"github.com/sjwl/jwx/v2/jwe/internal/content_crypt"
+)
+
+type ContentCipherFactory interface {
+	Create() (ContentCipher, error)
+}
+type ContentCipherFactoryFn func() (ContentCipher, error)
+
+func (fn ContentCipherFactoryFn) Create() (ContentCipher, error) {
+	return fn()
+}
+
+var muContentCipherDB sync.RWMutex
+var contentCipherDB = make(map[jwa.ContentEncryptionAlgorithm]ContentCipherFactory)
+
+// RegisterContentCipher is used to register a factory object that creates
+// ContentCipher objects based on the given algorithm.
+//
+// Registered factories take precedence over the built-in implementations,
+// so this function can be used to provide content encryption algorithms
+// that this library does not support, as well as to replace the
+// implementation of an existing algorithm (probably in your `init()`)
+func RegisterContentCipher(alg jwa.ContentEncryptionAlgorithm, f ContentCipherFactory) {
+	muContentCipherDB.Lock()
+	contentCipherDB[alg] = f
+	muContentCipherDB.Unlock()
+}
+
+// UnregisterContentCipher removes the factory object registered for the
+// given algorithm using `jwe.RegisterContentCipher()`. Built-in
+// implementations are not affected.
+func UnregisterContentCipher(alg jwa.ContentEncryptionAlgorithm) {
+	muContentCipherDB.Lock()
+	delete(contentCipherDB, alg)
+	muContentCipherDB.Unlock()
+}
+
+// newContentCipher creates a ContentCipher for the given algorithm,
+// using the registered factory if one exists.
+func newContentCipher(alg jwa.ContentEncryptionAlgorithm) (ContentCipher, error) {
+	muContentCipherDB.RLock()
+	f, ok := contentCipherDB[alg]
+	muContentCipherDB.RUnlock()
+	if ok {
+		c, err := f.Create()
+		if err != nil {
+			return nil, fmt.Errorf(`failed to create content cipher for %s: %w`, alg, err)
+		}
+		return c, nil
+	}
+
+	switch alg {
+	case jwa.A128GCM, jwa.A192GCM, jwa.A256GCM, jwa.A128CBC_HS256, jwa.A192CBC_HS384, jwa.A256CBC_HS512, jwa.XC20P:
+		c, err := content_crypt.NewGeneric(alg)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to build content cipher for %s: %w`, alg, err)
+		}
+		return c, nil
+	default:
+		return nil, fmt.Errorf(`invalid content cipher algorithm (%s)`, alg)
+	}
+}
@@ -1,23 +1,22 @@
 package jwe
 
 import (
+	"crypto"
 	"crypto/aes"
 	cryptocipher "crypto/cipher"
 	"crypto/ecdsa"
 	"crypto/rsa"
-	"crypto/sha256"
-	"crypto/sha512"
 	"fmt"
-	"hash"
 
 	"golang.org/x/crypto/pbkdf2"
 
+	"github.com/sjwl/jwx/v2/internal/brainpool"
 	"github.com/sjwl/jwx/v2/internal/keyconv"
+	"github.com/sjwl/jwx/v2/internal/mlkem"
 	"github.com/sjwl/jwx/v2/jwa"
-	"github.com/sjwl/jwx/v2/jwe/internal/cipher"
-	"github.com/sjwl/jwx/v2/jwe/internal/content_crypt"
 	"github.com/sjwl/jwx/v2/jwe/internal/keyenc"
 	"github.com/sjwl/jwx/v2/x25519"
+	"github.com/sjwl/jwx/v2/x448"
 )
 
 // decrypter is responsible for taking various components to decrypt a message.
@@ -29,6 +28,7 @@ type decrypter struct {
 	apu         []byte
 	apv         []byte
 	computedAad []byte
+	ek          []byte
 	iv          []byte
 	keyiv       []byte
 	keysalt     []byte
@@ -36,9 +36,12 @@ type decrypter struct {
 	tag         []byte
 	privkey     interface{}
 	pubkey      interface{}
+	senderkey   interface{}
 	ctalg       jwa.ContentEncryptionAlgorithm
 	keyalg      jwa.KeyEncryptionAlgorithm
-	cipher      content_crypt.Cipher
+	cipher      ContentCipher
+	keydec      KeyDecrypter
+	headers     Headers
 	keycount    int
 }
 
@@ -83,6 +86,12 @@ func (d *decrypter) ContentEncryptionAlgorithm(ctalg jwa.ContentEncryptionAlgori
 	return d
 }
 
+// EncapsulatedKey sets the HPKE or ML-KEM encapsulated key ("ek" header)
+func (d *decrypter) EncapsulatedKey(ek []byte) *decrypter {
+	d.ek = ek
+	return d
+}
+
 func (d *decrypter) InitializationVector(iv []byte) *decrypter {
 	d.iv = iv
 	return d
@@ -115,23 +124,34 @@ func (d *decrypter) PublicKey(pubkey interface{}) *decrypter {
 	return d
 }
 
+// SenderPublicKey sets the static public key of the sender used in
+// ECDH-1PU. The key must be in its "raw" format
+func (d *decrypter) SenderPublicKey(pubkey interface{}) *decrypter {
+	d.senderkey = pubkey
+	return d
+}
+
 func (d *decrypter) Tag(tag []byte) *decrypter {
 	d.tag = tag
 	return d
 }
 
-func (d *decrypter) ContentCipher() (content_crypt.Cipher, error) {
+// KeyDecrypter sets a user-supplied KeyDecrypter, which is used in place
+// of the built-in key decryption algorithms. `hdrs` are passed verbatim
+// to the KeyDecrypter.
+func (d *decrypter) KeyDecrypter(keydec KeyDecrypter, hdrs Headers) *decrypter {
+	d.keydec = keydec
+	d.headers = hdrs
+	return d
+}
+
+func (d *decrypter) ContentCipher() (ContentCipher, error) {
 	if d.cipher == nil {
-		switch d.ctalg {
-		case jwa.A128GCM, jwa.A192GCM, jwa.A256GCM, jwa.A128CBC_HS256, jwa.A192CBC_HS384, jwa.A256CBC_HS512:
-			cipher, err := cipher.NewAES(d.ctalg)
-			if err != nil {
-				return nil, fmt.Errorf(`failed to build content cipher for %s: %w`, d.ctalg, err)
-			}
-			d.cipher = cipher
-		default:
-			return nil, fmt.Errorf(`invalid content cipher algorithm (%s)`, d.ctalg)
+		cipher, err := newContentCipher(d.ctalg)
+		if err != nil {
+			return nil, err
 		}
+		d.cipher = cipher
 	}
 
 	return d.cipher, nil
@@ -169,23 +189,23 @@ func (d *decrypter) decryptSymmetricKey(recipientKey, cek []byte) ([]byte, error
 	case jwa.DIRECT:
 		return cek, nil
 	case jwa.PBES2_HS256_A128KW, jwa.PBES2_HS384_A192KW, jwa.PBES2_HS512_A256KW:
-		var hashFunc func() hash.Hash
 		var keylen int
 		switch d.keyalg {
 		case jwa.PBES2_HS256_A128KW:
-			hashFunc = sha256.New
 			keylen = 16
 		case jwa.PBES2_HS384_A192KW:
-			hashFunc = sha512.New384
 			keylen = 24
 		case jwa.PBES2_HS512_A256KW:
-			hashFunc = sha512.New
 			keylen = 32
 		}
+		hash, err := keyenc.HashForAlgorithm(d.keyalg)
+		if err != nil {
+			return nil, err
+		}
 		salt := []byte(d.keyalg)
 		salt = append(salt, byte(0))
 		salt = append(salt, d.keysalt...)
-		cek = pbkdf2.Key(cek, salt, d.keycount, keylen, hashFunc)
+		cek = pbkdf2.Key(cek, salt, d.keycount, keylen, hash.New)
 		fallthrough
 	case jwa.A128KW, jwa.A192KW, jwa.A256KW:
 		block, err := aes.NewCipher(cek)
@@ -221,12 +241,22 @@ func (d *decrypter) decryptSymmetricKey(recipientKey, cek []byte) ([]byte, error
 			return nil, fmt.Errorf(`failed to decode key: %w`, err)
 		}
 		return jek, nil
+	case jwa.XC20PKW:
+		return keyenc.OpenXC20PKW(cek, d.keyiv, d.keytag, recipientKey)
 	default:
 		return nil, fmt.Errorf("decrypt key: unsupported algorithm %s", d.keyalg)
 	}
 }
 
 func (d *decrypter) DecryptKey(recipientKey []byte) (cek []byte, err error) {
+	if d.keydec != nil {
+		cek, err = d.keydec.DecryptKey(d.ctalg, recipientKey, d.privkey, d.headers)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to decrypt key: %w`, err)
+		}
+		return cek, nil
+	}
+
 	if d.keyalg.IsSymmetric() {
 		var ok bool
 		cek, ok = d.privkey.([]byte)
@@ -263,14 +293,22 @@ func (d *decrypter) BuildKeyDecrypter() (keyenc.Decrypter, error) {
 			return nil, fmt.Errorf(`*rsa.PrivateKey is required as the key to build %s key decrypter: %w`, alg, err)
 		}
 
-		return keyenc.NewRSAPKCS15Decrypt(alg, &privkey, cipher.KeySize()/2), nil
-	case jwa.RSA_OAEP, jwa.RSA_OAEP_256:
-		var privkey rsa.PrivateKey
-		if err := keyconv.RSAPrivateKey(&privkey, d.privkey); err != nil {
-			return nil, fmt.Errorf(`*rsa.PrivateKey is required as the key to build %s key decrypter: %w`, alg, err)
+		return keyenc.NewRSAPKCS15Decrypt(alg, &privkey, cipher.KeySize()), nil
+	case jwa.RSA_OAEP, jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512:
+		decrypter, ok := d.privkey.(crypto.Decrypter)
+		if ok {
+			if _, isRSA := decrypter.Public().(*rsa.PublicKey); !isRSA {
+				return nil, fmt.Errorf(`crypto.Decrypter backed by an RSA key is required as the key to build %s key decrypter (got %T)`, alg, decrypter.Public())
+			}
+		} else {
+			var privkey rsa.PrivateKey
+			if err := keyconv.RSAPrivateKey(&privkey, d.privkey); err != nil {
+				return nil, fmt.Errorf(`*rsa.PrivateKey or crypto.Decrypter is required as the key to build %s key decrypter: %w`, alg, err)
+			}
+			decrypter = &privkey
 		}
 
-		return keyenc.NewRSAOAEPDecrypt(alg, &privkey)
+		return keyenc.NewRSAOAEPDecrypt(alg, decrypter)
 	case jwa.A128KW, jwa.A192KW, jwa.A256KW:
 		sharedkey, ok := d.privkey.([]byte)
 		if !ok {
@@ -278,10 +316,61 @@ func (d *decrypter) BuildKeyDecrypter() (keyenc.Decrypter, error) {
 		}
 
 		return keyenc.NewAES(alg, sharedkey)
-	case jwa.ECDH_ES, jwa.ECDH_ES_A128KW, jwa.ECDH_ES_A192KW, jwa.ECDH_ES_A256KW:
+	case jwa.ECDH_ES, jwa.ECDH_ES_A128KW, jwa.ECDH_ES_A192KW, jwa.ECDH_ES_A256KW, jwa.ECDH_ES_XC20PKW:
+		var dec *keyenc.ECDHESDecrypt
+		if agreement, ok := d.privkey.(KeyAgreement); ok {
+			dec = keyenc.NewECDHESDecrypt(alg, d.ctalg, d.pubkey, d.apu, d.apv, agreement)
+		} else {
+			switch d.pubkey.(type) {
+			case x25519.PublicKey, x448.PublicKey:
+				dec = keyenc.NewECDHESDecrypt(alg, d.ctalg, d.pubkey, d.apu, d.apv, d.privkey)
+			default:
+				var pubkey ecdsa.PublicKey
+				if err := keyconv.ECDSAPublicKey(&pubkey, d.pubkey); err != nil {
+					return nil, fmt.Errorf(`*ecdsa.PublicKey is required as the key to build %s key decrypter: %w`, alg, err)
+				}
+
+				var privkey ecdsa.PrivateKey
+				if err := keyconv.ECDSAPrivateKey(&privkey, d.privkey); err != nil {
+					return nil, fmt.Errorf(`*ecdsa.PrivateKey is required as the key to build %s key decrypter: %w`, alg, err)
+				}
+				if err := checkStaticECDSAKey(&privkey); err != nil {
+					return nil, err
+				}
+
+				dec = keyenc.NewECDHESDecrypt(alg, d.ctalg, &pubkey, d.apu, d.apv, &privkey)
+			}
+		}
+		if alg == jwa.ECDH_ES_XC20PKW {
+			dec.SetKeyIVAndTag(d.keyiv, d.keytag)
+		}
+		return dec, nil
+	case jwa.HPKE_0, jwa.HPKE_1, jwa.HPKE_2, jwa.HPKE_3, jwa.HPKE_4,
+		jwa.HPKE_0_KE, jwa.HPKE_1_KE, jwa.HPKE_2_KE, jwa.HPKE_3_KE, jwa.HPKE_4_KE:
+		switch privkey := d.privkey.(type) {
+		case KeyAgreement, x25519.PrivateKey:
+			return keyenc.NewHPKEDecrypt(alg, d.ek, privkey)
+		default:
+			var ecprivkey ecdsa.PrivateKey
+			if err := keyconv.ECDSAPrivateKey(&ecprivkey, d.privkey); err != nil {
+				return nil, fmt.Errorf(`*ecdsa.PrivateKey is required as the key to build %s key decrypter: %w`, alg, err)
+			}
+			return keyenc.NewHPKEDecrypt(alg, d.ek, &ecprivkey)
+		}
+	case jwa.MLKEM768, jwa.MLKEM768_A192KW, jwa.MLKEM1024, jwa.MLKEM1024_A256KW:
+		privkey, ok := d.privkey.(*mlkem.DecapsulationKey)
+		if !ok {
+			return nil, fmt.Errorf(`*mlkem.DecapsulationKey is required as the key to build %s key decrypter (got %T)`, alg, d.privkey)
+		}
+		return keyenc.NewMLKEMDecrypt(alg, d.ctalg, d.ek, d.apu, d.apv, privkey)
+	case jwa.ECDH_1PU, jwa.ECDH_1PU_A128KW, jwa.ECDH_1PU_A192KW, jwa.ECDH_1PU_A256KW:
+		if agreement, ok := d.privkey.(KeyAgreement); ok {
+			return keyenc.NewECDH1PUDecrypt(alg, d.ctalg, d.pubkey, d.senderkey, d.apu, d.apv, d.tag, agreement), nil
+		}
+
 		switch d.pubkey.(type) {
-		case x25519.PublicKey:
-			return keyenc.NewECDHESDecrypt(alg, d.ctalg, d.pubkey, d.apu, d.apv, d.privkey), nil
+		case x25519.PublicKey, x448.PublicKey:
+			return keyenc.NewECDH1PUDecrypt(alg, d.ctalg, d.pubkey, d.senderkey, d.apu, d.apv, d.tag, d.privkey), nil
 		default:
 			var pubkey ecdsa.PublicKey
 			if err := keyconv.ECDSAPublicKey(&pubkey, d.pubkey); err != nil {
@@ -292,10 +381,24 @@ func (d *decrypter) BuildKeyDecrypter() (keyenc.Decrypter, error) {
 			if err := keyconv.ECDSAPrivateKey(&privkey, d.privkey); err != nil {
 				return nil, fmt.Errorf(`*ecdsa.PrivateKey is required as the key to build %s key decrypter: %w`, alg, err)
 			}
+			if err := checkStaticECDSAKey(&privkey); err != nil {
+				return nil, err
+			}
 
-			return keyenc.NewECDHESDecrypt(alg, d.ctalg, &pubkey, d.apu, d.apv, &privkey), nil
+			return keyenc.NewECDH1PUDecrypt(alg, d.ctalg, &pubkey, d.senderkey, d.apu, d.apv, d.tag, &privkey), nil
 		}
 	default:
 		return nil, fmt.Errorf(`unsupported algorithm for key decryption (%s)`, alg)
 	}
 }
+
+// checkStaticECDSAKey refuses static private keys on the Brainpool curves.
+// Their arithmetic is not constant time, and using them to compute
+// shared secrets with keys chosen by the other party could leak them.
+// Ephemeral keys, and static keys behind a KeyAgreement, are not affected
+func checkStaticECDSAKey(privkey *ecdsa.PrivateKey) error {
+	if brainpool.IsCurve(privkey.Curve) {
+		return fmt.Errorf(`static %s private keys are not supported, as its implementation is not constant time`, privkey.Curve.Params().Name)
+	}
+	return nil
+}
@@ -25,10 +25,12 @@ const (
 	ContentEncryptionKey      = "enc"
 	ContentTypeKey            = "cty"
 	CriticalKey               = "crit"
+	EncapsulatedKeyKey        = "ek"
 	EphemeralPublicKeyKey     = "epk"
 	JWKKey                    = "jwk"
 	JWKSetURLKey              = "jku"
 	KeyIDKey                  = "kid"
+	SenderKeyIDKey            = "skid"
 	TypeKey                   = "typ"
 	X509CertChainKey          = "x5c"
 	X509CertThumbprintKey     = "x5t"
@@ -47,10 +49,12 @@ type Headers interface {
 	ContentEncryption() jwa.ContentEncryptionAlgorithm
 	ContentType() string
 	Critical() []string
+	EncapsulatedKey() []byte
 	EphemeralPublicKey() jwk.Key
 	JWK() jwk.Key
 	JWKSetURL() string
 	KeyID() string
+	SenderKeyID() string
 	Type() string
 	X509CertChain() *cert.Chain
 	X509CertThumbprint() string
@@ -82,10 +86,12 @@ type stdHeaders struct {
 	contentEncryption      *jwa.ContentEncryptionAlgorithm
 	contentType            *string
 	critical               []string
+	encapsulatedKey        []byte
 	ephemeralPublicKey     jwk.Key
 	jwk                    jwk.Key
 	jwkSetURL              *string
 	keyID                  *string
+	senderKeyID            *string
 	typ                    *string
 	x509CertChain          *cert.Chain
 	x509CertThumbprint     *string
@@ -156,6 +162,12 @@ func (h *stdHeaders) Critical() []string {
 	return h.critical
 }
 
+func (h *stdHeaders) EncapsulatedKey() []byte {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.encapsulatedKey
+}
+
 func (h *stdHeaders) EphemeralPublicKey() jwk.Key {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -186,6 +198,15 @@ func (h *stdHeaders) KeyID() string {
 	return *(h.keyID)
 }
 
+func (h *stdHeaders) SenderKeyID() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.senderKeyID == nil {
+		return ""
+	}
+	return *(h.senderKeyID)
+}
+
 func (h *stdHeaders) Type() string {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -253,6 +274,9 @@ func (h *stdHeaders) makePairs() []*HeaderPair {
 	if h.critical != nil {
 		pairs = append(pairs, &HeaderPair{Key: CriticalKey, Value: h.critical})
 	}
+	if h.encapsulatedKey != nil {
+		pairs = append(pairs, &HeaderPair{Key: EncapsulatedKeyKey, Value: h.encapsulatedKey})
+	}
 	if h.ephemeralPublicKey != nil {
 		pairs = append(pairs, &HeaderPair{Key: EphemeralPublicKeyKey, Value: h.ephemeralPublicKey})
 	}
@@ -265,6 +289,9 @@ func (h *stdHeaders) makePairs() []*HeaderPair {
 	if h.keyID != nil {
 		pairs = append(pairs, &HeaderPair{Key: KeyIDKey, Value: *(h.keyID)})
 	}
+	if h.senderKeyID != nil {
+		pairs = append(pairs, &HeaderPair{Key: SenderKeyIDKey, Value: *(h.senderKeyID)})
+	}
 	if h.typ != nil {
 		pairs = append(pairs, &HeaderPair{Key: TypeKey, Value: *(h.typ)})
 	}
@@ -331,6 +358,11 @@ func (h *stdHeaders) Get(name string) (interface{}, bool) {
 			return nil, false
 		}
 		return h.critical, true
+	case EncapsulatedKeyKey:
+		if h.encapsulatedKey == nil {
+			return nil, false
+		}
+		return h.encapsulatedKey, true
 	case EphemeralPublicKeyKey:
 		if h.ephemeralPublicKey == nil {
 			return nil, false
@@ -351,6 +383,11 @@ func (h *stdHeaders) Get(name string) (interface{}, bool) {
 			return nil, false
 		}
 		return *(h.keyID), true
+	case SenderKeyIDKey:
+		if h.senderKeyID == nil {
+			return nil, false
+		}
+		return *(h.senderKeyID), true
 	case TypeKey:
 		if h.typ == nil {
 			return nil, false
@@ -435,6 +472,12 @@ func (h *stdHeaders) setNoLock(name string, value interface{}) error {
 			return nil
 		}
 		return fmt.Errorf(`invalid value for %s key: %T`, CriticalKey, value)
+	case EncapsulatedKeyKey:
+		if v, ok := value.([]byte); ok {
+			h.encapsulatedKey = v
+			return nil
+		}
+		return fmt.Errorf(`invalid value for %s key: %T`, EncapsulatedKeyKey, value)
 	case EphemeralPublicKeyKey:
 		if v, ok := value.(jwk.Key); ok {
 			h.ephemeralPublicKey = v
@@ -459,6 +502,12 @@ func (h *stdHeaders) setNoLock(name string, value interface{}) error {
 			return nil
 		}
 		return fmt.Errorf(`invalid value for %s key: %T`, KeyIDKey, value)
+	case SenderKeyIDKey:
+		if v, ok := value.(string); ok {
+			h.senderKeyID = &v
+			return nil
+		}
+		return fmt.Errorf(`invalid value for %s key: %T`, SenderKeyIDKey, value)
 	case TypeKey:
 		if v, ok := value.(string); ok {
 			h.typ = &v
@@ -516,6 +565,8 @@ func (h *stdHeaders) Remove(key string) error {
 		h.contentType = nil
 	case CriticalKey:
 		h.critical = nil
+	case EncapsulatedKeyKey:
+		h.encapsulatedKey = nil
 	case EphemeralPublicKeyKey:
 		h.ephemeralPublicKey = nil
 	case JWKKey:
@@ -524,6 +575,8 @@ func (h *stdHeaders) Remove(key string) error {
 		h.jwkSetURL = nil
 	case KeyIDKey:
 		h.keyID = nil
+	case SenderKeyIDKey:
+		h.senderKeyID = nil
 	case TypeKey:
 		h.typ = nil
 	case X509CertChainKey:
@@ -548,10 +601,12 @@ func (h *stdHeaders) UnmarshalJSON(buf []byte) error {
 	h.contentEncryption = nil
 	h.contentType = nil
 	h.critical = nil
+	h.encapsulatedKey = nil
 	h.ephemeralPublicKey = nil
 	h.jwk = nil
 	h.jwkSetURL = nil
 	h.keyID = nil
+	h.senderKeyID = nil
 	h.typ = nil
 	h.x509CertChain = nil
 	h.x509CertThumbprint = nil
@@ -611,6 +666,10 @@ LOOP:
 					return fmt.Errorf(`failed to decode value for key %s: %w`, CriticalKey, err)
 				}
 				h.critical = decoded
+			case EncapsulatedKeyKey:
+				if err := json.AssignNextBytesToken(&h.encapsulatedKey, dec); err != nil {
+					return fmt.Errorf(`failed to decode value for key %s: %w`, EncapsulatedKeyKey, err)
+				}
 			case EphemeralPublicKeyKey:
 				var buf json.RawMessage
 				if err := dec.Decode(&buf); err != nil {
@@ -639,6 +698,10 @@ LOOP:
 				if err := json.AssignNextStringToken(&h.keyID, dec); err != nil {
 					return fmt.Errorf(`failed to decode value for key %s: %w`, KeyIDKey, err)
 				}
+			case SenderKeyIDKey:
+				if err := json.AssignNextStringToken(&h.senderKeyID, dec); err != nil {
+					return fmt.Errorf(`failed to decode value for key %s: %w`, SenderKeyIDKey, err)
+				}
 			case TypeKey:
 				if err := json.AssignNextStringToken(&h.typ, dec); err != nil {
 					return fmt.Errorf(`failed to decode value for key %s: %w`, TypeKey, err)
@@ -677,7 +740,7 @@ LOOP:
 
 func (h stdHeaders) MarshalJSON() ([]byte, error) {
 	data := make(map[string]interface{})
-	fields := make([]string, 0, 16)
+	fields := make([]string, 0, 18)
 	for _, pair := range h.makePairs() {
 		fields = append(fields, pair.Key.(string))
 		data[pair.Key.(string)] = pair.Value
@@ -0,0 +1,67 @@
+package jwe
+
+import (
+	"fmt"
+
+	"github.com/sjwl/jwx/v2/jwa"
+	"github.com/sjwl/jwx/v2/jwe/internal/hpke"
+	"github.com/sjwl/jwx/v2/jwe/internal/keyenc"
+)
+
+// hpkeContentCipher encrypts the content using the AEAD of a HPKE cipher
+// suite, in integrated encryption mode. The "CEK" is the AEAD key followed
+// by the nonce that have been derived by HPKE, and the JWE Initialization
+// Vector and Authentication Tag are empty: the tag is part of the ciphertext
+type hpkeContentCipher struct {
+	aead hpke.AEAD
+}
+
+func newHPKEContentCipher(alg jwa.KeyEncryptionAlgorithm) (*hpkeContentCipher, error) {
+	suite, ok := keyenc.HPKESuite(alg)
+	if !ok || !keyenc.IsHPKEIntegrated(alg) {
+		return nil, fmt.Errorf(`invalid HPKE integrated encryption algorithm (%s)`, alg)
+	}
+	return &hpkeContentCipher{aead: suite.AEAD}, nil
+}
+
+func (c *hpkeContentCipher) KeySize() int {
+	return c.aead.KeySize() + c.aead.NonceSize()
+}
+
+func (c *hpkeContentCipher) split(cek []byte) ([]byte, []byte, error) {
+	if len(cek) != c.KeySize() {
+		return nil, nil, fmt.Errorf(`invalid key size: expected %d bytes, got %d bytes`, c.KeySize(), len(cek))
+	}
+	return cek[:c.aead.KeySize()], cek[c.aead.KeySize():], nil
+}
+
+func (c *hpkeContentCipher) Encrypt(cek, plaintext, aad []byte) ([]byte, []byte, []byte, error) {
+	key, nonce, err := c.split(cek)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	aead, err := c.aead.New(key)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf(`failed to create AEAD: %w`, err)
+	}
+	return nil, aead.Seal(nil, nonce, plaintext, aad), nil, nil
+}
+
+func (c *hpkeContentCipher) Decrypt(cek, iv, ciphertext, tag, aad []byte) ([]byte, error) {
+	if len(iv) > 0 || len(tag) > 0 {
+		return nil, fmt.Errorf(`initialization vector and authentication tag must be empty in HPKE integrated encryption`)
+	}
+	key, nonce, err := c.split(cek)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := c.aead.New(key)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to create AEAD: %w`, err)
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to decrypt content: %w`, err)
+	}
+	return plaintext, nil
+}
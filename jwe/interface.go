@@ -3,6 +3,7 @@ package jwe
 import (
 	"github.com/lestrrat-go/iter/mapiter"
 	"github.com/sjwl/jwx/v2/internal/iter"
+	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/sjwl/jwx/v2/jwe/internal/keygen"
 )
 
@@ -154,6 +155,46 @@ type populater interface {
 	Populate(keygen.Setter) error
 }
 
+// KeyEncrypter encrypts the content encryption key (CEK) for a recipient.
+// Implementations may be registered using `jwe.RegisterKeyEncrypter()`
+type KeyEncrypter interface {
+	Algorithm() jwa.KeyEncryptionAlgorithm
+
+	// EncryptKey encrypts the CEK for a recipient using `key`, which
+	// is given in its raw form (e.g. *rsa.PublicKey instead of jwk.Key).
+	// `cek` is a randomly generated key whose size is suitable for the
+	// content encryption algorithm `enc`. Header parameters that the
+	// recipient needs in order to decrypt the key (e.g. "epk") should
+	// be set in `hdrs`.
+	//
+	// The first return value is the JWE Encrypted Key. Algorithms that
+	// determine the CEK by themselves (e.g. direct key agreement) should
+	// return a nil encrypted key, and return the CEK that should be used
+	// to encrypt the content as the second return value.
+	EncryptKey(enc jwa.ContentEncryptionAlgorithm, cek []byte, key interface{}, hdrs Headers) ([]byte, []byte, error)
+}
+
+// KeyDecrypter decrypts the content encryption key (CEK) for a recipient.
+// Implementations may be registered using `jwe.RegisterKeyDecrypter()`
+type KeyDecrypter interface {
+	Algorithm() jwa.KeyEncryptionAlgorithm
+
+	// DecryptKey decrypts the JWE Encrypted Key using `key`, which is
+	// given in its raw form (e.g. *rsa.PrivateKey instead of jwk.Key),
+	// and returns the CEK. `hdrs` contains the union of the protected,
+	// shared unprotected, and per-recipient headers.
+	DecryptKey(enc jwa.ContentEncryptionAlgorithm, encryptedKey []byte, key interface{}, hdrs Headers) ([]byte, error)
+}
+
+// ContentCipher encrypts and decrypts the JWE payload.
+// Implementations may be registered using `jwe.RegisterContentCipher()`
+type ContentCipher interface {
+	// KeySize returns the size of the CEK in bytes
+	KeySize() int
+	Encrypt(cek, plaintext, aad []byte) (iv, ciphertext, tag []byte, err error)
+	Decrypt(cek, iv, ciphertext, tag, aad []byte) ([]byte, error)
+}
+
 type Visitor = iter.MapVisitor
 type VisitorFunc = iter.MapVisitorFunc
 type HeaderPair = mapiter.Pair
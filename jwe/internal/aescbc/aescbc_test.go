@@ -1,7 +1,9 @@
 package aescbc
 
 import (
+	"bytes"
 	"crypto/aes"
+	"crypto/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -81,3 +83,101 @@ func TestPad(t *testing.T) {
 		}
 	}
 }
+
+func TestStream(t *testing.T) {
+	for _, keysize := range []int{32, 48, 64} {
+		key := make([]byte, keysize)
+		_, err := rand.Read(key)
+		if !assert.NoError(t, err, `rand.Read should succeed`) {
+			return
+		}
+
+		c, err := New(key, aes.NewCipher)
+		if !assert.NoError(t, err, `aescbc.New should succeed`) {
+			return
+		}
+
+		nonce := make([]byte, NonceSize)
+		aad := []byte(`eyJhbGciOiJkaXIiLCJlbmMiOiJBMTI4Q0JDLUhTMjU2In0`)
+		for _, size := range []int{0, 1, 15, 16, 17, 1000, 100000} {
+			for _, chunk := range []int{1, 7, 16, 4096, 100000} {
+				plaintext := make([]byte, size)
+				_, err := rand.Read(plaintext)
+				if !assert.NoError(t, err, `rand.Read should succeed`) {
+					return
+				}
+
+				sealed := c.Seal(nil, nonce, plaintext, aad)
+				tagOffset := len(sealed) - c.tagsize
+				expectedCiphertext := sealed[:tagOffset]
+				expectedTag := sealed[tagOffset:]
+
+				var ciphertext bytes.Buffer
+				enc, err := c.NewStreamEncrypter(&ciphertext, nonce, aad)
+				if !assert.NoError(t, err, `NewStreamEncrypter should succeed`) {
+					return
+				}
+				for p := plaintext; len(p) > 0; {
+					n := chunk
+					if n > len(p) {
+						n = len(p)
+					}
+					_, err := enc.Write(p[:n])
+					if !assert.NoError(t, err, `Write should succeed`) {
+						return
+					}
+					p = p[n:]
+				}
+				if !assert.NoError(t, enc.Close(), `Close should succeed`) {
+					return
+				}
+				if !assert.Equal(t, expectedCiphertext, ciphertext.Bytes(), `ciphertext should match (size=%d, chunk=%d)`, size, chunk) {
+					return
+				}
+				if !assert.Equal(t, expectedTag, enc.Tag(), `tag should match (size=%d, chunk=%d)`, size, chunk) {
+					return
+				}
+
+				var decrypted bytes.Buffer
+				dec, err := c.NewStreamDecrypter(&decrypted, nonce, aad)
+				if !assert.NoError(t, err, `NewStreamDecrypter should succeed`) {
+					return
+				}
+				for p := expectedCiphertext; len(p) > 0; {
+					n := chunk
+					if n > len(p) {
+						n = len(p)
+					}
+					_, err := dec.Write(p[:n])
+					if !assert.NoError(t, err, `Write should succeed`) {
+						return
+					}
+					p = p[n:]
+				}
+				if !assert.NoError(t, dec.Finish(expectedTag), `Finish should succeed`) {
+					return
+				}
+				if !assert.True(t, bytes.Equal(plaintext, decrypted.Bytes()), `plaintext should match (size=%d, chunk=%d)`, size, chunk) {
+					return
+				}
+			}
+		}
+
+		t.Run("tampered", func(t *testing.T) {
+			sealed := c.Seal(nil, nonce, []byte(`Hello, World!`), aad)
+			tagOffset := len(sealed) - c.tagsize
+
+			dec, err := c.NewStreamDecrypter(&bytes.Buffer{}, nonce, aad)
+			if !assert.NoError(t, err, `NewStreamDecrypter should succeed`) {
+				return
+			}
+			_, err = dec.Write(sealed[:tagOffset])
+			if !assert.NoError(t, err, `Write should succeed`) {
+				return
+			}
+			tag := append([]byte(nil), sealed[tagOffset:]...)
+			tag[0] ^= 1
+			assert.Error(t, dec.Finish(tag), `Finish should fail`)
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package aescbc
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// streamChunkSize is the maximum number of bytes that are encrypted
+// or decrypted at once by the stream encrypter and decrypter
+const streamChunkSize = 32 * 1024
+
+// StreamEncrypter encrypts data that is written to it incrementally,
+// and writes the ciphertext to the underlying io.Writer. Close() must
+// be called after all of the plaintext has been written, after which
+// Tag() returns the authentication tag.
+type StreamEncrypter struct {
+	dst      io.Writer
+	mode     cipher.BlockMode
+	mac      hash.Hash
+	aadlen   int
+	tagsize  int
+	partial  []byte
+	npartial int
+	work     []byte
+	tag      []byte
+}
+
+// NewStreamEncrypter creates a new StreamEncrypter that produces the same
+// ciphertext and authentication tag as Seal()
+func (c Hmac) NewStreamEncrypter(dst io.Writer, nonce, aad []byte) (*StreamEncrypter, error) {
+	bs := c.blockCipher.BlockSize()
+	if len(nonce) != bs {
+		return nil, fmt.Errorf(`invalid nonce size: expected %d bytes, got %d bytes`, bs, len(nonce))
+	}
+
+	mac := hmac.New(c.hash, c.integrityKey)
+	mac.Write(aad)
+	mac.Write(nonce)
+	return &StreamEncrypter{
+		dst:     dst,
+		mode:    cipher.NewCBCEncrypter(c.blockCipher, nonce),
+		mac:     mac,
+		aadlen:  len(aad),
+		tagsize: c.tagsize,
+		partial: make([]byte, bs),
+		work:    make([]byte, streamChunkSize-streamChunkSize%bs),
+	}, nil
+}
+
+func (e *StreamEncrypter) emit(ciphertext []byte) error {
+	e.mac.Write(ciphertext)
+	if _, err := e.dst.Write(ciphertext); err != nil {
+		return fmt.Errorf(`failed to write ciphertext: %w`, err)
+	}
+	return nil
+}
+
+// Write encrypts `p`. Ciphertext is written to the underlying io.Writer
+// one block at a time, so up to one block of plaintext may be kept
+// until the next call to Write() or Close()
+func (e *StreamEncrypter) Write(p []byte) (int, error) {
+	if e.tag != nil {
+		return 0, fmt.Errorf(`write to closed stream encrypter`)
+	}
+
+	written := len(p)
+	bs := len(e.partial)
+	for len(p) > 0 {
+		if e.npartial == 0 && len(p) >= bs {
+			n := len(p) - len(p)%bs
+			if n > len(e.work) {
+				n = len(e.work)
+			}
+			e.mode.CryptBlocks(e.work[:n], p[:n])
+			if err := e.emit(e.work[:n]); err != nil {
+				return 0, err
+			}
+			p = p[n:]
+			continue
+		}
+
+		n := copy(e.partial[e.npartial:], p)
+		e.npartial += n
+		p = p[n:]
+		if e.npartial == bs {
+			e.mode.CryptBlocks(e.partial, e.partial)
+			if err := e.emit(e.partial); err != nil {
+				return 0, err
+			}
+			e.npartial = 0
+		}
+	}
+	return written, nil
+}
+
+// Close pads and encrypts the remaining plaintext, and computes
+// the authentication tag
+func (e *StreamEncrypter) Close() error {
+	if e.tag != nil {
+		return nil
+	}
+
+	bs := len(e.partial)
+	padding := bs - e.npartial
+	for i := e.npartial; i < bs; i++ {
+		e.partial[i] = byte(padding)
+	}
+	e.mode.CryptBlocks(e.partial, e.partial)
+	if err := e.emit(e.partial); err != nil {
+		return err
+	}
+
+	e.tag = computeStreamTag(e.mac, e.aadlen, e.tagsize)
+	return nil
+}
+
+// Tag returns the authentication tag. It returns nil until Close()
+// has been called
+func (e *StreamEncrypter) Tag() []byte {
+	return e.tag
+}
+
+// StreamDecrypter decrypts data that is written to it incrementally,
+// and writes the plaintext to the underlying io.Writer. Finish() must
+// be called with the authentication tag after all of the ciphertext
+// has been written.
+//
+// Plaintext is written to the underlying io.Writer before the
+// authentication tag is verified. The last block is only written
+// after the tag has been verified, so that the padding is never
+// inspected for unauthenticated ciphertext.
+type StreamDecrypter struct {
+	dst      io.Writer
+	mode     cipher.BlockMode
+	mac      hash.Hash
+	aadlen   int
+	tagsize  int
+	partial  []byte
+	npartial int
+	work     []byte
+	last     []byte
+	haslast  bool
+	finished bool
+}
+
+// NewStreamDecrypter creates a new StreamDecrypter that is the
+// counterpart of NewStreamEncrypter()
+func (c Hmac) NewStreamDecrypter(dst io.Writer, nonce, aad []byte) (*StreamDecrypter, error) {
+	bs := c.blockCipher.BlockSize()
+	if len(nonce) != bs {
+		return nil, fmt.Errorf(`invalid nonce size: expected %d bytes, got %d bytes`, bs, len(nonce))
+	}
+
+	mac := hmac.New(c.hash, c.integrityKey)
+	mac.Write(aad)
+	mac.Write(nonce)
+	return &StreamDecrypter{
+		dst:     dst,
+		mode:    cipher.NewCBCDecrypter(c.blockCipher, nonce),
+		mac:     mac,
+		aadlen:  len(aad),
+		tagsize: c.tagsize,
+		partial: make([]byte, bs),
+		work:    make([]byte, streamChunkSize-streamChunkSize%bs),
+		last:    make([]byte, bs),
+	}, nil
+}
+
+// decryptBlocks decrypts `ciphertext`, whose length must be a multiple
+// of the block size. The last decrypted block is held back
+func (d *StreamDecrypter) decryptBlocks(ciphertext []byte) error {
+	bs := len(d.last)
+	n := len(ciphertext)
+	d.mac.Write(ciphertext)
+	d.mode.CryptBlocks(d.work[:n], ciphertext)
+
+	if d.haslast {
+		if _, err := d.dst.Write(d.last); err != nil {
+			return fmt.Errorf(`failed to write plaintext: %w`, err)
+		}
+	}
+	if n > bs {
+		if _, err := d.dst.Write(d.work[:n-bs]); err != nil {
+			return fmt.Errorf(`failed to write plaintext: %w`, err)
+		}
+	}
+	copy(d.last, d.work[n-bs:n])
+	d.haslast = true
+	return nil
+}
+
+// Write decrypts `p`
+func (d *StreamDecrypter) Write(p []byte) (int, error) {
+	if d.finished {
+		return 0, fmt.Errorf(`write to finished stream decrypter`)
+	}
+
+	written := len(p)
+	bs := len(d.partial)
+	for len(p) > 0 {
+		if d.npartial == 0 && len(p) >= bs {
+			n := len(p) - len(p)%bs
+			if n > len(d.work) {
+				n = len(d.work)
+			}
+			if err := d.decryptBlocks(p[:n]); err != nil {
+				return 0, err
+			}
+			p = p[n:]
+			continue
+		}
+
+		n := copy(d.partial[d.npartial:], p)
+		d.npartial += n
+		p = p[n:]
+		if d.npartial == bs {
+			if err := d.decryptBlocks(d.partial); err != nil {
+				return 0, err
+			}
+			d.npartial = 0
+		}
+	}
+	return written, nil
+}
+
+// Finish verifies the authentication tag, and writes the last block
+// of plaintext without its padding
+func (d *StreamDecrypter) Finish(tag []byte) error {
+	if d.finished {
+		return fmt.Errorf(`stream decrypter has already been finished`)
+	}
+	d.finished = true
+
+	if d.npartial != 0 || !d.haslast {
+		return fmt.Errorf(`invalid ciphertext (invalid length)`)
+	}
+
+	expected := computeStreamTag(d.mac, d.aadlen, d.tagsize)
+	if subtle.ConstantTimeCompare(expected, tag) != 1 {
+		return fmt.Errorf(`invalid ciphertext (tag mismatch)`)
+	}
+
+	plaintext, err := unpad(d.last, len(d.last))
+	if err != nil {
+		return fmt.Errorf(`failed to generate plaintext from decrypted blocks: %w`, err)
+	}
+	if _, err := d.dst.Write(plaintext); err != nil {
+		return fmt.Errorf(`failed to write plaintext: %w`, err)
+	}
+	return nil
+}
+
+func computeStreamTag(mac hash.Hash, aadlen, tagsize int) []byte {
+	var al [8]byte
+	binary.BigEndian.PutUint64(al[:], uint64(aadlen*8))
+	mac.Write(al[:])
+	return mac.Sum(nil)[:tagsize]
+}
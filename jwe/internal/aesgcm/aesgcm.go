@@ -0,0 +1,352 @@
+// Package aesgcm implements AES-GCM encryption and decryption of data
+// that does not fit in memory. The output is identical to that of
+// crypto/cipher's GCM implementation using 96 bit nonces and 128 bit tags.
+package aesgcm
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	NonceSize = 12
+	TagSize   = 16
+
+	// maxPlaintextSize is the maximum size of data that can be
+	// encrypted using a single key and nonce ((2^32 - 2) blocks)
+	maxPlaintextSize = (1<<32 - 2) * 16
+
+	// streamChunkSize is the maximum number of bytes that are
+	// encrypted or decrypted at once
+	streamChunkSize = 32 * 1024
+)
+
+// fieldElement is an element of GF(2^128), stored in the bit order
+// used by GCM: the most significant bit of hi is the coefficient of x^0
+type fieldElement struct {
+	hi, lo uint64
+}
+
+func loadFieldElement(b []byte) fieldElement {
+	return fieldElement{
+		hi: binary.BigEndian.Uint64(b[:8]),
+		lo: binary.BigEndian.Uint64(b[8:]),
+	}
+}
+
+// bmul64 returns the low 64 bits of the carry-less product of x and y.
+// Integer multiplications are used on operands whose bits are spaced
+// out so that carries never reach the bits that are kept, which avoids
+// branches and memory accesses that depend on secret data (this is the
+// technique used by BearSSL's ghash_ctmul64)
+func bmul64(x, y uint64) uint64 {
+	x0 := x & 0x1111111111111111
+	x1 := x & 0x2222222222222222
+	x2 := x & 0x4444444444444444
+	x3 := x & 0x8888888888888888
+	y0 := y & 0x1111111111111111
+	y1 := y & 0x2222222222222222
+	y2 := y & 0x4444444444444444
+	y3 := y & 0x8888888888888888
+	z0 := (x0 * y0) ^ (x1 * y3) ^ (x2 * y2) ^ (x3 * y1)
+	z1 := (x0 * y1) ^ (x1 * y0) ^ (x2 * y3) ^ (x3 * y2)
+	z2 := (x0 * y2) ^ (x1 * y1) ^ (x2 * y0) ^ (x3 * y3)
+	z3 := (x0 * y3) ^ (x1 * y2) ^ (x2 * y1) ^ (x3 * y0)
+	return z0&0x1111111111111111 |
+		z1&0x2222222222222222 |
+		z2&0x4444444444444444 |
+		z3&0x8888888888888888
+}
+
+// rev64 reverses the order of the bits of x
+func rev64(x uint64) uint64 {
+	x = (x&0x5555555555555555)<<1 | (x>>1)&0x5555555555555555
+	x = (x&0x3333333333333333)<<2 | (x>>2)&0x3333333333333333
+	x = (x&0x0f0f0f0f0f0f0f0f)<<4 | (x>>4)&0x0f0f0f0f0f0f0f0f
+	x = (x&0x00ff00ff00ff00ff)<<8 | (x>>8)&0x00ff00ff00ff00ff
+	x = (x&0x0000ffff0000ffff)<<16 | (x>>16)&0x0000ffff0000ffff
+	return x<<32 | x>>32
+}
+
+// ghash computes GHASH over data that is written to it incrementally.
+// Multiplication by H runs in constant time (see bmul64)
+type ghash struct {
+	// h, hr are the halves of H (h[2] = h[0] ^ h[1]), and their
+	// bit reversed counterparts
+	h        [3]uint64
+	hr       [3]uint64
+	y        fieldElement
+	partial  [16]byte
+	npartial int
+}
+
+func (g *ghash) init(h fieldElement) {
+	g.h = [3]uint64{h.lo, h.hi, h.lo ^ h.hi}
+	g.hr = [3]uint64{rev64(h.lo), rev64(h.hi), rev64(h.lo) ^ rev64(h.hi)}
+}
+
+// mulH sets y to y * H. The 128 bit carry-less product is computed
+// using Karatsuba multiplication over 64 bit halves, and then reduced
+// modulo the GCM polynomial
+func (g *ghash) mulH() {
+	y0, y1 := g.y.lo, g.y.hi
+	y0r, y1r := rev64(y0), rev64(y1)
+
+	z0 := bmul64(y0, g.h[0])
+	z1 := bmul64(y1, g.h[1])
+	z2 := bmul64(y0^y1, g.h[2])
+	z0h := bmul64(y0r, g.hr[0])
+	z1h := bmul64(y1r, g.hr[1])
+	z2h := bmul64(y0r^y1r, g.hr[2])
+	z2 ^= z0 ^ z1
+	z2h ^= z0h ^ z1h
+	z0h = rev64(z0h) >> 1
+	z1h = rev64(z1h) >> 1
+	z2h = rev64(z2h) >> 1
+
+	v0 := z0
+	v1 := z0h ^ z2
+	v2 := z1 ^ z2h
+	v3 := z1h
+
+	// GCM uses reflected bit order, so the product is one bit short
+	v3 = v3<<1 | v2>>63
+	v2 = v2<<1 | v1>>63
+	v1 = v1<<1 | v0>>63
+	v0 <<= 1
+
+	v2 ^= v0 ^ v0>>1 ^ v0>>2 ^ v0>>7
+	v1 ^= v0<<63 ^ v0<<62 ^ v0<<57
+	v3 ^= v1 ^ v1>>1 ^ v1>>2 ^ v1>>7
+	v2 ^= v1<<63 ^ v1<<62 ^ v1<<57
+
+	g.y = fieldElement{hi: v3, lo: v2}
+}
+
+func (g *ghash) block(b []byte) {
+	x := loadFieldElement(b)
+	g.y.hi ^= x.hi
+	g.y.lo ^= x.lo
+	g.mulH()
+}
+
+func (g *ghash) Write(p []byte) {
+	for len(p) > 0 {
+		if g.npartial == 0 && len(p) >= 16 {
+			g.block(p[:16])
+			p = p[16:]
+			continue
+		}
+		n := copy(g.partial[g.npartial:], p)
+		g.npartial += n
+		p = p[n:]
+		if g.npartial == 16 {
+			g.block(g.partial[:])
+			g.npartial = 0
+		}
+	}
+}
+
+// pad completes the current block with zeros
+func (g *ghash) pad() {
+	if g.npartial == 0 {
+		return
+	}
+	for i := g.npartial; i < 16; i++ {
+		g.partial[i] = 0
+	}
+	g.block(g.partial[:])
+	g.npartial = 0
+}
+
+type stream struct {
+	ctr     cipher.Stream
+	hash    ghash
+	tagMask [16]byte
+	aadlen  uint64
+	size    uint64
+	work    []byte
+}
+
+func newStream(block cipher.Block, nonce, aad []byte) (*stream, error) {
+	if block.BlockSize() != 16 {
+		return nil, fmt.Errorf(`aesgcm: block size must be 16 bytes`)
+	}
+	if len(nonce) != NonceSize {
+		return nil, fmt.Errorf(`aesgcm: invalid nonce size: expected %d bytes, got %d bytes`, NonceSize, len(nonce))
+	}
+
+	var s stream
+	var h [16]byte
+	block.Encrypt(h[:], h[:])
+	s.hash.init(loadFieldElement(h[:]))
+
+	// J0 = nonce || 0^31 || 1. The first counter block used for
+	// the plaintext is inc32(J0)
+	var counter [16]byte
+	copy(counter[:], nonce)
+	counter[15] = 1
+	block.Encrypt(s.tagMask[:], counter[:])
+	counter[15] = 2
+
+	// Because the size of the plaintext is limited to (2^32 - 2) blocks,
+	// the 32 bit counter used by GCM never wraps around, and the
+	// 128 bit counter of cipher.NewCTR produces the same key stream
+	s.ctr = cipher.NewCTR(block, counter[:])
+
+	s.hash.Write(aad)
+	s.hash.pad()
+	s.aadlen = uint64(len(aad))
+	s.work = make([]byte, streamChunkSize)
+	return &s, nil
+}
+
+func (s *stream) addSize(n int) error {
+	s.size += uint64(n)
+	if s.size > maxPlaintextSize {
+		return fmt.Errorf(`aesgcm: message too large`)
+	}
+	return nil
+}
+
+func (s *stream) tag() []byte {
+	s.hash.pad()
+	var lengths [16]byte
+	binary.BigEndian.PutUint64(lengths[:8], s.aadlen*8)
+	binary.BigEndian.PutUint64(lengths[8:], s.size*8)
+	s.hash.block(lengths[:])
+
+	tag := make([]byte, TagSize)
+	binary.BigEndian.PutUint64(tag[:8], s.hash.y.hi)
+	binary.BigEndian.PutUint64(tag[8:], s.hash.y.lo)
+	for i := range tag {
+		tag[i] ^= s.tagMask[i]
+	}
+	return tag
+}
+
+// StreamEncrypter encrypts data that is written to it incrementally,
+// and writes the ciphertext to the underlying io.Writer. Close() must
+// be called after all of the plaintext has been written, after which
+// Tag() returns the authentication tag.
+type StreamEncrypter struct {
+	stream *stream
+	dst    io.Writer
+	tag    []byte
+}
+
+// NewStreamEncrypter creates a new StreamEncrypter using the
+// AES block cipher `block`
+func NewStreamEncrypter(dst io.Writer, block cipher.Block, nonce, aad []byte) (*StreamEncrypter, error) {
+	s, err := newStream(block, nonce, aad)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamEncrypter{stream: s, dst: dst}, nil
+}
+
+// Write encrypts `p`, and writes the ciphertext to the underlying io.Writer
+func (e *StreamEncrypter) Write(p []byte) (int, error) {
+	if e.tag != nil {
+		return 0, fmt.Errorf(`aesgcm: write to closed stream encrypter`)
+	}
+
+	written := len(p)
+	s := e.stream
+	for len(p) > 0 {
+		n := len(p)
+		if n > len(s.work) {
+			n = len(s.work)
+		}
+		if err := s.addSize(n); err != nil {
+			return 0, err
+		}
+		s.ctr.XORKeyStream(s.work[:n], p[:n])
+		s.hash.Write(s.work[:n])
+		if _, err := e.dst.Write(s.work[:n]); err != nil {
+			return 0, fmt.Errorf(`aesgcm: failed to write ciphertext: %w`, err)
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Close computes the authentication tag
+func (e *StreamEncrypter) Close() error {
+	if e.tag == nil {
+		e.tag = e.stream.tag()
+	}
+	return nil
+}
+
+// Tag returns the authentication tag. It returns nil until Close()
+// has been called
+func (e *StreamEncrypter) Tag() []byte {
+	return e.tag
+}
+
+// StreamDecrypter decrypts data that is written to it incrementally,
+// and writes the plaintext to the underlying io.Writer. Finish() must
+// be called with the authentication tag after all of the ciphertext
+// has been written.
+//
+// Plaintext is written to the underlying io.Writer before the
+// authentication tag is verified. It must not be used unless
+// Finish() succeeds.
+type StreamDecrypter struct {
+	stream   *stream
+	dst      io.Writer
+	finished bool
+}
+
+// NewStreamDecrypter creates a new StreamDecrypter using the
+// AES block cipher `block`
+func NewStreamDecrypter(dst io.Writer, block cipher.Block, nonce, aad []byte) (*StreamDecrypter, error) {
+	s, err := newStream(block, nonce, aad)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamDecrypter{stream: s, dst: dst}, nil
+}
+
+// Write decrypts `p`, and writes the plaintext to the underlying io.Writer
+func (d *StreamDecrypter) Write(p []byte) (int, error) {
+	if d.finished {
+		return 0, fmt.Errorf(`aesgcm: write to finished stream decrypter`)
+	}
+
+	written := len(p)
+	s := d.stream
+	for len(p) > 0 {
+		n := len(p)
+		if n > len(s.work) {
+			n = len(s.work)
+		}
+		if err := s.addSize(n); err != nil {
+			return 0, err
+		}
+		s.hash.Write(p[:n])
+		s.ctr.XORKeyStream(s.work[:n], p[:n])
+		if _, err := d.dst.Write(s.work[:n]); err != nil {
+			return 0, fmt.Errorf(`aesgcm: failed to write plaintext: %w`, err)
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Finish verifies the authentication tag
+func (d *StreamDecrypter) Finish(tag []byte) error {
+	if d.finished {
+		return fmt.Errorf(`aesgcm: stream decrypter has already been finished`)
+	}
+	d.finished = true
+
+	if subtle.ConstantTimeCompare(d.stream.tag(), tag) != 1 {
+		return fmt.Errorf(`aesgcm: message authentication failed`)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package aesgcm_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/sjwl/jwx/v2/jwe/internal/aesgcm"
+	"github.com/stretchr/testify/require"
+)
+
+// writeInChunks writes `data` to `w` in chunks of `size` bytes
+func writeInChunks(t *testing.T, w interface{ Write([]byte) (int, error) }, data []byte, size int) {
+	t.Helper()
+	for len(data) > 0 {
+		n := size
+		if n > len(data) {
+			n = len(data)
+		}
+		_, err := w.Write(data[:n])
+		require.NoError(t, err, `Write should succeed`)
+		data = data[n:]
+	}
+}
+
+func TestStream(t *testing.T) {
+	for _, keysize := range []int{16, 24, 32} {
+		key := make([]byte, keysize)
+		_, err := rand.Read(key)
+		require.NoError(t, err, `rand.Read should succeed`)
+
+		block, err := aes.NewCipher(key)
+		require.NoError(t, err, `aes.NewCipher should succeed`)
+		aead, err := cipher.NewGCM(block)
+		require.NoError(t, err, `cipher.NewGCM should succeed`)
+
+		nonce := make([]byte, aesgcm.NonceSize)
+		_, err = rand.Read(nonce)
+		require.NoError(t, err, `rand.Read should succeed`)
+		aad := []byte(`eyJhbGciOiJkaXIiLCJlbmMiOiJBMTI4R0NNIn0`)
+
+		for _, size := range []int{0, 1, 15, 16, 17, 1000, 100000} {
+			for _, chunk := range []int{1, 7, 16, 4096, 100000} {
+				plaintext := make([]byte, size)
+				_, err := rand.Read(plaintext)
+				require.NoError(t, err, `rand.Read should succeed`)
+
+				sealed := aead.Seal(nil, nonce, plaintext, aad)
+				expectedCiphertext := sealed[:size]
+				expectedTag := sealed[size:]
+
+				var ciphertext bytes.Buffer
+				enc, err := aesgcm.NewStreamEncrypter(&ciphertext, block, nonce, aad)
+				require.NoError(t, err, `aesgcm.NewStreamEncrypter should succeed`)
+				writeInChunks(t, enc, plaintext, chunk)
+				require.NoError(t, enc.Close(), `Close should succeed`)
+				require.True(t, bytes.Equal(expectedCiphertext, ciphertext.Bytes()), `ciphertext should match (size=%d, chunk=%d)`, size, chunk)
+				require.Equal(t, expectedTag, enc.Tag(), `tag should match (size=%d, chunk=%d)`, size, chunk)
+
+				var decrypted bytes.Buffer
+				dec, err := aesgcm.NewStreamDecrypter(&decrypted, block, nonce, aad)
+				require.NoError(t, err, `aesgcm.NewStreamDecrypter should succeed`)
+				writeInChunks(t, dec, expectedCiphertext, chunk)
+				require.NoError(t, dec.Finish(expectedTag), `Finish should succeed`)
+				require.True(t, bytes.Equal(plaintext, decrypted.Bytes()), `plaintext should match`)
+			}
+		}
+	}
+}
+
+func TestStreamTampered(t *testing.T) {
+	key := make([]byte, 16)
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err, `aes.NewCipher should succeed`)
+	nonce := make([]byte, aesgcm.NonceSize)
+
+	var ciphertext bytes.Buffer
+	enc, err := aesgcm.NewStreamEncrypter(&ciphertext, block, nonce, nil)
+	require.NoError(t, err, `aesgcm.NewStreamEncrypter should succeed`)
+	_, err = enc.Write([]byte(`Hello, World!`))
+	require.NoError(t, err, `Write should succeed`)
+	require.NoError(t, enc.Close(), `Close should succeed`)
+
+	tampered := ciphertext.Bytes()
+	tampered[0] ^= 1
+
+	dec, err := aesgcm.NewStreamDecrypter(&bytes.Buffer{}, block, nonce, nil)
+	require.NoError(t, err, `aesgcm.NewStreamDecrypter should succeed`)
+	_, err = dec.Write(tampered)
+	require.NoError(t, err, `Write should succeed`)
+	require.Error(t, dec.Finish(enc.Tag()), `Finish should fail`)
+
+	_, err = aesgcm.NewStreamEncrypter(&bytes.Buffer{}, block, make([]byte, 16), nil)
+	require.Error(t, err, `non-96 bit nonces should be rejected`)
+}
+
+func BenchmarkStream(b *testing.B) {
+	block, err := aes.NewCipher(make([]byte, 32))
+	require.NoError(b, err, `aes.NewCipher should succeed`)
+	nonce := make([]byte, aesgcm.NonceSize)
+	plaintext := make([]byte, 1024*1024)
+
+	b.Run("Encrypt", func(b *testing.B) {
+		b.SetBytes(int64(len(plaintext)))
+		for i := 0; i < b.N; i++ {
+			enc, err := aesgcm.NewStreamEncrypter(io.Discard, block, nonce, nil)
+			require.NoError(b, err, `aesgcm.NewStreamEncrypter should succeed`)
+			_, _ = enc.Write(plaintext)
+			require.NoError(b, enc.Close(), `Close should succeed`)
+		}
+	})
+
+	var ciphertext bytes.Buffer
+	enc, err := aesgcm.NewStreamEncrypter(&ciphertext, block, nonce, nil)
+	require.NoError(b, err, `aesgcm.NewStreamEncrypter should succeed`)
+	_, _ = enc.Write(plaintext)
+	require.NoError(b, enc.Close(), `Close should succeed`)
+
+	b.Run("Decrypt", func(b *testing.B) {
+		b.SetBytes(int64(ciphertext.Len()))
+		for i := 0; i < b.N; i++ {
+			dec, err := aesgcm.NewStreamDecrypter(io.Discard, block, nonce, nil)
+			require.NoError(b, err, `aesgcm.NewStreamDecrypter should succeed`)
+			_, _ = dec.Write(ciphertext.Bytes())
+			require.NoError(b, dec.Finish(enc.Tag()), `Finish should succeed`)
+		}
+	})
+}
@@ -4,14 +4,19 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"fmt"
+	"io"
 
 	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/sjwl/jwx/v2/jwe/internal/aescbc"
+	"github.com/sjwl/jwx/v2/jwe/internal/aesgcm"
 	"github.com/sjwl/jwx/v2/jwe/internal/keygen"
+	"github.com/sjwl/jwx/v2/jwe/internal/xc20p"
+	"golang.org/x/crypto/chacha20poly1305"
 )
 
 var gcm = &gcmFetcher{}
 var cbc = &cbcFetcher{}
+var xchacha = &xc20pFetcher{}
 
 func (f gcmFetcher) Fetch(key []byte) (cipher.AEAD, error) {
 	aescipher, err := aes.NewCipher(key)
@@ -26,6 +31,22 @@ func (f gcmFetcher) Fetch(key []byte) (cipher.AEAD, error) {
 	return aead, nil
 }
 
+func (f gcmFetcher) FetchStreamEncrypter(dst io.Writer, key, iv, aad []byte) (StreamEncrypter, error) {
+	aescipher, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf(`cipher: failed to create AES cipher for GCM: %w`, err)
+	}
+	return aesgcm.NewStreamEncrypter(dst, aescipher, iv, aad)
+}
+
+func (f gcmFetcher) FetchStreamDecrypter(dst io.Writer, key, iv, aad []byte) (StreamDecrypter, error) {
+	aescipher, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf(`cipher: failed to create AES cipher for GCM: %w`, err)
+	}
+	return aesgcm.NewStreamDecrypter(dst, aescipher, iv, aad)
+}
+
 func (f cbcFetcher) Fetch(key []byte) (cipher.AEAD, error) {
 	aead, err := aescbc.New(key, aes.NewCipher)
 	if err != nil {
@@ -34,6 +55,38 @@ func (f cbcFetcher) Fetch(key []byte) (cipher.AEAD, error) {
 	return aead, nil
 }
 
+func (f cbcFetcher) FetchStreamEncrypter(dst io.Writer, key, iv, aad []byte) (StreamEncrypter, error) {
+	aead, err := aescbc.New(key, aes.NewCipher)
+	if err != nil {
+		return nil, fmt.Errorf(`cipher: failed to create AES cipher for CBC: %w`, err)
+	}
+	return aead.NewStreamEncrypter(dst, iv, aad)
+}
+
+func (f cbcFetcher) FetchStreamDecrypter(dst io.Writer, key, iv, aad []byte) (StreamDecrypter, error) {
+	aead, err := aescbc.New(key, aes.NewCipher)
+	if err != nil {
+		return nil, fmt.Errorf(`cipher: failed to create AES cipher for CBC: %w`, err)
+	}
+	return aead.NewStreamDecrypter(dst, iv, aad)
+}
+
+func (f xc20pFetcher) Fetch(key []byte) (cipher.AEAD, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf(`cipher: failed to create XChaCha20-Poly1305 cipher: %w`, err)
+	}
+	return aead, nil
+}
+
+func (f xc20pFetcher) FetchStreamEncrypter(dst io.Writer, key, iv, aad []byte) (StreamEncrypter, error) {
+	return xc20p.NewStreamEncrypter(dst, key, iv, aad)
+}
+
+func (f xc20pFetcher) FetchStreamDecrypter(dst io.Writer, key, iv, aad []byte) (StreamDecrypter, error) {
+	return xc20p.NewStreamDecrypter(dst, key, iv, aad)
+}
+
 func (c AesContentCipher) KeySize() int {
 	return c.keysize
 }
@@ -42,6 +95,24 @@ func (c AesContentCipher) TagSize() int {
 	return c.tagsize
 }
 
+// New creates a content cipher for the given algorithm
+func New(alg jwa.ContentEncryptionAlgorithm) (ContentCipher, error) {
+	if alg == jwa.XC20P {
+		return NewXC20P(), nil
+	}
+	return NewAES(alg)
+}
+
+// NewXC20P creates a content cipher using XChaCha20-Poly1305. Apart from
+// the AEAD that is used, it works exactly like the AES based ciphers
+func NewXC20P() *AesContentCipher {
+	return &AesContentCipher{
+		keysize: xc20p.KeySize,
+		tagsize: xc20p.TagSize,
+		fetch:   xchacha,
+	}
+}
+
 func NewAES(alg jwa.ContentEncryptionAlgorithm) (*AesContentCipher, error) {
 	var keysize int
 	var tagsize int
@@ -159,3 +230,48 @@ func (c AesContentCipher) Decrypt(cek, iv, ciphertxt, tag, aad []byte) (plaintex
 	plaintext = buf
 	return
 }
+
+// EncryptStream returns a StreamEncrypter that encrypts the content
+// written to it, and writes the ciphertext to `dst`. The returned
+// iv must be included in the message.
+func (c AesContentCipher) EncryptStream(dst io.Writer, cek, aad []byte) ([]byte, StreamEncrypter, error) {
+	if len(cek) != c.keysize {
+		return nil, nil, fmt.Errorf(`invalid key size: expected %d bytes, got %d bytes`, c.keysize, len(cek))
+	}
+
+	aead, err := c.fetch.Fetch(cek)
+	if err != nil {
+		return nil, nil, fmt.Errorf(`failed to fetch AEAD: %w`, err)
+	}
+
+	var bs keygen.ByteSource
+	if c.NonceGenerator == nil {
+		bs, err = keygen.NewRandom(aead.NonceSize()).Generate()
+	} else {
+		bs, err = c.NonceGenerator.Generate()
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf(`failed to generate nonce: %w`, err)
+	}
+	iv := bs.Bytes()
+
+	enc, err := c.fetch.FetchStreamEncrypter(dst, cek, iv, aad)
+	if err != nil {
+		return nil, nil, fmt.Errorf(`failed to create stream encrypter: %w`, err)
+	}
+	return iv, enc, nil
+}
+
+// DecryptStream returns a StreamDecrypter that decrypts the ciphertext
+// written to it, and writes the content to `dst`
+func (c AesContentCipher) DecryptStream(dst io.Writer, cek, iv, aad []byte) (StreamDecrypter, error) {
+	if len(cek) != c.keysize {
+		return nil, fmt.Errorf(`invalid key size: expected %d bytes, got %d bytes`, c.keysize, len(cek))
+	}
+
+	dec, err := c.fetch.FetchStreamDecrypter(dst, cek, iv, aad)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to create stream decrypter: %w`, err)
+	}
+	return dec, nil
+}
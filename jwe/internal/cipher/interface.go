@@ -2,6 +2,7 @@ package cipher
 
 import (
 	"crypto/cipher"
+	"io"
 
 	"github.com/sjwl/jwx/v2/jwe/internal/keygen"
 )
@@ -16,16 +17,42 @@ type ContentCipher interface {
 	KeySize() int
 	Encrypt(cek, aad, plaintext []byte) ([]byte, []byte, []byte, error)
 	Decrypt(cek, iv, aad, ciphertext, tag []byte) ([]byte, error)
+	EncryptStream(dst io.Writer, cek, aad []byte) ([]byte, StreamEncrypter, error)
+	DecryptStream(dst io.Writer, cek, iv, aad []byte) (StreamDecrypter, error)
+}
+
+// StreamEncrypter encrypts content that is written to it incrementally,
+// and writes the ciphertext to an io.Writer. Close() must be called after
+// all of the content has been written, after which Tag() returns the
+// authentication tag.
+type StreamEncrypter interface {
+	io.WriteCloser
+	Tag() []byte
+}
+
+// StreamDecrypter decrypts ciphertext that is written to it incrementally,
+// and writes the content to an io.Writer. Finish() must be called with
+// the authentication tag after all of the ciphertext has been written.
+// Content that has been written before Finish() succeeds is not authenticated,
+// which is why jwe.DecryptStream() verifies the tag with a StreamDecrypter that
+// discards the content before decrypting the ciphertext again.
+type StreamDecrypter interface {
+	io.Writer
+	Finish(tag []byte) error
 }
 
 type Fetcher interface {
 	Fetch([]byte) (cipher.AEAD, error)
+	FetchStreamEncrypter(dst io.Writer, key, iv, aad []byte) (StreamEncrypter, error)
+	FetchStreamDecrypter(dst io.Writer, key, iv, aad []byte) (StreamDecrypter, error)
 }
 
 type gcmFetcher struct{}
 type cbcFetcher struct{}
+type xc20pFetcher struct{}
 
-// AesContentCipher represents a cipher based on AES
+// AesContentCipher represents a cipher based on AES. It is also
+// used for XChaCha20-Poly1305 (see NewXC20P)
 type AesContentCipher struct {
 	NonceGenerator keygen.Generator
 	fetch          Fetcher
@@ -2,6 +2,7 @@ package content_crypt //nolint:golint
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/sjwl/jwx/v2/jwe/internal/cipher"
@@ -24,10 +25,26 @@ func (c Generic) Decrypt(cek, iv, ciphertext, tag, aad []byte) ([]byte, error) {
 	return c.cipher.Decrypt(cek, iv, ciphertext, tag, aad)
 }
 
+// EncryptStream returns a StreamEncrypter that encrypts the content
+// written to it. See `cipher.StreamEncrypter` for details
+func (c Generic) EncryptStream(dst io.Writer, cek, aad []byte) ([]byte, cipher.StreamEncrypter, error) {
+	iv, enc, err := c.cipher.EncryptStream(dst, cek, aad)
+	if err != nil {
+		return nil, nil, fmt.Errorf(`failed to crypt content: %w`, err)
+	}
+	return iv, enc, nil
+}
+
+// DecryptStream returns a StreamDecrypter that decrypts the ciphertext
+// written to it. See `cipher.StreamDecrypter` for details
+func (c Generic) DecryptStream(dst io.Writer, cek, iv, aad []byte) (cipher.StreamDecrypter, error) {
+	return c.cipher.DecryptStream(dst, cek, iv, aad)
+}
+
 func NewGeneric(alg jwa.ContentEncryptionAlgorithm) (*Generic, error) {
-	c, err := cipher.NewAES(alg)
+	c, err := cipher.New(alg)
 	if err != nil {
-		return nil, fmt.Errorf(`aes crypt: failed to create content cipher: %w`, err)
+		return nil, fmt.Errorf(`failed to create content cipher: %w`, err)
 	}
 
 	return &Generic{
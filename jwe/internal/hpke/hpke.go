@@ -0,0 +1,472 @@
+// Package hpke implements the base mode of Hybrid Public Key Encryption
+// as described in RFC9180, using the DH based KEMs for the NIST curves
+// and X25519.
+package hpke
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	_ "crypto/sha256" // registers SHA-256
+	_ "crypto/sha512" // registers SHA-384 and SHA-512
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/sjwl/jwx/v2/x25519"
+)
+
+// KEM is the identifier of a key encapsulation mechanism
+type KEM uint16
+
+// KDF is the identifier of a key derivation function
+type KDF uint16
+
+// AEAD is the identifier of an authenticated encryption algorithm
+type AEAD uint16
+
+//nolint:revive,stylecheck
+const (
+	KEM_P256_HKDF_SHA256   KEM = 0x0010
+	KEM_P384_HKDF_SHA384   KEM = 0x0011
+	KEM_P521_HKDF_SHA512   KEM = 0x0012
+	KEM_X25519_HKDF_SHA256 KEM = 0x0020
+
+	KDF_HKDF_SHA256 KDF = 0x0001
+	KDF_HKDF_SHA384 KDF = 0x0002
+	KDF_HKDF_SHA512 KDF = 0x0003
+
+	AEAD_AES_128_GCM      AEAD = 0x0001
+	AEAD_AES_256_GCM      AEAD = 0x0002
+	AEAD_ChaCha20Poly1305 AEAD = 0x0003
+)
+
+const modeBase = 0x00
+
+var versionLabel = []byte("HPKE-v1")
+
+// keyAgreement is implemented by private keys that compute the
+// Diffie-Hellman shared secret without exposing the private key
+// (e.g. jwe.KeyAgreement)
+type keyAgreement interface {
+	Public() crypto.PublicKey
+	SharedSecret(pubkey crypto.PublicKey) ([]byte, error)
+}
+
+// Suite is a combination of a KEM, a KDF, and an AEAD
+type Suite struct {
+	KEM  KEM
+	KDF  KDF
+	AEAD AEAD
+}
+
+// Context is the encryption context shared by the sender and the
+// recipient after the setup has been completed
+type Context struct {
+	aead      cipher.AEAD
+	key       []byte
+	baseNonce []byte
+	seq       uint64
+}
+
+func (kem KEM) hash() (crypto.Hash, error) {
+	switch kem {
+	case KEM_P256_HKDF_SHA256, KEM_X25519_HKDF_SHA256:
+		return crypto.SHA256, nil
+	case KEM_P384_HKDF_SHA384:
+		return crypto.SHA384, nil
+	case KEM_P521_HKDF_SHA512:
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf(`hpke: unsupported KEM (0x%04x)`, uint16(kem))
+	}
+}
+
+func (kem KEM) curve() elliptic.Curve {
+	switch kem {
+	case KEM_P256_HKDF_SHA256:
+		return elliptic.P256()
+	case KEM_P384_HKDF_SHA384:
+		return elliptic.P384()
+	case KEM_P521_HKDF_SHA512:
+		return elliptic.P521()
+	default:
+		return nil
+	}
+}
+
+func (kem KEM) suiteID() []byte {
+	return []byte{'K', 'E', 'M', byte(kem >> 8), byte(kem)}
+}
+
+func (kdf KDF) hash() (crypto.Hash, error) {
+	switch kdf {
+	case KDF_HKDF_SHA256:
+		return crypto.SHA256, nil
+	case KDF_HKDF_SHA384:
+		return crypto.SHA384, nil
+	case KDF_HKDF_SHA512:
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf(`hpke: unsupported KDF (0x%04x)`, uint16(kdf))
+	}
+}
+
+// KeySize returns the size of the AEAD key in bytes (Nk)
+func (aead AEAD) KeySize() int {
+	switch aead {
+	case AEAD_AES_128_GCM:
+		return 16
+	case AEAD_AES_256_GCM, AEAD_ChaCha20Poly1305:
+		return 32
+	default:
+		return 0
+	}
+}
+
+// NonceSize returns the size of the AEAD nonce in bytes (Nn)
+func (aead AEAD) NonceSize() int {
+	return 12
+}
+
+// New creates a cipher.AEAD using the given key
+func (aead AEAD) New(key []byte) (cipher.AEAD, error) {
+	if n := aead.KeySize(); n == 0 {
+		return nil, fmt.Errorf(`hpke: unsupported AEAD (0x%04x)`, uint16(aead))
+	} else if len(key) != n {
+		return nil, fmt.Errorf(`hpke: invalid key size: expected %d bytes, got %d bytes`, n, len(key))
+	}
+
+	switch aead {
+	case AEAD_ChaCha20Poly1305:
+		return chacha20poly1305.New(key)
+	default:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf(`hpke: failed to create AES cipher: %w`, err)
+		}
+		return cipher.NewGCM(block)
+	}
+}
+
+func labeledExtract(h crypto.Hash, suiteID, salt []byte, label string, ikm []byte) []byte {
+	labeled := make([]byte, 0, len(versionLabel)+len(suiteID)+len(label)+len(ikm))
+	labeled = append(labeled, versionLabel...)
+	labeled = append(labeled, suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, ikm...)
+	return hkdf.Extract(h.New, labeled, salt)
+}
+
+func labeledExpand(h crypto.Hash, suiteID, prk []byte, label string, info []byte, length int) ([]byte, error) {
+	labeled := make([]byte, 2, 2+len(versionLabel)+len(suiteID)+len(label)+len(info))
+	binary.BigEndian.PutUint16(labeled, uint16(length))
+	labeled = append(labeled, versionLabel...)
+	labeled = append(labeled, suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, info...)
+
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.Expand(h.New, prk, labeled), out); err != nil {
+		return nil, fmt.Errorf(`hpke: failed to expand key: %w`, err)
+	}
+	return out, nil
+}
+
+// extractAndExpand derives the shared secret of the KEM
+func (kem KEM) extractAndExpand(dh, kemContext []byte) ([]byte, error) {
+	h, err := kem.hash()
+	if err != nil {
+		return nil, err
+	}
+	suiteID := kem.suiteID()
+	prk := labeledExtract(h, suiteID, nil, "eae_prk", dh)
+	return labeledExpand(h, suiteID, prk, "shared_secret", kemContext, h.Size())
+}
+
+// serializePublicKey encodes the public key as specified in RFC9180
+// section 7.1.1, after making sure that it can be used with the KEM
+func (kem KEM) serializePublicKey(pubkey interface{}) ([]byte, error) {
+	if crv := kem.curve(); crv != nil {
+		key, ok := pubkey.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf(`hpke: public key must be *ecdsa.PublicKey, was: %T`, pubkey)
+		}
+		if key.Curve != crv || !crv.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf(`hpke: public key must be on curve %s`, crv.Params().Name)
+		}
+		return elliptic.Marshal(crv, key.X, key.Y), nil
+	}
+
+	key, ok := pubkey.(x25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf(`hpke: public key must be x25519.PublicKey, was: %T`, pubkey)
+	}
+	if len(key) != x25519.PublicKeySize {
+		return nil, fmt.Errorf(`hpke: invalid X25519 public key size`)
+	}
+	return []byte(key), nil
+}
+
+func (kem KEM) deserializePublicKey(enc []byte) (interface{}, error) {
+	if crv := kem.curve(); crv != nil {
+		x, y := elliptic.Unmarshal(crv, enc)
+		if x == nil {
+			return nil, fmt.Errorf(`hpke: invalid encapsulated key`)
+		}
+		return &ecdsa.PublicKey{Curve: crv, X: x, Y: y}, nil
+	}
+
+	if len(enc) != x25519.PublicKeySize {
+		return nil, fmt.Errorf(`hpke: invalid encapsulated key`)
+	}
+	return x25519.PublicKey(enc), nil
+}
+
+func (kem KEM) generateKey() (interface{}, error) {
+	if crv := kem.curve(); crv != nil {
+		return ecdsa.GenerateKey(crv, rand.Reader)
+	}
+	_, priv, err := x25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
+
+// publicKeyOf returns the public key that corresponds to the private key
+func publicKeyOf(privkey interface{}) (interface{}, error) {
+	switch key := privkey.(type) {
+	case *ecdsa.PrivateKey:
+		return &key.PublicKey, nil
+	case x25519.PrivateKey:
+		return key.Public(), nil
+	case keyAgreement:
+		return key.Public(), nil
+	default:
+		return nil, fmt.Errorf(`hpke: unsupported private key type %T`, privkey)
+	}
+}
+
+// dh computes the Diffie-Hellman shared secret. For NIST curves, it is
+// the x-coordinate of the shared point, left-padded to the size of the curve
+func (kem KEM) dh(privkey, pubkey interface{}) ([]byte, error) {
+	// makes sure that pubkey is of the correct type, and on the curve
+	if _, err := kem.serializePublicKey(pubkey); err != nil {
+		return nil, err
+	}
+
+	switch key := privkey.(type) {
+	case keyAgreement:
+		if _, err := kem.serializePublicKey(key.Public()); err != nil {
+			return nil, fmt.Errorf(`hpke: invalid key agreement: %w`, err)
+		}
+		dh, err := key.SharedSecret(pubkey)
+		if err != nil {
+			return nil, fmt.Errorf(`hpke: failed to compute shared secret: %w`, err)
+		}
+		return dh, nil
+	case x25519.PrivateKey:
+		if kem.curve() != nil {
+			return nil, fmt.Errorf(`hpke: private key must be *ecdsa.PrivateKey, was: %T`, privkey)
+		}
+		//nolint:forcetypeassert
+		dh, err := curve25519.X25519(key.Seed(), pubkey.(x25519.PublicKey))
+		if err != nil {
+			return nil, fmt.Errorf(`hpke: failed to compute shared secret: %w`, err)
+		}
+		return dh, nil
+	case *ecdsa.PrivateKey:
+		crv := kem.curve()
+		if crv == nil || key.Curve != crv {
+			return nil, fmt.Errorf(`hpke: private key must be on the curve of the KEM`)
+		}
+		//nolint:forcetypeassert
+		pub := pubkey.(*ecdsa.PublicKey)
+		x, _ := crv.ScalarMult(pub.X, pub.Y, key.D.Bytes())
+		return padBigInt(x, (crv.Params().BitSize+7)/8), nil
+	default:
+		return nil, fmt.Errorf(`hpke: unsupported private key type %T`, privkey)
+	}
+}
+
+func padBigInt(v *big.Int, size int) []byte {
+	buf := make([]byte, size)
+	return v.FillBytes(buf)
+}
+
+// encap generates the shared secret and its encapsulation for the
+// recipient's public key, using the given ephemeral private key
+func (kem KEM) encap(pkR, skE interface{}) ([]byte, []byte, error) {
+	pkE, err := publicKeyOf(skE)
+	if err != nil {
+		return nil, nil, err
+	}
+	dh, err := kem.dh(skE, pkR)
+	if err != nil {
+		return nil, nil, err
+	}
+	enc, err := kem.serializePublicKey(pkE)
+	if err != nil {
+		return nil, nil, err
+	}
+	pkRm, err := kem.serializePublicKey(pkR)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kemContext := append(append([]byte{}, enc...), pkRm...)
+	sharedSecret, err := kem.extractAndExpand(dh, kemContext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sharedSecret, enc, nil
+}
+
+func (kem KEM) decap(enc []byte, skR interface{}) ([]byte, error) {
+	pkE, err := kem.deserializePublicKey(enc)
+	if err != nil {
+		return nil, err
+	}
+	dh, err := kem.dh(skR, pkE)
+	if err != nil {
+		return nil, err
+	}
+	pkR, err := publicKeyOf(skR)
+	if err != nil {
+		return nil, err
+	}
+	pkRm, err := kem.serializePublicKey(pkR)
+	if err != nil {
+		return nil, err
+	}
+
+	kemContext := append(append([]byte{}, enc...), pkRm...)
+	return kem.extractAndExpand(dh, kemContext)
+}
+
+func (s Suite) suiteID() []byte {
+	return []byte{
+		'H', 'P', 'K', 'E',
+		byte(s.KEM >> 8), byte(s.KEM),
+		byte(s.KDF >> 8), byte(s.KDF),
+		byte(s.AEAD >> 8), byte(s.AEAD),
+	}
+}
+
+// keySchedule derives the encryption context from the shared secret
+// (RFC9180 section 5.1), without a pre-shared key
+func (s Suite) keySchedule(sharedSecret, info []byte) (*Context, error) {
+	h, err := s.KDF.hash()
+	if err != nil {
+		return nil, err
+	}
+	suiteID := s.suiteID()
+
+	pskIDHash := labeledExtract(h, suiteID, nil, "psk_id_hash", nil)
+	infoHash := labeledExtract(h, suiteID, nil, "info_hash", info)
+	ksContext := make([]byte, 0, 1+len(pskIDHash)+len(infoHash))
+	ksContext = append(ksContext, modeBase)
+	ksContext = append(ksContext, pskIDHash...)
+	ksContext = append(ksContext, infoHash...)
+
+	secret := labeledExtract(h, suiteID, sharedSecret, "secret", nil)
+	key, err := labeledExpand(h, suiteID, secret, "key", ksContext, s.AEAD.KeySize())
+	if err != nil {
+		return nil, err
+	}
+	baseNonce, err := labeledExpand(h, suiteID, secret, "base_nonce", ksContext, s.AEAD.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := s.AEAD.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Context{aead: aead, key: key, baseNonce: baseNonce}, nil
+}
+
+// SetupBaseS creates the encryption context of the sender for the
+// recipient's public key `pkR`, which must be an *ecdsa.PublicKey or
+// an x25519.PublicKey. It returns the encapsulated key, which must be
+// sent to the recipient.
+func (s Suite) SetupBaseS(pkR interface{}, info []byte) ([]byte, *Context, error) {
+	skE, err := s.KEM.generateKey()
+	if err != nil {
+		return nil, nil, fmt.Errorf(`hpke: failed to generate ephemeral key: %w`, err)
+	}
+	return s.setupBaseS(pkR, skE, info)
+}
+
+func (s Suite) setupBaseS(pkR, skE interface{}, info []byte) ([]byte, *Context, error) {
+	if s.AEAD.KeySize() == 0 {
+		return nil, nil, fmt.Errorf(`hpke: unsupported AEAD (0x%04x)`, uint16(s.AEAD))
+	}
+	sharedSecret, enc, err := s.KEM.encap(pkR, skE)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, err := s.keySchedule(sharedSecret, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, ctx, nil
+}
+
+// SetupBaseR creates the encryption context of the recipient from the
+// encapsulated key `enc`. `skR` must be an *ecdsa.PrivateKey, an
+// x25519.PrivateKey, or a private key that can compute the shared secret
+// by itself (see jwe.KeyAgreement).
+func (s Suite) SetupBaseR(enc []byte, skR interface{}, info []byte) (*Context, error) {
+	if s.AEAD.KeySize() == 0 {
+		return nil, fmt.Errorf(`hpke: unsupported AEAD (0x%04x)`, uint16(s.AEAD))
+	}
+	sharedSecret, err := s.KEM.decap(enc, skR)
+	if err != nil {
+		return nil, err
+	}
+	return s.keySchedule(sharedSecret, info)
+}
+
+// Key returns the AEAD key of the context
+func (c *Context) Key() []byte {
+	return c.key
+}
+
+// BaseNonce returns the nonce used for the first message
+func (c *Context) BaseNonce() []byte {
+	return c.baseNonce
+}
+
+func (c *Context) nextNonce() []byte {
+	nonce := make([]byte, len(c.baseNonce))
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], c.seq)
+	for i := range nonce {
+		nonce[i] ^= c.baseNonce[i]
+	}
+	c.seq++
+	return nonce
+}
+
+// Seal encrypts the next message
+func (c *Context) Seal(aad, plaintext []byte) []byte {
+	return c.aead.Seal(nil, c.nextNonce(), plaintext, aad)
+}
+
+// Open decrypts the next message
+func (c *Context) Open(aad, ciphertext []byte) ([]byte, error) {
+	plaintext, err := c.aead.Open(nil, c.nextNonce(), ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf(`hpke: failed to decrypt: %w`, err)
+	}
+	return plaintext, nil
+}
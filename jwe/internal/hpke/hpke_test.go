@@ -0,0 +1,125 @@
+package hpke
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/sjwl/jwx/v2/x25519"
+	"github.com/stretchr/testify/require"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err, `hex.DecodeString should succeed`)
+	return b
+}
+
+// RFC9180 Appendix A.1.1 (DHKEM(X25519, HKDF-SHA256), HKDF-SHA256, AES-128-GCM)
+func TestRFC9180Vector(t *testing.T) {
+	suite := Suite{KEM: KEM_X25519_HKDF_SHA256, KDF: KDF_HKDF_SHA256, AEAD: AEAD_AES_128_GCM}
+	info := mustHex(t, `4f6465206f6e2061204772656369616e2055726e`)
+
+	skE, err := x25519.NewKeyFromSeed(mustHex(t, `52c4a758a802cd8b936eceea314432798d5baf2d7e9235dc084ab1b9cfa2f736`))
+	require.NoError(t, err, `x25519.NewKeyFromSeed should succeed`)
+	skR, err := x25519.NewKeyFromSeed(mustHex(t, `4612c550263fc8ad58375df3f557aac531d26850903e55a9f23f21d8534e8ac8`))
+	require.NoError(t, err, `x25519.NewKeyFromSeed should succeed`)
+
+	enc, sctx, err := suite.setupBaseS(skR.Public(), skE, info)
+	require.NoError(t, err, `setupBaseS should succeed`)
+	require.Equal(t, mustHex(t, `37fda3567bdbd628e88668c3c8d7e97d1d1253b6d4ea6d44c150f741f1bf4431`), enc, `enc should match`)
+	require.Equal(t, mustHex(t, `4531685d41d65f03dc48f6b8302c05b0`), sctx.Key(), `key should match`)
+	require.Equal(t, mustHex(t, `56d890e5accaaf011cff4b7d`), sctx.BaseNonce(), `base_nonce should match`)
+
+	pt := mustHex(t, `4265617574792069732074727574682c20747275746820626561757479`)
+	aad := mustHex(t, `436f756e742d30`)
+	ct := sctx.Seal(aad, pt)
+	require.Equal(t, mustHex(t, `f938558b5d72f1a23810b4be2ab4f84331acc02fc97babc53a52ae8218a355a96d8770ac83d07bea87e13c512a`), ct, `ciphertext should match`)
+
+	rctx, err := suite.SetupBaseR(enc, skR, info)
+	require.NoError(t, err, `SetupBaseR should succeed`)
+	decrypted, err := rctx.Open(aad, ct)
+	require.NoError(t, err, `Open should succeed`)
+	require.Equal(t, pt, decrypted)
+}
+
+// agreement is a private key that does not expose the private key itself
+type agreement struct {
+	key *ecdsa.PrivateKey
+}
+
+func (a agreement) Public() crypto.PublicKey {
+	return &a.key.PublicKey
+}
+
+func (a agreement) SharedSecret(pubkey crypto.PublicKey) ([]byte, error) {
+	pub := pubkey.(*ecdsa.PublicKey)
+	x, _ := a.key.Curve.ScalarMult(pub.X, pub.Y, a.key.D.Bytes())
+	return padBigInt(x, (a.key.Curve.Params().BitSize+7)/8), nil
+}
+
+func TestRoundtrip(t *testing.T) {
+	type keyPair struct {
+		Public  interface{}
+		Private interface{}
+	}
+	keys := map[KEM][]keyPair{}
+	for kem, crv := range map[KEM]elliptic.Curve{
+		KEM_P256_HKDF_SHA256: elliptic.P256(),
+		KEM_P384_HKDF_SHA384: elliptic.P384(),
+		KEM_P521_HKDF_SHA512: elliptic.P521(),
+	} {
+		key, err := ecdsa.GenerateKey(crv, rand.Reader)
+		require.NoError(t, err, `ecdsa.GenerateKey should succeed`)
+		keys[kem] = []keyPair{
+			{Public: &key.PublicKey, Private: key},
+			{Public: &key.PublicKey, Private: agreement{key: key}},
+		}
+	}
+	pub, priv, err := x25519.GenerateKey(rand.Reader)
+	require.NoError(t, err, `x25519.GenerateKey should succeed`)
+	keys[KEM_X25519_HKDF_SHA256] = []keyPair{{Public: pub, Private: priv}}
+
+	for kem, pairs := range keys {
+		for _, kdf := range []KDF{KDF_HKDF_SHA256, KDF_HKDF_SHA384, KDF_HKDF_SHA512} {
+			for _, aead := range []AEAD{AEAD_AES_128_GCM, AEAD_AES_256_GCM, AEAD_ChaCha20Poly1305} {
+				suite := Suite{KEM: kem, KDF: kdf, AEAD: aead}
+				for _, pair := range pairs {
+					enc, sctx, err := suite.SetupBaseS(pair.Public, []byte(`info`))
+					require.NoError(t, err, `SetupBaseS should succeed (%#v)`, suite)
+					ct := sctx.Seal([]byte(`aad`), []byte(`Lorem ipsum`))
+
+					rctx, err := suite.SetupBaseR(enc, pair.Private, []byte(`info`))
+					require.NoError(t, err, `SetupBaseR should succeed (%#v)`, suite)
+					pt, err := rctx.Open([]byte(`aad`), ct)
+					require.NoError(t, err, `Open should succeed (%#v)`, suite)
+					require.Equal(t, []byte(`Lorem ipsum`), pt)
+
+					rctx, err = suite.SetupBaseR(enc, pair.Private, []byte(`other info`))
+					require.NoError(t, err, `SetupBaseR should succeed (%#v)`, suite)
+					_, err = rctx.Open([]byte(`aad`), ct)
+					require.Error(t, err, `Open should fail with different info (%#v)`, suite)
+				}
+			}
+		}
+	}
+
+	t.Run("invalid keys", func(t *testing.T) {
+		suite := Suite{KEM: KEM_P256_HKDF_SHA256, KDF: KDF_HKDF_SHA256, AEAD: AEAD_AES_128_GCM}
+		p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		require.NoError(t, err, `ecdsa.GenerateKey should succeed`)
+		_, _, err = suite.SetupBaseS(&p384.PublicKey, nil)
+		require.Error(t, err, `SetupBaseS should fail for keys on a different curve`)
+		_, _, err = suite.SetupBaseS(pub, nil)
+		require.Error(t, err, `SetupBaseS should fail for X25519 keys`)
+		_, err = suite.SetupBaseR([]byte{0x04, 0x01}, p384, nil)
+		require.Error(t, err, `SetupBaseR should fail for invalid encapsulated keys`)
+
+		_, _, err = Suite{KEM: KEM_X25519_HKDF_SHA256, KDF: KDF_HKDF_SHA256, AEAD: 0xffff}.SetupBaseS(pub, nil)
+		require.Error(t, err, `SetupBaseS should fail for unknown AEADs`)
+	})
+}
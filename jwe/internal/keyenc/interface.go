@@ -1,10 +1,13 @@
 package keyenc
 
 import (
+	"crypto"
 	"crypto/rsa"
 	"hash"
 
+	"github.com/sjwl/jwx/v2/internal/mlkem"
 	"github.com/sjwl/jwx/v2/jwa"
+	"github.com/sjwl/jwx/v2/jwe/internal/hpke"
 	"github.com/sjwl/jwx/v2/jwe/internal/keygen"
 )
 
@@ -26,6 +29,13 @@ type Decrypter interface {
 	Decrypt([]byte) ([]byte, error)
 }
 
+// KeyAgreement is an interface for private keys that can compute the
+// ECDH shared secret Z without exposing the private key itself
+type KeyAgreement interface {
+	Public() crypto.PublicKey
+	SharedSecret(pubkey crypto.PublicKey) ([]byte, error)
+}
+
 type Noop struct {
 	alg       jwa.KeyEncryptionAlgorithm
 	keyID     string
@@ -47,6 +57,13 @@ type AESGCMEncrypt struct {
 	sharedkey []byte
 }
 
+// XC20PKWEncrypt encrypts content encryption keys using XChaCha20-Poly1305 key wrap.
+type XC20PKWEncrypt struct {
+	algorithm jwa.KeyEncryptionAlgorithm
+	keyID     string
+	sharedkey []byte
+}
+
 // ECDHESEncrypt encrypts content encryption keys using ECDH-ES.
 type ECDHESEncrypt struct {
 	algorithm jwa.KeyEncryptionAlgorithm
@@ -60,8 +77,77 @@ type ECDHESDecrypt struct {
 	contentalg jwa.ContentEncryptionAlgorithm
 	apu        []byte
 	apv        []byte
+	keyiv      []byte
+	keytag     []byte
+	privkey    interface{}
+	pubkey     interface{}
+}
+
+// ECDH1PUEncrypt encrypts content encryption keys using ECDH-1PU.
+// In key wrapping mode, the key can only be wrapped once the
+// authentication tag of the content is known (see WrapKey)
+type ECDH1PUEncrypt struct {
+	algorithm jwa.KeyEncryptionAlgorithm
+	enc       jwa.ContentEncryptionAlgorithm
+	keyID     string
+	keysize   int
+	privkey   interface{}
+	pubkey    interface{}
+	apu       []byte
+	apv       []byte
+	z         []byte
+}
+
+// ECDH1PUDecrypt decrypts keys using ECDH-1PU.
+type ECDH1PUDecrypt struct {
+	keyalg     jwa.KeyEncryptionAlgorithm
+	contentalg jwa.ContentEncryptionAlgorithm
+	apu        []byte
+	apv        []byte
+	tag        []byte
 	privkey    interface{}
 	pubkey     interface{}
+	senderkey  interface{}
+}
+
+// HPKEEncrypt encrypts content encryption keys using HPKE. In
+// integrated encryption mode, it derives the content encryption
+// key instead (see HPKEIntegratedKey)
+type HPKEEncrypt struct {
+	algorithm jwa.KeyEncryptionAlgorithm
+	keyID     string
+	suite     hpke.Suite
+	pubkey    interface{}
+}
+
+// HPKEDecrypt decrypts keys using HPKE.
+type HPKEDecrypt struct {
+	algorithm jwa.KeyEncryptionAlgorithm
+	suite     hpke.Suite
+	ek        []byte
+	privkey   interface{}
+}
+
+// MLKEMEncrypt encrypts content encryption keys using ML-KEM. In direct
+// key agreement mode, it derives the content encryption key instead
+type MLKEMEncrypt struct {
+	algorithm jwa.KeyEncryptionAlgorithm
+	enc       jwa.ContentEncryptionAlgorithm
+	keyID     string
+	keysize   int
+	pubkey    *mlkem.EncapsulationKey
+	apu       []byte
+	apv       []byte
+}
+
+// MLKEMDecrypt decrypts keys using ML-KEM.
+type MLKEMDecrypt struct {
+	keyalg     jwa.KeyEncryptionAlgorithm
+	contentalg jwa.ContentEncryptionAlgorithm
+	ek         []byte
+	apu        []byte
+	apv        []byte
+	privkey    *mlkem.DecapsulationKey
 }
 
 // RSAOAEPEncrypt encrypts keys using RSA OAEP algorithm
@@ -74,7 +160,7 @@ type RSAOAEPEncrypt struct {
 // RSAOAEPDecrypt decrypts keys using RSA OAEP algorithm
 type RSAOAEPDecrypt struct {
 	alg     jwa.KeyEncryptionAlgorithm
-	privkey *rsa.PrivateKey
+	privkey crypto.Decrypter
 }
 
 // RSAPKCS15Decrypt decrypts keys using RSA PKCS1v15 algorithm
@@ -7,24 +7,28 @@ import (
 	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/sha1"
-	"crypto/sha256"
-	"crypto/sha512"
+	_ "crypto/sha1"   // for crypto.SHA1.New()
+	_ "crypto/sha256" // for crypto.SHA256.New()
+	_ "crypto/sha512" // for crypto.SHA384.New() and crypto.SHA512.New()
 	"crypto/subtle"
 	"encoding/binary"
 	"fmt"
-	"hash"
 	"io"
 
+	"golang.org/x/crypto/chacha20poly1305"
 	"golang.org/x/crypto/curve25519"
 	"golang.org/x/crypto/pbkdf2"
 
+	"github.com/sjwl/jwx/v2/internal/curve448"
 	"github.com/sjwl/jwx/v2/internal/ecutil"
+	"github.com/sjwl/jwx/v2/internal/mlkem"
 	"github.com/sjwl/jwx/v2/jwa"
 	contentcipher "github.com/sjwl/jwx/v2/jwe/internal/cipher"
 	"github.com/sjwl/jwx/v2/jwe/internal/concatkdf"
+	"github.com/sjwl/jwx/v2/jwe/internal/hpke"
 	"github.com/sjwl/jwx/v2/jwe/internal/keygen"
 	"github.com/sjwl/jwx/v2/x25519"
+	"github.com/sjwl/jwx/v2/x448"
 )
 
 func NewNoop(alg jwa.KeyEncryptionAlgorithm, sharedkey []byte) (*Noop, error) {
@@ -145,26 +149,126 @@ func (kw AESGCMEncrypt) Encrypt(cek []byte) (keygen.ByteSource, error) {
 	}, nil
 }
 
+func NewXC20PKWEncrypt(alg jwa.KeyEncryptionAlgorithm, sharedkey []byte) (*XC20PKWEncrypt, error) {
+	return &XC20PKWEncrypt{
+		algorithm: alg,
+		sharedkey: sharedkey,
+	}, nil
+}
+
+func (kw XC20PKWEncrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
+	return kw.algorithm
+}
+
+func (kw *XC20PKWEncrypt) SetKeyID(v string) {
+	kw.keyID = v
+}
+
+func (kw XC20PKWEncrypt) KeyID() string {
+	return kw.keyID
+}
+
+func (kw XC20PKWEncrypt) Encrypt(cek []byte) (keygen.ByteSource, error) {
+	return SealXC20PKW(kw.sharedkey, cek)
+}
+
+// SealXC20PKW wraps the content encryption key using XChaCha20-Poly1305,
+// as used by the XC20PKW and ECDH-ES+XC20PKW algorithms
+func SealXC20PKW(sharedkey, cek []byte) (keygen.ByteWithIVAndTag, error) {
+	aead, err := chacha20poly1305.NewX(sharedkey)
+	if err != nil {
+		return keygen.ByteWithIVAndTag{}, fmt.Errorf(`failed to create XChaCha20-Poly1305 cipher from shared key: %w`, err)
+	}
+
+	iv := make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, iv)
+	if err != nil {
+		return keygen.ByteWithIVAndTag{}, fmt.Errorf(`failed to get random iv: %w`, err)
+	}
+
+	encrypted := aead.Seal(nil, iv, cek, nil)
+	tag := encrypted[len(encrypted)-aead.Overhead():]
+	ciphertext := encrypted[:len(encrypted)-aead.Overhead()]
+	return keygen.ByteWithIVAndTag{
+		ByteKey: ciphertext,
+		IV:      iv,
+		Tag:     tag,
+	}, nil
+}
+
+// OpenXC20PKW unwraps a content encryption key that was wrapped using
+// XChaCha20-Poly1305. `iv` and `tag` are taken from the "iv" and "tag"
+// headers of the recipient
+func OpenXC20PKW(sharedkey, iv, tag, enckey []byte) ([]byte, error) {
+	if len(iv) != chacha20poly1305.NonceSizeX {
+		return nil, fmt.Errorf("XChaCha20-Poly1305 requires 192-bit iv, got %d", len(iv)*8)
+	}
+	if len(tag) != chacha20poly1305.Overhead {
+		return nil, fmt.Errorf("XChaCha20-Poly1305 requires 128-bit tag, got %d", len(tag)*8)
+	}
+	aead, err := chacha20poly1305.NewX(sharedkey)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to create XChaCha20-Poly1305 cipher from shared key: %w`, err)
+	}
+
+	ciphertext := make([]byte, 0, len(enckey)+len(tag))
+	ciphertext = append(ciphertext, enckey...)
+	ciphertext = append(ciphertext, tag...)
+	cek, err := aead.Open(nil, iv, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to decode key: %w`, err)
+	}
+	return cek, nil
+}
+
+// algorithmHashes holds the hash functions of the built-in algorithms
+// that use one, as given by their metadata. It is populated once, so
+// that metadata registered later on can't change how they work
+var algorithmHashes = map[jwa.KeyEncryptionAlgorithm]crypto.Hash{}
+
+func init() {
+	for _, alg := range []jwa.KeyEncryptionAlgorithm{
+		jwa.PBES2_HS256_A128KW, jwa.PBES2_HS384_A192KW, jwa.PBES2_HS512_A256KW,
+		jwa.RSA_OAEP, jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512,
+	} {
+		md, ok := alg.Metadata()
+		if !ok || md.Hash == 0 {
+			panic(fmt.Sprintf(`keyenc: missing hash function in the metadata of %s`, alg))
+		}
+		algorithmHashes[alg] = md.Hash
+	}
+}
+
+// HashForAlgorithm returns the hash function used by `alg`, as given
+// by its metadata
+func HashForAlgorithm(alg jwa.KeyEncryptionAlgorithm) (crypto.Hash, error) {
+	hash, ok := algorithmHashes[alg]
+	if !ok {
+		return 0, fmt.Errorf(`no hash function is known for %s`, alg)
+	}
+	return hash, nil
+}
+
 func NewPBES2Encrypt(alg jwa.KeyEncryptionAlgorithm, password []byte) (*PBES2Encrypt, error) {
-	var hashFunc func() hash.Hash
 	var keylen int
 	switch alg {
 	case jwa.PBES2_HS256_A128KW:
-		hashFunc = sha256.New
 		keylen = 16
 	case jwa.PBES2_HS384_A192KW:
-		hashFunc = sha512.New384
 		keylen = 24
 	case jwa.PBES2_HS512_A256KW:
-		hashFunc = sha512.New
 		keylen = 32
 	default:
 		return nil, fmt.Errorf("unexpected key encryption algorithm %s", alg)
 	}
+	hash, err := HashForAlgorithm(alg)
+	if err != nil {
+		return nil, err
+	}
 	return &PBES2Encrypt{
 		algorithm: alg,
 		password:  password,
-		hashFunc:  hashFunc,
+		hashFunc:  hash.New,
 		keylen:    keylen,
 	}, nil
 }
@@ -218,6 +322,8 @@ func NewECDHESEncrypt(alg jwa.KeyEncryptionAlgorithm, enc jwa.ContentEncryptionA
 		generator, err = keygen.NewEcdhes(alg, enc, keysize, key, apu, apv)
 	case x25519.PublicKey:
 		generator, err = keygen.NewX25519(alg, enc, keysize, key)
+	case x448.PublicKey:
+		generator, err = keygen.NewX448(alg, enc, keysize, key)
 	default:
 		return nil, fmt.Errorf("unexpected key type %T", keyif)
 	}
@@ -256,8 +362,22 @@ func (kw ECDHESEncrypt) Encrypt(cek []byte) (keygen.ByteSource, error) {
 		return nil, fmt.Errorf(`key generator generated invalid key (expected ByteWithECPrivateKey)`)
 	}
 
-	if kw.algorithm == jwa.ECDH_ES {
+	switch kw.algorithm {
+	case jwa.ECDH_ES:
 		return bwpk, nil
+	case jwa.ECDH_ES_XC20PKW:
+		wrapped, err := SealXC20PKW(bwpk.Bytes(), cek)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to wrap data: %w`, err)
+		}
+		return keygen.ByteWithECPublicKeyAndIVAndTag{
+			ByteWithECPublicKey: keygen.ByteWithECPublicKey{
+				ByteKey:   wrapped.ByteKey,
+				PublicKey: bwpk.PublicKey,
+			},
+			IV:  wrapped.IV,
+			Tag: wrapped.Tag,
+		}, nil
 	}
 
 	block, err := aes.NewCipher(bwpk.Bytes())
@@ -287,6 +407,13 @@ func NewECDHESDecrypt(keyalg jwa.KeyEncryptionAlgorithm, contentalg jwa.ContentE
 	}
 }
 
+// SetKeyIVAndTag sets the values of the "iv" and "tag" headers, which
+// are required to unwrap keys using ECDH-ES+XC20PKW
+func (kw *ECDHESDecrypt) SetKeyIVAndTag(iv, tag []byte) {
+	kw.keyiv = iv
+	kw.keytag = tag
+}
+
 // Algorithm returns the key encryption algorithm being used
 func (kw ECDHESDecrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
 	return kw.keyalg
@@ -294,6 +421,17 @@ func (kw ECDHESDecrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
 
 func DeriveZ(privkeyif interface{}, pubkeyif interface{}) ([]byte, error) {
 	switch privkeyif.(type) {
+	case KeyAgreement:
+		//nolint:forcetypeassert
+		agreement := privkeyif.(KeyAgreement)
+		if err := checkAgreementPublicKey(agreement.Public(), pubkeyif); err != nil {
+			return nil, err
+		}
+		z, err := agreement.SharedSecret(pubkeyif)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to compute shared secret: %w`, err)
+		}
+		return z, nil
 	case x25519.PrivateKey:
 		privkey, ok := privkeyif.(x25519.PrivateKey)
 		if !ok {
@@ -304,6 +442,16 @@ func DeriveZ(privkeyif interface{}, pubkeyif interface{}) ([]byte, error) {
 			return nil, fmt.Errorf(`public key must be x25519.PublicKey, was: %T`, pubkeyif)
 		}
 		return curve25519.X25519(privkey.Seed(), pubkey)
+	case x448.PrivateKey:
+		privkey, ok := privkeyif.(x448.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf(`private key must be x448.PrivateKey, was: %T`, privkeyif)
+		}
+		pubkey, ok := pubkeyif.(x448.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf(`public key must be x448.PublicKey, was: %T`, pubkeyif)
+		}
+		return curve448.X448(privkey.Seed(), pubkey)
 	default:
 		privkey, ok := privkeyif.(*ecdsa.PrivateKey)
 		if !ok {
@@ -326,6 +474,32 @@ func DeriveZ(privkeyif interface{}, pubkeyif interface{}) ([]byte, error) {
 	}
 }
 
+// checkAgreementPublicKey makes sure that the public key sent by the
+// other party can be used with the private key behind a KeyAgreement
+func checkAgreementPublicKey(ours crypto.PublicKey, theirs interface{}) error {
+	switch ours := ours.(type) {
+	case *ecdsa.PublicKey:
+		pubkey, ok := theirs.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf(`public key must be *ecdsa.PublicKey, was: %T`, theirs)
+		}
+		if !ours.Curve.IsOnCurve(pubkey.X, pubkey.Y) {
+			return fmt.Errorf(`public key must be on the same curve as private key`)
+		}
+	case x25519.PublicKey:
+		if _, ok := theirs.(x25519.PublicKey); !ok {
+			return fmt.Errorf(`public key must be x25519.PublicKey, was: %T`, theirs)
+		}
+	case x448.PublicKey:
+		if _, ok := theirs.(x448.PublicKey); !ok {
+			return fmt.Errorf(`public key must be x448.PublicKey, was: %T`, theirs)
+		}
+	default:
+		return fmt.Errorf(`unsupported key agreement public key type %T`, ours)
+	}
+	return nil
+}
+
 func DeriveECDHES(alg, apu, apv []byte, privkey interface{}, pubkey interface{}, keysize uint32) ([]byte, error) {
 	pubinfo := make([]byte, 4)
 	binary.BigEndian.PutUint32(pubinfo, keysize*8)
@@ -353,7 +527,7 @@ func (kw ECDHESDecrypt) Decrypt(enckey []byte) ([]byte, error) {
 	switch kw.keyalg {
 	case jwa.ECDH_ES:
 		// Create a content cipher from the content encryption algorithm
-		c, err := contentcipher.NewAES(kw.contentalg)
+		c, err := contentcipher.New(kw.contentalg)
 		if err != nil {
 			return nil, fmt.Errorf(`failed to create content cipher for %s: %w`, kw.contentalg, err)
 		}
@@ -363,7 +537,7 @@ func (kw ECDHESDecrypt) Decrypt(enckey []byte) ([]byte, error) {
 		keysize = 16
 	case jwa.ECDH_ES_A192KW:
 		keysize = 24
-	case jwa.ECDH_ES_A256KW:
+	case jwa.ECDH_ES_A256KW, jwa.ECDH_ES_XC20PKW:
 		keysize = 32
 	default:
 		return nil, fmt.Errorf("invalid ECDH-ES key wrap algorithm (%s)", kw.keyalg)
@@ -374,9 +548,12 @@ func (kw ECDHESDecrypt) Decrypt(enckey []byte) ([]byte, error) {
 		return nil, fmt.Errorf(`failed to derive ECDHES encryption key: %w`, err)
 	}
 
-	// ECDH-ES does not wrap keys
-	if kw.keyalg == jwa.ECDH_ES {
+	switch kw.keyalg {
+	case jwa.ECDH_ES:
+		// ECDH-ES does not wrap keys
 		return key, nil
+	case jwa.ECDH_ES_XC20PKW:
+		return OpenXC20PKW(key, kw.keyiv, kw.keytag, enckey)
 	}
 
 	block, err := aes.NewCipher(key)
@@ -387,10 +564,584 @@ func (kw ECDHESDecrypt) Decrypt(enckey []byte) ([]byte, error) {
 	return Unwrap(block, enckey)
 }
 
+// IsECDH1PUKeyWrap returns true if the algorithm is one of the
+// ECDH-1PU key wrapping algorithms, which require the authentication
+// tag of the content to derive the key encryption key
+func IsECDH1PUKeyWrap(alg jwa.KeyEncryptionAlgorithm) bool {
+	switch alg {
+	case jwa.ECDH_1PU_A128KW, jwa.ECDH_1PU_A192KW, jwa.ECDH_1PU_A256KW:
+		return true
+	default:
+		return false
+	}
+}
+
+// CheckECDH1PUContentEncryption makes sure that the content encryption
+// algorithm can be used with `alg`. The key wrapping modes of ECDH-1PU
+// may only be used with AES-CBC-HMAC-SHA2 (draft-madden-jose-ecdh-1pu-04,
+// section 2.1)
+func CheckECDH1PUContentEncryption(alg jwa.KeyEncryptionAlgorithm, enc jwa.ContentEncryptionAlgorithm) error {
+	if !IsECDH1PUKeyWrap(alg) {
+		return nil
+	}
+	switch enc {
+	case jwa.A128CBC_HS256, jwa.A192CBC_HS384, jwa.A256CBC_HS512:
+		return nil
+	default:
+		return fmt.Errorf(`%s can only be used with AES-CBC-HMAC-SHA2 content encryption (got %s)`, alg, enc)
+	}
+}
+
+// NewECDH1PUEncrypt creates a new key encrypter based on ECDH-1PU.
+// `privkey` is the static private key of the sender, and `pubkey` is
+// the public key of the recipient
+func NewECDH1PUEncrypt(alg jwa.KeyEncryptionAlgorithm, enc jwa.ContentEncryptionAlgorithm, keysize int, privkey interface{}, pubkey interface{}, apu, apv []byte) (*ECDH1PUEncrypt, error) {
+	switch alg {
+	case jwa.ECDH_1PU, jwa.ECDH_1PU_A128KW, jwa.ECDH_1PU_A192KW, jwa.ECDH_1PU_A256KW:
+	default:
+		return nil, fmt.Errorf("invalid ECDH-1PU key wrap algorithm (%s)", alg)
+	}
+	if err := CheckECDH1PUContentEncryption(alg, enc); err != nil {
+		return nil, err
+	}
+	switch pubkey.(type) {
+	case *ecdsa.PublicKey, x25519.PublicKey, x448.PublicKey:
+	default:
+		return nil, fmt.Errorf("unexpected key type %T", pubkey)
+	}
+	return &ECDH1PUEncrypt{
+		algorithm: alg,
+		enc:       enc,
+		keysize:   keysize,
+		privkey:   privkey,
+		pubkey:    pubkey,
+		apu:       apu,
+		apv:       apv,
+	}, nil
+}
+
+// Algorithm returns the key encryption algorithm being used
+func (kw ECDH1PUEncrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
+	return kw.algorithm
+}
+
+func (kw *ECDH1PUEncrypt) SetKeyID(v string) {
+	kw.keyID = v
+}
+
+// KeyID returns the key ID associated with this encrypter
+func (kw ECDH1PUEncrypt) KeyID() string {
+	return kw.keyID
+}
+
+// Encrypt generates the ephemeral key pair, and computes the shared
+// secret Z. In direct key agreement mode the result contains the
+// content encryption key. In key wrapping mode the result only contains
+// the ephemeral public key, and WrapKey must be called to obtain the
+// encrypted key once the content has been encrypted.
+func (kw *ECDH1PUEncrypt) Encrypt(_ []byte) (keygen.ByteSource, error) {
+	var ephemeral interface{}
+	var epk interface{}
+	switch pubkey := kw.pubkey.(type) {
+	case *ecdsa.PublicKey:
+		priv, err := ecdsa.GenerateKey(pubkey.Curve, rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to generate key for ECDH-1PU: %w`, err)
+		}
+		ephemeral = priv
+		epk = &priv.PublicKey
+	case x25519.PublicKey:
+		pub, priv, err := x25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to generate key for ECDH-1PU: %w`, err)
+		}
+		ephemeral = priv
+		epk = pub
+	case x448.PublicKey:
+		pub, priv, err := x448.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to generate key for ECDH-1PU: %w`, err)
+		}
+		ephemeral = priv
+		epk = pub
+	}
+
+	ze, err := DeriveZ(ephemeral, kw.pubkey)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to determine Ze: %w`, err)
+	}
+	zs, err := DeriveZ(kw.privkey, kw.pubkey)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to determine Zs: %w`, err)
+	}
+	kw.z = append(ze, zs...)
+
+	if IsECDH1PUKeyWrap(kw.algorithm) {
+		return keygen.ByteWithECPublicKey{PublicKey: epk}, nil
+	}
+
+	key, err := deriveECDH1PU([]byte(kw.enc.String()), kw.z, kw.apu, kw.apv, uint32(kw.keysize), nil)
+	if err != nil {
+		return nil, err
+	}
+	return keygen.ByteWithECPublicKey{
+		PublicKey: epk,
+		ByteKey:   keygen.ByteKey(key),
+	}, nil
+}
+
+// WrapKey wraps the content encryption key using the key encryption key
+// derived from Z and the authentication tag of the content. It must be
+// called after Encrypt
+func (kw *ECDH1PUEncrypt) WrapKey(cek, tag []byte) ([]byte, error) {
+	if kw.z == nil {
+		return nil, fmt.Errorf(`shared secret has not been computed`)
+	}
+	kek, err := deriveECDH1PU([]byte(kw.algorithm.String()), kw.z, kw.apu, kw.apv, uint32(kw.keysize), tag)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to generate cipher from derived key: %w`, err)
+	}
+	jek, err := Wrap(block, cek)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to wrap data: %w`, err)
+	}
+	return jek, nil
+}
+
+// DeriveECDH1PU derives a key using ECDH-1PU on the recipient's side.
+// `privkey` is the private key of the recipient, `epk` is the ephemeral
+// public key, and `senderkey` is the static public key of the sender.
+// `tag` must be nil in direct key agreement mode
+func DeriveECDH1PU(alg, apu, apv []byte, privkey, epk, senderkey interface{}, keysize uint32, tag []byte) ([]byte, error) {
+	ze, err := DeriveZ(privkey, epk)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to determine Ze: %w`, err)
+	}
+	zs, err := DeriveZ(privkey, senderkey)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to determine Zs: %w`, err)
+	}
+	return deriveECDH1PU(alg, append(ze, zs...), apu, apv, keysize, tag)
+}
+
+// deriveECDH1PU runs the Concat KDF over Z = Ze || Zs. In key wrapping
+// mode, the tag is appended to SuppPubInfo with its length prefixed
+func deriveECDH1PU(alg, z, apu, apv []byte, keysize uint32, tag []byte) ([]byte, error) {
+	pubinfo := make([]byte, 4, 8+len(tag))
+	binary.BigEndian.PutUint32(pubinfo, keysize*8)
+	if tag != nil {
+		var taglen [4]byte
+		binary.BigEndian.PutUint32(taglen[:], uint32(len(tag)))
+		pubinfo = append(append(pubinfo, taglen[:]...), tag...)
+	}
+
+	kdf := concatkdf.New(crypto.SHA256, alg, z, apu, apv, pubinfo, []byte{})
+	key := make([]byte, keysize)
+	if _, err := kdf.Read(key); err != nil {
+		return nil, fmt.Errorf(`failed to read kdf: %w`, err)
+	}
+	return key, nil
+}
+
+// NewECDH1PUDecrypt creates a new key decrypter using ECDH-1PU.
+// `pubkey` is the ephemeral public key, and `senderkey` is the
+// static public key of the sender. `tag` is the authentication
+// tag of the content, which is only used in key wrapping mode
+func NewECDH1PUDecrypt(keyalg jwa.KeyEncryptionAlgorithm, contentalg jwa.ContentEncryptionAlgorithm, pubkey, senderkey interface{}, apu, apv, tag []byte, privkey interface{}) *ECDH1PUDecrypt {
+	return &ECDH1PUDecrypt{
+		keyalg:     keyalg,
+		contentalg: contentalg,
+		apu:        apu,
+		apv:        apv,
+		tag:        tag,
+		privkey:    privkey,
+		pubkey:     pubkey,
+		senderkey:  senderkey,
+	}
+}
+
+// Algorithm returns the key encryption algorithm being used
+func (kw ECDH1PUDecrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
+	return kw.keyalg
+}
+
+// Decrypt decrypts the encrypted key using ECDH-1PU
+func (kw ECDH1PUDecrypt) Decrypt(enckey []byte) ([]byte, error) {
+	if err := CheckECDH1PUContentEncryption(kw.keyalg, kw.contentalg); err != nil {
+		return nil, err
+	}
+
+	var keysize uint32
+	algBytes := []byte(kw.keyalg.String())
+	var tag []byte
+	switch kw.keyalg {
+	case jwa.ECDH_1PU:
+		c, err := contentcipher.New(kw.contentalg)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to create content cipher for %s: %w`, kw.contentalg, err)
+		}
+		keysize = uint32(c.KeySize())
+		algBytes = []byte(kw.contentalg.String())
+	case jwa.ECDH_1PU_A128KW:
+		keysize = 16
+	case jwa.ECDH_1PU_A192KW:
+		keysize = 24
+	case jwa.ECDH_1PU_A256KW:
+		keysize = 32
+	default:
+		return nil, fmt.Errorf("invalid ECDH-1PU key wrap algorithm (%s)", kw.keyalg)
+	}
+	if IsECDH1PUKeyWrap(kw.keyalg) {
+		if len(kw.tag) == 0 {
+			return nil, fmt.Errorf(`%s requires the authentication tag of the content`, kw.keyalg)
+		}
+		tag = kw.tag
+	}
+
+	key, err := DeriveECDH1PU(algBytes, kw.apu, kw.apv, kw.privkey, kw.pubkey, kw.senderkey, keysize, tag)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to derive ECDH-1PU encryption key: %w`, err)
+	}
+
+	// ECDH-1PU in direct key agreement mode does not wrap keys
+	if kw.keyalg == jwa.ECDH_1PU {
+		return key, nil
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to create cipher for ECDH-1PU key wrap: %w`, err)
+	}
+
+	return Unwrap(block, enckey)
+}
+
+// HPKESuite returns the HPKE cipher suite used by the algorithm
+func HPKESuite(alg jwa.KeyEncryptionAlgorithm) (hpke.Suite, bool) {
+	switch alg {
+	case jwa.HPKE_0, jwa.HPKE_0_KE:
+		return hpke.Suite{KEM: hpke.KEM_P256_HKDF_SHA256, KDF: hpke.KDF_HKDF_SHA256, AEAD: hpke.AEAD_AES_128_GCM}, true
+	case jwa.HPKE_1, jwa.HPKE_1_KE:
+		return hpke.Suite{KEM: hpke.KEM_P384_HKDF_SHA384, KDF: hpke.KDF_HKDF_SHA384, AEAD: hpke.AEAD_AES_256_GCM}, true
+	case jwa.HPKE_2, jwa.HPKE_2_KE:
+		return hpke.Suite{KEM: hpke.KEM_P521_HKDF_SHA512, KDF: hpke.KDF_HKDF_SHA512, AEAD: hpke.AEAD_AES_256_GCM}, true
+	case jwa.HPKE_3, jwa.HPKE_3_KE:
+		return hpke.Suite{KEM: hpke.KEM_X25519_HKDF_SHA256, KDF: hpke.KDF_HKDF_SHA256, AEAD: hpke.AEAD_AES_128_GCM}, true
+	case jwa.HPKE_4, jwa.HPKE_4_KE:
+		return hpke.Suite{KEM: hpke.KEM_X25519_HKDF_SHA256, KDF: hpke.KDF_HKDF_SHA256, AEAD: hpke.AEAD_ChaCha20Poly1305}, true
+	default:
+		return hpke.Suite{}, false
+	}
+}
+
+// IsHPKEIntegrated returns true if the algorithm is one of the HPKE
+// integrated encryption algorithms, where the content is encrypted
+// by HPKE itself
+func IsHPKEIntegrated(alg jwa.KeyEncryptionAlgorithm) bool {
+	switch alg {
+	case jwa.HPKE_0, jwa.HPKE_1, jwa.HPKE_2, jwa.HPKE_3, jwa.HPKE_4:
+		return true
+	default:
+		return false
+	}
+}
+
+// HPKEIntegratedKey is the result of HPKEEncrypt.Encrypt in integrated
+// encryption mode. Bytes() returns the AEAD key followed by the nonce,
+// which are used to encrypt the content, and Enc holds the encapsulated
+// key, which is sent as the JWE Encrypted Key
+type HPKEIntegratedKey struct {
+	keygen.ByteKey
+	Enc []byte
+}
+
+// NewHPKEEncrypt creates a new key encrypter based on HPKE. `pubkey`
+// must be an *ecdsa.PublicKey or an x25519.PublicKey
+func NewHPKEEncrypt(alg jwa.KeyEncryptionAlgorithm, pubkey interface{}) (*HPKEEncrypt, error) {
+	suite, ok := HPKESuite(alg)
+	if !ok {
+		return nil, fmt.Errorf(`invalid HPKE algorithm (%s)`, alg)
+	}
+	return &HPKEEncrypt{
+		algorithm: alg,
+		suite:     suite,
+		pubkey:    pubkey,
+	}, nil
+}
+
+// Algorithm returns the key encryption algorithm being used
+func (kw HPKEEncrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
+	return kw.algorithm
+}
+
+func (kw *HPKEEncrypt) SetKeyID(v string) {
+	kw.keyID = v
+}
+
+// KeyID returns the key ID associated with this encrypter
+func (kw HPKEEncrypt) KeyID() string {
+	return kw.keyID
+}
+
+// Encrypt encrypts the content encryption key using HPKE. In integrated
+// encryption mode, `cek` is ignored, and a HPKEIntegratedKey is returned
+func (kw HPKEEncrypt) Encrypt(cek []byte) (keygen.ByteSource, error) {
+	enc, ctx, err := kw.suite.SetupBaseS(kw.pubkey, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to set up HPKE context: %w`, err)
+	}
+
+	if IsHPKEIntegrated(kw.algorithm) {
+		key := make([]byte, 0, len(ctx.Key())+len(ctx.BaseNonce()))
+		key = append(key, ctx.Key()...)
+		key = append(key, ctx.BaseNonce()...)
+		return HPKEIntegratedKey{
+			ByteKey: keygen.ByteKey(key),
+			Enc:     enc,
+		}, nil
+	}
+
+	return keygen.ByteWithEncapsulatedKey{
+		ByteKey:         keygen.ByteKey(ctx.Seal(nil, cek)),
+		EncapsulatedKey: enc,
+	}, nil
+}
+
+// NewHPKEDecrypt creates a new key decrypter based on HPKE. `ek` is
+// the value of the "ek" header, which is only used in key encryption mode
+func NewHPKEDecrypt(alg jwa.KeyEncryptionAlgorithm, ek []byte, privkey interface{}) (*HPKEDecrypt, error) {
+	suite, ok := HPKESuite(alg)
+	if !ok {
+		return nil, fmt.Errorf(`invalid HPKE algorithm (%s)`, alg)
+	}
+	return &HPKEDecrypt{
+		algorithm: alg,
+		suite:     suite,
+		ek:        ek,
+		privkey:   privkey,
+	}, nil
+}
+
+// Algorithm returns the key encryption algorithm being used
+func (kw HPKEDecrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
+	return kw.algorithm
+}
+
+// Decrypt decrypts the encrypted key using HPKE. In integrated
+// encryption mode, `enckey` is the encapsulated key, and the AEAD key
+// followed by the nonce is returned
+func (kw HPKEDecrypt) Decrypt(enckey []byte) ([]byte, error) {
+	if IsHPKEIntegrated(kw.algorithm) {
+		ctx, err := kw.suite.SetupBaseR(enckey, kw.privkey, nil)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to set up HPKE context: %w`, err)
+		}
+		key := make([]byte, 0, len(ctx.Key())+len(ctx.BaseNonce()))
+		key = append(key, ctx.Key()...)
+		key = append(key, ctx.BaseNonce()...)
+		return key, nil
+	}
+
+	if len(kw.ek) == 0 {
+		return nil, fmt.Errorf(`missing encapsulated key ("ek" header)`)
+	}
+	ctx, err := kw.suite.SetupBaseR(kw.ek, kw.privkey, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to set up HPKE context: %w`, err)
+	}
+	return ctx.Open(nil, enckey)
+}
+
+// MLKEMParameters returns the ML-KEM parameter set used by the algorithm
+func MLKEMParameters(alg jwa.KeyEncryptionAlgorithm) (*mlkem.Parameters, bool) {
+	switch alg {
+	case jwa.MLKEM768, jwa.MLKEM768_A192KW:
+		return mlkem.MLKEM768(), true
+	case jwa.MLKEM1024, jwa.MLKEM1024_A256KW:
+		return mlkem.MLKEM1024(), true
+	default:
+		return nil, false
+	}
+}
+
+// mlkemKeySize returns the size of the key derived from the ML-KEM
+// shared key, and the algorithm ID used to derive it. In direct key
+// agreement mode, the key is the content encryption key
+func mlkemKeySize(alg jwa.KeyEncryptionAlgorithm, enc jwa.ContentEncryptionAlgorithm) (uint32, []byte, error) {
+	switch alg {
+	case jwa.MLKEM768, jwa.MLKEM1024:
+		c, err := contentcipher.New(enc)
+		if err != nil {
+			return 0, nil, fmt.Errorf(`failed to create content cipher for %s: %w`, enc, err)
+		}
+		return uint32(c.KeySize()), []byte(enc.String()), nil
+	case jwa.MLKEM768_A192KW:
+		return 24, []byte(alg.String()), nil
+	case jwa.MLKEM1024_A256KW:
+		return 32, []byte(alg.String()), nil
+	default:
+		return 0, nil, fmt.Errorf(`invalid ML-KEM algorithm (%s)`, alg)
+	}
+}
+
+// deriveMLKEM derives the key from the ML-KEM shared key, using the
+// same Concat KDF as ECDH-ES
+func deriveMLKEM(alg, sharedKey, apu, apv []byte, keysize uint32) ([]byte, error) {
+	pubinfo := make([]byte, 4)
+	binary.BigEndian.PutUint32(pubinfo, keysize*8)
+	kdf := concatkdf.New(crypto.SHA256, alg, sharedKey, apu, apv, pubinfo, []byte{})
+	key := make([]byte, keysize)
+	if _, err := kdf.Read(key); err != nil {
+		return nil, fmt.Errorf(`failed to read kdf: %w`, err)
+	}
+	return key, nil
+}
+
+// NewMLKEMEncrypt creates a new key encrypter based on ML-KEM
+func NewMLKEMEncrypt(alg jwa.KeyEncryptionAlgorithm, enc jwa.ContentEncryptionAlgorithm, pubkey *mlkem.EncapsulationKey, apu, apv []byte) (*MLKEMEncrypt, error) {
+	params, ok := MLKEMParameters(alg)
+	if !ok {
+		return nil, fmt.Errorf(`invalid ML-KEM algorithm (%s)`, alg)
+	}
+	if pubkey == nil || pubkey.Parameters() != params {
+		return nil, fmt.Errorf(`%s requires a %s encapsulation key`, alg, params)
+	}
+	keysize, _, err := mlkemKeySize(alg, enc)
+	if err != nil {
+		return nil, err
+	}
+	return &MLKEMEncrypt{
+		algorithm: alg,
+		enc:       enc,
+		keysize:   int(keysize),
+		pubkey:    pubkey,
+		apu:       apu,
+		apv:       apv,
+	}, nil
+}
+
+// Algorithm returns the key encryption algorithm being used
+func (kw MLKEMEncrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
+	return kw.algorithm
+}
+
+func (kw *MLKEMEncrypt) SetKeyID(v string) {
+	kw.keyID = v
+}
+
+// KeyID returns the key ID associated with this encrypter
+func (kw MLKEMEncrypt) KeyID() string {
+	return kw.keyID
+}
+
+// Encrypt encrypts the content encryption key using ML-KEM. In direct
+// key agreement mode, `cek` is ignored, and the derived content
+// encryption key is returned instead
+func (kw MLKEMEncrypt) Encrypt(cek []byte) (keygen.ByteSource, error) {
+	sharedKey, ciphertext, err := kw.pubkey.Encapsulate(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to encapsulate key: %w`, err)
+	}
+
+	_, algBytes, err := mlkemKeySize(kw.algorithm, kw.enc)
+	if err != nil {
+		return nil, err
+	}
+	key, err := deriveMLKEM(algBytes, sharedKey, kw.apu, kw.apv, uint32(kw.keysize))
+	if err != nil {
+		return nil, fmt.Errorf(`failed to derive ML-KEM encryption key: %w`, err)
+	}
+
+	switch kw.algorithm {
+	case jwa.MLKEM768, jwa.MLKEM1024:
+		return keygen.ByteWithEncapsulatedKey{
+			ByteKey:         keygen.ByteKey(key),
+			EncapsulatedKey: ciphertext,
+		}, nil
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to generate cipher from derived key: %w`, err)
+	}
+	jek, err := Wrap(block, cek)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to wrap data: %w`, err)
+	}
+	return keygen.ByteWithEncapsulatedKey{
+		ByteKey:         keygen.ByteKey(jek),
+		EncapsulatedKey: ciphertext,
+	}, nil
+}
+
+// NewMLKEMDecrypt creates a new key decrypter based on ML-KEM. `ek` is
+// the value of the "ek" header, which holds the ML-KEM ciphertext
+func NewMLKEMDecrypt(keyalg jwa.KeyEncryptionAlgorithm, contentalg jwa.ContentEncryptionAlgorithm, ek, apu, apv []byte, privkey *mlkem.DecapsulationKey) (*MLKEMDecrypt, error) {
+	params, ok := MLKEMParameters(keyalg)
+	if !ok {
+		return nil, fmt.Errorf(`invalid ML-KEM algorithm (%s)`, keyalg)
+	}
+	if privkey == nil || privkey.Parameters() != params {
+		return nil, fmt.Errorf(`%s requires a %s decapsulation key`, keyalg, params)
+	}
+	return &MLKEMDecrypt{
+		keyalg:     keyalg,
+		contentalg: contentalg,
+		ek:         ek,
+		apu:        apu,
+		apv:        apv,
+		privkey:    privkey,
+	}, nil
+}
+
+// Algorithm returns the key encryption algorithm being used
+func (kw MLKEMDecrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
+	return kw.keyalg
+}
+
+// Decrypt decrypts the encrypted key using ML-KEM. In direct key
+// agreement mode, `enckey` is ignored, and the derived content
+// encryption key is returned
+func (kw MLKEMDecrypt) Decrypt(enckey []byte) ([]byte, error) {
+	if len(kw.ek) == 0 {
+		return nil, fmt.Errorf(`missing encapsulated key ("ek" header)`)
+	}
+
+	keysize, algBytes, err := mlkemKeySize(kw.keyalg, kw.contentalg)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedKey, err := kw.privkey.Decapsulate(kw.ek)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to decapsulate key: %w`, err)
+	}
+	key, err := deriveMLKEM(algBytes, sharedKey, kw.apu, kw.apv, keysize)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to derive ML-KEM encryption key: %w`, err)
+	}
+
+	switch kw.keyalg {
+	case jwa.MLKEM768, jwa.MLKEM1024:
+		return key, nil
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to create cipher for ML-KEM key wrap: %w`, err)
+	}
+	return Unwrap(block, enckey)
+}
+
 // NewRSAOAEPEncrypt creates a new key encrypter using RSA OAEP
 func NewRSAOAEPEncrypt(alg jwa.KeyEncryptionAlgorithm, pubkey *rsa.PublicKey) (*RSAOAEPEncrypt, error) {
 	switch alg {
-	case jwa.RSA_OAEP, jwa.RSA_OAEP_256:
+	case jwa.RSA_OAEP, jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512:
 	default:
 		return nil, fmt.Errorf("invalid RSA OAEP encrypt algorithm (%s)", alg)
 	}
@@ -456,25 +1207,26 @@ func (e RSAPKCSEncrypt) Encrypt(cek []byte) (keygen.ByteSource, error) {
 
 // KeyEncrypt encrypts the content encryption key using RSA OAEP
 func (e RSAOAEPEncrypt) Encrypt(cek []byte) (keygen.ByteSource, error) {
-	var hash hash.Hash
 	switch e.alg {
-	case jwa.RSA_OAEP:
-		hash = sha1.New()
-	case jwa.RSA_OAEP_256:
-		hash = sha256.New()
+	case jwa.RSA_OAEP, jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512:
 	default:
-		return nil, fmt.Errorf(`failed to generate key encrypter for RSA-OAEP: RSA_OAEP/RSA_OAEP_256 required`)
+		return nil, fmt.Errorf(`failed to generate key encrypter for RSA-OAEP: RSA_OAEP/RSA_OAEP_256/RSA_OAEP_384/RSA_OAEP_512 required`)
 	}
-	encrypted, err := rsa.EncryptOAEP(hash, rand.Reader, e.pubkey, cek, []byte{})
+	hash, err := HashForAlgorithm(e.alg)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := rsa.EncryptOAEP(hash.New(), rand.Reader, e.pubkey, cek, []byte{})
 	if err != nil {
 		return nil, fmt.Errorf(`failed to OAEP encrypt: %w`, err)
 	}
 	return keygen.ByteKey(encrypted), nil
 }
 
-// NewRSAPKCS15Decrypt creates a new decrypter using RSA PKCS1v15
+// NewRSAPKCS15Decrypt creates a new decrypter using RSA PKCS1v15.
+// `keysize` is the size of the content encryption key in bytes
 func NewRSAPKCS15Decrypt(alg jwa.KeyEncryptionAlgorithm, privkey *rsa.PrivateKey, keysize int) *RSAPKCS15Decrypt {
-	generator := keygen.NewRandom(keysize * 2)
+	generator := keygen.NewRandom(keysize)
 	return &RSAPKCS15Decrypt{
 		alg:       alg,
 		privkey:   privkey,
@@ -487,56 +1239,36 @@ func (d RSAPKCS15Decrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
 	return d.alg
 }
 
-// Decrypt decrypts the encrypted key using RSA PKCS1v1.5
+// Decrypt decrypts the encrypted key using RSA PKCS1v1.5.
+//
+// To prevent padding oracle attacks (RFC 3218, "Preventing the Million
+// Message Attack on Cryptographic Message Syntax"), this method follows
+// RFC 7516 Section 11.5: a random content encryption key of the correct
+// length is generated before decryption, and is returned in place of the
+// decrypted key if `enckey` is malformed in any way. The substitution is
+// done in constant time, and no error is returned, so that an invalid
+// `enckey` can only be detected when the content fails to decrypt, in
+// exactly the same way as a valid `enckey` with a tampered content.
 func (d RSAPKCS15Decrypt) Decrypt(enckey []byte) ([]byte, error) {
-	// Hey, these notes and workarounds were stolen from go-jose
-	defer func() {
-		// DecryptPKCS1v15SessionKey sometimes panics on an invalid payload
-		// because of an index out of bounds error, which we want to ignore.
-		// This has been fixed in Go 1.3.1 (released 2014/08/13), the recover()
-		// only exists for preventing crashes with unpatched versions.
-		// See: https://groups.google.com/forum/#!topic/golang-dev/7ihX6Y6kx9k
-		// See: https://code.google.com/p/go/source/detail?r=58ee390ff31602edb66af41ed10901ec95904d33
-		_ = recover()
-	}()
-
-	// Perform some input validation.
-	expectedlen := d.privkey.PublicKey.N.BitLen() / 8
-	if expectedlen != len(enckey) {
-		// Input size is incorrect, the encrypted payload should always match
-		// the size of the public modulus (e.g. using a 2048 bit key will
-		// produce 256 bytes of output). Reject this since it's invalid input.
-		return nil, fmt.Errorf(
-			"input size for key decrypt is incorrect (expected %d, got %d)",
-			expectedlen,
-			len(enckey),
-		)
-	}
-
-	var err error
-
 	bk, err := d.generator.Generate()
 	if err != nil {
-		return nil, fmt.Errorf(`failed to generate key`)
+		return nil, fmt.Errorf(`failed to generate key: %w`, err)
 	}
 	cek := bk.Bytes()
 
-	// When decrypting an RSA-PKCS1v1.5 payload, we must take precautions to
-	// prevent chosen-ciphertext attacks as described in RFC 3218, "Preventing
-	// the Million Message Attack on Cryptographic Message Syntax". We are
-	// therefore deliberately ignoring errors here.
-	err = rsa.DecryptPKCS1v15SessionKey(rand.Reader, d.privkey, enckey, cek)
-	if err != nil {
-		return nil, fmt.Errorf(`failed to decrypt via PKCS1v15: %w`, err)
-	}
-
+	// DecryptPKCS1v15SessionKey only overwrites cek if the padding is
+	// valid and the decrypted key has the expected length. Any error
+	// is deliberately ignored, leaving the random key in place
+	_ = rsa.DecryptPKCS1v15SessionKey(rand.Reader, d.privkey, enckey, cek)
 	return cek, nil
 }
 
 // NewRSAOAEPDecrypt creates a new key decrypter using RSA OAEP
-func NewRSAOAEPDecrypt(alg jwa.KeyEncryptionAlgorithm, privkey *rsa.PrivateKey) (*RSAOAEPDecrypt, error) {
+// `privkey` may be a *rsa.PrivateKey, or any crypto.Decrypter backed by
+// an RSA key, such as a key stored in a HSM
+func NewRSAOAEPDecrypt(alg jwa.KeyEncryptionAlgorithm, privkey crypto.Decrypter) (*RSAOAEPDecrypt, error) {
 	switch alg {
-	case jwa.RSA_OAEP, jwa.RSA_OAEP_256:
+	case jwa.RSA_OAEP, jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512:
 	default:
 		return nil, fmt.Errorf("invalid RSA OAEP decrypt algorithm (%s)", alg)
 	}
@@ -554,16 +1286,16 @@ func (d RSAOAEPDecrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
 
 // Decrypt decrypts the encrypted key using RSA OAEP
 func (d RSAOAEPDecrypt) Decrypt(enckey []byte) ([]byte, error) {
-	var hash hash.Hash
 	switch d.alg {
-	case jwa.RSA_OAEP:
-		hash = sha1.New()
-	case jwa.RSA_OAEP_256:
-		hash = sha256.New()
+	case jwa.RSA_OAEP, jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512:
 	default:
-		return nil, fmt.Errorf(`failed to generate key encrypter for RSA-OAEP: RSA_OAEP/RSA_OAEP_256 required`)
+		return nil, fmt.Errorf(`failed to generate key decrypter for RSA-OAEP: RSA_OAEP/RSA_OAEP_256/RSA_OAEP_384/RSA_OAEP_512 required`)
+	}
+	hash, err := HashForAlgorithm(d.alg)
+	if err != nil {
+		return nil, err
 	}
-	return rsa.DecryptOAEP(hash, rand.Reader, d.privkey, enckey, []byte{})
+	return d.privkey.Decrypt(rand.Reader, enckey, &rsa.OAEPOptions{Hash: hash})
 }
 
 // Decrypt for DirectDecrypt does not do anything other than
@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
 	"encoding/hex"
 	"testing"
 
+	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/sjwl/jwx/v2/jwe/internal/keyenc"
 	"github.com/sjwl/jwx/v2/jwk"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func mustHexDecode(s string) []byte {
@@ -182,3 +186,94 @@ func TestKeyWrap(t *testing.T) {
 		t.Error("key unwrap did not return original input, got", unwrap2, "wanted", cek2)
 	}
 }
+
+func TestDeriveECDH1PU(t *testing.T) {
+	// Example keys from draft-madden-jose-ecdh-1pu-04, Appendix A
+	const aliceKeySrc = `{"kty":"EC",
+      "crv":"P-256",
+      "x":"WKn-ZIGevcwGIyyrzFoZNBdaq9_TsqzGl96oc0CWuis",
+      "y":"y77t-RvAHRKTsSGdIYUfweuOvwrvDD-Q3Hv5J0fSKbE",
+      "d":"Hndv7ZZjs_ke8o9zXYo3iq-Yr8SewI5vrqd0pAvEPqg"
+     }`
+	const bobKeySrc = `{"kty":"EC",
+      "crv":"P-256",
+      "x":"weNJy2HscCSM6AEDTDg04biOvhFhyyWvOHQfeF_PxMQ",
+      "y":"e8lnCO-AlStT-NJVX-crhB7QRYhiix03illJOVAOyck",
+      "d":"VEmDZpDXXK8p8N0Cndsxs924q6nS1RXFASRl6BfUqdw"
+     }`
+	const ephemeralKeySrc = `{"kty":"EC",
+      "crv":"P-256",
+      "x":"gI0GAILBdu7T53akrFmMyGcsF3n5dO7MmwNBHKW5SV0",
+      "y":"SLW_xSffzlPWrHEVI30DHM_4egVwt3NQqeUD7nMFpps",
+      "d":"0_NxaRPUMQoAJt50Gz8YiTr8gRTwyEaCumd-MToTmIo"
+     }`
+
+	var aliceKey, bobKey, ephemeralKey ecdsa.PrivateKey
+	for _, pair := range []struct {
+		src string
+		dst *ecdsa.PrivateKey
+	}{
+		{src: aliceKeySrc, dst: &aliceKey},
+		{src: bobKeySrc, dst: &bobKey},
+		{src: ephemeralKeySrc, dst: &ephemeralKey},
+	} {
+		key, err := jwk.ParseKey([]byte(pair.src))
+		require.NoError(t, err, `jwk.ParseKey should succeed`)
+		require.NoError(t, key.Raw(pair.dst), `key.Raw should succeed`)
+	}
+
+	expected := mustHexDecode("6caf13723d14850ad4b42cd6dde935bffd2fff00a9ba70de05c203a5e1722ca7")
+
+	output, err := keyenc.DeriveECDH1PU([]byte("A256GCM"), []byte("Alice"), []byte("Bob"), &bobKey, &ephemeralKey.PublicKey, &aliceKey.PublicKey, 32, nil)
+	require.NoError(t, err, `keyenc.DeriveECDH1PU should succeed`)
+	require.Equal(t, expected, output, `result should match`)
+}
+
+func TestRSAPKCS15Decrypt(t *testing.T) {
+	privkey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err, `rsa.GenerateKey should succeed`)
+
+	const keysize = 32
+	cek := make([]byte, keysize)
+	_, err = rand.Read(cek)
+	require.NoError(t, err, `rand.Read should succeed`)
+
+	enc, err := keyenc.NewRSAPKCSEncrypt(jwa.RSA1_5, &privkey.PublicKey)
+	require.NoError(t, err, `keyenc.NewRSAPKCSEncrypt should succeed`)
+	enckey, err := enc.Encrypt(cek)
+	require.NoError(t, err, `enc.Encrypt should succeed`)
+
+	dec := keyenc.NewRSAPKCS15Decrypt(jwa.RSA1_5, privkey, keysize)
+	decrypted, err := dec.Decrypt(enckey.Bytes())
+	require.NoError(t, err, `dec.Decrypt should succeed`)
+	require.Equal(t, cek, decrypted, `keys should match`)
+
+	// a valid padding around a key of the wrong length
+	shortkey, err := rsa.EncryptPKCS1v15(rand.Reader, &privkey.PublicKey, cek[:keysize-1])
+	require.NoError(t, err, `rsa.EncryptPKCS1v15 should succeed`)
+
+	garbage := make([]byte, privkey.Size())
+	_, err = rand.Read(garbage)
+	require.NoError(t, err, `rand.Read should succeed`)
+
+	// malformed keys must be replaced by a random key of the correct
+	// length, without returning an error
+	for name, src := range map[string][]byte{
+		"invalid padding": garbage,
+		"wrong key size":  shortkey,
+		"truncated":       enckey.Bytes()[:privkey.Size()-1],
+		"empty":           nil,
+	} {
+		src := src
+		t.Run(name, func(t *testing.T) {
+			substituted, err := dec.Decrypt(src)
+			require.NoError(t, err, `dec.Decrypt should succeed`)
+			require.Len(t, substituted, keysize)
+			require.NotEqual(t, cek, substituted)
+
+			again, err := dec.Decrypt(src)
+			require.NoError(t, err, `dec.Decrypt should succeed`)
+			require.NotEqual(t, substituted, again, `substituted keys should be random`)
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/sjwl/jwx/v2/x25519"
+	"github.com/sjwl/jwx/v2/x448"
 )
 
 type Generator interface {
@@ -38,6 +39,14 @@ type X25519 struct {
 	pubkey    x25519.PublicKey
 }
 
+// X448 generates keys using ECDH-ES algorithm / X448 curve
+type X448 struct {
+	algorithm jwa.KeyEncryptionAlgorithm
+	enc       jwa.ContentEncryptionAlgorithm
+	keysize   int
+	pubkey    x448.PublicKey
+}
+
 // ByteKey is a generated key that only has the key's byte buffer
 // as its instance data. If a key needs to do more, such as providing
 // values to be set in a JWE header, that key type wraps a ByteKey
@@ -51,12 +60,28 @@ type ByteWithECPublicKey struct {
 	PublicKey interface{}
 }
 
+// ByteWithECPublicKeyAndIVAndTag holds the EC private key that
+// generated the key encryption key, along with the parameters that
+// were used to wrap the key (e.g. ECDH-ES+XC20PKW)
+type ByteWithECPublicKeyAndIVAndTag struct {
+	ByteWithECPublicKey
+	IV  []byte
+	Tag []byte
+}
+
 type ByteWithIVAndTag struct {
 	ByteKey
 	IV  []byte
 	Tag []byte
 }
 
+// ByteWithEncapsulatedKey holds the key encrypted using HPKE or ML-KEM, along
+// with the encapsulated key that is required to decrypt it ('ek' header)
+type ByteWithEncapsulatedKey struct {
+	ByteKey
+	EncapsulatedKey []byte
+}
+
 type ByteWithSaltAndCount struct {
 	ByteKey
 	Salt  []byte
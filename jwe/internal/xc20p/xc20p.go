@@ -0,0 +1,216 @@
+// Package xc20p implements XChaCha20-Poly1305 encryption and decryption
+// of data that does not fit in memory. The output is identical to that of
+// golang.org/x/crypto/chacha20poly1305's XChaCha20-Poly1305 implementation.
+package xc20p
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/poly1305" //nolint:staticcheck // used to build the full AEAD construction
+)
+
+const (
+	KeySize   = 32
+	NonceSize = 24
+	TagSize   = 16
+
+	// maxPlaintextSize is the maximum size of data that can be
+	// encrypted using a single key and nonce, as the 32 bit block
+	// counter must not wrap around
+	maxPlaintextSize = (1 << 38) - 64
+
+	// streamChunkSize is the maximum number of bytes that are
+	// encrypted or decrypted at once
+	streamChunkSize = 32 * 1024
+)
+
+var padding [16]byte
+
+type stream struct {
+	cipher *chacha20.Cipher
+	mac    *poly1305.MAC
+	aadlen uint64
+	size   uint64
+	work   []byte
+}
+
+func newStream(key, nonce, aad []byte) (*stream, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf(`xc20p: invalid key size: expected %d bytes, got %d bytes`, KeySize, len(key))
+	}
+	if len(nonce) != NonceSize {
+		return nil, fmt.Errorf(`xc20p: invalid nonce size: expected %d bytes, got %d bytes`, NonceSize, len(nonce))
+	}
+
+	// With a 24 byte nonce, chacha20 derives the subkey using HChaCha20
+	c, err := chacha20.NewUnauthenticatedCipher(key, nonce)
+	if err != nil {
+		return nil, fmt.Errorf(`xc20p: failed to create cipher: %w`, err)
+	}
+
+	// The Poly1305 key is taken from the first block of the key stream,
+	// and the content is encrypted starting from the second block
+	var polyKey [32]byte
+	c.XORKeyStream(polyKey[:], polyKey[:])
+	c.SetCounter(1)
+
+	s := &stream{
+		cipher: c,
+		mac:    poly1305.New(&polyKey),
+		aadlen: uint64(len(aad)),
+		work:   make([]byte, streamChunkSize),
+	}
+	s.write(aad)
+	return s, nil
+}
+
+func (s *stream) write(p []byte) {
+	_, _ = s.mac.Write(p)
+	if rem := len(p) % 16; rem != 0 {
+		_, _ = s.mac.Write(padding[:16-rem])
+	}
+}
+
+func (s *stream) addSize(n int) error {
+	s.size += uint64(n)
+	if s.size > maxPlaintextSize {
+		return fmt.Errorf(`xc20p: message too large`)
+	}
+	return nil
+}
+
+func (s *stream) tag() []byte {
+	if rem := s.size % 16; rem != 0 {
+		_, _ = s.mac.Write(padding[:16-rem])
+	}
+	var lengths [16]byte
+	binary.LittleEndian.PutUint64(lengths[:8], s.aadlen)
+	binary.LittleEndian.PutUint64(lengths[8:], s.size)
+	_, _ = s.mac.Write(lengths[:])
+	return s.mac.Sum(nil)
+}
+
+// StreamEncrypter encrypts data that is written to it incrementally,
+// and writes the ciphertext to the underlying io.Writer. Close() must
+// be called after all of the plaintext has been written, after which
+// Tag() returns the authentication tag.
+type StreamEncrypter struct {
+	stream *stream
+	dst    io.Writer
+	tag    []byte
+}
+
+// NewStreamEncrypter creates a new StreamEncrypter
+func NewStreamEncrypter(dst io.Writer, key, nonce, aad []byte) (*StreamEncrypter, error) {
+	s, err := newStream(key, nonce, aad)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamEncrypter{stream: s, dst: dst}, nil
+}
+
+// Write encrypts `p`, and writes the ciphertext to the underlying io.Writer
+func (e *StreamEncrypter) Write(p []byte) (int, error) {
+	if e.tag != nil {
+		return 0, fmt.Errorf(`xc20p: write to closed stream encrypter`)
+	}
+
+	written := len(p)
+	s := e.stream
+	for len(p) > 0 {
+		n := len(p)
+		if n > len(s.work) {
+			n = len(s.work)
+		}
+		if err := s.addSize(n); err != nil {
+			return 0, err
+		}
+		s.cipher.XORKeyStream(s.work[:n], p[:n])
+		_, _ = s.mac.Write(s.work[:n])
+		if _, err := e.dst.Write(s.work[:n]); err != nil {
+			return 0, fmt.Errorf(`xc20p: failed to write ciphertext: %w`, err)
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Close computes the authentication tag
+func (e *StreamEncrypter) Close() error {
+	if e.tag == nil {
+		e.tag = e.stream.tag()
+	}
+	return nil
+}
+
+// Tag returns the authentication tag. It returns nil until Close()
+// has been called
+func (e *StreamEncrypter) Tag() []byte {
+	return e.tag
+}
+
+// StreamDecrypter decrypts data that is written to it incrementally,
+// and writes the plaintext to the underlying io.Writer. Finish() must
+// be called with the authentication tag after all of the ciphertext
+// has been written.
+//
+// Plaintext is written to the underlying io.Writer before the
+// authentication tag is verified. It must not be used unless
+// Finish() succeeds.
+type StreamDecrypter struct {
+	stream   *stream
+	dst      io.Writer
+	finished bool
+}
+
+// NewStreamDecrypter creates a new StreamDecrypter
+func NewStreamDecrypter(dst io.Writer, key, nonce, aad []byte) (*StreamDecrypter, error) {
+	s, err := newStream(key, nonce, aad)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamDecrypter{stream: s, dst: dst}, nil
+}
+
+// Write decrypts `p`, and writes the plaintext to the underlying io.Writer
+func (d *StreamDecrypter) Write(p []byte) (int, error) {
+	if d.finished {
+		return 0, fmt.Errorf(`xc20p: write to finished stream decrypter`)
+	}
+
+	written := len(p)
+	s := d.stream
+	for len(p) > 0 {
+		n := len(p)
+		if n > len(s.work) {
+			n = len(s.work)
+		}
+		if err := s.addSize(n); err != nil {
+			return 0, err
+		}
+		_, _ = s.mac.Write(p[:n])
+		s.cipher.XORKeyStream(s.work[:n], p[:n])
+		if _, err := d.dst.Write(s.work[:n]); err != nil {
+			return 0, fmt.Errorf(`xc20p: failed to write plaintext: %w`, err)
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Finish verifies the authentication tag
+func (d *StreamDecrypter) Finish(tag []byte) error {
+	if d.finished {
+		return fmt.Errorf(`xc20p: stream decrypter has already been finished`)
+	}
+	d.finished = true
+
+	if subtle.ConstantTimeCompare(d.stream.tag(), tag) != 1 {
+		return fmt.Errorf(`xc20p: message authentication failed`)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package xc20p_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/sjwl/jwx/v2/jwe/internal/xc20p"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// writeInChunks writes `data` to `w` in chunks of `size` bytes
+func writeInChunks(t *testing.T, w interface{ Write([]byte) (int, error) }, data []byte, size int) {
+	t.Helper()
+	for len(data) > 0 {
+		n := size
+		if n > len(data) {
+			n = len(data)
+		}
+		_, err := w.Write(data[:n])
+		require.NoError(t, err, `Write should succeed`)
+		data = data[n:]
+	}
+}
+
+func TestStream(t *testing.T) {
+	key := make([]byte, xc20p.KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err, `rand.Read should succeed`)
+
+	aead, err := chacha20poly1305.NewX(key)
+	require.NoError(t, err, `chacha20poly1305.NewX should succeed`)
+
+	nonce := make([]byte, xc20p.NonceSize)
+	_, err = rand.Read(nonce)
+	require.NoError(t, err, `rand.Read should succeed`)
+
+	for _, aad := range [][]byte{nil, []byte(`eyJhbGciOiJkaXIiLCJlbmMiOiJYQzIwUCJ9`)} {
+		for _, size := range []int{0, 1, 15, 16, 17, 1000, 100000} {
+			for _, chunk := range []int{1, 7, 16, 4096, 100000} {
+				plaintext := make([]byte, size)
+				_, err := rand.Read(plaintext)
+				require.NoError(t, err, `rand.Read should succeed`)
+
+				sealed := aead.Seal(nil, nonce, plaintext, aad)
+				expectedCiphertext := sealed[:size]
+				expectedTag := sealed[size:]
+
+				var ciphertext bytes.Buffer
+				enc, err := xc20p.NewStreamEncrypter(&ciphertext, key, nonce, aad)
+				require.NoError(t, err, `xc20p.NewStreamEncrypter should succeed`)
+				writeInChunks(t, enc, plaintext, chunk)
+				require.NoError(t, enc.Close(), `Close should succeed`)
+				require.True(t, bytes.Equal(expectedCiphertext, ciphertext.Bytes()), `ciphertext should match (size=%d, chunk=%d)`, size, chunk)
+				require.Equal(t, expectedTag, enc.Tag(), `tag should match (size=%d, chunk=%d)`, size, chunk)
+
+				var decrypted bytes.Buffer
+				dec, err := xc20p.NewStreamDecrypter(&decrypted, key, nonce, aad)
+				require.NoError(t, err, `xc20p.NewStreamDecrypter should succeed`)
+				writeInChunks(t, dec, expectedCiphertext, chunk)
+				require.NoError(t, dec.Finish(expectedTag), `Finish should succeed`)
+				require.True(t, bytes.Equal(plaintext, decrypted.Bytes()), `plaintext should match`)
+			}
+		}
+	}
+}
+
+func TestStreamTampered(t *testing.T) {
+	key := make([]byte, xc20p.KeySize)
+	nonce := make([]byte, xc20p.NonceSize)
+
+	var ciphertext bytes.Buffer
+	enc, err := xc20p.NewStreamEncrypter(&ciphertext, key, nonce, nil)
+	require.NoError(t, err, `xc20p.NewStreamEncrypter should succeed`)
+	_, err = enc.Write([]byte(`Lorem ipsum dolor sit amet`))
+	require.NoError(t, err, `Write should succeed`)
+	require.NoError(t, enc.Close(), `Close should succeed`)
+
+	tampered := ciphertext.Bytes()
+	tampered[0] ^= 1
+
+	var decrypted bytes.Buffer
+	dec, err := xc20p.NewStreamDecrypter(&decrypted, key, nonce, nil)
+	require.NoError(t, err, `xc20p.NewStreamDecrypter should succeed`)
+	_, err = dec.Write(tampered)
+	require.NoError(t, err, `Write should succeed`)
+	require.Error(t, dec.Finish(enc.Tag()), `Finish should fail`)
+
+	_, err = xc20p.NewStreamEncrypter(&ciphertext, key[:16], nonce, nil)
+	require.Error(t, err, `xc20p.NewStreamEncrypter should fail with a short key`)
+	_, err = xc20p.NewStreamEncrypter(&ciphertext, key, nonce[:12], nil)
+	require.Error(t, err, `xc20p.NewStreamEncrypter should fail with a short nonce`)
+}
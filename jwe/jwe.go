@@ -15,13 +15,15 @@ import (
 	"github.com/sjwl/jwx/v2/internal/base64"
 	"github.com/sjwl/jwx/v2/internal/json"
 	"github.com/sjwl/jwx/v2/internal/keyconv"
+	"github.com/sjwl/jwx/v2/internal/keystrength"
+	"github.com/sjwl/jwx/v2/internal/mlkem"
 	"github.com/sjwl/jwx/v2/jwk"
 
 	"github.com/sjwl/jwx/v2/jwa"
-	"github.com/sjwl/jwx/v2/jwe/internal/content_crypt"
 	"github.com/sjwl/jwx/v2/jwe/internal/keyenc"
 	"github.com/sjwl/jwx/v2/jwe/internal/keygen"
 	"github.com/sjwl/jwx/v2/x25519"
+	"github.com/sjwl/jwx/v2/x448"
 )
 
 const (
@@ -38,12 +40,17 @@ var _ = fmtMax
 var registry = json.NewRegistry()
 
 type recipientBuilder struct {
-	alg     jwa.KeyEncryptionAlgorithm
-	key     interface{}
-	headers Headers
+	alg       jwa.KeyEncryptionAlgorithm
+	key       interface{}
+	senderKey interface{}
+	headers   Headers
+
+	// wrapper is set by Build() for algorithms that can only encrypt
+	// the key after the content has been encrypted
+	wrapper *keyenc.ECDH1PUEncrypt
 }
 
-func (b *recipientBuilder) Build(cek []byte, calg jwa.ContentEncryptionAlgorithm, cc *content_crypt.Generic) (Recipient, []byte, error) {
+func (b *recipientBuilder) Build(cek []byte, calg jwa.ContentEncryptionAlgorithm, cc ContentCipher) (Recipient, []byte, error) {
 	// we need the raw key
 	rawKey := b.key
 
@@ -60,6 +67,10 @@ func (b *recipientBuilder) Build(cek []byte, calg jwa.ContentEncryptionAlgorithm
 		rawKey = raw
 	}
 
+	if f, ok := lookupKeyEncrypter(b.alg); ok {
+		return b.buildCustom(f, cek, calg, rawKey, keyID)
+	}
+
 	// First, create a key encryptor
 	var enc keyenc.Encrypter
 	switch b.alg {
@@ -74,7 +85,7 @@ func (b *recipientBuilder) Build(cek []byte, calg jwa.ContentEncryptionAlgorithm
 			return nil, nil, fmt.Errorf(`failed to create RSA PKCS encrypter: %w`, err)
 		}
 		enc = v
-	case jwa.RSA_OAEP, jwa.RSA_OAEP_256:
+	case jwa.RSA_OAEP, jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512:
 		var pubkey rsa.PublicKey
 		if err := keyconv.RSAPublicKey(&pubkey, rawKey); err != nil {
 			return nil, nil, fmt.Errorf(`failed to generate public key from key (%T): %w`, rawKey, err)
@@ -86,7 +97,7 @@ func (b *recipientBuilder) Build(cek []byte, calg jwa.ContentEncryptionAlgorithm
 		}
 		enc = v
 	case jwa.A128KW, jwa.A192KW, jwa.A256KW,
-		jwa.A128GCMKW, jwa.A192GCMKW, jwa.A256GCMKW,
+		jwa.A128GCMKW, jwa.A192GCMKW, jwa.A256GCMKW, jwa.XC20PKW,
 		jwa.PBES2_HS256_A128KW, jwa.PBES2_HS384_A192KW, jwa.PBES2_HS512_A256KW:
 		sharedkey, ok := rawKey.([]byte)
 		if !ok {
@@ -99,6 +110,8 @@ func (b *recipientBuilder) Build(cek []byte, calg jwa.ContentEncryptionAlgorithm
 			enc, err = keyenc.NewAES(b.alg, sharedkey)
 		case jwa.PBES2_HS256_A128KW, jwa.PBES2_HS384_A192KW, jwa.PBES2_HS512_A256KW:
 			enc, err = keyenc.NewPBES2Encrypt(b.alg, sharedkey)
+		case jwa.XC20PKW:
+			enc, err = keyenc.NewXC20PKWEncrypt(b.alg, sharedkey)
 		default:
 			enc, err = keyenc.NewAESGCMEncrypt(b.alg, sharedkey)
 		}
@@ -109,7 +122,7 @@ func (b *recipientBuilder) Build(cek []byte, calg jwa.ContentEncryptionAlgorithm
 		// in PR #26, which disallowed certain key/content
 		// algorithm combinations. This seemed bogus, and
 		// interop with the jose tool demonstrates it.
-	case jwa.ECDH_ES, jwa.ECDH_ES_A128KW, jwa.ECDH_ES_A192KW, jwa.ECDH_ES_A256KW:
+	case jwa.ECDH_ES, jwa.ECDH_ES_A128KW, jwa.ECDH_ES_A192KW, jwa.ECDH_ES_A256KW, jwa.ECDH_ES_XC20PKW:
 		var keysize int
 		switch b.alg {
 		case jwa.ECDH_ES:
@@ -121,12 +134,12 @@ func (b *recipientBuilder) Build(cek []byte, calg jwa.ContentEncryptionAlgorithm
 			keysize = 16
 		case jwa.ECDH_ES_A192KW:
 			keysize = 24
-		case jwa.ECDH_ES_A256KW:
+		case jwa.ECDH_ES_A256KW, jwa.ECDH_ES_XC20PKW:
 			keysize = 32
 		}
 
 		switch key := rawKey.(type) {
-		case x25519.PublicKey:
+		case x25519.PublicKey, x448.PublicKey:
 			var apu, apv []byte
 			if hdrs := b.headers; hdrs != nil {
 				apu = hdrs.AgreementPartyUInfo()
@@ -156,6 +169,56 @@ func (b *recipientBuilder) Build(cek []byte, calg jwa.ContentEncryptionAlgorithm
 			}
 			enc = v
 		}
+	case jwa.ECDH_1PU, jwa.ECDH_1PU_A128KW, jwa.ECDH_1PU_A192KW, jwa.ECDH_1PU_A256KW:
+		v, err := b.buildECDH1PU(calg, cc, rawKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		enc = v
+		if keyenc.IsECDH1PUKeyWrap(b.alg) {
+			b.wrapper = v
+		}
+	case jwa.HPKE_0, jwa.HPKE_1, jwa.HPKE_2, jwa.HPKE_3, jwa.HPKE_4,
+		jwa.HPKE_0_KE, jwa.HPKE_1_KE, jwa.HPKE_2_KE, jwa.HPKE_3_KE, jwa.HPKE_4_KE:
+		var pubkey interface{}
+		switch key := rawKey.(type) {
+		case x25519.PublicKey:
+			pubkey = key
+		default:
+			var ecpubkey ecdsa.PublicKey
+			if err := keyconv.ECDSAPublicKey(&ecpubkey, rawKey); err != nil {
+				return nil, nil, fmt.Errorf(`failed to generate public key from key (%T): %w`, key, err)
+			}
+			pubkey = &ecpubkey
+		}
+
+		v, err := keyenc.NewHPKEEncrypt(b.alg, pubkey)
+		if err != nil {
+			return nil, nil, fmt.Errorf(`failed to create HPKE key encrypter: %w`, err)
+		}
+		enc = v
+	case jwa.MLKEM768, jwa.MLKEM768_A192KW, jwa.MLKEM1024, jwa.MLKEM1024_A256KW:
+		var pubkey *mlkem.EncapsulationKey
+		switch key := rawKey.(type) {
+		case *mlkem.EncapsulationKey:
+			pubkey = key
+		case *mlkem.DecapsulationKey:
+			pubkey = key.EncapsulationKey()
+		default:
+			return nil, nil, fmt.Errorf(`invalid key: *mlkem.EncapsulationKey required (%T)`, rawKey)
+		}
+
+		var apu, apv []byte
+		if hdrs := b.headers; hdrs != nil {
+			apu = hdrs.AgreementPartyUInfo()
+			apv = hdrs.AgreementPartyVInfo()
+		}
+
+		v, err := keyenc.NewMLKEMEncrypt(b.alg, calg, pubkey, apu, apv)
+		if err != nil {
+			return nil, nil, fmt.Errorf(`failed to create ML-KEM key encrypter: %w`, err)
+		}
+		enc = v
 	case jwa.DIRECT:
 		sharedkey, ok := rawKey.([]byte)
 		if !ok {
@@ -189,9 +252,19 @@ func (b *recipientBuilder) Build(cek []byte, calg jwa.ContentEncryptionAlgorithm
 	if err != nil {
 		return nil, nil, fmt.Errorf(`failed to encrypt key: %w`, err)
 	}
-	if enc.Algorithm() == jwa.ECDH_ES || enc.Algorithm() == jwa.DIRECT {
+	switch enc.Algorithm() {
+	case jwa.ECDH_ES, jwa.ECDH_1PU, jwa.DIRECT, jwa.MLKEM768, jwa.MLKEM1024:
 		rawCEK = enckey.Bytes()
-	} else {
+	case jwa.HPKE_0, jwa.HPKE_1, jwa.HPKE_2, jwa.HPKE_3, jwa.HPKE_4:
+		//nolint:forcetypeassert
+		integrated := enckey.(keyenc.HPKEIntegratedKey)
+		rawCEK = integrated.Bytes()
+		if err := r.SetEncryptedKey(integrated.Enc); err != nil {
+			return nil, nil, fmt.Errorf(`failed to set encrypted key: %w`, err)
+		}
+	case jwa.ECDH_1PU_A128KW, jwa.ECDH_1PU_A192KW, jwa.ECDH_1PU_A256KW:
+		// the key is wrapped once the content has been encrypted
+	default:
 		if err := r.SetEncryptedKey(enckey.Bytes()); err != nil {
 			return nil, nil, fmt.Errorf(`failed to set encrypted key: %w`, err)
 		}
@@ -206,6 +279,114 @@ func (b *recipientBuilder) Build(cek []byte, calg jwa.ContentEncryptionAlgorithm
 	return r, rawCEK, nil
 }
 
+// buildECDH1PU creates the key encrypter for the ECDH-1PU family of algorithms
+func (b *recipientBuilder) buildECDH1PU(calg jwa.ContentEncryptionAlgorithm, cc ContentCipher, rawKey interface{}) (*keyenc.ECDH1PUEncrypt, error) {
+	var keysize int
+	switch b.alg {
+	case jwa.ECDH_1PU:
+		keysize = cc.KeySize()
+	case jwa.ECDH_1PU_A128KW:
+		keysize = 16
+	case jwa.ECDH_1PU_A192KW:
+		keysize = 24
+	case jwa.ECDH_1PU_A256KW:
+		keysize = 32
+	}
+
+	if b.senderKey == nil {
+		return nil, fmt.Errorf(`%s requires the static private key of the sender (see jwe.WithSenderKey())`, b.alg)
+	}
+	senderKey, err := rawSenderKey(b.senderKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var pubkey interface{}
+	switch key := rawKey.(type) {
+	case x25519.PublicKey, x448.PublicKey:
+		pubkey = key
+	default:
+		var ecpubkey ecdsa.PublicKey
+		if err := keyconv.ECDSAPublicKey(&ecpubkey, rawKey); err != nil {
+			return nil, fmt.Errorf(`failed to generate public key from key (%T): %w`, key, err)
+		}
+		pubkey = &ecpubkey
+	}
+
+	var apu, apv []byte
+	if hdrs := b.headers; hdrs != nil {
+		apu = hdrs.AgreementPartyUInfo()
+		apv = hdrs.AgreementPartyVInfo()
+	}
+
+	v, err := keyenc.NewECDH1PUEncrypt(b.alg, calg, keysize, senderKey, pubkey, apu, apv)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to create ECDH-1PU key wrap encrypter: %w`, err)
+	}
+	return v, nil
+}
+
+// rawSenderKey converts the key given to `jwe.WithSenderKey()` into
+// a form that can be used to compute ECDH shared secrets
+func rawSenderKey(key interface{}) (interface{}, error) {
+	if jwkKey, ok := key.(jwk.Key); ok {
+		var raw interface{}
+		if err := jwkKey.Raw(&raw); err != nil {
+			return nil, fmt.Errorf(`failed to retrieve raw key out of %T: %w`, key, err)
+		}
+		key = raw
+	}
+
+	switch key := key.(type) {
+	case KeyAgreement, x25519.PrivateKey, x448.PrivateKey:
+		return key, nil
+	default:
+		var privkey ecdsa.PrivateKey
+		if err := keyconv.ECDSAPrivateKey(&privkey, key); err != nil {
+			return nil, fmt.Errorf(`invalid sender key (%T): %w`, key, err)
+		}
+		if err := checkStaticECDSAKey(&privkey); err != nil {
+			return nil, fmt.Errorf(`invalid sender key: %w`, err)
+		}
+		return &privkey, nil
+	}
+}
+
+// buildCustom builds the recipient using a KeyEncrypter registered
+// via `jwe.RegisterKeyEncrypter()`
+func (b *recipientBuilder) buildCustom(f KeyEncrypterFactory, cek []byte, calg jwa.ContentEncryptionAlgorithm, rawKey interface{}, keyID string) (Recipient, []byte, error) {
+	enc, err := f.Create()
+	if err != nil {
+		return nil, nil, fmt.Errorf(`failed to create key encrypter for %s: %w`, b.alg, err)
+	}
+
+	r := NewRecipient()
+	if hdrs := b.headers; hdrs != nil {
+		_ = r.SetHeaders(hdrs)
+	}
+
+	if err := r.Headers().Set(AlgorithmKey, b.alg); err != nil {
+		return nil, nil, fmt.Errorf(`failed to set header: %w`, err)
+	}
+	if keyID != "" {
+		if err := r.Headers().Set(KeyIDKey, keyID); err != nil {
+			return nil, nil, fmt.Errorf(`failed to set header: %w`, err)
+		}
+	}
+
+	enckey, rawCEK, err := enc.EncryptKey(calg, cek, rawKey, r.Headers())
+	if err != nil {
+		return nil, nil, fmt.Errorf(`failed to encrypt key: %w`, err)
+	}
+
+	if enckey != nil {
+		if err := r.SetEncryptedKey(enckey); err != nil {
+			return nil, nil, fmt.Errorf(`failed to set encrypted key: %w`, err)
+		}
+	}
+	return r, rawCEK, nil
+}
+
 // Encrypt generates a JWE message for the given payload and returns
 // it in serialized form, which can be in either compact or
 // JSON format. Default is compact.
@@ -227,6 +408,92 @@ func (b *recipientBuilder) Build(cek []byte, calg jwa.ContentEncryptionAlgorithm
 // Look for options that return `jwe.EncryptOption` or `jws.EncryptDecryptOption`
 // for a complete list of options that can be passed to this function.
 func Encrypt(payload []byte, options ...EncryptOption) ([]byte, error) {
+	ectx, err := newEncryptCtx(options)
+	if err != nil {
+		return nil, fmt.Errorf(`jwe.Encrypt: %w`, err)
+	}
+
+	if ectx.compressor != nil {
+		payload, err = compress(ectx.compressor, payload)
+		if err != nil {
+			return nil, fmt.Errorf(`jwe.Encrypt: failed to compress payload before encryption: %w`, err)
+		}
+	}
+
+	iv, ciphertext, tag, err := ectx.contentcrypt.Encrypt(ectx.cek, payload, ectx.aad)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to encrypt payload: %w`, err)
+	}
+
+	if err := ectx.wrapKeys(tag); err != nil {
+		return nil, fmt.Errorf(`jwe.Encrypt: %w`, err)
+	}
+
+	msg, err := ectx.newMessage(iv)
+	if err != nil {
+		return nil, fmt.Errorf(`jwe.Encrypt: %w`, err)
+	}
+	if err := msg.Set(CipherTextKey, ciphertext); err != nil {
+		return nil, fmt.Errorf(`failed to set %s: %w`, CipherTextKey, err)
+	}
+	if err := msg.Set(TagKey, tag); err != nil {
+		return nil, fmt.Errorf(`failed to set %s: %w`, TagKey, err)
+	}
+
+	switch ectx.format {
+	case fmtCompact:
+		return Compact(msg)
+	case fmtJSON:
+		return json.Marshal(msg)
+	case fmtJSONPretty:
+		return json.MarshalIndent(msg, "", "  ")
+	default:
+		return nil, fmt.Errorf(`jwe.Encrypt: invalid serialization`)
+	}
+}
+
+// encryptCtx holds the values that are shared between `jwe.Encrypt()`
+// and `jwe.EncryptStream()`
+type encryptCtx struct {
+	format       int
+	compression  jwa.CompressionAlgorithm
+	compressor   Compressor
+	contentcrypt ContentCipher
+	cek          []byte
+	protected    Headers
+	recipients   []Recipient
+	aad          []byte
+
+	// pending holds the recipients whose keys can only be
+	// encrypted after the content has been encrypted
+	pending []pendingRecipient
+}
+
+// pendingRecipient is a recipient whose key is encrypted using a key
+// derived from the authentication tag of the content (ECDH-1PU key wrapping)
+type pendingRecipient struct {
+	recipient Recipient
+	wrapper   *keyenc.ECDH1PUEncrypt
+}
+
+// wrapKeys encrypts the content encryption key for the pending recipients
+func (ectx *encryptCtx) wrapKeys(tag []byte) error {
+	for _, p := range ectx.pending {
+		enckey, err := p.wrapper.WrapKey(ectx.cek, tag)
+		if err != nil {
+			return fmt.Errorf(`failed to wrap key for %s recipient: %w`, p.wrapper.Algorithm(), err)
+		}
+		if err := p.recipient.SetEncryptedKey(enckey); err != nil {
+			return fmt.Errorf(`failed to set encrypted key: %w`, err)
+		}
+	}
+	return nil
+}
+
+// newEncryptCtx processes the options given to `jwe.Encrypt()` or
+// `jwe.EncryptStream()`, and builds the recipients and the protected
+// headers. The content itself is left to the caller
+func newEncryptCtx(options []EncryptOption) (*encryptCtx, error) {
 	// default content encryption algorithm
 	calg := jwa.A256GCM
 
@@ -240,20 +507,23 @@ func Encrypt(payload []byte, options ...EncryptOption) ([]byte, error) {
 
 	var protected Headers
 	var mergeProtected bool
-	var useRawCEK bool
+	var ksp *jwa.KeyStrengthPolicy
+	var setKeyStrengthPolicy bool
+	var senderKey interface{}
+	var setContentEncryption bool
 	for _, option := range options {
 		//nolint:forcetypeassert
 		switch option.Ident() {
+		case identKeyStrengthPolicy{}:
+			ksp = option.Value().(*jwa.KeyStrengthPolicy)
+			setKeyStrengthPolicy = true
+		case identSenderKey{}:
+			senderKey = option.Value()
 		case identKey{}:
 			data := option.Value().(*withKey)
 			v, ok := data.alg.(jwa.KeyEncryptionAlgorithm)
 			if !ok {
-				return nil, fmt.Errorf(`jwe.Encrypt: expected alg to be jwa.KeyEncryptionAlgorithm, but got %T`, data.alg)
-			}
-
-			switch v {
-			case jwa.DIRECT, jwa.ECDH_ES:
-				useRawCEK = true
+				return nil, fmt.Errorf(`expected alg to be jwa.KeyEncryptionAlgorithm, but got %T`, data.alg)
 			}
 
 			builders = append(builders, &recipientBuilder{
@@ -263,6 +533,7 @@ func Encrypt(payload []byte, options ...EncryptOption) ([]byte, error) {
 			})
 		case identContentEncryptionAlgorithm{}:
 			calg = option.Value().(jwa.ContentEncryptionAlgorithm)
+			setContentEncryption = true
 		case identCompress{}:
 			compression = option.Value().(jwa.CompressionAlgorithm)
 		case identMergeProtectedHeaders{}:
@@ -275,7 +546,7 @@ func Encrypt(payload []byte, options ...EncryptOption) ([]byte, error) {
 				ctx := context.TODO()
 				merged, err := protected.Merge(ctx, v)
 				if err != nil {
-					return nil, fmt.Errorf(`jwe.Encrypt: failed to merge headers: %w`, err)
+					return nil, fmt.Errorf(`failed to merge headers: %w`, err)
 				}
 				protected = merged
 			}
@@ -287,44 +558,93 @@ func Encrypt(payload []byte, options ...EncryptOption) ([]byte, error) {
 	// We need to have at least one builder
 	switch l := len(builders); {
 	case l == 0:
-		return nil, fmt.Errorf(`jwe.Encrypt: missing key encryption builders: use jwe.WithKey() to specify one`)
+		return nil, fmt.Errorf(`missing key encryption builders: use jwe.WithKey() to specify one`)
 	case l > 1:
 		if format == fmtCompact {
-			return nil, fmt.Errorf(`jwe.Encrypt: cannot use compact serialization when multiple recipients exist (check the number of WithKey() argument, or use WithJSON())`)
+			return nil, fmt.Errorf(`cannot use compact serialization when multiple recipients exist (check the number of WithKey() argument, or use WithJSON())`)
 		}
 	}
 
-	if useRawCEK {
-		if len(builders) != 1 {
-			return nil, fmt.Errorf(`jwe.Encrypt: multiple recipients for ECDH-ES/DIRECT mode supported`)
+	// In HPKE integrated encryption, the content is encrypted by HPKE
+	// itself, and the "enc" header is not used
+	integrated := keyenc.IsHPKEIntegrated(builders[0].alg)
+	for _, builder := range builders {
+		if keyenc.IsHPKEIntegrated(builder.alg) && len(builders) != 1 {
+			return nil, fmt.Errorf(`HPKE integrated encryption (%s) only supports a single recipient`, builder.alg)
+		}
+	}
+	if integrated && setContentEncryption {
+		return nil, fmt.Errorf(`content encryption algorithm cannot be specified for HPKE integrated encryption (%s)`, builders[0].alg)
+	}
+
+	if !setKeyStrengthPolicy {
+		ksp = getGlobalKeyStrengthPolicy()
+	}
+	if !integrated {
+		if err := keystrength.CheckContentEncryption(ksp, calg); err != nil {
+			return nil, fmt.Errorf(`content encryption algorithm %q: %w`, calg, err)
+		}
+	}
+	var useSenderKey bool
+	for i, builder := range builders {
+		if err := keystrength.Check(ksp, builder.alg, builder.key); err != nil {
+			return nil, fmt.Errorf(`key for recipient #%d (alg=%s): %w`, i, builder.alg, err)
+		}
+		switch builder.alg {
+		case jwa.ECDH_1PU, jwa.ECDH_1PU_A128KW, jwa.ECDH_1PU_A192KW, jwa.ECDH_1PU_A256KW:
+			if senderKey != nil {
+				if err := keystrength.Check(ksp, builder.alg, senderKey); err != nil {
+					return nil, fmt.Errorf(`sender key for recipient #%d (alg=%s): %w`, i, builder.alg, err)
+				}
+			}
+			builder.senderKey = senderKey
+			useSenderKey = true
 		}
 	}
 
 	// There is exactly one content encrypter.
-	contentcrypt, err := content_crypt.NewGeneric(calg)
-	if err != nil {
-		return nil, fmt.Errorf(`jwe.Encrypt: failed to create AES encrypter: %w`, err)
+	var contentcrypt ContentCipher
+	if integrated {
+		c, err := newHPKEContentCipher(builders[0].alg)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to create content encrypter: %w`, err)
+		}
+		contentcrypt = c
+	} else {
+		c, err := newContentCipher(calg)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to create content encrypter: %w`, err)
+		}
+		contentcrypt = c
 	}
 
 	generator := keygen.NewRandom(contentcrypt.KeySize())
 	bk, err := generator.Generate()
 	if err != nil {
-		return nil, fmt.Errorf(`jwe.Encrypt: failed to generate key: %w`, err)
+		return nil, fmt.Errorf(`failed to generate key: %w`, err)
 	}
 	cek := bk.Bytes()
 
 	recipients := make([]Recipient, len(builders))
+	var pending []pendingRecipient
 	for i, builder := range builders {
 		// some builders require hint from the contentcrypt object
 		r, rawCEK, err := builder.Build(cek, calg, contentcrypt)
 		if err != nil {
-			return nil, fmt.Errorf(`jwe.Encrypt: failed to create recipient #%d: %w`, i, err)
+			return nil, fmt.Errorf(`failed to create recipient #%d: %w`, i, err)
 		}
 		recipients[i] = r
+		if builder.wrapper != nil {
+			pending = append(pending, pendingRecipient{recipient: r, wrapper: builder.wrapper})
+		}
 
-		// Kinda feels weird, but if useRawCEK == true, we asserted earlier
-		// that len(builders) == 1, so this is OK
-		if useRawCEK {
+		// Algorithms such as ECDH-ES and DIRECT determine the CEK by
+		// themselves, which means that the CEK can't be shared with
+		// other recipients
+		if rawCEK != nil {
+			if len(builders) != 1 {
+				return nil, fmt.Errorf(`multiple recipients for ECDH-ES/DIRECT mode supported`)
+			}
 			cek = rawCEK
 		}
 	}
@@ -333,17 +653,30 @@ func Encrypt(payload []byte, options ...EncryptOption) ([]byte, error) {
 		protected = NewHeaders()
 	}
 
-	if err := protected.Set(ContentEncryptionKey, calg); err != nil {
-		return nil, fmt.Errorf(`jwe.Encrypt: failed to set "enc" in protected header: %w`, err)
+	if !integrated {
+		if err := protected.Set(ContentEncryptionKey, calg); err != nil {
+			return nil, fmt.Errorf(`failed to set "enc" in protected header: %w`, err)
+		}
+	}
+
+	// The key ID of the sender's static key is carried in the "skid" header
+	if key, ok := senderKey.(jwk.Key); ok && useSenderKey {
+		if _, ok := protected.Get(SenderKeyIDKey); !ok && key.KeyID() != "" {
+			if err := protected.Set(SenderKeyIDKey, key.KeyID()); err != nil {
+				return nil, fmt.Errorf(`failed to set "skid" in protected header: %w`, err)
+			}
+		}
 	}
 
+	var compressor Compressor
 	if compression != jwa.NoCompress {
-		payload, err = compress(payload)
+		c, err := lookupCompressor(compression)
 		if err != nil {
-			return nil, fmt.Errorf(`jwe.Encrypt: failed to compress payload before encryption: %w`, err)
+			return nil, err
 		}
+		compressor = c
 		if err := protected.Set(CompressionKey, compression); err != nil {
-			return nil, fmt.Errorf(`jwe.Encrypt: failed to set "zip" in protected header: %w`, err)
+			return nil, fmt.Errorf(`failed to set "zip" in protected header: %w`, err)
 		}
 	}
 
@@ -352,7 +685,7 @@ func Encrypt(payload []byte, options ...EncryptOption) ([]byte, error) {
 	if len(recipients) == 1 {
 		h, err := protected.Merge(context.TODO(), recipients[0].Headers())
 		if err != nil {
-			return nil, fmt.Errorf(`jwe.Encrypt: failed to merge protected headers: %w`, err)
+			return nil, fmt.Errorf(`failed to merge protected headers: %w`, err)
 		}
 		protected = h
 	}
@@ -362,39 +695,32 @@ func Encrypt(payload []byte, options ...EncryptOption) ([]byte, error) {
 		return nil, fmt.Errorf(`failed to base64 encode protected headers: %w`, err)
 	}
 
-	iv, ciphertext, tag, err := contentcrypt.Encrypt(cek, payload, aad)
-	if err != nil {
-		return nil, fmt.Errorf(`failed to encrypt payload: %w`, err)
-	}
+	return &encryptCtx{
+		format:       format,
+		compression:  compression,
+		compressor:   compressor,
+		contentcrypt: contentcrypt,
+		cek:          cek,
+		protected:    protected,
+		recipients:   recipients,
+		aad:          aad,
+		pending:      pending,
+	}, nil
+}
 
+// newMessage creates a message without the ciphertext and the tag
+func (ectx *encryptCtx) newMessage(iv []byte) (*Message, error) {
 	msg := NewMessage()
-
-	if err := msg.Set(CipherTextKey, ciphertext); err != nil {
-		return nil, fmt.Errorf(`failed to set %s: %w`, CipherTextKey, err)
-	}
 	if err := msg.Set(InitializationVectorKey, iv); err != nil {
 		return nil, fmt.Errorf(`failed to set %s: %w`, InitializationVectorKey, err)
 	}
-	if err := msg.Set(ProtectedHeadersKey, protected); err != nil {
+	if err := msg.Set(ProtectedHeadersKey, ectx.protected); err != nil {
 		return nil, fmt.Errorf(`failed to set %s: %w`, ProtectedHeadersKey, err)
 	}
-	if err := msg.Set(RecipientsKey, recipients); err != nil {
+	if err := msg.Set(RecipientsKey, ectx.recipients); err != nil {
 		return nil, fmt.Errorf(`failed to set %s: %w`, RecipientsKey, err)
 	}
-	if err := msg.Set(TagKey, tag); err != nil {
-		return nil, fmt.Errorf(`failed to set %s: %w`, TagKey, err)
-	}
-
-	switch format {
-	case fmtCompact:
-		return Compact(msg)
-	case fmtJSON:
-		return json.Marshal(msg)
-	case fmtJSONPretty:
-		return json.MarshalIndent(msg, "", "  ")
-	default:
-		return nil, fmt.Errorf(`jwe.Encrypt: invalid serialization`)
-	}
+	return msg, nil
 }
 
 type decryptCtx struct {
@@ -402,7 +728,23 @@ type decryptCtx struct {
 	aad              []byte
 	computedAad      []byte
 	keyProviders     []KeyProvider
+	policies         algorithmPolicies
+	keyStrength      *jwa.KeyStrengthPolicy
 	protectedHeaders Headers
+	recipients       []Recipient
+	keyUsed          interface{}
+	dst              *Message
+	senderKey        interface{}
+	limits           decryptLimits
+
+	// streamLimits holds the limits that were passed to
+	// `jwe.DecryptStream()` itself
+	streamLimits decryptLimits
+
+	// ciphertextStorage and releaseUnverified are only used by
+	// `jwe.DecryptStream()`
+	ciphertextStorage io.ReadWriter
+	releaseUnverified bool
 }
 
 // Decrypt takes the key encryption algorithm and the corresponding
@@ -414,51 +756,162 @@ type decryptCtx struct {
 // `jwa.KeyEncryptionAlgorithm` or otherwise it will cause an error.
 //
 // `key` must be a private key. It can be either in its raw format (e.g. *rsa.PrivateKey) or a jwk.Key
+//
+// Messages, recipients, and keys whose algorithms are rejected by the
+// `jwa.AlgorithmPolicy` in effect (see `jwe.Settings()`,
+// `jwe.WithAlgorithmPolicy()`, and `jwe.WithAllowedAlgorithms()`) are
+// rejected before any decryption takes place. Use
+// `jwa.ErrAlgorithmNotAllowed()` to detect this case. Keys that are
+// rejected by the `jwa.KeyStrengthPolicy` in effect (see
+// `jwe.WithKeyStrengthPolicy()`) are not used either. Use
+// `jwa.ErrKeyTooSmall()` and `jwa.ErrCurveNotAllowed()` to detect this case.
+//
+// To protect against messages crafted to exhaust CPU or memory, Decrypt
+// limits the size of the ciphertext, the number of recipients, the PBES2
+// iteration count, and the size of the decompressed payload. The defaults
+// can be changed using `jwe.WithMaxCiphertextSize()`, `jwe.WithMaxRecipients()`,
+// `jwe.WithMaxPBES2Count()`, and `jwe.WithMaxDecompressedSize()`.
 func Decrypt(buf []byte, options ...DecryptOption) ([]byte, error) {
-	var keyProviders []KeyProvider
-	var keyUsed interface{}
+	dctx, err := newDecryptCtx(options)
+	if err != nil {
+		return nil, fmt.Errorf(`jwe.Decrypt: %w`, err)
+	}
+
+	// Reject messages that are too large before spending any time
+	// or memory parsing them. This check is approximate, and the
+	// exact size of the ciphertext is checked after parsing
+	if err := dctx.limits.checkMessageSize(int64(len(buf))); err != nil {
+		return nil, fmt.Errorf(`jwe.Decrypt: %w`, err)
+	}
+
+	msg, err := parseJSONOrCompact(buf, true)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to parse buffer for Decrypt: %w`, err)
+	}
+
+	if err := dctx.limits.checkCiphertextSize(int64(len(msg.cipherText))); err != nil {
+		return nil, fmt.Errorf(`jwe.Decrypt: %w`, err)
+	}
+
+	if err := dctx.setMessage(msg); err != nil {
+		return nil, fmt.Errorf(`jwe.Decrypt: %w`, err)
+	}
+
+	ctx := context.TODO()
+	var lastError error
+	for _, recipient := range dctx.recipients {
+		decrypted, err := dctx.try(ctx, recipient, dctx.decryptKey)
+		if err != nil {
+			lastError = err
+			continue
+		}
+		dctx.populateMessage()
+		return decrypted, nil
+	}
+	return nil, fmt.Errorf(`jwe.Decrypt: failed to decrypt any of the recipients (last error = %w)`, lastError)
+}
+
+// newDecryptCtx processes the options given to `jwe.Decrypt()` or
+// `jwe.DecryptStream()`
+func newDecryptCtx(options []DecryptOption) (*decryptCtx, error) {
+	dctx := decryptCtx{limits: getGlobalDecryptLimits()}
+	var policy *jwa.AlgorithmPolicy
+	var setPolicy bool
+	allowRSA1_5 := getGlobalAllowRSA1_5()
+	var setKeyStrengthPolicy bool
 
-	var dst *Message
 	//nolint:forcetypeassert
 	for _, option := range options {
 		switch option.Ident() {
 		case identMessage{}:
-			dst = option.Value().(*Message)
+			dctx.dst = option.Value().(*Message)
+		case identAlgorithmPolicy{}:
+			policy = option.Value().(*jwa.AlgorithmPolicy)
+			setPolicy = true
+		case identAllowRSA1_5{}:
+			allowRSA1_5 = option.Value().(bool)
+		case identAllowedAlgorithms{}:
+			dctx.policies = append(dctx.policies, option.Value().(*jwa.AlgorithmPolicy))
+		case identKeyStrengthPolicy{}:
+			dctx.keyStrength = option.Value().(*jwa.KeyStrengthPolicy)
+			setKeyStrengthPolicy = true
 		case identKeyProvider{}:
-			keyProviders = append(keyProviders, option.Value().(KeyProvider))
+			dctx.keyProviders = append(dctx.keyProviders, option.Value().(KeyProvider))
 		case identKeyUsed{}:
-			keyUsed = option.Value()
+			dctx.keyUsed = option.Value()
+		case identSenderPublicKey{}:
+			dctx.senderKey = option.Value()
+		case identCiphertextStorage{}:
+			dctx.ciphertextStorage = option.Value().(io.ReadWriter)
+		case identReleaseUnverifiedPlaintext{}:
+			dctx.releaseUnverified = option.Value().(bool)
+		case identMaxDecompressedSize{}:
+			dctx.limits.decompressedSize = option.Value().(int64)
+			dctx.streamLimits.decompressedSize = dctx.limits.decompressedSize
+		case identMaxPBES2Count{}:
+			dctx.limits.pbes2Count = option.Value().(int64)
+		case identMaxRecipients{}:
+			dctx.limits.recipients = option.Value().(int)
+		case identMaxCiphertextSize{}:
+			dctx.limits.ciphertextSize = option.Value().(int64)
+			dctx.streamLimits.ciphertextSize = dctx.limits.ciphertextSize
 		case identKey{}:
 			pair := option.Value().(*withKey)
 			alg, ok := pair.alg.(jwa.KeyEncryptionAlgorithm)
 			if !ok {
 				return nil, fmt.Errorf(`WithKey() option must be specified using jwa.KeyEncryptionAlgorithm (got %T)`, pair.alg)
 			}
-			keyProviders = append(keyProviders, &staticKeyProvider{
+			dctx.keyProviders = append(dctx.keyProviders, &staticKeyProvider{
 				alg: alg,
 				key: pair.key,
 			})
 		}
 	}
 
-	if len(keyProviders) < 1 {
-		return nil, fmt.Errorf(`jwe.Decrypt: no key providers have been provided (see jwe.WithKey(), jwe.WithKeySet(), and jwe.WithKeyProvider()`)
+	if len(dctx.keyProviders) < 1 {
+		return nil, fmt.Errorf(`no key providers have been provided (see jwe.WithKey(), jwe.WithKeySet(), and jwe.WithKeyProvider()`)
 	}
 
-	msg, err := parseJSONOrCompact(buf, true)
-	if err != nil {
-		return nil, fmt.Errorf(`failed to parse buffer for Decrypt: %w`, err)
+	// A policy passed to jwe.Decrypt() replaces the global policy
+	if !setPolicy {
+		policy = getGlobalPolicy()
+	}
+	dctx.policies = append(dctx.policies, policy)
+	if !allowRSA1_5 {
+		dctx.policies = append(dctx.policies, denyRSA1_5)
+	}
+	if !setKeyStrengthPolicy {
+		dctx.keyStrength = getGlobalKeyStrengthPolicy()
+	}
+	return &dctx, nil
+}
+
+// setMessage checks the message against the policies, and prepares
+// the values that are common to all recipients
+func (dctx *decryptCtx) setMessage(msg *Message) error {
+	// "enc" is absent in HPKE integrated encryption. For other algorithms,
+	// the lack of "enc" is reported when the content cipher is created
+	if enc := msg.protectedHeaders.ContentEncryption(); enc != "" {
+		if err := dctx.policies.Check(enc); err != nil {
+			return fmt.Errorf(`invalid "enc" header: %w`, err)
+		}
+		if err := keystrength.CheckContentEncryption(dctx.keyStrength, enc); err != nil {
+			return fmt.Errorf(`invalid "enc" header: %w`, err)
+		}
+	}
+	if err := dctx.limits.checkRecipients(len(msg.recipients)); err != nil {
+		return err
 	}
 
 	// Process things that are common to the message
 	ctx := context.TODO()
 	h, err := msg.protectedHeaders.Clone(ctx)
 	if err != nil {
-		return nil, fmt.Errorf(`failed to copy protected headers: %w`, err)
+		return fmt.Errorf(`failed to copy protected headers: %w`, err)
 	}
 	h, err = h.Merge(ctx, msg.unprotectedHeaders)
 	if err != nil {
-		return nil, fmt.Errorf(`failed to merge headers for message decryption: %w`, err)
+		return fmt.Errorf(`failed to merge headers for message decryption: %w`, err)
 	}
 
 	var aad []byte
@@ -474,7 +927,7 @@ func Decrypt(buf []byte, options ...DecryptOption) ([]byte, error) {
 		var err error
 		computedAad, err = msg.protectedHeaders.Encode()
 		if err != nil {
-			return nil, fmt.Errorf(`failed to encode protected headers: %w`, err)
+			return fmt.Errorf(`failed to encode protected headers: %w`, err)
 		}
 	}
 
@@ -484,37 +937,38 @@ func Decrypt(buf []byte, options ...DecryptOption) ([]byte, error) {
 	if len(recipients) == 0 {
 		r := NewRecipient()
 		if err := r.SetHeaders(msg.protectedHeaders); err != nil {
-			return nil, fmt.Errorf(`failed to set headers to recipient: %w`, err)
+			return fmt.Errorf(`failed to set headers to recipient: %w`, err)
 		}
 		recipients = append(recipients, r)
 	}
 
-	var dctx decryptCtx
-
 	dctx.aad = aad
 	dctx.computedAad = computedAad
 	dctx.msg = msg
-	dctx.keyProviders = keyProviders
 	dctx.protectedHeaders = h
+	dctx.recipients = recipients
+	return nil
+}
 
-	var lastError error
-	for _, recipient := range recipients {
-		decrypted, err := dctx.try(ctx, recipient, keyUsed)
-		if err != nil {
-			lastError = err
-			continue
-		}
-		if dst != nil {
-			*dst = *msg
-			dst.rawProtectedHeaders = nil
-			dst.storeProtectedHeaders = false
-		}
-		return decrypted, nil
+// populateMessage copies the decrypted message to the destination
+// specified by `jwe.WithMessage()`
+func (dctx *decryptCtx) populateMessage() {
+	if dst := dctx.dst; dst != nil {
+		*dst = *dctx.msg
+		dst.rawProtectedHeaders = nil
+		dst.storeProtectedHeaders = false
 	}
-	return nil, fmt.Errorf(`jwe.Decrypt: failed to decrypt any of the recipients (last error = %w)`, lastError)
 }
 
-func (dctx *decryptCtx) try(ctx context.Context, recipient Recipient, keyUsed interface{}) ([]byte, error) {
+// decryptFunc decrypts the recipient using the given key. It is
+// called by `(*decryptCtx).try()` for each key until one succeeds
+type decryptFunc func(ctx context.Context, alg jwa.KeyEncryptionAlgorithm, key interface{}, recipient Recipient) ([]byte, error)
+
+func (dctx *decryptCtx) try(ctx context.Context, recipient Recipient, decrypt decryptFunc) ([]byte, error) {
+	if err := dctx.policies.Check(dctx.recipientAlgorithm(recipient)); err != nil {
+		return nil, fmt.Errorf(`invalid "alg" header: %w`, err)
+	}
+
 	var tried int
 	var lastError error
 	for i, kp := range dctx.keyProviders {
@@ -530,30 +984,80 @@ func (dctx *decryptCtx) try(ctx context.Context, recipient Recipient, keyUsed in
 			// structs for `alg jwa.KeyAlgorithm` and `alg jwa.SignatureAlgorithm`
 			//nolint:forcetypeassert
 			alg := pair.alg.(jwa.KeyEncryptionAlgorithm)
+			if err := dctx.policies.Check(alg); err != nil {
+				lastError = err
+				continue
+			}
 			key := pair.key
+			if err := keystrength.Check(dctx.keyStrength, alg, key); err != nil {
+				lastError = err
+				continue
+			}
 
-			decrypted, err := dctx.decryptKey(ctx, alg, key, recipient)
+			decrypted, err := decrypt(ctx, alg, key, recipient)
 			if err != nil {
 				lastError = err
 				continue
 			}
 
-			if keyUsed != nil {
-				if err := blackmagic.AssignIfCompatible(keyUsed, key); err != nil {
-					return nil, fmt.Errorf(`failed to assign used key (%T) to %T: %w`, key, keyUsed, err)
+			if dctx.keyUsed != nil {
+				if err := blackmagic.AssignIfCompatible(dctx.keyUsed, key); err != nil {
+					return nil, fmt.Errorf(`failed to assign used key (%T) to %T: %w`, key, dctx.keyUsed, err)
 				}
 			}
 			return decrypted, nil
 		}
 	}
-	return nil, fmt.Errorf(`jwe.Decrypt: tried %d keys, but failed to match any of the keys with recipient (last error = %s)`, tried, lastError)
+	return nil, fmt.Errorf(`tried %d keys, but failed to match any of the keys with recipient (last error = %w)`, tried, lastError)
 }
 
 func (dctx *decryptCtx) decryptKey(ctx context.Context, alg jwa.KeyEncryptionAlgorithm, key interface{}, recipient Recipient) ([]byte, error) {
+	dec, h2, err := dctx.newDecrypter(ctx, alg, key, recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := dec.Decrypt(recipient.EncryptedKey(), dctx.msg.cipherText)
+	if err != nil {
+		return nil, fmt.Errorf(`jwe.Decrypt: decryption failed: %w`, err)
+	}
+
+	if zip := h2.Compression(); zip != jwa.NoCompress {
+		c, err := lookupCompressor(zip)
+		if err != nil {
+			return nil, fmt.Errorf(`jwe.Decrypt: %w`, err)
+		}
+		buf, err := uncompress(c, plaintext, dctx.limits.decompressedSize)
+		if err != nil {
+			return nil, fmt.Errorf(`jwe.Derypt: failed to uncompress payload: %w`, err)
+		}
+		plaintext = buf
+	}
+
+	if plaintext == nil {
+		return nil, fmt.Errorf(`failed to find matching recipient`)
+	}
+
+	return plaintext, nil
+}
+
+// recipientAlgorithm returns the key encryption algorithm of the recipient.
+// "alg" may also be specified in the headers that are shared by all
+// recipients (RFC7516 Section 7.2.1)
+func (dctx *decryptCtx) recipientAlgorithm(recipient Recipient) jwa.KeyEncryptionAlgorithm {
+	if alg := recipient.Headers().Algorithm(); alg != "" {
+		return alg
+	}
+	return dctx.protectedHeaders.Algorithm()
+}
+
+// newDecrypter creates a decrypter for the recipient using the given key.
+// It also returns the headers for the recipient
+func (dctx *decryptCtx) newDecrypter(ctx context.Context, alg jwa.KeyEncryptionAlgorithm, key interface{}, recipient Recipient) (*decrypter, Headers, error) {
 	if jwkKey, ok := key.(jwk.Key); ok {
 		var raw interface{}
 		if err := jwkKey.Raw(&raw); err != nil {
-			return nil, fmt.Errorf(`failed to retrieve raw key from %T: %w`, key, err)
+			return nil, nil, fmt.Errorf(`failed to retrieve raw key from %T: %w`, key, err)
 		}
 		key = raw
 	}
@@ -564,42 +1068,123 @@ func (dctx *decryptCtx) decryptKey(ctx context.Context, alg jwa.KeyEncryptionAlg
 		InitializationVector(dctx.msg.initializationVector).
 		Tag(dctx.msg.tag)
 
-	if recipient.Headers().Algorithm() != alg {
+	if dctx.recipientAlgorithm(recipient) != alg {
 		// algorithms don't match
-		return nil, fmt.Errorf(`jwe.Decrypt: key and recipient algorithms do not match`)
+		return nil, nil, fmt.Errorf(`key and recipient algorithms do not match`)
+	}
+
+	if keyenc.IsHPKEIntegrated(alg) {
+		if dctx.msg.protectedHeaders.ContentEncryption() != "" {
+			return nil, nil, fmt.Errorf(`"enc" header must not be present in HPKE integrated encryption (%s)`, alg)
+		}
+		cc, err := newHPKEContentCipher(alg)
+		if err != nil {
+			return nil, nil, err
+		}
+		dec.cipher = cc
 	}
 
 	h2, err := dctx.protectedHeaders.Clone(ctx)
 	if err != nil {
-		return nil, fmt.Errorf(`jwe.Decrypt: failed to copy headers (1): %w`, err)
+		return nil, nil, fmt.Errorf(`failed to copy headers (1): %w`, err)
 	}
 
 	h2, err = h2.Merge(ctx, recipient.Headers())
 	if err != nil {
-		return nil, fmt.Errorf(`failed to copy headers (2): %w`, err)
+		return nil, nil, fmt.Errorf(`failed to copy headers (2): %w`, err)
+	}
+
+	if f, ok := lookupKeyDecrypter(alg); ok {
+		kd, err := f.Create()
+		if err != nil {
+			return nil, nil, fmt.Errorf(`failed to create key decrypter for %s: %w`, alg, err)
+		}
+		dec.KeyDecrypter(kd, h2)
+	} else if err := setKeyDecryptionParams(dec, alg, h2, dctx.limits); err != nil {
+		return nil, nil, err
 	}
 
 	switch alg {
-	case jwa.ECDH_ES, jwa.ECDH_ES_A128KW, jwa.ECDH_ES_A192KW, jwa.ECDH_ES_A256KW:
+	case jwa.ECDH_1PU, jwa.ECDH_1PU_A128KW, jwa.ECDH_1PU_A192KW, jwa.ECDH_1PU_A256KW:
+		senderKey, err := dctx.senderPublicKey(h2)
+		if err != nil {
+			return nil, nil, err
+		}
+		dec.SenderPublicKey(senderKey)
+	}
+
+	return dec, h2, nil
+}
+
+// senderPublicKey returns the static public key of the sender specified
+// using `jwe.WithSenderPublicKey()`, as required by ECDH-1PU
+func (dctx *decryptCtx) senderPublicKey(h Headers) (interface{}, error) {
+	skid := h.SenderKeyID()
+	var key interface{}
+	switch v := dctx.senderKey.(type) {
+	case nil:
+		return nil, fmt.Errorf(`ECDH-1PU requires the static public key of the sender (see jwe.WithSenderPublicKey())`)
+	case jwk.Set:
+		if skid == "" {
+			return nil, fmt.Errorf(`"skid" header is required to look up the sender key from a jwk.Set`)
+		}
+		found, ok := v.LookupKeyID(skid)
+		if !ok {
+			return nil, fmt.Errorf(`sender key with "skid" %q not found`, skid)
+		}
+		key = found
+	case jwk.Key:
+		if kid := v.KeyID(); kid != "" && skid != "" && kid != skid {
+			return nil, fmt.Errorf(`sender key ID %q does not match "skid" header %q`, kid, skid)
+		}
+		key = v
+	default:
+		key = v
+	}
+
+	raw, err := jwk.PublicRawKeyOf(key)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to retrieve sender public key: %w`, err)
+	}
+
+	switch raw := raw.(type) {
+	case x25519.PublicKey, x448.PublicKey:
+		return raw, nil
+	default:
+		var pubkey ecdsa.PublicKey
+		if err := keyconv.ECDSAPublicKey(&pubkey, raw); err != nil {
+			return nil, fmt.Errorf(`invalid sender public key (%T): %w`, raw, err)
+		}
+		return &pubkey, nil
+	}
+}
+
+// setKeyDecryptionParams extracts the algorithm specific parameters
+// required to decrypt the key from the headers, and sets them to
+// the decrypter
+func setKeyDecryptionParams(dec *decrypter, alg jwa.KeyEncryptionAlgorithm, h2 Headers, limits decryptLimits) error {
+	switch alg {
+	case jwa.ECDH_ES, jwa.ECDH_ES_A128KW, jwa.ECDH_ES_A192KW, jwa.ECDH_ES_A256KW, jwa.ECDH_ES_XC20PKW,
+		jwa.ECDH_1PU, jwa.ECDH_1PU_A128KW, jwa.ECDH_1PU_A192KW, jwa.ECDH_1PU_A256KW:
 		epkif, ok := h2.Get(EphemeralPublicKeyKey)
 		if !ok {
-			return nil, fmt.Errorf(`failed to get 'epk' field`)
+			return fmt.Errorf(`failed to get 'epk' field`)
 		}
 		switch epk := epkif.(type) {
 		case jwk.ECDSAPublicKey:
 			var pubkey ecdsa.PublicKey
 			if err := epk.Raw(&pubkey); err != nil {
-				return nil, fmt.Errorf(`failed to get public key: %w`, err)
+				return fmt.Errorf(`failed to get public key: %w`, err)
 			}
 			dec.PublicKey(&pubkey)
 		case jwk.OKPPublicKey:
 			var pubkey interface{}
 			if err := epk.Raw(&pubkey); err != nil {
-				return nil, fmt.Errorf(`failed to get public key: %w`, err)
+				return fmt.Errorf(`failed to get public key: %w`, err)
 			}
 			dec.PublicKey(pubkey)
 		default:
-			return nil, fmt.Errorf("unexpected 'epk' type %T for alg %s", epkif, alg)
+			return fmt.Errorf("unexpected 'epk' type %T for alg %s", epkif, alg)
 		}
 
 		if apu := h2.AgreementPartyUInfo(); len(apu) > 0 {
@@ -608,77 +1193,91 @@ func (dctx *decryptCtx) decryptKey(ctx context.Context, alg jwa.KeyEncryptionAlg
 		if apv := h2.AgreementPartyVInfo(); len(apv) > 0 {
 			dec.AgreementPartyVInfo(apv)
 		}
-	case jwa.A128GCMKW, jwa.A192GCMKW, jwa.A256GCMKW:
-		ivB64, ok := h2.Get(InitializationVectorKey)
-		if !ok {
-			return nil, fmt.Errorf(`failed to get 'iv' field`)
-		}
-		ivB64Str, ok := ivB64.(string)
-		if !ok {
-			return nil, fmt.Errorf("unexpected type for 'iv': %T", ivB64)
+		if alg == jwa.ECDH_ES_XC20PKW {
+			return setKeyIVAndTag(dec, h2)
 		}
-		tagB64, ok := h2.Get(TagKey)
-		if !ok {
-			return nil, fmt.Errorf(`failed to get 'tag' field`)
+	case jwa.A128GCMKW, jwa.A192GCMKW, jwa.A256GCMKW, jwa.XC20PKW:
+		return setKeyIVAndTag(dec, h2)
+	case jwa.HPKE_0_KE, jwa.HPKE_1_KE, jwa.HPKE_2_KE, jwa.HPKE_3_KE, jwa.HPKE_4_KE:
+		ek := h2.EncapsulatedKey()
+		if len(ek) == 0 {
+			return fmt.Errorf(`failed to get 'ek' field`)
 		}
-		tagB64Str, ok := tagB64.(string)
-		if !ok {
-			return nil, fmt.Errorf("unexpected type for 'tag': %T", tagB64)
+		dec.EncapsulatedKey(ek)
+	case jwa.MLKEM768, jwa.MLKEM768_A192KW, jwa.MLKEM1024, jwa.MLKEM1024_A256KW:
+		ek := h2.EncapsulatedKey()
+		if len(ek) == 0 {
+			return fmt.Errorf(`failed to get 'ek' field`)
 		}
-		iv, err := base64.DecodeString(ivB64Str)
-		if err != nil {
-			return nil, fmt.Errorf(`failed to b64-decode 'iv': %w`, err)
+		dec.EncapsulatedKey(ek)
+
+		if apu := h2.AgreementPartyUInfo(); len(apu) > 0 {
+			dec.AgreementPartyUInfo(apu)
 		}
-		tag, err := base64.DecodeString(tagB64Str)
-		if err != nil {
-			return nil, fmt.Errorf(`failed to b64-decode 'tag': %w`, err)
+		if apv := h2.AgreementPartyVInfo(); len(apv) > 0 {
+			dec.AgreementPartyVInfo(apv)
 		}
-		dec.KeyInitializationVector(iv)
-		dec.KeyTag(tag)
 	case jwa.PBES2_HS256_A128KW, jwa.PBES2_HS384_A192KW, jwa.PBES2_HS512_A256KW:
 		saltB64, ok := h2.Get(SaltKey)
 		if !ok {
-			return nil, fmt.Errorf(`failed to get 'p2s' field`)
+			return fmt.Errorf(`failed to get 'p2s' field`)
 		}
 		saltB64Str, ok := saltB64.(string)
 		if !ok {
-			return nil, fmt.Errorf("unexpected type for 'p2s': %T", saltB64)
+			return fmt.Errorf("unexpected type for 'p2s': %T", saltB64)
 		}
 
 		count, ok := h2.Get(CountKey)
 		if !ok {
-			return nil, fmt.Errorf(`failed to get 'p2c' field`)
+			return fmt.Errorf(`failed to get 'p2c' field`)
 		}
 		countFlt, ok := count.(float64)
 		if !ok {
-			return nil, fmt.Errorf("unexpected type for 'p2c': %T", count)
+			return fmt.Errorf("unexpected type for 'p2c': %T", count)
+		}
+		if err := limits.checkPBES2Count(countFlt); err != nil {
+			return err
 		}
 		salt, err := base64.DecodeString(saltB64Str)
 		if err != nil {
-			return nil, fmt.Errorf(`failed to b64-decode 'salt': %w`, err)
+			return fmt.Errorf(`failed to b64-decode 'salt': %w`, err)
 		}
 		dec.KeySalt(salt)
 		dec.KeyCount(int(countFlt))
 	}
+	return nil
+}
 
-	plaintext, err := dec.Decrypt(recipient.EncryptedKey(), dctx.msg.cipherText)
-	if err != nil {
-		return nil, fmt.Errorf(`jwe.Decrypt: decryption failed: %w`, err)
+// setKeyIVAndTag extracts the "iv" and "tag" headers used by the key
+// wrapping algorithms based on AEADs (e.g. A128GCMKW and XC20PKW)
+func setKeyIVAndTag(dec *decrypter, h2 Headers) error {
+	ivB64, ok := h2.Get(InitializationVectorKey)
+	if !ok {
+		return fmt.Errorf(`failed to get 'iv' field`)
 	}
-
-	if h2.Compression() == jwa.Deflate {
-		buf, err := uncompress(plaintext)
-		if err != nil {
-			return nil, fmt.Errorf(`jwe.Derypt: failed to uncompress payload: %w`, err)
-		}
-		plaintext = buf
+	ivB64Str, ok := ivB64.(string)
+	if !ok {
+		return fmt.Errorf("unexpected type for 'iv': %T", ivB64)
 	}
-
-	if plaintext == nil {
-		return nil, fmt.Errorf(`failed to find matching recipient`)
+	tagB64, ok := h2.Get(TagKey)
+	if !ok {
+		return fmt.Errorf(`failed to get 'tag' field`)
 	}
-
-	return plaintext, nil
+	tagB64Str, ok := tagB64.(string)
+	if !ok {
+		return fmt.Errorf("unexpected type for 'tag': %T", tagB64)
+	}
+	iv, err := base64.DecodeString(ivB64Str)
+	if err != nil {
+		return fmt.Errorf(`failed to b64-decode 'iv': %w`, err)
+	}
+	tag, err := base64.DecodeString(tagB64Str)
+	if err != nil {
+		return fmt.Errorf(`failed to b64-decode 'tag': %w`, err)
+	}
+	dec.KeyInitializationVector(iv)
+	dec.KeyTag(tag)
+	return nil
 }
 
 // Parse parses the JWE message into a Message object. The JWE message
@@ -1,14 +1,20 @@
 package jwe_test
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"testing"
@@ -17,12 +23,15 @@ import (
 	"github.com/sjwl/jwx/v2/internal/json"
 	"github.com/sjwl/jwx/v2/internal/jwxtest"
 
+	"github.com/sjwl/jwx/v2/internal/mlkem"
 	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/sjwl/jwx/v2/jwe"
 	"github.com/sjwl/jwx/v2/jwk"
 	"github.com/sjwl/jwx/v2/x25519"
+	"github.com/sjwl/jwx/v2/x448"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/curve25519"
 )
 
 const (
@@ -356,7 +365,7 @@ func TestRoundtrip_RSA1_5_A128CBC_HS256(t *testing.T) {
 			return
 		}
 
-		decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA1_5, rsaPrivKey))
+		decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA1_5, rsaPrivKey), jwe.WithAllowRSA1_5(true))
 		if !assert.NoError(t, err, "Decrypt successful") {
 			return
 		}
@@ -367,6 +376,33 @@ func TestRoundtrip_RSA1_5_A128CBC_HS256(t *testing.T) {
 	}
 }
 
+func TestRoundtrip_RSA_OAEP_SHA2(t *testing.T) {
+	for _, alg := range []jwa.KeyEncryptionAlgorithm{jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512} {
+		alg := alg
+		t.Run(alg.String(), func(t *testing.T) {
+			for _, enc := range []jwa.ContentEncryptionAlgorithm{jwa.A128GCM, jwa.A256GCM, jwa.A256CBC_HS512} {
+				encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(alg, &rsaPrivKey.PublicKey), jwe.WithContentEncryption(enc))
+				require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+				decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(alg, rsaPrivKey))
+				require.NoError(t, err, `jwe.Decrypt should succeed`)
+				require.Equal(t, examplePayload, string(decrypted))
+			}
+		})
+	}
+	t.Run("algorithm mismatch", func(t *testing.T) {
+		encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.RSA_OAEP_384, &rsaPrivKey.PublicKey))
+		require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+		msg, err := jwe.Parse(encrypted)
+		require.NoError(t, err, `jwe.Parse should succeed`)
+		require.Equal(t, jwa.RSA_OAEP_384, msg.ProtectedHeaders().Algorithm())
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA_OAEP_512, rsaPrivKey))
+		require.Error(t, err, `jwe.Decrypt should fail when the algorithm does not match`)
+	})
+}
+
 // https://tools.ietf.org/html/rfc7516#appendix-A.3. Note that cek is dynamically
 // generated, so the encrypted values will NOT match that of the RFC.
 func TestEncode_A128KW_A128CBC_HS256(t *testing.T) {
@@ -461,6 +497,52 @@ func TestEncode_X25519(t *testing.T) {
 	testEncodeECDHWithKey(t, privkey, pubkey)
 }
 
+func TestEncode_X448(t *testing.T) {
+	pubkey, privkey, err := x448.GenerateKey(rand.Reader)
+	require.NoError(t, err, `x448.GenerateKey should succeed`)
+
+	t.Run("Raw keys", func(t *testing.T) {
+		testEncodeECDHWithKey(t, privkey, pubkey)
+	})
+	t.Run("JWK", func(t *testing.T) {
+		privjwk, err := jwk.FromRaw(privkey)
+		require.NoError(t, err, `jwk.FromRaw should succeed`)
+		pubjwk, err := jwk.PublicKeyOf(privjwk)
+		require.NoError(t, err, `jwk.PublicKeyOf should succeed`)
+
+		for _, alg := range []jwa.KeyEncryptionAlgorithm{jwa.ECDH_ES, jwa.ECDH_ES_A128KW, jwa.ECDH_ES_A256KW} {
+			alg := alg
+			t.Run(alg.String(), func(t *testing.T) {
+				encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(alg, pubjwk))
+				require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+				msg, err := jwe.Parse(encrypted)
+				require.NoError(t, err, `jwe.Parse should succeed`)
+				epk, ok := msg.ProtectedHeaders().EphemeralPublicKey().(jwk.OKPPublicKey)
+				require.True(t, ok, `"epk" should be an OKP public key`)
+				require.Equal(t, jwa.X448, epk.Crv(), `"epk" should use X448`)
+
+				decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(alg, privjwk))
+				require.NoError(t, err, `jwe.Decrypt should succeed`)
+				require.Equal(t, examplePayload, string(decrypted), `payloads should match`)
+			})
+		}
+	})
+	t.Run("Wrong key", func(t *testing.T) {
+		_, otherKey, err := x448.GenerateKey(rand.Reader)
+		require.NoError(t, err, `x448.GenerateKey should succeed`)
+		encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.ECDH_ES_A128KW, pubkey))
+		require.NoError(t, err, `jwe.Encrypt should succeed`)
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.ECDH_ES_A128KW, otherKey))
+		require.Error(t, err, `jwe.Decrypt should fail`)
+
+		_, x25519Key, err := x25519.GenerateKey(rand.Reader)
+		require.NoError(t, err, `x25519.GenerateKey should succeed`)
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.ECDH_ES_A128KW, x25519Key))
+		require.Error(t, err, `jwe.Decrypt should fail for a key on another curve`)
+	})
+}
+
 func Test_GHIssue207(t *testing.T) {
 	const plaintext = "hi\n"
 	var testcases = []struct {
@@ -841,3 +923,1357 @@ func TestGH840(t *testing.T) {
 	_, err = jwe.Encrypt([]byte(payload), jwe.WithKey(jwa.ECDH_ES_A128KW, pubkey))
 	require.Error(t, err, `jwe.Encrypt should fail (instead of panic)`)
 }
+
+// xorKeyEncrypter is a toy key encryption algorithm used to test
+// jwe.RegisterKeyEncrypter and jwe.RegisterKeyDecrypter
+type xorKeyEncrypter struct {
+	alg jwa.KeyEncryptionAlgorithm
+}
+
+func (x *xorKeyEncrypter) Algorithm() jwa.KeyEncryptionAlgorithm {
+	return x.alg
+}
+
+func (x *xorKeyEncrypter) xor(src []byte, key interface{}) ([]byte, error) {
+	sharedkey, ok := key.([]byte)
+	if !ok || len(sharedkey) != len(src) {
+		return nil, fmt.Errorf(`invalid key`)
+	}
+	dst := make([]byte, len(src))
+	for i := range src {
+		dst[i] = src[i] ^ sharedkey[i]
+	}
+	return dst, nil
+}
+
+func (x *xorKeyEncrypter) EncryptKey(_ jwa.ContentEncryptionAlgorithm, cek []byte, key interface{}, hdrs jwe.Headers) ([]byte, []byte, error) {
+	if err := hdrs.Set(`x-custom`, `xor`); err != nil {
+		return nil, nil, err
+	}
+	enckey, err := x.xor(cek, key)
+	return enckey, nil, err
+}
+
+func (x *xorKeyEncrypter) DecryptKey(_ jwa.ContentEncryptionAlgorithm, enckey []byte, key interface{}, hdrs jwe.Headers) ([]byte, error) {
+	if v, ok := hdrs.Get(`x-custom`); !ok || v != `xor` {
+		return nil, fmt.Errorf(`missing x-custom header`)
+	}
+	return x.xor(enckey, key)
+}
+
+type countingContentCipher struct {
+	jwe.ContentCipher
+	encrypted int
+	decrypted int
+}
+
+func (c *countingContentCipher) Encrypt(cek, plaintext, aad []byte) ([]byte, []byte, []byte, error) {
+	c.encrypted++
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	iv := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return nil, nil, nil, err
+	}
+	sealed := aead.Seal(nil, iv, plaintext, aad)
+	tagOffset := len(sealed) - aead.Overhead()
+	return iv, sealed[:tagOffset], sealed[tagOffset:], nil
+}
+
+func (c *countingContentCipher) Decrypt(cek, iv, ciphertext, tag, aad []byte) ([]byte, error) {
+	c.decrypted++
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, iv, append(append([]byte(nil), ciphertext...), tag...), aad)
+}
+
+func (c *countingContentCipher) KeySize() int {
+	return 16
+}
+
+func TestRegisterAlgorithms(t *testing.T) {
+	const keyalg = jwa.KeyEncryptionAlgorithm(`X-XOR`)
+	const calg = jwa.ContentEncryptionAlgorithm(`X-A128GCM`)
+
+	jwa.RegisterKeyEncryptionAlgorithm(keyalg)
+	defer jwa.UnregisterKeyEncryptionAlgorithm(keyalg)
+	jwa.RegisterContentEncryptionAlgorithm(calg)
+	defer jwa.UnregisterContentEncryptionAlgorithm(calg)
+
+	xor := &xorKeyEncrypter{alg: keyalg}
+	jwe.RegisterKeyEncrypter(keyalg, jwe.KeyEncrypterFactoryFn(func() (jwe.KeyEncrypter, error) {
+		return xor, nil
+	}))
+	defer jwe.UnregisterKeyEncrypter(keyalg)
+	jwe.RegisterKeyDecrypter(keyalg, jwe.KeyDecrypterFactoryFn(func() (jwe.KeyDecrypter, error) {
+		return xor, nil
+	}))
+	defer jwe.UnregisterKeyDecrypter(keyalg)
+
+	cc := &countingContentCipher{}
+	jwe.RegisterContentCipher(calg, jwe.ContentCipherFactoryFn(func() (jwe.ContentCipher, error) {
+		return cc, nil
+	}))
+	defer jwe.UnregisterContentCipher(calg)
+
+	key := make([]byte, 16)
+	_, err := rand.Read(key)
+	require.NoError(t, err, `rand.Read should succeed`)
+
+	encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(keyalg, key), jwe.WithContentEncryption(calg))
+	require.NoError(t, err, `jwe.Encrypt should succeed`)
+	require.Equal(t, 1, cc.encrypted, `custom content cipher should have been used to encrypt`)
+
+	msg, err := jwe.Parse(encrypted)
+	require.NoError(t, err, `jwe.Parse should succeed`)
+	v, ok := msg.ProtectedHeaders().Get(`x-custom`)
+	require.True(t, ok, `"x-custom" should be set by the key encrypter`)
+	require.Equal(t, `xor`, v)
+
+	decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(keyalg, key))
+	require.NoError(t, err, `jwe.Decrypt should succeed`)
+	require.Equal(t, examplePayload, string(decrypted))
+	require.Equal(t, 1, cc.decrypted, `custom content cipher should have been used to decrypt`)
+
+	jwe.UnregisterKeyDecrypter(keyalg)
+	_, err = jwe.Decrypt(encrypted, jwe.WithKey(keyalg, key))
+	require.Error(t, err, `jwe.Decrypt should fail without a key decrypter`)
+}
+
+func TestAlgorithmPolicy(t *testing.T) {
+	key := make([]byte, 16)
+	_, err := rand.Read(key)
+	require.NoError(t, err, `rand.Read should succeed`)
+
+	encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.A128KW, key), jwe.WithContentEncryption(jwa.A128GCM))
+	require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+	t.Run("WithAllowedAlgorithms", func(t *testing.T) {
+		_, err := jwe.Decrypt(encrypted, jwe.WithKey(jwa.A128KW, key), jwe.WithAllowedAlgorithms(jwa.A256GCM))
+		require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed(), `"enc" should be checked`)
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.A128KW, key), jwe.WithAllowedAlgorithms(jwa.RSA_OAEP_256))
+		require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed(), `"alg" should be checked`)
+
+		decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(jwa.A128KW, key), jwe.WithAllowedAlgorithms(jwa.A128KW, jwa.A128GCM))
+		require.NoError(t, err, `jwe.Decrypt should succeed`)
+		require.Equal(t, examplePayload, string(decrypted))
+	})
+	t.Run("StrictAlgorithmPolicy", func(t *testing.T) {
+		direct, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.DIRECT, key), jwe.WithContentEncryption(jwa.A128GCM))
+		require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+		_, err = jwe.Decrypt(direct, jwe.WithKey(jwa.DIRECT, key), jwe.WithAlgorithmPolicy(jwa.StrictAlgorithmPolicy()))
+		require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed())
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.A128KW, key), jwe.WithAlgorithmPolicy(jwa.StrictAlgorithmPolicy()))
+		require.NoError(t, err, `jwe.Decrypt should succeed`)
+	})
+	t.Run("global policy", func(t *testing.T) {
+		jwe.Settings(jwe.WithAlgorithmPolicy(jwa.NewAlgorithmPolicy().Deny(jwa.A128KW)))
+		defer jwe.Settings(jwe.WithAlgorithmPolicy(nil))
+
+		_, err := jwe.Decrypt(encrypted, jwe.WithKey(jwa.A128KW, key))
+		require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed())
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.A128KW, key), jwe.WithAlgorithmPolicy(jwa.NewAlgorithmPolicy()))
+		require.NoError(t, err, `jwe.Decrypt should succeed`)
+	})
+}
+
+func TestKeyStrengthPolicy(t *testing.T) {
+	t.Run("RSA", func(t *testing.T) {
+		weak, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err, `rsa.GenerateKey should succeed`)
+
+		_, err = jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.RSA_OAEP, &weak.PublicKey), jwe.WithKeyStrengthPolicy(jwa.StrictKeyStrengthPolicy()))
+		require.ErrorIs(t, err, jwa.ErrKeyTooSmall(), `jwe.Encrypt should reject 1024 bit RSA keys`)
+
+		encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.RSA_OAEP, &weak.PublicKey))
+		require.NoError(t, err, `jwe.Encrypt should succeed without a policy`)
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA_OAEP, weak), jwe.WithKeyStrengthPolicy(jwa.StrictKeyStrengthPolicy()))
+		require.ErrorIs(t, err, jwa.ErrKeyTooSmall(), `jwe.Decrypt should reject 1024 bit RSA keys`)
+	})
+	t.Run("AES", func(t *testing.T) {
+		key := make([]byte, 16)
+		_, err := rand.Read(key)
+		require.NoError(t, err, `rand.Read should succeed`)
+
+		jwe.Settings(jwe.WithKeyStrengthPolicy(jwa.NewKeyStrengthPolicy().MinAESKeySize(256)))
+		defer jwe.Settings(jwe.WithKeyStrengthPolicy(nil))
+
+		_, err = jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.A128KW, key), jwe.WithContentEncryption(jwa.A256GCM))
+		require.ErrorIs(t, err, jwa.ErrKeyTooSmall(), `jwe.Encrypt should reject 128 bit key wrapping keys`)
+
+		_, err = jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.RSA_OAEP, &rsaPrivKey.PublicKey), jwe.WithContentEncryption(jwa.A128CBC_HS256))
+		require.ErrorIs(t, err, jwa.ErrKeyTooSmall(), `jwe.Encrypt should reject A128CBC-HS256`)
+
+		encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.A128KW, key), jwe.WithContentEncryption(jwa.A256GCM), jwe.WithKeyStrengthPolicy(nil))
+		require.NoError(t, err, `jwe.Encrypt should succeed when the global policy is overridden`)
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.A128KW, key))
+		require.ErrorIs(t, err, jwa.ErrKeyTooSmall(), `jwe.Decrypt should reject 128 bit key wrapping keys`)
+
+		decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(jwa.A128KW, key), jwe.WithKeyStrengthPolicy(nil))
+		require.NoError(t, err, `jwe.Decrypt should succeed`)
+		require.Equal(t, examplePayload, string(decrypted))
+	})
+	t.Run("curves", func(t *testing.T) {
+		key, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+
+		_, err = jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.ECDH_ES, &key.PublicKey), jwe.WithKeyStrengthPolicy(jwa.NewKeyStrengthPolicy().AllowCurves(jwa.P384, jwa.P521)))
+		require.ErrorIs(t, err, jwa.ErrCurveNotAllowed(), `jwe.Encrypt should reject P-256 keys`)
+	})
+}
+
+// hsmDecrypter is a crypto.Decrypter that does not expose its private key
+type hsmDecrypter struct {
+	key *rsa.PrivateKey
+}
+
+func (d *hsmDecrypter) Public() crypto.PublicKey {
+	return &d.key.PublicKey
+}
+
+func (d *hsmDecrypter) Decrypt(rand io.Reader, msg []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	return d.key.Decrypt(rand, msg, opts)
+}
+
+// hsmKeyAgreement is a jwe.KeyAgreement that does not expose its private key
+type hsmKeyAgreement struct {
+	key interface{}
+	err error
+}
+
+func (a *hsmKeyAgreement) Public() crypto.PublicKey {
+	switch key := a.key.(type) {
+	case *ecdsa.PrivateKey:
+		return &key.PublicKey
+	case x25519.PrivateKey:
+		return key.Public()
+	}
+	return nil
+}
+
+func (a *hsmKeyAgreement) SharedSecret(pubkey crypto.PublicKey) ([]byte, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	switch key := a.key.(type) {
+	case *ecdsa.PrivateKey:
+		//nolint:forcetypeassert
+		pub := pubkey.(*ecdsa.PublicKey)
+		x, _ := key.Curve.ScalarMult(pub.X, pub.Y, key.D.Bytes())
+		z := make([]byte, (key.Curve.Params().BitSize+7)/8)
+		return x.FillBytes(z), nil
+	case x25519.PrivateKey:
+		//nolint:forcetypeassert
+		return curve25519.X25519(key.Seed(), pubkey.(x25519.PublicKey))
+	}
+	return nil, fmt.Errorf(`unsupported key %T`, a.key)
+}
+
+func TestOpaqueDecryptionKeys(t *testing.T) {
+	t.Run("crypto.Decrypter", func(t *testing.T) {
+		for _, alg := range []jwa.KeyEncryptionAlgorithm{jwa.RSA_OAEP, jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512} {
+			alg := alg
+			t.Run(alg.String(), func(t *testing.T) {
+				encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(alg, &rsaPrivKey.PublicKey))
+				require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+				decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(alg, &hsmDecrypter{key: &rsaPrivKey}))
+				require.NoError(t, err, `jwe.Decrypt should succeed`)
+				require.Equal(t, examplePayload, string(decrypted))
+			})
+		}
+	})
+	t.Run("KeyAgreement", func(t *testing.T) {
+		ecKey, err := jwxtest.GenerateEcdsaKey(jwa.P384)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+		_, xKey, err := x25519.GenerateKey(rand.Reader)
+		require.NoError(t, err, `x25519.GenerateKey should succeed`)
+
+		keys := []struct {
+			Name    string
+			Private interface{}
+			Public  interface{}
+		}{
+			{Name: "P-384", Private: ecKey, Public: &ecKey.PublicKey},
+			{Name: "X25519", Private: xKey, Public: xKey.Public()},
+		}
+		algs := []jwa.KeyEncryptionAlgorithm{jwa.ECDH_ES, jwa.ECDH_ES_A128KW, jwa.ECDH_ES_A192KW, jwa.ECDH_ES_A256KW}
+		for _, key := range keys {
+			key := key
+			for _, alg := range algs {
+				alg := alg
+				t.Run(key.Name+"/"+alg.String(), func(t *testing.T) {
+					encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(alg, key.Public))
+					require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+					agreement := &hsmKeyAgreement{key: key.Private}
+					var used interface{}
+					decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(alg, agreement), jwe.WithKeyUsed(&used))
+					require.NoError(t, err, `jwe.Decrypt should succeed`)
+					require.Equal(t, examplePayload, string(decrypted))
+					require.Equal(t, agreement, used, `key used should be the key agreement`)
+				})
+			}
+		}
+	})
+	t.Run("KeyAgreement errors", func(t *testing.T) {
+		ecKey, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+
+		encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.ECDH_ES_A128KW, &ecKey.PublicKey))
+		require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.ECDH_ES_A128KW, &hsmKeyAgreement{key: ecKey, err: fmt.Errorf(`device unavailable`)}))
+		require.Error(t, err, `jwe.Decrypt should fail when the key agreement fails`)
+		require.Contains(t, err.Error(), `device unavailable`)
+
+		_, xKey, err := x25519.GenerateKey(rand.Reader)
+		require.NoError(t, err, `x25519.GenerateKey should succeed`)
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.ECDH_ES_A128KW, &hsmKeyAgreement{key: xKey}))
+		require.Error(t, err, `jwe.Decrypt should fail when the key types do not match`)
+	})
+	t.Run("KeyStrengthPolicy", func(t *testing.T) {
+		ecKey, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+
+		encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.ECDH_ES, &ecKey.PublicKey))
+		require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.ECDH_ES, &hsmKeyAgreement{key: ecKey}), jwe.WithKeyStrengthPolicy(jwa.NewKeyStrengthPolicy().AllowCurves(jwa.P384)))
+		require.ErrorIs(t, err, jwa.ErrCurveNotAllowed(), `jwe.Decrypt should reject P-256 key agreements`)
+	})
+}
+
+func TestStream(t *testing.T) {
+	rsaKey, err := jwxtest.GenerateRsaKey()
+	require.NoError(t, err, `jwxtest.GenerateRsaKey should succeed`)
+	ecKey, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+	require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+
+	// Large enough to span multiple chunks, and not a multiple of the block size
+	payload := make([]byte, 100*1024+7)
+	_, err = rand.Read(payload)
+	require.NoError(t, err, `rand.Read should succeed`)
+
+	formats := []struct {
+		Name    string
+		Options []jwe.EncryptOption
+	}{
+		{Name: "compact", Options: []jwe.EncryptOption{jwe.WithCompact()}},
+		{Name: "json", Options: []jwe.EncryptOption{jwe.WithJSON()}},
+		{Name: "json pretty", Options: []jwe.EncryptOption{jwe.WithJSON(jwe.WithPretty(true))}},
+	}
+	calgs := []jwa.ContentEncryptionAlgorithm{jwa.A128GCM, jwa.A256GCM, jwa.A128CBC_HS256, jwa.A256CBC_HS512}
+
+	for _, format := range formats {
+		format := format
+		for _, calg := range calgs {
+			calg := calg
+			for _, compress := range []jwa.CompressionAlgorithm{jwa.NoCompress, jwa.Deflate} {
+				compress := compress
+				t.Run(fmt.Sprintf("%s/%s/%s", format.Name, calg, compress), func(t *testing.T) {
+					options := append([]jwe.EncryptOption{
+						jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey),
+						jwe.WithContentEncryption(calg),
+						jwe.WithCompress(compress),
+					}, format.Options...)
+
+					var encrypted bytes.Buffer
+					require.NoError(t, jwe.EncryptStream(&encrypted, bytes.NewReader(payload), options...), `jwe.EncryptStream should succeed`)
+
+					decrypted, err := jwe.Decrypt(encrypted.Bytes(), jwe.WithKey(jwa.RSA_OAEP, rsaKey))
+					require.NoError(t, err, `jwe.Decrypt should succeed`)
+					require.True(t, bytes.Equal(payload, decrypted), `payloads should match`)
+
+					var streamed bytes.Buffer
+					require.NoError(t, jwe.DecryptStream(&streamed, bytes.NewReader(encrypted.Bytes()), jwe.WithKey(jwa.RSA_OAEP, rsaKey)), `jwe.DecryptStream should succeed`)
+					require.True(t, bytes.Equal(payload, streamed.Bytes()), `payloads should match`)
+
+					encrypted2, err := jwe.Encrypt(payload, options...)
+					require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+					streamed.Reset()
+					require.NoError(t, jwe.DecryptStream(&streamed, bytes.NewReader(encrypted2), jwe.WithKey(jwa.RSA_OAEP, rsaKey)), `jwe.DecryptStream should succeed`)
+					require.True(t, bytes.Equal(payload, streamed.Bytes()), `payloads should match`)
+				})
+			}
+		}
+	}
+	t.Run("multiple recipients", func(t *testing.T) {
+		var encrypted bytes.Buffer
+		err := jwe.EncryptStream(&encrypted, strings.NewReader(examplePayload),
+			jwe.WithJSON(),
+			jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey),
+			jwe.WithKey(jwa.ECDH_ES_A128KW, &ecKey.PublicKey),
+		)
+		require.NoError(t, err, `jwe.EncryptStream should succeed`)
+
+		for _, key := range []jwe.DecryptOption{jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithKey(jwa.ECDH_ES_A128KW, ecKey)} {
+			var decrypted bytes.Buffer
+			var msg jwe.Message
+			require.NoError(t, jwe.DecryptStream(&decrypted, bytes.NewReader(encrypted.Bytes()), key, jwe.WithMessage(&msg)), `jwe.DecryptStream should succeed`)
+			require.Equal(t, examplePayload, decrypted.String())
+			require.Len(t, msg.Recipients(), 2)
+			require.NotEmpty(t, msg.Tag())
+		}
+	})
+	t.Run("tampered", func(t *testing.T) {
+		for _, calg := range []jwa.ContentEncryptionAlgorithm{jwa.A128GCM, jwa.A128CBC_HS256} {
+			for _, compress := range []jwa.CompressionAlgorithm{jwa.NoCompress, jwa.Deflate} {
+				var encrypted bytes.Buffer
+				require.NoError(t, jwe.EncryptStream(&encrypted, bytes.NewReader(payload), jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey), jwe.WithContentEncryption(calg), jwe.WithCompress(compress)), `jwe.EncryptStream should succeed`)
+
+				parts := strings.Split(encrypted.String(), ".")
+				require.Len(t, parts, 5)
+				ciphertext := []byte(parts[3])
+				if ciphertext[100] == 'A' {
+					ciphertext[100] = 'B'
+				} else {
+					ciphertext[100] = 'A'
+				}
+				parts[3] = string(ciphertext)
+				tampered := strings.Join(parts, ".")
+
+				// Nothing may be written to dst, whether the ciphertext
+				// is read again from the source or from the storage
+				var dst bytes.Buffer
+				err := jwe.DecryptStream(&dst, strings.NewReader(tampered), jwe.WithKey(jwa.RSA_OAEP, rsaKey))
+				require.Error(t, err, `jwe.DecryptStream should fail for %s/%s`, calg, compress)
+				require.Zero(t, dst.Len(), `nothing should be written to dst for %s/%s`, calg, compress)
+
+				var storage bytes.Buffer
+				err = jwe.DecryptStream(&dst, struct{ io.Reader }{strings.NewReader(tampered)}, jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithCiphertextStorage(&storage))
+				require.Error(t, err, `jwe.DecryptStream should fail for %s/%s`, calg, compress)
+				require.Zero(t, dst.Len(), `nothing should be written to dst for %s/%s`, calg, compress)
+
+				err = jwe.DecryptStream(io.Discard, strings.NewReader(strings.Join(parts[:4], ".")), jwe.WithKey(jwa.RSA_OAEP, rsaKey))
+				require.Error(t, err, `jwe.DecryptStream should fail without a tag`)
+			}
+		}
+	})
+	t.Run("release unverified plaintext", func(t *testing.T) {
+		var encrypted bytes.Buffer
+		require.NoError(t, jwe.EncryptStream(&encrypted, bytes.NewReader(payload), jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey), jwe.WithContentEncryption(jwa.A128GCM)), `jwe.EncryptStream should succeed`)
+
+		var decrypted bytes.Buffer
+		require.NoError(t, jwe.DecryptStream(&decrypted, struct{ io.Reader }{bytes.NewReader(encrypted.Bytes())}, jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithReleaseUnverifiedPlaintext(true)), `jwe.DecryptStream should succeed`)
+		require.True(t, bytes.Equal(payload, decrypted.Bytes()), `payloads should match`)
+
+		tampered := encrypted.Bytes()
+		// the last character may only hold padding bits
+		i := bytes.LastIndexByte(tampered, '.') - 10
+		if tampered[i] == 'A' {
+			tampered[i] = 'B'
+		} else {
+			tampered[i] = 'A'
+		}
+		decrypted.Reset()
+		err := jwe.DecryptStream(&decrypted, bytes.NewReader(tampered), jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithReleaseUnverifiedPlaintext(true))
+		require.Error(t, err, `jwe.DecryptStream should fail`)
+		require.NotZero(t, decrypted.Len(), `unverified payload should have been written to dst`)
+	})
+	t.Run("ciphertext storage", func(t *testing.T) {
+		var encrypted bytes.Buffer
+		require.NoError(t, jwe.EncryptStream(&encrypted, bytes.NewReader(payload), jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey)), `jwe.EncryptStream should succeed`)
+
+		// hide the io.Seeker implementation of the source
+		src := func() io.Reader {
+			return struct{ io.Reader }{bytes.NewReader(encrypted.Bytes())}
+		}
+
+		var decrypted bytes.Buffer
+		err := jwe.DecryptStream(&decrypted, src(), jwe.WithKey(jwa.RSA_OAEP, rsaKey))
+		require.Error(t, err, `jwe.DecryptStream should fail without storage for a source that can't seek`)
+		require.Zero(t, decrypted.Len(), `nothing should be written to dst`)
+
+		var storage bytes.Buffer
+		require.NoError(t, jwe.DecryptStream(&decrypted, src(), jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithCiphertextStorage(&storage)), `jwe.DecryptStream should succeed`)
+		require.True(t, bytes.Equal(payload, decrypted.Bytes()), `payloads should match`)
+
+		// A file is read back from where the ciphertext was written
+		f, err := os.CreateTemp(t.TempDir(), `ciphertext`)
+		require.NoError(t, err, `os.CreateTemp should succeed`)
+		defer f.Close()
+		_, err = f.WriteString(`garbage`)
+		require.NoError(t, err, `WriteString should succeed`)
+
+		decrypted.Reset()
+		require.NoError(t, jwe.DecryptStream(&decrypted, src(), jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithCiphertextStorage(f)), `jwe.DecryptStream should succeed`)
+		require.True(t, bytes.Equal(payload, decrypted.Bytes()), `payloads should match`)
+
+		// A message whose ciphertext has been read into memory does not
+		// need to be read twice from the source
+		msg, err := jwe.Encrypt([]byte(examplePayload), jwe.WithJSON(), jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey))
+		require.NoError(t, err, `jwe.Encrypt should succeed`)
+		require.Less(t, bytes.Index(msg, []byte(`"ciphertext"`)), bytes.Index(msg, []byte(`"iv"`)), `"ciphertext" should come before "iv"`)
+		decrypted.Reset()
+		require.NoError(t, jwe.DecryptStream(&decrypted, struct{ io.Reader }{bytes.NewReader(msg)}, jwe.WithKey(jwa.RSA_OAEP, rsaKey)), `jwe.DecryptStream should succeed`)
+		require.Equal(t, examplePayload, decrypted.String())
+	})
+	t.Run("source position", func(t *testing.T) {
+		var encrypted bytes.Buffer
+		require.NoError(t, jwe.EncryptStream(&encrypted, strings.NewReader(examplePayload), jwe.WithJSON(), jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey)), `jwe.EncryptStream should succeed`)
+
+		// The message does not need to start at the beginning of the source
+		src := bytes.NewReader(append([]byte(`prefix`), encrypted.Bytes()...))
+		_, err := src.Seek(int64(len(`prefix`)), io.SeekStart)
+		require.NoError(t, err, `Seek should succeed`)
+
+		var decrypted bytes.Buffer
+		require.NoError(t, jwe.DecryptStream(&decrypted, src, jwe.WithKey(jwa.RSA_OAEP, rsaKey)), `jwe.DecryptStream should succeed`)
+		require.Equal(t, examplePayload, decrypted.String())
+		require.Zero(t, src.Len(), `source should have been read until the end`)
+	})
+	t.Run("member after ciphertext", func(t *testing.T) {
+		var encrypted bytes.Buffer
+		require.NoError(t, jwe.EncryptStream(&encrypted, strings.NewReader(examplePayload), jwe.WithJSON(), jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey)), `jwe.EncryptStream should succeed`)
+
+		modified := strings.TrimSuffix(encrypted.String(), `}`) + `,"aad":"Zm9v"}`
+		err := jwe.DecryptStream(io.Discard, strings.NewReader(modified), jwe.WithKey(jwa.RSA_OAEP, rsaKey))
+		require.Error(t, err, `jwe.DecryptStream should fail`)
+		require.Contains(t, err.Error(), `must appear before "ciphertext"`)
+	})
+}
+
+func TestDecryptLimits(t *testing.T) {
+	rsaKey, err := jwxtest.GenerateRsaKey()
+	require.NoError(t, err, `jwxtest.GenerateRsaKey should succeed`)
+
+	t.Run("decompressed size", func(t *testing.T) {
+		payload := make([]byte, 1024*1024)
+		encrypted, err := jwe.Encrypt(payload, jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey), jwe.WithCompress(jwa.Deflate))
+		require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+		decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA_OAEP, rsaKey))
+		require.NoError(t, err, `jwe.Decrypt should succeed with the default limit`)
+		require.Len(t, decrypted, len(payload))
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithMaxDecompressedSize(1024))
+		require.ErrorIs(t, err, jwe.ErrMaxDecompressedSizeExceeded(), `jwe.Decrypt should fail`)
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithMaxDecompressedSize(int64(len(payload))))
+		require.NoError(t, err, `jwe.Decrypt should succeed when the payload is exactly at the limit`)
+
+		err = jwe.DecryptStream(io.Discard, bytes.NewReader(encrypted), jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithMaxDecompressedSize(1024))
+		require.ErrorIs(t, err, jwe.ErrMaxDecompressedSizeExceeded(), `jwe.DecryptStream should fail`)
+	})
+	t.Run("PBES2 count", func(t *testing.T) {
+		encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.PBES2_HS256_A128KW, []byte("password")))
+		require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.PBES2_HS256_A128KW, []byte("password")))
+		require.NoError(t, err, `jwe.Decrypt should succeed with the default limit`)
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.PBES2_HS256_A128KW, []byte("password")), jwe.WithMaxPBES2Count(1000))
+		require.ErrorIs(t, err, jwe.ErrMaxPBES2CountExceeded(), `jwe.Decrypt should fail`)
+	})
+	t.Run("recipients", func(t *testing.T) {
+		encrypted, err := jwe.Encrypt([]byte(examplePayload),
+			jwe.WithJSON(),
+			jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey),
+			jwe.WithKey(jwa.RSA_OAEP_256, &rsaKey.PublicKey),
+			jwe.WithKey(jwa.RSA1_5, &rsaKey.PublicKey),
+		)
+		require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithMaxRecipients(2))
+		require.ErrorIs(t, err, jwe.ErrMaxRecipientsExceeded(), `jwe.Decrypt should fail`)
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithMaxRecipients(3))
+		require.NoError(t, err, `jwe.Decrypt should succeed`)
+	})
+	t.Run("ciphertext size", func(t *testing.T) {
+		encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey))
+		require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithMaxCiphertextSize(16))
+		require.ErrorIs(t, err, jwe.ErrMaxCiphertextSizeExceeded(), `jwe.Decrypt should fail`)
+
+		err = jwe.DecryptStream(io.Discard, bytes.NewReader(encrypted), jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithMaxCiphertextSize(16))
+		require.ErrorIs(t, err, jwe.ErrMaxCiphertextSizeExceeded(), `jwe.DecryptStream should fail`)
+
+		// Oversized messages are rejected before they are parsed, once they
+		// exceed the limit by more than the room left for the headers
+		_, err = jwe.Decrypt(bytes.Repeat([]byte(`A`), 2*1024*1024), jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithMaxCiphertextSize(512))
+		require.ErrorIs(t, err, jwe.ErrMaxCiphertextSizeExceeded(), `jwe.Decrypt should fail before parsing the message`)
+
+		// The headers and the encrypted keys do not count towards the limit
+		msg, err := jwe.Parse(encrypted)
+		require.NoError(t, err, `jwe.Parse should succeed`)
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithMaxCiphertextSize(int64(len(msg.CipherText()))))
+		require.NoError(t, err, `jwe.Decrypt should succeed when the ciphertext is exactly within the limit`)
+	})
+	t.Run("global settings", func(t *testing.T) {
+		encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey))
+		require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+		jwe.Settings(jwe.WithMaxCiphertextSize(16))
+		defer jwe.Settings(jwe.WithMaxCiphertextSize(jwe.DefaultMaxCiphertextSize))
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA_OAEP, rsaKey))
+		require.ErrorIs(t, err, jwe.ErrMaxCiphertextSizeExceeded(), `jwe.Decrypt should fail`)
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithMaxCiphertextSize(0))
+		require.NoError(t, err, `jwe.Decrypt should succeed when the limit is removed`)
+	})
+}
+
+type gzipCompressor struct {
+	used int
+}
+
+func (c *gzipCompressor) NewWriter(dst io.Writer) (io.WriteCloser, error) {
+	c.used++
+	return gzip.NewWriter(dst), nil
+}
+
+func (c *gzipCompressor) NewReader(src io.Reader) (io.ReadCloser, error) {
+	c.used++
+	return gzip.NewReader(src)
+}
+
+func TestRegisterCompressor(t *testing.T) {
+	const zip = jwa.CompressionAlgorithm(`X-GZIP`)
+
+	rsaKey, err := jwxtest.GenerateRsaKey()
+	require.NoError(t, err, `jwxtest.GenerateRsaKey should succeed`)
+
+	_, err = jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey), jwe.WithCompress(zip))
+	require.Error(t, err, `jwe.Encrypt should fail for an unregistered compression algorithm`)
+
+	jwa.RegisterCompressionAlgorithm(zip)
+	defer jwa.UnregisterCompressionAlgorithm(zip)
+	c := &gzipCompressor{}
+	jwe.RegisterCompressor(zip, c)
+	defer jwe.UnregisterCompressor(zip)
+
+	encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey), jwe.WithCompress(zip))
+	require.NoError(t, err, `jwe.Encrypt should succeed`)
+	require.Equal(t, 1, c.used, `custom compressor should have been used to compress`)
+
+	msg, err := jwe.Parse(encrypted)
+	require.NoError(t, err, `jwe.Parse should succeed`)
+	require.Equal(t, zip, msg.ProtectedHeaders().Compression())
+
+	decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA_OAEP, rsaKey))
+	require.NoError(t, err, `jwe.Decrypt should succeed`)
+	require.Equal(t, examplePayload, string(decrypted))
+	require.Equal(t, 2, c.used, `custom compressor should have been used to decompress`)
+
+	_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA_OAEP, rsaKey), jwe.WithMaxDecompressedSize(8))
+	require.ErrorIs(t, err, jwe.ErrMaxDecompressedSizeExceeded(), `jwe.Decrypt should fail`)
+
+	var streamed bytes.Buffer
+	require.NoError(t, jwe.EncryptStream(&streamed, strings.NewReader(examplePayload), jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey), jwe.WithCompress(zip)), `jwe.EncryptStream should succeed`)
+	var decryptedStream bytes.Buffer
+	require.NoError(t, jwe.DecryptStream(&decryptedStream, bytes.NewReader(streamed.Bytes()), jwe.WithKey(jwa.RSA_OAEP, rsaKey)), `jwe.DecryptStream should succeed`)
+	require.Equal(t, examplePayload, decryptedStream.String())
+
+	// DEFLATE cannot be replaced
+	jwe.RegisterCompressor(jwa.Deflate, c)
+	defer jwe.UnregisterCompressor(jwa.Deflate)
+	used := c.used
+	encrypted, err = jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey), jwe.WithCompress(jwa.Deflate))
+	require.NoError(t, err, `jwe.Encrypt should succeed`)
+	decrypted, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA_OAEP, rsaKey))
+	require.NoError(t, err, `jwe.Decrypt should succeed`)
+	require.Equal(t, examplePayload, string(decrypted))
+	require.Equal(t, used, c.used, `custom compressor should not be used for DEFLATE`)
+
+	jwe.UnregisterCompressor(zip)
+	_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA_OAEP, rsaKey))
+	require.NoError(t, err, `jwe.Decrypt should succeed for DEFLATE`)
+	_, err = jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.RSA_OAEP, &rsaKey.PublicKey), jwe.WithCompress(zip))
+	require.Error(t, err, `jwe.Encrypt should fail once the compressor is unregistered`)
+}
+
+func TestECDH1PU(t *testing.T) {
+	type keyPair struct {
+		Name      string
+		Sender    interface{}
+		SenderPub interface{}
+		Recipient interface{}
+		Public    interface{}
+	}
+
+	var pairs []keyPair
+	for _, crv := range []jwa.EllipticCurveAlgorithm{jwa.P256, jwa.P384, jwa.P521} {
+		sender, err := jwxtest.GenerateEcdsaKey(crv)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+		recipient, err := jwxtest.GenerateEcdsaKey(crv)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+		pairs = append(pairs, keyPair{Name: crv.String(), Sender: sender, SenderPub: &sender.PublicKey, Recipient: recipient, Public: &recipient.PublicKey})
+	}
+	senderPub, sender, err := x25519.GenerateKey(rand.Reader)
+	require.NoError(t, err, `x25519.GenerateKey should succeed`)
+	recipientPub, recipient, err := x25519.GenerateKey(rand.Reader)
+	require.NoError(t, err, `x25519.GenerateKey should succeed`)
+	pairs = append(pairs, keyPair{Name: "X25519", Sender: sender, SenderPub: senderPub, Recipient: recipient, Public: recipientPub})
+	senderPub448, sender448, err := x448.GenerateKey(rand.Reader)
+	require.NoError(t, err, `x448.GenerateKey should succeed`)
+	recipientPub448, recipient448, err := x448.GenerateKey(rand.Reader)
+	require.NoError(t, err, `x448.GenerateKey should succeed`)
+	pairs = append(pairs, keyPair{Name: "X448", Sender: sender448, SenderPub: senderPub448, Recipient: recipient448, Public: recipientPub448})
+
+	algs := []struct {
+		Alg  jwa.KeyEncryptionAlgorithm
+		Enc  jwa.ContentEncryptionAlgorithm
+		Wrap bool
+	}{
+		{Alg: jwa.ECDH_1PU, Enc: jwa.A256GCM},
+		{Alg: jwa.ECDH_1PU, Enc: jwa.A128CBC_HS256},
+		{Alg: jwa.ECDH_1PU_A128KW, Enc: jwa.A128CBC_HS256, Wrap: true},
+		{Alg: jwa.ECDH_1PU_A192KW, Enc: jwa.A192CBC_HS384, Wrap: true},
+		{Alg: jwa.ECDH_1PU_A256KW, Enc: jwa.A256CBC_HS512, Wrap: true},
+	}
+
+	for _, pair := range pairs {
+		pair := pair
+		for _, alg := range algs {
+			alg := alg
+			t.Run(pair.Name+"/"+alg.Alg.String()+"/"+alg.Enc.String(), func(t *testing.T) {
+				encrypted, err := jwe.Encrypt([]byte(examplePayload),
+					jwe.WithKey(alg.Alg, pair.Public),
+					jwe.WithContentEncryption(alg.Enc),
+					jwe.WithSenderKey(pair.Sender),
+				)
+				require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+				msg, err := jwe.Parse(encrypted)
+				require.NoError(t, err, `jwe.Parse should succeed`)
+				require.Equal(t, alg.Wrap, len(msg.Recipients()[0].EncryptedKey()) > 0, `encrypted key should only exist in key wrapping mode`)
+
+				decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(alg.Alg, pair.Recipient), jwe.WithSenderPublicKey(pair.SenderPub))
+				require.NoError(t, err, `jwe.Decrypt should succeed`)
+				require.Equal(t, examplePayload, string(decrypted))
+
+				_, err = jwe.Decrypt(encrypted, jwe.WithKey(alg.Alg, pair.Recipient))
+				require.Error(t, err, `jwe.Decrypt should fail without the sender key`)
+
+				_, err = jwe.Decrypt(encrypted, jwe.WithKey(alg.Alg, pair.Recipient), jwe.WithSenderPublicKey(pair.Public))
+				require.Error(t, err, `jwe.Decrypt should fail with the wrong sender key`)
+			})
+		}
+	}
+
+	t.Run("multiple recipients", func(t *testing.T) {
+		sender, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+		bob, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+		charlie, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+
+		encrypted, err := jwe.Encrypt([]byte(examplePayload),
+			jwe.WithJSON(),
+			jwe.WithKey(jwa.ECDH_1PU_A128KW, &bob.PublicKey),
+			jwe.WithKey(jwa.ECDH_1PU_A256KW, &charlie.PublicKey),
+			jwe.WithContentEncryption(jwa.A256CBC_HS512),
+			jwe.WithSenderKey(sender),
+		)
+		require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+		for _, key := range []jwe.DecryptOption{jwe.WithKey(jwa.ECDH_1PU_A128KW, bob), jwe.WithKey(jwa.ECDH_1PU_A256KW, charlie)} {
+			decrypted, err := jwe.Decrypt(encrypted, key, jwe.WithSenderPublicKey(&sender.PublicKey))
+			require.NoError(t, err, `jwe.Decrypt should succeed`)
+			require.Equal(t, examplePayload, string(decrypted))
+		}
+	})
+	t.Run("skid", func(t *testing.T) {
+		rawSender, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+		sender, err := jwk.FromRaw(rawSender)
+		require.NoError(t, err, `jwk.FromRaw should succeed`)
+		require.NoError(t, sender.Set(jwk.KeyIDKey, `alice`), `sender.Set should succeed`)
+		recipient, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+
+		encrypted, err := jwe.Encrypt([]byte(examplePayload),
+			jwe.WithKey(jwa.ECDH_1PU_A128KW, &recipient.PublicKey),
+			jwe.WithContentEncryption(jwa.A128CBC_HS256),
+			jwe.WithSenderKey(sender),
+		)
+		require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+		msg, err := jwe.Parse(encrypted)
+		require.NoError(t, err, `jwe.Parse should succeed`)
+		require.Equal(t, `alice`, msg.ProtectedHeaders().SenderKeyID())
+
+		senderPub, err := sender.PublicKey()
+		require.NoError(t, err, `sender.PublicKey should succeed`)
+		other, err := jwxtest.GenerateEcdsaJwk()
+		require.NoError(t, err, `jwxtest.GenerateEcdsaJwk should succeed`)
+		require.NoError(t, other.Set(jwk.KeyIDKey, `mallory`), `other.Set should succeed`)
+
+		set := jwk.NewSet()
+		require.NoError(t, set.AddKey(other), `set.AddKey should succeed`)
+		require.NoError(t, set.AddKey(senderPub), `set.AddKey should succeed`)
+
+		decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(jwa.ECDH_1PU_A128KW, recipient), jwe.WithSenderPublicKey(set))
+		require.NoError(t, err, `jwe.Decrypt should succeed`)
+		require.Equal(t, examplePayload, string(decrypted))
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.ECDH_1PU_A128KW, recipient), jwe.WithSenderPublicKey(other))
+		require.Error(t, err, `jwe.Decrypt should fail when the key ID does not match "skid"`)
+	})
+	t.Run("errors", func(t *testing.T) {
+		sender, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+		recipient, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+		p384, err := jwxtest.GenerateEcdsaKey(jwa.P384)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+
+		_, err = jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.ECDH_1PU, &recipient.PublicKey))
+		require.Error(t, err, `jwe.Encrypt should fail without the sender key`)
+
+		_, err = jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.ECDH_1PU_A128KW, &recipient.PublicKey), jwe.WithSenderKey(sender), jwe.WithContentEncryption(jwa.A128GCM))
+		require.Error(t, err, `jwe.Encrypt should fail for key wrapping with AES-GCM`)
+
+		_, err = jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.ECDH_1PU, &recipient.PublicKey), jwe.WithSenderKey(p384))
+		require.Error(t, err, `jwe.Encrypt should fail when the curves do not match`)
+
+		err = jwe.EncryptStream(io.Discard, strings.NewReader(examplePayload), jwe.WithKey(jwa.ECDH_1PU_A128KW, &recipient.PublicKey), jwe.WithSenderKey(sender), jwe.WithContentEncryption(jwa.A128CBC_HS256))
+		require.Error(t, err, `jwe.EncryptStream should fail for ECDH-1PU key wrapping`)
+
+		var buf bytes.Buffer
+		require.NoError(t, jwe.EncryptStream(&buf, strings.NewReader(examplePayload), jwe.WithKey(jwa.ECDH_1PU, &recipient.PublicKey), jwe.WithSenderKey(sender)), `jwe.EncryptStream should succeed for ECDH-1PU`)
+		var decrypted bytes.Buffer
+		require.NoError(t, jwe.DecryptStream(&decrypted, bytes.NewReader(buf.Bytes()), jwe.WithKey(jwa.ECDH_1PU, recipient), jwe.WithSenderPublicKey(&sender.PublicKey)), `jwe.DecryptStream should succeed for ECDH-1PU`)
+		require.Equal(t, examplePayload, decrypted.String())
+	})
+}
+
+// The example from draft-madden-jose-ecdh-1pu-04 Appendix B, where the
+// authentication tag of the content is an input to the key derivation
+func TestECDH1PUKeyWrapVector(t *testing.T) {
+	const alice = `{"kty":"OKP","crv":"X25519","x":"Knbm_BcdQr7WIoz-uqit9M0wbcfEr6y-9UfIZ8QnBD4","d":"i9KuFhSzEBsiv3PKVL5115OCdsqQai5nj_Flzfkw5jU"}`
+	const bob = `{"kty":"OKP","crv":"X25519","kid":"bob-key-2","x":"BT7aR0ItXfeDAldeeOlXL_wXqp-j5FltT0vRSG16kRw","d":"1gDirl_r_Y3-qUa3WXHgEXrrEHngWThU3c9zj9A2uBg"}`
+	const charlie = `{"kty":"OKP","crv":"X25519","kid":"2021-05-06","x":"q-LsvU772uV_2sPJhfAIq-3vnKNVefNoIlvyvg1hrnE","d":"Jcv8gklhMjC0b-lsk5onBbppWAx5ncNtbM63Jr9xBQE"}`
+	const message = `{
+  "protected":"eyJhbGciOiJFQ0RILTFQVStBMTI4S1ciLCJlbmMiOiJBMjU2Q0JDLUhTNTEyIiwiYXB1IjoiUVd4cFkyVSIsImFwdiI6IlFtOWlJR0Z1WkNCRGFHRnliR2xsIiwiZXBrIjp7Imt0eSI6Ik9LUCIsImNydiI6IlgyNTUxOSIsIngiOiJrOW9mX2NwQWFqeTBwb1c1Z2FpeFhHczluSGt3ZzFBRnFVQUZhMzlkeUJjIn19",
+  "unprotected":{"jku":"https://alice.example.com/keys.jwks"},
+  "recipients":[
+    {"header":{"kid":"bob-key-2"},"encrypted_key":"pOMVA9_PtoRe7xXW1139NzzN1UhiFoio8lGto9cf0t8PyU-sjNXH8-LIRLycq8CHJQbDwvQeU1cSl55cQ0hGezJu2N9IY0QN"},
+    {"header":{"kid":"2021-05-06"},"encrypted_key":"56GVudgRLIMEElQ7DpXsijJVRSWUSDNdbWkdV3g0GUNq6hcT_GkxwnxlPIWrTXCqRpVKQC8fe4z3PQ2YH2afvjQ28aiCTWFE"}
+  ],
+  "iv":"AAECAwQFBgcICQoLDA0ODw",
+  "ciphertext":"Az2IWsISEMDJvyc5XRL-3-d-RgNBOGolCsxFFoUXFYw",
+  "tag":"HLb4fTlm8spGmij3RyOs2gJ4DpHM4hhVRwdF_hGb3WQ"
+}`
+
+	sender, err := jwk.ParseKey([]byte(alice))
+	require.NoError(t, err, `jwk.ParseKey should succeed`)
+	senderPub, err := sender.PublicKey()
+	require.NoError(t, err, `PublicKey should succeed`)
+
+	for _, src := range []string{bob, charlie} {
+		recipient, err := jwk.ParseKey([]byte(src))
+		require.NoError(t, err, `jwk.ParseKey should succeed`)
+
+		decrypted, err := jwe.Decrypt([]byte(message), jwe.WithKey(jwa.ECDH_1PU_A128KW, recipient), jwe.WithSenderPublicKey(senderPub))
+		require.NoError(t, err, `jwe.Decrypt should succeed for %s`, recipient.KeyID())
+		require.Equal(t, `Three is a magic number.`, string(decrypted))
+	}
+}
+
+func TestXC20P(t *testing.T) {
+	sharedkey := make([]byte, 32)
+	_, err := rand.Read(sharedkey)
+	require.NoError(t, err, `rand.Read should succeed`)
+	eckey, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+	require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+	x25519Pub, x25519Priv, err := x25519.GenerateKey(rand.Reader)
+	require.NoError(t, err, `x25519.GenerateKey should succeed`)
+
+	testcases := []struct {
+		Name    string
+		Alg     jwa.KeyEncryptionAlgorithm
+		Enc     jwa.ContentEncryptionAlgorithm
+		Public  interface{}
+		Private interface{}
+		Headers []string
+	}{
+		{Name: "dir", Alg: jwa.DIRECT, Enc: jwa.XC20P, Public: sharedkey, Private: sharedkey},
+		{Name: "A256KW", Alg: jwa.A256KW, Enc: jwa.XC20P, Public: sharedkey, Private: sharedkey},
+		{Name: "XC20PKW", Alg: jwa.XC20PKW, Enc: jwa.XC20P, Public: sharedkey, Private: sharedkey, Headers: []string{jwe.InitializationVectorKey, jwe.TagKey}},
+		{Name: "XC20PKW with A256GCM", Alg: jwa.XC20PKW, Enc: jwa.A256GCM, Public: sharedkey, Private: sharedkey, Headers: []string{jwe.InitializationVectorKey, jwe.TagKey}},
+		{Name: "ECDH-ES", Alg: jwa.ECDH_ES, Enc: jwa.XC20P, Public: &eckey.PublicKey, Private: eckey, Headers: []string{jwe.EphemeralPublicKeyKey}},
+		{Name: "ECDH-ES+XC20PKW (P-256)", Alg: jwa.ECDH_ES_XC20PKW, Enc: jwa.XC20P, Public: &eckey.PublicKey, Private: eckey, Headers: []string{jwe.EphemeralPublicKeyKey, jwe.InitializationVectorKey, jwe.TagKey}},
+		{Name: "ECDH-ES+XC20PKW (X25519)", Alg: jwa.ECDH_ES_XC20PKW, Enc: jwa.XC20P, Public: x25519Pub, Private: x25519Priv, Headers: []string{jwe.EphemeralPublicKeyKey, jwe.InitializationVectorKey, jwe.TagKey}},
+		{Name: "ECDH-ES+XC20PKW with A128CBC-HS256", Alg: jwa.ECDH_ES_XC20PKW, Enc: jwa.A128CBC_HS256, Public: &eckey.PublicKey, Private: eckey, Headers: []string{jwe.EphemeralPublicKeyKey, jwe.InitializationVectorKey, jwe.TagKey}},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(tc.Alg, tc.Public), jwe.WithContentEncryption(tc.Enc))
+			require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+			msg, err := jwe.Parse(encrypted)
+			require.NoError(t, err, `jwe.Parse should succeed`)
+			if tc.Enc == jwa.XC20P {
+				require.Len(t, msg.InitializationVector(), 24, `XC20P should use a 192-bit iv`)
+			}
+			for _, name := range tc.Headers {
+				_, ok := msg.ProtectedHeaders().Get(name)
+				require.True(t, ok, `header %q should be set`, name)
+			}
+
+			decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(tc.Alg, tc.Private))
+			require.NoError(t, err, `jwe.Decrypt should succeed`)
+			require.Equal(t, examplePayload, string(decrypted))
+
+			var buf bytes.Buffer
+			require.NoError(t, jwe.DecryptStream(&buf, bytes.NewReader(encrypted), jwe.WithKey(tc.Alg, tc.Private)), `jwe.DecryptStream should succeed`)
+			require.Equal(t, examplePayload, buf.String())
+
+			tampered := make([]byte, len(encrypted))
+			copy(tampered, encrypted)
+			tampered[len(tampered)-1] ^= 1
+			_, err = jwe.Decrypt(tampered, jwe.WithKey(tc.Alg, tc.Private))
+			require.Error(t, err, `jwe.Decrypt should fail for tampered messages`)
+		})
+	}
+
+	t.Run("EncryptStream", func(t *testing.T) {
+		payload := bytes.Repeat([]byte(examplePayload), 1000)
+		var encrypted bytes.Buffer
+		require.NoError(t, jwe.EncryptStream(&encrypted, bytes.NewReader(payload), jwe.WithKey(jwa.ECDH_ES_XC20PKW, x25519Pub), jwe.WithContentEncryption(jwa.XC20P)), `jwe.EncryptStream should succeed`)
+
+		decrypted, err := jwe.Decrypt(encrypted.Bytes(), jwe.WithKey(jwa.ECDH_ES_XC20PKW, x25519Priv))
+		require.NoError(t, err, `jwe.Decrypt should succeed`)
+		require.Equal(t, payload, decrypted)
+	})
+	t.Run("DecryptStream", func(t *testing.T) {
+		payload := bytes.Repeat([]byte(examplePayload), 1000)
+		for _, compress := range []jwa.CompressionAlgorithm{jwa.NoCompress, jwa.Deflate} {
+			var encrypted bytes.Buffer
+			require.NoError(t, jwe.EncryptStream(&encrypted, bytes.NewReader(payload), jwe.WithKey(jwa.XC20PKW, sharedkey), jwe.WithContentEncryption(jwa.XC20P), jwe.WithCompress(compress)), `jwe.EncryptStream should succeed`)
+
+			var decrypted bytes.Buffer
+			require.NoError(t, jwe.DecryptStream(&decrypted, bytes.NewReader(encrypted.Bytes()), jwe.WithKey(jwa.XC20PKW, sharedkey)), `jwe.DecryptStream should succeed`)
+			require.Equal(t, payload, decrypted.Bytes())
+
+			// Tampered ciphertext must be rejected before anything is
+			// written to dst
+			parts := strings.Split(encrypted.String(), ".")
+			require.Len(t, parts, 5)
+			ciphertext := []byte(parts[3])
+			if ciphertext[10] == 'A' {
+				ciphertext[10] = 'B'
+			} else {
+				ciphertext[10] = 'A'
+			}
+			parts[3] = string(ciphertext)
+			tampered := strings.Join(parts, ".")
+
+			decrypted.Reset()
+			err := jwe.DecryptStream(&decrypted, strings.NewReader(tampered), jwe.WithKey(jwa.XC20PKW, sharedkey))
+			require.Error(t, err, `jwe.DecryptStream should fail for tampered messages`)
+			require.Zero(t, decrypted.Len(), `nothing should be written to dst`)
+
+			err = jwe.DecryptStream(&decrypted, struct{ io.Reader }{strings.NewReader(tampered)}, jwe.WithKey(jwa.XC20PKW, sharedkey), jwe.WithCiphertextStorage(&bytes.Buffer{}))
+			require.Error(t, err, `jwe.DecryptStream should fail for tampered messages`)
+			require.Zero(t, decrypted.Len(), `nothing should be written to dst`)
+		}
+	})
+	t.Run("invalid key size", func(t *testing.T) {
+		_, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.XC20PKW, sharedkey[:16]))
+		require.Error(t, err, `jwe.Encrypt should fail with a 128-bit key`)
+		_, err = jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.DIRECT, sharedkey[:16]), jwe.WithContentEncryption(jwa.XC20P))
+		require.Error(t, err, `jwe.Encrypt should fail with a 128-bit key`)
+	})
+}
+
+func TestHPKE(t *testing.T) {
+	type keyPair struct {
+		Name    string
+		Curve   string
+		Private interface{}
+		Public  interface{}
+	}
+
+	var pairs []keyPair
+	for _, crv := range []jwa.EllipticCurveAlgorithm{jwa.P256, jwa.P384, jwa.P521} {
+		key, err := jwxtest.GenerateEcdsaKey(crv)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+		pairs = append(pairs, keyPair{Name: crv.String(), Curve: crv.String(), Private: key, Public: &key.PublicKey})
+	}
+	xpub, xpriv, err := x25519.GenerateKey(rand.Reader)
+	require.NoError(t, err, `x25519.GenerateKey should succeed`)
+	pairs = append(pairs, keyPair{Name: "X25519", Curve: "X25519", Private: xpriv, Public: xpub})
+
+	// jwk.Key should work as well
+	for _, pair := range pairs {
+		priv, err := jwk.FromRaw(pair.Private)
+		require.NoError(t, err, `jwk.FromRaw should succeed`)
+		pub, err := jwk.PublicKeyOf(priv)
+		require.NoError(t, err, `jwk.PublicKeyOf should succeed`)
+		pairs = append(pairs, keyPair{Name: pair.Name + " (jwk)", Curve: pair.Curve, Private: priv, Public: pub})
+	}
+
+	algs := []struct {
+		Alg   jwa.KeyEncryptionAlgorithm
+		Curve string
+	}{
+		{Alg: jwa.HPKE_0, Curve: "P-256"},
+		{Alg: jwa.HPKE_1, Curve: "P-384"},
+		{Alg: jwa.HPKE_2, Curve: "P-521"},
+		{Alg: jwa.HPKE_3, Curve: "X25519"},
+		{Alg: jwa.HPKE_4, Curve: "X25519"},
+		{Alg: jwa.HPKE_0_KE, Curve: "P-256"},
+		{Alg: jwa.HPKE_1_KE, Curve: "P-384"},
+		{Alg: jwa.HPKE_2_KE, Curve: "P-521"},
+		{Alg: jwa.HPKE_3_KE, Curve: "X25519"},
+		{Alg: jwa.HPKE_4_KE, Curve: "X25519"},
+	}
+
+	isIntegrated := func(alg jwa.KeyEncryptionAlgorithm) bool {
+		return !strings.HasSuffix(alg.String(), "-KE")
+	}
+
+	for _, alg := range algs {
+		alg := alg
+		for _, pair := range pairs {
+			pair := pair
+			if pair.Curve != alg.Curve {
+				continue
+			}
+			t.Run(alg.Alg.String()+"/"+pair.Name, func(t *testing.T) {
+				for _, format := range []jwe.EncryptOption{jwe.WithCompact(), jwe.WithJSON()} {
+					encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(alg.Alg, pair.Public), format)
+					require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+					msg, err := jwe.Parse(encrypted)
+					require.NoError(t, err, `jwe.Parse should succeed`)
+					if isIntegrated(alg.Alg) {
+						_, ok := msg.ProtectedHeaders().Get(jwe.ContentEncryptionKey)
+						require.False(t, ok, `"enc" should not be set in integrated encryption`)
+						require.Empty(t, msg.InitializationVector(), `iv should be empty in integrated encryption`)
+						require.Empty(t, msg.Tag(), `tag should be empty in integrated encryption`)
+					} else {
+						require.NotEmpty(t, msg.Recipients()[0].Headers().EncapsulatedKey(), `"ek" should be set in key encryption`)
+					}
+
+					decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(alg.Alg, pair.Private))
+					require.NoError(t, err, `jwe.Decrypt should succeed`)
+					require.Equal(t, examplePayload, string(decrypted))
+
+					tampered := make([]byte, len(encrypted))
+					copy(tampered, encrypted)
+					tampered[len(tampered)-5] ^= 1
+					_, err = jwe.Decrypt(tampered, jwe.WithKey(alg.Alg, pair.Private))
+					require.Error(t, err, `jwe.Decrypt should fail for tampered messages`)
+				}
+			})
+		}
+	}
+
+	t.Run("KeyAgreement", func(t *testing.T) {
+		for _, alg := range []jwa.KeyEncryptionAlgorithm{jwa.HPKE_0, jwa.HPKE_0_KE} {
+			encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(alg, pairs[0].Public))
+			require.NoError(t, err, `jwe.Encrypt should succeed`)
+			decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(alg, &hsmKeyAgreement{key: pairs[0].Private}))
+			require.NoError(t, err, `jwe.Decrypt should succeed`)
+			require.Equal(t, examplePayload, string(decrypted))
+		}
+	})
+	t.Run("multiple recipients (key encryption)", func(t *testing.T) {
+		encrypted, err := jwe.Encrypt([]byte(examplePayload),
+			jwe.WithJSON(),
+			jwe.WithKey(jwa.HPKE_0_KE, &pairs[0].Private.(*ecdsa.PrivateKey).PublicKey),
+			jwe.WithKey(jwa.HPKE_3_KE, xpub),
+			jwe.WithContentEncryption(jwa.A256GCM),
+		)
+		require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+		for _, key := range []struct {
+			Alg jwa.KeyEncryptionAlgorithm
+			Key interface{}
+		}{{Alg: jwa.HPKE_0_KE, Key: pairs[0].Private}, {Alg: jwa.HPKE_3_KE, Key: xpriv}} {
+			decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(key.Alg, key.Key))
+			require.NoError(t, err, `jwe.Decrypt should succeed`)
+			require.Equal(t, examplePayload, string(decrypted))
+		}
+	})
+	t.Run("streaming (key encryption)", func(t *testing.T) {
+		payload := bytes.Repeat([]byte(examplePayload), 1000)
+		var encrypted bytes.Buffer
+		require.NoError(t, jwe.EncryptStream(&encrypted, bytes.NewReader(payload), jwe.WithKey(jwa.HPKE_3_KE, xpub)), `jwe.EncryptStream should succeed`)
+
+		var decrypted bytes.Buffer
+		require.NoError(t, jwe.DecryptStream(&decrypted, bytes.NewReader(encrypted.Bytes()), jwe.WithKey(jwa.HPKE_3_KE, xpriv)), `jwe.DecryptStream should succeed`)
+		require.Equal(t, payload, decrypted.Bytes())
+	})
+	t.Run("errors", func(t *testing.T) {
+		_, err := jwe.Encrypt([]byte(examplePayload), jwe.WithJSON(), jwe.WithKey(jwa.HPKE_3, xpub), jwe.WithKey(jwa.HPKE_3_KE, xpub))
+		require.Error(t, err, `integrated encryption should not allow multiple recipients`)
+
+		_, err = jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.HPKE_3, xpub), jwe.WithContentEncryption(jwa.A128GCM))
+		require.Error(t, err, `integrated encryption should not allow "enc"`)
+
+		_, err = jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.HPKE_0, xpub))
+		require.Error(t, err, `jwe.Encrypt should fail for keys that do not match the KEM`)
+
+		var buf bytes.Buffer
+		require.Error(t, jwe.EncryptStream(&buf, strings.NewReader(examplePayload), jwe.WithKey(jwa.HPKE_3, xpub)), `integrated encryption should not support streaming`)
+
+		encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.HPKE_3, xpub))
+		require.NoError(t, err, `jwe.Encrypt should succeed`)
+		require.Error(t, jwe.DecryptStream(&buf, bytes.NewReader(encrypted), jwe.WithKey(jwa.HPKE_3, xpriv)), `integrated encryption should not support streaming`)
+
+		_, otherKey, err := x25519.GenerateKey(rand.Reader)
+		require.NoError(t, err, `x25519.GenerateKey should succeed`)
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.HPKE_3, otherKey))
+		require.Error(t, err, `jwe.Decrypt should fail with the wrong key`)
+
+		// "enc" must not be present in integrated encryption
+		protected := jwe.NewHeaders()
+		require.NoError(t, protected.Set(jwe.ContentEncryptionKey, jwa.A128GCM))
+		encrypted, err = jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.HPKE_3, xpub), jwe.WithProtectedHeaders(protected))
+		require.NoError(t, err, `jwe.Encrypt should succeed`)
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.HPKE_3, xpriv))
+		require.Error(t, err, `jwe.Decrypt should fail when "enc" is present`)
+	})
+}
+
+func TestMLKEM(t *testing.T) {
+	t.Parallel()
+
+	keys := map[*mlkem.Parameters]*mlkem.DecapsulationKey{}
+	for _, params := range []*mlkem.Parameters{mlkem.MLKEM768(), mlkem.MLKEM1024()} {
+		key, err := jwxtest.GenerateMLKEMKey(params)
+		require.NoError(t, err, `jwxtest.GenerateMLKEMKey should succeed`)
+		keys[params] = key
+	}
+
+	algs := []struct {
+		Alg    jwa.KeyEncryptionAlgorithm
+		Params *mlkem.Parameters
+		Direct bool
+	}{
+		{Alg: jwa.MLKEM768, Params: mlkem.MLKEM768(), Direct: true},
+		{Alg: jwa.MLKEM768_A192KW, Params: mlkem.MLKEM768()},
+		{Alg: jwa.MLKEM1024, Params: mlkem.MLKEM1024(), Direct: true},
+		{Alg: jwa.MLKEM1024_A256KW, Params: mlkem.MLKEM1024()},
+	}
+
+	for _, alg := range algs {
+		alg := alg
+		t.Run(alg.Alg.String(), func(t *testing.T) {
+			t.Parallel()
+			priv := keys[alg.Params]
+			for _, calg := range []jwa.ContentEncryptionAlgorithm{jwa.A128GCM, jwa.A256GCM, jwa.A256CBC_HS512} {
+				for _, format := range []jwe.EncryptOption{jwe.WithCompact(), jwe.WithJSON()} {
+					encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(alg.Alg, priv.EncapsulationKey()), jwe.WithContentEncryption(calg), format)
+					require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+					msg, err := jwe.Parse(encrypted)
+					require.NoError(t, err, `jwe.Parse should succeed`)
+					require.Len(t, msg.Recipients()[0].Headers().EncapsulatedKey(), alg.Params.CiphertextSize(), `"ek" should hold the ML-KEM ciphertext`)
+					if alg.Direct {
+						require.Empty(t, msg.Recipients()[0].EncryptedKey(), `encrypted key should be empty in direct key agreement mode`)
+					} else {
+						require.NotEmpty(t, msg.Recipients()[0].EncryptedKey(), `encrypted key should be set in key wrap mode`)
+					}
+
+					decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(alg.Alg, priv))
+					require.NoError(t, err, `jwe.Decrypt should succeed`)
+					require.Equal(t, examplePayload, string(decrypted))
+				}
+			}
+		})
+	}
+
+	t.Run("jwk.Key", func(t *testing.T) {
+		t.Parallel()
+		for _, alg := range algs {
+			priv, err := jwk.FromRaw(keys[alg.Params])
+			require.NoError(t, err, `jwk.FromRaw should succeed`)
+			require.NoError(t, priv.Set(jwk.AlgorithmKey, alg.Alg), `priv.Set should succeed`)
+			require.NoError(t, priv.Set(jwk.KeyIDKey, alg.Alg.String()), `priv.Set should succeed`)
+			pub, err := jwk.PublicKeyOf(priv)
+			require.NoError(t, err, `jwk.PublicKeyOf should succeed`)
+
+			apu := []byte("Alice")
+			apv := []byte("Bob")
+			hdrs := jwe.NewHeaders()
+			require.NoError(t, hdrs.Set(jwe.AgreementPartyUInfoKey, apu), `hdrs.Set should succeed`)
+			require.NoError(t, hdrs.Set(jwe.AgreementPartyVInfoKey, apv), `hdrs.Set should succeed`)
+			encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(alg.Alg, pub, jwe.WithPerRecipientHeaders(hdrs)))
+			require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+			decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(alg.Alg, priv))
+			require.NoError(t, err, `jwe.Decrypt should succeed`)
+			require.Equal(t, examplePayload, string(decrypted))
+
+			// keys are selected by "kid" and "alg"
+			set := jwk.NewSet()
+			for _, other := range algs {
+				if other.Alg == alg.Alg {
+					continue
+				}
+				otherkey, err := jwxtest.GenerateMLKEMJwk(other.Params)
+				require.NoError(t, err, `jwxtest.GenerateMLKEMJwk should succeed`)
+				require.NoError(t, otherkey.Set(jwk.AlgorithmKey, other.Alg), `otherkey.Set should succeed`)
+				require.NoError(t, otherkey.Set(jwk.KeyIDKey, other.Alg.String()), `otherkey.Set should succeed`)
+				require.NoError(t, set.AddKey(otherkey), `set.AddKey should succeed`)
+			}
+			require.NoError(t, set.AddKey(priv), `set.AddKey should succeed`)
+
+			decrypted, err = jwe.Decrypt(encrypted, jwe.WithKeySet(set))
+			require.NoError(t, err, `jwe.Decrypt should succeed`)
+			require.Equal(t, examplePayload, string(decrypted))
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.MLKEM1024, keys[mlkem.MLKEM768()].EncapsulationKey()))
+		require.Error(t, err, `jwe.Encrypt should fail for keys that do not match the parameter set`)
+
+		_, err = jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.MLKEM768, []byte("0123456789abcdef")))
+		require.Error(t, err, `jwe.Encrypt should fail for non ML-KEM keys`)
+
+		for _, alg := range []jwa.KeyEncryptionAlgorithm{jwa.MLKEM768, jwa.MLKEM768_A192KW} {
+			encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(alg, keys[mlkem.MLKEM768()].EncapsulationKey()))
+			require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+			other, err := jwxtest.GenerateMLKEMKey(mlkem.MLKEM768())
+			require.NoError(t, err, `jwxtest.GenerateMLKEMKey should succeed`)
+			_, err = jwe.Decrypt(encrypted, jwe.WithKey(alg, other))
+			require.Error(t, err, `jwe.Decrypt should fail with the wrong key`)
+
+			_, err = jwe.Decrypt(encrypted, jwe.WithKey(alg, keys[mlkem.MLKEM1024()]))
+			require.Error(t, err, `jwe.Decrypt should fail with a key for the wrong parameter set`)
+		}
+	})
+}
+
+func TestRSA1_5(t *testing.T) {
+	rsaKey, err := jwxtest.GenerateRsaKey()
+	require.NoError(t, err, `jwxtest.GenerateRsaKey should succeed`)
+
+	encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.RSA1_5, &rsaKey.PublicKey))
+	require.NoError(t, err, `jwe.Encrypt should succeed`)
+
+	t.Run("disabled by default", func(t *testing.T) {
+		_, err := jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA1_5, rsaKey))
+		require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed(), `jwe.Decrypt should fail`)
+
+		// the algorithm policy does not enable RSA1_5 by itself
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA1_5, rsaKey), jwe.WithAlgorithmPolicy(jwa.NewAlgorithmPolicy().Allow(jwa.RSA1_5)))
+		require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed(), `jwe.Decrypt should fail`)
+
+		decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA1_5, rsaKey), jwe.WithAllowRSA1_5(true))
+		require.NoError(t, err, `jwe.Decrypt should succeed`)
+		require.Equal(t, examplePayload, string(decrypted))
+
+		// a policy that denies RSA1_5 still applies
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA1_5, rsaKey), jwe.WithAllowRSA1_5(true), jwe.WithAlgorithmPolicy(jwa.StrictAlgorithmPolicy()))
+		require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed(), `jwe.Decrypt should fail`)
+	})
+	t.Run("global setting", func(t *testing.T) {
+		jwe.Settings(jwe.WithAllowRSA1_5(true))
+		defer jwe.Settings(jwe.WithAllowRSA1_5(false))
+
+		decrypted, err := jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA1_5, rsaKey))
+		require.NoError(t, err, `jwe.Decrypt should succeed`)
+		require.Equal(t, examplePayload, string(decrypted))
+
+		_, err = jwe.Decrypt(encrypted, jwe.WithKey(jwa.RSA1_5, rsaKey), jwe.WithAllowRSA1_5(false))
+		require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed(), `jwe.Decrypt should fail`)
+	})
+	t.Run("indistinguishable failures", func(t *testing.T) {
+		// RFC 7516 Section 11.5: a malformed encrypted key must not be
+		// distinguishable from a valid encrypted key with a tampered content
+		testcases := []struct {
+			Alg     jwa.ContentEncryptionAlgorithm
+			KeySize int
+		}{
+			{Alg: jwa.A128CBC_HS256, KeySize: 32},
+			{Alg: jwa.A256CBC_HS512, KeySize: 64},
+			{Alg: jwa.A128GCM, KeySize: 16},
+			{Alg: jwa.A256GCM, KeySize: 32},
+		}
+		for _, tc := range testcases {
+			tc := tc
+			t.Run(tc.Alg.String(), func(t *testing.T) {
+				encrypted, err := jwe.Encrypt([]byte(examplePayload), jwe.WithKey(jwa.RSA1_5, &rsaKey.PublicKey), jwe.WithContentEncryption(tc.Alg))
+				require.NoError(t, err, `jwe.Encrypt should succeed`)
+				parts := strings.Split(string(encrypted), ".")
+				require.Len(t, parts, 5)
+
+				encode := base64.RawURLEncoding.EncodeToString
+				replace := func(i int, v []byte) []byte {
+					modified := append([]string(nil), parts...)
+					modified[i] = encode(v)
+					return []byte(strings.Join(modified, "."))
+				}
+
+				enckey, err := base64.RawURLEncoding.DecodeString(parts[1])
+				require.NoError(t, err, `base64 decode should succeed`)
+				ciphertext, err := base64.RawURLEncoding.DecodeString(parts[3])
+				require.NoError(t, err, `base64 decode should succeed`)
+
+				garbage := make([]byte, len(enckey))
+				_, err = rand.Read(garbage)
+				require.NoError(t, err, `rand.Read should succeed`)
+
+				// valid padding, but the key has the wrong length
+				shortkey, err := rsa.EncryptPKCS1v15(rand.Reader, &rsaKey.PublicKey, []byte("short"))
+				require.NoError(t, err, `rsa.EncryptPKCS1v15 should succeed`)
+
+				// valid padding and length, but not the key that was used
+				otherkey, err := rsa.EncryptPKCS1v15(rand.Reader, &rsaKey.PublicKey, make([]byte, tc.KeySize))
+				require.NoError(t, err, `rsa.EncryptPKCS1v15 should succeed`)
+
+				tampered := append([]byte(nil), ciphertext...)
+				tampered[0] ^= 0x01
+
+				messages := map[string][]byte{
+					"tampered content":   replace(3, tampered),
+					"invalid padding":    replace(1, garbage),
+					"wrong key size":     replace(1, shortkey),
+					"wrong key":          replace(1, otherkey),
+					"truncated key":      replace(1, enckey[:len(enckey)-1]),
+					"modified key":       replace(1, append(append([]byte(nil), enckey[:len(enckey)-1]...), enckey[len(enckey)-1]^0x01)),
+					"empty key":          replace(1, nil),
+					"oversized key":      replace(1, append(append([]byte(nil), enckey...), 0x00)),
+					"zero key":           replace(1, make([]byte, len(enckey))),
+					"tampered+malformed": []byte(strings.Join([]string{parts[0], encode(garbage), parts[2], encode(tampered), parts[4]}, ".")),
+				}
+
+				var expected string
+				for name, msg := range messages {
+					_, err := jwe.Decrypt(msg, jwe.WithKey(jwa.RSA1_5, rsaKey), jwe.WithAllowRSA1_5(true))
+					require.Error(t, err, `jwe.Decrypt should fail (%s)`, name)
+					require.False(t, errors.Is(err, jwa.ErrAlgorithmNotAllowed()), `jwe.Decrypt should not fail because of the policy (%s)`, name)
+					if expected == "" {
+						expected = err.Error()
+						continue
+					}
+					require.Equal(t, expected, err.Error(), `errors should be indistinguishable (%s)`, name)
+				}
+			})
+		}
+	})
+}
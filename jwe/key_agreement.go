@@ -0,0 +1,27 @@
+package jwe
+
+import (
+	"crypto"
+)
+
+// KeyAgreement is a private key that can perform the ECDH key agreement
+// used by ECDH-ES, ECDH-ES+A128KW, ECDH-ES+A192KW, and ECDH-ES+A256KW
+// without exposing the private key itself. Keys stored in a HSM or KMS
+// can implement this interface, and be passed to `jwe.Decrypt()` via
+// `jwe.WithKey()` like any other private key.
+//
+// `Public()` must return the public key that corresponds to the private
+// key: *ecdsa.PublicKey for NIST curves, x25519.PublicKey for X25519, or
+// x448.PublicKey for X448.
+//
+// `SharedSecret()` is called with the ephemeral public key of the sender
+// (the "epk" header), which is of the same type as `Public()`. It must
+// return the shared secret Z as defined in NIST SP 800-56A: for NIST curves,
+// the x-coordinate of the shared point, left-padded with zeros to the size
+// of the curve; for X25519 and X448, the 32 and 56 byte outputs of the
+// X25519 and X448 functions.
+// The key derivation function is applied by this package.
+type KeyAgreement interface {
+	Public() crypto.PublicKey
+	SharedSecret(pubkey crypto.PublicKey) ([]byte, error)
+}
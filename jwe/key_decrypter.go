@@ -0,0 +1,49 @@
+package jwe
+
+import (
+	"sync"
+
+	"github.com/sjwl/jwx/v2/jwa"
+)
+
+type KeyDecrypterFactory interface {
+	Create() (KeyDecrypter, error)
+}
+type KeyDecrypterFactoryFn func() (KeyDecrypter, error)
+
+func (fn KeyDecrypterFactoryFn) Create() (KeyDecrypter, error) {
+	return fn()
+}
+
+var muKeyDecrypterDB sync.RWMutex
+var keyDecrypterDB = make(map[jwa.KeyEncryptionAlgorithm]KeyDecrypterFactory)
+
+// RegisterKeyDecrypter is used to register a factory object that creates
+// KeyDecrypter objects based on the given algorithm.
+//
+// Registered factories take precedence over the built-in implementations,
+// so this function can be used to provide algorithms that this library
+// does not support, as well as to replace the implementation of an
+// existing algorithm (probably in your `init()`). The corresponding
+// `jwe.KeyEncrypter` should be registered using `jwe.RegisterKeyEncrypter()`
+func RegisterKeyDecrypter(alg jwa.KeyEncryptionAlgorithm, f KeyDecrypterFactory) {
+	muKeyDecrypterDB.Lock()
+	keyDecrypterDB[alg] = f
+	muKeyDecrypterDB.Unlock()
+}
+
+// UnregisterKeyDecrypter removes the factory object registered for the
+// given algorithm using `jwe.RegisterKeyDecrypter()`. Built-in
+// implementations are not affected.
+func UnregisterKeyDecrypter(alg jwa.KeyEncryptionAlgorithm) {
+	muKeyDecrypterDB.Lock()
+	delete(keyDecrypterDB, alg)
+	muKeyDecrypterDB.Unlock()
+}
+
+func lookupKeyDecrypter(alg jwa.KeyEncryptionAlgorithm) (KeyDecrypterFactory, bool) {
+	muKeyDecrypterDB.RLock()
+	f, ok := keyDecrypterDB[alg]
+	muKeyDecrypterDB.RUnlock()
+	return f, ok
+}
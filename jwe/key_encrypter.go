@@ -0,0 +1,49 @@
+package jwe
+
+import (
+	"sync"
+
+	"github.com/sjwl/jwx/v2/jwa"
+)
+
+type KeyEncrypterFactory interface {
+	Create() (KeyEncrypter, error)
+}
+type KeyEncrypterFactoryFn func() (KeyEncrypter, error)
+
+func (fn KeyEncrypterFactoryFn) Create() (KeyEncrypter, error) {
+	return fn()
+}
+
+var muKeyEncrypterDB sync.RWMutex
+var keyEncrypterDB = make(map[jwa.KeyEncryptionAlgorithm]KeyEncrypterFactory)
+
+// RegisterKeyEncrypter is used to register a factory object that creates
+// KeyEncrypter objects based on the given algorithm.
+//
+// Registered factories take precedence over the built-in implementations,
+// so this function can be used to provide algorithms that this library
+// does not support, as well as to replace the implementation of an
+// existing algorithm (probably in your `init()`). The corresponding
+// `jwe.KeyDecrypter` should be registered using `jwe.RegisterKeyDecrypter()`
+func RegisterKeyEncrypter(alg jwa.KeyEncryptionAlgorithm, f KeyEncrypterFactory) {
+	muKeyEncrypterDB.Lock()
+	keyEncrypterDB[alg] = f
+	muKeyEncrypterDB.Unlock()
+}
+
+// UnregisterKeyEncrypter removes the factory object registered for the
+// given algorithm using `jwe.RegisterKeyEncrypter()`. Built-in
+// implementations are not affected.
+func UnregisterKeyEncrypter(alg jwa.KeyEncryptionAlgorithm) {
+	muKeyEncrypterDB.Lock()
+	delete(keyEncrypterDB, alg)
+	muKeyEncrypterDB.Unlock()
+}
+
+func lookupKeyEncrypter(alg jwa.KeyEncryptionAlgorithm) (KeyEncrypterFactory, bool) {
+	muKeyEncrypterDB.RLock()
+	f, ok := keyEncrypterDB[alg]
+	muKeyEncrypterDB.RUnlock()
+	return f, ok
+}
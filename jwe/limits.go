@@ -0,0 +1,193 @@
+package jwe
+
+import (
+	"fmt"
+	"io"
+)
+
+// Default limits that are applied by `jwe.Decrypt()`, unless they are
+// changed using `jwe.Settings()` or the options passed to `jwe.Decrypt()`.
+const (
+	// DefaultMaxDecompressedSize is the default maximum size of a payload
+	// after decompression (see `jwe.WithMaxDecompressedSize()`)
+	DefaultMaxDecompressedSize int64 = 10 * 1024 * 1024
+
+	// DefaultMaxPBES2Count is the default maximum value of the "p2c"
+	// header (see `jwe.WithMaxPBES2Count()`)
+	DefaultMaxPBES2Count int64 = 10000
+
+	// DefaultMaxRecipients is the default maximum number of recipients
+	// in a message (see `jwe.WithMaxRecipients()`)
+	DefaultMaxRecipients = 100
+
+	// DefaultMaxCiphertextSize is the default maximum size of the
+	// ciphertext (see `jwe.WithMaxCiphertextSize()`)
+	DefaultMaxCiphertextSize int64 = 10 * 1024 * 1024
+)
+
+// decryptLimits holds the resource limits that apply to a single call
+// to `jwe.Decrypt()`. A limit of 0 or less means that there is no limit
+type decryptLimits struct {
+	decompressedSize int64
+	pbes2Count       int64
+	recipients       int
+	ciphertextSize   int64
+}
+
+func defaultDecryptLimits() decryptLimits {
+	return decryptLimits{
+		decompressedSize: DefaultMaxDecompressedSize,
+		pbes2Count:       DefaultMaxPBES2Count,
+		recipients:       DefaultMaxRecipients,
+		ciphertextSize:   DefaultMaxCiphertextSize,
+	}
+}
+
+// checkPBES2Count takes the "p2c" header as it was decoded from JSON,
+// so that values that do not fit in an int64 are also caught
+func (l decryptLimits) checkPBES2Count(count float64) error {
+	if l.pbes2Count > 0 && count > float64(l.pbes2Count) {
+		return fmt.Errorf(`%w: "p2c" is %.0f, but the maximum is %d`, errMaxPBES2CountExceeded, count, l.pbes2Count)
+	}
+	return nil
+}
+
+func (l decryptLimits) checkRecipients(count int) error {
+	if l.recipients > 0 && count > l.recipients {
+		return fmt.Errorf(`%w: message has %d recipients, but the maximum is %d`, errMaxRecipientsExceeded, count, l.recipients)
+	}
+	return nil
+}
+
+func (l decryptLimits) checkCiphertextSize(size int64) error {
+	if l.ciphertextSize > 0 && size > l.ciphertextSize {
+		return fmt.Errorf(`%w: ciphertext is larger than %d bytes`, errMaxCiphertextSizeExceeded, l.ciphertextSize)
+	}
+	return nil
+}
+
+// messageSizeHeadroom is the room that the early check on the size of a
+// serialized message leaves for everything but the ciphertext: the headers,
+// the encrypted keys, the IV, and the authentication tag
+const messageSizeHeadroom int64 = 1024 * 1024
+
+// checkMessageSize is an approximate check of the ciphertext size, which
+// takes place before the message is parsed. `size` is the size of the whole
+// serialized message, which is converted to the size it would have once base64
+// decoded. Up to messageSizeHeadroom bytes of that are assumed to be something
+// other than the ciphertext, so that only messages whose ciphertext can't
+// possibly be within the limit are rejected. The exact size of the ciphertext
+// is checked using checkCiphertextSize once the message has been parsed
+func (l decryptLimits) checkMessageSize(size int64) error {
+	if l.ciphertextSize > 0 && size/4*3-messageSizeHeadroom > l.ciphertextSize {
+		return fmt.Errorf(`%w: message is too large to contain a ciphertext of at most %d bytes`, errMaxCiphertextSizeExceeded, l.ciphertextSize)
+	}
+	return nil
+}
+
+// limitedReader reads from `src`, and returns an error wrapping `err`
+// once more than `max` bytes have been read
+type limitedReader struct {
+	src  io.Reader
+	max  int64
+	read int64
+	err  error
+}
+
+func newLimitedReader(src io.Reader, max int64, err error) *limitedReader {
+	return &limitedReader{src: src, max: max, err: err}
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	n, err := r.src.Read(p)
+	r.read += int64(n)
+	if r.read > r.max {
+		return 0, fmt.Errorf(`%w: data is larger than %d bytes`, r.err, r.max)
+	}
+	return n, err
+}
+
+type maxDecompressedSizeExceededError struct{}
+
+func (*maxDecompressedSizeExceededError) Error() string {
+	return `maximum decompressed size exceeded`
+}
+
+func (*maxDecompressedSizeExceededError) Is(target error) bool {
+	_, ok := target.(*maxDecompressedSizeExceededError)
+	return ok
+}
+
+type maxPBES2CountExceededError struct{}
+
+func (*maxPBES2CountExceededError) Error() string {
+	return `maximum PBES2 count exceeded`
+}
+
+func (*maxPBES2CountExceededError) Is(target error) bool {
+	_, ok := target.(*maxPBES2CountExceededError)
+	return ok
+}
+
+type maxRecipientsExceededError struct{}
+
+func (*maxRecipientsExceededError) Error() string {
+	return `maximum number of recipients exceeded`
+}
+
+func (*maxRecipientsExceededError) Is(target error) bool {
+	_, ok := target.(*maxRecipientsExceededError)
+	return ok
+}
+
+type maxCiphertextSizeExceededError struct{}
+
+func (*maxCiphertextSizeExceededError) Error() string {
+	return `maximum ciphertext size exceeded`
+}
+
+func (*maxCiphertextSizeExceededError) Is(target error) bool {
+	_, ok := target.(*maxCiphertextSizeExceededError)
+	return ok
+}
+
+var errMaxDecompressedSizeExceeded = &maxDecompressedSizeExceededError{}
+var errMaxPBES2CountExceeded = &maxPBES2CountExceededError{}
+var errMaxRecipientsExceeded = &maxRecipientsExceededError{}
+var errMaxCiphertextSizeExceeded = &maxCiphertextSizeExceededError{}
+
+// ErrMaxDecompressedSizeExceeded returns the immutable error used when
+// the decompressed payload of a JWE message is larger than the limit
+// specified by `jwe.WithMaxDecompressedSize()`.
+//
+// The return value should only be used for comparison using `errors.Is()`
+func ErrMaxDecompressedSizeExceeded() error {
+	return errMaxDecompressedSizeExceeded
+}
+
+// ErrMaxPBES2CountExceeded returns the immutable error used when the
+// "p2c" header of a JWE message is larger than the limit specified by
+// `jwe.WithMaxPBES2Count()`.
+//
+// The return value should only be used for comparison using `errors.Is()`
+func ErrMaxPBES2CountExceeded() error {
+	return errMaxPBES2CountExceeded
+}
+
+// ErrMaxRecipientsExceeded returns the immutable error used when a JWE
+// message has more recipients than the limit specified by
+// `jwe.WithMaxRecipients()`.
+//
+// The return value should only be used for comparison using `errors.Is()`
+func ErrMaxRecipientsExceeded() error {
+	return errMaxRecipientsExceeded
+}
+
+// ErrMaxCiphertextSizeExceeded returns the immutable error used when the
+// ciphertext of a JWE message is larger than the limit specified by
+// `jwe.WithMaxCiphertextSize()`.
+//
+// The return value should only be used for comparison using `errors.Is()`
+func ErrMaxCiphertextSizeExceeded() error {
+	return errMaxCiphertextSizeExceeded
+}
@@ -49,6 +49,10 @@ func WithPerRecipientHeaders(hdr Headers) WithKeySuboption {
 // passed to the option. If you specify other algorithm types such as `jwa.ContentEncryptionAlgorithm`,
 // then you will get an error when `jwe.Encrypt()` or `jwe.Decrypt()` is executed.
 //
+// When decrypting, keys that do not expose their private key material
+// may also be used: a crypto.Decrypter for the RSA-OAEP family, and
+// a `jwe.KeyAgreement` for ECDH-ES and its key wrapping variants.
+//
 // Unlike `jwe.WithKeySet()`, the `kid` field does not need to match for the key
 // to be tried.
 func WithKey(alg jwa.KeyAlgorithm, key interface{}, options ...WithKeySuboption) EncryptDecryptOption {
@@ -84,6 +88,20 @@ func WithKeySet(set jwk.Set, options ...WithKeySetSuboption) DecryptOption {
 	})
 }
 
+// WithAllowedAlgorithms specifies the key encryption ("alg") and content
+// encryption ("enc") algorithms that may be used to decrypt a JWE message.
+// `algs` should be values of type `jwa.KeyEncryptionAlgorithm` or
+// `jwa.ContentEncryptionAlgorithm`. Restrictions are applied separately to
+// each type, so `jwe.WithAllowedAlgorithms(jwa.RSA_OAEP_256)` restricts
+// "alg" while leaving "enc" unrestricted.
+//
+// This is a shorthand for a `jwa.AlgorithmPolicy` that only allows `algs`.
+// Unlike `jwe.WithAlgorithmPolicy()`, it does not replace the global policy
+// set via `jwe.Settings()`: the algorithm must be allowed by both.
+func WithAllowedAlgorithms(algs ...jwa.KeyAlgorithm) DecryptOption {
+	return &decryptOption{option.New(identAllowedAlgorithms{}, jwa.NewAlgorithmPolicy().Allow(algs...))}
+}
+
 // WithJSON specifies that the result of `jwe.Encrypt()` is serialized in
 // JSON format.
 //
@@ -3,10 +3,11 @@
 package jwe
 
 import (
+	"io"
 	"io/fs"
 
-	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/lestrrat-go/option"
+	"github.com/sjwl/jwx/v2/jwa"
 )
 
 type Option = option.Interface
@@ -62,6 +63,53 @@ type encryptOption struct {
 
 func (*encryptOption) encryptOption() {}
 
+// GlobalDecryptOption describes options that can be passed to either `jwe.Settings()`
+// or `jwe.Decrypt()`
+type GlobalDecryptOption interface {
+	Option
+	globalOption()
+	decryptOption()
+}
+
+type globalDecryptOption struct {
+	Option
+}
+
+func (*globalDecryptOption) globalOption() {}
+
+func (*globalDecryptOption) decryptOption() {}
+
+// GlobalEncryptDecryptOption describes options that can be passed to either `jwe.Settings()`,
+// `jwe.Encrypt()`, or `jwe.Decrypt()`
+type GlobalEncryptDecryptOption interface {
+	Option
+	globalOption()
+	encryptOption()
+	decryptOption()
+}
+
+type globalEncryptDecryptOption struct {
+	Option
+}
+
+func (*globalEncryptDecryptOption) globalOption() {}
+
+func (*globalEncryptDecryptOption) encryptOption() {}
+
+func (*globalEncryptDecryptOption) decryptOption() {}
+
+// GlobalOption describes options that can be passed to `jwe.Settings()`
+type GlobalOption interface {
+	Option
+	globalOption()
+}
+
+type globalOption struct {
+	Option
+}
+
+func (*globalOption) globalOption() {}
+
 // ReadFileOption is a type of `Option` that can be passed to `jwe.Parse`
 type ParseOption interface {
 	Option
@@ -110,20 +158,48 @@ type withKeySetSuboption struct {
 
 func (*withKeySetSuboption) withKeySetSuboption() {}
 
+type identAlgorithmPolicy struct{}
+type identAllowRSA1_5 struct{}
+type identAllowedAlgorithms struct{}
+type identCiphertextStorage struct{}
 type identCompress struct{}
 type identContentEncryptionAlgorithm struct{}
 type identFS struct{}
 type identKey struct{}
 type identKeyProvider struct{}
+type identKeyStrengthPolicy struct{}
 type identKeyUsed struct{}
+type identMaxCiphertextSize struct{}
+type identMaxDecompressedSize struct{}
+type identMaxPBES2Count struct{}
+type identMaxRecipients struct{}
 type identMergeProtectedHeaders struct{}
 type identMessage struct{}
 type identPerRecipientHeaders struct{}
 type identPretty struct{}
 type identProtectedHeaders struct{}
+type identReleaseUnverifiedPlaintext struct{}
 type identRequireKid struct{}
+type identSenderKey struct{}
+type identSenderPublicKey struct{}
 type identSerialization struct{}
 
+func (identAlgorithmPolicy) String() string {
+	return "WithAlgorithmPolicy"
+}
+
+func (identAllowRSA1_5) String() string {
+	return "WithAllowRSA1_5"
+}
+
+func (identAllowedAlgorithms) String() string {
+	return "WithAllowedAlgorithms"
+}
+
+func (identCiphertextStorage) String() string {
+	return "WithCiphertextStorage"
+}
+
 func (identCompress) String() string {
 	return "WithCompress"
 }
@@ -144,10 +220,30 @@ func (identKeyProvider) String() string {
 	return "WithKeyProvider"
 }
 
+func (identKeyStrengthPolicy) String() string {
+	return "WithKeyStrengthPolicy"
+}
+
 func (identKeyUsed) String() string {
 	return "WithKeyUsed"
 }
 
+func (identMaxCiphertextSize) String() string {
+	return "WithMaxCiphertextSize"
+}
+
+func (identMaxDecompressedSize) String() string {
+	return "WithMaxDecompressedSize"
+}
+
+func (identMaxPBES2Count) String() string {
+	return "WithMaxPBES2Count"
+}
+
+func (identMaxRecipients) String() string {
+	return "WithMaxRecipients"
+}
+
 func (identMergeProtectedHeaders) String() string {
 	return "WithMergeProtectedHeaders"
 }
@@ -168,18 +264,74 @@ func (identProtectedHeaders) String() string {
 	return "WithProtectedHeaders"
 }
 
+func (identReleaseUnverifiedPlaintext) String() string {
+	return "WithReleaseUnverifiedPlaintext"
+}
+
 func (identRequireKid) String() string {
 	return "WithRequireKid"
 }
 
+func (identSenderKey) String() string {
+	return "WithSenderKey"
+}
+
+func (identSenderPublicKey) String() string {
+	return "WithSenderPublicKey"
+}
+
 func (identSerialization) String() string {
 	return "WithSerialization"
 }
 
+// WithAlgorithmPolicy specifies the `jwa.AlgorithmPolicy` that decides which
+// key encryption ("alg") and content encryption ("enc") algorithms may be used
+// to decrypt a JWE message. Messages, recipients, and keys whose algorithms are
+// rejected by the policy are never passed to the decrypter.
+//
+// When passed to `jwe.Settings()`, the policy is used by all subsequent calls
+// to `jwe.Decrypt()`. When passed to `jwe.Decrypt()`, the policy replaces the
+// global policy for that call. By default no policy is set, and all algorithms
+// are allowed.
+func WithAlgorithmPolicy(v *jwa.AlgorithmPolicy) GlobalDecryptOption {
+	return &globalDecryptOption{option.New(identAlgorithmPolicy{}, v)}
+}
+
+// WithAllowRSA1_5 specifies whether `jwe.Decrypt()` may decrypt messages
+// using `jwa.RSA1_5`. RSA PKCS #1 v1.5 encryption is prone to padding oracle
+// attacks, and is therefore disabled by default: recipients and keys using
+// RSA1_5 are rejected, with an error that matches `jwa.ErrAlgorithmNotAllowed()`.
+// Encryption using RSA1_5 is not affected.
+//
+// When enabled, a malformed encrypted key is replaced by a random key as
+// described in RFC 7516 Section 11.5, so that it results in the same error
+// as a message whose content has been tampered with.
+//
+// When passed to `jwe.Settings()`, the value is used by all subsequent
+// calls to `jwe.Decrypt()`. When passed to `jwe.Decrypt()`, the value
+// replaces the global value for that call.
+func WithAllowRSA1_5(v bool) GlobalDecryptOption {
+	return &globalDecryptOption{option.New(identAllowRSA1_5{}, v)}
+}
+
+// WithCiphertextStorage specifies where `jwe.DecryptStream()` keeps the
+// ciphertext while it is being authenticated, when the ciphertext can't be
+// read twice from the source. The decoded ciphertext is written to the
+// storage as the authentication tag is verified, and read back to be
+// decrypted once the tag has been verified. If the storage is also an
+// io.Seeker, it is read back starting from the position it was in when
+// `jwe.DecryptStream()` started writing to it.
+//
+// The storage must be able to hold all of the ciphertext (e.g. a temporary
+// file), and must not be modified until `jwe.DecryptStream()` returns.
+// This option has no effect on `jwe.Decrypt()`.
+func WithCiphertextStorage(v io.ReadWriter) DecryptOption {
+	return &decryptOption{option.New(identCiphertextStorage{}, v)}
+}
+
 // WithCompress specifies the compression algorithm to use when encrypting
-// a payload using `jwe.Encrypt` (Yes, we know it can only be "" or "DEF",
-// but the way the specification is written it could allow for more options,
-// and therefore this option takes an argument)
+// a payload using `jwe.Encrypt`. Besides `jwa.Deflate`, algorithms
+// registered using `jwe.RegisterCompressor()` may be specified.
 func WithCompress(v jwa.CompressionAlgorithm) EncryptOption {
 	return &encryptOption{option.New(identCompress{}, v)}
 }
@@ -199,6 +351,20 @@ func WithKeyProvider(v KeyProvider) DecryptOption {
 	return &decryptOption{option.New(identKeyProvider{}, v)}
 }
 
+// WithKeyStrengthPolicy specifies the `jwa.KeyStrengthPolicy` that decides the
+// minimum strength of keys used to encrypt or decrypt JWE messages. This
+// includes the keys used for key encryption, as well as the AES key used
+// for content encryption. `jwe.Encrypt()` fails if any of the keys is too
+// weak, and `jwe.Decrypt()` does not use keys that are too weak.
+//
+// When passed to `jwe.Settings()`, the policy is used by all subsequent calls
+// to `jwe.Encrypt()` and `jwe.Decrypt()`. When passed to `jwe.Encrypt()` or
+// `jwe.Decrypt()`, the policy replaces the global policy for that call. By
+// default no policy is set, and all keys are allowed.
+func WithKeyStrengthPolicy(v *jwa.KeyStrengthPolicy) GlobalEncryptDecryptOption {
+	return &globalEncryptDecryptOption{option.New(identKeyStrengthPolicy{}, v)}
+}
+
 // WithKeyUsed allows you to specify the `jwe.Decrypt()` function to
 // return the key used for decryption. This may be useful when
 // you specify multiple key sources or if you pass a `jwk.Set`
@@ -213,6 +379,65 @@ func WithKeyUsed(v interface{}) DecryptOption {
 	return &decryptOption{option.New(identKeyUsed{}, v)}
 }
 
+// WithMaxCiphertextSize specifies the maximum size of the ciphertext,
+// in bytes after base64 decoding, that `jwe.Decrypt()` accepts. Messages
+// whose ciphertext exceeds the limit are rejected before any decryption
+// takes place, with an error that matches `jwe.ErrMaxCiphertextSizeExceeded()`.
+// `jwe.Decrypt()` also performs an approximate check before parsing the
+// message, so that oversized messages are rejected early. As it can't tell
+// the ciphertext apart from the rest of the message at that point, it allows
+// up to 1MiB (once base64 decoded) for the headers and the encrypted keys,
+// and only rejects messages that can't possibly be within the limit.
+//
+// The default is `jwe.DefaultMaxCiphertextSize`. A value of 0 or
+// less removes the limit. When passed to `jwe.Settings()`, the limit is
+// used by all subsequent calls to `jwe.Decrypt()`. When passed to
+// `jwe.Decrypt()` or `jwe.DecryptStream()`, the limit replaces the
+// global limit for that call.
+func WithMaxCiphertextSize(v int64) GlobalDecryptOption {
+	return &globalDecryptOption{option.New(identMaxCiphertextSize{}, v)}
+}
+
+// WithMaxDecompressedSize specifies the maximum number of bytes that
+// a compressed payload may expand to when it is decompressed by
+// `jwe.Decrypt()`. Payloads that exceed the limit are rejected with an
+// error that matches `jwe.ErrMaxDecompressedSizeExceeded()`.
+//
+// The default is `jwe.DefaultMaxDecompressedSize`. A value of 0 or
+// less removes the limit. When passed to `jwe.Settings()`, the limit is
+// used by all subsequent calls to `jwe.Decrypt()`. When passed to
+// `jwe.Decrypt()` or `jwe.DecryptStream()`, the limit replaces the
+// global limit for that call.
+func WithMaxDecompressedSize(v int64) GlobalDecryptOption {
+	return &globalDecryptOption{option.New(identMaxDecompressedSize{}, v)}
+}
+
+// WithMaxPBES2Count specifies the maximum PBES2 iteration count ("p2c")
+// that `jwe.Decrypt()` accepts. Recipients whose "p2c" header exceeds
+// the limit are not decrypted, and the error matches
+// `jwe.ErrMaxPBES2CountExceeded()`.
+//
+// The default is `jwe.DefaultMaxPBES2Count`. A value of 0 or less
+// removes the limit. When passed to `jwe.Settings()`, the limit is used
+// by all subsequent calls to `jwe.Decrypt()`. When passed to
+// `jwe.Decrypt()`, the limit replaces the global limit for that call.
+func WithMaxPBES2Count(v int64) GlobalDecryptOption {
+	return &globalDecryptOption{option.New(identMaxPBES2Count{}, v)}
+}
+
+// WithMaxRecipients specifies the maximum number of recipients that
+// `jwe.Decrypt()` tries to decrypt. Messages with more recipients than
+// the limit are rejected before any decryption takes place, with an
+// error that matches `jwe.ErrMaxRecipientsExceeded()`.
+//
+// The default is `jwe.DefaultMaxRecipients`. A value of 0 or less
+// removes the limit. When passed to `jwe.Settings()`, the limit is used
+// by all subsequent calls to `jwe.Decrypt()`. When passed to
+// `jwe.Decrypt()`, the limit replaces the global limit for that call.
+func WithMaxRecipients(v int) GlobalDecryptOption {
+	return &globalDecryptOption{option.New(identMaxRecipients{}, v)}
+}
+
 // WithMergeProtectedHeaders specify that when given multiple headers
 // as options to `jwe.Encrypt`, these headers should be merged instead
 // of overwritten
@@ -238,6 +463,20 @@ func WithPretty(v bool) WithJSONSuboption {
 	return &withJSONSuboption{option.New(identPretty{}, v)}
 }
 
+// WithReleaseUnverifiedPlaintext specifies that `jwe.DecryptStream()` may
+// write the payload to its destination BEFORE the message is authenticated,
+// so that the ciphertext is only read once. If the message has been tampered
+// with, forged or corrupted payload is written to the destination (and
+// goes through the decompressor, if the message is compressed) before
+// `jwe.DecryptStream()` returns an error.
+//
+// Only use this option if the destination can safely hold unauthenticated
+// data, and everything written to it is discarded when `jwe.DecryptStream()`
+// fails. This option has no effect on `jwe.Decrypt()`.
+func WithReleaseUnverifiedPlaintext(v bool) DecryptOption {
+	return &decryptOption{option.New(identReleaseUnverifiedPlaintext{}, v)}
+}
+
 // WithrequiredKid specifies whether the keys in the jwk.Set should
 // only be matched if the target JWE message's Key ID and the Key ID
 // in the given key matches.
@@ -245,6 +484,31 @@ func WithRequireKid(v bool) WithKeySetSuboption {
 	return &withKeySetSuboption{option.New(identRequireKid{}, v)}
 }
 
+// WithSenderKey specifies the static private key of the sender, which is
+// required to encrypt messages using the ECDH-1PU family of algorithms
+// (`jwa.ECDH_1PU`, `jwa.ECDH_1PU_A128KW`, `jwa.ECDH_1PU_A192KW`, and
+// `jwa.ECDH_1PU_A256KW`).
+//
+// The key can be an *ecdsa.PrivateKey, an x25519.PrivateKey, an x448.PrivateKey,
+// a `jwe.KeyAgreement`, or a jwk.Key holding one of the first three. It must use the same curve as
+// the keys of the recipients. If the key is a jwk.Key with a key ID, the
+// "skid" header is set to the key ID.
+func WithSenderKey(v interface{}) EncryptOption {
+	return &encryptOption{option.New(identSenderKey{}, v)}
+}
+
+// WithSenderPublicKey specifies the static public key of the sender, which
+// is required to decrypt messages that were encrypted using the ECDH-1PU
+// family of algorithms.
+//
+// The key can be a raw public key (e.g. *ecdsa.PublicKey or x25519.PublicKey),
+// a jwk.Key, or a jwk.Set. If a jwk.Set is given, the key is looked up using the
+// "skid" header of the message. If a jwk.Key with a key ID is given and the message
+// has a "skid" header, the two must match.
+func WithSenderPublicKey(v interface{}) DecryptOption {
+	return &decryptOption{option.New(identSenderPublicKey{}, v)}
+}
+
 // WithCompact specifies that the result of `jwe.Encrypt()` is serialized in
 // compact format.
 //
@@ -9,17 +9,29 @@ import (
 )
 
 func TestOptionIdent(t *testing.T) {
+	require.Equal(t, "WithAlgorithmPolicy", identAlgorithmPolicy{}.String())
+	require.Equal(t, "WithAllowRSA1_5", identAllowRSA1_5{}.String())
+	require.Equal(t, "WithAllowedAlgorithms", identAllowedAlgorithms{}.String())
+	require.Equal(t, "WithCiphertextStorage", identCiphertextStorage{}.String())
 	require.Equal(t, "WithCompress", identCompress{}.String())
 	require.Equal(t, "WithContentEncryption", identContentEncryptionAlgorithm{}.String())
 	require.Equal(t, "WithFS", identFS{}.String())
 	require.Equal(t, "WithKey", identKey{}.String())
 	require.Equal(t, "WithKeyProvider", identKeyProvider{}.String())
+	require.Equal(t, "WithKeyStrengthPolicy", identKeyStrengthPolicy{}.String())
 	require.Equal(t, "WithKeyUsed", identKeyUsed{}.String())
+	require.Equal(t, "WithMaxCiphertextSize", identMaxCiphertextSize{}.String())
+	require.Equal(t, "WithMaxDecompressedSize", identMaxDecompressedSize{}.String())
+	require.Equal(t, "WithMaxPBES2Count", identMaxPBES2Count{}.String())
+	require.Equal(t, "WithMaxRecipients", identMaxRecipients{}.String())
 	require.Equal(t, "WithMergeProtectedHeaders", identMergeProtectedHeaders{}.String())
 	require.Equal(t, "WithMessage", identMessage{}.String())
 	require.Equal(t, "WithPerRecipientHeaders", identPerRecipientHeaders{}.String())
 	require.Equal(t, "WithPretty", identPretty{}.String())
 	require.Equal(t, "WithProtectedHeaders", identProtectedHeaders{}.String())
+	require.Equal(t, "WithReleaseUnverifiedPlaintext", identReleaseUnverifiedPlaintext{}.String())
 	require.Equal(t, "WithRequireKid", identRequireKid{}.String())
+	require.Equal(t, "WithSenderKey", identSenderKey{}.String())
+	require.Equal(t, "WithSenderPublicKey", identSenderPublicKey{}.String())
 	require.Equal(t, "WithSerialization", identSerialization{}.String())
 }
@@ -0,0 +1,91 @@
+package jwe
+
+import (
+	"sync"
+
+	"github.com/sjwl/jwx/v2/jwa"
+)
+
+var muGlobalPolicy sync.RWMutex
+var globalPolicy *jwa.AlgorithmPolicy
+var globalKeyStrengthPolicy *jwa.KeyStrengthPolicy
+var globalDecryptLimits = defaultDecryptLimits()
+var globalAllowRSA1_5 bool
+
+// denyRSA1_5 is the policy that applies to `jwe.Decrypt()` unless
+// RSA1_5 has been enabled using `jwe.WithAllowRSA1_5()`
+var denyRSA1_5 = jwa.NewAlgorithmPolicy().Deny(jwa.RSA1_5)
+
+// Settings controls global settings that are specific to JWE.
+func Settings(options ...GlobalOption) {
+	//nolint:forcetypeassert
+	for _, option := range options {
+		switch option.Ident() {
+		case identAlgorithmPolicy{}:
+			muGlobalPolicy.Lock()
+			globalPolicy = option.Value().(*jwa.AlgorithmPolicy)
+			muGlobalPolicy.Unlock()
+		case identAllowRSA1_5{}:
+			muGlobalPolicy.Lock()
+			globalAllowRSA1_5 = option.Value().(bool)
+			muGlobalPolicy.Unlock()
+		case identKeyStrengthPolicy{}:
+			muGlobalPolicy.Lock()
+			globalKeyStrengthPolicy = option.Value().(*jwa.KeyStrengthPolicy)
+			muGlobalPolicy.Unlock()
+		case identMaxDecompressedSize{}:
+			muGlobalPolicy.Lock()
+			globalDecryptLimits.decompressedSize = option.Value().(int64)
+			muGlobalPolicy.Unlock()
+		case identMaxPBES2Count{}:
+			muGlobalPolicy.Lock()
+			globalDecryptLimits.pbes2Count = option.Value().(int64)
+			muGlobalPolicy.Unlock()
+		case identMaxRecipients{}:
+			muGlobalPolicy.Lock()
+			globalDecryptLimits.recipients = option.Value().(int)
+			muGlobalPolicy.Unlock()
+		case identMaxCiphertextSize{}:
+			muGlobalPolicy.Lock()
+			globalDecryptLimits.ciphertextSize = option.Value().(int64)
+			muGlobalPolicy.Unlock()
+		}
+	}
+}
+
+func getGlobalPolicy() *jwa.AlgorithmPolicy {
+	muGlobalPolicy.RLock()
+	defer muGlobalPolicy.RUnlock()
+	return globalPolicy
+}
+
+func getGlobalKeyStrengthPolicy() *jwa.KeyStrengthPolicy {
+	muGlobalPolicy.RLock()
+	defer muGlobalPolicy.RUnlock()
+	return globalKeyStrengthPolicy
+}
+
+func getGlobalAllowRSA1_5() bool {
+	muGlobalPolicy.RLock()
+	defer muGlobalPolicy.RUnlock()
+	return globalAllowRSA1_5
+}
+
+func getGlobalDecryptLimits() decryptLimits {
+	muGlobalPolicy.RLock()
+	defer muGlobalPolicy.RUnlock()
+	return globalDecryptLimits
+}
+
+// algorithmPolicies is the list of policies that apply to a single
+// call to `jwe.Decrypt()`. An algorithm must be allowed by all of them
+type algorithmPolicies []*jwa.AlgorithmPolicy
+
+func (list algorithmPolicies) Check(alg jwa.KeyAlgorithm) error {
+	for _, p := range list {
+		if err := p.Check(alg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
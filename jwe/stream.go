@@ -0,0 +1,891 @@
+package jwe
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/sjwl/jwx/v2/internal/base64"
+	"github.com/sjwl/jwx/v2/internal/json"
+	"github.com/sjwl/jwx/v2/jwa"
+	contentcipher "github.com/sjwl/jwx/v2/jwe/internal/cipher"
+	"github.com/sjwl/jwx/v2/jwe/internal/keyenc"
+)
+
+// streamChunkSize is the size of the ciphertext that is decrypted at once
+const streamChunkSize = 32 * 1024
+
+// maxStreamTagSize is the maximum size of the base64 encoded "tag"
+// that is read after the ciphertext in compact serialization
+const maxStreamTagSize = 1024
+
+// streamContentCipher is implemented by content ciphers that can
+// encrypt and decrypt content without having all of it in memory.
+// The built-in AES-GCM, AES-CBC-HMAC-SHA2, and XChaCha20-Poly1305
+// content ciphers implement this interface, but ciphers registered
+// using `jwe.RegisterContentCipher()` usually do not.
+type streamContentCipher interface {
+	EncryptStream(dst io.Writer, cek, aad []byte) ([]byte, contentcipher.StreamEncrypter, error)
+	DecryptStream(dst io.Writer, cek, iv, aad []byte) (contentcipher.StreamDecrypter, error)
+}
+
+// EncryptStream is the streaming counterpart of `jwe.Encrypt()`. It reads
+// the payload from `src` and writes the serialized JWE message to `dst`,
+// without loading the entire payload or the ciphertext in memory.
+//
+// It accepts the same options as `jwe.Encrypt()`: keys, protected headers,
+// compression, and the serialization format are handled the same way.
+// Only the built-in content encryption algorithms (A128GCM, A192GCM,
+// A256GCM, A128CBC-HS256, A192CBC-HS384, A256CBC-HS512, and XC20P) are
+// supported.
+//
+// When the JSON serialization format is used, the "ciphertext" and "tag"
+// members are written after all other members, so that the result can be
+// decrypted by `jwe.DecryptStream()` using bounded memory.
+//
+// If an error occurs, a partial message may have been written to `dst`.
+func EncryptStream(dst io.Writer, src io.Reader, options ...EncryptOption) error {
+	ectx, err := newEncryptCtx(options)
+	if err != nil {
+		return fmt.Errorf(`jwe.EncryptStream: %w`, err)
+	}
+
+	if _, ok := ectx.contentcrypt.(*hpkeContentCipher); ok {
+		return fmt.Errorf(`jwe.EncryptStream: HPKE integrated encryption does not support streaming`)
+	}
+
+	calg := ectx.protected.ContentEncryption()
+	sc, ok := ectx.contentcrypt.(streamContentCipher)
+	if !ok {
+		return fmt.Errorf(`jwe.EncryptStream: content encryption algorithm %q does not support streaming`, calg)
+	}
+
+	// The encrypted key is written before the ciphertext, so it can't
+	// depend on the authentication tag
+	if len(ectx.pending) > 0 {
+		return fmt.Errorf(`jwe.EncryptStream: key encryption algorithm %q does not support streaming`, ectx.pending[0].wrapper.Algorithm())
+	}
+
+	b64 := base64.NewEncoder(dst)
+	iv, enc, err := sc.EncryptStream(b64, ectx.cek, ectx.aad)
+	if err != nil {
+		return fmt.Errorf(`jwe.EncryptStream: failed to encrypt payload: %w`, err)
+	}
+
+	msg, err := ectx.newMessage(iv)
+	if err != nil {
+		return fmt.Errorf(`jwe.EncryptStream: %w`, err)
+	}
+
+	prefix, suffix, err := streamEnvelope(msg, ectx.format)
+	if err != nil {
+		return fmt.Errorf(`jwe.EncryptStream: %w`, err)
+	}
+
+	if _, err := dst.Write(prefix); err != nil {
+		return fmt.Errorf(`jwe.EncryptStream: failed to write message: %w`, err)
+	}
+
+	var w io.WriteCloser = enc
+	if ectx.compressor != nil {
+		cw, err := ectx.compressor.NewWriter(enc)
+		if err != nil {
+			return fmt.Errorf(`jwe.EncryptStream: failed to create compression writer: %w`, err)
+		}
+		w = cw
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		return fmt.Errorf(`jwe.EncryptStream: failed to encrypt payload: %w`, err)
+	}
+	if w != enc {
+		if err := w.Close(); err != nil {
+			return fmt.Errorf(`jwe.EncryptStream: failed to compress payload: %w`, err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf(`jwe.EncryptStream: failed to encrypt payload: %w`, err)
+	}
+	if err := b64.Close(); err != nil {
+		return fmt.Errorf(`jwe.EncryptStream: failed to flush base64 encoded ciphertext: %w`, err)
+	}
+
+	if _, err := dst.Write(suffix(base64.EncodeToString(enc.Tag()))); err != nil {
+		return fmt.Errorf(`jwe.EncryptStream: failed to write message: %w`, err)
+	}
+	return nil
+}
+
+// streamEnvelope serializes `msg`, which does not contain the ciphertext
+// and the tag, and returns the parts that should be written before and
+// after the base64 encoded ciphertext.
+func streamEnvelope(msg *Message, format int) ([]byte, func(string) []byte, error) {
+	switch format {
+	case fmtCompact:
+		serialized, err := Compact(msg)
+		if err != nil {
+			return nil, nil, fmt.Errorf(`failed to serialize message: %w`, err)
+		}
+		// serialized is "header.key.iv..", as both the ciphertext and the tag are empty
+		prefix := serialized[:len(serialized)-1]
+		return prefix, func(tag string) []byte {
+			return []byte(`.` + tag)
+		}, nil
+	case fmtJSON:
+		serialized, err := json.Marshal(msg)
+		if err != nil {
+			return nil, nil, fmt.Errorf(`failed to serialize message: %w`, err)
+		}
+		prefix := append(bytes.TrimSuffix(serialized, []byte(`}`)), []byte(`,"ciphertext":"`)...)
+		return prefix, func(tag string) []byte {
+			return []byte(`","tag":"` + tag + `"}`)
+		}, nil
+	case fmtJSONPretty:
+		serialized, err := json.MarshalIndent(msg, "", "  ")
+		if err != nil {
+			return nil, nil, fmt.Errorf(`failed to serialize message: %w`, err)
+		}
+		prefix := append(bytes.TrimSuffix(serialized, []byte("\n}")), []byte(",\n  \"ciphertext\": \"")...)
+		return prefix, func(tag string) []byte {
+			return []byte("\",\n  \"tag\": \"" + tag + "\"\n}")
+		}, nil
+	default:
+		return nil, nil, fmt.Errorf(`invalid serialization`)
+	}
+}
+
+// DecryptStream is the streaming counterpart of `jwe.Decrypt()`. It reads
+// a JWE message in either compact or JSON serialization format from `src`,
+// and writes the decrypted payload to `dst`, without loading the entire
+// ciphertext or the payload in memory.
+//
+// It accepts the same options as `jwe.Decrypt()`. Only the built-in content
+// encryption algorithms are supported. Unlike `jwe.Decrypt()`, the content
+// encryption key is decided before the ciphertext is read: the first key
+// that successfully decrypts the encrypted key of a recipient is used, even if
+// the ciphertext later fails to be authenticated using that key.
+//
+// The authentication tag can only be verified after all of the ciphertext
+// has been read, so the ciphertext is read twice: once to verify the tag,
+// and once more to decrypt it into `dst`. Nothing is written to `dst` until
+// the tag has been verified. The ciphertext is read again from
+//
+//   - memory, if it had to be read into memory anyway (see below)
+//   - `src`, if it is an io.ReadSeeker. `src` must not be modified until
+//     DecryptStream returns, and its position is restored to the end of
+//     the message afterwards
+//   - the storage given by `jwe.WithCiphertextStorage()`, otherwise
+//
+// If none of these are available, DecryptStream fails before reading the
+// ciphertext. `jwe.WithReleaseUnverifiedPlaintext()` makes DecryptStream
+// read the ciphertext only once, writing the payload to `dst` BEFORE the
+// message has been authenticated.
+//
+// If the ciphertext read the second time does not match the one that was
+// authenticated, DecryptStream returns an error after having written part
+// of the payload to `dst`.
+//
+// In JSON serialization format, the message can only be decrypted using
+// bounded memory if the "ciphertext" member comes after all other members
+// except "tag", as is the case for messages created by `jwe.EncryptStream()`.
+// Otherwise the ciphertext is read into memory before being decrypted, and
+// any member other than "tag" that appears after "ciphertext" is an error.
+//
+// The limits on the number of recipients and the PBES2 count (see
+// `jwe.WithMaxRecipients()` and `jwe.WithMaxPBES2Count()`) apply to
+// DecryptStream as they do to `jwe.Decrypt()`. As DecryptStream is meant to
+// process large payloads, the limits on the ciphertext size and the
+// decompressed size only apply when `jwe.WithMaxCiphertextSize()` or
+// `jwe.WithMaxDecompressedSize()` are passed to DecryptStream itself.
+func DecryptStream(dst io.Writer, src io.Reader, options ...DecryptOption) error {
+	dctx, err := newDecryptCtx(options)
+	if err != nil {
+		return fmt.Errorf(`jwe.DecryptStream: %w`, err)
+	}
+
+	// Keep track of the position of the ciphertext in `src`, so that
+	// it can be read again once it has been authenticated
+	seeker, _ := src.(io.ReadSeeker)
+	var start int64
+	if seeker != nil && !dctx.releaseUnverified {
+		// Seek fails for non-seekable files, such as pipes
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			seeker = nil
+		}
+	}
+	counter := &countingReader{src: src}
+	br := bufio.NewReader(counter)
+	sm, err := readStreamMessage(br)
+	if err != nil {
+		return fmt.Errorf(`jwe.DecryptStream: failed to parse message: %w`, err)
+	}
+	msg := sm.msg
+	offset := start + counter.n - int64(br.Buffered())
+
+	storage := dctx.ciphertextStorage
+	if !dctx.releaseUnverified && sm.buffered == nil && seeker == nil && storage == nil {
+		return fmt.Errorf(`jwe.DecryptStream: the ciphertext must be read twice to be authenticated before it is decrypted: src must be an io.ReadSeeker, or jwe.WithCiphertextStorage() must be specified (see also jwe.WithReleaseUnverifiedPlaintext())`)
+	}
+
+	if err := dctx.setMessage(msg); err != nil {
+		return fmt.Errorf(`jwe.DecryptStream: %w`, err)
+	}
+
+	// HPKE integrated encryption does not use a JWE content cipher
+	for _, recipient := range msg.recipients {
+		if alg := dctx.recipientAlgorithm(recipient); keyenc.IsHPKEIntegrated(alg) {
+			return fmt.Errorf(`jwe.DecryptStream: key encryption algorithm %q does not support streaming`, alg)
+		}
+	}
+	if alg := msg.protectedHeaders.Algorithm(); keyenc.IsHPKEIntegrated(alg) {
+		return fmt.Errorf(`jwe.DecryptStream: key encryption algorithm %q does not support streaming`, alg)
+	}
+
+	calg := msg.protectedHeaders.ContentEncryption()
+	cc, err := newContentCipher(calg)
+	if err != nil {
+		return fmt.Errorf(`jwe.DecryptStream: %w`, err)
+	}
+	sc, ok := cc.(streamContentCipher)
+	if !ok {
+		return fmt.Errorf(`jwe.DecryptStream: content encryption algorithm %q does not support streaming`, calg)
+	}
+
+	var cek []byte
+	var hdrs Headers
+	decryptCEK := func(ctx context.Context, alg jwa.KeyEncryptionAlgorithm, key interface{}, recipient Recipient) ([]byte, error) {
+		if keyenc.IsECDH1PUKeyWrap(alg) {
+			return nil, fmt.Errorf(`key encryption algorithm %q does not support streaming`, alg)
+		}
+		dec, h2, err := dctx.newDecrypter(ctx, alg, key, recipient)
+		if err != nil {
+			return nil, err
+		}
+		k, err := dec.DecryptKey(recipient.EncryptedKey())
+		if err != nil {
+			return nil, fmt.Errorf(`failed to decrypt key: %w`, err)
+		}
+		if len(k) != cc.KeySize() {
+			return nil, fmt.Errorf(`invalid content encryption key size: expected %d bytes, got %d bytes`, cc.KeySize(), len(k))
+		}
+		cek = k
+		hdrs = h2
+		return k, nil
+	}
+
+	ctx := context.TODO()
+	var lastError error
+	for _, recipient := range dctx.recipients {
+		if _, err := dctx.try(ctx, recipient, decryptCEK); err != nil {
+			lastError = err
+			continue
+		}
+		break
+	}
+	if cek == nil {
+		return fmt.Errorf(`jwe.DecryptStream: failed to decrypt any of the recipients (last error = %w)`, lastError)
+	}
+
+	aad := append([]byte(nil), dctx.computedAad...)
+	if dctx.aad != nil {
+		aad = append(append(aad, '.'), dctx.aad...)
+	}
+
+	encoded := &countingReader{src: sm.ciphertext}
+	var ciphertext io.Reader = base64.NewDecoder(encoded)
+	if max := dctx.streamLimits.ciphertextSize; max > 0 {
+		ciphertext = newLimitedReader(ciphertext, max, errMaxCiphertextSizeExceeded)
+	}
+
+	newDecryptReader := func(src io.Reader, tag func() ([]byte, error), dst io.Writer) (*decryptReader, error) {
+		r := &decryptReader{
+			src:   src,
+			tag:   tag,
+			chunk: make([]byte, streamChunkSize),
+		}
+		if dst == nil {
+			dst = &r.buf
+		}
+		dec, err := sc.DecryptStream(dst, cek, msg.initializationVector, aad)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to decrypt payload: %w`, err)
+		}
+		r.dec = dec
+		return r, nil
+	}
+
+	var r *decryptReader
+	if dctx.releaseUnverified {
+		r, err = newDecryptReader(ciphertext, sm.readTag, nil)
+		if err != nil {
+			return fmt.Errorf(`jwe.DecryptStream: %w`, err)
+		}
+	} else {
+		if seeker != nil {
+			// Return to where the first pass stopped reading `src`
+			defer func() { _, _ = seeker.Seek(start+counter.n, io.SeekStart) }()
+		}
+		r, err = authenticateStream(sm, ciphertext, encoded, storage, seeker, offset, newDecryptReader)
+		if err != nil {
+			return fmt.Errorf(`jwe.DecryptStream: %w`, err)
+		}
+	}
+
+	var plaintext io.Reader = r
+	if zip := hdrs.Compression(); zip != jwa.NoCompress {
+		c, err := lookupCompressor(zip)
+		if err != nil {
+			return fmt.Errorf(`jwe.DecryptStream: %w`, err)
+		}
+		cr, err := c.NewReader(r)
+		if err != nil {
+			return fmt.Errorf(`jwe.DecryptStream: failed to create decompression reader: %w`, err)
+		}
+		defer cr.Close()
+		plaintext = cr
+		if max := dctx.streamLimits.decompressedSize; max > 0 {
+			plaintext = newLimitedReader(cr, max, errMaxDecompressedSizeExceeded)
+		}
+	}
+	if _, err := io.Copy(dst, plaintext); err != nil {
+		return fmt.Errorf(`jwe.DecryptStream: failed to decrypt payload: %w`, err)
+	}
+
+	// Make sure that all of the ciphertext has been authenticated, even
+	// if the compressed payload ended before the ciphertext did
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return fmt.Errorf(`jwe.DecryptStream: failed to decrypt payload: %w`, err)
+	}
+
+	if err := msg.Set(TagKey, r.tagValue); err != nil {
+		return fmt.Errorf(`jwe.DecryptStream: failed to set %s: %w`, TagKey, err)
+	}
+	dctx.populateMessage()
+	return nil
+}
+
+// authenticateStream reads all of the ciphertext from `ciphertext` and
+// verifies the tag, without writing the plaintext anywhere. It returns
+// a decryptReader that reads the ciphertext again, from memory, `seeker`,
+// or `storage`, in that order of preference, and decrypts it.
+func authenticateStream(sm *streamMessage, ciphertext io.Reader, encoded *countingReader, storage io.ReadWriter, seeker io.ReadSeeker, offset int64, newDecryptReader func(io.Reader, func() ([]byte, error), io.Writer) (*decryptReader, error)) (*decryptReader, error) {
+	var reread func() (io.Reader, error)
+	switch {
+	case sm.buffered != nil:
+		reread = func() (io.Reader, error) {
+			return base64.NewDecoder(bytes.NewReader(sm.buffered)), nil
+		}
+	case seeker != nil:
+		reread = func() (io.Reader, error) {
+			if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+				return nil, fmt.Errorf(`failed to seek to ciphertext: %w`, err)
+			}
+			return base64.NewDecoder(io.LimitReader(seeker, encoded.n)), nil
+		}
+	default:
+		var storageOffset int64
+		storageSeeker, isSeeker := storage.(io.Seeker)
+		if isSeeker {
+			pos, err := storageSeeker.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, fmt.Errorf(`failed to get the position of the ciphertext storage: %w`, err)
+			}
+			storageOffset = pos
+		}
+		decoded := &countingReader{src: io.TeeReader(ciphertext, storage)}
+		ciphertext = decoded
+		reread = func() (io.Reader, error) {
+			if isSeeker {
+				if _, err := storageSeeker.Seek(storageOffset, io.SeekStart); err != nil {
+					return nil, fmt.Errorf(`failed to seek to ciphertext storage: %w`, err)
+				}
+			}
+			return io.LimitReader(storage, decoded.n), nil
+		}
+	}
+
+	verifier, err := newDecryptReader(ciphertext, sm.readTag, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(io.Discard, verifier); err != nil {
+		return nil, fmt.Errorf(`failed to decrypt payload: %w`, err)
+	}
+
+	src, err := reread()
+	if err != nil {
+		return nil, err
+	}
+	tag := verifier.tagValue
+	return newDecryptReader(src, func() ([]byte, error) {
+		return tag, nil
+	}, nil)
+}
+
+// countingReader counts the number of bytes read from `src`
+type countingReader struct {
+	src io.Reader
+	n   int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.src.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// decryptReader decrypts the ciphertext read from `src`. The tag is
+// retrieved and verified once all of the ciphertext has been read,
+// before the last chunk of the plaintext is returned.
+type decryptReader struct {
+	src      io.Reader
+	dec      contentcipher.StreamDecrypter
+	tag      func() ([]byte, error)
+	tagValue []byte
+	buf      bytes.Buffer
+	chunk    []byte
+	eof      bool
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+
+		n, err := r.src.Read(r.chunk)
+		if n > 0 {
+			if _, err := r.dec.Write(r.chunk[:n]); err != nil {
+				return 0, err
+			}
+		}
+		if err == io.EOF {
+			tag, err := r.tag()
+			if err != nil {
+				return 0, err
+			}
+			if err := r.dec.Finish(tag); err != nil {
+				return 0, err
+			}
+			r.tagValue = tag
+			r.eof = true
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf(`failed to read ciphertext: %w`, err)
+		}
+	}
+	return r.buf.Read(p)
+}
+
+// streamMessage is a JWE message whose ciphertext has not been read yet
+type streamMessage struct {
+	// msg contains everything but the ciphertext and the tag
+	msg *Message
+
+	// ciphertext is the base64 encoded ciphertext
+	ciphertext io.Reader
+
+	// readTag reads the rest of the message after the ciphertext,
+	// and returns the decoded tag
+	readTag func() ([]byte, error)
+
+	// buffered is the base64 encoded ciphertext, if it had to be
+	// read into memory
+	buffered []byte
+}
+
+func readStreamMessage(src *bufio.Reader) (*streamMessage, error) {
+	c, err := skipSpace(src)
+	if err != nil {
+		return nil, fmt.Errorf(`empty message`)
+	}
+	if c == '{' {
+		return readJSONStreamMessage(src)
+	}
+	if err := src.UnreadByte(); err != nil {
+		return nil, fmt.Errorf(`failed to read message: %w`, err)
+	}
+	return readCompactStreamMessage(src)
+}
+
+func readCompactStreamMessage(src *bufio.Reader) (*streamMessage, error) {
+	// The header, the encrypted key, and the iv are small enough
+	// to be read into memory
+	var parts [3][]byte
+	for i := range parts {
+		part, err := src.ReadBytes('.')
+		if err != nil {
+			return nil, fmt.Errorf(`compact JWE format must have five parts`)
+		}
+		parts[i] = part[:len(part)-1]
+	}
+
+	// Let parseCompact() handle the first three parts, by pretending
+	// that the ciphertext and the tag are empty
+	msg, err := parseCompact(bytes.Join([][]byte{parts[0], parts[1], parts[2], nil, nil}, []byte{'.'}), true)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext := &delimitedReader{src: src, delim: '.'}
+	return &streamMessage{
+		msg:        msg,
+		ciphertext: ciphertext,
+		readTag: func() ([]byte, error) {
+			if !ciphertext.found {
+				return nil, fmt.Errorf(`compact JWE format must have five parts`)
+			}
+			buf, err := io.ReadAll(io.LimitReader(src, maxStreamTagSize+1))
+			if err != nil {
+				return nil, fmt.Errorf(`failed to read tag: %w`, err)
+			}
+			if len(buf) > maxStreamTagSize {
+				return nil, fmt.Errorf(`tag is too large`)
+			}
+			buf = bytes.TrimSpace(buf)
+			if bytes.IndexByte(buf, '.') >= 0 {
+				return nil, fmt.Errorf(`compact JWE format must have five parts`)
+			}
+			tag, err := base64.Decode(buf)
+			if err != nil {
+				return nil, fmt.Errorf(`failed to base64 decode tag: %w`, err)
+			}
+			return tag, nil
+		},
+	}, nil
+}
+
+// jsonStreamReader reads the members of a JWE message in JSON
+// serialization format one at a time. The opening brace must
+// have been consumed already.
+type jsonStreamReader struct {
+	src   *bufio.Reader
+	count int
+	seen  map[string]struct{}
+}
+
+// next reads the name of the next member, and the colon that follows it.
+// It returns false when the end of the object has been reached
+func (r *jsonStreamReader) next() (string, bool, error) {
+	c, err := skipSpace(r.src)
+	if err != nil {
+		return "", false, fmt.Errorf(`unexpected end of JSON input`)
+	}
+	if c == '}' {
+		// make sure that there's nothing after the object
+		if _, err := skipSpace(r.src); err != io.EOF {
+			return "", false, fmt.Errorf(`unexpected data after JSON object`)
+		}
+		return "", false, nil
+	}
+
+	if r.count > 0 {
+		if c != ',' {
+			return "", false, fmt.Errorf(`expected ',' or '}' in JSON object, got %q`, c)
+		}
+	} else if err := r.src.UnreadByte(); err != nil {
+		return "", false, fmt.Errorf(`failed to read JSON object: %w`, err)
+	}
+
+	raw, err := readJSONValue(r.src)
+	if err != nil {
+		return "", false, err
+	}
+	var name string
+	if err := json.Unmarshal(raw, &name); err != nil {
+		return "", false, fmt.Errorf(`invalid member name in JSON object: %w`, err)
+	}
+	if _, ok := r.seen[name]; ok {
+		return "", false, fmt.Errorf(`duplicate member %q in JSON object`, name)
+	}
+	r.seen[name] = struct{}{}
+	r.count++
+
+	c, err = skipSpace(r.src)
+	if err != nil || c != ':' {
+		return "", false, fmt.Errorf(`expected ':' after member name %q`, name)
+	}
+	return name, true, nil
+}
+
+func readJSONStreamMessage(src *bufio.Reader) (*streamMessage, error) {
+	r := &jsonStreamReader{
+		src:  src,
+		seen: make(map[string]struct{}),
+	}
+
+	// members holds all members except for "ciphertext" and "tag",
+	// which are handled separately
+	var members bytes.Buffer
+	var rawTag, rawCiphertext []byte
+	members.WriteByte('{')
+	for {
+		name, ok, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+
+		if name == CipherTextKey {
+			_, hasProtected := r.seen[ProtectedHeadersKey]
+			_, hasIV := r.seen[InitializationVectorKey]
+			if hasProtected && hasIV {
+				return streamJSONCiphertext(r, members.Bytes(), rawTag)
+			}
+		}
+
+		raw, err := readJSONValue(src)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case CipherTextKey:
+			rawCiphertext = raw
+		case TagKey:
+			rawTag = raw
+		default:
+			if members.Len() > 1 {
+				members.WriteByte(',')
+			}
+			members.WriteString(fmt.Sprintf(`%q:`, name))
+			members.Write(raw)
+		}
+	}
+
+	// The ciphertext came before the members required to decrypt it,
+	// so it has been read into memory
+	if rawCiphertext == nil {
+		return nil, fmt.Errorf(`missing "ciphertext" member`)
+	}
+	var ciphertext string
+	if err := json.Unmarshal(rawCiphertext, &ciphertext); err != nil {
+		return nil, fmt.Errorf(`failed to decode "ciphertext": %w`, err)
+	}
+
+	msg, err := parseStreamMembers(members.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	buffered := []byte(ciphertext)
+	return &streamMessage{
+		msg:        msg,
+		ciphertext: bytes.NewReader(buffered),
+		readTag: func() ([]byte, error) {
+			return decodeStreamTag(rawTag)
+		},
+		buffered: buffered,
+	}, nil
+}
+
+// streamJSONCiphertext prepares to read the value of the "ciphertext"
+// member directly from the underlying reader
+func streamJSONCiphertext(r *jsonStreamReader, members, rawTag []byte) (*streamMessage, error) {
+	c, err := skipSpace(r.src)
+	if err != nil || c != '"' {
+		return nil, fmt.Errorf(`"ciphertext" must be a string`)
+	}
+
+	msg, err := parseStreamMembers(members)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext := &delimitedReader{src: r.src, delim: '"', noEscape: true}
+	return &streamMessage{
+		msg:        msg,
+		ciphertext: ciphertext,
+		readTag: func() ([]byte, error) {
+			if !ciphertext.found {
+				return nil, fmt.Errorf(`unexpected end of JSON input`)
+			}
+			for {
+				name, ok, err := r.next()
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					break
+				}
+				if name != TagKey {
+					return nil, fmt.Errorf(`%q must appear before "ciphertext" to decrypt the message as a stream`, name)
+				}
+				raw, err := readJSONValue(r.src)
+				if err != nil {
+					return nil, err
+				}
+				rawTag = raw
+			}
+			return decodeStreamTag(rawTag)
+		},
+	}, nil
+}
+
+func parseStreamMembers(members []byte) (*Message, error) {
+	buf := make([]byte, 0, len(members)+1)
+	buf = append(append(buf, members...), '}')
+	return parseJSON(buf, true)
+}
+
+func decodeStreamTag(raw []byte) ([]byte, error) {
+	if raw == nil {
+		return nil, fmt.Errorf(`missing "tag" member`)
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf(`failed to decode "tag": %w`, err)
+	}
+	tag, err := base64.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to base64 decode "tag": %w`, err)
+	}
+	return tag, nil
+}
+
+// delimitedReader reads from `src` until `delim` is found. The delimiter
+// is consumed, but is not returned. If `noEscape` is true, backslashes
+// are not allowed (base64 encoded values never need to be escaped in JSON)
+type delimitedReader struct {
+	src      *bufio.Reader
+	delim    byte
+	noEscape bool
+	found    bool
+}
+
+func (r *delimitedReader) Read(p []byte) (int, error) {
+	if r.found {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	// Peek(1) makes sure that there's buffered data
+	if _, err := r.src.Peek(1); err != nil {
+		if err == io.EOF {
+			return 0, io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+
+	n := r.src.Buffered()
+	if n > len(p) {
+		n = len(p)
+	}
+	buf, err := r.src.Peek(n)
+	if err != nil {
+		return 0, err
+	}
+
+	if i := bytes.IndexByte(buf, r.delim); i >= 0 {
+		r.found = true
+		buf = buf[:i]
+		n = i + 1
+	}
+	if r.noEscape && bytes.IndexByte(buf, '\\') >= 0 {
+		return 0, fmt.Errorf(`unexpected escape sequence`)
+	}
+	copied := copy(p, buf)
+	if _, err := r.src.Discard(n); err != nil {
+		return 0, err
+	}
+	if copied == 0 && r.found {
+		return 0, io.EOF
+	}
+	return copied, nil
+}
+
+// skipSpace returns the next byte that is not a JSON whitespace character
+func skipSpace(src *bufio.Reader) (byte, error) {
+	for {
+		c, err := src.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch c {
+		case ' ', '\t', '\r', '\n':
+		default:
+			return c, nil
+		}
+	}
+}
+
+// readJSONValue reads a single JSON value from `src` without decoding it.
+// The value is validated when the result is decoded
+func readJSONValue(src *bufio.Reader) ([]byte, error) {
+	c, err := skipSpace(src)
+	if err != nil {
+		return nil, fmt.Errorf(`unexpected end of JSON input`)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(c)
+	switch c {
+	case '"':
+		if err := readJSONString(src, &buf); err != nil {
+			return nil, err
+		}
+	case '{', '[':
+		for depth := 1; depth > 0; {
+			c, err := src.ReadByte()
+			if err != nil {
+				return nil, fmt.Errorf(`unexpected end of JSON input`)
+			}
+			buf.WriteByte(c)
+			switch c {
+			case '"':
+				if err := readJSONString(src, &buf); err != nil {
+					return nil, err
+				}
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+	default:
+		// true, false, null, or a number
+		for {
+			c, err := src.ReadByte()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if c == ',' || c == '}' || c == ']' || c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+				if err := src.UnreadByte(); err != nil {
+					return nil, err
+				}
+				break
+			}
+			buf.WriteByte(c)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// readJSONString reads the rest of a JSON string whose opening quote
+// has already been read
+func readJSONString(src *bufio.Reader, dst *bytes.Buffer) error {
+	for {
+		c, err := src.ReadByte()
+		if err != nil {
+			return fmt.Errorf(`unexpected end of JSON input`)
+		}
+		dst.WriteByte(c)
+		switch c {
+		case '\\':
+			c, err := src.ReadByte()
+			if err != nil {
+				return fmt.Errorf(`unexpected end of JSON input`)
+			}
+			dst.WriteByte(c)
+		case '"':
+			return nil
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package jwk
+
+import (
+	"bytes"
+	"crypto"
+	"fmt"
+	"reflect"
+
+	"github.com/lestrrat-go/blackmagic"
+	"github.com/sjwl/jwx/v2/internal/base64"
+	"github.com/sjwl/jwx/v2/internal/mldsa"
+	"github.com/sjwl/jwx/v2/internal/mlkem"
+	"github.com/sjwl/jwx/v2/jwa"
+)
+
+// AKP ("algorithm key pair") keys are bound to a single algorithm,
+// which determines how the "pub" and "priv" fields are interpreted.
+// The "alg" field is therefore required for these keys.
+//
+// The ML-DSA signature algorithms use *mldsa.PrivateKey and
+// *mldsa.PublicKey, and the ML-KEM key encryption algorithms use
+// *mlkem.DecapsulationKey and *mlkem.EncapsulationKey as raw keys.
+
+func akpMLDSAParameters(alg jwa.KeyAlgorithm) (*mldsa.Parameters, bool) {
+	switch alg.String() {
+	case jwa.MLDSA44.String():
+		return mldsa.MLDSA44(), true
+	case jwa.MLDSA65.String():
+		return mldsa.MLDSA65(), true
+	case jwa.MLDSA87.String():
+		return mldsa.MLDSA87(), true
+	default:
+		return nil, false
+	}
+}
+
+func akpMLKEMParameters(alg jwa.KeyAlgorithm) (*mlkem.Parameters, bool) {
+	switch alg.String() {
+	case jwa.MLKEM768.String(), jwa.MLKEM768_A192KW.String():
+		return mlkem.MLKEM768(), true
+	case jwa.MLKEM1024.String(), jwa.MLKEM1024_A256KW.String():
+		return mlkem.MLKEM1024(), true
+	default:
+		return nil, false
+	}
+}
+
+func akpUnsupportedAlgorithm(alg jwa.KeyAlgorithm) error {
+	if alg.String() == "" {
+		return fmt.Errorf(`missing "alg" field in AKP key`)
+	}
+	return fmt.Errorf(`unsupported algorithm for AKP key: %s`, alg)
+}
+
+// akpAlgorithmForKey returns the algorithm to be stored in the "alg"
+// field of AKP keys created from raw keys. For ML-KEM keys, the
+// algorithm that uses the shared key directly is chosen
+func akpAlgorithmForKey(key interface{}) (jwa.KeyAlgorithm, error) {
+	var name string
+	switch key := key.(type) {
+	case *mldsa.PublicKey:
+		name = key.Parameters().String()
+	case *mldsa.PrivateKey:
+		name = key.Parameters().String()
+	case *mlkem.EncapsulationKey:
+		name = key.Parameters().String()
+	case *mlkem.DecapsulationKey:
+		name = key.Parameters().String()
+	default:
+		return nil, fmt.Errorf(`unknown key type %T`, key)
+	}
+
+	// the parameter set names are also the names of the algorithms
+	alg := jwa.KeyAlgorithmFrom(name)
+	if _, ok := alg.(jwa.InvalidKeyAlgorithm); ok {
+		return nil, fmt.Errorf(`unsupported parameter set %s`, name)
+	}
+	return alg, nil
+}
+
+func (k *akpPublicKey) FromRaw(rawKeyIf interface{}) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	var pub []byte
+	switch rawKey := rawKeyIf.(type) {
+	case *mldsa.PublicKey:
+		if rawKey == nil {
+			return fmt.Errorf(`unknown key type %T`, rawKeyIf)
+		}
+		pub = rawKey.Bytes()
+	case *mlkem.EncapsulationKey:
+		if rawKey == nil {
+			return fmt.Errorf(`unknown key type %T`, rawKeyIf)
+		}
+		pub = rawKey.Bytes()
+	default:
+		return fmt.Errorf(`unknown key type %T`, rawKeyIf)
+	}
+
+	alg, err := akpAlgorithmForKey(rawKeyIf)
+	if err != nil {
+		return err
+	}
+	k.algorithm = &alg
+	k.pub = pub
+	return nil
+}
+
+func (k *akpPrivateKey) FromRaw(rawKeyIf interface{}) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	var pub, priv []byte
+	switch rawKey := rawKeyIf.(type) {
+	case *mldsa.PrivateKey:
+		if rawKey == nil {
+			return fmt.Errorf(`unknown key type %T`, rawKeyIf)
+		}
+		pub = rawKey.PublicKey().Bytes()
+		priv = rawKey.Bytes()
+	case *mlkem.DecapsulationKey:
+		if rawKey == nil {
+			return fmt.Errorf(`unknown key type %T`, rawKeyIf)
+		}
+		pub = rawKey.EncapsulationKey().Bytes()
+		priv = rawKey.Bytes()
+	default:
+		return fmt.Errorf(`unknown key type %T`, rawKeyIf)
+	}
+
+	alg, err := akpAlgorithmForKey(rawKeyIf)
+	if err != nil {
+		return err
+	}
+	k.algorithm = &alg
+	k.pub = pub
+	k.priv = priv
+	return nil
+}
+
+func buildAKPPublicKey(alg jwa.KeyAlgorithm, pub []byte) (interface{}, error) {
+	if params, ok := akpMLDSAParameters(alg); ok {
+		return mldsa.NewPublicKey(params, pub)
+	}
+	if params, ok := akpMLKEMParameters(alg); ok {
+		return mlkem.NewEncapsulationKey(params, pub)
+	}
+	return nil, akpUnsupportedAlgorithm(alg)
+}
+
+// assignAKPKey assigns key to v. The raw keys of AKP keys are meant to
+// be handled as pointers, so v may also point to a pointer variable
+// (e.g. a *mldsa.PublicKey)
+func assignAKPKey(v, key interface{}) error {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Ptr {
+		if rk := reflect.ValueOf(key); rk.Type().AssignableTo(rv.Elem().Type()) {
+			rv.Elem().Set(rk)
+			return nil
+		}
+	}
+	return blackmagic.AssignIfCompatible(v, key)
+}
+
+// Raw returns the ML-DSA public key or the ML-KEM encapsulation key
+// represented by this JWK
+func (k *akpPublicKey) Raw(v interface{}) error {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	pubk, err := buildAKPPublicKey(k.Algorithm(), k.pub)
+	if err != nil {
+		return fmt.Errorf(`failed to build public key: %w`, err)
+	}
+	return assignAKPKey(v, pubk)
+}
+
+func buildAKPPrivateKey(alg jwa.KeyAlgorithm, pub, priv []byte) (interface{}, error) {
+	var privk interface{}
+	var derived []byte
+	if params, ok := akpMLDSAParameters(alg); ok {
+		key, err := mldsa.NewPrivateKey(params, priv)
+		if err != nil {
+			return nil, err
+		}
+		privk, derived = key, key.PublicKey().Bytes()
+	} else if params, ok := akpMLKEMParameters(alg); ok {
+		key, err := mlkem.NewDecapsulationKey(params, priv)
+		if err != nil {
+			return nil, err
+		}
+		privk, derived = key, key.EncapsulationKey().Bytes()
+	} else {
+		return nil, akpUnsupportedAlgorithm(alg)
+	}
+
+	if !bytes.Equal(pub, derived) {
+		return nil, fmt.Errorf(`invalid pub value given priv value`)
+	}
+	return privk, nil
+}
+
+// Raw returns the ML-DSA private key or the ML-KEM decapsulation key
+// represented by this JWK
+func (k *akpPrivateKey) Raw(v interface{}) error {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	privk, err := buildAKPPrivateKey(k.Algorithm(), k.pub, k.priv)
+	if err != nil {
+		return fmt.Errorf(`failed to build private key: %w`, err)
+	}
+	return assignAKPKey(v, privk)
+}
+
+func makeAKPPublicKey(v interface {
+	makePairs() []*HeaderPair
+}) (Key, error) {
+	newKey := newAKPPublicKey()
+
+	// Iterate and copy everything except for the bits that should not be in the public key
+	for _, pair := range v.makePairs() {
+		switch pair.Key {
+		case AKPPrivKey:
+			continue
+		default:
+			//nolint:forcetypeassert
+			key := pair.Key.(string)
+			if err := newKey.Set(key, pair.Value); err != nil {
+				return nil, fmt.Errorf(`failed to set field %q: %w`, key, err)
+			}
+		}
+	}
+
+	return newKey, nil
+}
+
+func (k *akpPrivateKey) PublicKey() (Key, error) {
+	return makeAKPPublicKey(k)
+}
+
+func (k *akpPublicKey) PublicKey() (Key, error) {
+	return makeAKPPublicKey(k)
+}
+
+// akpThumbprint computes the thumbprint over the required members of
+// an AKP key, which are "alg", "kty", and "pub"
+func akpThumbprint(hash crypto.Hash, alg jwa.KeyAlgorithm, pub []byte) ([]byte, error) {
+	if alg.String() == "" {
+		return nil, fmt.Errorf(`missing "alg" field in AKP key`)
+	}
+
+	h := hash.New()
+	fmt.Fprint(h, `{"alg":"`)
+	fmt.Fprint(h, alg.String())
+	fmt.Fprint(h, `","kty":"AKP","pub":"`)
+	fmt.Fprint(h, base64.EncodeToString(pub))
+	fmt.Fprint(h, `"}`)
+	return h.Sum(nil), nil
+}
+
+// Thumbprint returns the JWK thumbprint using the indicated
+// hashing algorithm, according to RFC 7638
+func (k akpPublicKey) Thumbprint(hash crypto.Hash) ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return akpThumbprint(hash, k.Algorithm(), k.pub)
+}
+
+// Thumbprint returns the JWK thumbprint using the indicated
+// hashing algorithm, according to RFC 7638
+func (k akpPrivateKey) Thumbprint(hash crypto.Hash) ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return akpThumbprint(hash, k.Algorithm(), k.pub)
+}
@@ -0,0 +1,16 @@
+//go:build jwx_brainpool
+// +build jwx_brainpool
+
+package jwk
+
+import (
+	"github.com/sjwl/jwx/v2/internal/brainpool"
+	"github.com/sjwl/jwx/v2/internal/ecutil"
+	"github.com/sjwl/jwx/v2/jwa"
+)
+
+func init() {
+	ecutil.RegisterCurve(brainpool.P256r1(), jwa.BrainpoolP256r1)
+	ecutil.RegisterCurve(brainpool.P384r1(), jwa.BrainpoolP384r1)
+	ecutil.RegisterCurve(brainpool.P512r1(), jwa.BrainpoolP512r1)
+}
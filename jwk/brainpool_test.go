@@ -0,0 +1,64 @@
+//go:build jwx_brainpool
+// +build jwx_brainpool
+
+package jwk_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/sjwl/jwx/v2/internal/base64"
+	"github.com/sjwl/jwx/v2/internal/ecutil"
+	"github.com/sjwl/jwx/v2/internal/jwxtest"
+	"github.com/sjwl/jwx/v2/jwa"
+	"github.com/sjwl/jwx/v2/jwk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrainpool(t *testing.T) {
+	t.Parallel()
+	for _, alg := range []jwa.EllipticCurveAlgorithm{jwa.BrainpoolP256r1, jwa.BrainpoolP384r1, jwa.BrainpoolP512r1} {
+		alg := alg
+		t.Run(alg.String(), func(t *testing.T) {
+			t.Parallel()
+			require.True(t, ecutil.IsAvailable(alg), `curve should be available`)
+			crv, ok := jwk.CurveForAlgorithm(alg)
+			require.True(t, ok, `jwk.CurveForAlgorithm should succeed`)
+
+			key, err := jwxtest.GenerateEcdsaKey(alg)
+			require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+			require.Equal(t, crv, key.Curve)
+
+			privkey, err := jwk.FromRaw(key)
+			require.NoError(t, err, `jwk.FromRaw should succeed`)
+			buf, err := json.Marshal(privkey)
+			require.NoError(t, err, `json.Marshal should succeed`)
+
+			parsed, err := jwk.ParseKey(buf)
+			require.NoError(t, err, `jwk.ParseKey should succeed`)
+			ecKey, ok := parsed.(jwk.ECDSAPrivateKey)
+			require.True(t, ok, `key should be a jwk.ECDSAPrivateKey`)
+			require.Equal(t, alg, ecKey.Crv())
+
+			var rawkey ecdsa.PrivateKey
+			require.NoError(t, parsed.Raw(&rawkey), `parsed.Raw should succeed`)
+			require.True(t, key.Equal(&rawkey), `raw keys should match`)
+
+			pubkey, err := jwk.PublicKeyOf(parsed)
+			require.NoError(t, err, `jwk.PublicKeyOf should succeed`)
+
+			// RFC 7638 thumbprint, using the JWK name of the curve
+			canonical := fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, alg, base64.EncodeToString(ecKey.X()), base64.EncodeToString(ecKey.Y()))
+			expected := sha256.Sum256([]byte(canonical))
+			for _, k := range []jwk.Key{parsed, pubkey} {
+				tp, err := k.Thumbprint(crypto.SHA256)
+				require.NoError(t, err, `Thumbprint should succeed`)
+				require.Equal(t, expected[:], tp, `thumbprints should match`)
+			}
+		})
+	}
+}
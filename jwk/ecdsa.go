@@ -190,6 +190,13 @@ func (k ecdsaPublicKey) Thumbprint(hash crypto.Hash) ([]byte, error) {
 		return nil, fmt.Errorf(`failed to materialize ecdsa.PublicKey for thumbprint generation: %w`, err)
 	}
 
+	// The name of the curve in the JWK may differ from the name
+	// used by the elliptic.Curve implementation
+	crv, ok := ecutil.AlgorithmForCurve(key.Curve)
+	if !ok {
+		return nil, fmt.Errorf(`invalid elliptic curve %s`, key.Curve.Params().Name)
+	}
+
 	xbuf := ecutil.AllocECPointBuffer(key.X, key.Curve)
 	ybuf := ecutil.AllocECPointBuffer(key.Y, key.Curve)
 	defer ecutil.ReleaseECPointBuffer(xbuf)
@@ -197,7 +204,7 @@ func (k ecdsaPublicKey) Thumbprint(hash crypto.Hash) ([]byte, error) {
 
 	return ecdsaThumbprint(
 		hash,
-		key.Curve.Params().Name,
+		crv.String(),
 		base64.EncodeToString(xbuf),
 		base64.EncodeToString(ybuf),
 	), nil
@@ -214,6 +221,13 @@ func (k ecdsaPrivateKey) Thumbprint(hash crypto.Hash) ([]byte, error) {
 		return nil, fmt.Errorf(`failed to materialize ecdsa.PrivateKey for thumbprint generation: %w`, err)
 	}
 
+	// The name of the curve in the JWK may differ from the name
+	// used by the elliptic.Curve implementation
+	crv, ok := ecutil.AlgorithmForCurve(key.Curve)
+	if !ok {
+		return nil, fmt.Errorf(`invalid elliptic curve %s`, key.Curve.Params().Name)
+	}
+
 	xbuf := ecutil.AllocECPointBuffer(key.X, key.Curve)
 	ybuf := ecutil.AllocECPointBuffer(key.Y, key.Curve)
 	defer ecutil.ReleaseECPointBuffer(xbuf)
@@ -221,7 +235,7 @@ func (k ecdsaPrivateKey) Thumbprint(hash crypto.Hash) ([]byte, error) {
 
 	return ecdsaThumbprint(
 		hash,
-		key.Curve.Params().Name,
+		crv.String(),
 		base64.EncodeToString(xbuf),
 		base64.EncodeToString(ybuf),
 	), nil
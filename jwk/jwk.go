@@ -16,11 +16,15 @@ import (
 	"io"
 	"math/big"
 
+	"github.com/sjwl/jwx/v2/ed448"
 	"github.com/sjwl/jwx/v2/internal/base64"
 	"github.com/sjwl/jwx/v2/internal/ecutil"
 	"github.com/sjwl/jwx/v2/internal/json"
+	"github.com/sjwl/jwx/v2/internal/mldsa"
+	"github.com/sjwl/jwx/v2/internal/mlkem"
 	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/sjwl/jwx/v2/x25519"
+	"github.com/sjwl/jwx/v2/x448"
 )
 
 var registry = json.NewRegistry()
@@ -40,6 +44,9 @@ func bigIntToBytes(n *big.Int) ([]byte, error) {
 //   - "crypto/rsa".PrivateKey and "crypto/rsa".PublicKey creates an RSA based key
 //   - "crypto/ecdsa".PrivateKey and "crypto/ecdsa".PublicKey creates an EC based key
 //   - "crypto/ed25519".PrivateKey and "crypto/ed25519".PublicKey creates an OKP based key
+//   - x25519, ed448, and x448 private and public keys from this module create OKP based keys
+//   - the raw ML-DSA and ML-KEM keys obtained from AKP keys create AKP
+//     based keys
 //   - []byte creates a symmetric key
 func FromRaw(key interface{}) (Key, error) {
 	if key == nil {
@@ -109,6 +116,42 @@ func FromRaw(key interface{}) (Key, error) {
 			return nil, fmt.Errorf(`failed to initialize %T from %T: %w`, k, rawKey, err)
 		}
 		return k, nil
+	case ed448.PrivateKey:
+		k := newOKPPrivateKey()
+		if err := k.FromRaw(rawKey); err != nil {
+			return nil, fmt.Errorf(`failed to initialize %T from %T: %w`, k, rawKey, err)
+		}
+		return k, nil
+	case ed448.PublicKey:
+		k := newOKPPublicKey()
+		if err := k.FromRaw(rawKey); err != nil {
+			return nil, fmt.Errorf(`failed to initialize %T from %T: %w`, k, rawKey, err)
+		}
+		return k, nil
+	case x448.PrivateKey:
+		k := newOKPPrivateKey()
+		if err := k.FromRaw(rawKey); err != nil {
+			return nil, fmt.Errorf(`failed to initialize %T from %T: %w`, k, rawKey, err)
+		}
+		return k, nil
+	case x448.PublicKey:
+		k := newOKPPublicKey()
+		if err := k.FromRaw(rawKey); err != nil {
+			return nil, fmt.Errorf(`failed to initialize %T from %T: %w`, k, rawKey, err)
+		}
+		return k, nil
+	case *mldsa.PrivateKey, *mlkem.DecapsulationKey:
+		k := newAKPPrivateKey()
+		if err := k.FromRaw(rawKey); err != nil {
+			return nil, fmt.Errorf(`failed to initialize %T from %T: %w`, k, rawKey, err)
+		}
+		return k, nil
+	case *mldsa.PublicKey, *mlkem.EncapsulationKey:
+		k := newAKPPublicKey()
+		if err := k.FromRaw(rawKey); err != nil {
+			return nil, fmt.Errorf(`failed to initialize %T from %T: %w`, k, rawKey, err)
+		}
+		return k, nil
 	case []byte:
 		k := newSymmetricKey()
 		if err := k.FromRaw(rawKey); err != nil {
@@ -226,6 +269,22 @@ func PublicRawKeyOf(v interface{}) (interface{}, error) {
 		return x.Public(), nil
 	case x25519.PublicKey:
 		return x, nil
+	case ed448.PrivateKey:
+		return x.Public(), nil
+	case ed448.PublicKey:
+		return x, nil
+	case x448.PrivateKey:
+		return x.Public(), nil
+	case x448.PublicKey:
+		return x, nil
+	case *mldsa.PrivateKey:
+		return x.PublicKey(), nil
+	case *mldsa.PublicKey:
+		return x, nil
+	case *mlkem.DecapsulationKey:
+		return x.EncapsulationKey(), nil
+	case *mlkem.EncapsulationKey:
+		return x, nil
 	case []byte:
 		return x, nil
 	default:
@@ -431,8 +490,9 @@ func ParseKey(data []byte, options ...ParseOption) (Key, error) {
 	}
 
 	var hint struct {
-		Kty string          `json:"kty"`
-		D   json.RawMessage `json:"d"`
+		Kty  string          `json:"kty"`
+		D    json.RawMessage `json:"d"`
+		Priv json.RawMessage `json:"priv"`
 	}
 
 	if err := json.Unmarshal(data, &hint); err != nil {
@@ -461,6 +521,12 @@ func ParseKey(data []byte, options ...ParseOption) (Key, error) {
 		} else {
 			key = newOKPPublicKey()
 		}
+	case jwa.AKP:
+		if len(hint.Priv) > 0 {
+			key = newAKPPrivateKey()
+		} else {
+			key = newAKPPublicKey()
+		}
 	default:
 		return nil, fmt.Errorf(`invalid key type from JSON (%s)`, hint.Kty)
 	}
@@ -618,6 +684,10 @@ func cloneKey(src Key) (Key, error) {
 		dst = newOKPPrivateKey()
 	case OKPPublicKey:
 		dst = newOKPPublicKey()
+	case AKPPrivateKey:
+		dst = newAKPPrivateKey()
+	case AKPPublicKey:
+		dst = newAKPPublicKey()
 	case SymmetricKey:
 		dst = newSymmetricKey()
 	default:
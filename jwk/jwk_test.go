@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/sjwl/jwx/v2/cert"
+	"github.com/sjwl/jwx/v2/ed448"
 	"github.com/sjwl/jwx/v2/internal/ecutil"
 	"github.com/sjwl/jwx/v2/internal/jose"
 	"github.com/sjwl/jwx/v2/internal/json"
@@ -27,9 +28,12 @@ import (
 	"github.com/sjwl/jwx/v2/jws"
 
 	"github.com/sjwl/jwx/v2/internal/base64"
+	"github.com/sjwl/jwx/v2/internal/mldsa"
+	"github.com/sjwl/jwx/v2/internal/mlkem"
 	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/sjwl/jwx/v2/jwk"
 	"github.com/sjwl/jwx/v2/x25519"
+	"github.com/sjwl/jwx/v2/x448"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -152,6 +156,10 @@ func expectedRawKeyType(key jwk.Key) interface{} {
 			return ed25519.PrivateKey(nil)
 		case jwa.X25519:
 			return x25519.PrivateKey(nil)
+		case jwa.Ed448:
+			return ed448.PrivateKey(nil)
+		case jwa.X448:
+			return x448.PrivateKey(nil)
 		default:
 			panic("unknown curve type for OKPPrivateKey:" + key.Crv())
 		}
@@ -161,6 +169,10 @@ func expectedRawKeyType(key jwk.Key) interface{} {
 			return ed25519.PublicKey(nil)
 		case jwa.X25519:
 			return x25519.PublicKey(nil)
+		case jwa.Ed448:
+			return ed448.PublicKey(nil)
+		case jwa.X448:
+			return x448.PublicKey(nil)
 		default:
 			panic("unknown curve type for OKPPublicKey:" + key.Crv())
 		}
@@ -429,6 +441,18 @@ func TestParse(t *testing.T) {
 							return
 						}
 						crawkey = rawkey
+					case jwa.Ed448:
+						var rawkey ed448.PrivateKey
+						if !assert.NoError(t, key.Raw(&rawkey), `key.Raw(&ed448.PrivateKey) should succeed`) {
+							return
+						}
+						crawkey = rawkey
+					case jwa.X448:
+						var rawkey x448.PrivateKey
+						if !assert.NoError(t, key.Raw(&rawkey), `key.Raw(&x448.PrivateKey) should succeed`) {
+							return
+						}
+						crawkey = rawkey
 					default:
 						t.Errorf(`invalid curve %s`, k.Crv())
 					}
@@ -449,6 +473,18 @@ func TestParse(t *testing.T) {
 							return
 						}
 						crawkey = rawkey
+					case jwa.Ed448:
+						var rawkey ed448.PublicKey
+						if !assert.NoError(t, key.Raw(&rawkey), `key.Raw(&ed448.PublicKey) should succeed`) {
+							return
+						}
+						crawkey = rawkey
+					case jwa.X448:
+						var rawkey x448.PublicKey
+						if !assert.NoError(t, key.Raw(&rawkey), `key.Raw(&x448.PublicKey) should succeed`) {
+							return
+						}
+						crawkey = rawkey
 					default:
 						t.Errorf(`invalid curve %s`, k.Crv())
 					}
@@ -562,6 +598,61 @@ func TestParse(t *testing.T) {
 		}`
 		verify(t, src, reflect.TypeOf((*jwk.OKPPrivateKey)(nil)).Elem())
 	})
+	t.Run("Ed448 Public Key", func(t *testing.T) {
+		t.Parallel()
+		// Key taken from RFC 8032
+		const src = `{
+		  "kty" : "OKP",
+		  "crv" : "Ed448",
+		  "x"   : "X9dEm1m0Yf0s54fsYWrUah2hNCSFpw4fig6nXYDpZ3jt8SR2m0bHBhvWeD3x5Q9s0foavq_oJWGA"
+		}`
+		verify(t, src, reflect.TypeOf((*jwk.OKPPublicKey)(nil)).Elem())
+	})
+	t.Run("Ed448 Private Key", func(t *testing.T) {
+		t.Parallel()
+		// Key taken from RFC 8032
+		const src = `{
+		  "kty" : "OKP",
+		  "crv" : "Ed448",
+		  "d"   : "bIKlYsuAjRDWMr6JyFE-v2ySnzTd-oyfY8mWDvbjSKNSjIo_zC8ETjmj_FuUSS-PAy51SaIAmPlb",
+		  "x"   : "X9dEm1m0Yf0s54fsYWrUah2hNCSFpw4fig6nXYDpZ3jt8SR2m0bHBhvWeD3x5Q9s0foavq_oJWGA"
+		}`
+		verify(t, src, reflect.TypeOf((*jwk.OKPPrivateKey)(nil)).Elem())
+	})
+	t.Run("X448 Public Key", func(t *testing.T) {
+		t.Parallel()
+		// Key taken from RFC 7748
+		const src = `{
+		  "kty" : "OKP",
+		  "crv" : "X448",
+		  "x"   : "mwj3zDG34-Z9ItWuoSEHSic70rg94Jxj-qc9LCLF2bvINmRyQdlT1AxbEtqIEg1TF3-A5TLEH6A"
+		}`
+		verify(t, src, reflect.TypeOf((*jwk.OKPPublicKey)(nil)).Elem())
+	})
+	t.Run("X448 Private Key", func(t *testing.T) {
+		t.Parallel()
+		// Key taken from RFC 7748
+		const src = `{
+		  "kty" : "OKP",
+		  "crv" : "X448",
+		  "d"   : "mo9JJdFRn1d1z0awS1gA1O6e6LrovFVl1JjCjdnJuvV0qUGXRIlzkQBjgqbxJ6sdmsLYwKWYcms",
+		  "x"   : "mwj3zDG34-Z9ItWuoSEHSic70rg94Jxj-qc9LCLF2bvINmRyQdlT1AxbEtqIEg1TF3-A5TLEH6A"
+		}`
+		verify(t, src, reflect.TypeOf((*jwk.OKPPrivateKey)(nil)).Elem())
+	})
+	t.Run("Ed448 Private Key with mismatched public key", func(t *testing.T) {
+		t.Parallel()
+		const src = `{
+		  "kty" : "OKP",
+		  "crv" : "Ed448",
+		  "d"   : "bIKlYsuAjRDWMr6JyFE-v2ySnzTd-oyfY8mWDvbjSKNSjIo_zC8ETjmj_FuUSS-PAy51SaIAmPlb",
+		  "x"   : "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"
+		}`
+		key, err := jwk.ParseKey([]byte(src))
+		require.NoError(t, err, `jwk.ParseKey should succeed`)
+		var rawkey interface{}
+		require.Error(t, key.Raw(&rawkey), `key.Raw should fail`)
+	})
 }
 
 func TestRoundtrip(t *testing.T) {
@@ -824,6 +915,171 @@ func TestAssignKeyID(t *testing.T) {
 	}
 }
 
+func TestAKP(t *testing.T) {
+	t.Parallel()
+
+	seed := make([]byte, mldsa.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	rawkey, err := mldsa.NewPrivateKey(mldsa.MLDSA44(), seed)
+	require.NoError(t, err, `mldsa.NewPrivateKey should succeed`)
+
+	t.Run("FromRaw", func(t *testing.T) {
+		t.Parallel()
+		key, err := jwk.FromRaw(rawkey)
+		require.NoError(t, err, `jwk.FromRaw should succeed`)
+		akpkey, ok := key.(jwk.AKPPrivateKey)
+		require.True(t, ok, `key should be a jwk.AKPPrivateKey`)
+		require.Equal(t, jwa.AKP, akpkey.KeyType())
+		require.Equal(t, jwa.MLDSA44, akpkey.Algorithm())
+		require.Equal(t, seed, akpkey.Priv())
+		require.Equal(t, rawkey.PublicKey().Bytes(), akpkey.Pub())
+
+		var rawback *mldsa.PrivateKey
+		require.NoError(t, key.Raw(&rawback), `key.Raw should succeed`)
+		require.True(t, rawkey.Equal(rawback), `private keys should match`)
+
+		pubkey, err := jwk.PublicKeyOf(key)
+		require.NoError(t, err, `jwk.PublicKeyOf should succeed`)
+		_, ok = pubkey.(jwk.AKPPublicKey)
+		require.True(t, ok, `public key should be a jwk.AKPPublicKey`)
+		_, ok = pubkey.Get(jwk.AKPPrivKey)
+		require.False(t, ok, `public key should not contain "priv"`)
+
+		var rawpub *mldsa.PublicKey
+		require.NoError(t, pubkey.Raw(&rawpub), `pubkey.Raw should succeed`)
+		require.True(t, rawpub.Equal(rawkey.Public()), `public keys should match`)
+
+		rawpubif, err := jwk.PublicRawKeyOf(rawkey)
+		require.NoError(t, err, `jwk.PublicRawKeyOf should succeed`)
+		require.True(t, rawkey.PublicKey().Equal(rawpubif), `public keys should match`)
+
+		tp, err := key.Thumbprint(crypto.SHA256)
+		require.NoError(t, err, `key.Thumbprint should succeed`)
+		pubtp, err := pubkey.Thumbprint(crypto.SHA256)
+		require.NoError(t, err, `pubkey.Thumbprint should succeed`)
+		require.Equal(t, tp, pubtp, `thumbprints should match`)
+		require.Equal(t, "XqS8KAWB0Sc42bmWeKxan98eQKcHS4DVOHGc_kr_68E", base64.EncodeToString(tp))
+	})
+	t.Run("Parse", func(t *testing.T) {
+		t.Parallel()
+		src := fmt.Sprintf(`{"kty":"AKP","alg":"ML-DSA-44","pub":%q,"priv":%q}`,
+			base64.EncodeToString(rawkey.PublicKey().Bytes()), base64.EncodeToString(seed))
+		key, err := jwk.ParseKey([]byte(src))
+		require.NoError(t, err, `jwk.ParseKey should succeed`)
+		_, ok := key.(jwk.AKPPrivateKey)
+		require.True(t, ok, `key should be a jwk.AKPPrivateKey`)
+
+		var rawback *mldsa.PrivateKey
+		require.NoError(t, key.Raw(&rawback), `key.Raw should succeed`)
+		require.True(t, rawkey.Equal(rawback), `private keys should match`)
+
+		buf, err := json.Marshal(key)
+		require.NoError(t, err, `json.Marshal should succeed`)
+		key2, err := jwk.ParseKey(buf)
+		require.NoError(t, err, `jwk.ParseKey should succeed`)
+		buf2, err := json.Marshal(key2)
+		require.NoError(t, err, `json.Marshal should succeed`)
+		require.Equal(t, buf, buf2, `keys should match after roundtrip`)
+
+		pubsrc := fmt.Sprintf(`{"kty":"AKP","alg":"ML-DSA-44","pub":%q}`,
+			base64.EncodeToString(rawkey.PublicKey().Bytes()))
+		pubkey, err := jwk.ParseKey([]byte(pubsrc))
+		require.NoError(t, err, `jwk.ParseKey should succeed`)
+		_, ok = pubkey.(jwk.AKPPublicKey)
+		require.True(t, ok, `key should be a jwk.AKPPublicKey`)
+	})
+	t.Run("Invalid keys", func(t *testing.T) {
+		t.Parallel()
+		pub := base64.EncodeToString(rawkey.PublicKey().Bytes())
+		testcases := []struct {
+			Name   string
+			Source string
+		}{
+			{
+				Name:   "missing alg",
+				Source: fmt.Sprintf(`{"kty":"AKP","pub":%q}`, pub),
+			},
+			{
+				Name:   "unsupported alg",
+				Source: fmt.Sprintf(`{"kty":"AKP","alg":"ES256","pub":%q}`, pub),
+			},
+			{
+				Name:   "wrong parameter set",
+				Source: fmt.Sprintf(`{"kty":"AKP","alg":"ML-DSA-65","pub":%q}`, pub),
+			},
+			{
+				Name:   "mismatched pub",
+				Source: fmt.Sprintf(`{"kty":"AKP","alg":"ML-DSA-44","pub":%q,"priv":%q}`, pub, base64.EncodeToString(make([]byte, mldsa.SeedSize))),
+			},
+		}
+		for _, tc := range testcases {
+			tc := tc
+			t.Run(tc.Name, func(t *testing.T) {
+				t.Parallel()
+				key, err := jwk.ParseKey([]byte(tc.Source))
+				require.NoError(t, err, `jwk.ParseKey should succeed`)
+				var raw interface{}
+				require.Error(t, key.Raw(&raw), `key.Raw should fail`)
+			})
+		}
+
+		key, err := jwk.ParseKey([]byte(fmt.Sprintf(`{"kty":"AKP","pub":%q}`, pub)))
+		require.NoError(t, err, `jwk.ParseKey should succeed`)
+		_, err = key.Thumbprint(crypto.SHA256)
+		require.Error(t, err, `key.Thumbprint should fail without "alg"`)
+	})
+	t.Run("ML-KEM", func(t *testing.T) {
+		t.Parallel()
+		seed := make([]byte, mlkem.SeedSize)
+		for i := range seed {
+			seed[i] = byte(i)
+		}
+		rawkey, err := mlkem.NewDecapsulationKey(mlkem.MLKEM768(), seed)
+		require.NoError(t, err, `mlkem.NewDecapsulationKey should succeed`)
+
+		key, err := jwk.FromRaw(rawkey)
+		require.NoError(t, err, `jwk.FromRaw should succeed`)
+		akpkey, ok := key.(jwk.AKPPrivateKey)
+		require.True(t, ok, `key should be a jwk.AKPPrivateKey`)
+		require.Equal(t, jwa.MLKEM768, akpkey.Algorithm())
+		require.Equal(t, seed, akpkey.Priv())
+		require.Equal(t, rawkey.EncapsulationKey().Bytes(), akpkey.Pub())
+
+		var rawback *mlkem.DecapsulationKey
+		require.NoError(t, key.Raw(&rawback), `key.Raw should succeed`)
+		require.True(t, rawkey.Equal(rawback), `private keys should match`)
+
+		pubkey, err := jwk.PublicKeyOf(key)
+		require.NoError(t, err, `jwk.PublicKeyOf should succeed`)
+		var rawpub *mlkem.EncapsulationKey
+		require.NoError(t, pubkey.Raw(&rawpub), `pubkey.Raw should succeed`)
+		require.True(t, rawkey.EncapsulationKey().Equal(rawpub), `public keys should match`)
+
+		rawpubif, err := jwk.PublicRawKeyOf(rawkey)
+		require.NoError(t, err, `jwk.PublicRawKeyOf should succeed`)
+		require.True(t, rawkey.EncapsulationKey().Equal(rawpubif), `public keys should match`)
+
+		// the same key may be used with AES key wrap
+		pub := base64.EncodeToString(rawkey.EncapsulationKey().Bytes())
+		kwkey, err := jwk.ParseKey([]byte(fmt.Sprintf(`{"kty":"AKP","alg":"ML-KEM-768+A192KW","pub":%q,"priv":%q}`, pub, base64.EncodeToString(seed))))
+		require.NoError(t, err, `jwk.ParseKey should succeed`)
+		require.NoError(t, kwkey.Raw(&rawback), `key.Raw should succeed`)
+		require.True(t, rawkey.Equal(rawback), `private keys should match`)
+
+		for _, src := range []string{
+			fmt.Sprintf(`{"kty":"AKP","alg":"ML-KEM-1024","pub":%q}`, pub),
+			fmt.Sprintf(`{"kty":"AKP","alg":"ML-KEM-768","pub":%q,"priv":%q}`, pub, base64.EncodeToString(make([]byte, mlkem.SeedSize))),
+		} {
+			key, err := jwk.ParseKey([]byte(src))
+			require.NoError(t, err, `jwk.ParseKey should succeed`)
+			var raw interface{}
+			require.Error(t, key.Raw(&raw), `key.Raw should fail`)
+		}
+	})
+}
+
 func TestPublicKeyOf(t *testing.T) {
 	t.Parallel()
 
@@ -1382,6 +1638,63 @@ func TestOKP(t *testing.T) {
 			})
 		})
 	})
+	t.Run("Ed448", func(t *testing.T) {
+		t.Parallel()
+		// Key taken from RFC 8032
+		seed, err := base64.DecodeString("bIKlYsuAjRDWMr6JyFE-v2ySnzTd-oyfY8mWDvbjSKNSjIo_zC8ETjmj_FuUSS-PAy51SaIAmPlb")
+		require.NoError(t, err, `base64.DecodeString should succeed`)
+		rawkey := ed448.NewKeyFromSeed(seed)
+
+		key, err := jwk.FromRaw(rawkey)
+		require.NoError(t, err, `jwk.FromRaw should succeed`)
+		okpkey, ok := key.(jwk.OKPPrivateKey)
+		require.True(t, ok, `key should be a jwk.OKPPrivateKey`)
+		require.Equal(t, jwa.Ed448, okpkey.Crv())
+		require.Equal(t, "X9dEm1m0Yf0s54fsYWrUah2hNCSFpw4fig6nXYDpZ3jt8SR2m0bHBhvWeD3x5Q9s0foavq_oJWGA", base64.EncodeToString(okpkey.X()))
+
+		var rawback ed448.PrivateKey
+		require.NoError(t, key.Raw(&rawback), `key.Raw should succeed`)
+		require.Equal(t, rawkey, rawback)
+
+		pubkey, err := jwk.PublicKeyOf(key)
+		require.NoError(t, err, `jwk.PublicKeyOf should succeed`)
+		var rawpub ed448.PublicKey
+		require.NoError(t, pubkey.Raw(&rawpub), `pubkey.Raw should succeed`)
+		require.True(t, rawpub.Equal(rawkey.Public()), `public keys should match`)
+
+		tp, err := pubkey.Thumbprint(crypto.SHA256)
+		require.NoError(t, err, `pubkey.Thumbprint should succeed`)
+		require.Equal(t, "zQstisLFDWZb-FiVsZl6490ATVgxw_63L-xYldKyuUY", base64.EncodeToString(tp))
+	})
+	t.Run("X448", func(t *testing.T) {
+		t.Parallel()
+		// Key taken from RFC 7748
+		seed, err := base64.DecodeString("mo9JJdFRn1d1z0awS1gA1O6e6LrovFVl1JjCjdnJuvV0qUGXRIlzkQBjgqbxJ6sdmsLYwKWYcms")
+		require.NoError(t, err, `base64.DecodeString should succeed`)
+		rawkey, err := x448.NewKeyFromSeed(seed)
+		require.NoError(t, err, `x448.NewKeyFromSeed should succeed`)
+
+		key, err := jwk.FromRaw(rawkey)
+		require.NoError(t, err, `jwk.FromRaw should succeed`)
+		okpkey, ok := key.(jwk.OKPPrivateKey)
+		require.True(t, ok, `key should be a jwk.OKPPrivateKey`)
+		require.Equal(t, jwa.X448, okpkey.Crv())
+		require.Equal(t, "mwj3zDG34-Z9ItWuoSEHSic70rg94Jxj-qc9LCLF2bvINmRyQdlT1AxbEtqIEg1TF3-A5TLEH6A", base64.EncodeToString(okpkey.X()))
+
+		var rawback x448.PrivateKey
+		require.NoError(t, key.Raw(&rawback), `key.Raw should succeed`)
+		require.Equal(t, rawkey, rawback)
+
+		pubkey, err := jwk.PublicKeyOf(key)
+		require.NoError(t, err, `jwk.PublicKeyOf should succeed`)
+		var rawpub x448.PublicKey
+		require.NoError(t, pubkey.Raw(&rawpub), `pubkey.Raw should succeed`)
+		require.Equal(t, rawkey.Public(), rawpub)
+
+		tp, err := pubkey.Thumbprint(crypto.SHA256)
+		require.NoError(t, err, `pubkey.Thumbprint should succeed`)
+		require.Equal(t, "X7Nqq56_hWB_zjSTTN0UEEsN9OnnjvGJIjV7MjEnCko", base64.EncodeToString(tp))
+	})
 }
 
 func TestCustomField(t *testing.T) {
@@ -7,9 +7,11 @@ import (
 	"fmt"
 
 	"github.com/lestrrat-go/blackmagic"
+	"github.com/sjwl/jwx/v2/ed448"
 	"github.com/sjwl/jwx/v2/internal/base64"
 	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/sjwl/jwx/v2/x25519"
+	"github.com/sjwl/jwx/v2/x448"
 )
 
 func (k *okpPublicKey) FromRaw(rawKeyIf interface{}) error {
@@ -26,6 +28,14 @@ func (k *okpPublicKey) FromRaw(rawKeyIf interface{}) error {
 		k.x = rawKey
 		crv = jwa.X25519
 		k.crv = &crv
+	case ed448.PublicKey:
+		k.x = rawKey
+		crv = jwa.Ed448
+		k.crv = &crv
+	case x448.PublicKey:
+		k.x = rawKey
+		crv = jwa.X448
+		k.crv = &crv
 	default:
 		return fmt.Errorf(`unknown key type %T`, rawKeyIf)
 	}
@@ -49,6 +59,16 @@ func (k *okpPrivateKey) FromRaw(rawKeyIf interface{}) error {
 		k.x = rawKey.Public().(x25519.PublicKey) //nolint:forcetypeassert
 		crv = jwa.X25519
 		k.crv = &crv
+	case ed448.PrivateKey:
+		k.d = rawKey.Seed()
+		k.x = rawKey.Public().(ed448.PublicKey) //nolint:forcetypeassert
+		crv = jwa.Ed448
+		k.crv = &crv
+	case x448.PrivateKey:
+		k.d = rawKey.Seed()
+		k.x = rawKey.Public().(x448.PublicKey) //nolint:forcetypeassert
+		crv = jwa.X448
+		k.crv = &crv
 	default:
 		return fmt.Errorf(`unknown key type %T`, rawKeyIf)
 	}
@@ -62,6 +82,16 @@ func buildOKPPublicKey(alg jwa.EllipticCurveAlgorithm, xbuf []byte) (interface{}
 		return ed25519.PublicKey(xbuf), nil
 	case jwa.X25519:
 		return x25519.PublicKey(xbuf), nil
+	case jwa.Ed448:
+		if len(xbuf) != ed448.PublicKeySize {
+			return nil, fmt.Errorf(`invalid Ed448 public key size: %d`, len(xbuf))
+		}
+		return ed448.PublicKey(xbuf), nil
+	case jwa.X448:
+		if len(xbuf) != x448.PublicKeySize {
+			return nil, fmt.Errorf(`invalid X448 public key size: %d`, len(xbuf))
+		}
+		return x448.PublicKey(xbuf), nil
 	default:
 		return nil, fmt.Errorf(`invalid curve algorithm %s`, alg)
 	}
@@ -99,6 +129,26 @@ func buildOKPPrivateKey(alg jwa.EllipticCurveAlgorithm, xbuf []byte, dbuf []byte
 			return nil, fmt.Errorf(`invalid x value given d value`)
 		}
 		return ret, nil
+	case jwa.Ed448:
+		if len(dbuf) != ed448.SeedSize {
+			return nil, fmt.Errorf(`invalid Ed448 private key size: %d`, len(dbuf))
+		}
+		ret := ed448.NewKeyFromSeed(dbuf)
+		//nolint:forcetypeassert
+		if !bytes.Equal(xbuf, ret.Public().(ed448.PublicKey)) {
+			return nil, fmt.Errorf(`invalid x value given d value`)
+		}
+		return ret, nil
+	case jwa.X448:
+		ret, err := x448.NewKeyFromSeed(dbuf)
+		if err != nil {
+			return nil, fmt.Errorf(`unable to construct x448 private key from seed: %w`, err)
+		}
+		//nolint:forcetypeassert
+		if !bytes.Equal(xbuf, ret.Public().(x448.PublicKey)) {
+			return nil, fmt.Errorf(`invalid x value given d value`)
+		}
+		return ret, nil
 	default:
 		return nil, fmt.Errorf(`invalid curve algorithm %s`, alg)
 	}
@@ -0,0 +1,14 @@
+//go:build jwx_brainpool
+// +build jwx_brainpool
+
+package jws
+
+import (
+	"github.com/sjwl/jwx/v2/jwa"
+)
+
+func init() {
+	addAlgorithm(jwa.ESB256)
+	addAlgorithm(jwa.ESB384)
+	addAlgorithm(jwa.ESB512)
+}
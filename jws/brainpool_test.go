@@ -0,0 +1,89 @@
+//go:build jwx_brainpool
+// +build jwx_brainpool
+
+package jws_test
+
+import (
+	"testing"
+
+	"github.com/sjwl/jwx/v2/internal/jwxtest"
+	"github.com/sjwl/jwx/v2/jwa"
+	"github.com/sjwl/jwx/v2/jwk"
+	"github.com/sjwl/jwx/v2/jws"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrainpool(t *testing.T) {
+	payload := []byte("Hello, World!")
+
+	t.Parallel()
+	testcases := []struct {
+		Curve     jwa.EllipticCurveAlgorithm
+		Algorithm jwa.SignatureAlgorithm
+	}{
+		{Curve: jwa.BrainpoolP256r1, Algorithm: jwa.ESB256},
+		{Curve: jwa.BrainpoolP384r1, Algorithm: jwa.ESB384},
+		{Curve: jwa.BrainpoolP512r1, Algorithm: jwa.ESB512},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Algorithm.String(), func(t *testing.T) {
+			t.Parallel()
+			key, err := jwxtest.GenerateEcdsaKey(tc.Curve)
+			require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+			jwkKey, err := jwk.FromRaw(key.PublicKey)
+			require.NoError(t, err, `jwk.FromRaw should succeed`)
+
+			// The arithmetic for the Brainpool curves is not constant time,
+			// so signing with raw private keys is refused
+			privJWK, err := jwk.FromRaw(key)
+			require.NoError(t, err, `jwk.FromRaw should succeed`)
+			for _, privkey := range []interface{}{key, privJWK} {
+				_, err = jws.Sign(payload, jws.WithKey(tc.Algorithm, privkey))
+				require.Error(t, err, `jws.Sign should fail for %T`, privkey)
+			}
+
+			// Keys held by a crypto.Signer can still be used
+			signer := &dummyECDSACryptoSigner{raw: key}
+			signed, err := jws.Sign(payload, jws.WithKey(tc.Algorithm, signer))
+			require.NoError(t, err, `jws.Sign should succeed using a crypto.Signer`)
+			for name, pubkey := range map[string]interface{}{
+				"ecdsa.PublicKey":  key.PublicKey,
+				"*ecdsa.PublicKey": &key.PublicKey,
+				"jwk.Key":          jwkKey,
+			} {
+				verified, err := jws.Verify(signed, jws.WithKey(tc.Algorithm, pubkey))
+				require.NoError(t, err, `jws.Verify should succeed using %s`, name)
+				require.Equal(t, payload, verified, `payloads should match`)
+			}
+
+			algs, err := jws.AlgorithmsForKey(key)
+			require.NoError(t, err, `jws.AlgorithmsForKey should succeed`)
+			for _, alg := range []jwa.SignatureAlgorithm{jwa.ESB256, jwa.ESB384, jwa.ESB512} {
+				if alg == tc.Algorithm {
+					require.Contains(t, algs, alg, `jws.AlgorithmsForKey should list %s`, alg)
+				} else {
+					require.NotContains(t, algs, alg, `jws.AlgorithmsForKey should not list %s`, alg)
+				}
+			}
+
+			p256key, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+			require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+			_, err = jws.Sign(payload, jws.WithKey(tc.Algorithm, p256key))
+			require.Error(t, err, `jws.Sign should fail for a key on a different curve`)
+
+			hdrs := jws.NewHeaders()
+			require.NoError(t, hdrs.Set(jws.KeyIDKey, tc.Algorithm.String()), `hdrs.Set should succeed`)
+			signed, err = jws.Sign(payload, jws.WithKey(tc.Algorithm, signer, jws.WithProtectedHeaders(hdrs)))
+			require.NoError(t, err, `jws.Sign should succeed`)
+
+			set := jwk.NewSet()
+			require.NoError(t, jwkKey.Set(jwk.KeyIDKey, tc.Algorithm.String()), `jwkKey.Set should succeed`)
+			require.NoError(t, jwkKey.Set(jwk.AlgorithmKey, tc.Algorithm), `jwkKey.Set should succeed`)
+			require.NoError(t, set.AddKey(jwkKey), `set.AddKey should succeed`)
+			verified, err := jws.Verify(signed, jws.WithKeySet(set))
+			require.NoError(t, err, `jws.Verify should succeed`)
+			require.Equal(t, payload, verified)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package jws
+
+import (
+	"context"
+	"crypto"
+	"io"
+)
+
+// ContextSigner is a Signer that can receive the context.Context passed
+// to `jws.Sign()` via `jws.WithContext()`. Signers that talk to remote
+// services (e.g. KMS or HSM) should implement this interface so that
+// deadlines, cancellation, and request-scoped values are honored.
+//
+// The built-in RSA, ECDSA, and EdDSA signers implement this interface,
+// and pass the context to keys that implement `jws.ContextCryptoSigner`.
+type ContextSigner interface {
+	Signer
+	SignContext(ctx context.Context, payload []byte, key interface{}) ([]byte, error)
+}
+
+// ContextCryptoSigner is a crypto.Signer that can receive a context.Context.
+// When a key that implements this interface is used with the built-in
+// RSA, ECDSA, or EdDSA signers, `SignContext()` is called with the
+// context passed to `jws.Sign()` instead of `Sign()`.
+type ContextCryptoSigner interface {
+	crypto.Signer
+	SignContext(ctx context.Context, rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error)
+}
+
+// CryptoSignerWithContext returns a crypto.Signer whose `Sign()` method
+// calls `s.SignContext()` using `ctx`. This allows a `jws.ContextCryptoSigner`
+// to be used wherever a crypto.Signer is expected.
+func CryptoSignerWithContext(ctx context.Context, s ContextCryptoSigner) crypto.Signer {
+	return &contextCryptoSigner{ctx: ctx, signer: s}
+}
+
+type contextCryptoSigner struct {
+	ctx    context.Context
+	signer ContextCryptoSigner
+}
+
+func (s *contextCryptoSigner) Public() crypto.PublicKey {
+	return s.signer.Public()
+}
+
+func (s *contextCryptoSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.signer.SignContext(s.ctx, rand, digest, opts)
+}
+
+// bindContext binds ctx to key if the key is a jws.ContextCryptoSigner
+func bindContext(ctx context.Context, key interface{}) interface{} {
+	if s, ok := key.(ContextCryptoSigner); ok {
+		return CryptoSignerWithContext(ctx, s)
+	}
+	return key
+}
+
+func (rs *rsaSigner) SignContext(ctx context.Context, payload []byte, key interface{}) ([]byte, error) {
+	return rs.Sign(payload, bindContext(ctx, key))
+}
+
+func (es *ecdsaSigner) SignContext(ctx context.Context, payload []byte, key interface{}) ([]byte, error) {
+	return es.Sign(payload, bindContext(ctx, key))
+}
+
+func (s eddsaSigner) SignContext(ctx context.Context, payload []byte, key interface{}) ([]byte, error) {
+	return s.Sign(payload, bindContext(ctx, key))
+}
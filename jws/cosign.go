@@ -0,0 +1,212 @@
+package jws
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/sjwl/jwx/v2/internal/base64"
+	"github.com/sjwl/jwx/v2/internal/json"
+	"github.com/sjwl/jwx/v2/internal/keystrength"
+	"github.com/sjwl/jwx/v2/internal/pool"
+	"github.com/sjwl/jwx/v2/jwa"
+	"github.com/sjwl/jwx/v2/jwk"
+)
+
+// AppendSignature adds signatures to an existing JWS message, without
+// touching the signatures that are already present. This is useful when
+// a message must be signed by multiple parties at different times.
+//
+// `src` may be in compact, flattened JSON, or general JSON serialization
+// format. Specify the keys for the new signatures using `jws.WithKey()`:
+//
+//	jws.AppendSignature(signed, jws.WithKey(alg, key))
+//
+// The new signatures are computed over the same payload as the existing
+// ones. The protected headers of the existing signatures are kept as they
+// appear in `src`, so that the existing signatures remain valid. If the
+// message was signed with a detached payload, the payload must be passed
+// using `jws.WithDetachedPayload()`, as a message without a payload is
+// otherwise rejected. The "b64" header of the new signatures
+// must match that of the existing signatures.
+//
+// The result is always in general JSON serialization format, as it is the
+// only format that can hold multiple signatures. Use `jws.WithJSON(jws.WithPretty(true))`
+// to obtain an indented result.
+func AppendSignature(src []byte, options ...SignOption) ([]byte, error) {
+	format := fmtJSON
+	var signers []*payloadSigner
+	var detachedPayload []byte
+	var ksp *jwa.KeyStrengthPolicy
+	var setKeyStrengthPolicy bool
+	ctx := context.Background()
+	for _, option := range options {
+		//nolint:forcetypeassert
+		switch option.Ident() {
+		case identSerialization{}:
+			format = option.Value().(int)
+		case identContext{}:
+			ctx = option.Value().(context.Context)
+		case identKeyStrengthPolicy{}:
+			ksp = option.Value().(*jwa.KeyStrengthPolicy)
+			setKeyStrengthPolicy = true
+		case identKey{}:
+			data := option.Value().(*withKey)
+
+			alg, ok := data.alg.(jwa.SignatureAlgorithm)
+			if !ok {
+				return nil, fmt.Errorf(`jws.AppendSignature: expected algorithm to be of type jwa.SignatureAlgorithm but got (%[1]q, %[1]T)`, data.alg)
+			}
+			signer, err := makeSigner(alg, data.key, data.public, data.protected)
+			if err != nil {
+				return nil, fmt.Errorf(`jws.AppendSignature: failed to create signer: %w`, err)
+			}
+			signers = append(signers, signer)
+		case identDetachedPayload{}:
+			detachedPayload = option.Value().([]byte)
+		}
+	}
+
+	if len(signers) == 0 {
+		return nil, fmt.Errorf(`jws.AppendSignature: no signers available. Specify an algorithm and a key using jws.WithKey()`)
+	}
+
+	if format == fmtCompact {
+		return nil, fmt.Errorf(`jws.AppendSignature: cannot use compact serialization for messages with multiple signatures`)
+	}
+
+	if !setKeyStrengthPolicy {
+		ksp = getGlobalKeyStrengthPolicy()
+	}
+	for i, signer := range signers {
+		if err := keystrength.Check(ksp, signer.Algorithm(), signer.key); err != nil {
+			return nil, fmt.Errorf(`jws.AppendSignature: key for signer #%d (alg=%s): %w`, i, signer.Algorithm(), err)
+		}
+	}
+
+	msg, err := Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf(`jws.AppendSignature: failed to parse message: %w`, err)
+	}
+
+	if detachedPayload != nil {
+		if len(msg.payload) != 0 {
+			return nil, fmt.Errorf(`jws.AppendSignature: can't specify detached payload for JWS with payload`)
+		}
+		msg.payload = detachedPayload
+		msg.detached = true
+	} else if len(msg.payload) == 0 {
+		return nil, fmt.Errorf(`jws.AppendSignature: message has no payload. Specify the detached payload using jws.WithDetachedPayload()`)
+	}
+
+	existing := len(msg.signatures)
+	for i, signer := range signers {
+		protected := signer.ProtectedHeader()
+		if protected == nil {
+			protected = NewHeaders()
+		}
+
+		if err := protected.Set(AlgorithmKey, signer.Algorithm()); err != nil {
+			return nil, fmt.Errorf(`jws.AppendSignature: failed to set "alg" header: %w`, err)
+		}
+
+		if key, ok := signer.key.(jwk.Key); ok {
+			if kid := key.KeyID(); kid != "" {
+				if err := protected.Set(KeyIDKey, kid); err != nil {
+					return nil, fmt.Errorf(`jws.AppendSignature: failed to set "kid" header: %w`, err)
+				}
+			}
+		}
+
+		if existing > 0 && getB64Value(protected) != msg.b64 {
+			return nil, fmt.Errorf(`jws.AppendSignature: "b64" header of signer #%d does not match the existing signatures`, i)
+		}
+
+		sig := &Signature{
+			headers:   signer.PublicHeader(),
+			protected: protected,
+			detached:  msg.detached,
+		}
+		if _, _, err := sig.SignContext(ctx, msg.payload, signer.signer, signer.key); err != nil {
+			return nil, fmt.Errorf(`jws.AppendSignature: failed to generate signature for signer #%d (alg=%s): %w`, i, signer.Algorithm(), err)
+		}
+		msg.signatures = append(msg.signatures, sig)
+	}
+
+	serialized, err := marshalPreservingHeaders(msg)
+	if err != nil {
+		return nil, fmt.Errorf(`jws.AppendSignature: %w`, err)
+	}
+
+	if format == fmtJSONPretty {
+		return json.MarshalIndent(json.RawMessage(serialized), "", "  ")
+	}
+	return serialized, nil
+}
+
+// marshalPreservingHeaders serializes the message in general JSON
+// serialization format. Unlike `(jws.Message).MarshalJSON()`, protected
+// headers that were parsed from a message are written out exactly as
+// they were received, and the payload is only base64 encoded if the
+// "b64" header says so.
+func marshalPreservingHeaders(msg *Message) ([]byte, error) {
+	buf := pool.GetBytesBuffer()
+	defer pool.ReleaseBytesBuffer(buf)
+
+	buf.WriteRune('{')
+	if !msg.detached {
+		buf.WriteString(`"payload":`)
+		var payload string
+		if msg.b64 {
+			payload = base64.EncodeToString(msg.payload)
+		} else {
+			payload = string(msg.payload)
+		}
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to marshal "payload": %w`, err)
+		}
+		buf.Write(encoded)
+		buf.WriteRune(',')
+	}
+	buf.WriteString(`"signatures":[`)
+	for i, sig := range msg.signatures {
+		if i > 0 {
+			buf.WriteRune(',')
+		}
+
+		buf.WriteRune('{')
+		if hdr := sig.headers; hdr != nil {
+			hdrbuf, err := hdr.MarshalJSON()
+			if err != nil {
+				return nil, fmt.Errorf(`failed to marshal "header" for signature #%d: %w`, i+1, err)
+			}
+			// Signatures parsed from the flattened format always have
+			// an unprotected header, even if the message had none
+			if !bytes.Equal(bytes.TrimSpace(hdrbuf), []byte(`{}`)) {
+				buf.WriteString(`"header":`)
+				buf.Write(hdrbuf)
+				buf.WriteRune(',')
+			}
+		}
+
+		if sig.protected != nil {
+			protected, err := encodeProtectedHeader(sig)
+			if err != nil {
+				return nil, fmt.Errorf(`failed to marshal "protected" for signature #%d: %w`, i+1, err)
+			}
+			buf.WriteString(`"protected":"`)
+			buf.WriteString(protected)
+			buf.WriteString(`",`)
+		}
+
+		buf.WriteString(`"signature":"`)
+		buf.WriteString(base64.EncodeToString(sig.signature))
+		buf.WriteString(`"}`)
+	}
+	buf.WriteString(`]}`)
+
+	ret := make([]byte, buf.Len())
+	copy(ret, buf.Bytes())
+	return ret, nil
+}
@@ -0,0 +1,138 @@
+package jws
+
+import (
+	"fmt"
+	"sync"
+)
+
+// critical headers that this package (or the user) knows how to process.
+// Names listed in the "crit" header of an incoming message must be
+// found in here, or the message is rejected by `jws.Verify()`
+var muCriticalHeaders sync.RWMutex
+var criticalHeaders = map[string]struct{}{
+	"b64": {}, // RFC7797
+}
+
+// RegisterCriticalHeader registers the name of a header field that the
+// application understands and processes. This has a global effect.
+//
+// RFC7515 states that if a JWS message lists a header name in its "crit"
+// header that the recipient does not understand, the message must be
+// rejected. By default `jws.Verify()` (and therefore `jwt.Parse()`) only
+// understands "b64" (RFC7797). If your application uses extension headers
+// that are marked as critical, you must register them using this function
+// (probably in your `init()`) so that `jws.Verify()` accepts them.
+//
+// Note that registering a name only tells the library that the
+// application processes the header. It is still up to you to actually
+// check the value of the header after verification.
+func RegisterCriticalHeader(name string) {
+	muCriticalHeaders.Lock()
+	criticalHeaders[name] = struct{}{}
+	muCriticalHeaders.Unlock()
+}
+
+// UnregisterCriticalHeader removes a header name previously registered
+// using `jws.RegisterCriticalHeader()`
+func UnregisterCriticalHeader(name string) {
+	muCriticalHeaders.Lock()
+	delete(criticalHeaders, name)
+	muCriticalHeaders.Unlock()
+}
+
+func isCriticalHeaderUnderstood(name string) bool {
+	muCriticalHeaders.RLock()
+	_, ok := criticalHeaders[name]
+	muCriticalHeaders.RUnlock()
+	return ok
+}
+
+type unknownCriticalHeaderError struct {
+	name string
+}
+
+func (err *unknownCriticalHeaderError) Error() string {
+	if err.name == "" {
+		return `unknown header listed in "crit"`
+	}
+	return fmt.Sprintf(`unknown header %q listed in "crit"`, err.name)
+}
+
+func (err *unknownCriticalHeaderError) Is(target error) bool {
+	_, ok := target.(*unknownCriticalHeaderError)
+	return ok
+}
+
+type missingCriticalHeaderError struct {
+	name string
+}
+
+func (err *missingCriticalHeaderError) Error() string {
+	if err.name == "" {
+		return `header listed in "crit" is missing from protected headers`
+	}
+	return fmt.Sprintf(`header %q listed in "crit" is missing from protected headers`, err.name)
+}
+
+func (err *missingCriticalHeaderError) Is(target error) bool {
+	_, ok := target.(*missingCriticalHeaderError)
+	return ok
+}
+
+var errUnknownCriticalHeader = &unknownCriticalHeaderError{}
+var errMissingCriticalHeader = &missingCriticalHeaderError{}
+
+// ErrUnknownCriticalHeader returns the immutable error used when a
+// JWS message lists a header name in its "crit" header that has not
+// been registered via `jws.RegisterCriticalHeader()`.
+//
+// The return value should only be used for comparison using `errors.Is()`
+func ErrUnknownCriticalHeader() error {
+	return errUnknownCriticalHeader
+}
+
+// ErrMissingCriticalHeader returns the immutable error used when a
+// JWS message lists a header name in its "crit" header, but the
+// protected headers do not contain the header.
+//
+// The return value should only be used for comparison using `errors.Is()`
+func ErrMissingCriticalHeader() error {
+	return errMissingCriticalHeader
+}
+
+// verifyCritical checks the "crit" header in the signature, as
+// described in https://tools.ietf.org/html/rfc7515#section-4.1.11
+func verifyCritical(sig *Signature) error {
+	if public := sig.PublicHeaders(); public != nil {
+		if _, ok := public.Get(CriticalKey); ok {
+			return fmt.Errorf(`"crit" must be in the protected header`)
+		}
+	}
+
+	protected := sig.ProtectedHeaders()
+	if protected == nil {
+		return nil
+	}
+
+	v, ok := protected.Get(CriticalKey)
+	if !ok {
+		return nil
+	}
+
+	//nolint:forcetypeassert
+	crit := v.([]string) // the generated header code guarantees this
+	if len(crit) == 0 {
+		return fmt.Errorf(`"crit" must not be an empty list`)
+	}
+
+	for _, name := range crit {
+		if !isCriticalHeaderUnderstood(name) {
+			return &unknownCriticalHeaderError{name: name}
+		}
+
+		if _, ok := protected.Get(name); !ok {
+			return &missingCriticalHeaderError{name: name}
+		}
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/sjwl/jwx/v2/internal/brainpool"
 	"github.com/sjwl/jwx/v2/internal/keyconv"
 	"github.com/sjwl/jwx/v2/internal/pool"
 	"github.com/sjwl/jwx/v2/jwa"
@@ -17,16 +18,16 @@ var ecdsaSigners map[jwa.SignatureAlgorithm]*ecdsaSigner
 var ecdsaVerifiers map[jwa.SignatureAlgorithm]*ecdsaVerifier
 
 func init() {
-	algs := map[jwa.SignatureAlgorithm]crypto.Hash{
-		jwa.ES256:  crypto.SHA256,
-		jwa.ES384:  crypto.SHA384,
-		jwa.ES512:  crypto.SHA512,
-		jwa.ES256K: crypto.SHA256,
+	algs := []jwa.SignatureAlgorithm{
+		jwa.ES256, jwa.ES384, jwa.ES512, jwa.ES256K,
+		jwa.ESP256, jwa.ESP384, jwa.ESP512,
+		jwa.ESB256, jwa.ESB384, jwa.ESB512,
 	}
 	ecdsaSigners = make(map[jwa.SignatureAlgorithm]*ecdsaSigner)
 	ecdsaVerifiers = make(map[jwa.SignatureAlgorithm]*ecdsaVerifier)
 
-	for alg, hash := range algs {
+	for _, alg := range algs {
+		hash := builtinMetadata(alg).Hash
 		ecdsaSigners[alg] = &ecdsaSigner{
 			alg:  alg,
 			hash: hash,
@@ -61,7 +62,23 @@ func (es *ecdsaSigner) Sign(payload []byte, key interface{}) ([]byte, error) {
 	if _, err := h.Write(payload); err != nil {
 		return nil, fmt.Errorf(`failed to write payload using ecdsa: %w`, err)
 	}
+	return es.signDigest(h.Sum(nil), key)
+}
+
+func (es *ecdsaSigner) newSignStream(key interface{}) (signStream, error) {
+	if key == nil {
+		return nil, fmt.Errorf(`missing private key while signing payload`)
+	}
+
+	return &digestSignStream{
+		Hash: es.hash.New(),
+		sign: func(digest []byte) ([]byte, error) {
+			return es.signDigest(digest, key)
+		},
+	}, nil
+}
 
+func (es *ecdsaSigner) signDigest(digest []byte, key interface{}) ([]byte, error) {
 	signer, ok := key.(crypto.Signer)
 	if ok {
 		switch key.(type) {
@@ -75,7 +92,7 @@ func (es *ecdsaSigner) Sign(payload []byte, key interface{}) ([]byte, error) {
 	var r, s *big.Int
 	var curveBits int
 	if ok {
-		signed, err := signer.Sign(rand.Reader, h.Sum(nil), es.hash)
+		signed, err := signer.Sign(rand.Reader, digest, es.hash)
 		if err != nil {
 			return nil, err
 		}
@@ -100,6 +117,9 @@ func (es *ecdsaSigner) Sign(payload []byte, key interface{}) ([]byte, error) {
 		if !ok {
 			return nil, fmt.Errorf(`expected *ecdsa.PublicKey, got %T`, pubkey)
 		}
+		if err := checkECDSACurve(es.alg, pubkey); err != nil {
+			return nil, err
+		}
 		curveBits = pubkey.Curve.Params().BitSize
 
 		r = p.R
@@ -109,8 +129,17 @@ func (es *ecdsaSigner) Sign(payload []byte, key interface{}) ([]byte, error) {
 		if err := keyconv.ECDSAPrivateKey(&privkey, key); err != nil {
 			return nil, fmt.Errorf(`failed to retrieve ecdsa.PrivateKey out of %T: %w`, key, err)
 		}
+		if err := checkECDSACurve(es.alg, &privkey.PublicKey); err != nil {
+			return nil, err
+		}
+		if brainpool.IsCurve(privkey.Curve) {
+			// the arithmetic for these curves is not constant time, and
+			// could leak the private key. Keys held by a crypto.Signer
+			// (e.g. in a HSM) are handled above, and are still accepted
+			return nil, fmt.Errorf(`signing with %s private keys is not supported, as its implementation is not constant time`, privkey.Curve.Params().Name)
+		}
 		curveBits = privkey.Curve.Params().BitSize
-		rtmp, stmp, err := ecdsa.Sign(rand.Reader, &privkey, h.Sum(nil))
+		rtmp, stmp, err := ecdsa.Sign(rand.Reader, &privkey, digest)
 		if err != nil {
 			return nil, fmt.Errorf(`failed to sign payload using ecdsa: %w`, err)
 		}
@@ -151,8 +180,51 @@ func (v ecdsaVerifier) Algorithm() jwa.SignatureAlgorithm {
 }
 
 func (v *ecdsaVerifier) Verify(payload []byte, signature []byte, key interface{}) error {
+	pubkey, err := ecdsaVerifyKey(v.alg, key)
+	if err != nil {
+		return err
+	}
+
+	h := v.hash.New()
+	if _, err := h.Write(payload); err != nil {
+		return fmt.Errorf(`failed to write payload using ecdsa: %w`, err)
+	}
+	return v.verifyDigest(pubkey, h.Sum(nil), signature)
+}
+
+func (v *ecdsaVerifier) newVerifyStream(key interface{}) (verifyStream, error) {
+	pubkey, err := ecdsaVerifyKey(v.alg, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &digestVerifyStream{
+		Hash: v.hash.New(),
+		verify: func(digest, signature []byte) error {
+			return v.verifyDigest(pubkey, digest, signature)
+		},
+	}, nil
+}
+
+func (v *ecdsaVerifier) verifyDigest(pubkey *ecdsa.PublicKey, digest, signature []byte) error {
+	r := pool.GetBigInt()
+	s := pool.GetBigInt()
+	defer pool.ReleaseBigInt(r)
+	defer pool.ReleaseBigInt(s)
+
+	n := len(signature) / 2
+	r.SetBytes(signature[:n])
+	s.SetBytes(signature[n:])
+
+	if !ecdsa.Verify(pubkey, digest, r, s) {
+		return fmt.Errorf(`failed to verify signature using ecdsa`)
+	}
+	return nil
+}
+
+func ecdsaVerifyKey(alg jwa.SignatureAlgorithm, key interface{}) (*ecdsa.PublicKey, error) {
 	if key == nil {
-		return fmt.Errorf(`missing public key while verifying payload`)
+		return nil, fmt.Errorf(`missing public key while verifying payload`)
 	}
 
 	var pubkey ecdsa.PublicKey
@@ -164,34 +236,26 @@ func (v *ecdsaVerifier) Verify(payload []byte, signature []byte, key interface{}
 		case *ecdsa.PublicKey:
 			pubkey = *cpub
 		default:
-			return fmt.Errorf(`failed to retrieve ecdsa.PublicKey out of crypto.Signer %T`, key)
+			return nil, fmt.Errorf(`failed to retrieve ecdsa.PublicKey out of crypto.Signer %T`, key)
 		}
 	} else {
 		if err := keyconv.ECDSAPublicKey(&pubkey, key); err != nil {
-			return fmt.Errorf(`failed to retrieve ecdsa.PublicKey out of %T: %w`, key, err)
+			return nil, fmt.Errorf(`failed to retrieve ecdsa.PublicKey out of %T: %w`, key, err)
 		}
 	}
 
-	if !pubkey.Curve.IsOnCurve(pubkey.X, pubkey.Y) {
-		return fmt.Errorf(`public key used does not contain a point (X,Y) on the curve`)
+	if err := checkECDSACurve(alg, &pubkey); err != nil {
+		return nil, err
 	}
-
-	r := pool.GetBigInt()
-	s := pool.GetBigInt()
-	defer pool.ReleaseBigInt(r)
-	defer pool.ReleaseBigInt(s)
-
-	n := len(signature) / 2
-	r.SetBytes(signature[:n])
-	s.SetBytes(signature[n:])
-
-	h := v.hash.New()
-	if _, err := h.Write(payload); err != nil {
-		return fmt.Errorf(`failed to write payload using ecdsa: %w`, err)
+	if !pubkey.Curve.IsOnCurve(pubkey.X, pubkey.Y) {
+		return nil, fmt.Errorf(`public key used does not contain a point (X,Y) on the curve`)
 	}
+	return &pubkey, nil
+}
 
-	if !ecdsa.Verify(&pubkey, h.Sum(nil), r, s) {
-		return fmt.Errorf(`failed to verify signature using ecdsa`)
-	}
-	return nil
+// checkECDSACurve makes sure that fully-specified algorithms such as
+// ESP256 are only used with keys on the curve that they require
+func checkECDSACurve(alg jwa.SignatureAlgorithm, key *ecdsa.PublicKey) error {
+	crv, _ := ecdsaCurve(key)
+	return checkAlgorithmCurve(alg, crv)
 }
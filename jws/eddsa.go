@@ -6,18 +6,24 @@ import (
 	"crypto/rand"
 	"fmt"
 
+	"github.com/sjwl/jwx/v2/ed448"
 	"github.com/sjwl/jwx/v2/internal/keyconv"
 	"github.com/sjwl/jwx/v2/jwa"
 )
 
-type eddsaSigner struct{}
+// eddsaSigner signs payloads using jwa.EdDSA, or one of the fully-specified
+// algorithms jwa.EdDSAEd25519 and jwa.EdDSAEd448, which only accept keys
+// for their respective curves
+type eddsaSigner struct {
+	alg jwa.SignatureAlgorithm
+}
 
-func newEdDSASigner() Signer {
-	return &eddsaSigner{}
+func newEdDSASigner(alg jwa.SignatureAlgorithm) Signer {
+	return &eddsaSigner{alg: alg}
 }
 
 func (s eddsaSigner) Algorithm() jwa.SignatureAlgorithm {
-	return jwa.EdDSA
+	return s.alg
 }
 
 func (s eddsaSigner) Sign(payload []byte, key interface{}) ([]byte, error) {
@@ -25,25 +31,49 @@ func (s eddsaSigner) Sign(payload []byte, key interface{}) ([]byte, error) {
 		return nil, fmt.Errorf(`missing private key while signing payload`)
 	}
 
-	// The ed25519.PrivateKey object implements crypto.Signer, so we should
-	// simply accept a crypto.Signer here.
+	// The ed25519.PrivateKey and ed448.PrivateKey objects implement
+	// crypto.Signer, so we should simply accept a crypto.Signer here.
 	signer, ok := key.(crypto.Signer)
 	if !ok {
 		// This fallback exists for cases when jwk.Key was passed, or
 		// users gave us a pointer instead of non-pointer, etc.
 		var privkey ed25519.PrivateKey
-		if err := keyconv.Ed25519PrivateKey(&privkey, key); err != nil {
-			return nil, fmt.Errorf(`failed to retrieve ed25519.PrivateKey out of %T: %w`, key, err)
+		if err := keyconv.Ed25519PrivateKey(&privkey, key); err == nil {
+			signer = privkey
+		} else {
+			var privkey448 ed448.PrivateKey
+			if err448 := keyconv.Ed448PrivateKey(&privkey448, key); err448 != nil {
+				return nil, fmt.Errorf(`failed to retrieve ed25519.PrivateKey or ed448.PrivateKey out of %T: %w`, key, err)
+			}
+			signer = privkey448
+		}
+	}
+
+	if crv, ok := eddsaCurve(signer.Public()); ok {
+		if err := checkAlgorithmCurve(s.alg, crv); err != nil {
+			return nil, err
 		}
-		signer = privkey
 	}
 	return signer.Sign(rand.Reader, payload, crypto.Hash(0))
 }
 
-type eddsaVerifier struct{}
+// eddsaCurve returns the curve of an EdDSA public key
+func eddsaCurve(pubkey interface{}) (jwa.EllipticCurveAlgorithm, bool) {
+	switch pubkey.(type) {
+	case ed25519.PublicKey:
+		return jwa.Ed25519, true
+	case ed448.PublicKey:
+		return jwa.Ed448, true
+	}
+	return jwa.InvalidEllipticCurve, false
+}
+
+type eddsaVerifier struct {
+	alg jwa.SignatureAlgorithm
+}
 
-func newEdDSAVerifier() Verifier {
-	return &eddsaVerifier{}
+func newEdDSAVerifier(alg jwa.SignatureAlgorithm) Verifier {
+	return &eddsaVerifier{alg: alg}
 }
 
 func (v eddsaVerifier) Verify(payload, signature []byte, key interface{}) (err error) {
@@ -51,21 +81,42 @@ func (v eddsaVerifier) Verify(payload, signature []byte, key interface{}) (err e
 		return fmt.Errorf(`missing public key while verifying payload`)
 	}
 
-	var pubkey ed25519.PublicKey
-	signer, ok := key.(crypto.Signer)
-	if ok {
-		v := signer.Public()
-		pubkey, ok = v.(ed25519.PublicKey)
-		if !ok {
-			return fmt.Errorf(`expected crypto.Signer.Public() to return ed25519.PublicKey, but got %T`, v)
-		}
+	var pubkey interface{}
+	if signer, ok := key.(crypto.Signer); ok {
+		pubkey = signer.Public()
 	} else {
-		if err := keyconv.Ed25519PublicKey(&pubkey, key); err != nil {
-			return fmt.Errorf(`failed to retrieve ed25519.PublicKey out of %T: %w`, key, err)
+		var pubkey25519 ed25519.PublicKey
+		if err := keyconv.Ed25519PublicKey(&pubkey25519, key); err == nil {
+			pubkey = pubkey25519
+		} else {
+			var pubkey448 ed448.PublicKey
+			if err448 := keyconv.Ed448PublicKey(&pubkey448, key); err448 != nil {
+				return fmt.Errorf(`failed to retrieve ed25519.PublicKey or ed448.PublicKey out of %T: %w`, key, err)
+			}
+			pubkey = pubkey448
+		}
+	}
+
+	if crv, ok := eddsaCurve(pubkey); ok {
+		if err := checkAlgorithmCurve(v.alg, crv); err != nil {
+			return err
+		}
+	}
+
+	var verified bool
+	switch pubkey := pubkey.(type) {
+	case ed25519.PublicKey:
+		verified = ed25519.Verify(pubkey, payload, signature)
+	case ed448.PublicKey:
+		if len(pubkey) != ed448.PublicKeySize {
+			return fmt.Errorf(`invalid ed448.PublicKey size: %d`, len(pubkey))
 		}
+		verified = ed448.Verify(pubkey, payload, signature)
+	default:
+		return fmt.Errorf(`expected crypto.Signer.Public() to return ed25519.PublicKey or ed448.PublicKey, but got %T`, pubkey)
 	}
 
-	if !ed25519.Verify(pubkey, payload, signature) {
+	if !verified {
 		return fmt.Errorf(`failed to match EdDSA signature`)
 	}
 
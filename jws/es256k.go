@@ -8,5 +8,5 @@ import (
 )
 
 func init() {
-	addAlgorithmForKeyType(jwa.EC, jwa.ES256K)
+	addAlgorithm(jwa.ES256K)
 }
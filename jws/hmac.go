@@ -2,8 +2,8 @@ package jws
 
 import (
 	"crypto/hmac"
-	"crypto/sha256"
-	"crypto/sha512"
+	_ "crypto/sha256" // for crypto.SHA256.New()
+	_ "crypto/sha512" // for crypto.SHA384.New() and crypto.SHA512.New()
 	"fmt"
 	"hash"
 
@@ -12,16 +12,13 @@ import (
 )
 
 var hmacSignFuncs = map[jwa.SignatureAlgorithm]hmacSignFunc{}
+var hmacHashFuncs = map[jwa.SignatureAlgorithm]func() hash.Hash{}
 
 func init() {
-	algs := map[jwa.SignatureAlgorithm]func() hash.Hash{
-		jwa.HS256: sha256.New,
-		jwa.HS384: sha512.New384,
-		jwa.HS512: sha512.New,
-	}
-
-	for alg, h := range algs {
+	for _, alg := range []jwa.SignatureAlgorithm{jwa.HS256, jwa.HS384, jwa.HS512} {
+		h := builtinMetadata(alg).Hash.New
 		hmacSignFuncs[alg] = makeHMACSignFunc(h)
+		hmacHashFuncs[alg] = h
 	}
 }
 
@@ -47,6 +44,30 @@ func (s HMACSigner) Algorithm() jwa.SignatureAlgorithm {
 }
 
 func (s HMACSigner) Sign(payload []byte, key interface{}) ([]byte, error) {
+	hmackey, err := hmacKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.sign(payload, hmackey)
+}
+
+func (s HMACSigner) newSignStream(key interface{}) (signStream, error) {
+	hmackey, err := hmacKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// The HMAC value is the signature, so there's nothing more to do
+	// once the signing input has been written
+	return &digestSignStream{
+		Hash: hmac.New(hmacHashFuncs[s.alg], hmackey),
+		sign: func(digest []byte) ([]byte, error) {
+			return digest, nil
+		},
+	}, nil
+}
+
+func hmacKey(key interface{}) ([]byte, error) {
 	var hmackey []byte
 	if err := keyconv.ByteSliceKey(&hmackey, key); err != nil {
 		return nil, fmt.Errorf(`invalid key type %T. []byte is required: %w`, key, err)
@@ -55,8 +76,7 @@ func (s HMACSigner) Sign(payload []byte, key interface{}) ([]byte, error) {
 	if len(hmackey) == 0 {
 		return nil, fmt.Errorf(`missing key while signing payload`)
 	}
-
-	return s.sign(payload, hmackey)
+	return hmackey, nil
 }
 
 func newHMACVerifier(alg jwa.SignatureAlgorithm) Verifier {
@@ -75,3 +95,20 @@ func (v HMACVerifier) Verify(payload, signature []byte, key interface{}) (err er
 	}
 	return nil
 }
+
+func (v HMACVerifier) newVerifyStream(key interface{}) (verifyStream, error) {
+	hmackey, err := hmacKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &digestVerifyStream{
+		Hash: hmac.New(hmacHashFuncs[v.signer.Algorithm()], hmackey),
+		verify: func(expected, signature []byte) error {
+			if !hmac.Equal(signature, expected) {
+				return fmt.Errorf(`failed to match hmac signature`)
+			}
+			return nil
+		},
+	}, nil
+}
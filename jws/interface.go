@@ -54,6 +54,7 @@ type Message struct {
 	payload    []byte
 	signatures []*Signature
 	b64        bool // true if payload should be base64 encoded
+	detached   bool // true if the payload should be omitted from the JSON serialization
 }
 
 type Signature struct {
@@ -0,0 +1,32 @@
+package jws
+
+import (
+	"fmt"
+
+	"github.com/sjwl/jwx/v2/internal/json"
+)
+
+// canonicalPayload returns the JCS (RFC8785) canonical form of `v`.
+// `[]byte` and `json.RawMessage` values are treated as raw JSON text,
+// and other values are converted to JSON first.
+func canonicalPayload(v interface{}) ([]byte, error) {
+	var src []byte
+	switch v := v.(type) {
+	case []byte:
+		src = v
+	case json.RawMessage:
+		src = v
+	default:
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to marshal %T into JSON: %w`, v, err)
+		}
+		src = buf
+	}
+
+	canonical, err := json.Canonicalize(src)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to canonicalize JSON: %w`, err)
+	}
+	return canonical, nil
+}
@@ -36,13 +36,17 @@ import (
 	"unicode"
 	"unicode/utf8"
 
-	"github.com/lestrrat-go/blackmagic"
+	"github.com/sjwl/jwx/v2/ed448"
 	"github.com/sjwl/jwx/v2/internal/base64"
+	"github.com/sjwl/jwx/v2/internal/ecutil"
 	"github.com/sjwl/jwx/v2/internal/json"
+	"github.com/sjwl/jwx/v2/internal/keystrength"
+	"github.com/sjwl/jwx/v2/internal/mldsa"
 	"github.com/sjwl/jwx/v2/internal/pool"
 	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/sjwl/jwx/v2/jwk"
 	"github.com/sjwl/jwx/v2/x25519"
+	"github.com/sjwl/jwx/v2/x448"
 )
 
 var registry = json.NewRegistry()
@@ -139,11 +143,20 @@ func Sign(payload []byte, options ...SignOption) ([]byte, error) {
 	format := fmtCompact
 	var signers []*payloadSigner
 	var detached bool
+	var detachedReader io.Reader
+	var ksp *jwa.KeyStrengthPolicy
+	var setKeyStrengthPolicy bool
+	ctx := context.Background()
 	for _, option := range options {
 		//nolint:forcetypeassert
 		switch option.Ident() {
 		case identSerialization{}:
 			format = option.Value().(int)
+		case identContext{}:
+			ctx = option.Value().(context.Context)
+		case identKeyStrengthPolicy{}:
+			ksp = option.Value().(*jwa.KeyStrengthPolicy)
+			setKeyStrengthPolicy = true
 		case identKey{}:
 			data := option.Value().(*withKey)
 
@@ -162,9 +175,30 @@ func Sign(payload []byte, options ...SignOption) ([]byte, error) {
 				return nil, fmt.Errorf(`jws.Sign: payload must be nil when jws.WithDetachedPayload() is specified`)
 			}
 			payload = option.Value().([]byte)
+		case identDetachedPayloadReader{}:
+			if payload != nil {
+				return nil, fmt.Errorf(`jws.Sign: payload must be nil when jws.WithDetachedPayloadReader() is specified`)
+			}
+			detachedReader = option.Value().(io.Reader)
+		case identCanonicalPayload{}, identDetachedCanonicalPayload{}:
+			if payload != nil {
+				return nil, fmt.Errorf(`jws.Sign: payload must be nil when jws.%s() is specified`, option.Ident())
+			}
+			canonical, err := canonicalPayload(option.Value())
+			if err != nil {
+				return nil, fmt.Errorf(`jws.Sign: %w`, err)
+			}
+			payload = canonical
+			if option.Ident() == (identDetachedCanonicalPayload{}) {
+				detached = true
+			}
 		}
 	}
 
+	if detached && detachedReader != nil {
+		return nil, fmt.Errorf(`jws.Sign: jws.WithDetachedPayload() and jws.WithDetachedPayloadReader() cannot be used together`)
+	}
+
 	lsigner := len(signers)
 	if lsigner == 0 {
 		return nil, fmt.Errorf(`jws.Sign: no signers available. Specify an alogirthm and akey using jws.WithKey()`)
@@ -182,6 +216,19 @@ func Sign(payload []byte, options ...SignOption) ([]byte, error) {
 		return nil, fmt.Errorf(`jws.Sign: cannot have multiple signers (keys) specified for compact serialization. Use only one jws.WithKey()`)
 	}
 
+	if !setKeyStrengthPolicy {
+		ksp = getGlobalKeyStrengthPolicy()
+	}
+	for i, signer := range signers {
+		if err := keystrength.Check(ksp, signer.Algorithm(), signer.key); err != nil {
+			return nil, fmt.Errorf(`jws.Sign: key for signer #%d (alg=%s): %w`, i, signer.Algorithm(), err)
+		}
+	}
+
+	if detachedReader != nil {
+		return signReader(ctx, detachedReader, format, signers)
+	}
+
 	// Create a Message object with all the bits and bobs, and we'll
 	// serialize it in the end
 	var result Message
@@ -212,7 +259,7 @@ func Sign(payload []byte, options ...SignOption) ([]byte, error) {
 			// cheat. FIXXXXXXMEEEEEE
 			detached: detached,
 		}
-		_, _, err := sig.Sign(payload, signer.signer, signer.key)
+		_, _, err := sig.SignContext(ctx, payload, signer.signer, signer.key)
 		if err != nil {
 			return nil, fmt.Errorf(`failed to generate signature for signer #%d (alg=%s): %w`, i, signer.Algorithm(), err)
 		}
@@ -246,7 +293,29 @@ var allowNoneWhitelist = jwk.WhitelistFunc(func(string) bool {
 // `key` may be a "raw" key (e.g. rsa.PublicKey) or a jwk.Key
 //
 // If the verification is successful, `err` is nil, and the content of the
-// payload that was signed is returned. If you need more fine-grained
+// payload that was signed is returned.
+//
+// Signatures whose protected headers contain a "crit" header listing
+// names that have not been registered via `jws.RegisterCriticalHeader()`,
+// or whose protected headers do not contain the listed names, are
+// never considered valid. If no other signature could be verified, the
+// returned error can be compared against `jws.ErrUnknownCriticalHeader()`
+// or `jws.ErrMissingCriticalHeader()` using `errors.Is()`.
+//
+// Likewise, signatures and keys whose algorithms are rejected by the
+// `jwa.AlgorithmPolicy` in effect (see `jws.Settings()`,
+// `jws.WithAlgorithmPolicy()`, and `jws.WithAllowedAlgorithms()`) are
+// skipped without being verified. Use `jwa.ErrAlgorithmNotAllowed()`
+// to detect this case. Keys that are rejected by the `jwa.KeyStrengthPolicy`
+// in effect (see `jws.WithKeyStrengthPolicy()`) are not used either. Use
+// `jwa.ErrKeyTooSmall()` and `jwa.ErrCurveNotAllowed()` to detect this case.
+//
+// By default, verification succeeds as soon as one of the signatures in
+// the message has been verified. Use `jws.WithRequiredSignatures()` or
+// `jws.WithAllSignaturesRequired()` to require more signatures, and
+// `jws.WithVerifyResult()` to learn the outcome for each signature.
+//
+// If you need more fine-grained
 // control of the verification process, manually generate a
 // `Verifier` in `verify` subpackage, and call `Verify` method on it.
 // If you need to access signatures and JOSE headers in a JWS message,
@@ -254,8 +323,17 @@ var allowNoneWhitelist = jwk.WhitelistFunc(func(string) bool {
 func Verify(buf []byte, options ...VerifyOption) ([]byte, error) {
 	var dst *Message
 	var detachedPayload []byte
+	var detachedReader io.Reader
 	var keyProviders []KeyProvider
 	var keyUsed interface{}
+	var policy *jwa.AlgorithmPolicy
+	var setPolicy bool
+	var policies algorithmPolicies
+	var ksp *jwa.KeyStrengthPolicy
+	var setKeyStrengthPolicy bool
+	var result *VerifyResult
+	var expectedPayload []byte
+	required := 1
 
 	ctx := context.Background()
 
@@ -264,8 +342,30 @@ func Verify(buf []byte, options ...VerifyOption) ([]byte, error) {
 		switch option.Ident() {
 		case identMessage{}:
 			dst = option.Value().(*Message)
+		case identAlgorithmPolicy{}:
+			policy = option.Value().(*jwa.AlgorithmPolicy)
+			setPolicy = true
+		case identAllowedAlgorithms{}:
+			policies = append(policies, option.Value().(*jwa.AlgorithmPolicy))
+		case identKeyStrengthPolicy{}:
+			ksp = option.Value().(*jwa.KeyStrengthPolicy)
+			setKeyStrengthPolicy = true
 		case identDetachedPayload{}:
 			detachedPayload = option.Value().([]byte)
+		case identDetachedPayloadReader{}:
+			detachedReader = option.Value().(io.Reader)
+		case identCanonicalPayload{}:
+			canonical, err := canonicalPayload(option.Value())
+			if err != nil {
+				return nil, fmt.Errorf(`jws.Verify: %w`, err)
+			}
+			expectedPayload = canonical
+		case identDetachedCanonicalPayload{}:
+			canonical, err := canonicalPayload(option.Value())
+			if err != nil {
+				return nil, fmt.Errorf(`jws.Verify: %w`, err)
+			}
+			detachedPayload = canonical
 		case identKey{}:
 			pair := option.Value().(*withKey)
 			alg, ok := pair.alg.(jwa.SignatureAlgorithm)
@@ -282,6 +382,13 @@ func Verify(buf []byte, options ...VerifyOption) ([]byte, error) {
 			keyUsed = option.Value()
 		case identContext{}:
 			ctx = option.Value().(context.Context)
+		case identVerifyResult{}:
+			result = option.Value().(*VerifyResult)
+		case identRequiredSignatures{}:
+			required = option.Value().(int)
+			if required < 1 && required != requireAllSignatures {
+				return nil, fmt.Errorf(`jws.Verify: number of required signatures must be at least 1 (got %d)`, required)
+			}
 		default:
 			return nil, fmt.Errorf(`invalid jws.VerifyOption %q passed`, `With`+strings.TrimPrefix(fmt.Sprintf(`%T`, option.Ident()), `jws.ident`))
 		}
@@ -291,12 +398,45 @@ func Verify(buf []byte, options ...VerifyOption) ([]byte, error) {
 		return nil, fmt.Errorf(`jws.Verify: no key providers have been provided (see jws.WithKey(), jws.WithKeySet(), jws.WithVerifyAuto(), and jws.WithKeyProvider()`)
 	}
 
+	// A policy passed to jws.Verify() replaces the global policy
+	if !setPolicy {
+		policy = getGlobalPolicy()
+	}
+	policies = append(policies, policy)
+	if !setKeyStrengthPolicy {
+		ksp = getGlobalKeyStrengthPolicy()
+	}
+
 	msg, err := Parse(buf)
 	if err != nil {
 		return nil, fmt.Errorf(`failed to parse jws: %w`, err)
 	}
 	defer msg.clearRaw()
 
+	vctx := verifyCtx{
+		ctx:          ctx,
+		msg:          msg,
+		keyProviders: keyProviders,
+		policies:     policies,
+		keyStrength:  ksp,
+		required:     required,
+		exhaustive:   result != nil || required != 1,
+	}
+	vctx.init()
+
+	if detachedReader != nil {
+		if detachedPayload != nil {
+			return nil, fmt.Errorf(`jws.WithDetachedPayload() and jws.WithDetachedPayloadReader() cannot be used together`)
+		}
+		if err := verifyReader(&vctx, detachedReader); err != nil {
+			return nil, err
+		}
+		if err := vctx.finish(keyUsed, dst, result); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
 	if detachedPayload != nil {
 		if len(msg.payload) != 0 {
 			return nil, fmt.Errorf(`can't specify detached payload for JWS with payload`)
@@ -317,65 +457,73 @@ func Verify(buf []byte, options ...VerifyOption) ([]byte, error) {
 	defer pool.ReleaseBytesBuffer(verifyBuf)
 
 	for i, sig := range msg.signatures {
-		verifyBuf.Reset()
+		if vctx.done() {
+			break
+		}
 
-		var encodedProtectedHeader string
-		if rbp, ok := sig.protected.(interface{ rawBuffer() []byte }); ok {
-			if raw := rbp.rawBuffer(); raw != nil {
-				encodedProtectedHeader = base64.EncodeToString(raw)
-			}
+		if !vctx.checkSignature(i, sig) {
+			continue
 		}
 
-		if encodedProtectedHeader == "" {
-			protected, err := json.Marshal(sig.protected)
-			if err != nil {
-				return nil, fmt.Errorf(`failed to marshal "protected" for signature #%d: %w`, i+1, err)
-			}
+		verifyBuf.Reset()
 
-			encodedProtectedHeader = base64.EncodeToString(protected)
+		encodedProtectedHeader, err := encodeProtectedHeader(sig)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to marshal "protected" for signature #%d: %w`, i+1, err)
 		}
 
 		verifyBuf.WriteString(encodedProtectedHeader)
 		verifyBuf.WriteByte('.')
 		verifyBuf.WriteString(payload)
 
-		for i, kp := range keyProviders {
-			var sink algKeySink
-			if err := kp.FetchKeys(ctx, &sink, sig, msg); err != nil {
-				return nil, fmt.Errorf(`key provider %d failed: %w`, i, err)
+		pairs, err := vctx.keys(i, sig)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pair := range pairs {
+			//nolint:forcetypeassert
+			alg := pair.alg.(jwa.SignatureAlgorithm)
+			verifier, err := NewVerifier(alg)
+			if err != nil {
+				return nil, fmt.Errorf(`failed to create verifier for algorithm %q: %w`, alg, err)
 			}
 
-			for _, pair := range sink.list {
-				// alg is converted here because pair.alg is of type jwa.KeyAlgorithm.
-				// this may seem ugly, but we're trying to avoid declaring separate
-				// structs for `alg jwa.KeyAlgorithm` and `alg jwa.SignatureAlgorithm`
-				//nolint:forcetypeassert
-				alg := pair.alg.(jwa.SignatureAlgorithm)
-				key := pair.key
-				verifier, err := NewVerifier(alg)
-				if err != nil {
-					return nil, fmt.Errorf(`failed to create verifier for algorithm %q: %w`, alg, err)
-				}
+			if err := verifier.Verify(verifyBuf.Bytes(), sig.signature, pair.key); err != nil {
+				vctx.fail(i, err)
+				continue
+			}
 
-				if err := verifier.Verify(verifyBuf.Bytes(), sig.signature, key); err != nil {
-					continue
-				}
+			vctx.result.signatures[i].setVerified(alg, pair.key)
+			break
+		}
+	}
 
-				if keyUsed != nil {
-					if err := blackmagic.AssignIfCompatible(keyUsed, key); err != nil {
-						return nil, fmt.Errorf(`failed to assign used key (%T) to %T: %w`, key, keyUsed, err)
-					}
-				}
+	if expectedPayload != nil && !bytes.Equal(msg.payload, expectedPayload) {
+		return nil, fmt.Errorf(`jws.Verify: payload does not match the canonical form of the given document`)
+	}
 
-				if dst != nil {
-					*(dst) = *msg
-				}
+	if err := vctx.finish(keyUsed, dst, result); err != nil {
+		return nil, err
+	}
+	return msg.payload, nil
+}
 
-				return msg.payload, nil
-			}
+// encodeProtectedHeader returns the base64 encoded protected header
+// for the signature. If the header was parsed from a message, the
+// original bytes are used.
+func encodeProtectedHeader(sig *Signature) (string, error) {
+	if rbp, ok := sig.protected.(interface{ rawBuffer() []byte }); ok {
+		if raw := rbp.rawBuffer(); raw != nil {
+			return base64.EncodeToString(raw), nil
 		}
 	}
-	return nil, fmt.Errorf(`could not verify message using any of the signatures or keys`)
+
+	protected, err := json.Marshal(sig.protected)
+	if err != nil {
+		return "", err
+	}
+	return base64.EncodeToString(protected), nil
 }
 
 // get the value of b64 header field.
@@ -665,20 +813,47 @@ var keyTypeToAlgorithms = make(map[jwa.KeyType][]jwa.SignatureAlgorithm)
 func init() {
 	rawKeyToKeyType[reflect.TypeOf([]byte(nil))] = jwa.OctetSeq
 	rawKeyToKeyType[reflect.TypeOf(ed25519.PublicKey(nil))] = jwa.OKP
+	rawKeyToKeyType[reflect.TypeOf(ed448.PublicKey(nil))] = jwa.OKP
 	rawKeyToKeyType[reflect.TypeOf(rsa.PublicKey{})] = jwa.RSA
 	rawKeyToKeyType[reflect.TypeOf((*rsa.PublicKey)(nil))] = jwa.RSA
 	rawKeyToKeyType[reflect.TypeOf(ecdsa.PublicKey{})] = jwa.EC
 	rawKeyToKeyType[reflect.TypeOf((*ecdsa.PublicKey)(nil))] = jwa.EC
+	rawKeyToKeyType[reflect.TypeOf((*mldsa.PublicKey)(nil))] = jwa.AKP
+
+	for _, alg := range []jwa.SignatureAlgorithm{
+		jwa.EdDSA,
+		jwa.HS256, jwa.HS384, jwa.HS512,
+		jwa.RS256, jwa.RS384, jwa.RS512, jwa.PS256, jwa.PS384, jwa.PS512,
+		jwa.ES256, jwa.ES384, jwa.ES512,
+		jwa.ESP256, jwa.ESP384, jwa.ESP512,
+		jwa.EdDSAEd25519, jwa.EdDSAEd448,
+	} {
+		addAlgorithm(alg)
+	}
+}
 
-	addAlgorithmForKeyType(jwa.OKP, jwa.EdDSA)
-	for _, alg := range []jwa.SignatureAlgorithm{jwa.HS256, jwa.HS384, jwa.HS512} {
-		addAlgorithmForKeyType(jwa.OctetSeq, alg)
+// builtinMetadata returns the metadata of a built-in algorithm. The
+// tables in this package are derived from it, so that they always
+// agree with what package jwa says about the algorithm
+func builtinMetadata(alg jwa.SignatureAlgorithm) jwa.AlgorithmMetadata {
+	md, ok := alg.Metadata()
+	if !ok {
+		panic(fmt.Sprintf(`jws: missing metadata for built-in algorithm %s`, alg))
 	}
-	for _, alg := range []jwa.SignatureAlgorithm{jwa.RS256, jwa.RS384, jwa.RS512, jwa.PS256, jwa.PS384, jwa.PS512} {
-		addAlgorithmForKeyType(jwa.RSA, alg)
+	return md
+}
+
+// addAlgorithm makes `alg` available to `jws.AlgorithmsForKey()`.
+// Fully-specified algorithms are listed for the curve given by their
+// metadata, and other algorithms for each of their key types
+func addAlgorithm(alg jwa.SignatureAlgorithm) {
+	md := builtinMetadata(alg)
+	if md.Curve != "" {
+		addAlgorithmForCurve(md.Curve, alg)
+		return
 	}
-	for _, alg := range []jwa.SignatureAlgorithm{jwa.ES256, jwa.ES384, jwa.ES512} {
-		addAlgorithmForKeyType(jwa.EC, alg)
+	for _, kty := range md.KeyTypes {
+		addAlgorithmForKeyType(kty, alg)
 	}
 }
 
@@ -686,20 +861,98 @@ func addAlgorithmForKeyType(kty jwa.KeyType, alg jwa.SignatureAlgorithm) {
 	keyTypeToAlgorithms[kty] = append(keyTypeToAlgorithms[kty], alg)
 }
 
+// curveToAlgorithms lists the fully-specified algorithms that can
+// only be used with keys on a particular curve
+var curveToAlgorithms = make(map[jwa.EllipticCurveAlgorithm][]jwa.SignatureAlgorithm)
+
+func addAlgorithmForCurve(crv jwa.EllipticCurveAlgorithm, alg jwa.SignatureAlgorithm) {
+	curveToAlgorithms[crv] = append(curveToAlgorithms[crv], alg)
+}
+
+// curveForKey returns the curve of an elliptic curve or OKP key.
+// The second return value is false if the curve cannot be determined
+func curveForKey(key interface{}) (jwa.EllipticCurveAlgorithm, bool) {
+	switch key := key.(type) {
+	case jwk.ECDSAPrivateKey:
+		return key.Crv(), true
+	case jwk.ECDSAPublicKey:
+		return key.Crv(), true
+	case jwk.OKPPrivateKey:
+		return key.Crv(), true
+	case jwk.OKPPublicKey:
+		return key.Crv(), true
+	case ecdsa.PublicKey:
+		return ecdsaCurve(&key)
+	case *ecdsa.PublicKey:
+		return ecdsaCurve(key)
+	case ecdsa.PrivateKey:
+		return ecdsaCurve(&key.PublicKey)
+	case *ecdsa.PrivateKey:
+		return ecdsaCurve(&key.PublicKey)
+	case ed25519.PublicKey, ed25519.PrivateKey:
+		return jwa.Ed25519, true
+	case ed448.PublicKey, ed448.PrivateKey:
+		return jwa.Ed448, true
+	case x25519.PublicKey, x25519.PrivateKey:
+		return jwa.X25519, true
+	case x448.PublicKey, x448.PrivateKey:
+		return jwa.X448, true
+	}
+	return jwa.InvalidEllipticCurve, false
+}
+
+func ecdsaCurve(key *ecdsa.PublicKey) (jwa.EllipticCurveAlgorithm, bool) {
+	if key == nil || key.Curve == nil {
+		return jwa.InvalidEllipticCurve, false
+	}
+	return ecutil.AlgorithmForCurve(key.Curve)
+}
+
+// checkAlgorithmCurve returns an error if alg is a fully-specified
+// algorithm that requires a curve other than crv
+func checkAlgorithmCurve(alg jwa.SignatureAlgorithm, crv jwa.EllipticCurveAlgorithm) error {
+	md, ok := alg.Metadata()
+	if !ok || md.Curve == "" || md.Curve == crv {
+		return nil
+	}
+	return fmt.Errorf(`algorithm %s requires a key on curve %s (got %q)`, alg, md.Curve, crv)
+}
+
 // AlgorithmsForKey returns the possible signature algorithms that can
 // be used for a given key. It only takes in consideration keys/algorithms
 // for verification purposes, as this is the only usage where one may need
 // dynamically figure out which method to use.
+//
+// For elliptic curve and OKP keys, the fully-specified algorithms that
+// match the curve of the key (e.g. jwa.ESP256 for a P-256 key, or
+// jwa.EdDSAEd25519 for an Ed25519 key) are listed after the polymorphic ones.
+//
+// ML-DSA keys (and AKP keys in jwk) can only be used with the algorithm
+// for their parameter set, which is the only algorithm returned.
 func AlgorithmsForKey(key interface{}) ([]jwa.SignatureAlgorithm, error) {
 	var kty jwa.KeyType
 	switch key := key.(type) {
 	case jwk.Key:
 		kty = key.KeyType()
+		if kty == jwa.AKP {
+			alg := jwa.SignatureAlgorithm(key.Algorithm().String())
+			if _, ok := mldsaParameters[alg]; !ok {
+				return nil, fmt.Errorf(`invalid algorithm %q for AKP key`, key.Algorithm())
+			}
+			return []jwa.SignatureAlgorithm{alg}, nil
+		}
+	case *mldsa.PublicKey, *mldsa.PrivateKey:
+		alg, ok := mldsaAlgorithmForKey(key)
+		if !ok {
+			return nil, fmt.Errorf(`invalid key %T`, key)
+		}
+		return []jwa.SignatureAlgorithm{alg}, nil
 	case rsa.PublicKey, *rsa.PublicKey, rsa.PrivateKey, *rsa.PrivateKey:
 		kty = jwa.RSA
 	case ecdsa.PublicKey, *ecdsa.PublicKey, ecdsa.PrivateKey, *ecdsa.PrivateKey:
 		kty = jwa.EC
-	case ed25519.PublicKey, ed25519.PrivateKey, x25519.PublicKey, x25519.PrivateKey:
+	case ed25519.PublicKey, ed25519.PrivateKey, x25519.PublicKey, x25519.PrivateKey,
+		ed448.PublicKey, ed448.PrivateKey, x448.PublicKey, x448.PrivateKey:
 		kty = jwa.OKP
 	case []byte:
 		kty = jwa.OctetSeq
@@ -711,5 +964,12 @@ func AlgorithmsForKey(key interface{}) ([]jwa.SignatureAlgorithm, error) {
 	if !ok {
 		return nil, fmt.Errorf(`invalid key type %q`, kty)
 	}
+
+	if crv, ok := curveForKey(key); ok {
+		if list := curveToAlgorithms[crv]; len(list) > 0 {
+			// do not modify the shared slice in keyTypeToAlgorithms
+			algs = append(append([]jwa.SignatureAlgorithm(nil), algs...), list...)
+		}
+	}
 	return algs, nil
 }
@@ -7,7 +7,10 @@ import (
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/asn1"
 	"fmt"
@@ -22,14 +25,17 @@ import (
 	"time"
 
 	"github.com/lestrrat-go/httprc"
+	"github.com/sjwl/jwx/v2/ed448"
 	"github.com/sjwl/jwx/v2/internal/base64"
 	"github.com/sjwl/jwx/v2/internal/json"
 	"github.com/sjwl/jwx/v2/internal/jwxtest"
+	"github.com/sjwl/jwx/v2/internal/mldsa"
 	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/sjwl/jwx/v2/jwk"
 	"github.com/sjwl/jwx/v2/jws"
 	"github.com/sjwl/jwx/v2/jwt"
 	"github.com/sjwl/jwx/v2/x25519"
+	"github.com/sjwl/jwx/v2/x448"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -370,6 +376,19 @@ func TestRoundtrip(t *testing.T) {
 			})
 		}
 	})
+	t.Run("EdDSA (Ed448)", func(t *testing.T) {
+		t.Parallel()
+		key, err := jwxtest.GenerateEd448Key()
+		require.NoError(t, err, `jwxtest.GenerateEd448Key should succeed`)
+		pubkey := key.Public()
+		jwkKey, err := jwk.FromRaw(pubkey)
+		require.NoError(t, err, `jwk.FromRaw should succeed`)
+		keys := map[string]interface{}{
+			"Verify(ed448.Public())": pubkey,
+			"Verify(jwk.Key)":        jwkKey,
+		}
+		testRoundtrip(t, payload, jwa.EdDSA, key, keys)
+	})
 }
 
 func TestSignMulti2(t *testing.T) {
@@ -805,6 +824,36 @@ func TestEncode(t *testing.T) {
 			return
 		}
 	})
+	t.Run("EdDSA Ed448", func(t *testing.T) {
+		t.Parallel()
+		// Test vector "1 octet" taken from RFC 8032, Section 7.4
+		const jwksrc = `{
+    "kty":"OKP",
+    "crv":"Ed448",
+    "d":"xOqwXTVwB8Yy89u0hImSTVUrCP4MNToNSh8ArNosRjr76mfF6NKHfF47w5emWZSe-AIelU4KEidO",
+    "x":"Q7oo9DDN_0Vq5TFUX37NCsg0pV2TWMA3K_oMbGeYwIZq6gHrAHQoArhDjqTLghacI1FgYntMOpSA"
+  }`
+		const expected = `Jrj5Fye9Yol68V5B60PDd--5xhDUjyM1ywvQCHgQ9DUlQbFDxLmBt-GPYt6MzfYz_BvwN6t813mAXg28wKrhy87hr7LgJ982vATc7L8VQzbBnwr34KZHKQXnmfGVPSoP8zSKshqkra_R0jREHPgHwDoA`
+		message := []byte{0x03}
+
+		privkey, err := jwk.ParseKey([]byte(jwksrc))
+		require.NoError(t, err, `jwk.ParseKey should succeed`)
+		pubkey, err := jwk.PublicKeyOf(privkey)
+		require.NoError(t, err, `jwk.PublicKeyOf should succeed`)
+
+		signer, err := jws.NewSigner(jwa.EdDSA)
+		require.NoError(t, err, `jws.NewSigner should succeed`)
+		signature, err := signer.Sign(message, privkey)
+		require.NoError(t, err, `signer.Sign should succeed`)
+		require.Equal(t, expected, string(base64.Encode(signature)), `signature should match`)
+
+		verifier, err := jws.NewVerifier(jwa.EdDSA)
+		require.NoError(t, err, `jws.NewVerifier should succeed`)
+		require.NoError(t, verifier.Verify(message, signature, pubkey), `verifier.Verify should succeed`)
+
+		signature[0] ^= 0x01
+		require.Error(t, verifier.Verify(message, signature, pubkey), `verifier.Verify should fail for a modified signature`)
+	})
 	t.Run("UnsecuredCompact", func(t *testing.T) {
 		t.Parallel()
 		s := `eyJhbGciOiJub25lIn0.eyJpc3MiOiJqb2UiLA0KICJleHAiOjEzMDA4MTkzODAsDQogImh0dHA6Ly9leGFtcGxlLmNvbS9pc19yb290Ijp0cnVlfQ.`
@@ -1516,6 +1565,16 @@ func TestGH485(t *testing.T) {
     "signatures": [{"protected": %q, "signature": %q}]
 }`, payload, protected, signature)
 
+	// "exp" is listed in "crit", so it must be registered before
+	// the message can be verified
+	_, err := jws.Verify([]byte(signed), jws.WithKey(jwa.HS256, []byte("secret")))
+	if !assert.ErrorIs(t, err, jws.ErrUnknownCriticalHeader(), `jws.Verify should fail`) {
+		return
+	}
+
+	jws.RegisterCriticalHeader("exp")
+	defer jws.UnregisterCriticalHeader("exp")
+
 	verified, err := jws.Verify([]byte(signed), jws.WithKey(jwa.HS256, []byte("secret")))
 	if !assert.NoError(t, err, `jws.Verify should succeed`) {
 		return
@@ -1792,6 +1851,15 @@ func TestAlgorithmsForKey(t *testing.T) {
 		return
 	}
 
+	p256key, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+	if !assert.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`) {
+		return
+	}
+	p384key, err := jwxtest.GenerateEcdsaKey(jwa.P384)
+	if !assert.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`) {
+		return
+	}
+
 	testcases := []struct {
 		Name     string
 		Key      interface{}
@@ -1830,7 +1898,7 @@ func TestAlgorithmsForKey(t *testing.T) {
 		{
 			Name:     "jwk.ECDSAPublicKey",
 			Key:      ecdsapubkey,
-			Expected: []jwa.SignatureAlgorithm{jwa.ES256, jwa.ES384, jwa.ES512},
+			Expected: []jwa.SignatureAlgorithm{jwa.ES256, jwa.ES384, jwa.ES512, jwa.ESP512},
 		},
 		{
 			Name:     "rsa.PrivateKey",
@@ -1860,18 +1928,38 @@ func TestAlgorithmsForKey(t *testing.T) {
 		{
 			Name:     "jwk.ECDSAPrivateKey",
 			Key:      ecdsaprivkey,
-			Expected: []jwa.SignatureAlgorithm{jwa.ES256, jwa.ES384, jwa.ES512},
+			Expected: []jwa.SignatureAlgorithm{jwa.ES256, jwa.ES384, jwa.ES512, jwa.ESP512},
+		},
+		{
+			Name:     "*ecdsa.PrivateKey (P-256)",
+			Key:      p256key,
+			Expected: []jwa.SignatureAlgorithm{jwa.ES256, jwa.ES384, jwa.ES512, jwa.ESP256},
+		},
+		{
+			Name:     "ecdsa.PublicKey (P-384)",
+			Key:      p384key.PublicKey,
+			Expected: []jwa.SignatureAlgorithm{jwa.ES256, jwa.ES384, jwa.ES512, jwa.ESP384},
 		},
 		{
 			Name:     "ed25519.PublicKey",
 			Key:      ed25519.PublicKey(nil),
-			Expected: []jwa.SignatureAlgorithm{jwa.EdDSA},
+			Expected: []jwa.SignatureAlgorithm{jwa.EdDSA, jwa.EdDSAEd25519},
 		},
 		{
 			Name:     "x25519.PublicKey",
 			Key:      x25519.PublicKey(nil),
 			Expected: []jwa.SignatureAlgorithm{jwa.EdDSA},
 		},
+		{
+			Name:     "ed448.PublicKey",
+			Key:      ed448.PublicKey(nil),
+			Expected: []jwa.SignatureAlgorithm{jwa.EdDSA, jwa.EdDSAEd448},
+		},
+		{
+			Name:     "x448.PublicKey",
+			Key:      x448.PublicKey(nil),
+			Expected: []jwa.SignatureAlgorithm{jwa.EdDSA},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -1902,6 +1990,84 @@ func TestAlgorithmsForKey(t *testing.T) {
 	}
 }
 
+// The signers must use the hash functions described by the algorithm
+// metadata, as other users of the metadata rely on it
+func TestSignatureAlgorithmMetadata(t *testing.T) {
+	rsakey, err := jwxtest.GenerateRsaKey()
+	require.NoError(t, err, `jwxtest.GenerateRsaKey should succeed`)
+	hmackey := make([]byte, 64)
+	_, err = rand.Read(hmackey)
+	require.NoError(t, err, `rand.Read should succeed`)
+
+	algs := []jwa.SignatureAlgorithm{
+		jwa.HS256, jwa.HS384, jwa.HS512,
+		jwa.RS256, jwa.RS384, jwa.RS512,
+		jwa.PS256, jwa.PS384, jwa.PS512,
+		jwa.ES256, jwa.ES384, jwa.ES512,
+		jwa.ESP256, jwa.ESP384, jwa.ESP512,
+	}
+	for _, alg := range algs {
+		alg := alg
+		t.Run(alg.String(), func(t *testing.T) {
+			md, ok := alg.Metadata()
+			require.True(t, ok, `metadata should exist`)
+			require.NotZero(t, md.Hash, `metadata should specify a hash function`)
+
+			var key interface{}
+			switch {
+			case md.HasKeyType(jwa.OctetSeq):
+				key = hmackey
+			case md.HasKeyType(jwa.RSA):
+				key = rsakey
+			default:
+				crv := md.Curve
+				if crv == "" {
+					crv = jwa.P256
+					switch md.KeySize {
+					case 384:
+						crv = jwa.P384
+					case 521:
+						crv = jwa.P521
+					}
+				}
+				eckey, err := jwxtest.GenerateEcdsaKey(crv)
+				require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+				key = eckey
+			}
+
+			signed, err := jws.Sign([]byte(examplePayload), jws.WithKey(alg, key))
+			require.NoError(t, err, `jws.Sign should succeed`)
+			parts := bytes.Split(signed, []byte{'.'})
+			require.Len(t, parts, 3)
+			sig, err := base64.Decode(parts[2])
+			require.NoError(t, err, `base64.Decode should succeed`)
+
+			input := signed[:bytes.LastIndexByte(signed, '.')]
+			h := md.Hash.New()
+			h.Write(input)
+			digest := h.Sum(nil)
+
+			switch key := key.(type) {
+			case []byte:
+				mac := hmac.New(md.Hash.New, key)
+				mac.Write(input)
+				require.True(t, hmac.Equal(mac.Sum(nil), sig), `HMAC should use the hash from the metadata`)
+			case *rsa.PrivateKey:
+				if strings.HasPrefix(alg.String(), `PS`) {
+					require.NoError(t, rsa.VerifyPSS(&key.PublicKey, md.Hash, digest, sig, nil), `RSASSA-PSS should use the hash from the metadata`)
+				} else {
+					require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, md.Hash, digest, sig), `RSASSA-PKCS1-v1_5 should use the hash from the metadata`)
+				}
+			case *ecdsa.PrivateKey:
+				n := len(sig) / 2
+				r := new(big.Int).SetBytes(sig[:n])
+				s := new(big.Int).SetBytes(sig[n:])
+				require.True(t, ecdsa.Verify(&key.PublicKey, digest, r, s), `ECDSA should use the hash from the metadata`)
+			}
+		})
+	}
+}
+
 func TestGH681(t *testing.T) {
 	privkey, err := jwxtest.GenerateRsaKey()
 	if !assert.NoError(t, err, "failed to create private key") {
@@ -1951,3 +2117,866 @@ func TestGH840(t *testing.T) {
 	_, err = jwt.Parse(signed, jwt.WithKey(jwa.ES256, pubkey))
 	require.Error(t, err, `jwt.Parse should FAIL`) // pubkey's X/Y is not on the curve
 }
+
+func TestCritical(t *testing.T) {
+	key := []byte("abracadabra")
+	signWithCrit := func(t *testing.T, crit []string, extra map[string]interface{}) []byte {
+		t.Helper()
+		hdrs := jws.NewHeaders()
+		require.NoError(t, hdrs.Set(jws.CriticalKey, crit), `hdrs.Set should succeed`)
+		for k, v := range extra {
+			require.NoError(t, hdrs.Set(k, v), `hdrs.Set should succeed`)
+		}
+		signed, err := jws.Sign([]byte("Lorem ipsum"), jws.WithKey(jwa.HS256, key, jws.WithProtectedHeaders(hdrs)))
+		require.NoError(t, err, `jws.Sign should succeed`)
+		return signed
+	}
+
+	t.Run("b64 is understood by default", func(t *testing.T) {
+		signed := signWithCrit(t, []string{"b64"}, map[string]interface{}{"b64": true})
+		_, err := jws.Verify(signed, jws.WithKey(jwa.HS256, key))
+		require.NoError(t, err, `jws.Verify should succeed`)
+	})
+	t.Run("Unknown header", func(t *testing.T) {
+		signed := signWithCrit(t, []string{"x-critical-unknown"}, map[string]interface{}{"x-critical-unknown": "foo"})
+		_, err := jws.Verify(signed, jws.WithKey(jwa.HS256, key))
+		require.ErrorIs(t, err, jws.ErrUnknownCriticalHeader(), `jws.Verify should fail`)
+
+		_, err = jwt.Parse(signed, jwt.WithKey(jwa.HS256, key), jwt.WithValidate(false))
+		require.ErrorIs(t, err, jws.ErrUnknownCriticalHeader(), `jwt.Parse should fail`)
+	})
+	t.Run("Registered header", func(t *testing.T) {
+		jws.RegisterCriticalHeader("x-critical-registered")
+		defer jws.UnregisterCriticalHeader("x-critical-registered")
+
+		signed := signWithCrit(t, []string{"x-critical-registered"}, map[string]interface{}{"x-critical-registered": "foo"})
+		_, err := jws.Verify(signed, jws.WithKey(jwa.HS256, key))
+		require.NoError(t, err, `jws.Verify should succeed`)
+	})
+	t.Run("Registered header missing from protected headers", func(t *testing.T) {
+		jws.RegisterCriticalHeader("x-critical-missing")
+		defer jws.UnregisterCriticalHeader("x-critical-missing")
+
+		signed := signWithCrit(t, []string{"x-critical-missing"}, nil)
+		_, err := jws.Verify(signed, jws.WithKey(jwa.HS256, key))
+		require.ErrorIs(t, err, jws.ErrMissingCriticalHeader(), `jws.Verify should fail`)
+	})
+	t.Run("Empty list", func(t *testing.T) {
+		signed := signWithCrit(t, []string{}, nil)
+		_, err := jws.Verify(signed, jws.WithKey(jwa.HS256, key))
+		require.Error(t, err, `jws.Verify should fail`)
+	})
+}
+
+// repeatReader infinitely repeats the same content
+type repeatReader struct {
+	content string
+	off     int
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.content[r.off]
+		r.off = (r.off + 1) % len(r.content)
+	}
+	return len(p), nil
+}
+
+func TestDetachedPayloadReader(t *testing.T) {
+	rsakey, err := jwxtest.GenerateRsaKey()
+	require.NoError(t, err, `jwxtest.GenerateRsaKey should succeed`)
+	eckey, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+	require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+	hmackey := jwxtest.GenerateSymmetricKey()
+
+	// 1MB worth of payload, which is never held in memory as a whole
+	// by the signing/verification process
+	const payloadSize = 1024 * 1024
+	newPayload := func() io.Reader {
+		return io.LimitReader(&repeatReader{content: "Lorem ipsum dolor sit amet. "}, payloadSize)
+	}
+
+	testcases := []struct {
+		alg     jwa.SignatureAlgorithm
+		signKey interface{}
+		verKey  interface{}
+	}{
+		{alg: jwa.HS256, signKey: hmackey, verKey: hmackey},
+		{alg: jwa.RS256, signKey: rsakey, verKey: &rsakey.PublicKey},
+		{alg: jwa.PS384, signKey: rsakey, verKey: &rsakey.PublicKey},
+		{alg: jwa.ES256, signKey: eckey, verKey: &eckey.PublicKey},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		for _, b64 := range []bool{true, false} {
+			b64 := b64
+			for _, useJSON := range []bool{true, false} {
+				useJSON := useJSON
+				t.Run(fmt.Sprintf("%s (b64=%t, json=%t)", tc.alg, b64, useJSON), func(t *testing.T) {
+					t.Parallel()
+					hdrs := jws.NewHeaders()
+					if !b64 {
+						require.NoError(t, hdrs.Set("b64", false), `hdrs.Set should succeed`)
+						require.NoError(t, hdrs.Set(jws.CriticalKey, []string{"b64"}), `hdrs.Set should succeed`)
+					}
+
+					signOptions := []jws.SignOption{
+						jws.WithKey(tc.alg, tc.signKey, jws.WithProtectedHeaders(hdrs)),
+						jws.WithDetachedPayloadReader(newPayload()),
+					}
+					if useJSON {
+						signOptions = append(signOptions, jws.WithJSON())
+					}
+					signed, err := jws.Sign(nil, signOptions...)
+					require.NoError(t, err, `jws.Sign should succeed`)
+
+					if useJSON {
+						require.NotContains(t, string(signed), `"payload"`, `JSON serialization should not contain payload`)
+					} else {
+						require.Contains(t, string(signed), `..`, `compact serialization should not contain payload`)
+					}
+
+					var keyUsed interface{}
+					verified, err := jws.Verify(signed, jws.WithKey(tc.alg, tc.verKey), jws.WithDetachedPayloadReader(newPayload()), jws.WithKeyUsed(&keyUsed))
+					require.NoError(t, err, `jws.Verify should succeed`)
+					require.Nil(t, verified, `jws.Verify should return nil payload`)
+					require.Equal(t, tc.verKey, keyUsed, `key used should match`)
+
+					// The signature must be compatible with the in-memory version
+					payload, err := io.ReadAll(newPayload())
+					require.NoError(t, err, `io.ReadAll should succeed`)
+					verified, err = jws.Verify(signed, jws.WithKey(tc.alg, tc.verKey), jws.WithDetachedPayload(payload))
+					require.NoError(t, err, `jws.Verify should succeed`)
+					require.Equal(t, payload, verified, `payload should match`)
+
+					// Tampered payload must fail
+					tampered := io.MultiReader(newPayload(), strings.NewReader("x"))
+					_, err = jws.Verify(signed, jws.WithKey(tc.alg, tc.verKey), jws.WithDetachedPayloadReader(tampered))
+					require.Error(t, err, `jws.Verify should fail`)
+				})
+			}
+		}
+	}
+
+	t.Run("Multiple signatures", func(t *testing.T) {
+		t.Parallel()
+		signed, err := jws.Sign(nil,
+			jws.WithJSON(),
+			jws.WithKey(jwa.RS256, rsakey),
+			jws.WithKey(jwa.ES256, eckey),
+			jws.WithDetachedPayloadReader(newPayload()),
+		)
+		require.NoError(t, err, `jws.Sign should succeed`)
+
+		for _, key := range []jws.SignVerifyOption{jws.WithKey(jwa.RS256, &rsakey.PublicKey), jws.WithKey(jwa.ES256, &eckey.PublicKey)} {
+			_, err := jws.Verify(signed, key, jws.WithDetachedPayloadReader(newPayload()))
+			require.NoError(t, err, `jws.Verify should succeed`)
+		}
+	})
+	t.Run("EdDSA is not supported", func(t *testing.T) {
+		t.Parallel()
+		edkey, err := jwxtest.GenerateEd25519Key()
+		require.NoError(t, err, `jwxtest.GenerateEd25519Key should succeed`)
+		_, err = jws.Sign(nil, jws.WithKey(jwa.EdDSA, edkey), jws.WithDetachedPayloadReader(newPayload()))
+		require.Error(t, err, `jws.Sign should fail`)
+	})
+	t.Run("Keys for EdDSA are skipped", func(t *testing.T) {
+		t.Parallel()
+		edkey, err := jwxtest.GenerateEd25519Key()
+		require.NoError(t, err, `jwxtest.GenerateEd25519Key should succeed`)
+		payload, err := io.ReadAll(newPayload())
+		require.NoError(t, err, `io.ReadAll should succeed`)
+		signed, err := jws.Sign(nil, jws.WithJSON(), jws.WithKey(jwa.HS256, hmackey), jws.WithDetachedPayloadReader(newPayload()))
+		require.NoError(t, err, `jws.Sign should succeed`)
+		signed, err = jws.AppendSignature(signed, jws.WithKey(jwa.EdDSA, edkey), jws.WithDetachedPayload(payload))
+		require.NoError(t, err, `jws.AppendSignature should succeed`)
+
+		var result jws.VerifyResult
+		_, err = jws.Verify(signed,
+			jws.WithKey(jwa.EdDSA, edkey.Public()),
+			jws.WithKey(jwa.HS256, hmackey),
+			jws.WithDetachedPayloadReader(newPayload()),
+			jws.WithVerifyResult(&result),
+		)
+		require.NoError(t, err, `jws.Verify should succeed using the HS256 key`)
+		require.Equal(t, 1, result.VerifiedCount(), `one signature should be verified`)
+
+		_, err = jws.Verify(signed, jws.WithKey(jwa.EdDSA, edkey.Public()), jws.WithDetachedPayloadReader(newPayload()))
+		require.Error(t, err, `jws.Verify should fail using only the EdDSA key`)
+		require.Contains(t, err.Error(), `does not support streaming payloads`, `error should explain why the key was skipped`)
+	})
+	t.Run("Payload must be nil", func(t *testing.T) {
+		t.Parallel()
+		_, err := jws.Sign([]byte("foo"), jws.WithKey(jwa.HS256, hmackey), jws.WithDetachedPayloadReader(newPayload()))
+		require.Error(t, err, `jws.Sign should fail`)
+	})
+}
+
+type customHMAC struct {
+	alg jwa.SignatureAlgorithm
+}
+
+func (s *customHMAC) Algorithm() jwa.SignatureAlgorithm {
+	return s.alg
+}
+
+func (s *customHMAC) Sign(payload []byte, key interface{}) ([]byte, error) {
+	if jwkKey, ok := key.(jwk.Key); ok {
+		var raw interface{}
+		if err := jwkKey.Raw(&raw); err != nil {
+			return nil, err
+		}
+		key = raw
+	}
+	sharedkey, ok := key.([]byte)
+	if !ok {
+		return nil, fmt.Errorf(`[]byte key required`)
+	}
+	h := hmac.New(sha256.New, sharedkey)
+	h.Write(payload)
+	return h.Sum(nil), nil
+}
+
+func (s *customHMAC) Verify(payload, signature []byte, key interface{}) error {
+	expected, err := s.Sign(payload, key)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(expected, signature) {
+		return fmt.Errorf(`invalid signature`)
+	}
+	return nil
+}
+
+func TestCustomAlgorithm(t *testing.T) {
+	const alg = jwa.SignatureAlgorithm(`X-HS256`)
+	jwa.RegisterSignatureAlgorithm(alg)
+	defer jwa.UnregisterSignatureAlgorithm(alg)
+
+	jws.RegisterSigner(alg, jws.SignerFactoryFn(func() (jws.Signer, error) {
+		return &customHMAC{alg: alg}, nil
+	}))
+	jws.RegisterVerifier(alg, jws.VerifierFactoryFn(func() (jws.Verifier, error) {
+		return &customHMAC{alg: alg}, nil
+	}))
+
+	key := []byte(`abracadabra`)
+	signed, err := jws.Sign([]byte(examplePayload), jws.WithKey(alg, key))
+	require.NoError(t, err, `jws.Sign should succeed`)
+
+	msg, err := jws.Parse(signed)
+	require.NoError(t, err, `jws.Parse should succeed`)
+	require.Equal(t, alg, msg.Signatures()[0].ProtectedHeaders().Algorithm())
+
+	verified, err := jws.Verify(signed, jws.WithKey(alg, key))
+	require.NoError(t, err, `jws.Verify should succeed`)
+	require.Equal(t, examplePayload, string(verified))
+
+	jwkKey, err := jwk.FromRaw(key)
+	require.NoError(t, err, `jwk.FromRaw should succeed`)
+	require.NoError(t, jwkKey.Set(jwk.AlgorithmKey, alg.String()), `setting "alg" should succeed`)
+	require.Equal(t, alg, jwkKey.Algorithm(), `"alg" should be recognized as a signature algorithm`)
+
+	set := jwk.NewSet()
+	require.NoError(t, set.AddKey(jwkKey))
+	_, err = jws.Verify(signed, jws.WithKeySet(set, jws.WithRequireKid(false)))
+	require.NoError(t, err, `jws.Verify with a key set should succeed`)
+}
+
+func TestAlgorithmPolicy(t *testing.T) {
+	key := []byte(`abracadabra`)
+	signed, err := jws.Sign([]byte(examplePayload), jws.WithKey(jwa.HS256, key))
+	require.NoError(t, err, `jws.Sign should succeed`)
+
+	t.Run("WithAllowedAlgorithms", func(t *testing.T) {
+		_, err := jws.Verify(signed, jws.WithKey(jwa.HS256, key), jws.WithAllowedAlgorithms(jwa.ES256, jwa.RS256))
+		require.Error(t, err, `jws.Verify should fail`)
+		require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed())
+
+		_, err = jws.Verify(signed, jws.WithKey(jwa.HS256, key), jws.WithAllowedAlgorithms(jwa.HS256))
+		require.NoError(t, err, `jws.Verify should succeed`)
+	})
+	t.Run("key algorithm is checked", func(t *testing.T) {
+		// The "alg" header is allowed, but the key is not
+		_, err := jws.Verify(signed, jws.WithKey(jwa.HS512, key), jws.WithAllowedAlgorithms(jwa.HS256))
+		require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed())
+	})
+	t.Run("global policy", func(t *testing.T) {
+		jws.Settings(jws.WithAlgorithmPolicy(jwa.NewAlgorithmPolicy().Deny(jwa.HS256)))
+		defer jws.Settings(jws.WithAlgorithmPolicy(nil))
+
+		_, err := jws.Verify(signed, jws.WithKey(jwa.HS256, key))
+		require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed())
+
+		detached, err := jws.Sign(nil, jws.WithKey(jwa.HS256, key), jws.WithDetachedPayload([]byte(examplePayload)))
+		require.NoError(t, err, `jws.Sign should succeed`)
+		_, err = jws.Verify(detached, jws.WithKey(jwa.HS256, key), jws.WithDetachedPayloadReader(strings.NewReader(examplePayload)))
+		require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed(), `streaming verification should check the policy as well`)
+
+		// WithAllowedAlgorithms and WithAdditionalAlgorithmPolicy do not override the global policy
+		_, err = jws.Verify(signed, jws.WithKey(jwa.HS256, key), jws.WithAllowedAlgorithms(jwa.HS256))
+		require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed())
+		_, err = jws.Verify(signed, jws.WithKey(jwa.HS256, key), jws.WithAdditionalAlgorithmPolicy(jwa.NewAlgorithmPolicy()))
+		require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed())
+
+		// ...but WithAlgorithmPolicy does
+		_, err = jws.Verify(signed, jws.WithKey(jwa.HS256, key), jws.WithAlgorithmPolicy(jwa.NewAlgorithmPolicy()))
+		require.NoError(t, err, `jws.Verify should succeed`)
+	})
+}
+
+func TestKeyStrengthPolicy(t *testing.T) {
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err, `rsa.GenerateKey should succeed`)
+
+	t.Run("RSA", func(t *testing.T) {
+		_, err := jws.Sign([]byte(examplePayload), jws.WithKey(jwa.RS256, weak), jws.WithKeyStrengthPolicy(jwa.StrictKeyStrengthPolicy()))
+		require.ErrorIs(t, err, jwa.ErrKeyTooSmall(), `jws.Sign should reject 1024 bit RSA keys`)
+
+		signed, err := jws.Sign([]byte(examplePayload), jws.WithKey(jwa.RS256, weak))
+		require.NoError(t, err, `jws.Sign should succeed without a policy`)
+
+		pubkey, err := jwk.FromRaw(weak.PublicKey)
+		require.NoError(t, err, `jwk.FromRaw should succeed`)
+		_, err = jws.Verify(signed, jws.WithKey(jwa.RS256, pubkey), jws.WithKeyStrengthPolicy(jwa.StrictKeyStrengthPolicy()))
+		require.ErrorIs(t, err, jwa.ErrKeyTooSmall(), `jws.Verify should reject 1024 bit RSA keys`)
+	})
+	t.Run("RSA with odd sized modulus", func(t *testing.T) {
+		// A 2047 bit modulus is stored in 256 bytes, just like a 2048 bit one
+		key, err := rsa.GenerateKey(rand.Reader, 2047)
+		require.NoError(t, err, `rsa.GenerateKey should succeed`)
+		require.Equal(t, 256, key.Size(), `modulus should be 256 bytes long`)
+
+		_, err = jws.Sign([]byte(examplePayload), jws.WithKey(jwa.RS256, key), jws.WithKeyStrengthPolicy(jwa.StrictKeyStrengthPolicy()))
+		require.ErrorIs(t, err, jwa.ErrKeyTooSmall(), `jws.Sign should reject 2047 bit RSA keys`)
+	})
+	t.Run("HMAC", func(t *testing.T) {
+		key := []byte(`abracadabra`)
+		_, err := jws.Sign([]byte(examplePayload), jws.WithKey(jwa.HS256, key), jws.WithKeyStrengthPolicy(jwa.StrictKeyStrengthPolicy()))
+		require.ErrorIs(t, err, jwa.ErrKeyTooSmall(), `jws.Sign should reject short HMAC keys`)
+
+		longKey := make([]byte, 32)
+		_, err = rand.Read(longKey)
+		require.NoError(t, err, `rand.Read should succeed`)
+		signed, err := jws.Sign([]byte(examplePayload), jws.WithKey(jwa.HS256, longKey), jws.WithKeyStrengthPolicy(jwa.StrictKeyStrengthPolicy()))
+		require.NoError(t, err, `jws.Sign should succeed`)
+		_, err = jws.Verify(signed, jws.WithKey(jwa.HS256, longKey), jws.WithKeyStrengthPolicy(jwa.StrictKeyStrengthPolicy()))
+		require.NoError(t, err, `jws.Verify should succeed`)
+	})
+	t.Run("curves", func(t *testing.T) {
+		key, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+
+		jws.Settings(jws.WithKeyStrengthPolicy(jwa.NewKeyStrengthPolicy().AllowCurves(jwa.P384)))
+		defer jws.Settings(jws.WithKeyStrengthPolicy(nil))
+
+		_, err = jws.Sign([]byte(examplePayload), jws.WithKey(jwa.ES256, key))
+		require.ErrorIs(t, err, jwa.ErrCurveNotAllowed(), `jws.Sign should reject P-256 keys`)
+
+		signed, err := jws.Sign([]byte(examplePayload), jws.WithKey(jwa.ES256, key), jws.WithKeyStrengthPolicy(nil))
+		require.NoError(t, err, `jws.Sign should succeed when the global policy is overridden`)
+		_, err = jws.Verify(signed, jws.WithKey(jwa.ES256, key.PublicKey))
+		require.ErrorIs(t, err, jwa.ErrCurveNotAllowed(), `jws.Verify should reject P-256 keys`)
+	})
+}
+
+func TestVerifyResult(t *testing.T) {
+	key1, err := jwk.FromRaw([]byte(`abracadabra`))
+	require.NoError(t, err, `jwk.FromRaw should succeed`)
+	require.NoError(t, key1.Set(jwk.KeyIDKey, `key1`), `key1.Set should succeed`)
+	key2, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+	require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+	key3 := []byte(`opensesame`)
+
+	signed, err := jws.Sign([]byte(examplePayload), jws.WithJSON(),
+		jws.WithKey(jwa.HS256, key1),
+		jws.WithKey(jwa.ES256, key2),
+		jws.WithKey(jwa.HS512, key3),
+	)
+	require.NoError(t, err, `jws.Sign should succeed`)
+
+	detached, err := jws.Sign(nil, jws.WithJSON(),
+		jws.WithKey(jwa.HS256, key1),
+		jws.WithKey(jwa.ES256, key2),
+		jws.WithKey(jwa.HS512, key3),
+		jws.WithDetachedPayloadReader(strings.NewReader(examplePayload)),
+	)
+	require.NoError(t, err, `jws.Sign should succeed`)
+
+	// Only the first two signatures can be verified
+	verify := func(t *testing.T, streaming bool, options ...jws.VerifyOption) ([]byte, error) {
+		t.Helper()
+		options = append(options, jws.WithKey(jwa.HS256, key1), jws.WithKey(jwa.ES256, key2.PublicKey))
+		if streaming {
+			options = append(options, jws.WithDetachedPayloadReader(strings.NewReader(examplePayload)))
+			return jws.Verify(detached, options...)
+		}
+		return jws.Verify(signed, options...)
+	}
+
+	for _, streaming := range []bool{false, true} {
+		streaming := streaming
+		t.Run(fmt.Sprintf("streaming=%t", streaming), func(t *testing.T) {
+			t.Run("WithVerifyResult", func(t *testing.T) {
+				var result jws.VerifyResult
+				_, err := verify(t, streaming, jws.WithVerifyResult(&result))
+				require.NoError(t, err, `jws.Verify should succeed`)
+
+				sigs := result.Signatures()
+				require.Len(t, sigs, 3, `there should be a result for each signature`)
+				require.Equal(t, 2, result.VerifiedCount())
+
+				require.True(t, sigs[0].Verified(), `signature #1 should be verified`)
+				require.Equal(t, jwa.HS256, sigs[0].Algorithm())
+				require.Equal(t, `key1`, sigs[0].KeyID())
+				require.Equal(t, key1, sigs[0].Key())
+
+				require.True(t, sigs[1].Verified(), `signature #2 should be verified`)
+				require.Equal(t, jwa.ES256, sigs[1].Algorithm())
+				require.NoError(t, sigs[1].Err())
+
+				require.False(t, sigs[2].Verified(), `signature #3 should not be verified`)
+				require.Error(t, sigs[2].Err())
+				require.Equal(t, jwa.HS512, sigs[2].Signature().ProtectedHeaders().Algorithm())
+			})
+			t.Run("WithRequiredSignatures", func(t *testing.T) {
+				_, err := verify(t, streaming, jws.WithRequiredSignatures(2))
+				require.NoError(t, err, `jws.Verify should succeed`)
+
+				var result jws.VerifyResult
+				_, err = verify(t, streaming, jws.WithRequiredSignatures(3), jws.WithVerifyResult(&result))
+				require.Error(t, err, `jws.Verify should fail`)
+				require.Equal(t, 2, result.VerifiedCount(), `result should be populated even on failure`)
+
+				_, err = verify(t, streaming, jws.WithRequiredSignatures(0))
+				require.Error(t, err, `jws.Verify should fail`)
+			})
+			t.Run("WithAllSignaturesRequired", func(t *testing.T) {
+				_, err := verify(t, streaming, jws.WithAllSignaturesRequired())
+				require.Error(t, err, `jws.Verify should fail`)
+
+				_, err = verify(t, streaming, jws.WithAllSignaturesRequired(), jws.WithKey(jwa.HS512, key3))
+				require.NoError(t, err, `jws.Verify should succeed`)
+			})
+			t.Run("verification errors are recorded", func(t *testing.T) {
+				options := []jws.VerifyOption{jws.WithKey(jwa.HS512, []byte(`wrong key`))}
+				src := signed
+				if streaming {
+					src = detached
+					options = append(options, jws.WithDetachedPayloadReader(strings.NewReader(examplePayload)))
+				}
+				var result jws.VerifyResult
+				options = append(options, jws.WithVerifyResult(&result))
+				_, err := jws.Verify(src, options...)
+				require.Error(t, err, `jws.Verify should fail`)
+
+				sigs := result.Signatures()
+				require.False(t, sigs[2].Verified(), `signature #3 should not be verified`)
+				require.Contains(t, sigs[2].Err().Error(), `failed to match hmac signature`, `the error from the verifier should be recorded`)
+			})
+			t.Run("signatures by the same key count once", func(t *testing.T) {
+				// copy the first signature (by key1) three times
+				src := signed
+				if streaming {
+					src = detached
+				}
+				var m map[string]interface{}
+				require.NoError(t, json.Unmarshal(src, &m), `json.Unmarshal should succeed`)
+				//nolint:forcetypeassert
+				sig := m["signatures"].([]interface{})[0]
+				m["signatures"] = []interface{}{sig, sig, sig}
+				copied, err := json.Marshal(m)
+				require.NoError(t, err, `json.Marshal should succeed`)
+
+				verifyCopied := func(options ...jws.VerifyOption) error {
+					options = append(options, jws.WithKey(jwa.HS256, key1))
+					if streaming {
+						options = append(options, jws.WithDetachedPayloadReader(strings.NewReader(examplePayload)))
+					}
+					_, err := jws.Verify(copied, options...)
+					return err
+				}
+
+				var result jws.VerifyResult
+				require.Error(t, verifyCopied(jws.WithRequiredSignatures(3), jws.WithVerifyResult(&result)), `jws.Verify should fail`)
+				require.Equal(t, 3, result.VerifiedCount(), `all copies should be verified`)
+				require.Error(t, verifyCopied(jws.WithRequiredSignatures(2)), `jws.Verify should fail`)
+				require.NoError(t, verifyCopied(jws.WithRequiredSignatures(1)), `jws.Verify should succeed`)
+				require.NoError(t, verifyCopied(jws.WithAllSignaturesRequired()), `jws.Verify should succeed`)
+
+				// the same key in a different representation is still the same key
+				raw, err := jwk.PublicRawKeyOf(key1)
+				require.NoError(t, err, `jwk.PublicRawKeyOf should succeed`)
+				require.Error(t, verifyCopied(jws.WithRequiredSignatures(2), jws.WithKey(jwa.HS256, raw)), `jws.Verify should fail`)
+			})
+		})
+	}
+
+	t.Run("distinct signatures by the same key count once", func(t *testing.T) {
+		signed, err := jws.Sign([]byte(examplePayload), jws.WithJSON(),
+			jws.WithKey(jwa.ES256, key2),
+			jws.WithKey(jwa.ES256, key2),
+		)
+		require.NoError(t, err, `jws.Sign should succeed`)
+
+		pubkey, err := jwk.PublicKeyOf(key2)
+		require.NoError(t, err, `jwk.PublicKeyOf should succeed`)
+		_, err = jws.Verify(signed, jws.WithRequiredSignatures(2), jws.WithKey(jwa.ES256, key2.PublicKey), jws.WithKey(jwa.ES256, pubkey))
+		require.Error(t, err, `jws.Verify should fail`)
+		require.Contains(t, err.Error(), `using 1 distinct keys`)
+		_, err = jws.Verify(signed, jws.WithAllSignaturesRequired(), jws.WithKey(jwa.ES256, key2.PublicKey))
+		require.NoError(t, err, `jws.Verify should succeed`)
+	})
+}
+
+func TestAppendSignature(t *testing.T) {
+	key1 := []byte(`abracadabra`)
+	key2, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+	require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+
+	t.Run("compact", func(t *testing.T) {
+		signed, err := jws.Sign([]byte(examplePayload), jws.WithKey(jwa.HS256, key1))
+		require.NoError(t, err, `jws.Sign should succeed`)
+
+		cosigned, err := jws.AppendSignature(signed, jws.WithKey(jwa.ES256, key2))
+		require.NoError(t, err, `jws.AppendSignature should succeed`)
+
+		msg, err := jws.Parse(cosigned)
+		require.NoError(t, err, `jws.Parse should succeed`)
+		require.Len(t, msg.Signatures(), 2)
+
+		payload, err := jws.Verify(cosigned, jws.WithAllSignaturesRequired(), jws.WithKey(jwa.HS256, key1), jws.WithKey(jwa.ES256, key2.PublicKey))
+		require.NoError(t, err, `jws.Verify should succeed`)
+		require.Equal(t, examplePayload, string(payload))
+
+		_, err = jws.AppendSignature(signed, jws.WithKey(jwa.ES256, key2), jws.WithCompact())
+		require.Error(t, err, `jws.AppendSignature should fail for compact serialization`)
+	})
+	t.Run("protected headers are preserved", func(t *testing.T) {
+		// The protected header is not in the order that jwx would produce
+		protected := base64.EncodeToString([]byte(`{"typ":"JWT", "alg":"HS256"}`))
+		encodedPayload := base64.EncodeToString([]byte(examplePayload))
+		mac := hmac.New(sha256.New, key1)
+		mac.Write([]byte(protected + "." + encodedPayload))
+		flattened := fmt.Sprintf(`{"payload":%q,"protected":%q,"signature":%q}`, encodedPayload, protected, base64.EncodeToString(mac.Sum(nil)))
+
+		_, err := jws.Verify([]byte(flattened), jws.WithKey(jwa.HS256, key1))
+		require.NoError(t, err, `jws.Verify should succeed`)
+
+		cosigned, err := jws.AppendSignature([]byte(flattened), jws.WithKey(jwa.ES256, key2), jws.WithJSON(jws.WithPretty(true)))
+		require.NoError(t, err, `jws.AppendSignature should succeed`)
+
+		var raw struct {
+			Signatures []map[string]interface{} `json:"signatures"`
+		}
+		require.NoError(t, json.Unmarshal(cosigned, &raw), `json.Unmarshal should succeed`)
+		require.Len(t, raw.Signatures, 2)
+		require.Equal(t, protected, raw.Signatures[0]["protected"], `existing protected header should be kept byte-for-byte`)
+
+		// The existing signature must come back exactly as it was, without
+		// gaining an empty "header" member
+		var original map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(flattened), &original), `json.Unmarshal should succeed`)
+		delete(original, "payload")
+		require.Equal(t, original, raw.Signatures[0], `existing signature should be unchanged`)
+
+		var result jws.VerifyResult
+		_, err = jws.Verify(cosigned, jws.WithVerifyResult(&result), jws.WithKey(jwa.HS256, key1), jws.WithKey(jwa.ES256, key2.PublicKey))
+		require.NoError(t, err, `jws.Verify should succeed`)
+		require.Equal(t, 2, result.VerifiedCount())
+	})
+	t.Run("detached payload", func(t *testing.T) {
+		signed, err := jws.Sign(nil, jws.WithKey(jwa.HS256, key1), jws.WithDetachedPayload([]byte(examplePayload)))
+		require.NoError(t, err, `jws.Sign should succeed`)
+
+		cosigned, err := jws.AppendSignature(signed, jws.WithKey(jwa.ES256, key2), jws.WithDetachedPayload([]byte(examplePayload)))
+		require.NoError(t, err, `jws.AppendSignature should succeed`)
+		require.NotContains(t, string(cosigned), `"payload"`, `payload should remain detached`)
+
+		_, err = jws.Verify(cosigned, jws.WithAllSignaturesRequired(), jws.WithDetachedPayload([]byte(examplePayload)), jws.WithKey(jwa.HS256, key1), jws.WithKey(jwa.ES256, key2.PublicKey))
+		require.NoError(t, err, `jws.Verify should succeed`)
+	})
+	t.Run("detached payload not specified", func(t *testing.T) {
+		signed, err := jws.Sign(nil, jws.WithKey(jwa.HS256, key1), jws.WithDetachedPayload([]byte(examplePayload)))
+		require.NoError(t, err, `jws.Sign should succeed`)
+
+		_, err = jws.AppendSignature(signed, jws.WithKey(jwa.ES256, key2))
+		require.Error(t, err, `jws.AppendSignature should fail without the detached payload`)
+	})
+	t.Run("b64 mismatch", func(t *testing.T) {
+		hdrs := jws.NewHeaders()
+		require.NoError(t, hdrs.Set("b64", false), `hdrs.Set should succeed`)
+		require.NoError(t, hdrs.Set("crit", []string{"b64"}), `hdrs.Set should succeed`)
+		signed, err := jws.Sign(nil, jws.WithKey(jwa.HS256, key1, jws.WithProtectedHeaders(hdrs)), jws.WithDetachedPayload([]byte(examplePayload)))
+		require.NoError(t, err, `jws.Sign should succeed`)
+
+		_, err = jws.AppendSignature(signed, jws.WithKey(jwa.ES256, key2), jws.WithDetachedPayload([]byte(examplePayload)))
+		require.Error(t, err, `jws.AppendSignature should fail`)
+	})
+}
+
+func TestCanonicalPayload(t *testing.T) {
+	key := []byte(`abracadabra`)
+	doc := map[string]interface{}{
+		"name":   "jwx",
+		"amount": 100.50,
+		"tags":   []string{"b", "a"},
+	}
+	// The same document, as it may look like after being re-encoded
+	reencoded := []byte("{\n  \"tags\": [\"b\", \"a\"],\n  \"amount\": 1.005e2,\n  \"name\": \"\\u006awx\"\n}")
+
+	t.Run("attached", func(t *testing.T) {
+		signed, err := jws.Sign(nil, jws.WithKey(jwa.HS256, key), jws.WithCanonicalPayload(doc))
+		require.NoError(t, err, `jws.Sign should succeed`)
+
+		payload, err := jws.Verify(signed, jws.WithKey(jwa.HS256, key))
+		require.NoError(t, err, `jws.Verify should succeed`)
+		require.Equal(t, `{"amount":100.5,"name":"jwx","tags":["b","a"]}`, string(payload))
+
+		_, err = jws.Verify(signed, jws.WithKey(jwa.HS256, key), jws.WithCanonicalPayload(reencoded))
+		require.NoError(t, err, `jws.Verify should succeed with a re-encoded document`)
+
+		_, err = jws.Verify(signed, jws.WithKey(jwa.HS256, key), jws.WithCanonicalPayload(json.RawMessage(`{"name":"jwx"}`)))
+		require.Error(t, err, `jws.Verify should fail with a different document`)
+
+		_, err = jws.Sign([]byte(examplePayload), jws.WithKey(jwa.HS256, key), jws.WithCanonicalPayload(doc))
+		require.Error(t, err, `jws.Sign should fail when payload is not nil`)
+	})
+	t.Run("detached", func(t *testing.T) {
+		signed, err := jws.Sign(nil, jws.WithKey(jwa.HS256, key), jws.WithDetachedCanonicalPayload(doc))
+		require.NoError(t, err, `jws.Sign should succeed`)
+
+		_, err = jws.Verify(signed, jws.WithKey(jwa.HS256, key), jws.WithDetachedCanonicalPayload(reencoded))
+		require.NoError(t, err, `jws.Verify should succeed with a re-encoded document`)
+
+		_, err = jws.Verify(signed, jws.WithKey(jwa.HS256, key), jws.WithDetachedPayload(reencoded))
+		require.Error(t, err, `jws.Verify should fail when the document is not canonicalized`)
+
+		_, err = jws.Verify(signed, jws.WithKey(jwa.HS256, key), jws.WithDetachedCanonicalPayload([]byte(`{"name":"jwx","amount":100.51,"tags":["b","a"]}`)))
+		require.Error(t, err, `jws.Verify should fail with a different document`)
+	})
+}
+
+type ctxKey struct{}
+
+// remoteSigner emulates a crypto.Signer backed by a remote service
+type remoteSigner struct {
+	key      *ecdsa.PrivateKey
+	requests []string
+}
+
+func (s *remoteSigner) Public() crypto.PublicKey {
+	return s.key.Public()
+}
+
+func (s *remoteSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return nil, fmt.Errorf(`Sign should not be called when a context is available`)
+}
+
+func (s *remoteSigner) SignContext(ctx context.Context, rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	id, _ := ctx.Value(ctxKey{}).(string)
+	s.requests = append(s.requests, id)
+	return s.key.Sign(rand, digest, opts)
+}
+
+func TestContextSigner(t *testing.T) {
+	key, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+	require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+	signer := &remoteSigner{key: key}
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, `request-1`)
+	signed, err := jws.Sign([]byte(examplePayload), jws.WithKey(jwa.ES256, signer), jws.WithContext(ctx))
+	require.NoError(t, err, `jws.Sign should succeed`)
+	require.Equal(t, []string{`request-1`}, signer.requests, `context should be passed to the signer`)
+
+	_, err = jws.Verify(signed, jws.WithKey(jwa.ES256, key.PublicKey))
+	require.NoError(t, err, `jws.Verify should succeed`)
+
+	ctx = context.WithValue(context.Background(), ctxKey{}, `request-2`)
+	_, err = jws.Sign(nil, jws.WithKey(jwa.ES256, signer), jws.WithContext(ctx), jws.WithDetachedPayloadReader(strings.NewReader(examplePayload)))
+	require.NoError(t, err, `jws.Sign should succeed`)
+	require.Equal(t, []string{`request-1`, `request-2`}, signer.requests, `context should be passed to the signer when streaming`)
+
+	cctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = jws.Sign([]byte(examplePayload), jws.WithKey(jwa.ES256, signer), jws.WithContext(cctx))
+	require.ErrorIs(t, err, context.Canceled, `jws.Sign should fail with a cancelled context`)
+
+	_, err = jws.CryptoSignerWithContext(ctx, signer).Sign(rand.Reader, make([]byte, 32), crypto.SHA256)
+	require.NoError(t, err, `adapter should call SignContext`)
+}
+
+func TestFullySpecifiedAlgorithms(t *testing.T) {
+	payload := []byte("Lorem ipsum")
+
+	t.Run("Roundtrip", func(t *testing.T) {
+		p256key, err := jwxtest.GenerateEcdsaKey(jwa.P256)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+		p384key, err := jwxtest.GenerateEcdsaKey(jwa.P384)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+		p521key, err := jwxtest.GenerateEcdsaKey(jwa.P521)
+		require.NoError(t, err, `jwxtest.GenerateEcdsaKey should succeed`)
+		ed25519key, err := jwxtest.GenerateEd25519Key()
+		require.NoError(t, err, `jwxtest.GenerateEd25519Key should succeed`)
+		ed448key, err := jwxtest.GenerateEd448Key()
+		require.NoError(t, err, `jwxtest.GenerateEd448Key should succeed`)
+
+		testcases := []struct {
+			Algorithm jwa.SignatureAlgorithm
+			Key       crypto.Signer
+			WrongKey  crypto.Signer
+		}{
+			{Algorithm: jwa.ESP256, Key: p256key, WrongKey: p384key},
+			{Algorithm: jwa.ESP384, Key: p384key, WrongKey: p521key},
+			{Algorithm: jwa.ESP512, Key: p521key, WrongKey: p256key},
+			{Algorithm: jwa.EdDSAEd25519, Key: ed25519key, WrongKey: ed448key},
+			{Algorithm: jwa.EdDSAEd448, Key: ed448key, WrongKey: ed25519key},
+		}
+		for _, tc := range testcases {
+			tc := tc
+			t.Run(tc.Algorithm.String(), func(t *testing.T) {
+				pubkey := tc.Key.Public()
+				jwkKey, err := jwk.FromRaw(pubkey)
+				require.NoError(t, err, `jwk.FromRaw should succeed`)
+				keys := map[string]interface{}{
+					"Verify(crypto.PublicKey)": pubkey,
+					"Verify(jwk.Key)":          jwkKey,
+				}
+				testRoundtrip(t, payload, tc.Algorithm, tc.Key, keys)
+
+				_, err = jws.Sign(payload, jws.WithKey(tc.Algorithm, tc.WrongKey))
+				require.Error(t, err, `jws.Sign should fail for a key on a different curve`)
+
+				// A signature created using the polymorphic algorithm is
+				// identical, so it can only be rejected because of the key
+				polymorphic := tc.Algorithm.Polymorphic()
+				require.NotEqual(t, tc.Algorithm, polymorphic)
+				signed, err := jws.Sign(payload, jws.WithKey(polymorphic, tc.WrongKey))
+				require.NoError(t, err, `jws.Sign should succeed`)
+				_, err = jws.Verify(signed, jws.WithKey(tc.Algorithm, tc.WrongKey.Public()))
+				require.Error(t, err, `jws.Verify should fail for a key on a different curve`)
+			})
+		}
+	})
+	t.Run("Interoperability with polymorphic algorithms", func(t *testing.T) {
+		key, err := jwxtest.GenerateEd25519Key()
+		require.NoError(t, err, `jwxtest.GenerateEd25519Key should succeed`)
+
+		pubkey, err := jwk.FromRaw(key.Public())
+		require.NoError(t, err, `jwk.FromRaw should succeed`)
+		require.NoError(t, pubkey.Set(jwk.KeyIDKey, `ed25519`), `pubkey.Set should succeed`)
+
+		for _, signAlg := range []jwa.SignatureAlgorithm{jwa.EdDSA, jwa.EdDSAEd25519} {
+			signed, err := jws.Sign(payload, jws.WithKey(signAlg, key, jws.WithProtectedHeaders(mustHeaders(t, jws.KeyIDKey, `ed25519`))))
+			require.NoError(t, err, `jws.Sign should succeed`)
+
+			// keys that carry either of the algorithm names in "alg"
+			for _, keyAlg := range []jwa.SignatureAlgorithm{jwa.EdDSA, jwa.EdDSAEd25519} {
+				k, err := pubkey.Clone()
+				require.NoError(t, err, `pubkey.Clone should succeed`)
+				require.NoError(t, k.Set(jwk.AlgorithmKey, keyAlg), `k.Set should succeed`)
+				set := jwk.NewSet()
+				require.NoError(t, set.AddKey(k), `set.AddKey should succeed`)
+
+				verified, err := jws.Verify(signed, jws.WithKeySet(set))
+				require.NoError(t, err, `jws.Verify (sign=%s, key=%s) should succeed`, signAlg, keyAlg)
+				require.Equal(t, payload, verified)
+			}
+
+			// keys without "alg", where the algorithm is inferred
+			set := jwk.NewSet()
+			require.NoError(t, set.AddKey(pubkey), `set.AddKey should succeed`)
+			verified, err := jws.Verify(signed, jws.WithKeySet(set, jws.WithInferAlgorithmFromKey(true)))
+			require.NoError(t, err, `jws.Verify (sign=%s) should succeed`, signAlg)
+			require.Equal(t, payload, verified)
+		}
+	})
+	t.Run("jwk alg field", func(t *testing.T) {
+		key, err := jwk.ParseKey([]byte(`{"kty":"OKP","crv":"Ed25519","alg":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`))
+		require.NoError(t, err, `jwk.ParseKey should succeed`)
+		require.Equal(t, jwa.EdDSAEd25519, key.Algorithm())
+	})
+}
+
+func TestMLDSA(t *testing.T) {
+	payload := []byte("Lorem ipsum")
+
+	keys := make(map[jwa.SignatureAlgorithm]*mldsa.PrivateKey)
+	for alg, params := range map[jwa.SignatureAlgorithm]*mldsa.Parameters{
+		jwa.MLDSA44: mldsa.MLDSA44(),
+		jwa.MLDSA65: mldsa.MLDSA65(),
+		jwa.MLDSA87: mldsa.MLDSA87(),
+	} {
+		key, err := jwxtest.GenerateMLDSAKey(params)
+		require.NoError(t, err, `jwxtest.GenerateMLDSAKey should succeed`)
+		keys[alg] = key
+	}
+
+	testcases := []struct {
+		Algorithm jwa.SignatureAlgorithm
+		WrongKey  jwa.SignatureAlgorithm
+	}{
+		{Algorithm: jwa.MLDSA44, WrongKey: jwa.MLDSA65},
+		{Algorithm: jwa.MLDSA65, WrongKey: jwa.MLDSA87},
+		{Algorithm: jwa.MLDSA87, WrongKey: jwa.MLDSA44},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Algorithm.String(), func(t *testing.T) {
+			key := keys[tc.Algorithm]
+			pubkey := key.PublicKey()
+			jwkPubkey, err := jwk.FromRaw(pubkey)
+			require.NoError(t, err, `jwk.FromRaw should succeed`)
+			jwkPrivkey, err := jwk.FromRaw(key)
+			require.NoError(t, err, `jwk.FromRaw should succeed`)
+
+			t.Run("Roundtrip", func(t *testing.T) {
+				verifyKeys := map[string]interface{}{
+					"Verify(*mldsa.PublicKey)": pubkey,
+					"Verify(jwk.Key)":          jwkPubkey,
+				}
+				testRoundtrip(t, payload, tc.Algorithm, key, verifyKeys)
+			})
+			t.Run("Wrong parameter set", func(t *testing.T) {
+				wrongKey := keys[tc.WrongKey]
+				_, err := jws.Sign(payload, jws.WithKey(tc.Algorithm, wrongKey))
+				require.Error(t, err, `jws.Sign should fail for a key with a different parameter set`)
+
+				signed, err := jws.Sign(payload, jws.WithKey(tc.WrongKey, wrongKey))
+				require.NoError(t, err, `jws.Sign should succeed`)
+				_, err = jws.Verify(signed, jws.WithKey(tc.Algorithm, wrongKey.PublicKey()))
+				require.Error(t, err, `jws.Verify should fail for a key with a different parameter set`)
+				_, err = jws.Verify(signed, jws.WithKey(tc.WrongKey, pubkey))
+				require.Error(t, err, `jws.Verify should fail with the wrong key`)
+			})
+			t.Run("AlgorithmsForKey", func(t *testing.T) {
+				for _, k := range []interface{}{key, pubkey, jwkPrivkey, jwkPubkey} {
+					algs, err := jws.AlgorithmsForKey(k)
+					require.NoError(t, err, `jws.AlgorithmsForKey should succeed`)
+					require.Equal(t, []jwa.SignatureAlgorithm{tc.Algorithm}, algs)
+				}
+			})
+			t.Run("WithKeySet", func(t *testing.T) {
+				signed, err := jws.Sign(payload, jws.WithKey(tc.Algorithm, jwkPrivkey))
+				require.NoError(t, err, `jws.Sign should succeed`)
+
+				set := jwk.NewSet()
+				require.NoError(t, set.AddKey(jwkPubkey), `set.AddKey should succeed`)
+				verified, err := jws.Verify(signed, jws.WithKeySet(set, jws.WithRequireKid(false)))
+				require.NoError(t, err, `jws.Verify should succeed`)
+				require.Equal(t, payload, verified)
+			})
+		})
+	}
+}
+
+func mustHeaders(t *testing.T, name string, value interface{}) jws.Headers {
+	t.Helper()
+	hdrs := jws.NewHeaders()
+	require.NoError(t, hdrs.Set(name, value), `hdrs.Set should succeed`)
+	return hdrs
+}
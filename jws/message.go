@@ -100,8 +100,15 @@ func (s *Signature) UnmarshalJSON(data []byte) error {
 // The second return value s the full three-segment signature
 // (e.g. "eyXXXX.XXXXX.XXXX")
 func (s *Signature) Sign(payload []byte, signer Signer, key interface{}) ([]byte, []byte, error) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	return s.SignContext(context.Background(), payload, signer, key)
+}
+
+// SignContext is like Sign, but passes `ctx` to the signer if it
+// implements `jws.ContextSigner`.
+func (s *Signature) SignContext(ctx context.Context, payload []byte, signer Signer, key interface{}) ([]byte, []byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 
 	hdrs, err := mergeHeaders(ctx, s.headers, s.protected)
 	if err != nil {
@@ -148,7 +155,12 @@ func (s *Signature) Sign(payload []byte, signer Signer, key interface{}) ([]byte
 		buf.Write(payload)
 	}
 
-	signature, err := signer.Sign(buf.Bytes(), key)
+	var signature []byte
+	if cs, ok := signer.(ContextSigner); ok {
+		signature, err = cs.SignContext(ctx, buf.Bytes(), key)
+	} else {
+		signature, err = signer.Sign(buf.Bytes(), key)
+	}
 	if err != nil {
 		return nil, nil, fmt.Errorf(`failed to sign payload: %w`, err)
 	}
@@ -254,6 +266,7 @@ func (m *Message) UnmarshalJSON(buf []byte) error {
 	m.payload = nil
 	m.signatures = nil
 	m.b64 = true
+	m.detached = false
 
 	var mup messageUnmarshalProbe
 	mup.Header = NewHeaders()
@@ -321,7 +334,9 @@ func (m *Message) UnmarshalJSON(buf []byte) error {
 		b64 = getB64Value(sig.protected)
 	}
 
-	if mup.Payload != nil {
+	if mup.Payload == nil {
+		m.detached = true
+	} else {
 		if !b64 { // NOT base64 encoded
 			m.payload = []byte(*mup.Payload)
 		} else {
@@ -362,24 +377,34 @@ func (m Message) marshalFlattened() ([]byte, error) {
 		wrote = true
 	}
 
-	if wrote {
-		buf.WriteRune(',')
+	if !m.detached {
+		if wrote {
+			buf.WriteRune(',')
+		}
+		buf.WriteString(`"payload":"`)
+		buf.WriteString(base64.EncodeToString(m.payload))
+		buf.WriteRune('"')
+		wrote = true
 	}
-	buf.WriteString(`"payload":"`)
-	buf.WriteString(base64.EncodeToString(m.payload))
-	buf.WriteRune('"')
 
 	if protected := sig.protected; protected != nil {
 		protectedbuf, err := protected.MarshalJSON()
 		if err != nil {
 			return nil, fmt.Errorf(`failed to marshal "protected" (flattened format): %w`, err)
 		}
-		buf.WriteString(`,"protected":"`)
+		if wrote {
+			buf.WriteRune(',')
+		}
+		buf.WriteString(`"protected":"`)
 		buf.WriteString(base64.EncodeToString(protectedbuf))
 		buf.WriteRune('"')
+		wrote = true
 	}
 
-	buf.WriteString(`,"signature":"`)
+	if wrote {
+		buf.WriteRune(',')
+	}
+	buf.WriteString(`"signature":"`)
 	buf.WriteString(base64.EncodeToString(sig.signature))
 	buf.WriteRune('"')
 	buf.WriteRune('}')
@@ -393,9 +418,13 @@ func (m Message) marshalFull() ([]byte, error) {
 	buf := pool.GetBytesBuffer()
 	defer pool.ReleaseBytesBuffer(buf)
 
-	buf.WriteString(`{"payload":"`)
-	buf.WriteString(base64.EncodeToString(m.payload))
-	buf.WriteString(`","signatures":[`)
+	buf.WriteRune('{')
+	if !m.detached {
+		buf.WriteString(`"payload":"`)
+		buf.WriteString(base64.EncodeToString(m.payload))
+		buf.WriteString(`",`)
+	}
+	buf.WriteString(`"signatures":[`)
 	for i, sig := range m.signatures {
 		if i > 0 {
 			buf.WriteRune(',')
@@ -0,0 +1,120 @@
+package jws
+
+import (
+	"crypto"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/sjwl/jwx/v2/internal/keyconv"
+	"github.com/sjwl/jwx/v2/internal/mldsa"
+	"github.com/sjwl/jwx/v2/jwa"
+)
+
+var mldsaParameters = map[jwa.SignatureAlgorithm]*mldsa.Parameters{
+	jwa.MLDSA44: mldsa.MLDSA44(),
+	jwa.MLDSA65: mldsa.MLDSA65(),
+	jwa.MLDSA87: mldsa.MLDSA87(),
+}
+
+// mldsaAlgorithmForKey returns the only signature algorithm that can be
+// used with the given ML-DSA key
+func mldsaAlgorithmForKey(key interface{}) (jwa.SignatureAlgorithm, bool) {
+	var params *mldsa.Parameters
+	switch key := key.(type) {
+	case *mldsa.PublicKey:
+		params = key.Parameters()
+	case *mldsa.PrivateKey:
+		params = key.Parameters()
+	default:
+		return "", false
+	}
+
+	for alg, p := range mldsaParameters {
+		if p == params {
+			return alg, true
+		}
+	}
+	return "", false
+}
+
+// checkMLDSAParameters returns an error if pubkey is not an ML-DSA
+// public key for the parameter set required by alg
+func checkMLDSAParameters(alg jwa.SignatureAlgorithm, pubkey interface{}) (*mldsa.PublicKey, error) {
+	mldsaPubkey, ok := pubkey.(*mldsa.PublicKey)
+	if !ok || mldsaPubkey == nil {
+		return nil, fmt.Errorf(`expected *mldsa.PublicKey, got %T`, pubkey)
+	}
+	if params := mldsaParameters[alg]; mldsaPubkey.Parameters() != params {
+		return nil, fmt.Errorf(`algorithm %s requires a %s key (got %s)`, alg, params, mldsaPubkey.Parameters())
+	}
+	return mldsaPubkey, nil
+}
+
+type mldsaSigner struct {
+	alg jwa.SignatureAlgorithm
+}
+
+func newMLDSASigner(alg jwa.SignatureAlgorithm) Signer {
+	return &mldsaSigner{alg: alg}
+}
+
+func (s mldsaSigner) Algorithm() jwa.SignatureAlgorithm {
+	return s.alg
+}
+
+func (s mldsaSigner) Sign(payload []byte, key interface{}) ([]byte, error) {
+	if key == nil {
+		return nil, fmt.Errorf(`missing private key while signing payload`)
+	}
+
+	// *mldsa.PrivateKey implements crypto.Signer, so we should simply
+	// accept a crypto.Signer here.
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		var privkey *mldsa.PrivateKey
+		if err := keyconv.MLDSAPrivateKey(&privkey, key); err != nil {
+			return nil, fmt.Errorf(`failed to retrieve *mldsa.PrivateKey out of %T: %w`, key, err)
+		}
+		signer = privkey
+	}
+
+	if _, err := checkMLDSAParameters(s.alg, signer.Public()); err != nil {
+		return nil, err
+	}
+	return signer.Sign(rand.Reader, payload, crypto.Hash(0))
+}
+
+type mldsaVerifier struct {
+	alg jwa.SignatureAlgorithm
+}
+
+func newMLDSAVerifier(alg jwa.SignatureAlgorithm) Verifier {
+	return &mldsaVerifier{alg: alg}
+}
+
+func (v mldsaVerifier) Verify(payload, signature []byte, key interface{}) error {
+	if key == nil {
+		return fmt.Errorf(`missing public key while verifying payload`)
+	}
+
+	var pubkey interface{}
+	if signer, ok := key.(crypto.Signer); ok {
+		pubkey = signer.Public()
+	} else {
+		var mldsaPubkey *mldsa.PublicKey
+		if err := keyconv.MLDSAPublicKey(&mldsaPubkey, key); err != nil {
+			return fmt.Errorf(`failed to retrieve *mldsa.PublicKey out of %T: %w`, key, err)
+		}
+		pubkey = mldsaPubkey
+	}
+
+	mldsaPubkey, err := checkMLDSAParameters(v.alg, pubkey)
+	if err != nil {
+		return err
+	}
+
+	if !mldsa.Verify(mldsaPubkey, payload, signature) {
+		return fmt.Errorf(`failed to match ML-DSA signature`)
+	}
+	return nil
+}
@@ -143,6 +143,29 @@ func WithKeySet(set jwk.Set, options ...WithKeySetSuboption) VerifyOption {
 	})
 }
 
+// WithAllowedAlgorithms specifies the signature algorithms that may be
+// used to verify a JWS message. Signatures using any other algorithm are
+// rejected before any cryptographic operation takes place.
+//
+// This is a shorthand for a `jwa.AlgorithmPolicy` that only allows `algs`.
+// Unlike `jws.WithAlgorithmPolicy()`, it does not replace the global policy
+// set via `jws.Settings()`: the algorithm must be allowed by both.
+func WithAllowedAlgorithms(algs ...jwa.SignatureAlgorithm) VerifyOption {
+	list := make([]jwa.KeyAlgorithm, len(algs))
+	for i, alg := range algs {
+		list[i] = alg
+	}
+	return &verifyOption{option.New(identAllowedAlgorithms{}, jwa.NewAlgorithmPolicy().Allow(list...))}
+}
+
+// WithAdditionalAlgorithmPolicy specifies a `jwa.AlgorithmPolicy` that is
+// enforced in addition to the policy in effect for a call to `jws.Verify()`.
+// Unlike `jws.WithAlgorithmPolicy()`, it does not replace the global policy
+// set via `jws.Settings()`: the algorithm must be allowed by both.
+func WithAdditionalAlgorithmPolicy(p *jwa.AlgorithmPolicy) VerifyOption {
+	return &verifyOption{option.New(identAllowedAlgorithms{}, p)}
+}
+
 func WithVerifyAuto(f jwk.Fetcher, options ...jwk.FetchOption) VerifyOption {
 	if f == nil {
 		f = jwk.FetchFunc(jwk.Fetch)
@@ -4,9 +4,11 @@ package jws
 
 import (
 	"context"
+	"io"
 	"io/fs"
 
 	"github.com/lestrrat-go/option"
+	"github.com/sjwl/jwx/v2/jwa"
 )
 
 type Option = option.Interface
@@ -23,6 +25,53 @@ type compactOption struct {
 
 func (*compactOption) compactOption() {}
 
+// GlobalOption describes options that can be passed to `jws.Settings()`
+type GlobalOption interface {
+	Option
+	globalOption()
+}
+
+type globalOption struct {
+	Option
+}
+
+func (*globalOption) globalOption() {}
+
+// GlobalSignVerifyOption describes options that can be passed to either `jws.Settings()`,
+// `jws.Sign()`, or `jws.Verify()`
+type GlobalSignVerifyOption interface {
+	Option
+	globalOption()
+	signOption()
+	verifyOption()
+}
+
+type globalSignVerifyOption struct {
+	Option
+}
+
+func (*globalSignVerifyOption) globalOption() {}
+
+func (*globalSignVerifyOption) signOption() {}
+
+func (*globalSignVerifyOption) verifyOption() {}
+
+// GlobalVerifyOption describes options that can be passed to either `jws.Settings()`
+// or `jws.Verify()`
+type GlobalVerifyOption interface {
+	Option
+	globalOption()
+	verifyOption()
+}
+
+type globalVerifyOption struct {
+	Option
+}
+
+func (*globalVerifyOption) globalOption() {}
+
+func (*globalVerifyOption) verifyOption() {}
+
 // ReadFileOption is a type of `Option` that can be passed to `jwe.Parse`
 type ParseOption interface {
 	Option
@@ -123,13 +172,19 @@ type withKeySuboption struct {
 
 func (*withKeySuboption) withKeySuboption() {}
 
+type identAlgorithmPolicy struct{}
+type identAllowedAlgorithms struct{}
+type identCanonicalPayload struct{}
 type identContext struct{}
 type identDetached struct{}
+type identDetachedCanonicalPayload struct{}
 type identDetachedPayload struct{}
+type identDetachedPayloadReader struct{}
 type identFS struct{}
 type identInferAlgorithmFromKey struct{}
 type identKey struct{}
 type identKeyProvider struct{}
+type identKeyStrengthPolicy struct{}
 type identKeyUsed struct{}
 type identMessage struct{}
 type identMultipleKeysPerKeyID struct{}
@@ -137,8 +192,22 @@ type identPretty struct{}
 type identProtectedHeaders struct{}
 type identPublicHeaders struct{}
 type identRequireKid struct{}
+type identRequiredSignatures struct{}
 type identSerialization struct{}
 type identUseDefault struct{}
+type identVerifyResult struct{}
+
+func (identAlgorithmPolicy) String() string {
+	return "WithAlgorithmPolicy"
+}
+
+func (identAllowedAlgorithms) String() string {
+	return "WithAllowedAlgorithms"
+}
+
+func (identCanonicalPayload) String() string {
+	return "WithCanonicalPayload"
+}
 
 func (identContext) String() string {
 	return "WithContext"
@@ -148,10 +217,18 @@ func (identDetached) String() string {
 	return "WithDetached"
 }
 
+func (identDetachedCanonicalPayload) String() string {
+	return "WithDetachedCanonicalPayload"
+}
+
 func (identDetachedPayload) String() string {
 	return "WithDetachedPayload"
 }
 
+func (identDetachedPayloadReader) String() string {
+	return "WithDetachedPayloadReader"
+}
+
 func (identFS) String() string {
 	return "WithFS"
 }
@@ -168,6 +245,10 @@ func (identKeyProvider) String() string {
 	return "WithKeyProvider"
 }
 
+func (identKeyStrengthPolicy) String() string {
+	return "WithKeyStrengthPolicy"
+}
+
 func (identKeyUsed) String() string {
 	return "WithKeyUsed"
 }
@@ -196,6 +277,10 @@ func (identRequireKid) String() string {
 	return "WithRequireKid"
 }
 
+func (identRequiredSignatures) String() string {
+	return "WithAllSignaturesRequired"
+}
+
 func (identSerialization) String() string {
 	return "WithSerialization"
 }
@@ -204,8 +289,57 @@ func (identUseDefault) String() string {
 	return "WithUseDefault"
 }
 
-func WithContext(v context.Context) VerifyOption {
-	return &verifyOption{option.New(identContext{}, v)}
+func (identVerifyResult) String() string {
+	return "WithVerifyResult"
+}
+
+// WithAlgorithmPolicy specifies the `jwa.AlgorithmPolicy` that decides which
+// signature algorithms may be used to verify a JWS message. Signatures whose
+// "alg" header, or keys whose algorithm, is rejected by the policy are never
+// passed to the verifier.
+//
+// When passed to `jws.Settings()`, the policy is used by all subsequent calls
+// to `jws.Verify()` (and therefore `jwt.Parse()`). When passed to `jws.Verify()`,
+// the policy replaces the global policy for that call. By default no policy is
+// set, and all algorithms are allowed.
+func WithAlgorithmPolicy(v *jwa.AlgorithmPolicy) GlobalVerifyOption {
+	return &globalVerifyOption{option.New(identAlgorithmPolicy{}, v)}
+}
+
+// WithCanonicalPayload can be used to sign or verify a JSON document in
+// its canonical form, as defined by the JSON Canonicalization Scheme (JCS, RFC8785).
+// This allows the signature to survive re-encoding of the document, as
+// long as its contents do not change.
+//
+// `v` may be either raw JSON text (`[]byte` or `json.RawMessage`), or any
+// other Go value, which is first converted to JSON using `json.Marshal()`.
+// As required by RFC8785, raw JSON text with duplicate member names,
+// invalid UTF-8, or unpaired surrogates is rejected.
+//
+// When this option is used for `jws.Sign()`, the first parameter (normally the payload)
+// must be set to `nil`. The canonical form of `v` is signed, and is included
+// in the resulting message as the payload.
+//
+// When this option is used for `jws.Verify()`, the canonical form of `v` is
+// compared with the payload of the message after the signature has been verified.
+// Verification fails if they do not match.
+func WithCanonicalPayload(v interface{}) SignVerifyOption {
+	return &signVerifyOption{option.New(identCanonicalPayload{}, v)}
+}
+
+// WithContext specifies the context.Context object to use when signing
+// or verifying JWS messages.
+//
+// When used with `jws.Sign()`, the context is passed to signers that
+// implement `jws.ContextSigner`, and from the built-in RSA, ECDSA, and
+// EdDSA signers to keys that implement `jws.ContextCryptoSigner`. This
+// allows signing operations against remote services such as KMS or HSM
+// to be cancelled or traced per request.
+//
+// When used with `jws.Verify()`, the context is passed to the
+// `jws.KeyProvider` objects.
+func WithContext(v context.Context) SignVerifyOption {
+	return &signVerifyOption{option.New(identContext{}, v)}
 }
 
 // WithDetached specifies that the `jws.Message` should be serialized in
@@ -215,6 +349,18 @@ func WithDetached(v bool) CompactOption {
 	return &compactOption{option.New(identDetached{}, v)}
 }
 
+// WithDetachedCanonicalPayload is like `jws.WithCanonicalPayload()`, but the
+// canonical form of `v` is used as a detached payload, as if it were passed to
+// `jws.WithDetachedPayload()`.
+//
+// This is useful when signing the body of JSON API requests and responses,
+// where the signature is transported separately from the body: the receiver
+// can verify the signature against the body it received, even if the body has
+// been re-encoded along the way.
+func WithDetachedCanonicalPayload(v interface{}) SignVerifyOption {
+	return &signVerifyOption{option.New(identDetachedCanonicalPayload{}, v)}
+}
+
 // WithDetachedPayload can be used to both sign or verify a JWS message with a
 // detached payload.
 //
@@ -226,6 +372,30 @@ func WithDetachedPayload(v []byte) SignVerifyOption {
 	return &signVerifyOption{option.New(identDetachedPayload{}, v)}
 }
 
+// WithDetachedPayloadReader can be used to both sign or verify a JWS message with a
+// detached payload that is read from an `io.Reader`.
+//
+// Unlike `jws.WithDetachedPayload()`, the payload is never loaded into memory as a
+// whole. Instead it is fed to the signature algorithm incrementally, so that
+// arbitrarily large payloads can be signed and verified using a constant amount
+// of memory. The payload is read exactly once, even if there are multiple
+// signatures or candidate keys.
+//
+// When this option is used for `jws.Sign()`, the first parameter (normally the payload)
+// must be set to `nil`. The resulting message does not contain the payload, regardless
+// of the serialization format.
+//
+// When this option is used for `jws.Verify()`, the returned payload is always `nil`,
+// as the payload has already been consumed from the reader.
+//
+// Only algorithms that hash their input (HMAC, RSA, and ECDSA family of algorithms)
+// can be used with this option. EdDSA requires the entire payload to be available,
+// and therefore cannot be used. When verifying, keys for such algorithms are skipped,
+// just like any other key that cannot be used for a signature.
+func WithDetachedPayloadReader(v io.Reader) SignVerifyOption {
+	return &signVerifyOption{option.New(identDetachedPayloadReader{}, v)}
+}
+
 // WithFS specifies the source `fs.FS` object to read the file from.
 func WithFS(v fs.FS) ReadFileOption {
 	return &readFileOption{option.New(identFS{}, v)}
@@ -253,6 +423,19 @@ func WithKeyProvider(v KeyProvider) VerifyOption {
 	return &verifyOption{option.New(identKeyProvider{}, v)}
 }
 
+// WithKeyStrengthPolicy specifies the `jwa.KeyStrengthPolicy` that decides the
+// minimum strength of keys used to sign or verify JWS messages. `jws.Sign()`
+// fails if any of the keys is too weak, and `jws.Verify()` does not use keys
+// that are too weak.
+//
+// When passed to `jws.Settings()`, the policy is used by all subsequent calls
+// to `jws.Sign()` and `jws.Verify()`. When passed to `jws.Sign()` or `jws.Verify()`,
+// the policy replaces the global policy for that call. By default no policy
+// is set, and all keys are allowed.
+func WithKeyStrengthPolicy(v *jwa.KeyStrengthPolicy) GlobalSignVerifyOption {
+	return &globalSignVerifyOption{option.New(identKeyStrengthPolicy{}, v)}
+}
+
 // WithKeyUsed allows you to specify the `jws.Verify()` function to
 // return the key used for verification. This may be useful when
 // you specify multiple key sources or if you pass a `jwk.Set`
@@ -314,6 +497,26 @@ func WithRequireKid(v bool) WithKeySetSuboption {
 	return &withKeySetSuboption{option.New(identRequireKid{}, v)}
 }
 
+// WithAllSignaturesRequired specifies that `jws.Verify()` only succeeds
+// if all of the signatures in the message are verified.
+func WithAllSignaturesRequired() VerifyOption {
+	return &verifyOption{option.New(identRequiredSignatures{}, requireAllSignatures)}
+}
+
+// WithRequiredSignatures specifies the minimum number of signatures that
+// must be verified in order for `jws.Verify()` to succeed. By default
+// only one of the signatures needs to be verified.
+//
+// Each key only counts once: signatures that were verified using the
+// same key (e.g. copies of the same signature) count as one.
+//
+// This is useful for messages in JSON serialization format that carry
+// signatures from multiple parties. Use `jws.WithVerifyResult()` to
+// learn which of the signatures were verified.
+func WithRequiredSignatures(v int) VerifyOption {
+	return &verifyOption{option.New(identRequiredSignatures{}, v)}
+}
+
 // WithCompact specifies that the result of `jws.Sign()` is serialized in
 // compact format.
 //
@@ -329,3 +532,14 @@ func WithCompact() SignOption {
 func WithUseDefault(v bool) WithKeySetSuboption {
 	return &withKeySetSuboption{option.New(identUseDefault{}, v)}
 }
+
+// WithVerifyResult allows you to specify the `jws.Verify()` function to
+// store the outcome of the verification for each of the signatures in
+// the message, including the algorithm and key that was used to verify
+// each signature, or the reason why it could not be verified.
+//
+// When this option is specified, `jws.Verify()` attempts to verify all
+// of the signatures, instead of stopping at the first one that verifies.
+func WithVerifyResult(v *VerifyResult) VerifyOption {
+	return &verifyOption{option.New(identVerifyResult{}, v)}
+}
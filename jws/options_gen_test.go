@@ -9,13 +9,19 @@ import (
 )
 
 func TestOptionIdent(t *testing.T) {
+	require.Equal(t, "WithAlgorithmPolicy", identAlgorithmPolicy{}.String())
+	require.Equal(t, "WithAllowedAlgorithms", identAllowedAlgorithms{}.String())
+	require.Equal(t, "WithCanonicalPayload", identCanonicalPayload{}.String())
 	require.Equal(t, "WithContext", identContext{}.String())
 	require.Equal(t, "WithDetached", identDetached{}.String())
+	require.Equal(t, "WithDetachedCanonicalPayload", identDetachedCanonicalPayload{}.String())
 	require.Equal(t, "WithDetachedPayload", identDetachedPayload{}.String())
+	require.Equal(t, "WithDetachedPayloadReader", identDetachedPayloadReader{}.String())
 	require.Equal(t, "WithFS", identFS{}.String())
 	require.Equal(t, "WithInferAlgorithmFromKey", identInferAlgorithmFromKey{}.String())
 	require.Equal(t, "WithKey", identKey{}.String())
 	require.Equal(t, "WithKeyProvider", identKeyProvider{}.String())
+	require.Equal(t, "WithKeyStrengthPolicy", identKeyStrengthPolicy{}.String())
 	require.Equal(t, "WithKeyUsed", identKeyUsed{}.String())
 	require.Equal(t, "WithMessage", identMessage{}.String())
 	require.Equal(t, "WithMultipleKeysPerKeyID", identMultipleKeysPerKeyID{}.String())
@@ -23,6 +29,8 @@ func TestOptionIdent(t *testing.T) {
 	require.Equal(t, "WithProtectedHeaders", identProtectedHeaders{}.String())
 	require.Equal(t, "WithPublicHeaders", identPublicHeaders{}.String())
 	require.Equal(t, "WithRequireKid", identRequireKid{}.String())
+	require.Equal(t, "WithAllSignaturesRequired", identRequiredSignatures{}.String())
 	require.Equal(t, "WithSerialization", identSerialization{}.String())
 	require.Equal(t, "WithUseDefault", identUseDefault{}.String())
+	require.Equal(t, "WithVerifyResult", identVerifyResult{}.String())
 }
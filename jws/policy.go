@@ -0,0 +1,53 @@
+package jws
+
+import (
+	"sync"
+
+	"github.com/sjwl/jwx/v2/jwa"
+)
+
+var muGlobalPolicy sync.RWMutex
+var globalPolicy *jwa.AlgorithmPolicy
+var globalKeyStrengthPolicy *jwa.KeyStrengthPolicy
+
+// Settings controls global settings that are specific to JWS.
+func Settings(options ...GlobalOption) {
+	//nolint:forcetypeassert
+	for _, option := range options {
+		switch option.Ident() {
+		case identAlgorithmPolicy{}:
+			muGlobalPolicy.Lock()
+			globalPolicy = option.Value().(*jwa.AlgorithmPolicy)
+			muGlobalPolicy.Unlock()
+		case identKeyStrengthPolicy{}:
+			muGlobalPolicy.Lock()
+			globalKeyStrengthPolicy = option.Value().(*jwa.KeyStrengthPolicy)
+			muGlobalPolicy.Unlock()
+		}
+	}
+}
+
+func getGlobalPolicy() *jwa.AlgorithmPolicy {
+	muGlobalPolicy.RLock()
+	defer muGlobalPolicy.RUnlock()
+	return globalPolicy
+}
+
+func getGlobalKeyStrengthPolicy() *jwa.KeyStrengthPolicy {
+	muGlobalPolicy.RLock()
+	defer muGlobalPolicy.RUnlock()
+	return globalKeyStrengthPolicy
+}
+
+// algorithmPolicies is the list of policies that apply to a single
+// call to `jws.Verify()`. An algorithm must be allowed by all of them
+type algorithmPolicies []*jwa.AlgorithmPolicy
+
+func (list algorithmPolicies) Check(alg jwa.SignatureAlgorithm) error {
+	for _, p := range list {
+		if err := p.Check(alg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -14,45 +14,29 @@ var rsaSigners map[jwa.SignatureAlgorithm]*rsaSigner
 var rsaVerifiers map[jwa.SignatureAlgorithm]*rsaVerifier
 
 func init() {
-	algs := map[jwa.SignatureAlgorithm]struct {
-		Hash crypto.Hash
-		PSS  bool
-	}{
-		jwa.RS256: {
-			Hash: crypto.SHA256,
-		},
-		jwa.RS384: {
-			Hash: crypto.SHA384,
-		},
-		jwa.RS512: {
-			Hash: crypto.SHA512,
-		},
-		jwa.PS256: {
-			Hash: crypto.SHA256,
-			PSS:  true,
-		},
-		jwa.PS384: {
-			Hash: crypto.SHA384,
-			PSS:  true,
-		},
-		jwa.PS512: {
-			Hash: crypto.SHA512,
-			PSS:  true,
-		},
+	// the value is true for RSASSA-PSS
+	algs := map[jwa.SignatureAlgorithm]bool{
+		jwa.RS256: false,
+		jwa.RS384: false,
+		jwa.RS512: false,
+		jwa.PS256: true,
+		jwa.PS384: true,
+		jwa.PS512: true,
 	}
 
 	rsaSigners = make(map[jwa.SignatureAlgorithm]*rsaSigner)
 	rsaVerifiers = make(map[jwa.SignatureAlgorithm]*rsaVerifier)
-	for alg, item := range algs {
+	for alg, pss := range algs {
+		hash := builtinMetadata(alg).Hash
 		rsaSigners[alg] = &rsaSigner{
 			alg:  alg,
-			hash: item.Hash,
-			pss:  item.PSS,
+			hash: hash,
+			pss:  pss,
 		}
 		rsaVerifiers[alg] = &rsaVerifier{
 			alg:  alg,
-			hash: item.Hash,
-			pss:  item.PSS,
+			hash: hash,
+			pss:  pss,
 		}
 	}
 }
@@ -72,6 +56,43 @@ func (rs *rsaSigner) Algorithm() jwa.SignatureAlgorithm {
 }
 
 func (rs *rsaSigner) Sign(payload []byte, key interface{}) ([]byte, error) {
+	signer, err := rsaCryptoSigner(key)
+	if err != nil {
+		return nil, err
+	}
+
+	h := rs.hash.New()
+	if _, err := h.Write(payload); err != nil {
+		return nil, fmt.Errorf(`failed to write payload to hash: %w`, err)
+	}
+	return rs.signDigest(signer, h.Sum(nil))
+}
+
+func (rs *rsaSigner) newSignStream(key interface{}) (signStream, error) {
+	signer, err := rsaCryptoSigner(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &digestSignStream{
+		Hash: rs.hash.New(),
+		sign: func(digest []byte) ([]byte, error) {
+			return rs.signDigest(signer, digest)
+		},
+	}, nil
+}
+
+func (rs *rsaSigner) signDigest(signer crypto.Signer, digest []byte) ([]byte, error) {
+	if rs.pss {
+		return signer.Sign(rand.Reader, digest, &rsa.PSSOptions{
+			Hash:       rs.hash,
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+		})
+	}
+	return signer.Sign(rand.Reader, digest, rs.hash)
+}
+
+func rsaCryptoSigner(key interface{}) (crypto.Signer, error) {
 	if key == nil {
 		return nil, fmt.Errorf(`missing private key while signing payload`)
 	}
@@ -84,18 +105,7 @@ func (rs *rsaSigner) Sign(payload []byte, key interface{}) ([]byte, error) {
 		}
 		signer = &privkey
 	}
-
-	h := rs.hash.New()
-	if _, err := h.Write(payload); err != nil {
-		return nil, fmt.Errorf(`failed to write payload to hash: %w`, err)
-	}
-	if rs.pss {
-		return signer.Sign(rand.Reader, h.Sum(nil), &rsa.PSSOptions{
-			Hash:       rs.hash,
-			SaltLength: rsa.PSSSaltLengthEqualsHash,
-		})
-	}
-	return signer.Sign(rand.Reader, h.Sum(nil), rs.hash)
+	return signer, nil
 }
 
 type rsaVerifier struct {
@@ -109,8 +119,42 @@ func newRSAVerifier(alg jwa.SignatureAlgorithm) Verifier {
 }
 
 func (rv *rsaVerifier) Verify(payload, signature []byte, key interface{}) error {
+	pubkey, err := rsaVerifyKey(key)
+	if err != nil {
+		return err
+	}
+
+	h := rv.hash.New()
+	if _, err := h.Write(payload); err != nil {
+		return fmt.Errorf(`failed to write payload to hash: %w`, err)
+	}
+	return rv.verifyDigest(pubkey, h.Sum(nil), signature)
+}
+
+func (rv *rsaVerifier) newVerifyStream(key interface{}) (verifyStream, error) {
+	pubkey, err := rsaVerifyKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &digestVerifyStream{
+		Hash: rv.hash.New(),
+		verify: func(digest, signature []byte) error {
+			return rv.verifyDigest(pubkey, digest, signature)
+		},
+	}, nil
+}
+
+func (rv *rsaVerifier) verifyDigest(pubkey *rsa.PublicKey, digest, signature []byte) error {
+	if rv.pss {
+		return rsa.VerifyPSS(pubkey, rv.hash, digest, signature, nil)
+	}
+	return rsa.VerifyPKCS1v15(pubkey, rv.hash, digest, signature)
+}
+
+func rsaVerifyKey(key interface{}) (*rsa.PublicKey, error) {
 	if key == nil {
-		return fmt.Errorf(`missing public key while verifying payload`)
+		return nil, fmt.Errorf(`missing public key while verifying payload`)
 	}
 
 	var pubkey rsa.PublicKey
@@ -122,21 +166,12 @@ func (rv *rsaVerifier) Verify(payload, signature []byte, key interface{}) error
 		case *rsa.PublicKey:
 			pubkey = *cpub
 		default:
-			return fmt.Errorf(`failed to retrieve rsa.PublicKey out of crypto.Signer %T`, key)
+			return nil, fmt.Errorf(`failed to retrieve rsa.PublicKey out of crypto.Signer %T`, key)
 		}
 	} else {
 		if err := keyconv.RSAPublicKey(&pubkey, key); err != nil {
-			return fmt.Errorf(`failed to retrieve rsa.PublicKey out of %T: %w`, key, err)
+			return nil, fmt.Errorf(`failed to retrieve rsa.PublicKey out of %T: %w`, key, err)
 		}
 	}
-
-	h := rv.hash.New()
-	if _, err := h.Write(payload); err != nil {
-		return fmt.Errorf(`failed to write payload to hash: %w`, err)
-	}
-
-	if rv.pss {
-		return rsa.VerifyPSS(&pubkey, rv.hash, h.Sum(nil), signature, nil)
-	}
-	return rsa.VerifyPKCS1v15(&pubkey, rv.hash, h.Sum(nil), signature)
+	return &pubkey, nil
 }
@@ -38,7 +38,7 @@ func init() {
 		}(alg))
 	}
 
-	for _, alg := range []jwa.SignatureAlgorithm{jwa.ES256, jwa.ES384, jwa.ES512, jwa.ES256K} {
+	for _, alg := range []jwa.SignatureAlgorithm{jwa.ES256, jwa.ES384, jwa.ES512, jwa.ESP256, jwa.ESP384, jwa.ESP512, jwa.ES256K, jwa.ESB256, jwa.ESB384, jwa.ESB512} {
 		RegisterSigner(alg, func(alg jwa.SignatureAlgorithm) SignerFactory {
 			return SignerFactoryFn(func() (Signer, error) {
 				return newECDSASigner(alg), nil
@@ -54,9 +54,21 @@ func init() {
 		}(alg))
 	}
 
-	RegisterSigner(jwa.EdDSA, SignerFactoryFn(func() (Signer, error) {
-		return newEdDSASigner(), nil
-	}))
+	for _, alg := range []jwa.SignatureAlgorithm{jwa.EdDSA, jwa.EdDSAEd25519, jwa.EdDSAEd448} {
+		RegisterSigner(alg, func(alg jwa.SignatureAlgorithm) SignerFactory {
+			return SignerFactoryFn(func() (Signer, error) {
+				return newEdDSASigner(alg), nil
+			})
+		}(alg))
+	}
+
+	for _, alg := range []jwa.SignatureAlgorithm{jwa.MLDSA44, jwa.MLDSA65, jwa.MLDSA87} {
+		RegisterSigner(alg, func(alg jwa.SignatureAlgorithm) SignerFactory {
+			return SignerFactoryFn(func() (Signer, error) {
+				return newMLDSASigner(alg), nil
+			})
+		}(alg))
+	}
 }
 
 // NewSigner creates a signer that signs payloads using the given signature algorithm.
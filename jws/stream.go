@@ -0,0 +1,268 @@
+package jws
+
+import (
+	"context"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/sjwl/jwx/v2/internal/base64"
+	"github.com/sjwl/jwx/v2/internal/json"
+	"github.com/sjwl/jwx/v2/jwa"
+	"github.com/sjwl/jwx/v2/jwk"
+)
+
+// signStream computes a signature over data that is written to it
+// incrementally. Sign() must be called after all of the signing input
+// has been written.
+type signStream interface {
+	io.Writer
+	Sign() ([]byte, error)
+}
+
+// verifyStream verifies a signature over data that is written to it
+// incrementally. Verify() must be called after all of the signing input
+// has been written.
+type verifyStream interface {
+	io.Writer
+	Verify([]byte) error
+}
+
+// streamSigner is implemented by Signers that can compute signatures
+// without having the entire signing input in memory. Signers that
+// do not implement this interface (e.g. EdDSA, which requires the
+// entire message) cannot be used with `jws.WithDetachedPayloadReader()`
+type streamSigner interface {
+	newSignStream(interface{}) (signStream, error)
+}
+
+// streamVerifier is the Verifier counterpart of streamSigner
+type streamVerifier interface {
+	newVerifyStream(interface{}) (verifyStream, error)
+}
+
+// digestSignStream feeds the signing input to a hash function, and
+// signs the resulting digest
+type digestSignStream struct {
+	hash.Hash
+	sign func([]byte) ([]byte, error)
+}
+
+func (s *digestSignStream) Sign() ([]byte, error) {
+	return s.sign(s.Sum(nil))
+}
+
+type digestVerifyStream struct {
+	hash.Hash
+	verify func([]byte, []byte) error
+}
+
+func (s *digestVerifyStream) Verify(signature []byte) error {
+	return s.verify(s.Sum(nil), signature)
+}
+
+// writePayload copies the payload from src to dst. If b64 is true,
+// the payload is base64 encoded while being copied
+func writePayload(dst io.Writer, src io.Reader, b64 bool) error {
+	if !b64 {
+		if _, err := io.Copy(dst, src); err != nil {
+			return fmt.Errorf(`failed to read payload: %w`, err)
+		}
+		return nil
+	}
+
+	enc := base64.NewEncoder(dst)
+	if _, err := io.Copy(enc, src); err != nil {
+		return fmt.Errorf(`failed to read payload: %w`, err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf(`failed to flush base64 encoded payload: %w`, err)
+	}
+	return nil
+}
+
+// signReader is the implementation of `jws.Sign()` when
+// `jws.WithDetachedPayloadReader()` is specified.
+func signReader(ctx context.Context, src io.Reader, format int, signers []*payloadSigner) ([]byte, error) {
+	var result Message
+	result.detached = true
+	result.signatures = make([]*Signature, 0, len(signers))
+
+	streams := make([]signStream, 0, len(signers))
+	writers := make([]io.Writer, 0, len(signers))
+	var b64 bool
+	for i, signer := range signers {
+		ss, ok := signer.signer.(streamSigner)
+		if !ok {
+			return nil, fmt.Errorf(`jws.Sign: algorithm %q does not support streaming payloads`, signer.Algorithm())
+		}
+
+		protected := signer.ProtectedHeader()
+		if protected == nil {
+			protected = NewHeaders()
+		}
+
+		if err := protected.Set(AlgorithmKey, signer.Algorithm()); err != nil {
+			return nil, fmt.Errorf(`failed to set "alg" header: %w`, err)
+		}
+
+		if key, ok := signer.key.(jwk.Key); ok {
+			if kid := key.KeyID(); kid != "" {
+				if err := protected.Set(KeyIDKey, kid); err != nil {
+					return nil, fmt.Errorf(`failed to set "kid" header: %w`, err)
+				}
+			}
+		}
+
+		if i == 0 {
+			b64 = getB64Value(protected)
+		} else if b64 != getB64Value(protected) {
+			return nil, fmt.Errorf(`jws.Sign: b64 value must be the same for all signatures`)
+		}
+
+		hdrbuf, err := json.Marshal(protected)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to marshal headers for signer #%d: %w`, i, err)
+		}
+
+		stream, err := ss.newSignStream(bindContext(ctx, signer.key))
+		if err != nil {
+			return nil, fmt.Errorf(`failed to generate signature for signer #%d (alg=%s): %w`, i, signer.Algorithm(), err)
+		}
+
+		if _, err := io.WriteString(stream, base64.EncodeToString(hdrbuf)+"."); err != nil {
+			return nil, fmt.Errorf(`failed to write signing input for signer #%d: %w`, i, err)
+		}
+
+		streams = append(streams, stream)
+		writers = append(writers, stream)
+		result.signatures = append(result.signatures, &Signature{
+			headers:   signer.PublicHeader(),
+			protected: protected,
+			detached:  true,
+		})
+	}
+
+	// All signatures are computed in a single pass over the payload
+	if err := writePayload(io.MultiWriter(writers...), src, b64); err != nil {
+		return nil, fmt.Errorf(`jws.Sign: %w`, err)
+	}
+
+	for i, stream := range streams {
+		signature, err := stream.Sign()
+		if err != nil {
+			return nil, fmt.Errorf(`failed to generate signature for signer #%d (alg=%s): %w`, i, signers[i].Algorithm(), err)
+		}
+		result.signatures[i].signature = signature
+	}
+
+	switch format {
+	case fmtJSON:
+		return json.Marshal(result)
+	case fmtJSONPretty:
+		return json.MarshalIndent(result, "", "  ")
+	case fmtCompact:
+		return Compact(&result, WithDetached(true))
+	default:
+		return nil, fmt.Errorf(`jws.Sign: invalid serialization format`)
+	}
+}
+
+type verifyCandidate struct {
+	alg    jwa.SignatureAlgorithm
+	key    interface{}
+	index  int
+	sig    *Signature
+	stream verifyStream
+}
+
+// verifyReader is the implementation of `jws.Verify()` when
+// `jws.WithDetachedPayloadReader()` is specified.
+func verifyReader(vctx *verifyCtx, src io.Reader) error {
+	msg := vctx.msg
+	if len(msg.payload) != 0 {
+		return fmt.Errorf(`can't specify detached payload for JWS with payload`)
+	}
+
+	// Since we can only read the payload once, we need to prepare
+	// all of the signature/key combinations before we start reading
+	var candidates []*verifyCandidate
+	var writers []io.Writer
+	for i, sig := range msg.signatures {
+		if !vctx.checkSignature(i, sig) {
+			continue
+		}
+
+		encodedProtectedHeader, err := encodeProtectedHeader(sig)
+		if err != nil {
+			return fmt.Errorf(`failed to marshal "protected" for signature #%d: %w`, i+1, err)
+		}
+
+		pairs, err := vctx.keys(i, sig)
+		if err != nil {
+			return err
+		}
+
+		for _, pair := range pairs {
+			//nolint:forcetypeassert
+			alg := pair.alg.(jwa.SignatureAlgorithm)
+			verifier, err := NewVerifier(alg)
+			if err != nil {
+				return fmt.Errorf(`failed to create verifier for algorithm %q: %w`, alg, err)
+			}
+
+			sv, ok := verifier.(streamVerifier)
+			if !ok {
+				// this key can't be used with a payload reader. skip
+				vctx.reject(i, fmt.Errorf(`key for signature #%d: algorithm %q does not support streaming payloads`, i+1, alg))
+				continue
+			}
+
+			stream, err := sv.newVerifyStream(pair.key)
+			if err != nil {
+				// this key can't be used for this algorithm. skip
+				vctx.fail(i, err)
+				continue
+			}
+
+			if _, err := io.WriteString(stream, encodedProtectedHeader+"."); err != nil {
+				return fmt.Errorf(`failed to write signing input: %w`, err)
+			}
+
+			candidates = append(candidates, &verifyCandidate{
+				alg:    alg,
+				key:    pair.key,
+				index:  i,
+				sig:    sig,
+				stream: stream,
+			})
+			writers = append(writers, stream)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	if err := writePayload(io.MultiWriter(writers...), src, msg.b64); err != nil {
+		return err
+	}
+
+	for _, candidate := range candidates {
+		if vctx.done() {
+			break
+		}
+
+		result := vctx.result.signatures[candidate.index]
+		if result.Verified() {
+			continue
+		}
+
+		if err := candidate.stream.Verify(candidate.sig.signature); err != nil {
+			vctx.fail(candidate.index, err)
+			continue
+		}
+		result.setVerified(candidate.alg, candidate.key)
+	}
+	return nil
+}
@@ -38,7 +38,7 @@ func init() {
 		}(alg))
 	}
 
-	for _, alg := range []jwa.SignatureAlgorithm{jwa.ES256, jwa.ES384, jwa.ES512, jwa.ES256K} {
+	for _, alg := range []jwa.SignatureAlgorithm{jwa.ES256, jwa.ES384, jwa.ES512, jwa.ESP256, jwa.ESP384, jwa.ESP512, jwa.ES256K, jwa.ESB256, jwa.ESB384, jwa.ESB512} {
 		RegisterVerifier(alg, func(alg jwa.SignatureAlgorithm) VerifierFactory {
 			return VerifierFactoryFn(func() (Verifier, error) {
 				return newECDSAVerifier(alg), nil
@@ -54,9 +54,21 @@ func init() {
 		}(alg))
 	}
 
-	RegisterVerifier(jwa.EdDSA, VerifierFactoryFn(func() (Verifier, error) {
-		return newEdDSAVerifier(), nil
-	}))
+	for _, alg := range []jwa.SignatureAlgorithm{jwa.EdDSA, jwa.EdDSAEd25519, jwa.EdDSAEd448} {
+		RegisterVerifier(alg, func(alg jwa.SignatureAlgorithm) VerifierFactory {
+			return VerifierFactoryFn(func() (Verifier, error) {
+				return newEdDSAVerifier(alg), nil
+			})
+		}(alg))
+	}
+
+	for _, alg := range []jwa.SignatureAlgorithm{jwa.MLDSA44, jwa.MLDSA65, jwa.MLDSA87} {
+		RegisterVerifier(alg, func(alg jwa.SignatureAlgorithm) VerifierFactory {
+			return VerifierFactoryFn(func() (Verifier, error) {
+				return newMLDSAVerifier(alg), nil
+			})
+		}(alg))
+	}
 }
 
 // NewVerifier creates a verifier that signs payloads using the given signature algorithm.
@@ -0,0 +1,283 @@
+package jws
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"reflect"
+
+	"github.com/lestrrat-go/blackmagic"
+	"github.com/sjwl/jwx/v2/internal/keystrength"
+	"github.com/sjwl/jwx/v2/jwa"
+	"github.com/sjwl/jwx/v2/jwk"
+)
+
+// requireAllSignatures is the value stored by `jws.WithAllSignaturesRequired()`
+const requireAllSignatures = -1
+
+// SignatureResult describes the outcome of verifying a single
+// signature in a JWS message.
+type SignatureResult struct {
+	signature *Signature
+	alg       jwa.SignatureAlgorithm
+	key       interface{}
+	kid       string
+	err       error
+}
+
+// Signature returns the signature that this result describes
+func (r *SignatureResult) Signature() *Signature {
+	return r.signature
+}
+
+// Verified returns true if the signature was successfully verified
+func (r *SignatureResult) Verified() bool {
+	return r.err == nil
+}
+
+// Algorithm returns the algorithm that was used to verify the signature.
+// If the signature could not be verified, the return value is empty.
+func (r *SignatureResult) Algorithm() jwa.SignatureAlgorithm {
+	return r.alg
+}
+
+// Key returns the key that was used to verify the signature.
+// If the signature could not be verified, the return value is nil.
+func (r *SignatureResult) Key() interface{} {
+	return r.key
+}
+
+// KeyID returns the key ID of the key that was used to verify the
+// signature. If the key is not a `jwk.Key` with a "kid", the "kid"
+// header of the signature is used instead.
+func (r *SignatureResult) KeyID() string {
+	return r.kid
+}
+
+// Err returns the reason why the signature could not be verified.
+// If the signature was verified, the return value is nil.
+func (r *SignatureResult) Err() error {
+	return r.err
+}
+
+func (r *SignatureResult) setVerified(alg jwa.SignatureAlgorithm, key interface{}) {
+	r.alg = alg
+	r.key = key
+	r.err = nil
+	if jwkKey, ok := key.(jwk.Key); ok {
+		r.kid = jwkKey.KeyID()
+	}
+	if r.kid == "" {
+		r.kid = r.signature.ProtectedHeaders().KeyID()
+	}
+	if r.kid == "" && r.signature.PublicHeaders() != nil {
+		r.kid = r.signature.PublicHeaders().KeyID()
+	}
+}
+
+// VerifyResult describes the outcome of `jws.Verify()` for each of
+// the signatures in a JWS message. Use `jws.WithVerifyResult()` to
+// obtain it.
+type VerifyResult struct {
+	signatures []*SignatureResult
+}
+
+// Signatures returns the results for each signature, in the same
+// order as the signatures appear in the message.
+func (r *VerifyResult) Signatures() []*SignatureResult {
+	return r.signatures
+}
+
+// VerifiedCount returns the number of signatures that were verified
+func (r *VerifyResult) VerifiedCount() int {
+	var count int
+	for _, sig := range r.signatures {
+		if sig.Verified() {
+			count++
+		}
+	}
+	return count
+}
+
+// signerCount returns the number of distinct keys that verified at least
+// one of the signatures. A key that verified several signatures (e.g.
+// copies of the same signature) is only counted once
+func (r *VerifyResult) signerCount() int {
+	signers := make(map[interface{}]struct{})
+	for _, sig := range r.signatures {
+		if sig.Verified() {
+			signers[signerIdentity(sig.key)] = struct{}{}
+		}
+	}
+	return len(signers)
+}
+
+// signerIdentity returns a value that identifies the key, regardless of
+// its representation: it is the thumbprint of the public key when it can
+// be computed. Otherwise pointers are compared, and all other keys are
+// treated as a single key
+func signerIdentity(key interface{}) interface{} {
+	if pubkey, err := jwk.PublicKeyOf(key); err == nil {
+		if thumbprint, err := pubkey.Thumbprint(crypto.SHA256); err == nil {
+			return string(thumbprint)
+		}
+	}
+	if key != nil && reflect.TypeOf(key).Kind() == reflect.Ptr {
+		return key
+	}
+	return nil
+}
+
+// verifyCtx holds the state that is shared by the regular and the
+// streaming implementations of `jws.Verify()`
+type verifyCtx struct {
+	ctx          context.Context
+	msg          *Message
+	keyProviders []KeyProvider
+	policies     algorithmPolicies
+	keyStrength  *jwa.KeyStrengthPolicy
+	required     int
+	result       VerifyResult
+
+	// exhaustive is true if all signatures must be verified, even after
+	// one of them has been verified
+	exhaustive bool
+
+	// lastError is the last reason for rejecting a signature or a key
+	// before verification took place
+	lastError error
+}
+
+var errSignatureNotVerified = fmt.Errorf(`could not verify signature using any of the keys`)
+
+func (vctx *verifyCtx) init() {
+	vctx.result.signatures = make([]*SignatureResult, len(vctx.msg.signatures))
+	for i, sig := range vctx.msg.signatures {
+		vctx.result.signatures[i] = &SignatureResult{
+			signature: sig,
+			err:       errSignatureNotVerified,
+		}
+	}
+}
+
+// reject records the reason why signature #i (or a key for it) was
+// rejected before verification
+func (vctx *verifyCtx) reject(i int, err error) {
+	vctx.lastError = err
+	vctx.result.signatures[i].err = err
+}
+
+// fail records the reason why signature #i could not be verified
+// using a key
+func (vctx *verifyCtx) fail(i int, err error) {
+	vctx.result.signatures[i].err = fmt.Errorf(`failed to verify signature #%d: %w`, i+1, err)
+}
+
+// checkSignature checks if signature #i may be verified at all
+func (vctx *verifyCtx) checkSignature(i int, sig *Signature) bool {
+	// A signature that lists extensions we do not understand must
+	// not be considered valid (RFC7515 Section 4.1.11). Other
+	// signatures in the same message may still be verified.
+	if err := verifyCritical(sig); err != nil {
+		vctx.reject(i, fmt.Errorf(`invalid "crit" header in signature #%d: %w`, i+1, err))
+		return false
+	}
+
+	if err := vctx.policies.Check(sig.ProtectedHeaders().Algorithm()); err != nil {
+		vctx.reject(i, fmt.Errorf(`invalid "alg" header in signature #%d: %w`, i+1, err))
+		return false
+	}
+	return true
+}
+
+// keys returns the keys that may be used to verify signature #i
+func (vctx *verifyCtx) keys(i int, sig *Signature) ([]algKeyPair, error) {
+	var list []algKeyPair
+	for j, kp := range vctx.keyProviders {
+		var sink algKeySink
+		if err := kp.FetchKeys(vctx.ctx, &sink, sig, vctx.msg); err != nil {
+			return nil, fmt.Errorf(`key provider %d failed: %w`, j, err)
+		}
+
+		for _, pair := range sink.list {
+			// alg is converted here because pair.alg is of type jwa.KeyAlgorithm.
+			// this may seem ugly, but we're trying to avoid declaring separate
+			// structs for `alg jwa.KeyAlgorithm` and `alg jwa.SignatureAlgorithm`
+			//nolint:forcetypeassert
+			alg := pair.alg.(jwa.SignatureAlgorithm)
+			if err := vctx.policies.Check(alg); err != nil {
+				vctx.reject(i, fmt.Errorf(`key for signature #%d: %w`, i+1, err))
+				continue
+			}
+			if err := keystrength.Check(vctx.keyStrength, alg, pair.key); err != nil {
+				vctx.reject(i, fmt.Errorf(`key for signature #%d: %w`, i+1, err))
+				continue
+			}
+			list = append(list, pair)
+		}
+	}
+	return list, nil
+}
+
+// done returns true if no more signatures need to be verified
+func (vctx *verifyCtx) done() bool {
+	return !vctx.exhaustive && vctx.result.VerifiedCount() > 0
+}
+
+// finish decides whether enough signatures have been verified, and
+// populates the values requested by the user
+func (vctx *verifyCtx) finish(keyUsed interface{}, dst *Message, result *VerifyResult) error {
+	if result != nil {
+		*result = vctx.result
+	}
+
+	verified := vctx.result.VerifiedCount()
+	total := len(vctx.result.signatures)
+	required := vctx.required
+	signers := verified
+	switch required {
+	case requireAllSignatures:
+		required = total
+	case 1:
+	default:
+		// Signatures only count towards the requirement if they were
+		// verified using distinct keys. Otherwise a single valid
+		// signature could be copied to satisfy it
+		signers = vctx.result.signerCount()
+	}
+
+	if verified == 0 || verified < required || signers < required {
+		if vctx.required == 1 {
+			if vctx.lastError != nil {
+				return fmt.Errorf(`could not verify message using any of the signatures or keys: %w`, vctx.lastError)
+			}
+			return fmt.Errorf(`could not verify message using any of the signatures or keys`)
+		}
+
+		reason := fmt.Sprintf(`only %d of %d signatures could be verified (%d required)`, verified, total, required)
+		if signers < verified {
+			reason = fmt.Sprintf(`only %d of %d signatures could be verified, using %d distinct keys (%d required)`, verified, total, signers, required)
+		}
+		if vctx.lastError != nil {
+			return fmt.Errorf(`%s: %w`, reason, vctx.lastError)
+		}
+		return fmt.Errorf(`%s`, reason)
+	}
+
+	if keyUsed != nil {
+		for _, r := range vctx.result.signatures {
+			if !r.Verified() {
+				continue
+			}
+			if err := blackmagic.AssignIfCompatible(keyUsed, r.key); err != nil {
+				return fmt.Errorf(`failed to assign used key (%T) to %T: %w`, r.key, keyUsed, err)
+			}
+			break
+		}
+	}
+
+	if dst != nil {
+		*(dst) = *vctx.msg
+	}
+	return nil
+}
@@ -9,10 +9,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sync"
 	"sync/atomic"
 
+	"github.com/lestrrat-go/option"
 	"github.com/sjwl/jwx/v2"
 	"github.com/sjwl/jwx/v2/internal/json"
+	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/sjwl/jwx/v2/jws"
 	"github.com/sjwl/jwx/v2/jwt/internal/types"
 )
@@ -26,6 +29,9 @@ func ErrInvalidJWT() error {
 	return errInvalidJWT
 }
 
+var muGlobalPolicy sync.RWMutex
+var globalPolicy *jwa.AlgorithmPolicy
+
 // Settings controls global settings that are specific to JWTs.
 func Settings(options ...GlobalOption) {
 	var flattenAudienceBool bool
@@ -38,6 +44,10 @@ func Settings(options ...GlobalOption) {
 		switch option.Ident() {
 		case identFlattenAudience{}:
 			flattenAudienceBool = option.Value().(bool)
+		case identAlgorithmPolicy{}:
+			muGlobalPolicy.Lock()
+			globalPolicy = option.Value().(*jwa.AlgorithmPolicy)
+			muGlobalPolicy.Unlock()
 		case identNumericDateParsePedantic{}:
 			parsePedantic = option.Value().(bool)
 		case identNumericDateParsePrecision{}:
@@ -178,6 +188,8 @@ func parseBytes(data []byte, options ...ParseOption) (Token, error) {
 	verification := true
 
 	var verifyOpts []Option
+	var policyOpts []Option
+	var setPolicy bool
 	for _, o := range options {
 		if v, ok := o.(ValidateOption); ok {
 			ctx.validateOpts = append(ctx.validateOpts, v)
@@ -188,6 +200,11 @@ func parseBytes(data []byte, options ...ParseOption) (Token, error) {
 		switch o.Ident() {
 		case identKey{}, identKeySet{}, identVerifyAuto{}, identKeyProvider{}:
 			verifyOpts = append(verifyOpts, o)
+		case identAllowedAlgorithms{}:
+			policyOpts = append(policyOpts, o)
+		case identAlgorithmPolicy{}:
+			policyOpts = append(policyOpts, o)
+			setPolicy = true
 		case identToken{}:
 			token, ok := o.Value().(Token)
 			if !ok {
@@ -215,6 +232,18 @@ func parseBytes(data []byte, options ...ParseOption) (Token, error) {
 	}
 
 	if lvo > 0 {
+		if !setPolicy {
+			muGlobalPolicy.RLock()
+			policy := globalPolicy
+			muGlobalPolicy.RUnlock()
+			// The global policy for JWTs is enforced in addition to the
+			// one set via jws.Settings(), rather than replacing it
+			if policy != nil {
+				policyOpts = append(policyOpts, &parseOption{option.New(identAllowedAlgorithms{}, jws.WithAdditionalAlgorithmPolicy(policy))})
+			}
+		}
+		verifyOpts = append(verifyOpts, policyOpts...)
+
 		converted, err := toVerifyOptions(verifyOpts...)
 		if err != nil {
 			return nil, fmt.Errorf(`jwt.Parse: failed to convert options into jws.VerifyOption: %w`, err)
@@ -26,6 +26,7 @@ import (
 	"github.com/sjwl/jwx/v2/jwe"
 	"github.com/sjwl/jwx/v2/jwt/internal/types"
 
+	"github.com/sjwl/jwx/v2/internal/mldsa"
 	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/sjwl/jwx/v2/jwk"
 	"github.com/sjwl/jwx/v2/jws"
@@ -672,6 +673,46 @@ func TestSignJWK(t *testing.T) {
 	assert.Len(t, signatures, 1)
 }
 
+func TestSignMLDSA(t *testing.T) {
+	t.Parallel()
+	key, err := jwxtest.GenerateMLDSAJwk(mldsa.MLDSA65())
+	require.NoError(t, err, `jwxtest.GenerateMLDSAJwk should succeed`)
+	require.NoError(t, key.Set(jwk.KeyIDKey, "mldsa"), `key.Set should succeed`)
+
+	tok, err := jwt.NewBuilder().
+		Issuer(`github.com/sjwl/jwx`).
+		Subject(`mldsa`).
+		Build()
+	require.NoError(t, err, `jwt.NewBuilder should succeed`)
+
+	signed, err := jwt.Sign(tok, jwt.WithKey(key.Algorithm(), key))
+	require.NoError(t, err, `jwt.Sign should succeed`)
+
+	headers, err := getJWTHeaders(signed)
+	require.NoError(t, err, `getJWTHeaders should succeed`)
+	require.Equal(t, jwa.MLDSA65, headers.Algorithm())
+
+	pubkey, err := jwk.PublicKeyOf(key)
+	require.NoError(t, err, `jwk.PublicKeyOf should succeed`)
+	set := jwk.NewSet()
+	require.NoError(t, set.AddKey(pubkey), `set.AddKey should succeed`)
+
+	parsed, err := jwt.Parse(signed, jwt.WithKeySet(set))
+	require.NoError(t, err, `jwt.Parse should succeed`)
+	require.Equal(t, tok.Subject(), parsed.Subject())
+
+	// a key for a different parameter set must not verify the token
+	other, err := jwxtest.GenerateMLDSAJwk(mldsa.MLDSA44())
+	require.NoError(t, err, `jwxtest.GenerateMLDSAJwk should succeed`)
+	require.NoError(t, other.Set(jwk.KeyIDKey, "mldsa"), `other.Set should succeed`)
+	otherpub, err := jwk.PublicKeyOf(other)
+	require.NoError(t, err, `jwk.PublicKeyOf should succeed`)
+	otherset := jwk.NewSet()
+	require.NoError(t, otherset.AddKey(otherpub), `otherset.AddKey should succeed`)
+	_, err = jwt.Parse(signed, jwt.WithKeySet(otherset))
+	require.Error(t, err, `jwt.Parse should fail`)
+}
+
 func getJWTHeaders(jwt []byte) (jws.Headers, error) {
 	msg, err := jws.Parse(jwt)
 	if err != nil {
@@ -1667,3 +1708,36 @@ func TestGH850(t *testing.T) {
 	_, err := jwt.Parse([]byte(testToken), jwt.WithVerify(false))
 	require.True(t, errors.Is(err, jwt.ErrInvalidJWT()))
 }
+
+func TestAlgorithmPolicy(t *testing.T) {
+	key := []byte(`abracadabra`)
+	signed, err := jwt.Sign(jwt.New(), jwt.WithKey(jwa.HS256, key))
+	require.NoError(t, err, `jwt.Sign should succeed`)
+
+	_, err = jwt.Parse(signed, jwt.WithKey(jwa.HS256, key), jwt.WithAllowedAlgorithms(jwa.RS256))
+	require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed())
+
+	_, err = jwt.Parse(signed, jwt.WithKey(jwa.HS256, key), jwt.WithAllowedAlgorithms(jwa.HS256))
+	require.NoError(t, err, `jwt.Parse should succeed`)
+
+	t.Run("global policy", func(t *testing.T) {
+		jwt.Settings(jwt.WithAlgorithmPolicy(jwa.NewAlgorithmPolicy().Allow(jwa.ES256)))
+		defer jwt.Settings(jwt.WithAlgorithmPolicy(nil))
+
+		_, err := jwt.Parse(signed, jwt.WithKey(jwa.HS256, key))
+		require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed())
+
+		_, err = jwt.Parse(signed, jwt.WithKey(jwa.HS256, key), jwt.WithAlgorithmPolicy(jwa.NewAlgorithmPolicy()))
+		require.NoError(t, err, `jwt.Parse should succeed`)
+	})
+	t.Run("global policies for jws and jwt", func(t *testing.T) {
+		jws.Settings(jws.WithAlgorithmPolicy(jwa.NewAlgorithmPolicy().Allow(jwa.ES256)))
+		defer jws.Settings(jws.WithAlgorithmPolicy(nil))
+		jwt.Settings(jwt.WithAlgorithmPolicy(jwa.NewAlgorithmPolicy().Allow(jwa.HS256, jwa.ES256)))
+		defer jwt.Settings(jwt.WithAlgorithmPolicy(nil))
+
+		// the stricter policy for jws is still enforced
+		_, err := jwt.Parse(signed, jwt.WithKey(jwa.HS256, key))
+		require.ErrorIs(t, err, jwa.ErrAlgorithmNotAllowed())
+	})
+}
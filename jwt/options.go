@@ -89,9 +89,11 @@ func toVerifyOptions(options ...Option) ([]jws.VerifyOption, error) {
 			}
 
 			voptions = append(voptions, jws.WithKeySet(wks.set, wkssoptions...))
-		case identVerifyAuto{}:
-			// this one doesn't need conversion. just get the stored option
+		case identVerifyAuto{}, identAllowedAlgorithms{}:
+			// these don't need conversion. just get the stored option
 			voptions = append(voptions, option.Value().(jws.VerifyOption))
+		case identAlgorithmPolicy{}:
+			voptions = append(voptions, jws.WithAlgorithmPolicy(option.Value().(*jwa.AlgorithmPolicy)))
 		case identKeyProvider{}:
 			kp, ok := option.Value().(jws.KeyProvider)
 			if !ok {
@@ -294,3 +296,11 @@ func WithMinDelta(dur time.Duration, c1, c2 string) ValidateOption {
 func WithVerifyAuto(f jwk.Fetcher, options ...jwk.FetchOption) ParseOption {
 	return &parseOption{option.New(identVerifyAuto{}, jws.WithVerifyAuto(f, options...))}
 }
+
+// WithAllowedAlgorithms specifies the signature algorithms that may be
+// used to verify a JWT. It is passed down to `jws.Verify()` via
+// `jws.WithAllowedAlgorithms()`: tokens signed using any other algorithm
+// are rejected before any cryptographic operation takes place.
+func WithAllowedAlgorithms(algs ...jwa.SignatureAlgorithm) ParseOption {
+	return &parseOption{option.New(identAllowedAlgorithms{}, jws.WithAllowedAlgorithms(algs...))}
+}
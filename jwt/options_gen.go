@@ -7,9 +7,10 @@ import (
 	"io/fs"
 	"time"
 
+	"github.com/lestrrat-go/option"
+	"github.com/sjwl/jwx/v2/jwa"
 	"github.com/sjwl/jwx/v2/jwe"
 	"github.com/sjwl/jwx/v2/jws"
-	"github.com/lestrrat-go/option"
 )
 
 type Option = option.Interface
@@ -38,6 +39,25 @@ type globalOption struct {
 
 func (*globalOption) globalOption() {}
 
+// GlobalParseOption describes an Option that can be passed to either `Settings()`
+// or `jwt.Parse()`
+type GlobalParseOption interface {
+	Option
+	globalOption()
+	parseOption()
+	readFileOption()
+}
+
+type globalParseOption struct {
+	Option
+}
+
+func (*globalParseOption) globalOption() {}
+
+func (*globalParseOption) parseOption() {}
+
+func (*globalParseOption) readFileOption() {}
+
 // ParseOption describes an Option that can be passed to `jwt.Parse()`.
 // ParseOption also implements ReadFileOption, therefore it may be
 // safely pass them to `jwt.ReadFile()`
@@ -123,6 +143,8 @@ func (*validateOption) readFileOption() {}
 func (*validateOption) validateOption() {}
 
 type identAcceptableSkew struct{}
+type identAlgorithmPolicy struct{}
+type identAllowedAlgorithms struct{}
 type identClock struct{}
 type identContext struct{}
 type identEncryptOption struct{}
@@ -146,6 +168,14 @@ func (identAcceptableSkew) String() string {
 	return "WithAcceptableSkew"
 }
 
+func (identAlgorithmPolicy) String() string {
+	return "WithAlgorithmPolicy"
+}
+
+func (identAllowedAlgorithms) String() string {
+	return "WithAllowedAlgorithms"
+}
+
 func (identClock) String() string {
 	return "WithClock"
 }
@@ -224,6 +254,18 @@ func WithAcceptableSkew(v time.Duration) ValidateOption {
 	return &validateOption{option.New(identAcceptableSkew{}, v)}
 }
 
+// WithAlgorithmPolicy specifies the `jwa.AlgorithmPolicy` that decides which
+// signature algorithms may be used to verify a JWT. It is passed down to
+// `jws.Verify()` via `jws.WithAlgorithmPolicy()`.
+//
+// When passed to `jwt.Settings()`, the policy is used by all subsequent calls
+// to `jwt.Parse()`, in addition to the policy set via `jws.Settings()`: the
+// algorithm must be allowed by both. When passed to `jwt.Parse()`, the policy
+// replaces both global policies for that call.
+func WithAlgorithmPolicy(v *jwa.AlgorithmPolicy) GlobalParseOption {
+	return &globalParseOption{option.New(identAlgorithmPolicy{}, v)}
+}
+
 // WithClock specifies the `Clock` to be used when verifying
 // exp and nbf claims.
 func WithClock(v Clock) ValidateOption {
@@ -10,6 +10,8 @@ import (
 
 func TestOptionIdent(t *testing.T) {
 	require.Equal(t, "WithAcceptableSkew", identAcceptableSkew{}.String())
+	require.Equal(t, "WithAlgorithmPolicy", identAlgorithmPolicy{}.String())
+	require.Equal(t, "WithAllowedAlgorithms", identAllowedAlgorithms{}.String())
 	require.Equal(t, "WithClock", identClock{}.String())
 	require.Equal(t, "WithContext", identContext{}.String())
 	require.Equal(t, "WithEncryptOption", identEncryptOption{}.String())
@@ -22,9 +22,10 @@ func main() {
 func _main() error {
 	typs := []typ{
 		{
-			name:     `CompressionAlgorithm`,
-			comment:  `CompressionAlgorithm represents the compression algorithms as described in https://tools.ietf.org/html/rfc7518#section-7.3`,
-			filename: `compression_gen.go`,
+			name:        `CompressionAlgorithm`,
+			registrable: true,
+			comment:     `CompressionAlgorithm represents the compression algorithms as described in https://tools.ietf.org/html/rfc7518#section-7.3`,
+			filename:    `compression_gen.go`,
 			elements: []element{
 				{
 					name:    `NoCompress`,
@@ -39,9 +40,10 @@ func _main() error {
 			},
 		},
 		{
-			name:     `ContentEncryptionAlgorithm`,
-			comment:  `ContentEncryptionAlgorithm represents the various encryption algorithms as described in https://tools.ietf.org/html/rfc7518#section-5`,
-			filename: `content_encryption_gen.go`,
+			name:        `ContentEncryptionAlgorithm`,
+			registrable: true,
+			comment:     `ContentEncryptionAlgorithm represents the various encryption algorithms as described in https://tools.ietf.org/html/rfc7518#section-5`,
+			filename:    `content_encryption_gen.go`,
 			elements: []element{
 				{
 					name:    `A128CBC_HS256`,
@@ -73,6 +75,11 @@ func _main() error {
 					value:   `A256GCM`,
 					comment: `AES-GCM (256)`,
 				},
+				{
+					name:    `XC20P`,
+					value:   `XC20P`,
+					comment: `XChaCha20-Poly1305`,
+				},
 			},
 		},
 		{
@@ -106,12 +113,18 @@ func _main() error {
 					value:   `OKP`,
 					comment: `Octet string key pairs`,
 				},
+				{
+					name:    `AKP`,
+					value:   `AKP`,
+					comment: `Algorithm key pairs (used to represent post-quantum keys such as ML-DSA)`,
+				},
 			},
 		},
 		{
-			name:     `EllipticCurveAlgorithm`,
-			comment:  `EllipticCurveAlgorithm represents the algorithms used for EC keys`,
-			filename: `elliptic_gen.go`,
+			name:        `EllipticCurveAlgorithm`,
+			registrable: true,
+			comment:     `EllipticCurveAlgorithm represents the algorithms used for EC keys`,
+			filename:    `elliptic_gen.go`,
 			elements: []element{
 				{
 					name:    `InvalidEllipticCurve`,
@@ -149,9 +162,10 @@ func _main() error {
 			},
 		},
 		{
-			name:     `SignatureAlgorithm`,
-			comment:  `SignatureAlgorithm represents the various signature algorithms as described in https://tools.ietf.org/html/rfc7518#section-3.1`,
-			filename: `signature_gen.go`,
+			name:        `SignatureAlgorithm`,
+			registrable: true,
+			comment:     `SignatureAlgorithm represents the various signature algorithms as described in https://tools.ietf.org/html/rfc7518#section-3.1`,
+			filename:    `signature_gen.go`,
 			elements: []element{
 				{
 					name:  `NoSignature`,
@@ -202,16 +216,71 @@ func _main() error {
 					value:   "ES512",
 					comment: `ECDSA using P-521 and SHA-512`,
 				},
+				{
+					name:    `ESP256`,
+					value:   "ESP256",
+					comment: `ECDSA using P-256 and SHA-256 (fully-specified)`,
+				},
+				{
+					name:    `ESP384`,
+					value:   "ESP384",
+					comment: `ECDSA using P-384 and SHA-384 (fully-specified)`,
+				},
+				{
+					name:    `ESP512`,
+					value:   "ESP512",
+					comment: `ECDSA using P-521 and SHA-512 (fully-specified)`,
+				},
 				{
 					name:    `ES256K`,
 					value:   "ES256K",
 					comment: `ECDSA using secp256k1 and SHA-256`,
 				},
+				{
+					name:    `ESB256`,
+					value:   "ESB256",
+					comment: `ECDSA using brainpoolP256r1 and SHA-256`,
+				},
+				{
+					name:    `ESB384`,
+					value:   "ESB384",
+					comment: `ECDSA using brainpoolP384r1 and SHA-384`,
+				},
+				{
+					name:    `ESB512`,
+					value:   "ESB512",
+					comment: `ECDSA using brainpoolP512r1 and SHA-512`,
+				},
 				{
 					name:    `EdDSA`,
 					value:   `EdDSA`,
 					comment: `EdDSA signature algorithms`,
 				},
+				{
+					name:    `EdDSAEd25519`,
+					value:   `Ed25519`,
+					comment: `EdDSA using the Ed25519 parameter set (fully-specified)`,
+				},
+				{
+					name:    `EdDSAEd448`,
+					value:   `Ed448`,
+					comment: `EdDSA using the Ed448 parameter set (fully-specified)`,
+				},
+				{
+					name:    `MLDSA44`,
+					value:   `ML-DSA-44`,
+					comment: `ML-DSA using the ML-DSA-44 parameter set (FIPS 204)`,
+				},
+				{
+					name:    `MLDSA65`,
+					value:   `ML-DSA-65`,
+					comment: `ML-DSA using the ML-DSA-65 parameter set (FIPS 204)`,
+				},
+				{
+					name:    `MLDSA87`,
+					value:   `ML-DSA-87`,
+					comment: `ML-DSA using the ML-DSA-87 parameter set (FIPS 204)`,
+				},
 				{
 					name:    `PS256`,
 					value:   `PS256`,
@@ -230,9 +299,10 @@ func _main() error {
 			},
 		},
 		{
-			name:     `KeyEncryptionAlgorithm`,
-			comment:  `KeyEncryptionAlgorithm represents the various encryption algorithms as described in https://tools.ietf.org/html/rfc7518#section-4.1`,
-			filename: `key_encryption_gen.go`,
+			name:        `KeyEncryptionAlgorithm`,
+			registrable: true,
+			comment:     `KeyEncryptionAlgorithm represents the various encryption algorithms as described in https://tools.ietf.org/html/rfc7518#section-4.1`,
+			filename:    `key_encryption_gen.go`,
 			elements: []element{
 				{
 					name:    `RSA1_5`,
@@ -249,6 +319,16 @@ func _main() error {
 					value:   "RSA-OAEP-256",
 					comment: `RSA-OAEP-SHA256`,
 				},
+				{
+					name:    `RSA_OAEP_384`,
+					value:   "RSA-OAEP-384",
+					comment: `RSA-OAEP-SHA384`,
+				},
+				{
+					name:    `RSA_OAEP_512`,
+					value:   "RSA-OAEP-512",
+					comment: `RSA-OAEP-SHA512`,
+				},
 				{
 					name:    `A128KW`,
 					value:   "A128KW",
@@ -289,6 +369,31 @@ func _main() error {
 					value:   "ECDH-ES+A256KW",
 					comment: `ECDH-ES + AES key wrap (256)`,
 				},
+				{
+					name:    `ECDH_ES_XC20PKW`,
+					value:   "ECDH-ES+XC20PKW",
+					comment: `ECDH-ES + XChaCha20-Poly1305 key wrap`,
+				},
+				{
+					name:    `ECDH_1PU`,
+					value:   "ECDH-1PU",
+					comment: `ECDH-1PU`,
+				},
+				{
+					name:    `ECDH_1PU_A128KW`,
+					value:   "ECDH-1PU+A128KW",
+					comment: `ECDH-1PU + AES key wrap (128)`,
+				},
+				{
+					name:    `ECDH_1PU_A192KW`,
+					value:   "ECDH-1PU+A192KW",
+					comment: `ECDH-1PU + AES key wrap (192)`,
+				},
+				{
+					name:    `ECDH_1PU_A256KW`,
+					value:   "ECDH-1PU+A256KW",
+					comment: `ECDH-1PU + AES key wrap (256)`,
+				},
 				{
 					name:    `A128GCMKW`,
 					value:   "A128GCMKW",
@@ -304,6 +409,11 @@ func _main() error {
 					value:   "A256GCMKW",
 					comment: `AES-GCM key wrap (256)`,
 				},
+				{
+					name:    `XC20PKW`,
+					value:   "XC20PKW",
+					comment: `XChaCha20-Poly1305 key wrap`,
+				},
 				{
 					name:    `PBES2_HS256_A128KW`,
 					value:   "PBES2-HS256+A128KW",
@@ -319,6 +429,76 @@ func _main() error {
 					value:   "PBES2-HS512+A256KW",
 					comment: `PBES2 + HMAC-SHA512 + AES key wrap (256)`,
 				},
+				{
+					name:    `HPKE_0`,
+					value:   "HPKE-0",
+					comment: `HPKE integrated encryption (P-256, HKDF-SHA256, AES-128-GCM)`,
+				},
+				{
+					name:    `HPKE_0_KE`,
+					value:   "HPKE-0-KE",
+					comment: `HPKE key encryption (P-256, HKDF-SHA256, AES-128-GCM)`,
+				},
+				{
+					name:    `HPKE_1`,
+					value:   "HPKE-1",
+					comment: `HPKE integrated encryption (P-384, HKDF-SHA384, AES-256-GCM)`,
+				},
+				{
+					name:    `HPKE_1_KE`,
+					value:   "HPKE-1-KE",
+					comment: `HPKE key encryption (P-384, HKDF-SHA384, AES-256-GCM)`,
+				},
+				{
+					name:    `HPKE_2`,
+					value:   "HPKE-2",
+					comment: `HPKE integrated encryption (P-521, HKDF-SHA512, AES-256-GCM)`,
+				},
+				{
+					name:    `HPKE_2_KE`,
+					value:   "HPKE-2-KE",
+					comment: `HPKE key encryption (P-521, HKDF-SHA512, AES-256-GCM)`,
+				},
+				{
+					name:    `HPKE_3`,
+					value:   "HPKE-3",
+					comment: `HPKE integrated encryption (X25519, HKDF-SHA256, AES-128-GCM)`,
+				},
+				{
+					name:    `HPKE_3_KE`,
+					value:   "HPKE-3-KE",
+					comment: `HPKE key encryption (X25519, HKDF-SHA256, AES-128-GCM)`,
+				},
+				{
+					name:    `HPKE_4`,
+					value:   "HPKE-4",
+					comment: `HPKE integrated encryption (X25519, HKDF-SHA256, ChaCha20Poly1305)`,
+				},
+				{
+					name:    `HPKE_4_KE`,
+					value:   "HPKE-4-KE",
+					comment: `HPKE key encryption (X25519, HKDF-SHA256, ChaCha20Poly1305)`,
+				},
+				{
+					name:    `MLKEM768`,
+					value:   "ML-KEM-768",
+					comment: `ML-KEM-768 key encapsulation, used directly as the content encryption key`,
+				},
+				{
+					name:    `MLKEM768_A192KW`,
+					value:   "ML-KEM-768+A192KW",
+					comment: `ML-KEM-768 key encapsulation + AES key wrap (192)`,
+				},
+				{
+					name:    `MLKEM1024`,
+					value:   "ML-KEM-1024",
+					comment: `ML-KEM-1024 key encapsulation, used directly as the content encryption key`,
+				},
+				{
+					name:    `MLKEM1024_A256KW`,
+					value:   "ML-KEM-1024+A256KW",
+					comment: `ML-KEM-1024 key encapsulation + AES key wrap (256)`,
+				},
 			},
 		},
 	}
@@ -347,6 +527,9 @@ type typ struct {
 	comment  string
 	filename string
 	elements []element
+	// registrable is true if users can register their own values
+	// for this type using Register%s()
+	registrable bool
 }
 
 type element struct {
@@ -364,6 +547,7 @@ var isSymmetricKeyEncryption = map[string]struct{}{
 	`A128GCMKW`: {},
 	`A192GCMKW`: {},
 	`A256GCMKW`: {},
+	`XC20PKW`:   {},
 
 	`PBES2_HS256_A128KW`: {},
 	`PBES2_HS384_A192KW`: {},
@@ -400,6 +584,9 @@ func (t typ) Generate() error {
 	}
 	o.L(")") // end const
 
+	if t.registrable {
+		o.LL("var mu%ss sync.RWMutex", t.name)
+	}
 	o.L("var all%[1]ss = map[%[1]s]struct{} {", t.name)
 	for _, e := range t.elements {
 		if !e.invalid {
@@ -408,21 +595,11 @@ func (t typ) Generate() error {
 	}
 	o.L("}")
 
-	o.LL("var list%sOnce sync.Once", t.name)
-	o.L("var list%[1]s []%[1]s", t.name)
-	o.LL("// %[1]ss returns a list of all available values for %[1]s", t.name)
-	o.L("func %[1]ss() []%[1]s {", t.name)
-	o.L("list%sOnce.Do(func() {", t.name)
-	o.L("list%[1]s = make([]%[1]s, 0, len(all%[1]ss))", t.name)
-	o.L("for v := range all%ss {", t.name)
-	o.L("list%[1]s = append(list%[1]s, v)", t.name)
-	o.L("}")
-	o.L("sort.Slice(list%s, func(i, j int) bool {", t.name)
-	o.L("return string(list%[1]s[i]) < string(list%[1]s[j])", t.name)
-	o.L("})")
-	o.L("})")
-	o.L("return list%s", t.name)
-	o.L("}")
+	if t.registrable {
+		t.generateRegistry(o)
+	} else {
+		t.generateList(o)
+	}
 
 	o.LL("// Accept is used when conversion from values given by")
 	o.L("// outside sources (such as JSON payloads) is required")
@@ -443,7 +620,14 @@ func (t typ) Generate() error {
 	o.L("tmp = %s(s)", t.name)
 	o.L("}")
 
-	o.L("if _, ok := all%ss[tmp]; !ok {", t.name)
+	if t.registrable {
+		o.LL("mu%ss.RLock()", t.name)
+		o.L("_, ok := all%ss[tmp]", t.name)
+		o.L("mu%ss.RUnlock()", t.name)
+		o.L("if !ok {")
+	} else {
+		o.L("if _, ok := all%ss[tmp]; !ok {", t.name)
+	}
 	o.L("return fmt.Errorf(`invalid jwa.%s value`)", t.name)
 	o.L("}")
 
@@ -489,6 +673,75 @@ func (t typ) Generate() error {
 	return nil
 }
 
+func (t typ) generateList(o *codegen.Output) {
+	o.LL("var list%sOnce sync.Once", t.name)
+	o.L("var list%[1]s []%[1]s", t.name)
+	o.LL("// %[1]ss returns a list of all available values for %[1]s", t.name)
+	o.L("func %[1]ss() []%[1]s {", t.name)
+	o.L("list%sOnce.Do(func() {", t.name)
+	o.L("list%[1]s = make([]%[1]s, 0, len(all%[1]ss))", t.name)
+	o.L("for v := range all%ss {", t.name)
+	o.L("list%[1]s = append(list%[1]s, v)", t.name)
+	o.L("}")
+	o.L("sort.Slice(list%s, func(i, j int) bool {", t.name)
+	o.L("return string(list%[1]s[i]) < string(list%[1]s[j])", t.name)
+	o.L("})")
+	o.L("})")
+	o.L("return list%s", t.name)
+	o.L("}")
+}
+
+func (t typ) generateRegistry(o *codegen.Output) {
+	o.LL("var list%[1]s []%[1]s", t.name)
+
+	o.LL("func init() {")
+	o.L("mu%ss.Lock()", t.name)
+	o.L("defer mu%ss.Unlock()", t.name)
+	o.L("rebuild%s()", t.name)
+	o.L("}")
+
+	o.LL("// Register%[1]s registers a new %[1]s so that", t.name)
+	o.L("// jwx can properly handle the new value (e.g. in Accept() and %ss()).", t.name)
+	o.L("// Duplicates are silently ignored.")
+	o.L("func Register%[1]s(v %[1]s) {", t.name)
+	o.L("mu%ss.Lock()", t.name)
+	o.L("defer mu%ss.Unlock()", t.name)
+	o.L("if _, ok := all%ss[v]; !ok {", t.name)
+	o.L("all%ss[v] = struct{}{}", t.name)
+	o.L("rebuild%s()", t.name)
+	o.L("}")
+	o.L("}")
+
+	o.LL("// Unregister%[1]s unregisters a %[1]s from the list of", t.name)
+	o.L("// known values. Non-existent entries are silently ignored.")
+	o.L("func Unregister%[1]s(v %[1]s) {", t.name)
+	o.L("mu%ss.Lock()", t.name)
+	o.L("defer mu%ss.Unlock()", t.name)
+	o.L("if _, ok := all%ss[v]; ok {", t.name)
+	o.L("delete(all%ss, v)", t.name)
+	o.L("rebuild%s()", t.name)
+	o.L("}")
+	o.L("}")
+
+	o.LL("func rebuild%s() {", t.name)
+	o.L("list := make([]%[1]s, 0, len(all%[1]ss))", t.name)
+	o.L("for v := range all%ss {", t.name)
+	o.L("list = append(list, v)")
+	o.L("}")
+	o.L("sort.Slice(list, func(i, j int) bool {")
+	o.L("return string(list[i]) < string(list[j])")
+	o.L("})")
+	o.L("list%s = list", t.name)
+	o.L("}")
+
+	o.LL("// %[1]ss returns a list of all available values for %[1]s", t.name)
+	o.L("func %[1]ss() []%[1]s {", t.name)
+	o.L("mu%ss.RLock()", t.name)
+	o.L("defer mu%ss.RUnlock()", t.name)
+	o.L("return list%s", t.name)
+	o.L("}")
+}
+
 func (t typ) GenerateTest() error {
 	var buf bytes.Buffer
 
@@ -614,8 +867,9 @@ func (t typ) GenerateTest() error {
 	o.L("for _, v := range jwa.%ss() {", t.name)
 	if t.name == "EllipticCurveAlgorithm" {
 		o.L("// There is no good way to detect from a test if es256k (secp256k1)")
-		o.L("// is supported, so just allow it")
-		o.L("if v.String() == `secp256k1` {")
+		o.L("// or brainpool curves are supported, so just allow them")
+		o.L("switch v.String() {")
+		o.L("case `secp256k1`, `BP-256`, `BP-384`, `BP-512`:")
 		o.L("continue")
 		o.L("}")
 	}
@@ -631,6 +885,36 @@ func (t typ) GenerateTest() error {
 
 	o.L("}")
 
+	if t.registrable {
+		// This test must not be run in parallel with the tests above,
+		// as it modifies the list of known values
+		o.LL("func Test%sCustomAlgorithm(t *testing.T) {", t.name)
+		o.L("const customAlgorithm = jwa.%s(`custom-algorithm`)", t.name)
+		o.L("var dst jwa.%s", t.name)
+		o.L("if !assert.Error(t, dst.Accept(customAlgorithm), `accept should fail before registration`) {")
+		o.L("return")
+		o.L("}")
+		o.LL("jwa.Register%s(customAlgorithm)", t.name)
+		o.L("defer jwa.Unregister%s(customAlgorithm)", t.name)
+		o.LL("if !assert.NoError(t, dst.Accept(customAlgorithm.String()), `accept should succeed after registration`) {")
+		o.L("return")
+		o.L("}")
+		o.L("if !assert.Equal(t, customAlgorithm, dst, `accepted value should be equal to constant`) {")
+		o.L("return")
+		o.L("}")
+		o.L("if !assert.Contains(t, jwa.%ss(), customAlgorithm, `list should contain the registered value`) {", t.name)
+		o.L("return")
+		o.L("}")
+		o.LL("jwa.Unregister%s(customAlgorithm)", t.name)
+		o.L("if !assert.Error(t, dst.Accept(customAlgorithm), `accept should fail after unregistration`) {")
+		o.L("return")
+		o.L("}")
+		o.L("if !assert.NotContains(t, jwa.%ss(), customAlgorithm, `list should not contain the unregistered value`) {", t.name)
+		o.L("return")
+		o.L("}")
+		o.L("}")
+	}
+
 	filename := strings.Replace(t.filename, "_gen.go", "_gen_test.go", 1)
 	if err := o.WriteFile(filename, codegen.WithFormatCode(true)); err != nil {
 		if cfe, ok := err.(codegen.CodeFormatError); ok {
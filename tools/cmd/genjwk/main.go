@@ -469,7 +469,7 @@ func generateObject(o *codegen.Output, kt *KeyType, obj *codegen.Object) error {
 		} else if f.Type() == "[]byte" {
 			name := f.Name(true)
 			switch f.Name(false) {
-			case "n", "e", "d", "p", "dp", "dq", "x", "y", "q", "qi", "octets":
+			case "n", "e", "d", "p", "dp", "dq", "x", "y", "q", "qi", "octets", "pub", "priv":
 				name = kt.Prefix + f.Name(true)
 			}
 			o.L("case %sKey:", name)